@@ -0,0 +1,394 @@
+// Command import-users loads existing LTO clients from a CSV file.
+//
+//	go run ./cmd/import-users -file users.csv [-report failures.csv]
+//
+// The header row names each column after its JSON field in models.User:
+// last_name, first_name, middle_name, email, password, role, status, region
+// and lto_client_id, plus section.field for the related records, e.g.
+// contact.mobile_number, address.zip_code or medical_information.weight.
+// first_name, last_name, email and password are required; passwords are
+// plain text and hashed here. role defaults to "user", status to "active"
+// and a missing lto_client_id is generated. Users whose email or
+// lto_client_id is already registered are skipped. Rows that fail are written
+// to the report.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/mail"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"smartplate-api/internal/config"
+	"smartplate-api/internal/database"
+	"smartplate-api/internal/models"
+	"smartplate-api/internal/repository"
+)
+
+// batchSize is how many users go into one BulkCreate call
+const batchSize = 500
+
+// idAttempts is how many lto_client_ids a row is given before a clash with
+// an existing client is reported as a failure
+const idAttempts = 3
+
+// importableRoles are the roles a CSV row may carry; superadmins are never imported
+var importableRoles = map[string]bool{
+	models.RoleUser:    true,
+	models.RoleOfficer: true,
+	models.RoleAdmin:   true,
+}
+
+// failure is one line of the report
+type failure struct {
+	line   int // line in the CSV, counting the header as 1
+	email  string
+	reason string
+}
+
+// pending is a valid row waiting to be inserted
+type pending struct {
+	line        int
+	user        *models.User
+	generatedID bool // lto_client_id was made up here, so may be replaced
+}
+
+// bulkCreator is the part of repository.UserRepository the import needs
+type bulkCreator interface {
+	BulkCreate(ctx context.Context, users []*models.User) (succeeded, failed int, errs []repository.BulkCreateError, err error)
+}
+
+func main() {
+	file := flag.String("file", "", "CSV file to import (required)")
+	reportPath := flag.String("report", "import-users-failures.csv", "where to write rows that failed")
+	flag.Parse()
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	cost, err := config.LoadBcryptCost()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rows, failures, err := readUsers(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := hashPasswords(rows, cost); err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := database.Connect()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	imported, batchFailures := importUsers(context.Background(), repository.NewUserRepository(db), rows)
+	failures = append(failures, batchFailures...)
+
+	fmt.Printf("imported %d users, %d failed\n", imported, len(failures))
+	if len(failures) == 0 {
+		return
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].line < failures[j].line })
+	if err := writeReport(*reportPath, failures); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("failures written to %s\n", *reportPath)
+	os.Exit(1)
+}
+
+// importUsers inserts rows batchSize at a time and returns how many went in
+// and which failed
+func importUsers(ctx context.Context, repo bulkCreator, rows []pending) (int, []failure) {
+	imported := 0
+	var failures []failure
+	for start := 0; start < len(rows); start += batchSize {
+		batch := rows[start:min(start+batchSize, len(rows))]
+		n, batchFailures := importBatch(ctx, repo, batch)
+		imported += n
+		failures = append(failures, batchFailures...)
+		log.Printf("imported %d of %d users", imported, len(rows))
+	}
+	return imported, failures
+}
+
+// importBatch inserts one batch. Rows whose generated lto_client_id turned
+// out to be taken get a fresh one and are sent again, up to idAttempts times.
+func importBatch(ctx context.Context, repo bulkCreator, batch []pending) (int, []failure) {
+	imported := 0
+	var failures []failure
+	for attempt := 1; len(batch) > 0; attempt++ {
+		users := make([]*models.User, len(batch))
+		for i, p := range batch {
+			users[i] = p.user
+		}
+		succeeded, _, errs, err := repo.BulkCreate(ctx, users)
+		if err != nil {
+			// the batch was rolled back, so every row in it failed
+			for _, p := range batch {
+				failures = append(failures, failure{p.line, p.user.EMAIL, err.Error()})
+			}
+			log.Printf("batch starting at line %d failed: %v", batch[0].line, err)
+			break
+		}
+		imported += succeeded
+
+		var retry []pending
+		for _, e := range errs {
+			p := batch[e.Index]
+			if e.Reason == repository.ReasonLTOClientIDTaken && p.generatedID && attempt < idAttempts {
+				id, err := newLTOClientID()
+				if err == nil {
+					p.user.LTO_CLIENT_ID = id
+					retry = append(retry, p)
+					continue
+				}
+			}
+			failures = append(failures, failure{p.line, e.Email, e.Reason})
+		}
+		batch = retry
+	}
+	return imported, failures
+}
+
+// readUsers parses and validates the CSV. Invalid rows come back as
+// failures; a malformed file or an unknown column is an error.
+func readUsers(r io.Reader) ([]pending, []failure, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read header: %w", err)
+	}
+	setters := userColumns()
+	cols := make([]func(*models.User, string) error, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if cols[i] = setters[name]; cols[i] == nil {
+			return nil, nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+
+	var rows []pending
+	var failures []failure
+	seen := map[string]int{}
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		u := &models.User{ROLE: models.RoleUser, STATUS: "active"}
+		var problems []string
+		for i, value := range record {
+			if err := cols[i](u, strings.TrimSpace(value)); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", header[i], err))
+			}
+		}
+		problems = append(problems, validate(u)...)
+		if first, ok := seen[strings.ToLower(u.EMAIL)]; ok && u.EMAIL != "" {
+			problems = append(problems, fmt.Sprintf("email repeats line %d", first))
+		}
+		if len(problems) > 0 {
+			failures = append(failures, failure{line, u.EMAIL, strings.Join(problems, "; ")})
+			continue
+		}
+		seen[strings.ToLower(u.EMAIL)] = line
+		p := pending{line: line, user: u}
+		if u.LTO_CLIENT_ID == "" {
+			if u.LTO_CLIENT_ID, err = newLTOClientID(); err != nil {
+				return nil, nil, err
+			}
+			p.generatedID = true
+		}
+		rows = append(rows, p)
+	}
+	return rows, failures, nil
+}
+
+// validate returns what is wrong with u, if anything
+func validate(u *models.User) []string {
+	var problems []string
+	for _, f := range []struct{ name, value string }{
+		{"first_name", u.FIRST_NAME},
+		{"last_name", u.LAST_NAME},
+		{"email", u.EMAIL},
+		{"password", u.PASSWORD},
+	} {
+		if f.value == "" {
+			problems = append(problems, f.name+" is required")
+		}
+	}
+	if u.EMAIL != "" {
+		if addr, err := mail.ParseAddress(u.EMAIL); err != nil || addr.Address != u.EMAIL {
+			problems = append(problems, "email is not a valid address")
+		}
+	}
+	if !importableRoles[u.ROLE] {
+		problems = append(problems, fmt.Sprintf("role %q can't be imported", u.ROLE))
+	}
+	return problems
+}
+
+// userColumns maps each CSV column name to a func that stores a value in the
+// matching models.User field; empty values leave the field unset
+func userColumns() map[string]func(*models.User, string) error {
+	cols := map[string]func(*models.User, string) error{}
+	skip := map[string]bool{"user_id": true, "lto_client_id": true}
+	var walk func(t reflect.Type, prefix string, path []int)
+	walk = func(t reflect.Type, prefix string, path []int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			fieldPath := append(append([]int{}, path...), i)
+			if field.Type.Kind() == reflect.Struct && field.Type.PkgPath() == t.PkgPath() {
+				walk(field.Type, name+".", fieldPath)
+				continue
+			}
+			// ids and the client id of related rows are filled in on insert
+			if prefix != "" && (skip[name] || strings.HasSuffix(name, "_id")) {
+				continue
+			}
+			if set := setterFor(field.Type); set != nil {
+				cols[prefix+name] = func(u *models.User, value string) error {
+					if value == "" {
+						return nil
+					}
+					return set(reflect.ValueOf(u).Elem().FieldByIndex(fieldPath), value)
+				}
+			}
+		}
+	}
+	walk(reflect.TypeOf(models.User{}), "", nil)
+	delete(cols, "user_id")
+	return cols
+}
+
+// setterFor returns how to parse a CSV value into a field of type t, or nil
+// for types a CSV can't carry
+func setterFor(t reflect.Type) func(reflect.Value, string) error {
+	parse := func(kind reflect.Kind, value string) (reflect.Value, error) {
+		switch kind {
+		case reflect.String:
+			return reflect.ValueOf(value), nil
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			return reflect.ValueOf(n), err
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			return reflect.ValueOf(b), err
+		}
+		return reflect.Value{}, fmt.Errorf("unsupported type %s", kind)
+	}
+	switch {
+	case t.Kind() == reflect.String:
+		return func(v reflect.Value, value string) error {
+			v.SetString(value)
+			return nil
+		}
+	case t.Kind() == reflect.Ptr && (t.Elem().Kind() == reflect.String || t.Elem().Kind() == reflect.Int || t.Elem().Kind() == reflect.Bool):
+		return func(v reflect.Value, value string) error {
+			parsed, err := parse(t.Elem().Kind(), value)
+			if err != nil {
+				return err
+			}
+			p := reflect.New(t.Elem())
+			p.Elem().Set(parsed)
+			v.Set(p)
+			return nil
+		}
+	}
+	return nil
+}
+
+// hashPasswords replaces each plain-text password with its bcrypt hash,
+// spreading the work over every CPU since bcrypt is slow on purpose
+func hashPasswords(rows []pending, cost int) error {
+	work := make(chan *models.User)
+	errs := make(chan error, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range work {
+				hash, err := bcrypt.GenerateFromPassword([]byte(u.PASSWORD), cost)
+				if err != nil {
+					select {
+					case errs <- fmt.Errorf("hash password for %s: %w", u.EMAIL, err):
+					default:
+					}
+					continue
+				}
+				u.PASSWORD = string(hash)
+			}
+		}()
+	}
+	for _, p := range rows {
+		work <- p.user
+	}
+	close(work)
+	wg.Wait()
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// newLTOClientID makes a random 15-digit client id in the format the API
+// issues. A clash with an existing client is caught by BulkCreate and the
+// row retried with a new id.
+func newLTOClientID() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1e13))
+	if err != nil {
+		return "", fmt.Errorf("generate lto_client_id: %w", err)
+	}
+	return fmt.Sprintf("25%013d", n), nil
+}
+
+// writeReport saves the failed rows as CSV
+func writeReport(path string, failures []failure) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"line", "email", "reason"})
+	for _, fl := range failures {
+		w.Write([]string{strconv.Itoa(fl.line), fl.email, fl.reason})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
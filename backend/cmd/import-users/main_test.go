@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"smartplate-api/internal/models"
+	"smartplate-api/internal/repository"
+)
+
+// fakeBulk records each BulkCreate call. reject decides whether a user is
+// skipped and why; err fails the call with the given number.
+type fakeBulk struct {
+	calls   [][]string // lto_client_ids of each call
+	reject  func(u *models.User) string
+	err     error
+	errCall int
+}
+
+func (f *fakeBulk) BulkCreate(ctx context.Context, users []*models.User) (succeeded, failed int, errs []repository.BulkCreateError, err error) {
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.LTO_CLIENT_ID
+	}
+	f.calls = append(f.calls, ids)
+	if f.err != nil && len(f.calls) == f.errCall {
+		return 0, 0, nil, f.err
+	}
+	for i, u := range users {
+		if f.reject != nil {
+			if reason := f.reject(u); reason != "" {
+				errs = append(errs, repository.BulkCreateError{Index: i, Email: u.EMAIL, Reason: reason})
+				continue
+			}
+		}
+		succeeded++
+	}
+	return succeeded, len(errs), errs, nil
+}
+
+// rowsOf makes n pending rows starting at CSV line 2
+func rowsOf(n int) []pending {
+	rows := make([]pending, n)
+	for i := range rows {
+		rows[i] = pending{line: i + 2, user: &models.User{
+			EMAIL:         fmt.Sprintf("user%d@example.com", i),
+			LTO_CLIENT_ID: fmt.Sprintf("LTO-%d", i),
+		}}
+	}
+	return rows
+}
+
+func TestImportUsersBatches(t *testing.T) {
+	repo := &fakeBulk{}
+	imported, failures := importUsers(context.Background(), repo, rowsOf(1201))
+	if imported != 1201 || len(failures) != 0 {
+		t.Fatalf("imported %d with failures %+v, want 1201 and none", imported, failures)
+	}
+	var sizes []int
+	for _, c := range repo.calls {
+		sizes = append(sizes, len(c))
+	}
+	if fmt.Sprint(sizes) != "[500 500 201]" {
+		t.Fatalf("batch sizes = %v, want [500 500 201]", sizes)
+	}
+}
+
+func TestImportUsersReportsFailures(t *testing.T) {
+	repo := &fakeBulk{
+		reject: func(u *models.User) string {
+			if u.EMAIL == "user3@example.com" || u.EMAIL == "user503@example.com" {
+				return repository.ReasonEmailTaken
+			}
+			return ""
+		},
+		// the second batch is rolled back as a whole
+		err:     errors.New("connection reset"),
+		errCall: 2,
+	}
+	imported, failures := importUsers(context.Background(), repo, rowsOf(1100))
+
+	if imported != 499+100 {
+		t.Fatalf("imported %d, want 599", imported)
+	}
+	if len(failures) != 1+500 {
+		t.Fatalf("got %d failures, want 501", len(failures))
+	}
+	if f := failures[0]; f.line != 5 || f.email != "user3@example.com" || f.reason != repository.ReasonEmailTaken {
+		t.Fatalf("first failure = %+v, want line 5 email taken", f)
+	}
+	for _, f := range failures[1:] {
+		if f.line < 502 || f.line > 1001 || f.reason != "connection reset" {
+			t.Fatalf("failure %+v is not from the rolled back batch", f)
+		}
+	}
+}
+
+func TestImportUsersRetriesGeneratedIDs(t *testing.T) {
+	rows := rowsOf(3)
+	rows[0].generatedID = true // clashes once
+	rows[1].generatedID = true // clashes every time
+	// rows[2] carries an id from the CSV, so a clash is the file's problem
+	clashed := map[string]bool{}
+	repo := &fakeBulk{reject: func(u *models.User) string {
+		switch {
+		case u.EMAIL == "user0@example.com" && !clashed[u.EMAIL]:
+			clashed[u.EMAIL] = true
+			return repository.ReasonLTOClientIDTaken
+		case u.EMAIL == "user1@example.com", u.EMAIL == "user2@example.com":
+			return repository.ReasonLTOClientIDTaken
+		}
+		return ""
+	}}
+
+	imported, failures := importUsers(context.Background(), repo, rows)
+	if imported != 1 {
+		t.Fatalf("imported %d, want 1", imported)
+	}
+	if len(repo.calls) != idAttempts {
+		t.Fatalf("BulkCreate called %d times, want %d", len(repo.calls), idAttempts)
+	}
+	if retried := repo.calls[1]; len(retried) != 2 || retried[0] == "LTO-0" || retried[1] == "LTO-1" {
+		t.Fatalf("second call sent %v, want rows 0 and 1 with new ids", retried)
+	}
+	if len(failures) != 2 || failures[0].line != 4 || failures[1].line != 3 {
+		t.Fatalf("failures = %+v, want lines 4 and 3", failures)
+	}
+}
+
+func TestNewLTOClientID(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		id, err := newLTOClientID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(id) != 15 || !strings.HasPrefix(id, "25") || strings.Trim(id, "0123456789") != "" {
+			t.Fatalf("id %q is not 15 digits starting with 25", id)
+		}
+		if seen[id] {
+			t.Fatalf("id %q generated twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestReadUsers(t *testing.T) {
+	csv := `first_name,last_name,email,password,role,contact.mobile_number,lto_client_id
+Juan,Dela Cruz,juan@example.com,secret1,,+639171234567,
+Maria,,maria@example.com,secret2,,,
+Jose,Reyes,not-an-email,secret3,,,
+Ana,Santos,JUAN@example.com,secret4,,,
+Ben,Cruz,ben@example.com,secret5,superadmin,,
+Lia,Tan,lia@example.com,secret6,LTO Officer,,LTO-2024-000045
+`
+	rows, failures, err := readUsers(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("got %d valid rows, want 2", len(rows))
+	}
+	juan := rows[0]
+	if juan.line != 2 || juan.user.ROLE != models.RoleUser || !juan.generatedID || juan.user.LTO_CLIENT_ID == "" {
+		t.Fatalf("juan = %+v, want line 2, role user and a generated id", juan)
+	}
+	if m := juan.user.Contact.MOBILE_NUMBER; m == nil || *m != "+639171234567" {
+		t.Fatalf("mobile number = %v", m)
+	}
+	if lia := rows[1]; lia.generatedID || lia.user.LTO_CLIENT_ID != "LTO-2024-000045" || lia.user.ROLE != models.RoleOfficer {
+		t.Fatalf("lia = %+v, want her own id and the officer role", lia.user)
+	}
+
+	want := map[int]string{
+		3: "last_name is required",
+		4: "email is not a valid address",
+		5: "email repeats line 2",
+		6: `role "superadmin" can't be imported`,
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("failures = %+v, want lines 3 to 6", failures)
+	}
+	for _, f := range failures {
+		if !strings.Contains(f.reason, want[f.line]) {
+			t.Errorf("line %d: reason %q, want it to mention %q", f.line, f.reason, want[f.line])
+		}
+	}
+}
+
+func TestReadUsersUnknownColumn(t *testing.T) {
+	if _, _, err := readUsers(strings.NewReader("first_name,shoe_size\nJuan,42\n")); err == nil {
+		t.Fatal("unknown column accepted")
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "failures.csv")
+	err := writeReport(path, []failure{
+		{3, "maria@example.com", "last_name is required"},
+		{5, "juan@example.com", "email repeats line 2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "line,email,reason\n3,maria@example.com,last_name is required\n5,juan@example.com,email repeats line 2\n"
+	if string(got) != want {
+		t.Fatalf("report = %q, want %q", got, want)
+	}
+}
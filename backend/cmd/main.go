@@ -1,41 +1,103 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"smartplate-api/docs"
+	"smartplate-api/internal/config"
 	"smartplate-api/internal/database"
+	"smartplate-api/internal/email"
+	"smartplate-api/internal/geoip"
 	"smartplate-api/internal/handlers"
+	"smartplate-api/internal/insurance"
+	"smartplate-api/internal/jobs"
+	mw "smartplate-api/internal/middleware"
+	"smartplate-api/internal/models"
 	"smartplate-api/internal/plate"
 	"smartplate-api/internal/repository"
+	"smartplate-api/internal/sms"
+	"smartplate-api/internal/webhook"
 	"smartplate-api/internal/ws"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
-	"github.com/rs/zerolog"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
+// @title SmartPlate API
+// @version 1
+// @description Vehicle registration, plate issuance and roadside scanning for the LTO.
+// @BasePath /v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+
+// version is the git sha, set at build time with
+// -ldflags "-X main.version=$(git rev-parse --short HEAD)"
+var version = "dev"
+
 func main() {
 	e := echo.New()
+	ipExtractor, err := config.LoadIPExtractor()
+	if err != nil {
+		log.Fatalf("Invalid proxy configuration: %v", err)
+	}
+	e.IPExtractor = ipExtractor
 	// Initialize database connection
-	db, err := database.Connect()
+	rw, err := database.ConnectReadWrite()
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer db.Close()
+	defer rw.Close()
+	db := rw.Write
+
+	if os.Getenv("RUN_MIGRATIONS") == "true" {
+		if err := database.MigrateUp(); err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
+	}
 
 
 	// Middleware
-	e.Use(middleware.Logger())
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+	e.Use(mw.RequestIDMiddleware())
+	e.Use(mw.StructuredLoggerMiddleware(logger))
+
+	poolCtx, stopPoolMonitor := context.WithCancel(context.Background())
+	defer stopPoolMonitor()
+	go database.MonitorPool(poolCtx, db, logger)
+	e.Use(mw.MetricsMiddleware())
+	e.Use(mw.BodyLimit(mw.BodyLimitConfig{
+		Default: 1 << 20, // 1 MB
+		Routes: map[string]int64{
+			"/v1/api/vehicles/plates/bulk": 10 << 20,
+			"/v1/api/scan-logs/bulk":       2 << 20,
+		},
+	}))
+	e.Use(mw.MaxParamLength(128))
 	e.Use(middleware.Recover())
 	
-	// Enhanced CORS configuration
+	// Enhanced CORS configuration, shared with the websocket origin check
+	corsCfg, err := config.LoadCORSConfig()
+	if err != nil {
+		log.Fatalf("CORS config: %v", err)
+	}
+	e.Use(mw.OriginGuard(corsCfg))
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:5174"},
+		AllowOrigins:     corsCfg.AllowedOrigins,
 		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, echo.HeaderXRequestID},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", echo.HeaderXRequestID},
 		AllowCredentials: true,
 		MaxAge:           3600,
 	}))
@@ -47,6 +109,30 @@ func main() {
 		HSTSMaxAge:            31536000,
 		ContentSecurityPolicy: "default-src 'self'",
 	}))
+	// API versions; clients either use the /vN prefix or send Accept-Version
+	e.Pre(mw.VersionNegotiation("1"))
+	e.Use(mw.APIVersionHeader())
+	v1 := e.Group("/v1")
+
+	// Route groups by required role; handlers read the caller from the context
+	// requests made while impersonating a user are all written to the audit trail
+	auditRepo := repository.NewAuditLogRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	authGroup := v1.Group("")
+	userGroup := v1.Group("", mw.RequireRole(), mw.RejectRevoked(sessionRepo), mw.ImpersonationAudit(auditRepo))
+	officerGroup := v1.Group("", mw.RequireRole(models.RoleOfficer, models.RoleAdmin, models.RoleSuperAdmin), mw.RejectRevoked(sessionRepo), mw.ImpersonationAudit(auditRepo))
+	adminGroup := v1.Group("", mw.RequireRole(models.RoleAdmin, models.RoleSuperAdmin), mw.RejectRevoked(sessionRepo), mw.ImpersonationAudit(auditRepo))
+
+	// probes stay outside the auth groups
+	health := handlers.NewHealthHandler(db, version)
+	e.GET("/health", health.Health)
+	e.GET("/ready", health.Ready)
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()), mw.InternalOnly())
+	e.GET("/swagger/openapi.json", func(c echo.Context) error {
+		return c.JSONBlob(http.StatusOK, docs.OpenAPI)
+	})
+	e.GET("/swagger/*", echoSwagger.EchoWrapHandler(echoSwagger.URL("/swagger/openapi.json")))
+
 	// Vehicle routes
 	e.GET("/", func(c echo.Context) error {
 		return c.String(http.StatusOK, "Server is running")
@@ -54,65 +140,234 @@ func main() {
 
 	// Initialize repositories and handlers
 	userRepo := repository.NewUserRepository(db)
+	plateRepo := repository.NewPlateRepository(rw)
+	plateCacheSize, err := config.LoadPlateCacheSize()
+	if err != nil {
+		log.Fatalf("plate cache config: %v", err)
+	}
+	var plateCache *repository.CachingPlateRepository
+	if plateCacheSize > 0 {
+		plateCache = repository.NewCachingPlateRepository(plateRepo, plateCacheSize)
+		// deleting a user deactivates their plates, which must not stay cached
+		plateRepo, userRepo = plateCache, plateCache.ForgetUserPlates(userRepo)
+	}
 	userHandler := handlers.NewUserHandler(userRepo)
 
-	e.POST("/users", userHandler.CreateUser)//working
-	e.GET("/users", userHandler.GetAllUsers)//working
-	e.GET("/users/:id", userHandler.GetUserByID)//working
-	e.GET("/users/email/:email", userHandler.GetUserByEmail)//working
-	e.PUT("/users/:id", userHandler.UpdateUser)	//working
-	e.DELETE("/users/:id", userHandler.DeleteUser)//working
+	resetTokenRepo := repository.NewPasswordResetTokenRepository(db)
+	jwtCfg, err := config.LoadJWTConfig()
+	if err != nil {
+		log.Fatalf("jwt config: %v", err)
+	}
+	bcryptCost, err := config.LoadBcryptCost()
+	if err != nil {
+		log.Fatalf("bcrypt config: %v", err)
+	}
+	handlers.SetPasswordCost(bcryptCost)
+	passwordPolicy, err := config.LoadPasswordPolicy()
+	if err != nil {
+		log.Fatalf("password policy config: %v", err)
+	}
+	handlers.SetPasswordPolicy(passwordPolicy)
+	authHandler := handlers.NewAuthHandler(userRepo, resetTokenRepo, sessionRepo, repository.NewLoginAuditRepository(db), jwtCfg)
+	geo, err := geoip.FromEnv()
+	if err != nil {
+		log.Fatalf("geoip config: %v", err)
+	}
+	if geo != nil {
+		defer geo.Close()
+		authHandler.SetGeoLocator(geo)
+	}
+	// each reset request looks up the email domain's MX records, so keep
+	// strangers from using it to fire off DNS queries
+	authGroup.POST("/auth/password-reset", authHandler.RequestPasswordReset, mw.PerIPRateLimit(5))
+	authGroup.POST("/auth/password-reset/confirm", authHandler.ResetPassword)
+	authGroup.POST("/api/auth/login", authHandler.Login)
+	authGroup.POST("/api/auth/admin/login", authHandler.AdminLogin)
+	authGroup.GET("/api/auth/google", authHandler.GoogleLogin)
+	authGroup.GET("/api/auth/google/callback", authHandler.GoogleCallback)
+	userGroup.PUT("/api/users/me/password", authHandler.ChangePassword)
+	userGroup.GET("/api/auth/me", authHandler.GetCurrentUser)
+	adminGroup.POST("/api/admin/users/:id/impersonate", authHandler.Impersonate, mw.Audit(auditRepo, "user", "id", nil))
+	userGroup.POST("/api/auth/end-impersonation", authHandler.EndImpersonation)
+	userGroup.GET("/api/auth/sessions", authHandler.ListSessions)
+	userGroup.DELETE("/api/auth/sessions/:session_id", authHandler.RevokeSession)
+	userGroup.POST("/api/auth/logout", authHandler.Logout)
+	adminGroup.GET("/api/admin/users/:id/login-history", authHandler.LoginHistory)
+	adminGroup.DELETE("/api/admin/users/:id/sessions", authHandler.RevokeUserSessions, mw.Audit(auditRepo, "user", "id", nil))
+	resetTokenHandler := handlers.NewPasswordResetTokenHandler(resetTokenRepo)
+	adminGroup.GET("/api/admin/password-reset-tokens", resetTokenHandler.List)
+	adminGroup.DELETE("/api/admin/password-reset-tokens/:id", resetTokenHandler.Revoke)
+
+	authGroup.POST("/users", userHandler.CreateUser)//working
+	adminGroup.GET("/users", userHandler.GetAllUsers)//working
+	userGroup.PUT("/api/users/me", userHandler.UpdateMe)
+	userGroup.GET("/users/:id", userHandler.GetUserByID)//working
+	userGroup.GET("/users/email/:email", userHandler.GetUserByEmail)//working
+	userGroup.PUT("/users/:id", userHandler.UpdateUser)	//working
+	adminGroup.DELETE("/users/:id", userHandler.DeleteUser)//working
 
 	//for getting user by lto client id
-	e.GET("/users/lto/:lto_client_id", userHandler.GetUserByLTOID)//working
-	e.PUT("/users/by-lto/:lto_client_id", userHandler.UpdateUserByLTO)//working
-	e.DELETE("/users/by-lto/:lto_client_id", userHandler.DeleteUserByLTO)//working
+	userGroup.GET("/users/lto/:lto_client_id", userHandler.GetUserByLTOID)//working
+	userGroup.PUT("/users/by-lto/:lto_client_id", userHandler.UpdateUserByLTO)//working
+	adminGroup.DELETE("/users/by-lto/:lto_client_id", userHandler.DeleteUserByLTO)//working
+	adminGroup.GET("/api/admin/users/inactive", userHandler.GetInactiveUsers)
+	adminGroup.DELETE("/api/admin/users/:id", userHandler.AdminDeleteUser)
+	adminGroup.PUT("/api/admin/users/:id/restore", userHandler.RestoreUser)
 	//for generating lto client id
 	// e.GET("/generate-lto-id", userHandler.GenerateLTOID)  
 
+	// outgoing email goes through the queue so SMTP outages are retried
+	emailQueueRepo := repository.NewEmailQueueRepository(db)
+	email.UseQueue(emailQueueRepo)
+	// background workers stop with workerCtx; shutdown waits for them to
+	// return before the database is closed
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+	runWorker := func(run func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+	runWorker(email.NewEmailWorker(emailQueueRepo, logger).Run)
+	runWorker(jobs.NewInactivityMailer(userRepo, logger).Run)
+	adminGroup.GET("/api/admin/email-queue", handlers.NewEmailQueueHandler(emailQueueRepo).List)
+
+	// audit trail for admin and officer actions
+	adminGroup.GET("/api/admin/audit-logs", handlers.NewAuditLogHandler(auditRepo).List)
+
 	//for Vehicle routes
-	vh := handlers.NewVehicleHandler(repository.NewVehicleRepository(db))
+	vRepo := repository.NewVehicleRepository(db)
+	if plateCache != nil {
+		vRepo = plateCache.ForgetVehiclePlates(vRepo)
+	}
+	vh := handlers.NewVehicleHandler(vRepo, plateRepo)
+
+	loadVehicle := func(ctx context.Context, id string) (interface{}, error) {
+		return vRepo.GetVehicleByID(ctx, id)
+	}
+	loadPlate := func(ctx context.Context, id string) (interface{}, error) {
+		return plateRepo.GetPlateByPlateID(ctx, id)
+	}
+	loadVehiclePlates := func(ctx context.Context, id string) (interface{}, error) {
+		return plateRepo.GetPlatesByVehicleID(ctx, id)
+	}
+
+	userGroup.POST   ("/api/vehicles",       vh.CreateVehicle)//working
+	userGroup.GET    ("/api/vehicles",       vh.ListVehicles)
+	officerGroup.GET ("/api/vehicles/search", vh.SearchVehicles)
 
-	e.POST   ("/api/vehicles",       vh.CreateVehicle)//working
-	e.GET    ("/api/vehicles",       vh.GetAllVehicles)//working
+	userGroup.GET    ("/api/vehicles/:id",   vh.GetVehicleByID)//working
+	userGroup.PUT    ("/api/vehicles/:id",   vh.UpdateVehicle) //working
+	userGroup.DELETE ("/api/vehicles/:id",   vh.DeleteVehicle, mw.Audit(auditRepo, "vehicle", "id", loadVehicle))//working
 
-	e.GET    ("/api/vehicles/:id",   vh.GetVehicle)//working
-	e.PUT    ("/api/vehicles/:id",   vh.UpdateVehicle) //working
-	e.DELETE ("/api/vehicles/:id",   vh.DeleteVehicle)//working
+	userGroup.GET    ("/api/vehicles/lto/:lto_client_id", vh.GetByClientID)//working
+	userGroup.PUT    ("/api/vehicles/lto/:lto_client_id", vh.UpdateByClientID)//working
+	userGroup.DELETE ("/api/vehicles/lto/:lto_client_id", vh.DeleteByClientID)//working
+	userGroup.GET    ("/api/vehicles/owner/:lto_client_id", vh.GetVehiclesByOwner)
+	adminGroup.GET   ("/api/admin/vehicles/deleted", vh.GetDeletedVehicles)
+	adminGroup.PUT   ("/api/admin/vehicles/:id/restore", vh.RestoreVehicle, mw.Audit(auditRepo, "vehicle", "id", loadVehicle))
 
-	e.GET    ("/api/vehicles/lto/:lto_client_id", vh.GetByClientID)//working
-	e.PUT    ("/api/vehicles/lto/:lto_client_id", vh.UpdateByClientID)//working
-	e.DELETE ("/api/vehicles/lto/:lto_client_id", vh.DeleteByClientID)//working
+	// vehicle inspections
+	inspectionRepo := repository.NewVehicleInspectionRepository(db)
+	vih := handlers.NewVehicleInspectionHandler(inspectionRepo, vRepo)
+	officerGroup.POST("/api/vehicles/:id/inspections", vih.Create)
+	userGroup.GET    ("/api/vehicles/:id/inspections", vih.GetByVehicle)
+	adminGroup.GET("/api/admin/inspections/overdue", vih.Overdue)
+
+	// vehicle documents, stored in S3
+	vdh := handlers.NewVehicleDocumentHandler(repository.NewVehicleDocumentRepository(db), vRepo)
+	userGroup.POST   ("/api/vehicles/:id/documents", vdh.Upload)
+	userGroup.GET    ("/api/vehicles/:id/documents", vdh.List)
+	userGroup.POST   ("/api/vehicles/:id/documents/confirm", vdh.Confirm)
+
+	// notification channel opt-outs, honoured by expiry reminders and scan alerts
+	notifPrefsRepo := repository.NewNotificationPreferencesRepository(db)
+	nph := handlers.NewNotificationPreferencesHandler(notifPrefsRepo)
+	userGroup.GET("/api/users/me/notification-preferences", nph.GetMine)
+	userGroup.PUT("/api/users/me/notification-preferences", nph.UpdateMine)
 
 	//for plates routes
-	// plateRepo    := repository.NewPlateRepository(db)
-	plateRepo := repository.NewPlateRepository(db)
-	plateHandler := handlers.NewPlateHandler(plateRepo)
+	plateHandler := handlers.NewPlateHandler(
+		plateRepo,
+		vRepo,
+		userRepo,
+		repository.NewPlateNotificationLogRepository(db),
+		notifPrefsRepo,
+		jwtCfg.Secret,
+	)
+	// text messages go through Twilio when configured, otherwise only to the log
+	smsSender := sms.FromEnv()
+	plateHandler.SetSMSSender(smsSender)
 	
-	p := e.Group("/api/vehicles/:vehicle_id/plates")
-	p.POST   ("",               plateHandler.CreatePlate)//working
-	p.GET    ("",               plateHandler.GetPlates)//working
-	p.GET    ("/:plate_id",   plateHandler.GetPlateByID)//working
-	p.PUT	 ("/:plate_id",   plateHandler.UpdatePlate)//working
-	p.DELETE("/:plate_id",    plateHandler.DeletePlateByID)//working
+	officerGroup.POST("/api/vehicles/plates/bulk", plateHandler.BulkCreatePlates)
+	officerGroup.GET("/api/plates/search", plateHandler.SearchPlates)
+	userGroup.POST("/api/plates/validate", plateHandler.ValidatePlateNumber)
+	userGroup.GET("/api/users/:lto_client_id/plates", plateHandler.GetByOwner)
+	authGroup.GET("/api/plates/:plate_number", plateHandler.GetPlateByNumber, mw.MaskPII(sessionRepo))
+	officerGroup.PUT("/api/vehicles/:vehicle_id/plates/status", plateHandler.UpdateVehiclePlatesStatus, mw.Audit(auditRepo, "vehicle", "vehicle_id", loadVehiclePlates))
+	officerGroup.POST("/api/plates/:plate_id/transfer", plateHandler.TransferPlate, mw.Audit(auditRepo, "plate", "plate_id", loadPlate))
+	officerGroup.PUT("/api/vehicles/:vehicle_id/plates/:plate_id/deactivate", plateHandler.DeactivatePlate, mw.Audit(auditRepo, "plate", "plate_id", loadPlate))
+	adminGroup.GET("/api/admin/plates", plateHandler.ListPlates)
+	adminGroup.GET("/api/admin/plates/expiring", plateHandler.GetExpiringSoon)
+	adminGroup.GET("/api/admin/plates/expired", plateHandler.GetExpired)
+	adminGroup.GET("/api/admin/plates/stats", plateHandler.GetPlateStats)
+
+	// generated four-wheel plates take their sequences from the per-region pools
+	platePoolRepo := repository.NewPlatePoolRepository(db)
+	plate.DefaultGenerator.SetPool(platePoolRepo)
+	adminGroup.GET("/api/admin/plate-pools", handlers.NewPlatePoolHandler(platePoolRepo).Usage)
+
+	// signed, PII-free plate checks for insurers and other integrators
+	verifyLimiter := mw.PerIPRateLimit(100)
+	authGroup.GET("/api/verify/plate", handlers.NewPlateVerifyHandler(plateRepo).Verify, verifyLimiter)
+
+	// plates are issued by officers; owners can only look at and renew theirs
+	officerGroup.POST("/api/vehicles/:vehicle_id/plates", plateHandler.CreatePlate)
+	p := userGroup.Group("/api/vehicles/:vehicle_id/plates")
+	p.GET    ("",               plateHandler.GetPlates, mw.RegionScope())//working
+	p.GET    ("/:plate_id",   plateHandler.GetPlateByID, mw.RegionScope())//working
+	p.GET    ("/:plate_id/qr",  plateHandler.GenerateQR)
+	p.PUT    ("/:plate_id/renew", plateHandler.RenewPlate, mw.Audit(auditRepo, "plate", "plate_id", loadPlate))
+	p.GET    ("/:plate_id/history", plateHandler.GetPlateHistory)
+	// owners renew their own plates; any other change to a plate is an officer's
+	officerGroup.PUT   ("/api/vehicles/:vehicle_id/plates/:plate_id", plateHandler.UpdatePlate, mw.Audit(auditRepo, "plate", "plate_id", loadPlate))
+	officerGroup.DELETE("/api/vehicles/:vehicle_id/plates/:plate_id", plateHandler.DeletePlateByID, mw.Audit(auditRepo, "plate", "plate_id", loadPlate))
 
 	//registration routes
 	rfRepo := repository.NewRegistrationFormRepository(db)
 	riRepo := repository.NewRegistrationInspectionRepository(db)
 	rpRepo := repository.NewRegistrationPaymentRepository(db)
 	rdRepo := repository.NewRegistrationDocumentRepository(db)
-	vRepo := repository.NewVehicleRepository(db)
-	
-	rh := handlers.NewRegistrationHandler(rfRepo, riRepo, rpRepo, rdRepo, vRepo)
-	g := e.Group("/api/registration-form")
+
+	rh := handlers.NewRegistrationHandler(rfRepo, riRepo, rpRepo, rdRepo, vRepo, repository.NewTransactor(db))
+	g := userGroup.Group("/api/registration-form", mw.RegionScope())
 	g.POST("", rh.CreateForm)//working
 	g.GET("", rh.GetAllForms)//working
-	g.GET("/:id", rh.GetFormByID)//working
-	g.PUT("/:id", rh.UpdateForm)//working
-	g.DELETE("/:id", rh.DeleteForm)//working
-	g.GET("/:id/full", rh.GetFull)
+	g.GET("/:id", rh.GetFormByID, rh.OwnForm)//working
+	g.PUT("/:id", rh.UpdateForm, rh.OwnForm)//working
+	g.DELETE("/:id", rh.DeleteForm, rh.OwnForm)//working
+	g.GET("/:id/full", rh.GetFull, rh.OwnForm)
+
+	// registration approval workflow
+	scanLogRepo := repository.NewScanLogRepository(rw)
+	rfh := handlers.NewRegistrationFormHandler(rfRepo, plateRepo, vRepo, userRepo, scanLogRepo, repository.NewFormStateHistoryRepository(db), repository.NewTransactor(db))
+	userGroup.POST("/api/registrations", rfh.Submit)
+	userGroup.GET ("/api/registrations", rfh.List, mw.RegionScope())
+	officerGroup.GET("/api/registrations/search", rfh.Search, mw.RegionScope())
+	userGroup.GET ("/api/registrations/:id", rfh.GetByID, mw.RegionScope())
+	officerGroup.GET("/api/registrations/:id/scan-logs", rfh.ScanLogs, mw.RegionScope())
+	loadForm := func(ctx context.Context, id string) (interface{}, error) {
+		return rfRepo.GetByID(ctx, id)
+	}
+	officerGroup.GET("/api/registrations/:id/history", rfh.History, mw.RegionScope())
+	officerGroup.PUT("/api/registrations/:id/review", rfh.StartReview, mw.Audit(auditRepo, "registration_form", "id", loadForm))
+	officerGroup.PUT("/api/registrations/:id/approve", rfh.Approve, mw.Audit(auditRepo, "registration_form", "id", loadForm))
+	officerGroup.PUT("/api/registrations/:id/reject", rfh.Reject, mw.Audit(auditRepo, "registration_form", "id", loadForm))
 	
-	e.GET("/api/generate-plate/:vehicle_type", func(c echo.Context) error {
+	userGroup.GET("/api/generate-plate/:vehicle_type", func(c echo.Context) error {
 		vt := c.Param("vehicle_type")
 		if vt == "" {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "vehicleType is required"})
@@ -125,62 +380,126 @@ func main() {
 		if reg == "" {
 			reg = "NCR"
 	}
-		plate := plate.GeneratePlateNumber(vt, pt, reg)
+		plate, err := plate.DefaultGenerator.Generate(c.Request().Context(), vt, pt, reg, plateRepo)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusOK, map[string]string{"plate": plate})
 	})
 
 	// inspection
-	g.POST("/:id/inspection", rh.CreateInspection)//working
-	g.GET("/:id/inspection", rh.GetInspections)//working
-	g.GET("/:id/inspection/:inspId", rh.GetInspection)//working
-	g.PUT("/:id/inspection/:inspId", rh.UpdateInspection)//working
-	g.DELETE("/:id/inspection/:inspId", rh.DeleteInspection)//working
+	g.POST("/:id/inspection", rh.CreateInspection, rh.OwnForm)//working
+	g.GET("/:id/inspection", rh.GetInspections, rh.OwnForm)//working
+	g.GET("/:id/inspection/:inspId", rh.GetInspection, rh.OwnForm)//working
+	g.PUT("/:id/inspection/:inspId", rh.UpdateInspection, rh.OwnForm)//working
+	g.DELETE("/:id/inspection/:inspId", rh.DeleteInspection, rh.OwnForm)//working
 
 	// payment
-	g.POST("/:id/payment", rh.CreatePayment)//working
-	g.GET("/:id/payment", rh.GetPayments)//working
-	g.GET("/:id/payment/:payId", rh.GetPayment)//working
-	g.PUT("/:id/payment/:payId", rh.UpdatePayment)//working
-	g.DELETE("/:id/payment/:payId", rh.DeletePayment)//woriking
+	g.POST("/:id/payment", rh.CreatePayment, rh.OwnForm)//working
+	g.GET("/:id/payment", rh.GetPayments, rh.OwnForm)//working
+	g.GET("/:id/payment/:payId", rh.GetPayment, rh.OwnForm)//working
+	g.PUT("/:id/payment/:payId", rh.UpdatePayment, rh.OwnForm)//working
+	g.DELETE("/:id/payment/:payId", rh.DeletePayment, rh.OwnForm)//woriking
 
 	// document
-	g.POST("/:id/document", rh.CreateDocument)//working
-	g.GET("/:id/document", rh.GetDocuments)//working
-	g.GET("/:id/document/:docId", rh.GetDocument)//working
-	g.PUT("/:id/document/:docId", rh.UpdateDocument)//working
-	g.DELETE("/:id/document/:docId", rh.DeleteDocument)//working
+	g.POST("/:id/document", rh.CreateDocument, rh.OwnForm)//working
+	g.GET("/:id/document", rh.GetDocuments, rh.OwnForm)//working
+	g.GET("/:id/document/:docId", rh.GetDocument, rh.OwnForm)//working
+	g.PUT("/:id/document/:docId", rh.UpdateDocument, rh.OwnForm)//working
+	g.DELETE("/:id/document/:docId", rh.DeleteDocument, rh.OwnForm)//working
 
 	//websocket
-	scanLogRepo := repository.NewScanLogRepository(db)
-	ws.SetScanLogRepository(scanLogRepo)
-	e.GET("/ws/scan", ws.ScannerWS(plateRepo, rfRepo, userRepo))
+	wsCfg := ws.ConfigFromEnv()
+	wsCfg.AllowOrigin = corsCfg.Allowed
+	ws.SetConfig(wsCfg)
+	// logged scans are pushed to external enforcement systems' webhooks
+	webhookRepo := repository.NewWebhookRepository(db)
+	webhookDispatcher := webhook.NewDispatcher(workerCtx, webhookRepo)
+	ws.SetWebhookDispatcher(webhookDispatcher)
+	whh := handlers.NewWebhookHandler(webhookRepo)
+	adminGroup.GET(   "/api/admin/webhooks",     whh.List)
+	adminGroup.POST(  "/api/admin/webhooks",     whh.Create, mw.Audit(auditRepo, "webhook", "id", nil))
+	adminGroup.GET(   "/api/admin/webhooks/:id", whh.Get)
+	adminGroup.PUT(   "/api/admin/webhooks/:id", whh.Update, mw.Audit(auditRepo, "webhook", "id", nil))
+	adminGroup.DELETE("/api/admin/webhooks/:id", whh.Delete, mw.Audit(auditRepo, "webhook", "id", nil))
+	// ws connections are hijacked, so Shutdown doesn't see them; cancel them ourselves
+	wsCtx, cancelWS := context.WithCancel(context.Background())
+	var wsWG sync.WaitGroup
+	e.Server.RegisterOnShutdown(cancelWS)
+	scanHub := ws.NewHub()
+	authGroup.GET("/ws/scanner", ws.ScannerWS(wsCtx, &wsWG, plateRepo, vRepo, rfRepo, userRepo, scanLogRepo, inspectionRepo, insurance.FromEnv(), scanHub, sessionRepo, jwtCfg.Secret))
+	officerGroup.GET("/api/admin/scan-logs/stream", ws.ScanLogStream(wsCtx, scanHub), mw.RegionScope())
 
 // scan-log endpoints
-	scanLogHandler   := handlers.NewScanLogHandler(scanLogRepo)
-	e.POST("/api/scan-log", scanLogHandler.Create)
-	e.GET( "/api/scan-log", scanLogHandler.GetAll)
-	e.GET( "/api/scan-log/:id", scanLogHandler.GetByID)
+	appLoc, err := config.LoadLocation()
+	if err != nil {
+		log.Fatalf("timezone config: %v", err)
+	}
+	scanLogHandler   := handlers.NewScanLogHandler(scanLogRepo, appLoc)
+	officerGroup.POST("/api/scan-log", scanLogHandler.Create)
+	officerGroup.POST("/api/scan-logs/bulk", scanLogHandler.BulkCreate)
+	officerGroup.GET( "/api/scan-log", scanLogHandler.GetAll, mw.RegionScope())
+	officerGroup.GET( "/api/scan-log/:id", scanLogHandler.GetByID, mw.RegionScope())
+	scanAlertHandler := handlers.NewScanAlertHandler(scanLogRepo, plateRepo, userRepo, notifPrefsRepo, smsSender)
+	officerGroup.POST("/api/scan-log/:id/suspicious", scanAlertHandler.ReportSuspicious, mw.RegionScope())
+	adminGroup.GET("/api/admin/scan-log/export", scanLogHandler.ExportCSV)
+	adminGroup.GET("/api/admin/analytics/hourly-breakdown", scanLogHandler.HourlyBreakdown)
+	adminGroup.GET("/api/admin/analytics/officer-scan-counts", scanLogHandler.GetScanCountPerOfficer)
+	adminGroup.GET("/api/admin/analytics/top-scanner-ips", scanLogHandler.TopScannerIPs)
+	analyticsHandler := handlers.NewAnalyticsHandler(rfRepo, plateRepo, scanLogRepo, appLoc)
+	adminGroup.GET("/api/admin/dashboard", analyticsHandler.Dashboard, mw.RegionScope())
+	adminGroup.GET("/api/admin/scan-logs/map", scanLogHandler.Map)
+	adminGroup.GET("/api/admin/scan-logs/by-ip/:ip", scanLogHandler.GetByIPAddress)
+	adminGroup.GET("/api/admin/scan-logs/report.pdf", scanLogHandler.ReportPDF)
+	runWorker(func(ctx context.Context) {
+		jobs.StartCleanupJobs(ctx, resetTokenRepo, scanLogRepo, plateRepo, time.Hour)
+	})
+
+	// admin user management; :id is the LTO client id
+	uah := handlers.NewUserAdminHandler(userRepo, scanLogRepo)
+	loadUserAccess := func(ctx context.Context, id string) (interface{}, error) {
+		u, err := userRepo.GetByLTOClientID(id)
+		if err != nil {
+			return nil, err
+		}
+		region := ""
+		if u.REGION != nil {
+			region = *u.REGION
+		}
+		return map[string]string{"role": u.ROLE, "region": region}, nil
+	}
+	adminGroup.GET("/api/admin/users", uah.List)
+	adminGroup.GET("/api/admin/users/:id", uah.Get)
+	adminGroup.PUT("/api/admin/users/:id/role", uah.UpdateRole, mw.Audit(auditRepo, "user", "id", loadUserAccess))
+	adminGroup.GET("/api/admin/users/:id/scan-history", uah.ScanHistory)
+	adminGroup.PUT("/api/admin/users/:id/region", uah.UpdateRegion, mw.Audit(auditRepo, "user", "id", loadUserAccess))
 
 	// // Start server
-	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
-e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
-    LogStatus: true,
-    LogURI:    true,
-    LogMethod: true,
-    LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
-        logger.Info().
-            Str("URI", v.URI).
-            Str("method", v.Method).
-            Int("status", v.Status).
-            Msg("request")
-        return nil
-    },
-}))
 fmt.Println("Registered routes:")
 for _, route := range e.Routes() {
     fmt.Printf("%-6s %s\n", route.Method, route.Path)
 }
 // Then start the server
-e.Logger.Fatal(e.Start(":8081"))
+go func() {
+    if err := e.Start(":8081"); err != nil && err != http.ErrServerClosed {
+        e.Logger.Fatal(err)
+    }
+}()
+
+// Wait for SIGINT/SIGTERM, then drain HTTP and WS connections and the
+// background workers; the deferred rw.Close runs only once they're all done
+sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+defer stop()
+<-sigCtx.Done()
+stopWorkers()
+
+shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+defer cancel()
+if err := e.Shutdown(shutdownCtx); err != nil {
+    e.Logger.Error(err)
+}
+wsWG.Wait()
+workers.Wait()
+webhookDispatcher.Wait()
 }
 
@@ -0,0 +1,76 @@
+// Command migrate manages the database schema.
+//
+//	go run ./cmd/migrate up        apply all pending migrations
+//	go run ./cmd/migrate down [N]  roll back N migrations (default 1)
+//	go run ./cmd/migrate version   print the current version
+//	go run ./cmd/migrate force N   mark version N as applied after fixing a dirty migration
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"smartplate-api/internal/database"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up | down [N] | version | force N")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	m, err := database.NewMigrator()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer m.Close()
+
+	switch os.Args[1] {
+	case "up":
+		err = m.Up()
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			if steps, err = strconv.Atoi(os.Args[2]); err != nil || steps < 1 {
+				usage()
+			}
+		}
+		err = m.Steps(-steps)
+	case "version":
+		v, dirty, verr := m.Version()
+		if errors.Is(verr, migrate.ErrNilVersion) {
+			fmt.Println("no migrations applied")
+			return
+		}
+		if verr != nil {
+			log.Fatal(verr)
+		}
+		fmt.Printf("version %d (dirty: %t)\n", v, dirty)
+		return
+	case "force":
+		if len(os.Args) < 3 {
+			usage()
+		}
+		v, perr := strconv.Atoi(os.Args[2])
+		if perr != nil {
+			usage()
+		}
+		err = m.Force(v)
+	default:
+		usage()
+	}
+
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		log.Fatal(err)
+	}
+	fmt.Println("ok")
+}
@@ -0,0 +1,9 @@
+// Package db embeds the SQL migrations so the binaries don't depend on the working directory.
+package db
+
+import "embed"
+
+// Migrations holds the golang-migrate files under migrations/
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
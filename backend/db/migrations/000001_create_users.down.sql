@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS personal_information;
+DROP TABLE IF EXISTS people;
+DROP TABLE IF EXISTS medical_information;
+DROP TABLE IF EXISTS addresses;
+DROP TABLE IF EXISTS contacts;
+DROP TABLE IF EXISTS users;
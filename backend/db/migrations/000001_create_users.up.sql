@@ -0,0 +1,81 @@
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+CREATE TABLE IF NOT EXISTS users (
+    user_id       SERIAL PRIMARY KEY,
+    last_name     TEXT        NOT NULL,
+    first_name    TEXT        NOT NULL,
+    middle_name   TEXT        NOT NULL DEFAULT '',
+    email         TEXT        NOT NULL UNIQUE,
+    password      TEXT        NOT NULL,
+    role          TEXT        NOT NULL DEFAULT 'user',
+    status        TEXT        NOT NULL DEFAULT 'active',
+    lto_client_id TEXT        NOT NULL UNIQUE,
+    created       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS contacts (
+    contact_id                     SERIAL PRIMARY KEY,
+    lto_client_id                  TEXT NOT NULL REFERENCES users (lto_client_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    telephone_number               TEXT,
+    int_area_code                  TEXT,
+    mobile_number                  TEXT,
+    emergency_contact_number       TEXT,
+    emergency_contact_name         TEXT,
+    emergency_contact_relationship TEXT,
+    emergency_contact_address      TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_contacts_lto_client_id ON contacts (lto_client_id);
+
+CREATE TABLE IF NOT EXISTS addresses (
+    address_id        SERIAL PRIMARY KEY,
+    lto_client_id     TEXT NOT NULL REFERENCES users (lto_client_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    house_no          TEXT,
+    street            TEXT,
+    province          TEXT,
+    city_municipality TEXT,
+    barangay          TEXT,
+    zip_code          TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_addresses_lto_client_id ON addresses (lto_client_id);
+
+CREATE TABLE IF NOT EXISTS medical_information (
+    medical_id    SERIAL PRIMARY KEY,
+    lto_client_id TEXT NOT NULL REFERENCES users (lto_client_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    gender        TEXT,
+    blood_type    TEXT,
+    complexion    TEXT,
+    eye_color     TEXT,
+    hair_color    TEXT,
+    weight        INTEGER,
+    height        INTEGER,
+    organ_donor   BOOLEAN
+);
+CREATE INDEX IF NOT EXISTS idx_medical_information_lto_client_id ON medical_information (lto_client_id);
+
+CREATE TABLE IF NOT EXISTS people (
+    people_id          SERIAL PRIMARY KEY,
+    lto_client_id      TEXT NOT NULL REFERENCES users (lto_client_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    employer_name      TEXT,
+    employer_address   TEXT,
+    mother_first_name  TEXT,
+    mother_maiden_name TEXT,
+    mother_middle_name TEXT,
+    father_first_name  TEXT,
+    father_middle_name TEXT,
+    father_last_name   TEXT,
+    address            TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_people_lto_client_id ON people (lto_client_id);
+
+CREATE TABLE IF NOT EXISTS personal_information (
+    personal_id            SERIAL PRIMARY KEY,
+    lto_client_id          TEXT NOT NULL REFERENCES users (lto_client_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    nationality            TEXT,
+    civil_status           TEXT,
+    date_of_birth          TEXT,
+    place_of_birth         TEXT,
+    educational_attainment TEXT,
+    tin                    TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_personal_information_lto_client_id ON personal_information (lto_client_id);
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS vehicles (
+    vehicle_id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vehicle_category         TEXT NOT NULL DEFAULT '',
+    mv_file_number           TEXT NOT NULL UNIQUE,
+    vehicle_make             TEXT NOT NULL DEFAULT '',
+    vehicle_series           TEXT NOT NULL DEFAULT '',
+    vehicle_type             TEXT NOT NULL DEFAULT '',
+    body_type                TEXT NOT NULL DEFAULT '',
+    year_model               TEXT NOT NULL DEFAULT '',
+    engine_model             TEXT NOT NULL DEFAULT '',
+    engine_number            TEXT NOT NULL DEFAULT '',
+    chassis_number           TEXT NOT NULL DEFAULT '',
+    piston_displacement      TEXT NOT NULL DEFAULT '',
+    number_of_cylinders      TEXT NOT NULL DEFAULT '',
+    fuel_type                TEXT NOT NULL DEFAULT '',
+    color                    TEXT NOT NULL DEFAULT '',
+    gvw                      TEXT NOT NULL DEFAULT '',
+    net_weight               TEXT NOT NULL DEFAULT '',
+    shipping_weight          TEXT NOT NULL DEFAULT '',
+    usage_classification     TEXT NOT NULL DEFAULT '',
+    first_registration_date  TEXT NOT NULL DEFAULT '',
+    late_renewal_date        TEXT NOT NULL DEFAULT '',
+    registration_expiry_date TEXT NOT NULL DEFAULT '',
+    lto_office_code          TEXT NOT NULL DEFAULT '',
+    classification           TEXT NOT NULL DEFAULT '',
+    denomination             TEXT NOT NULL DEFAULT '',
+    or_number                TEXT NOT NULL DEFAULT '',
+    cr_number                TEXT NOT NULL DEFAULT '',
+    lto_client_id            TEXT REFERENCES users (lto_client_id) ON UPDATE CASCADE,
+    deleted_at               TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_vehicles_lto_client_id ON vehicles (lto_client_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS plate_notification_log;
+DROP TABLE IF EXISTS plate_renewal_history;
+DROP TABLE IF EXISTS plate_transfer_log;
+DROP TABLE IF EXISTS plate_history;
+DROP TABLE IF EXISTS plates;
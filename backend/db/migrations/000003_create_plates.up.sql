@@ -0,0 +1,52 @@
+CREATE TABLE IF NOT EXISTS plates (
+    plate_id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vehicle_id            UUID        NOT NULL REFERENCES vehicles (vehicle_id),
+    plate_number          TEXT        NOT NULL UNIQUE,
+    plate_type            TEXT        NOT NULL,
+    plate_issue_date      TIMESTAMPTZ NOT NULL,
+    plate_expiration_date TIMESTAMPTZ NOT NULL,
+    status                TEXT        NOT NULL DEFAULT 'Active'
+);
+CREATE INDEX IF NOT EXISTS idx_plates_vehicle_id ON plates (vehicle_id);
+CREATE INDEX IF NOT EXISTS idx_plates_expiration_date ON plates (plate_expiration_date);
+
+CREATE TABLE IF NOT EXISTS plate_history (
+    history_id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    plate_id              UUID        NOT NULL REFERENCES plates (plate_id) ON DELETE CASCADE,
+    vehicle_id            UUID        NOT NULL,
+    plate_number          TEXT        NOT NULL,
+    plate_type            TEXT        NOT NULL,
+    plate_issue_date      TIMESTAMPTZ NOT NULL,
+    plate_expiration_date TIMESTAMPTZ NOT NULL,
+    status                TEXT        NOT NULL,
+    changed_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    changed_by            TEXT        NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_plate_history_plate_id ON plate_history (plate_id, changed_at DESC);
+
+CREATE TABLE IF NOT EXISTS plate_transfer_log (
+    transfer_id     UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    plate_id        UUID        NOT NULL REFERENCES plates (plate_id) ON DELETE CASCADE,
+    from_vehicle_id UUID        NOT NULL REFERENCES vehicles (vehicle_id),
+    to_vehicle_id   UUID        NOT NULL REFERENCES vehicles (vehicle_id),
+    transferred_by  TEXT        NOT NULL DEFAULT '',
+    transferred_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_plate_transfer_log_plate_id ON plate_transfer_log (plate_id);
+
+CREATE TABLE IF NOT EXISTS plate_renewal_history (
+    renewal_id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    plate_id                 UUID        NOT NULL REFERENCES plates (plate_id) ON DELETE CASCADE,
+    previous_expiration_date TIMESTAMPTZ NOT NULL,
+    new_expiration_date      TIMESTAMPTZ NOT NULL,
+    renewed_at               TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_plate_renewal_history_plate_id ON plate_renewal_history (plate_id);
+
+CREATE TABLE IF NOT EXISTS plate_notification_log (
+    notification_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    plate_id        UUID        NOT NULL REFERENCES plates (plate_id) ON DELETE CASCADE,
+    recipient       TEXT        NOT NULL,
+    sent_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_plate_notification_log_plate_id ON plate_notification_log (plate_id, sent_at);
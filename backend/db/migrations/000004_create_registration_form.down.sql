@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS registration_document;
+DROP TABLE IF EXISTS registration_payment;
+DROP TABLE IF EXISTS registration_inspection;
+DROP TABLE IF EXISTS registration_form;
@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS registration_form (
+    registration_form_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    lto_client_id        TEXT        NOT NULL REFERENCES users (lto_client_id) ON UPDATE CASCADE,
+    vehicle_id           UUID        NOT NULL REFERENCES vehicles (vehicle_id),
+    submitted_date       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    status               TEXT        NOT NULL DEFAULT 'Pending',
+    region               TEXT        NOT NULL DEFAULT '',
+    registration_type    TEXT        NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_registration_form_vehicle_id ON registration_form (vehicle_id);
+CREATE INDEX IF NOT EXISTS idx_registration_form_lto_client_id ON registration_form (lto_client_id);
+CREATE INDEX IF NOT EXISTS idx_registration_form_status ON registration_form (status, submitted_date DESC);
+
+CREATE TABLE IF NOT EXISTS registration_inspection (
+    inspection_id        UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    registration_form_id UUID        NOT NULL REFERENCES registration_form (registration_form_id) ON DELETE CASCADE,
+    inspection_status    TEXT        NOT NULL DEFAULT '',
+    inspection_code      TEXT        NOT NULL DEFAULT '',
+    inspection_notes     TEXT        NOT NULL DEFAULT '',
+    inspected_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_registration_inspection_form_id ON registration_inspection (registration_form_id);
+
+CREATE TABLE IF NOT EXISTS registration_payment (
+    payment_id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    registration_form_id UUID NOT NULL REFERENCES registration_form (registration_form_id) ON DELETE CASCADE,
+    payment_status       TEXT NOT NULL DEFAULT '',
+    payment_code         TEXT NOT NULL DEFAULT '',
+    amount_paid          NUMERIC(12, 2),
+    payment_method       TEXT,
+    payment_date         TIMESTAMPTZ,
+    payment_notes        TEXT,
+    payment_details      JSONB
+);
+CREATE INDEX IF NOT EXISTS idx_registration_payment_form_id ON registration_payment (registration_form_id);
+
+CREATE TABLE IF NOT EXISTS registration_document (
+    document_id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    registration_form_id UUID        NOT NULL REFERENCES registration_form (registration_form_id) ON DELETE CASCADE,
+    doc_type             TEXT        NOT NULL,
+    filename             TEXT        NOT NULL,
+    file_size            INTEGER     NOT NULL DEFAULT 0,
+    uploaded_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_registration_document_form_id ON registration_document (registration_form_id);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS vehicle_inspections (
+    inspection_id       UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vehicle_id          UUID        NOT NULL REFERENCES vehicles (vehicle_id),
+    inspector_lto_id    TEXT        NOT NULL DEFAULT '',
+    inspected_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    result              TEXT        NOT NULL CHECK (result IN ('Pass', 'Fail')),
+    remarks             TEXT        NOT NULL DEFAULT '',
+    next_inspection_due TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_vehicle_inspections_vehicle_id ON vehicle_inspections (vehicle_id, inspected_at DESC);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS scan_log (
+    log_id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    plate_id        UUID        NOT NULL REFERENCES plates (plate_id) ON DELETE CASCADE,
+    registration_id UUID        NOT NULL REFERENCES registration_form (registration_form_id) ON DELETE CASCADE,
+    lto_client_id   TEXT        NOT NULL,
+    scanned_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_scan_log_scanned_at ON scan_log (scanned_at DESC);
+CREATE INDEX IF NOT EXISTS idx_scan_log_client_scanned_at ON scan_log (lto_client_id, scanned_at);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS password_reset_token (
+    token         TEXT PRIMARY KEY,
+    lto_client_id TEXT        NOT NULL REFERENCES users (lto_client_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    used_at       TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_password_reset_token_lto_client_id ON password_reset_token (lto_client_id);
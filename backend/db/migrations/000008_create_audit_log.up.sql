@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    audit_id     UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_lto_id TEXT        NOT NULL DEFAULT '',
+    action       TEXT        NOT NULL,
+    entity_type  TEXT        NOT NULL,
+    entity_id    TEXT        NOT NULL DEFAULT '',
+    old_value    JSONB,
+    new_value    JSONB,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor_lto_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (entity_type, entity_id);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS email_queue (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    to_email          TEXT        NOT NULL,
+    subject           TEXT        NOT NULL,
+    html_body         TEXT        NOT NULL,
+    status            TEXT        NOT NULL DEFAULT 'Pending',
+    attempts          INT         NOT NULL DEFAULT 0,
+    last_attempted_at TIMESTAMPTZ,
+    error_message     TEXT        NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_email_queue_status ON email_queue (status, created_at);
@@ -0,0 +1 @@
+ALTER TABLE email_queue ADD COLUMN IF NOT EXISTS text_body TEXT NOT NULL DEFAULT '';
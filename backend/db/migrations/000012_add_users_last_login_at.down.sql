@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS inactivity_emailed_at;
+ALTER TABLE users DROP COLUMN IF EXISTS last_login_at;
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS region TEXT;
+CREATE INDEX IF NOT EXISTS idx_users_region ON users (region);
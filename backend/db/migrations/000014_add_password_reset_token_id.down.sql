@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_password_reset_token_token_id;
+ALTER TABLE password_reset_token DROP COLUMN IF EXISTS token_id;
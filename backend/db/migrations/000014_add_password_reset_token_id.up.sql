@@ -0,0 +1,2 @@
+ALTER TABLE password_reset_token ADD COLUMN IF NOT EXISTS token_id UUID NOT NULL DEFAULT gen_random_uuid();
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_reset_token_token_id ON password_reset_token (token_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_plates_type_expiration_date;
+DROP INDEX IF EXISTS idx_plates_status_expiration_date;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_plates_status_expiration_date ON plates (status, plate_expiration_date);
+CREATE INDEX IF NOT EXISTS idx_plates_type_expiration_date ON plates (plate_type, plate_expiration_date);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_plates_upper_plate_number;
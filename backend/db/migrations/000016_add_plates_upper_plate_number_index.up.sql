@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_plates_upper_plate_number ON plates (UPPER(plate_number));
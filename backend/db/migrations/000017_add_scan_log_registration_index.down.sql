@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_scan_log_registration_scanned_at;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_scan_log_registration_scanned_at ON scan_log (registration_id, scanned_at DESC);
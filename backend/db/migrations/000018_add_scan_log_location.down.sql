@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_scan_log_lat_lon;
+DROP INDEX IF EXISTS idx_scan_log_device_scanned_at;
+ALTER TABLE scan_log DROP COLUMN IF EXISTS scanner_device_id;
+ALTER TABLE scan_log DROP COLUMN IF EXISTS longitude;
+ALTER TABLE scan_log DROP COLUMN IF EXISTS latitude;
@@ -0,0 +1,5 @@
+ALTER TABLE scan_log ADD COLUMN IF NOT EXISTS latitude NUMERIC(9, 6);
+ALTER TABLE scan_log ADD COLUMN IF NOT EXISTS longitude NUMERIC(9, 6);
+ALTER TABLE scan_log ADD COLUMN IF NOT EXISTS scanner_device_id VARCHAR(128);
+CREATE INDEX IF NOT EXISTS idx_scan_log_device_scanned_at ON scan_log (scanner_device_id, scanned_at DESC);
+CREATE INDEX IF NOT EXISTS idx_scan_log_lat_lon ON scan_log (latitude, longitude);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS plate_number_pool;
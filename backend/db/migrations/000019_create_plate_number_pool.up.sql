@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS plate_number_pool (
+    region        VARCHAR(50) NOT NULL,
+    prefix        VARCHAR(16) NOT NULL,
+    last_sequence INTEGER NOT NULL DEFAULT 999,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (region, prefix)
+);
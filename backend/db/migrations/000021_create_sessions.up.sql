@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    lto_client_id TEXT NOT NULL REFERENCES users (lto_client_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    issued_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMPTZ NOT NULL,
+    user_agent    TEXT,
+    ip_address    VARCHAR(45),
+    revoked_at    TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_lto_client_id ON sessions (lto_client_id, expires_at);
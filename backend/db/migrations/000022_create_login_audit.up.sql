@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS login_audit (
+    id            BIGSERIAL PRIMARY KEY,
+    lto_client_id TEXT REFERENCES users (lto_client_id) ON UPDATE CASCADE ON DELETE CASCADE, -- NULL when the email matched no account
+    ip_address    VARCHAR(45),
+    user_agent    TEXT,
+    success       BOOLEAN NOT NULL,
+    attempted_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    country       VARCHAR(2),
+    city          TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_login_audit_lto_client_id ON login_audit (lto_client_id, attempted_at DESC);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS vehicle_documents (
+    document_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    vehicle_id  UUID        NOT NULL REFERENCES vehicles (vehicle_id),
+    doc_type    TEXT        NOT NULL,
+    s3_key      TEXT        NOT NULL UNIQUE,
+    uploaded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    uploaded_by TEXT        NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_vehicle_documents_vehicle_id ON vehicle_documents (vehicle_id, uploaded_at DESC);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_registration_form_active_vehicle;
@@ -0,0 +1,3 @@
+-- a vehicle may have any number of rejected forms but only one other
+CREATE UNIQUE INDEX IF NOT EXISTS idx_registration_form_active_vehicle
+    ON registration_form (vehicle_id) WHERE status != 'Rejected';
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS form_state_history (
+    history_id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    registration_form_id UUID        NOT NULL REFERENCES registration_form (registration_form_id) ON DELETE CASCADE,
+    from_state           TEXT        NOT NULL,
+    to_state             TEXT        NOT NULL,
+    changed_by           TEXT        NOT NULL DEFAULT '',
+    changed_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_form_state_history_form_id ON form_state_history (registration_form_id, changed_at);
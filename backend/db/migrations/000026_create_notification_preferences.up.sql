@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    lto_client_id      TEXT PRIMARY KEY REFERENCES users (lto_client_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    email_plate_expiry BOOLEAN     NOT NULL DEFAULT TRUE,
+    sms_plate_expiry   BOOLEAN     NOT NULL DEFAULT TRUE,
+    email_scan_alert   BOOLEAN     NOT NULL DEFAULT TRUE,
+    sms_scan_alert     BOOLEAN     NOT NULL DEFAULT TRUE,
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    webhook_id  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    url         TEXT        NOT NULL,
+    secret      TEXT        NOT NULL,
+    event_types TEXT[]      NOT NULL DEFAULT '{}',
+    active      BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_log (
+    delivery_id  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id   UUID        NOT NULL REFERENCES webhooks (webhook_id) ON DELETE CASCADE,
+    event_type   TEXT        NOT NULL,
+    attempt      INT         NOT NULL,
+    status_code  INT,
+    error        TEXT,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_log_webhook_id ON webhook_delivery_log (webhook_id, attempted_at DESC);
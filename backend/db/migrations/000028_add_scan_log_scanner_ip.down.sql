@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_scan_log_scanner_ip_scanned_at;
+ALTER TABLE scan_log DROP COLUMN IF EXISTS scanner_ip;
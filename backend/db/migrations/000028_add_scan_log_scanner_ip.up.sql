@@ -0,0 +1,3 @@
+ALTER TABLE scan_log ADD COLUMN IF NOT EXISTS scanner_ip INET;
+
+CREATE INDEX IF NOT EXISTS idx_scan_log_scanner_ip_scanned_at ON scan_log (scanner_ip, scanned_at DESC);
@@ -0,0 +1 @@
+ALTER TABLE plate_history DROP COLUMN IF EXISTS reason;
@@ -0,0 +1 @@
+ALTER TABLE plate_history ADD COLUMN IF NOT EXISTS reason TEXT NOT NULL DEFAULT '';
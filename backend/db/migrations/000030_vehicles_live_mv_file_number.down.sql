@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_vehicles_live_mv_file_number;
+ALTER TABLE vehicles ADD CONSTRAINT vehicles_mv_file_number_key UNIQUE (mv_file_number);
@@ -0,0 +1,4 @@
+-- a soft-deleted vehicle must not block re-registering its MV file number
+ALTER TABLE vehicles DROP CONSTRAINT IF EXISTS vehicles_mv_file_number_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vehicles_live_mv_file_number
+    ON vehicles (mv_file_number) WHERE deleted_at IS NULL;
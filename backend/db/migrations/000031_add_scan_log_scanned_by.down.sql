@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_scan_log_scanned_by_scanned_at;
+ALTER TABLE scan_log DROP COLUMN IF EXISTS scanned_by;
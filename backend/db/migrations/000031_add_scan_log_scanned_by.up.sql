@@ -0,0 +1,4 @@
+-- lto_client_id is the plate's registrant; scanned_by is who ran the scanner
+ALTER TABLE scan_log ADD COLUMN IF NOT EXISTS scanned_by TEXT REFERENCES users (lto_client_id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_scan_log_scanned_by_scanned_at ON scan_log (scanned_by, scanned_at DESC);
@@ -0,0 +1 @@
+ALTER TABLE plates DROP COLUMN IF EXISTS expiry_notified_at;
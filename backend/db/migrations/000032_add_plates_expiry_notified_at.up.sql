@@ -0,0 +1,8 @@
+-- claimed with a conditional UPDATE so concurrent expiry runs notify a plate once
+ALTER TABLE plates ADD COLUMN IF NOT EXISTS expiry_notified_at TIMESTAMPTZ;
+
+UPDATE plates p SET expiry_notified_at = l.sent_at
+FROM (
+  SELECT plate_id, MAX(sent_at) AS sent_at FROM plate_notification_log GROUP BY plate_id
+) l
+WHERE l.plate_id = p.plate_id;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_plates_region_prefix;
+ALTER TABLE plates DROP COLUMN IF EXISTS region_prefix;
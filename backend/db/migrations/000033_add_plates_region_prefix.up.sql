@@ -0,0 +1,5 @@
+-- stored so the plate stats can group by it off an index instead of
+-- computing LEFT(plate_number, 1) for every row
+ALTER TABLE plates ADD COLUMN IF NOT EXISTS region_prefix TEXT GENERATED ALWAYS AS (LEFT(plate_number, 1)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_plates_region_prefix ON plates (region_prefix);
@@ -0,0 +1,9 @@
+// Package docs holds the OpenAPI 3 spec generated by `make docs`.
+package docs
+
+import _ "embed"
+
+// OpenAPI is the spec in JSON, served at /swagger/openapi.json
+//
+//go:embed openapi.json
+var OpenAPI []byte
@@ -0,0 +1,29 @@
+package config
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+)
+
+// Bounds for BCRYPT_COST: below 10 is too weak to store passwords with, and
+// above 14 makes every login take well over a second.
+const (
+    defaultBcryptCost = 12
+    minBcryptCost     = 10
+    maxBcryptCost     = 14
+)
+
+// LoadBcryptCost reads BCRYPT_COST, the bcrypt cost new password hashes are
+// made with (default 12, allowed 10 to 14).
+func LoadBcryptCost() (int, error) {
+    raw := os.Getenv("BCRYPT_COST")
+    if raw == "" {
+        return defaultBcryptCost, nil
+    }
+    cost, err := strconv.Atoi(raw)
+    if err != nil || cost < minBcryptCost || cost > maxBcryptCost {
+        return 0, fmt.Errorf("BCRYPT_COST must be between %d and %d, got %q", minBcryptCost, maxBcryptCost, raw)
+    }
+    return cost, nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import "testing"
+
+func TestLoadBcryptCost(t *testing.T) {
+    tests := []struct {
+        raw     string
+        want    int
+        wantErr bool
+    }{
+        {"", 12, false},
+        {"10", 10, false},
+        {"12", 12, false},
+        {"14", 14, false},
+        {"9", 0, true},
+        {"15", 0, true},
+        {"4", 0, true},
+        {"-12", 0, true},
+        {"twelve", 0, true},
+        {"12.5", 0, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.raw, func(t *testing.T) {
+            t.Setenv("BCRYPT_COST", tt.raw)
+            got, err := LoadBcryptCost()
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("LoadBcryptCost() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Fatalf("LoadBcryptCost() = %d, want %d", got, tt.want)
+            }
+        })
+    }
+}
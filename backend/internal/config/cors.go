@@ -0,0 +1,60 @@
+// Package config reads application settings from the environment.
+package config
+
+import (
+    "fmt"
+    "net/url"
+    "os"
+    "strings"
+)
+
+// defaultAllowedOrigins are the Vite dev servers, used when CORS_ALLOWED_ORIGINS is unset
+const defaultAllowedOrigins = "http://localhost:5173,http://localhost:5174"
+
+// CORSConfig lists the browser origins allowed to call the REST API and open
+// scanner websockets.
+type CORSConfig struct {
+    AllowedOrigins []string
+    AllowAll       bool // only in test mode
+}
+
+// LoadCORSConfig reads CORS_ALLOWED_ORIGINS (comma-separated). Every entry must
+// be a bare http(s) origin such as https://smartplate.example.com. With
+// APP_ENV=test every origin is allowed.
+func LoadCORSConfig() (CORSConfig, error) {
+    if os.Getenv("APP_ENV") == "test" {
+        return CORSConfig{AllowedOrigins: []string{"*"}, AllowAll: true}, nil
+    }
+
+    raw := os.Getenv("CORS_ALLOWED_ORIGINS")
+    if raw == "" {
+        raw = defaultAllowedOrigins
+    }
+
+    var cfg CORSConfig
+    for _, o := range strings.Split(raw, ",") {
+        o = strings.TrimRight(strings.TrimSpace(o), "/")
+        if o == "" {
+            continue
+        }
+        u, err := url.Parse(o)
+        if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+            return CORSConfig{}, fmt.Errorf("invalid origin %q in CORS_ALLOWED_ORIGINS", o)
+        }
+        cfg.AllowedOrigins = append(cfg.AllowedOrigins, o)
+    }
+    return cfg, nil
+}
+
+// Allowed reports whether a request from origin may be served
+func (c CORSConfig) Allowed(origin string) bool {
+    if c.AllowAll {
+        return true
+    }
+    for _, o := range c.AllowedOrigins {
+        if o == origin {
+            return true
+        }
+    }
+    return false
+}
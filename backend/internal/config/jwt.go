@@ -0,0 +1,72 @@
+package config
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+    "time"
+
+    "smartplate-api/internal/models"
+)
+
+// maxPrivilegedExpiry is the security policy's ceiling on admin and officer token lifetimes
+const maxPrivilegedExpiry = 24 * time.Hour
+
+// JWTConfig holds the signing secret and how long issued tokens stay valid,
+// per role.
+type JWTConfig struct {
+    Secret        []byte // JWT_SECRET; also signs plate QR codes
+    UserExpiry    time.Duration
+    OfficerExpiry time.Duration
+    AdminExpiry   time.Duration // admins and superadmins
+}
+
+// LoadJWTConfig reads JWT_USER_EXPIRY_HOURS (default 168),
+// JWT_OFFICER_EXPIRY_HOURS (default 8) and JWT_ADMIN_EXPIRY_HOURS (default 12).
+// Officers are a sub-role of admin, so both are held to at most 24 hours.
+// JWT_SECRET is required.
+func LoadJWTConfig() (JWTConfig, error) {
+    secret := os.Getenv("JWT_SECRET")
+    if secret == "" {
+        return JWTConfig{}, fmt.Errorf("JWT_SECRET must be set")
+    }
+    cfg := JWTConfig{
+        Secret:        []byte(secret),
+        UserExpiry:    7 * 24 * time.Hour,
+        OfficerExpiry: 8 * time.Hour,
+        AdminExpiry:   12 * time.Hour,
+    }
+    for name, dst := range map[string]*time.Duration{
+        "JWT_USER_EXPIRY_HOURS":    &cfg.UserExpiry,
+        "JWT_OFFICER_EXPIRY_HOURS": &cfg.OfficerExpiry,
+        "JWT_ADMIN_EXPIRY_HOURS":   &cfg.AdminExpiry,
+    } {
+        raw := os.Getenv(name)
+        if raw == "" {
+            continue
+        }
+        hours, err := strconv.Atoi(raw)
+        if err != nil || hours <= 0 {
+            return JWTConfig{}, fmt.Errorf("%s must be a positive number of hours, got %q", name, raw)
+        }
+        *dst = time.Duration(hours) * time.Hour
+    }
+    if cfg.AdminExpiry > maxPrivilegedExpiry {
+        return JWTConfig{}, fmt.Errorf("JWT_ADMIN_EXPIRY_HOURS must be at most %d", int(maxPrivilegedExpiry.Hours()))
+    }
+    if cfg.OfficerExpiry > maxPrivilegedExpiry {
+        return JWTConfig{}, fmt.Errorf("JWT_OFFICER_EXPIRY_HOURS must be at most %d", int(maxPrivilegedExpiry.Hours()))
+    }
+    return cfg, nil
+}
+
+// ExpiryFor returns the token lifetime for role; unknown roles get the user expiry
+func (c JWTConfig) ExpiryFor(role string) time.Duration {
+    switch role {
+    case models.RoleOfficer:
+        return c.OfficerExpiry
+    case models.RoleAdmin, models.RoleSuperAdmin:
+        return c.AdminExpiry
+    }
+    return c.UserExpiry
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+    "testing"
+    "time"
+
+    "smartplate-api/internal/models"
+)
+
+func TestLoadJWTConfig(t *testing.T) {
+    tests := []struct {
+        name        string
+        env         map[string]string
+        wantUser    time.Duration
+        wantOfficer time.Duration
+        wantAdmin   time.Duration
+        wantErr     bool
+    }{
+        {"defaults", nil, 168 * time.Hour, 8 * time.Hour, 12 * time.Hour, false},
+        {"overrides", map[string]string{
+            "JWT_USER_EXPIRY_HOURS":    "24",
+            "JWT_OFFICER_EXPIRY_HOURS": "4",
+            "JWT_ADMIN_EXPIRY_HOURS":   "2",
+        }, 24 * time.Hour, 4 * time.Hour, 2 * time.Hour, false},
+        {"admin at the ceiling", map[string]string{"JWT_ADMIN_EXPIRY_HOURS": "24"}, 168 * time.Hour, 8 * time.Hour, 24 * time.Hour, false},
+        {"long user tokens are allowed", map[string]string{"JWT_USER_EXPIRY_HOURS": "720"}, 720 * time.Hour, 8 * time.Hour, 12 * time.Hour, false},
+        {"admin over 24 hours", map[string]string{"JWT_ADMIN_EXPIRY_HOURS": "25"}, 0, 0, 0, true},
+        {"officer over 24 hours", map[string]string{"JWT_OFFICER_EXPIRY_HOURS": "48"}, 0, 0, 0, true},
+        {"zero hours", map[string]string{"JWT_USER_EXPIRY_HOURS": "0"}, 0, 0, 0, true},
+        {"negative hours", map[string]string{"JWT_OFFICER_EXPIRY_HOURS": "-1"}, 0, 0, 0, true},
+        {"not a number", map[string]string{"JWT_ADMIN_EXPIRY_HOURS": "12h"}, 0, 0, 0, true},
+        {"no secret", map[string]string{"JWT_SECRET": ""}, 0, 0, 0, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            t.Setenv("JWT_SECRET", "test-secret")
+            for _, name := range []string{"JWT_USER_EXPIRY_HOURS", "JWT_OFFICER_EXPIRY_HOURS", "JWT_ADMIN_EXPIRY_HOURS"} {
+                t.Setenv(name, "")
+            }
+            for name, value := range tt.env {
+                t.Setenv(name, value)
+            }
+
+            cfg, err := LoadJWTConfig()
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("LoadJWTConfig error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if tt.wantErr {
+                return
+            }
+            if cfg.UserExpiry != tt.wantUser || cfg.OfficerExpiry != tt.wantOfficer || cfg.AdminExpiry != tt.wantAdmin {
+                t.Fatalf("got user %v, officer %v, admin %v; want %v, %v, %v",
+                    cfg.UserExpiry, cfg.OfficerExpiry, cfg.AdminExpiry, tt.wantUser, tt.wantOfficer, tt.wantAdmin)
+            }
+            if string(cfg.Secret) != "test-secret" {
+                t.Fatalf("Secret = %q", cfg.Secret)
+            }
+        })
+    }
+}
+
+func TestExpiryFor(t *testing.T) {
+    cfg := JWTConfig{UserExpiry: 1 * time.Hour, OfficerExpiry: 2 * time.Hour, AdminExpiry: 3 * time.Hour}
+    tests := []struct {
+        role string
+        want time.Duration
+    }{
+        {models.RoleUser, 1 * time.Hour},
+        {models.RoleOfficer, 2 * time.Hour},
+        {models.RoleAdmin, 3 * time.Hour},
+        {models.RoleSuperAdmin, 3 * time.Hour},
+        {"", 1 * time.Hour},
+        {"unknown", 1 * time.Hour},
+    }
+
+    for _, tt := range tests {
+        if got := cfg.ExpiryFor(tt.role); got != tt.want {
+            t.Errorf("ExpiryFor(%q) = %v, want %v", tt.role, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+    "unicode"
+)
+
+// maxBcryptPasswordLength is the most bytes bcrypt will hash; longer
+// passwords are rejected by the hasher, so the policy can't allow them
+const maxBcryptPasswordLength = 72
+
+// PasswordPolicy is what new passwords must satisfy.
+type PasswordPolicy struct {
+    MinLength          int
+    MaxLength          int
+    RequireUppercase   bool
+    RequireDigit       bool
+    RequireSpecialChar bool
+}
+
+// DefaultPasswordPolicy only asks for 8 to 72 characters
+func DefaultPasswordPolicy() PasswordPolicy {
+    return PasswordPolicy{MinLength: 8, MaxLength: maxBcryptPasswordLength}
+}
+
+// LoadPasswordPolicy reads PASSWORD_MIN_LENGTH (default 8),
+// PASSWORD_MAX_LENGTH (default and at most 72, bcrypt's limit),
+// PASSWORD_REQUIRE_UPPERCASE, PASSWORD_REQUIRE_DIGIT and
+// PASSWORD_REQUIRE_SPECIAL (all default false).
+func LoadPasswordPolicy() (PasswordPolicy, error) {
+    p := DefaultPasswordPolicy()
+    for name, dst := range map[string]*int{
+        "PASSWORD_MIN_LENGTH": &p.MinLength,
+        "PASSWORD_MAX_LENGTH": &p.MaxLength,
+    } {
+        raw := os.Getenv(name)
+        if raw == "" {
+            continue
+        }
+        n, err := strconv.Atoi(raw)
+        if err != nil || n <= 0 {
+            return PasswordPolicy{}, fmt.Errorf("%s must be a positive number, got %q", name, raw)
+        }
+        *dst = n
+    }
+    for name, dst := range map[string]*bool{
+        "PASSWORD_REQUIRE_UPPERCASE": &p.RequireUppercase,
+        "PASSWORD_REQUIRE_DIGIT":     &p.RequireDigit,
+        "PASSWORD_REQUIRE_SPECIAL":   &p.RequireSpecialChar,
+    } {
+        raw := os.Getenv(name)
+        if raw == "" {
+            continue
+        }
+        b, err := strconv.ParseBool(raw)
+        if err != nil {
+            return PasswordPolicy{}, fmt.Errorf("%s must be true or false, got %q", name, raw)
+        }
+        *dst = b
+    }
+    if p.MaxLength > maxBcryptPasswordLength {
+        return PasswordPolicy{}, fmt.Errorf("PASSWORD_MAX_LENGTH must be at most %d", maxBcryptPasswordLength)
+    }
+    if p.MinLength > p.MaxLength {
+        return PasswordPolicy{}, fmt.Errorf("PASSWORD_MIN_LENGTH (%d) is above PASSWORD_MAX_LENGTH (%d)", p.MinLength, p.MaxLength)
+    }
+    return p, nil
+}
+
+// ValidatePassword lists every rule of policy that password breaks, as
+// messages fit to show the user; it is empty when the password is acceptable.
+// Lengths are counted in bytes, as bcrypt counts them.
+func ValidatePassword(policy PasswordPolicy, password string) []string {
+    var upper, digit, special bool
+    for _, r := range password {
+        switch {
+        case unicode.IsUpper(r):
+            upper = true
+        case unicode.IsDigit(r):
+            digit = true
+        case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+            special = true
+        }
+    }
+
+    failures := []string{}
+    if len(password) < policy.MinLength {
+        failures = append(failures, fmt.Sprintf("password must be at least %d characters", policy.MinLength))
+    }
+    if policy.MaxLength > 0 && len(password) > policy.MaxLength {
+        failures = append(failures, fmt.Sprintf("password must be at most %d characters", policy.MaxLength))
+    }
+    if policy.RequireUppercase && !upper {
+        failures = append(failures, "password must contain an uppercase letter")
+    }
+    if policy.RequireDigit && !digit {
+        failures = append(failures, "password must contain a digit")
+    }
+    if policy.RequireSpecialChar && !special {
+        failures = append(failures, "password must contain a special character")
+    }
+    return failures
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+    "fmt"
+    "strings"
+    "testing"
+)
+
+func TestValidatePassword(t *testing.T) {
+    strict := PasswordPolicy{MinLength: 10, MaxLength: 20, RequireUppercase: true, RequireDigit: true, RequireSpecialChar: true}
+    const (
+        tooShort = "password must be at least 10 characters"
+        tooLong  = "password must be at most 20 characters"
+        noUpper  = "password must contain an uppercase letter"
+        noDigit  = "password must contain a digit"
+        noSymbol = "password must contain a special character"
+    )
+
+    // every combination of length (fine, short, long) with each character
+    // class present or missing
+    for _, length := range []int{12, 5, 25} {
+        for mask := 0; mask < 8; mask++ {
+            hasUpper, hasDigit, hasSymbol := mask&1 != 0, mask&2 != 0, mask&4 != 0
+            var b strings.Builder
+            if hasUpper {
+                b.WriteString("Q")
+            }
+            if hasDigit {
+                b.WriteString("7")
+            }
+            if hasSymbol {
+                b.WriteString("#")
+            }
+            password := b.String() + strings.Repeat("x", length-b.Len())
+
+            var lengthFailures []string
+            switch length {
+            case 5:
+                lengthFailures = []string{tooShort}
+            case 25:
+                lengthFailures = []string{tooLong}
+            }
+            want := append([]string{}, lengthFailures...)
+            if !hasUpper {
+                want = append(want, noUpper)
+            }
+            if !hasDigit {
+                want = append(want, noDigit)
+            }
+            if !hasSymbol {
+                want = append(want, noSymbol)
+            }
+
+            t.Run(password, func(t *testing.T) {
+                got := ValidatePassword(strict, password)
+                if fmt.Sprint(got) != fmt.Sprint(want) {
+                    t.Fatalf("ValidatePassword(%q) = %q, want %q", password, got, want)
+                }
+                // with only the length rules, just the length failure remains
+                lenient := PasswordPolicy{MinLength: 10, MaxLength: 20}
+                if got := ValidatePassword(lenient, password); fmt.Sprint(got) != fmt.Sprint(lengthFailures) {
+                    t.Fatalf("length-only policy: ValidatePassword(%q) = %q, want %q", password, got, lengthFailures)
+                }
+            })
+        }
+    }
+}
+
+func TestValidatePasswordCharacterClasses(t *testing.T) {
+    policy := PasswordPolicy{MinLength: 1, RequireUppercase: true, RequireDigit: true, RequireSpecialChar: true}
+    tests := []struct {
+        password string
+        want     int // failures
+    }{
+        {"Ñ٣—", 0},     // non-ASCII upper, digit and punctuation count
+        {"abc def", 3}, // a space isn't a special character
+        {"ABC", 2},
+        {"123", 2},
+        {"!@#", 2},
+        {"Aa1!", 0},
+    }
+    for _, tt := range tests {
+        if got := ValidatePassword(policy, tt.password); len(got) != tt.want {
+            t.Errorf("ValidatePassword(%q) = %q, want %d failures", tt.password, got, tt.want)
+        }
+    }
+}
+
+func TestValidatePasswordDefaultPolicy(t *testing.T) {
+    tests := []struct {
+        password string
+        want     []string
+    }{
+        {"short", []string{"password must be at least 8 characters"}},
+        {"longenough", []string{}},
+        {strings.Repeat("a", 72), []string{}},
+        {strings.Repeat("a", 73), []string{"password must be at most 72 characters"}},
+        {"ñññññ", []string{}}, // 10 bytes
+    }
+    for _, tt := range tests {
+        if got := ValidatePassword(DefaultPasswordPolicy(), tt.password); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+            t.Errorf("ValidatePassword(%q) = %q, want %q", tt.password, got, tt.want)
+        }
+    }
+}
+
+func TestLoadPasswordPolicy(t *testing.T) {
+    tests := []struct {
+        name    string
+        env     map[string]string
+        want    PasswordPolicy
+        wantErr bool
+    }{
+        {"defaults", nil, DefaultPasswordPolicy(), false},
+        {"all rules", map[string]string{
+            "PASSWORD_MIN_LENGTH":        "12",
+            "PASSWORD_MAX_LENGTH":        "64",
+            "PASSWORD_REQUIRE_UPPERCASE": "true",
+            "PASSWORD_REQUIRE_DIGIT":     "1",
+            "PASSWORD_REQUIRE_SPECIAL":   "TRUE",
+        }, PasswordPolicy{MinLength: 12, MaxLength: 64, RequireUppercase: true, RequireDigit: true, RequireSpecialChar: true}, false},
+        {"max over bcrypt's limit", map[string]string{"PASSWORD_MAX_LENGTH": "100"}, PasswordPolicy{}, true},
+        {"min above max", map[string]string{"PASSWORD_MIN_LENGTH": "30", "PASSWORD_MAX_LENGTH": "20"}, PasswordPolicy{}, true},
+        {"zero min", map[string]string{"PASSWORD_MIN_LENGTH": "0"}, PasswordPolicy{}, true},
+        {"not a number", map[string]string{"PASSWORD_MIN_LENGTH": "eight"}, PasswordPolicy{}, true},
+        {"not a bool", map[string]string{"PASSWORD_REQUIRE_DIGIT": "yes"}, PasswordPolicy{}, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            for _, name := range []string{"PASSWORD_MIN_LENGTH", "PASSWORD_MAX_LENGTH", "PASSWORD_REQUIRE_UPPERCASE", "PASSWORD_REQUIRE_DIGIT", "PASSWORD_REQUIRE_SPECIAL"} {
+                t.Setenv(name, tt.env[name])
+            }
+            got, err := LoadPasswordPolicy()
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("LoadPasswordPolicy error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Fatalf("LoadPasswordPolicy = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
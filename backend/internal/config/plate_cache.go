@@ -0,0 +1,24 @@
+package config
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+)
+
+// defaultPlateCacheSize comfortably holds the plates a busy day of scanning touches
+const defaultPlateCacheSize = 10000
+
+// LoadPlateCacheSize reads PLATE_CACHE_SIZE, how many plates the scanner
+// lookup cache holds (default 10000); 0 turns the cache off.
+func LoadPlateCacheSize() (int, error) {
+    raw := os.Getenv("PLATE_CACHE_SIZE")
+    if raw == "" {
+        return defaultPlateCacheSize, nil
+    }
+    size, err := strconv.Atoi(raw)
+    if err != nil || size < 0 {
+        return 0, fmt.Errorf("PLATE_CACHE_SIZE must be a non-negative number, got %q", raw)
+    }
+    return size, nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+    "fmt"
+    "net"
+    "os"
+    "strings"
+
+    "github.com/labstack/echo/v4"
+)
+
+// LoadIPExtractor reads TRUSTED_PROXIES, a comma-separated list of CIDRs the
+// API sits behind. Unset, the client IP is the TCP peer and X-Forwarded-For
+// and X-Real-IP are ignored, since anyone can send them. Set, X-Forwarded-For
+// is walked back only through those ranges.
+func LoadIPExtractor() (echo.IPExtractor, error) {
+    raw := os.Getenv("TRUSTED_PROXIES")
+    if strings.TrimSpace(raw) == "" {
+        return echo.ExtractIPDirect(), nil
+    }
+    opts := []echo.TrustOption{
+        echo.TrustLoopback(false),
+        echo.TrustLinkLocal(false),
+        echo.TrustPrivateNet(false),
+    }
+    for _, s := range strings.Split(raw, ",") {
+        _, ipNet, err := net.ParseCIDR(strings.TrimSpace(s))
+        if err != nil {
+            return nil, fmt.Errorf("TRUSTED_PROXIES: %q is not a CIDR", strings.TrimSpace(s))
+        }
+        opts = append(opts, echo.TrustIPRange(ipNet))
+    }
+    return echo.ExtractIPFromXFFHeader(opts...), nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+    "net/http/httptest"
+    "testing"
+)
+
+func TestLoadIPExtractor(t *testing.T) {
+    tests := []struct {
+        name    string
+        raw     string
+        remote  string
+        xff     string
+        want    string
+        wantErr bool
+    }{
+        {"unset ignores xff", "", "203.0.113.7:5000", "10.0.0.1", "203.0.113.7", false},
+        {"unset ignores xff from private peer", "", "10.0.0.2:5000", "127.0.0.1", "10.0.0.2", false},
+        {"trusted proxy forwards client", "10.0.0.0/8", "10.0.0.2:5000", "198.51.100.4", "198.51.100.4", false},
+        {"untrusted peer can't forward", "10.0.0.0/8", "203.0.113.7:5000", "127.0.0.1", "203.0.113.7", false},
+        {"spoofed hop before proxy", "10.0.0.0/8", "10.0.0.2:5000", "127.0.0.1, 198.51.100.4", "198.51.100.4", false},
+        {"not a cidr", "10.0.0.1", "", "", "", true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            t.Setenv("TRUSTED_PROXIES", tt.raw)
+            extract, err := LoadIPExtractor()
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("LoadIPExtractor() error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if err != nil {
+                return
+            }
+            req := httptest.NewRequest("GET", "/", nil)
+            req.RemoteAddr = tt.remote
+            req.Header.Set("X-Forwarded-For", tt.xff)
+            req.Header.Set("X-Real-IP", tt.xff)
+            if got := extract(req); got != tt.want {
+                t.Fatalf("client ip = %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+    "fmt"
+    "os"
+    "time"
+
+    // bundled so APP_TIMEZONE works on images without a zoneinfo database
+    _ "time/tzdata"
+)
+
+// LoadLocation returns the zone named by APP_TIMEZONE (an IANA name such as
+// Asia/Manila), used when reports bucket times by hour of day. It defaults
+// to UTC.
+func LoadLocation() (*time.Location, error) {
+    name := os.Getenv("APP_TIMEZONE")
+    if name == "" {
+        return time.UTC, nil
+    }
+    loc, err := time.LoadLocation(name)
+    if err != nil || loc.String() == "Local" {
+        return nil, fmt.Errorf("APP_TIMEZONE %q is not an IANA time zone", name)
+    }
+    return loc, nil
+}
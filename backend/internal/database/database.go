@@ -1,7 +1,9 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 
@@ -11,15 +13,20 @@ import (
 )
 
 func init() {
-	// Load .env file
+	// Load .env file. Without one the DB_* variables must come from the
+	// environment, as they do in tests and containers.
 	err := godotenv.Load("../.env")
-	if err != nil {
-		log.Fatalf("Error loading .env file")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("Error loading .env file: %v", err)
 	}
 }
 
-func Connect() (*sqlx.DB, error) {
-	// Get environment variables
+// connString returns DB_WRITE_DSN, or builds the lib/pq connection string
+// from the DB_* environment variables when it is unset
+func connString() string {
+	if dsn := os.Getenv("DB_WRITE_DSN"); dsn != "" {
+		return dsn
+	}
 	host := os.Getenv("DB_HOST")
 	port := os.Getenv("DB_PORT")
 	user := os.Getenv("DB_USER")
@@ -27,16 +34,23 @@ func Connect() (*sqlx.DB, error) {
 	dbname := os.Getenv("DB_NAME")
 	sslmode := os.Getenv("DB_SSLMODE")
 
-	// Create connection string
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		host, port, user, password, dbname, sslmode)
+}
 
+func Connect() (*sqlx.DB, error) {
+	return open(connString())
+}
+
+func open(dsn string) (*sqlx.DB, error) {
 	// Connect to the database
-	db, err := sqlx.Connect("postgres", connStr)
+	db, err := sqlx.Connect("postgres", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	configurePool(db)
+
 	// Ping the database to ensure connection is alive
 	if err = db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
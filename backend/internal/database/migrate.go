@@ -0,0 +1,53 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"smartplate-api/db"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// NewMigrator returns a migrator for the embedded migrations. It owns its own
+// connection, which Close on the returned migrator releases.
+func NewMigrator() (*migrate.Migrate, error) {
+	conn, err := sql.Open("postgres", connString())
+	if err != nil {
+		return nil, fmt.Errorf("open migration connection: %w", err)
+	}
+	driver, err := postgres.WithInstance(conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("migration driver: %w", err)
+	}
+	src, err := iofs.New(db.Migrations, "migrations")
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("migration source: %w", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		src.Close()
+		driver.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// MigrateUp applies every pending migration
+func MigrateUp() error {
+	m, err := NewMigrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// poolStatsInterval is how often MonitorPool logs the pool's stats
+const poolStatsInterval = 30 * time.Second
+
+// configurePool applies DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and
+// DB_CONN_MAX_LIFETIME_SECONDS; unset or invalid values keep database/sql's defaults
+func configurePool(db *sqlx.DB) {
+	if n, err := strconv.Atoi(os.Getenv("DB_MAX_OPEN_CONNS")); err == nil && n > 0 {
+		db.SetMaxOpenConns(n)
+	}
+	if n, err := strconv.Atoi(os.Getenv("DB_MAX_IDLE_CONNS")); err == nil && n >= 0 {
+		db.SetMaxIdleConns(n)
+	}
+	if n, err := strconv.Atoi(os.Getenv("DB_CONN_MAX_LIFETIME_SECONDS")); err == nil && n > 0 {
+		db.SetConnMaxLifetime(time.Duration(n) * time.Second)
+	}
+}
+
+// PoolStats is the subset of sql.DBStats reported in logs and on /ready
+type PoolStats struct {
+	OpenConnections int   `json:"open_connections"`
+	InUse           int   `json:"in_use"`
+	Idle            int   `json:"idle"`
+	WaitCount       int64 `json:"wait_count"`
+}
+
+// Stats returns the current pool stats
+func Stats(db *sqlx.DB) PoolStats {
+	s := db.Stats()
+	return PoolStats{
+		OpenConnections: s.OpenConnections,
+		InUse:           s.InUse,
+		Idle:            s.Idle,
+		WaitCount:       s.WaitCount,
+	}
+}
+
+// MonitorPool logs the pool's stats every 30 seconds until ctx is cancelled
+func MonitorPool(ctx context.Context, db *sqlx.DB, logger *slog.Logger) {
+	ticker := time.NewTicker(poolStatsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s := Stats(db)
+			logger.Info("db pool stats",
+				"open_connections", s.OpenConnections,
+				"in_use", s.InUse,
+				"idle", s.Idle,
+				"wait_count", s.WaitCount,
+			)
+		}
+	}
+}
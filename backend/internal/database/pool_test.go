@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestConfigurePool(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxOpen     string
+		wantMaxOpen int
+	}{
+		{"unset", "", 0},
+		{"set", "7", 7},
+		{"zero is ignored", "0", 0},
+		{"negative is ignored", "-3", 0},
+		{"not a number", "many", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DB_MAX_OPEN_CONNS", tt.maxOpen)
+			raw, _, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer raw.Close()
+			db := sqlx.NewDb(raw, "postgres")
+
+			configurePool(db)
+			if got := db.Stats().MaxOpenConnections; got != tt.wantMaxOpen {
+				t.Fatalf("MaxOpenConnections = %d, want %d", got, tt.wantMaxOpen)
+			}
+		})
+	}
+}
+
+func TestSingleConnectionPoolQueuesConcurrentQueries(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS", "1")
+	raw, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	db := sqlx.NewDb(raw, "postgres")
+	configurePool(db)
+
+	const workers = 5
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < workers; i++ {
+		mock.ExpectQuery("SELECT 1").
+			WillDelayFor(20 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var n int
+			errs <- db.GetContext(context.Background(), &n, "SELECT 1")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("query failed instead of waiting for the connection: %v", err)
+		}
+	}
+	s := db.Stats()
+	if s.MaxOpenConnections != 1 || s.OpenConnections > 1 {
+		t.Fatalf("pool opened %d connections with a limit of %d", s.OpenConnections, s.MaxOpenConnections)
+	}
+	if s.WaitCount == 0 {
+		t.Fatal("no query waited for the single connection")
+	}
+	if got := Stats(db); got.WaitCount != s.WaitCount || got.OpenConnections != s.OpenConnections {
+		t.Fatalf("Stats = %+v, want it to mirror %+v", got, s)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ReadWriteDB pairs the primary with a read replica. Reporting queries go to
+// Read; everything else, including reads that must see the caller's own
+// writes, stays on Write.
+type ReadWriteDB struct {
+	Write *sqlx.DB
+	Read  *sqlx.DB
+}
+
+// ConnectReadWrite connects to the primary and, when DB_READ_DSN is set, to
+// the replica; without DB_READ_DSN, Read is the same pool as Write
+func ConnectReadWrite() (ReadWriteDB, error) {
+	write, err := Connect()
+	if err != nil {
+		return ReadWriteDB{}, err
+	}
+	dsn := os.Getenv("DB_READ_DSN")
+	if dsn == "" {
+		return ReadWriteDB{Write: write, Read: write}, nil
+	}
+	read, err := open(dsn)
+	if err != nil {
+		write.Close()
+		return ReadWriteDB{}, fmt.Errorf("read replica: %w", err)
+	}
+	return ReadWriteDB{Write: write, Read: read}, nil
+}
+
+// Close closes both pools, or the shared one once
+func (rw ReadWriteDB) Close() error {
+	err := rw.Write.Close()
+	if rw.Read != rw.Write {
+		if rerr := rw.Read.Close(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
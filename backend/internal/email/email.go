@@ -0,0 +1,663 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Config holds the SMTP settings read from the environment
+type Config struct {
+	SMTPHost    string
+	SMTPPort    string
+	Username    string
+	Password    string
+	From        string
+	FrontendURL string
+}
+
+// loadConfig reads SMTP_* settings; called per send so .env changes are picked up
+func loadConfig() Config {
+	cfg := Config{
+		SMTPHost:    os.Getenv("SMTP_HOST"),
+		SMTPPort:    os.Getenv("SMTP_PORT"),
+		Username:    os.Getenv("SMTP_USERNAME"),
+		Password:    os.Getenv("SMTP_PASSWORD"),
+		From:        os.Getenv("SMTP_FROM"),
+		FrontendURL: os.Getenv("FRONTEND_URL"),
+	}
+	if cfg.SMTPPort == "" {
+		cfg.SMTPPort = "587"
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	if cfg.FrontendURL == "" {
+		cfg.FrontendURL = "http://localhost:5173"
+	}
+	return cfg
+}
+
+// skipSending reports whether emails should only be logged (dev mode)
+func skipSending(cfg Config) bool {
+	return os.Getenv("SKIP_EMAIL_SENDING") == "true" || cfg.SMTPHost == "" || cfg.Username == ""
+}
+
+const resetPasswordTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <h2>SmartPlate Password Reset</h2>
+  <p>We received a request to reset your SmartPlate password.</p>
+  <p><a href="{{.ResetURL}}" style="background:#1d4ed8;color:#fff;padding:10px 16px;text-decoration:none;border-radius:4px;">Reset Password</a></p>
+  <p>This link expires in 1 hour. If you didn't request a reset, you can ignore this email.</p>
+</body>
+</html>`
+
+const resetPasswordText = `SmartPlate Password Reset
+
+We received a request to reset your SmartPlate password.
+
+Reset your password: {{.ResetURL}}
+
+This link expires in 1 hour. If you didn't request a reset, you can ignore this email.
+`
+
+const welcomeTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <h2>Welcome to SmartPlate</h2>
+  <p>Hi {{.FirstName}},</p>
+  <p>Your SmartPlate account is ready. Your LTO client ID is <strong>{{.LTOClientID}}</strong>; keep it handy for registrations and renewals.</p>
+  <p><a href="{{.DashboardURL}}" style="background:#1d4ed8;color:#fff;padding:10px 16px;text-decoration:none;border-radius:4px;">Go to Dashboard</a></p>
+  <p style="font-size:12px;color:#666;">Keep your password private and never share it with anyone, including LTO staff. If you didn't create this account, please contact your LTO office.</p>
+</body>
+</html>`
+
+const welcomeText = `Welcome to SmartPlate
+
+Hi {{.FirstName}},
+
+Your SmartPlate account is ready. Your LTO client ID is {{.LTOClientID}}; keep it handy for registrations and renewals.
+
+Go to your dashboard: {{.DashboardURL}}
+
+Keep your password private and never share it with anyone, including LTO staff. If you didn't create this account, please contact your LTO office.
+`
+
+const inactivityTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <h2>We Miss You at SmartPlate</h2>
+  <p>Hi {{.FirstName}},</p>
+  <p>It's been a while since you last used SmartPlate. Sign in to check your vehicles, registrations and plate renewals.</p>
+  <p><a href="{{.LoginURL}}" style="background:#1d4ed8;color:#fff;padding:10px 16px;text-decoration:none;border-radius:4px;">Sign In</a></p>
+  <p style="font-size:12px;color:#666;">If you no longer need your account, you can ignore this email.</p>
+</body>
+</html>`
+
+const inactivityText = `We Miss You at SmartPlate
+
+Hi {{.FirstName}},
+
+It's been a while since you last used SmartPlate. Sign in to check your vehicles, registrations and plate renewals.
+
+Sign in: {{.LoginURL}}
+
+If you no longer need your account, you can ignore this email.
+`
+
+const plateRenewalTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <h2>Plate Renewal Confirmed</h2>
+  <p>Hi {{.OwnerName}},</p>
+  <p>Your plate <strong>{{.PlateNumber}}</strong> has been renewed and is now valid until <strong>{{.ExpiryDate}}</strong>.</p>
+  <p>Thank you for keeping your registration up to date.</p>
+</body>
+</html>`
+
+const plateRenewalText = `Plate Renewal Confirmed
+
+Hi {{.OwnerName}},
+
+Your plate {{.PlateNumber}} has been renewed and is now valid until {{.ExpiryDate}}.
+
+Thank you for keeping your registration up to date.
+`
+
+const plateExpiryTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  {{if .Expired}}<h2 style="color:#b91c1c;">Your Plate Has Expired</h2>{{else}}<h2>Your Plate Is Expiring Soon</h2>{{end}}
+  <p>Hi {{.OwnerName}},</p>
+  {{if .Expired}}<p style="background:#fee2e2;border-left:4px solid #b91c1c;padding:10px;">Your plate <strong>{{.PlateNumber}}</strong> already expired on <strong>{{.ExpiryDate}}</strong>. Driving with an expired plate may result in penalties.</p>
+  {{else}}<p>Your plate <strong>{{.PlateNumber}}</strong> expires in <strong>{{.DaysUntilExpiry}} day(s)</strong>, on <strong>{{.ExpiryDate}}</strong>.</p>
+  {{end}}<p><a href="{{.RenewalURL}}" style="display:inline-block;background:#1d4ed8;color:#fff;padding:14px 24px;font-size:16px;font-weight:bold;text-decoration:none;border-radius:4px;">Renew Now</a></p>
+</body>
+</html>`
+
+const plateExpiryText = `{{if .Expired}}Your Plate Has Expired{{else}}Your Plate Is Expiring Soon{{end}}
+
+Hi {{.OwnerName}},
+
+{{if .Expired}}Your plate {{.PlateNumber}} already expired on {{.ExpiryDate}}. Driving with an expired plate may result in penalties.
+{{else}}Your plate {{.PlateNumber}} expires in {{.DaysUntilExpiry}} day(s), on {{.ExpiryDate}}.
+{{end}}
+Renew now: {{.RenewalURL}}
+`
+
+const plateTransferTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <h2>Plate Transferred</h2>
+  <p>Hi {{.OwnerName}},</p>
+  <p>Plate <strong>{{.PlateNumber}}</strong> has been transferred to your vehicle with MV file number <strong>{{.MVFileNumber}}</strong>.</p>
+  <p>If you did not expect this change, please contact your LTO office.</p>
+</body>
+</html>`
+
+const plateTransferText = `Plate Transferred
+
+Hi {{.OwnerName}},
+
+Plate {{.PlateNumber}} has been transferred to your vehicle with MV file number {{.MVFileNumber}}.
+
+If you did not expect this change, please contact your LTO office.
+`
+
+const plateDeactivationTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <h2>Plate Deactivated</h2>
+  <p>Hi {{.OwnerName}},</p>
+  <p>Your plate <strong>{{.PlateNumber}}</strong> has been deactivated effective <strong>{{.EffectiveDate}}</strong>.</p>
+  <p><strong>Reason:</strong> {{.Reason}}</p>
+  <p>If you believe this is a mistake, please contact your LTO office.</p>
+</body>
+</html>`
+
+const plateDeactivationText = `Plate Deactivated
+
+Hi {{.OwnerName}},
+
+Your plate {{.PlateNumber}} has been deactivated effective {{.EffectiveDate}}.
+
+Reason: {{.Reason}}
+
+If you believe this is a mistake, please contact your LTO office.
+`
+
+const registrationApprovalTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <h2>Registration Approved</h2>
+  <p>Hi {{.OwnerName}},</p>
+  <p>Your registration for the vehicle with MV file number <strong>{{.MVFileNumber}}</strong> has been approved.</p>
+  <p>Your plate number is <strong>{{.PlateNumber}}</strong>, valid until <strong>{{.ExpiryDate}}</strong>.</p>
+  <p><a href="{{.CertificateURL}}" style="background:#1d4ed8;color:#fff;padding:10px 16px;text-decoration:none;border-radius:4px;">Download Registration Certificate</a></p>
+</body>
+</html>`
+
+const registrationApprovalText = `Registration Approved
+
+Hi {{.OwnerName}},
+
+Your registration for the vehicle with MV file number {{.MVFileNumber}} has been approved.
+Your plate number is {{.PlateNumber}}, valid until {{.ExpiryDate}}.
+
+Download your registration certificate: {{.CertificateURL}}
+`
+
+const registrationRejectionTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <h2>Registration Not Approved</h2>
+  <p>Hi {{.OwnerName}},</p>
+  <p>Your registration for the vehicle with MV file number <strong>{{.MVFileNumber}}</strong> was not approved.</p>
+  <p><strong>Reason:</strong> {{.Reason}}</p>
+  <p>You can correct the issue and submit again.</p>
+  <p><a href="{{.ResubmitURL}}" style="background:#1d4ed8;color:#fff;padding:10px 16px;text-decoration:none;border-radius:4px;">Resubmit Registration</a></p>
+</body>
+</html>`
+
+const registrationRejectionText = `Registration Not Approved
+
+Hi {{.OwnerName}},
+
+Your registration for the vehicle with MV file number {{.MVFileNumber}} was not approved.
+
+Reason: {{.Reason}}
+
+You can correct the issue and submit again: {{.ResubmitURL}}
+`
+
+const newLoginLocationTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <h2>New Sign-In Location</h2>
+  <p>Hi {{.FirstName}},</p>
+  <p>Your SmartPlate account was just signed in to from a new location:</p>
+  <p><strong>{{.Location}}</strong><br>IP address: {{.IPAddress}}<br>Time: {{.Time}}</p>
+  <p>If this was you, there's nothing to do. If not, change your password right away.</p>
+  <p><a href="{{.SettingsURL}}" style="background:#1d4ed8;color:#fff;padding:10px 16px;text-decoration:none;border-radius:4px;">Review Account</a></p>
+</body>
+</html>`
+
+const newLoginLocationText = `New Sign-In Location
+
+Hi {{.FirstName}},
+
+Your SmartPlate account was just signed in to from a new location:
+
+{{.Location}}
+IP address: {{.IPAddress}}
+Time: {{.Time}}
+
+If this was you, there's nothing to do. If not, change your password right away: {{.SettingsURL}}
+`
+
+const scanAlertTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+  <h2>Suspicious Scan of Your Plate</h2>
+  <p>Dear {{.OwnerName}},</p>
+  <p>An LTO officer flagged a scan of your plate <strong>{{.PlateNumber}}</strong> as suspicious.</p>
+  <p>Location: {{.Location}}<br>Time: {{.Time}}</p>
+  <p>If this wasn't your vehicle, your plate may have been copied. Please contact your nearest LTO office.</p>
+</body>
+</html>`
+
+const scanAlertText = `Suspicious Scan of Your Plate
+
+Dear {{.OwnerName}},
+
+An LTO officer flagged a scan of your plate {{.PlateNumber}} as suspicious.
+
+Location: {{.Location}}
+Time: {{.Time}}
+
+If this wasn't your vehicle, your plate may have been copied. Please contact your nearest LTO office.
+`
+
+// generateHTMLEmail renders an HTML template with the given values
+func generateHTMLEmail(tmpl string, data map[string]string) (string, error) {
+	t, err := template.New("email").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parse email template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute email template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// generatePlainTextEmail renders the plain-text counterpart of an HTML template
+func generatePlainTextEmail(tmpl string, data map[string]string) (string, error) {
+	t, err := texttemplate.New("email").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parse text email template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute text email template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// renderEmail renders both parts of an email from the same values
+func renderEmail(htmlTmpl, textTmpl string, data map[string]string) (html, text string, err error) {
+	if html, err = generateHTMLEmail(htmlTmpl, data); err != nil {
+		return "", "", err
+	}
+	if text, err = generatePlainTextEmail(textTmpl, data); err != nil {
+		return "", "", err
+	}
+	return html, text, nil
+}
+
+// sendEmail queues an email for delivery, or just logs it when sending is skipped
+func sendEmail(ctx context.Context, to, subject, htmlBody, textBody string) error {
+	cfg := loadConfig()
+	if skipSending(cfg) {
+		log.Printf("[DEV] simulated email to %s: %s", to, subject)
+		return nil
+	}
+	return Enqueue(ctx, to, subject, htmlBody, textBody)
+}
+
+// buildMessage assembles a multipart/alternative message with the plain-text
+// part first, so clients that can render HTML pick the last part
+func buildMessage(from, to, subject, htmlBody, textBody string) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=\"UTF-8\"", textBody},
+		{"text/html; charset=\"UTF-8\"", htmlBody},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	headers := []string{
+		"From: " + from,
+		"To: " + to,
+		"Subject: " + mime.QEncoding.Encode("UTF-8", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=\"" + mw.Boundary() + "\"",
+	}
+	return append([]byte(strings.Join(headers, "\r\n")+"\r\n\r\n"), body.Bytes()...), nil
+}
+
+// smtpDialTimeout bounds connecting to the SMTP server and smtpSendTimeout
+// the whole exchange, on top of any deadline the caller's context has
+const (
+	smtpDialTimeout = 10 * time.Second
+	smtpSendTimeout = 30 * time.Second
+)
+
+// deliver sends an email over SMTP. It gives up, closing the connection,
+// once ctx is done.
+func deliver(ctx context.Context, cfg Config, to, subject, htmlBody, textBody string) error {
+	msg, err := buildMessage(cfg.From, to, subject, htmlBody, textBody)
+	if err != nil {
+		return fmt.Errorf("build email to %s: %w", to, err)
+	}
+	if err := smtpSend(ctx, cfg, to, msg); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return fmt.Errorf("send email to %s: %w", to, err)
+	}
+	return nil
+}
+
+// dialSMTP opens the connection smtpSend talks over; tests swap in a fake server
+var dialSMTP = (&net.Dialer{Timeout: smtpDialTimeout}).DialContext
+
+// smtpSend does what smtp.SendMail does, over a connection that respects ctx
+func smtpSend(ctx context.Context, cfg Config, to string, msg []byte) error {
+	conn, err := dialSMTP(ctx, "tcp", net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// unblocks any read or write in progress when ctx is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if err := client.Hello("localhost"); err != nil {
+		return err
+	}
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: cfg.SMTPHost}); err != nil {
+			return err
+		}
+	}
+	if ok, _ := client.Extension("AUTH"); ok {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(cfg.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// SendResetEmail sends the password reset link for the given token
+func SendResetEmail(ctx context.Context, recipientEmail, token string) error {
+	cfg := loadConfig()
+	body, text, err := renderEmail(resetPasswordTemplate, resetPasswordText, map[string]string{
+		"ResetURL": cfg.FrontendURL + "/reset-password?token=" + token,
+	})
+	if err != nil {
+		return err
+	}
+	return sendEmail(ctx, recipientEmail, "SmartPlate Password Reset", body, text)
+}
+
+// SendWelcomeEmail greets a newly registered user with their LTO client ID
+func SendWelcomeEmail(recipientEmail, firstName, ltoClientID string) error {
+	cfg := loadConfig()
+	body, text, err := renderEmail(welcomeTemplate, welcomeText, map[string]string{
+		"FirstName":    firstName,
+		"LTOClientID":  ltoClientID,
+		"DashboardURL": cfg.FrontendURL + "/dashboard",
+	})
+	if err != nil {
+		return err
+	}
+	return sendEmail(context.Background(), recipientEmail, "Welcome to SmartPlate", body, text)
+}
+
+// SendInactivityEmail invites a dormant user to sign back in
+func SendInactivityEmail(recipientEmail, firstName string) error {
+	cfg := loadConfig()
+	body, text, err := renderEmail(inactivityTemplate, inactivityText, map[string]string{
+		"FirstName": firstName,
+		"LoginURL":  cfg.FrontendURL + "/login",
+	})
+	if err != nil {
+		return err
+	}
+	return sendEmail(context.Background(), recipientEmail, "We miss you at SmartPlate", body, text)
+}
+
+// SendPlateRenewalConfirmation tells the owner their plate was renewed
+func SendPlateRenewalConfirmation(recipientEmail, ownerName, plateNumber string, newExpiry time.Time) error {
+	body, text, err := renderEmail(plateRenewalTemplate, plateRenewalText, map[string]string{
+		"OwnerName":   ownerName,
+		"PlateNumber": plateNumber,
+		"ExpiryDate":  newExpiry.Format("January 2, 2006"),
+	})
+	if err != nil {
+		return err
+	}
+	return sendEmail(context.Background(), recipientEmail, "SmartPlate Plate Renewal Confirmation", body, text)
+}
+
+// SendPlateExpiryNotification warns the owner that their plate is about to
+// expire, or that it already has when expiryDate is in the past
+func SendPlateExpiryNotification(recipientEmail, ownerName, plateNumber string, expiryDate time.Time, renewalURL string) error {
+	subject, body, text, err := plateExpiryEmail(ownerName, plateNumber, expiryDate, renewalURL, time.Now())
+	if err != nil {
+		return err
+	}
+	return sendEmail(context.Background(), recipientEmail, subject, body, text)
+}
+
+// plateExpiryEmail renders the expiry notice as of now
+func plateExpiryEmail(ownerName, plateNumber string, expiryDate time.Time, renewalURL string, now time.Time) (subject, body, text string, err error) {
+	remaining := expiryDate.Sub(now)
+	// round up so a plate expiring tomorrow afternoon reads "1 day", not 0
+	days := int(math.Ceil(remaining.Hours() / 24))
+	expired := ""
+	subject = "SmartPlate Plate Expiry Notice"
+	if remaining <= 0 {
+		expired = "true"
+		subject = "SmartPlate Plate Already Expired"
+	}
+	body, text, err = renderEmail(plateExpiryTemplate, plateExpiryText, map[string]string{
+		"OwnerName":       ownerName,
+		"PlateNumber":     plateNumber,
+		"DaysUntilExpiry": strconv.Itoa(days),
+		"ExpiryDate":      expiryDate.Format("January 2, 2006"),
+		"RenewalURL":      renewalURL,
+		"Expired":         expired,
+	})
+	return subject, body, text, err
+}
+
+// SendPlateTransferNotification tells the receiving vehicle's owner a plate was moved to it
+func SendPlateTransferNotification(recipientEmail, ownerName, plateNumber, mvFileNumber string) error {
+	body, text, err := renderEmail(plateTransferTemplate, plateTransferText, map[string]string{
+		"OwnerName":    ownerName,
+		"PlateNumber":  plateNumber,
+		"MVFileNumber": mvFileNumber,
+	})
+	if err != nil {
+		return err
+	}
+	return sendEmail(context.Background(), recipientEmail, "SmartPlate Plate Transfer", body, text)
+}
+
+// SendPlateDeactivationNotification confirms to the owner that their plate was deactivated
+func SendPlateDeactivationNotification(recipientEmail, ownerName, plateNumber, reason string, effectiveDate time.Time) error {
+	body, text, err := renderEmail(plateDeactivationTemplate, plateDeactivationText, map[string]string{
+		"OwnerName":     ownerName,
+		"PlateNumber":   plateNumber,
+		"Reason":        reason,
+		"EffectiveDate": effectiveDate.Format("January 2, 2006"),
+	})
+	if err != nil {
+		return err
+	}
+	return sendEmail(context.Background(), recipientEmail, "SmartPlate Plate Deactivated", body, text)
+}
+
+// SendRegistrationApprovalEmail tells the applicant their registration was approved
+func SendRegistrationApprovalEmail(to, ownerName, mvFileNumber, plateNumber string, expiryDate time.Time) error {
+	cfg := loadConfig()
+	body, text, err := renderEmail(registrationApprovalTemplate, registrationApprovalText, map[string]string{
+		"OwnerName":      ownerName,
+		"MVFileNumber":   mvFileNumber,
+		"PlateNumber":    plateNumber,
+		"ExpiryDate":     expiryDate.Format("January 2, 2006"),
+		"CertificateURL": cfg.FrontendURL + "/registrations/" + url.PathEscape(mvFileNumber) + "/certificate",
+	})
+	if err != nil {
+		return err
+	}
+	return sendEmail(context.Background(), to, "SmartPlate Registration Approved", body, text)
+}
+
+// SendRegistrationRejectionEmail tells the applicant why their registration was rejected
+func SendRegistrationRejectionEmail(to, ownerName, mvFileNumber, reason string) error {
+	cfg := loadConfig()
+	body, text, err := renderEmail(registrationRejectionTemplate, registrationRejectionText, map[string]string{
+		"OwnerName":    ownerName,
+		"MVFileNumber": mvFileNumber,
+		"Reason":       reason,
+		"ResubmitURL":  cfg.FrontendURL + "/registrations/new",
+	})
+	if err != nil {
+		return err
+	}
+	return sendEmail(context.Background(), to, "SmartPlate Registration Update", body, text)
+}
+
+// SendNewLoginLocationAlert warns a user that their account was signed in to
+// from a country it hasn't been used from before
+func SendNewLoginLocationAlert(recipientEmail, firstName, location, ipAddress string, at time.Time) error {
+	cfg := loadConfig()
+	body, text, err := renderEmail(newLoginLocationTemplate, newLoginLocationText, map[string]string{
+		"FirstName":   firstName,
+		"Location":    location,
+		"IPAddress":   ipAddress,
+		"Time":        at.Format("January 2, 2006 3:04 PM MST"),
+		"SettingsURL": cfg.FrontendURL + "/account-settings",
+	})
+	if err != nil {
+		return err
+	}
+	return sendEmail(context.Background(), recipientEmail, "New sign-in to your SmartPlate account", body, text)
+}
+
+// SendScanAlertEmail tells the owner an officer flagged a scan of their plate
+// as suspicious
+func SendScanAlertEmail(recipientEmail, ownerName, plateNumber, location string, at time.Time) error {
+	if location == "" {
+		location = "unknown"
+	}
+	body, text, err := renderEmail(scanAlertTemplate, scanAlertText, map[string]string{
+		"OwnerName":   ownerName,
+		"PlateNumber": plateNumber,
+		"Location":    location,
+		"Time":        at.Format("January 2, 2006 3:04 PM MST"),
+	})
+	if err != nil {
+		return err
+	}
+	return sendEmail(context.Background(), recipientEmail, "SmartPlate Suspicious Scan Alert", body, text)
+}
+
+// smtpCheckTimeout bounds both the dial and the EHLO exchange in TestSMTPConnection
+const smtpCheckTimeout = 3 * time.Second
+
+// TestSMTPConnection dials the configured SMTP server and exchanges EHLO
+// without sending anything. It returns nil when sending is disabled.
+func TestSMTPConnection() error {
+	cfg := loadConfig()
+	if skipSending(cfg) {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort), smtpCheckTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(smtpCheckTimeout))
+
+	client, err := smtp.NewClient(conn, cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if err := client.Hello("localhost"); err != nil {
+		return err
+	}
+	return client.Quit()
+}
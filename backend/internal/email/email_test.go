@@ -0,0 +1,241 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureLog returns the standard logger's output for the rest of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestSendWelcomeEmailSkipped(t *testing.T) {
+	t.Setenv("SKIP_EMAIL_SENDING", "true")
+	logs := captureLog(t)
+
+	if err := SendWelcomeEmail("juan@example.com", "Juan", "LTO-0001"); err != nil {
+		t.Fatalf("SendWelcomeEmail: %v", err)
+	}
+	if !strings.Contains(logs.String(), "juan@example.com") {
+		t.Fatalf("log %q doesn't mention the recipient", logs.String())
+	}
+}
+
+func TestWelcomeTemplate(t *testing.T) {
+	html, text, err := renderEmail(welcomeTemplate, welcomeText, map[string]string{
+		"FirstName":    "Juan",
+		"LTOClientID":  "LTO-0001",
+		"DashboardURL": "https://smartplate.example/dashboard",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Hi Juan", "LTO-0001", "https://smartplate.example/dashboard", "never share it"} {
+		if !strings.Contains(html, want) || !strings.Contains(text, want) {
+			t.Errorf("welcome email is missing %q", want)
+		}
+	}
+}
+
+func TestPlateExpiryEmail(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		expiry      time.Time
+		wantSubject string
+		want        []string
+		notWant     []string
+	}{
+		{
+			"expires in ten days", now.AddDate(0, 0, 10),
+			"SmartPlate Plate Expiry Notice",
+			[]string{"expires in", "10 day(s)", "March 20, 2026", "https://smartplate.example/renew"},
+			[]string{"already expired"},
+		},
+		{
+			"expires later today", now.Add(3 * time.Hour),
+			"SmartPlate Plate Expiry Notice",
+			[]string{"1 day(s)"},
+			[]string{"already expired"},
+		},
+		{
+			"expires right now", now,
+			"SmartPlate Plate Already Expired",
+			[]string{"already expired", "March 10, 2026", "https://smartplate.example/renew"},
+			[]string{"expires in"},
+		},
+		{
+			"expired last week", now.AddDate(0, 0, -7),
+			"SmartPlate Plate Already Expired",
+			[]string{"already expired", "March 3, 2026", "Your Plate Has Expired"},
+			[]string{"expires in", "day(s)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, html, text, err := plateExpiryEmail("Juan", "ABC 12344", tt.expiry, "https://smartplate.example/renew", now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", subject, tt.wantSubject)
+			}
+			for name, body := range map[string]string{"html": html, "text": text} {
+				for _, want := range tt.want {
+					if !strings.Contains(body, want) {
+						t.Errorf("%s body is missing %q", name, want)
+					}
+				}
+				for _, bad := range tt.notWant {
+					if strings.Contains(body, bad) {
+						t.Errorf("%s body unexpectedly contains %q", name, bad)
+					}
+				}
+			}
+		})
+	}
+}
+
+// emailTemplates lists every HTML/plain-text template pair with the values
+// its Send function supplies. hidden keys only steer the template and
+// don't show up in the output.
+var emailTemplates = []struct {
+	name   string
+	html   string
+	text   string
+	data   map[string]string
+	hidden []string
+}{
+	{"reset password", resetPasswordTemplate, resetPasswordText, map[string]string{
+		"ResetURL": "https://smartplate.example/reset-password?token=t0k3n",
+	}, nil},
+	{"welcome", welcomeTemplate, welcomeText, map[string]string{
+		"FirstName": "Juan", "LTOClientID": "LTO-0001", "DashboardURL": "https://smartplate.example/dashboard",
+	}, nil},
+	{"inactivity", inactivityTemplate, inactivityText, map[string]string{
+		"FirstName": "Juan", "LoginURL": "https://smartplate.example/login",
+	}, nil},
+	{"plate renewal", plateRenewalTemplate, plateRenewalText, map[string]string{
+		"OwnerName": "Juan Dela Cruz", "PlateNumber": "ABC 12344", "ExpiryDate": "January 15, 2030",
+	}, nil},
+	{"plate expiry", plateExpiryTemplate, plateExpiryText, map[string]string{
+		"OwnerName": "Juan Dela Cruz", "PlateNumber": "ABC 12344", "DaysUntilExpiry": "12",
+		"ExpiryDate": "January 15, 2027", "RenewalURL": "https://smartplate.example/renew", "Expired": "",
+	}, []string{"Expired"}},
+	{"plate transfer", plateTransferTemplate, plateTransferText, map[string]string{
+		"OwnerName": "Juan Dela Cruz", "PlateNumber": "ABC 12344", "MVFileNumber": "1301-00000123456",
+	}, nil},
+	{"plate deactivation", plateDeactivationTemplate, plateDeactivationText, map[string]string{
+		"OwnerName": "Juan Dela Cruz", "PlateNumber": "ABC 12344", "Reason": "Reported stolen", "EffectiveDate": "March 1, 2026",
+	}, nil},
+	{"registration approval", registrationApprovalTemplate, registrationApprovalText, map[string]string{
+		"OwnerName": "Juan Dela Cruz", "MVFileNumber": "1301-00000123456", "PlateNumber": "ABC 12344",
+		"ExpiryDate": "January 15, 2029", "CertificateURL": "https://smartplate.example/registrations/1301-00000123456/certificate",
+	}, nil},
+	{"registration rejection", registrationRejectionTemplate, registrationRejectionText, map[string]string{
+		"OwnerName": "Juan Dela Cruz", "MVFileNumber": "1301-00000123456", "Reason": "Blurry OR/CR scan",
+		"ResubmitURL": "https://smartplate.example/registrations/new",
+	}, nil},
+	{"new login location", newLoginLocationTemplate, newLoginLocationText, map[string]string{
+		"FirstName": "Juan", "Location": "Cebu, PH", "IPAddress": "203.0.113.7",
+		"Time": "March 1, 2026 9:00 AM PST", "SettingsURL": "https://smartplate.example/account-settings",
+	}, nil},
+	{"scan alert", scanAlertTemplate, scanAlertText, map[string]string{
+		"OwnerName": "Juan Dela Cruz", "PlateNumber": "ABC 1234", "Location": "14.59951, 120.98422",
+		"Time": "March 1, 2026 9:00 AM PST",
+	}, nil},
+}
+
+func TestEmailTemplatesRenderEveryValue(t *testing.T) {
+	for _, tt := range emailTemplates {
+		t.Run(tt.name, func(t *testing.T) {
+			html, text, err := renderEmail(tt.html, tt.text, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, val := range tt.data {
+				if slices.Contains(tt.hidden, key) {
+					continue
+				}
+				// html/template escapes some characters, so compare escaped
+				if !strings.Contains(html, template.HTMLEscapeString(val)) {
+					t.Errorf("html part doesn't show %s (%q)", key, val)
+				}
+				if !strings.Contains(text, val) {
+					t.Errorf("text part doesn't show %s (%q)", key, val)
+				}
+			}
+		})
+	}
+}
+
+func TestEmailTemplatesRejectMissingValues(t *testing.T) {
+	for _, tt := range emailTemplates {
+		for key := range tt.data {
+			t.Run(tt.name+"/without "+key, func(t *testing.T) {
+				data := maps.Clone(tt.data)
+				delete(data, key)
+				if _, err := generateHTMLEmail(tt.html, data); err == nil {
+					t.Errorf("html template rendered without %s", key)
+				}
+				if _, err := generatePlainTextEmail(tt.text, data); err == nil {
+					t.Errorf("text template rendered without %s", key)
+				}
+			})
+		}
+	}
+}
+
+// TestSendFunctionsSupplyEveryValue runs each Send function in dev mode, where
+// a template variable the function forgot to fill in fails the render
+func TestSendFunctionsSupplyEveryValue(t *testing.T) {
+	t.Setenv("SKIP_EMAIL_SENDING", "true")
+	captureLog(t)
+	when := time.Date(2027, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		send func() error
+	}{
+		{"reset", func() error { return SendResetEmail(context.Background(), "a@example.com", "t0k3n") }},
+		{"welcome", func() error { return SendWelcomeEmail("a@example.com", "Juan", "LTO-0001") }},
+		{"inactivity", func() error { return SendInactivityEmail("a@example.com", "Juan") }},
+		{"renewal", func() error { return SendPlateRenewalConfirmation("a@example.com", "Juan", "ABC 12344", when) }},
+		{"expiry", func() error {
+			return SendPlateExpiryNotification("a@example.com", "Juan", "ABC 12344", when, "https://x/renew")
+		}},
+		{"transfer", func() error { return SendPlateTransferNotification("a@example.com", "Juan", "ABC 12344", "1301-1") }},
+		{"deactivation", func() error {
+			return SendPlateDeactivationNotification("a@example.com", "Juan", "ABC 12344", "stolen", when)
+		}},
+		{"approval", func() error {
+			return SendRegistrationApprovalEmail("a@example.com", "Juan", "1301-1", "ABC 12344", when)
+		}},
+		{"rejection", func() error { return SendRegistrationRejectionEmail("a@example.com", "Juan", "1301-1", "blurry scan") }},
+		{"new login location", func() error {
+			return SendNewLoginLocationAlert("a@example.com", "Juan", "Cebu, PH", "203.0.113.7", when)
+		}},
+		{"scan alert", func() error { return SendScanAlertEmail("a@example.com", "Juan", "ABC 12344", "", when) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.send(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
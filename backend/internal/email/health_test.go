@@ -0,0 +1,96 @@
+package email
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// listenSMTP serves fakeSMTP on a local port and reports each connection
+// the server has finished with on the returned channel
+func listenSMTP(t *testing.T, greeting string) (host, port string, closed <-chan struct{}) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	done := make(chan struct{}, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { done <- struct{}{} }()
+				if greeting != "" {
+					conn.Write([]byte(greeting + "\r\n"))
+					conn.Close()
+					return
+				}
+				(&fakeSMTP{}).serve(conn)
+			}()
+		}
+	}()
+	host, port, _ = net.SplitHostPort(ln.Addr().String())
+	return host, port, done
+}
+
+func TestSMTPConnectionCheck(t *testing.T) {
+	t.Run("server answers EHLO", func(t *testing.T) {
+		host, port, closed := listenSMTP(t, "")
+		t.Setenv("SKIP_EMAIL_SENDING", "")
+		t.Setenv("SMTP_HOST", host)
+		t.Setenv("SMTP_PORT", port)
+		t.Setenv("SMTP_USERNAME", "smartplate")
+
+		if err := TestSMTPConnection(); err != nil {
+			t.Fatalf("TestSMTPConnection: %v", err)
+		}
+		select {
+		case <-closed:
+		case <-time.After(time.Second):
+			t.Fatal("the check left its SMTP connection open")
+		}
+	})
+
+	t.Run("server refuses service", func(t *testing.T) {
+		host, port, _ := listenSMTP(t, "554 no SMTP service here")
+		t.Setenv("SKIP_EMAIL_SENDING", "")
+		t.Setenv("SMTP_HOST", host)
+		t.Setenv("SMTP_PORT", port)
+		t.Setenv("SMTP_USERNAME", "smartplate")
+
+		if err := TestSMTPConnection(); err == nil {
+			t.Fatal("want an error for a 554 greeting")
+		}
+	})
+
+	t.Run("nothing listening", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		host, port, _ := net.SplitHostPort(ln.Addr().String())
+		ln.Close()
+		t.Setenv("SKIP_EMAIL_SENDING", "")
+		t.Setenv("SMTP_HOST", host)
+		t.Setenv("SMTP_PORT", port)
+		t.Setenv("SMTP_USERNAME", "smartplate")
+
+		if err := TestSMTPConnection(); err == nil {
+			t.Fatal("want an error when the server is down")
+		}
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		t.Setenv("SKIP_EMAIL_SENDING", "")
+		t.Setenv("SMTP_HOST", "")
+		t.Setenv("SMTP_USERNAME", "")
+		if err := TestSMTPConnection(); err != nil {
+			t.Fatalf("dev mode should pass without dialing: %v", err)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package email
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// parseAlternative splits a raw message into its headers and its parts' bodies, keyed by media type
+func parseAlternative(t *testing.T, raw string) (mail.Header, map[string]string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("parse Content-Type: %v", err)
+	}
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %s, want multipart/alternative", mediaType)
+	}
+
+	parts := map[string]string{}
+	var order []string
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		partType, partParams, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatalf("parse part Content-Type: %v", err)
+		}
+		if partParams["charset"] != "UTF-8" {
+			t.Errorf("%s part charset = %q, want UTF-8", partType, partParams["charset"])
+		}
+		// multipart.Reader undoes the quoted-printable encoding
+		body, err := io.ReadAll(p)
+		if err != nil {
+			t.Fatalf("read %s part: %v", partType, err)
+		}
+		parts[partType] = string(body)
+		order = append(order, partType)
+	}
+	if strings.Join(order, ",") != "text/plain,text/html" {
+		t.Fatalf("parts are %v, want text/plain then text/html", order)
+	}
+	return msg.Header, parts
+}
+
+func TestBuildMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		html    string
+		text    string
+	}{
+		{"plain ascii", "SmartPlate Password Reset", "<p>Reset it</p>", "Reset it"},
+		{"non-ascii", "Maligayang pagdating, Niño", "<p>Hi Niño ñ é</p>", "Hi Niño ñ é"},
+		{"long lines and equals signs", "Renewal", "<a href=\"https://x/?a=1&b=2\">" + strings.Repeat("x", 200) + "</a>", "a=1 " + strings.Repeat("y", 200)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := buildMessage("noreply@smartplate.example", "juan@example.com", tt.subject, tt.html, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			header, parts := parseAlternative(t, string(raw))
+
+			subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+			if err != nil || subject != tt.subject {
+				t.Errorf("Subject = %q (%v), want %q", subject, err, tt.subject)
+			}
+			if header.Get("MIME-Version") != "1.0" || header.Get("To") != "juan@example.com" {
+				t.Errorf("headers = %v", header)
+			}
+			if parts["text/plain"] != tt.text {
+				t.Errorf("text part = %q, want %q", parts["text/plain"], tt.text)
+			}
+			if parts["text/html"] != tt.html {
+				t.Errorf("html part = %q, want %q", parts["text/html"], tt.html)
+			}
+		})
+	}
+}
+
+func TestDeliverSendsBothParts(t *testing.T) {
+	server := useFakeSMTP(t)
+	html, text, err := renderEmail(resetPasswordTemplate, resetPasswordText, map[string]string{
+		"ResetURL": "https://smartplate.example/reset-password?token=t0k3n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := deliver(context.Background(), loadConfig(), "juan@example.com", "SmartPlate Password Reset", html, text); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := server.sent()
+	if len(msgs) != 1 {
+		t.Fatalf("server got %d messages", len(msgs))
+	}
+	_, parts := parseAlternative(t, msgs[0].data)
+	if parts["text/plain"] != text || parts["text/html"] != html {
+		t.Fatalf("delivered parts don't match what was rendered: %q", parts)
+	}
+}
@@ -0,0 +1,99 @@
+package email
+
+import (
+	"context"
+	"log/slog"
+	"smartplate-api/internal/models"
+	"time"
+)
+
+const (
+	// workerInterval is how often EmailWorker polls the queue
+	workerInterval = 30 * time.Second
+	// maxAttempts is how many times an email is tried before it stays Failed
+	maxAttempts = 3
+	workerBatch = 50
+)
+
+// Queue persists outgoing emails; repository.EmailQueueRepository implements it
+type Queue interface {
+	Enqueue(ctx context.Context, to, subject, htmlBody, textBody string) error
+	Due(ctx context.Context, maxAttempts, limit int) ([]models.EmailQueueItem, error)
+	MarkSent(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id, errMsg string) error
+}
+
+var queue Queue
+
+// UseQueue routes every send through q; call once at startup before serving
+func UseQueue(q Queue) {
+	queue = q
+}
+
+// Enqueue stores an email for the worker to deliver, or delivers it right
+// away when no queue is configured
+func Enqueue(ctx context.Context, to, subject, htmlBody, textBody string) error {
+	if queue == nil {
+		ctx, cancel := context.WithTimeout(ctx, smtpSendTimeout)
+		defer cancel()
+		return deliver(ctx, loadConfig(), to, subject, htmlBody, textBody)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return queue.Enqueue(ctx, to, subject, htmlBody, textBody)
+}
+
+// EmailWorker delivers queued emails, retrying failures up to maxAttempts times
+type EmailWorker struct {
+	queue  Queue
+	logger *slog.Logger
+	send   func(ctx context.Context, to, subject, htmlBody, textBody string) error
+}
+
+// NewEmailWorker creates a worker that sends over the configured SMTP server
+func NewEmailWorker(q Queue, logger *slog.Logger) *EmailWorker {
+	return &EmailWorker{
+		queue:  q,
+		logger: logger,
+		send: func(ctx context.Context, to, subject, htmlBody, textBody string) error {
+			ctx, cancel := context.WithTimeout(ctx, smtpSendTimeout)
+			defer cancel()
+			return deliver(ctx, loadConfig(), to, subject, htmlBody, textBody)
+		},
+	}
+}
+
+// Run polls the queue every 30 seconds until ctx is cancelled
+func (w *EmailWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(workerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.process(ctx)
+		}
+	}
+}
+
+// process attempts one batch of due emails
+func (w *EmailWorker) process(ctx context.Context) {
+	items, err := w.queue.Due(ctx, maxAttempts, workerBatch)
+	if err != nil {
+		w.logger.Error("email queue poll failed", "error", err)
+		return
+	}
+	for _, it := range items {
+		if err := w.send(ctx, it.ToEmail, it.Subject, it.HTMLBody, it.TextBody); err != nil {
+			w.logger.Warn("email delivery failed", "id", it.ID, "to", it.ToEmail, "attempt", it.Attempts+1, "error", err)
+			if err := w.queue.MarkFailed(ctx, it.ID, err.Error()); err != nil {
+				w.logger.Error("email queue update failed", "id", it.ID, "error", err)
+			}
+			continue
+		}
+		if err := w.queue.MarkSent(ctx, it.ID); err != nil {
+			w.logger.Error("email queue update failed", "id", it.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+
+	"smartplate-api/internal/models"
+)
+
+// fakeQueue hands out a fixed batch and records what happened to each item
+type fakeQueue struct {
+	due         []models.EmailQueueItem
+	maxAttempts int
+	enqueued    []string // recipients
+	sent        []string // ids
+	failed      map[string]string
+}
+
+func (q *fakeQueue) Enqueue(ctx context.Context, to, subject, htmlBody, textBody string) error {
+	q.enqueued = append(q.enqueued, to)
+	return nil
+}
+
+func (q *fakeQueue) Due(ctx context.Context, maxAttempts, limit int) ([]models.EmailQueueItem, error) {
+	q.maxAttempts = maxAttempts
+	return q.due, nil
+}
+
+func (q *fakeQueue) MarkSent(ctx context.Context, id string) error {
+	q.sent = append(q.sent, id)
+	return nil
+}
+
+func (q *fakeQueue) MarkFailed(ctx context.Context, id, errMsg string) error {
+	if q.failed == nil {
+		q.failed = map[string]string{}
+	}
+	q.failed[id] = errMsg
+	return nil
+}
+
+func TestEmailWorkerProcess(t *testing.T) {
+	server := useFakeSMTP(t)
+	q := &fakeQueue{due: []models.EmailQueueItem{
+		{ID: "1", ToEmail: "juan@example.com", Subject: "Hello", HTMLBody: "<p>hi</p>", TextBody: "hi"},
+		{ID: "2", ToEmail: "bounce@example.com", Subject: "Hello", HTMLBody: "<p>hi</p>", TextBody: "hi", Attempts: 1},
+		{ID: "3", ToEmail: "maria@example.com", Subject: "Hello again", HTMLBody: "<p>hey</p>", TextBody: "hey", Attempts: 2},
+	}}
+
+	NewEmailWorker(q, slog.New(slog.NewTextHandler(io.Discard, nil))).process(context.Background())
+
+	if q.maxAttempts != maxAttempts {
+		t.Errorf("polled for items under %d attempts, want %d", q.maxAttempts, maxAttempts)
+	}
+	if strings.Join(q.sent, ",") != "1,3" {
+		t.Errorf("marked sent: %v, want [1 3]", q.sent)
+	}
+	if msg, ok := q.failed["2"]; !ok || !strings.Contains(msg, "550") {
+		t.Errorf("bounce wasn't marked failed with the server's reply: %v", q.failed)
+	}
+	msgs := server.sent()
+	if len(msgs) != 2 || msgs[0].to[0] != "juan@example.com" || msgs[1].to[0] != "maria@example.com" {
+		t.Fatalf("server got %+v", msgs)
+	}
+	if msgs[0].from != "noreply@smartplate.example" {
+		t.Errorf("sent from %q", msgs[0].from)
+	}
+}
+
+func TestEmailWorkerMarksFailureWhenServerIsDown(t *testing.T) {
+	useFakeSMTP(t)
+	dialSMTP = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+	q := &fakeQueue{due: []models.EmailQueueItem{{ID: "1", ToEmail: "juan@example.com", Subject: "Hello"}}}
+
+	NewEmailWorker(q, slog.New(slog.NewTextHandler(io.Discard, nil))).process(context.Background())
+
+	if len(q.sent) != 0 || !strings.Contains(q.failed["1"], "connection refused") {
+		t.Fatalf("sent %v, failed %v", q.sent, q.failed)
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	server := useFakeSMTP(t)
+	t.Cleanup(func() { UseQueue(nil) })
+
+	// without a queue the email goes straight out
+	UseQueue(nil)
+	if err := Enqueue(context.Background(), "juan@example.com", "Direct", "<p>hi</p>", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if len(server.sent()) != 1 {
+		t.Fatalf("direct send delivered %d messages", len(server.sent()))
+	}
+
+	// with one it is only stored
+	q := &fakeQueue{}
+	UseQueue(q)
+	if err := Enqueue(context.Background(), "maria@example.com", "Queued", "<p>hi</p>", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.enqueued) != 1 || q.enqueued[0] != "maria@example.com" || len(server.sent()) != 1 {
+		t.Fatalf("queued %v, delivered %d", q.enqueued, len(server.sent()))
+	}
+}
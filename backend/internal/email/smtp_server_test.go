@@ -0,0 +1,108 @@
+package email
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSMTP is just enough of an SMTP server for net/smtp: no STARTTLS, no
+// AUTH, and it rejects recipients whose address contains "bounce"
+type fakeSMTP struct {
+	mu       sync.Mutex
+	messages []fakeMail
+}
+
+// fakeMail is one message the fake server accepted
+type fakeMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// useFakeSMTP points sending at a fresh fakeSMTP for the rest of the test
+func useFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	t.Setenv("SKIP_EMAIL_SENDING", "")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", "587")
+	t.Setenv("SMTP_USERNAME", "smartplate")
+	t.Setenv("SMTP_FROM", "noreply@smartplate.example")
+
+	s := &fakeSMTP{}
+	orig := dialSMTP
+	dialSMTP = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go s.serve(server)
+		return client, nil
+	}
+	t.Cleanup(func() { dialSMTP = orig })
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	var mail fakeMail
+	if tp.PrintfLine("220 fake ESMTP ready") != nil {
+		return
+	}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-fake greets you")
+			tp.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			mail = fakeMail{from: addrArg(line)}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			to := addrArg(line)
+			if strings.Contains(to, "bounce") {
+				tp.PrintfLine("550 no such user: %s", to)
+				continue
+			}
+			mail.to = append(mail.to, to)
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, mail)
+			s.mu.Unlock()
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+// addrArg pulls the address out of "MAIL FROM:<a@b> ..." or "RCPT TO:<a@b>"
+func addrArg(line string) string {
+	start, end := strings.IndexByte(line, '<'), strings.IndexByte(line, '>')
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+// sent returns the messages accepted so far
+func (s *fakeSMTP) sent() []fakeMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeMail(nil), s.messages...)
+}
@@ -0,0 +1,43 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+)
+
+// mxLookupTimeout bounds the DNS query made by ValidateEmailAddress
+const mxLookupTimeout = 3 * time.Second
+
+// lookupMX resolves a domain's mail servers; tests replace it
+var lookupMX = net.DefaultResolver.LookupMX
+
+// ValidateEmailAddress checks that addr parses and that its domain publishes
+// MX records, so we don't send mail that is certain to bounce. The DNS lookup
+// is skipped when APP_ENV=test.
+func ValidateEmailAddress(addr string) error {
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil {
+		return fmt.Errorf("invalid email address: %w", err)
+	}
+	domain := parsed.Address[strings.LastIndex(parsed.Address, "@")+1:]
+	if os.Getenv("APP_ENV") == "test" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mxLookupTimeout)
+	defer cancel()
+	records, err := lookupMX(ctx, domain)
+	if err != nil {
+		return fmt.Errorf("lookup mx for %s: %w", domain, err)
+	}
+	if len(records) == 0 {
+		return errors.New("no mail server for " + domain)
+	}
+	return nil
+}
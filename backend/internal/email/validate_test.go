@@ -0,0 +1,75 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestValidateEmailAddress(t *testing.T) {
+	// stand-in DNS: example.com has a mail server, nomx.example has none and
+	// anything else doesn't exist
+	lookups := 0
+	orig := lookupMX
+	lookupMX = func(ctx context.Context, domain string) ([]*net.MX, error) {
+		lookups++
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("MX lookup for %s has no timeout", domain)
+		}
+		switch domain {
+		case "example.com":
+			return []*net.MX{{Host: "mx.example.com.", Pref: 10}}, nil
+		case "nomx.example":
+			return nil, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: domain, IsNotFound: true}
+	}
+	t.Cleanup(func() { lookupMX = orig })
+
+	tests := []struct {
+		name        string
+		appEnv      string
+		addr        string
+		wantErr     bool
+		wantLookups int
+	}{
+		{"valid", "", "juan@example.com", false, 1},
+		{"valid with display name", "", "Juan Dela Cruz <juan@example.com>", false, 1},
+		{"domain without mx", "", "juan@nomx.example", true, 1},
+		{"unknown domain", "", "juan@does-not-exist.example", true, 1},
+		{"missing at sign", "", "juan.example.com", true, 0},
+		{"missing local part", "", "@example.com", true, 0},
+		{"empty", "", "", true, 0},
+		{"spaces", "", "juan dela cruz@example.com", true, 0},
+		{"test env skips lookup", "test", "juan@does-not-exist.example", false, 0},
+		{"test env still parses", "test", "not an address", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APP_ENV", tt.appEnv)
+			lookups = 0
+			err := ValidateEmailAddress(tt.addr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateEmailAddress(%q) = %v, wantErr %v", tt.addr, err, tt.wantErr)
+			}
+			if lookups != tt.wantLookups {
+				t.Fatalf("made %d MX lookups, want %d", lookups, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestValidateEmailAddressWrapsLookupErrors(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+	dnsErr := &net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true}
+	orig := lookupMX
+	lookupMX = func(ctx context.Context, domain string) ([]*net.MX, error) { return nil, dnsErr }
+	t.Cleanup(func() { lookupMX = orig })
+
+	var got *net.DNSError
+	if err := ValidateEmailAddress("juan@example.com"); !errors.As(err, &got) || got != dnsErr {
+		t.Fatalf("err = %v, want it to wrap the DNS error", err)
+	}
+}
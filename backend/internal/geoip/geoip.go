@@ -0,0 +1,53 @@
+// Package geoip locates IP addresses with a MaxMind GeoLite2 City database.
+package geoip
+
+import (
+    "fmt"
+    "net"
+    "os"
+
+    "github.com/oschwald/geoip2-golang"
+)
+
+// Locator answers country and city lookups from an open database
+type Locator struct {
+    db *geoip2.Reader
+}
+
+// Open loads the GeoLite2 (or GeoIP2) City database at path
+func Open(path string) (*Locator, error) {
+    db, err := geoip2.Open(path)
+    if err != nil {
+        return nil, fmt.Errorf("open geoip database: %w", err)
+    }
+    return &Locator{db: db}, nil
+}
+
+// FromEnv opens the database at GEOIP_DB_PATH, or returns nil when it is
+// unset
+func FromEnv() (*Locator, error) {
+    path := os.Getenv("GEOIP_DB_PATH")
+    if path == "" {
+        return nil, nil
+    }
+    return Open(path)
+}
+
+// Locate returns the ISO country code and English city name for ip. Either
+// is empty when the address is unparseable, private or not in the database.
+func (l *Locator) Locate(ip string) (country, city string) {
+    addr := net.ParseIP(ip)
+    if addr == nil {
+        return "", ""
+    }
+    rec, err := l.db.City(addr)
+    if err != nil {
+        return "", ""
+    }
+    return rec.Country.IsoCode, rec.City.Names["en"]
+}
+
+// Close releases the database
+func (l *Locator) Close() error {
+    return l.db.Close()
+}
@@ -0,0 +1,108 @@
+package geoip
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+// The helpers below encode just enough of the MaxMind DB format
+// (https://maxmind.github.io/MaxMind-DB/) to build a one-node City database.
+
+func mmdbString(s string) []byte {
+    return append([]byte{0x40 | byte(len(s))}, s...)
+}
+
+func mmdbUint16(v byte) []byte {
+    return []byte{0xa1, v}
+}
+
+// mmdbMap encodes pairs of already encoded keys and values
+func mmdbMap(pairs ...[]byte) []byte {
+    out := []byte{0xe0 | byte(len(pairs)/2)}
+    for _, p := range pairs {
+        out = append(out, p...)
+    }
+    return out
+}
+
+// writeCityDB writes an IPv4 City database in which 0.0.0.0/1 is London, GB
+// and 128.0.0.0/1 is unknown
+func writeCityDB(t *testing.T) string {
+    t.Helper()
+    const nodeCount = 1
+    // 24-bit records: left points at the data section's offset 0
+    // (node_count + 16), right is node_count, meaning no data
+    db := []byte{0, 0, nodeCount + 16, 0, 0, nodeCount}
+    db = append(db, make([]byte, 16)...)
+    db = append(db, mmdbMap(
+        mmdbString("country"), mmdbMap(mmdbString("iso_code"), mmdbString("GB")),
+        mmdbString("city"), mmdbMap(mmdbString("names"), mmdbMap(mmdbString("en"), mmdbString("London"))),
+    )...)
+    db = append(db, "\xab\xcd\xefMaxMind.com"...)
+    db = append(db, mmdbMap(
+        mmdbString("node_count"), []byte{0xc1, nodeCount},
+        mmdbString("record_size"), mmdbUint16(24),
+        mmdbString("ip_version"), mmdbUint16(4),
+        mmdbString("database_type"), mmdbString("GeoLite2-City"),
+        mmdbString("languages"), append([]byte{0x01, 0x04}, mmdbString("en")...),
+        mmdbString("binary_format_major_version"), mmdbUint16(2),
+        mmdbString("binary_format_minor_version"), []byte{0xa0},
+        mmdbString("build_epoch"), []byte{0x01, 0x02, 1},
+    )...)
+
+    path := filepath.Join(t.TempDir(), "GeoLite2-City.mmdb")
+    if err := os.WriteFile(path, db, 0o600); err != nil {
+        t.Fatal(err)
+    }
+    return path
+}
+
+func TestLocate(t *testing.T) {
+    loc, err := Open(writeCityDB(t))
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer loc.Close()
+
+    tests := []struct {
+        ip          string
+        wantCountry string
+        wantCity    string
+    }{
+        {"81.2.69.142", "GB", "London"},
+        {"203.0.113.7", "", ""},
+        {"not an ip", "", ""},
+        {"", "", ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.ip, func(t *testing.T) {
+            country, city := loc.Locate(tt.ip)
+            if country != tt.wantCountry || city != tt.wantCity {
+                t.Fatalf("Locate(%q) = %q, %q, want %q, %q", tt.ip, country, city, tt.wantCountry, tt.wantCity)
+            }
+        })
+    }
+}
+
+func TestFromEnv(t *testing.T) {
+    t.Setenv("GEOIP_DB_PATH", "")
+    if loc, err := FromEnv(); loc != nil || err != nil {
+        t.Fatalf("unset: FromEnv() = %v, %v, want nil, nil", loc, err)
+    }
+
+    t.Setenv("GEOIP_DB_PATH", filepath.Join(t.TempDir(), "missing.mmdb"))
+    if _, err := FromEnv(); err == nil {
+        t.Fatal("missing file: FromEnv() succeeded")
+    }
+
+    t.Setenv("GEOIP_DB_PATH", writeCityDB(t))
+    loc, err := FromEnv()
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer loc.Close()
+    if country, _ := loc.Locate("81.2.69.142"); country != "GB" {
+        t.Fatalf("country = %q, want GB", country)
+    }
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+    "context"
+    "net/http"
+    "time"
+
+    "github.com/hashicorp/golang-lru/v2/expirable"
+    "github.com/labstack/echo/v4"
+    "golang.org/x/sync/errgroup"
+
+    "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+)
+
+const (
+    // dashboardTimeout bounds the queries behind one dashboard response
+    dashboardTimeout = 5 * time.Second
+
+    // dashboardTTL is how long each admin is served the same dashboard; the
+    // widget refreshes every 10 seconds, so most refreshes never reach the database
+    dashboardTTL       = 60 * time.Second
+    dashboardCacheSize = 1000
+
+    dashboardExpiryWindow = 30 * 24 * time.Hour
+)
+
+// DashboardStats is the admin dashboard's headline numbers
+type DashboardStats struct {
+    PendingRegistrations int            `json:"pending_registrations" example:"42"`
+    PendingByRegion      map[string]int `json:"pending_by_region"`
+    ExpiringPlates30d    int            `json:"expiring_plates_30d" example:"120"`
+    TotalScansToday      int            `json:"total_scans_today" example:"850"`
+    ActivePlates         int            `json:"active_plates" example:"15000"`
+    GeneratedAt          time.Time      `json:"generated_at"`
+}
+
+// AnalyticsHandler serves the admin dashboard.
+type AnalyticsHandler struct {
+    forms  repository.RegistrationFormRepository
+    plates repository.PlateRepository
+    scans  repository.ScanLogRepository
+    loc    *time.Location // APP_TIMEZONE, which decides when "today" starts
+
+    cache *expirable.LRU[string, DashboardStats] // by the admin's lto_client_id
+}
+
+// NewAnalyticsHandler creates a new AnalyticsHandler.
+func NewAnalyticsHandler(
+    fr repository.RegistrationFormRepository,
+    pr repository.PlateRepository,
+    sr repository.ScanLogRepository,
+    loc *time.Location,
+) *AnalyticsHandler {
+    return &AnalyticsHandler{
+        forms:  fr,
+        plates: pr,
+        scans:  sr,
+        loc:    loc,
+        cache:  expirable.NewLRU[string, DashboardStats](dashboardCacheSize, nil, dashboardTTL),
+    }
+}
+
+// Dashboard returns the counts shown on the admin dashboard homepage. Each
+// admin gets the same numbers for up to a minute. A caller limited to a region
+// only sees the pending registrations filed there.
+// @Summary Admin dashboard counts
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} DashboardStats
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/dashboard [get]
+func (h *AnalyticsHandler) Dashboard(c echo.Context) error {
+    key := actorID(c)
+    if stats, ok := h.cache.Get(key); ok {
+        return c.JSON(http.StatusOK, stats)
+    }
+
+    ctx, cancel := context.WithTimeout(c.Request().Context(), dashboardTimeout)
+    defer cancel()
+    g, ctx := errgroup.WithContext(ctx)
+
+    now := time.Now().In(h.loc)
+    y, m, d := now.Date()
+    startOfDay := time.Date(y, m, d, 0, 0, 0, 0, h.loc)
+
+    region := middleware.ScopedRegion(c)
+    stats := DashboardStats{GeneratedAt: now}
+    if region == "" {
+        g.Go(func() (err error) {
+            stats.PendingRegistrations, err = h.forms.GetPendingCount(ctx)
+            return err
+        })
+    }
+    g.Go(func() (err error) {
+        stats.PendingByRegion, err = h.forms.GetPendingByRegion(ctx)
+        return err
+    })
+    g.Go(func() (err error) {
+        stats.ExpiringPlates30d, err = h.plates.CountExpiringSoon(ctx, dashboardExpiryWindow)
+        return err
+    })
+    g.Go(func() (err error) {
+        stats.ActivePlates, err = h.plates.CountByStatus(ctx, models.PlateActive)
+        return err
+    })
+    g.Go(func() error {
+        hours, err := h.scans.HourlyBreakdown(ctx, startOfDay, now)
+        for _, hc := range hours {
+            stats.TotalScansToday += hc.Count
+        }
+        return err
+    })
+    if err := g.Wait(); err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    if region != "" {
+        stats.PendingRegistrations = stats.PendingByRegion[region]
+        stats.PendingByRegion = map[string]int{region: stats.PendingRegistrations}
+    }
+
+    h.cache.Add(key, stats)
+    return c.JSON(http.StatusOK, stats)
+}
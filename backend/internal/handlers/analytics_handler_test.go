@@ -0,0 +1,164 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+)
+
+// dashboardForms counts pending forms by region and how often it was asked
+type dashboardForms struct {
+    repository.RegistrationFormRepository
+    byRegion map[string]int
+    err      error
+    calls    atomic.Int32
+}
+
+func (f *dashboardForms) GetPendingCount(ctx context.Context) (int, error) {
+    f.calls.Add(1)
+    n := 0
+    for _, c := range f.byRegion {
+        n += c
+    }
+    return n, f.err
+}
+
+func (f *dashboardForms) GetPendingByRegion(ctx context.Context) (map[string]int, error) {
+    f.calls.Add(1)
+    out := make(map[string]int, len(f.byRegion))
+    for r, c := range f.byRegion {
+        out[r] = c
+    }
+    return out, f.err
+}
+
+// dashboardPlates answers the plate counts and records what was asked
+type dashboardPlates struct {
+    repository.PlateRepository
+    expiring, active int
+    window           time.Duration
+    status           string
+}
+
+func (f *dashboardPlates) CountExpiringSoon(ctx context.Context, within time.Duration) (int, error) {
+    f.window = within
+    return f.expiring, nil
+}
+
+func (f *dashboardPlates) CountByStatus(ctx context.Context, status string) (int, error) {
+    f.status = status
+    return f.active, nil
+}
+
+func TestDashboard(t *testing.T) {
+    now := time.Now().In(time.UTC)
+    midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+    pending := map[string]int{"NCR": 3, "Region IV-A": 2, "": 1}
+
+    tests := []struct {
+        name       string
+        region     string
+        formsErr   error
+        wantCode   int
+        wantTotal  int
+        wantRegion map[string]int
+    }{
+        {"every region", "", nil, http.StatusOK, 6, map[string]int{"NCR": 3, "Region IV-A": 2, "": 1}},
+        {"limited to a region", "NCR", nil, http.StatusOK, 3, map[string]int{"NCR": 3}},
+        {"region with nothing pending", "Region VII", nil, http.StatusOK, 0, map[string]int{"Region VII": 0}},
+        {"query fails", "", errors.New("connection reset"), http.StatusInternalServerError, 0, nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            forms := &dashboardForms{byRegion: pending, err: tt.formsErr}
+            plates := &dashboardPlates{expiring: 120, active: 15000}
+            scans := &fakeScanRepo{scannedAt: []time.Time{
+                midnight.Add(-time.Minute), // yesterday
+                midnight,
+                now,
+            }}
+            h := NewAnalyticsHandler(forms, plates, scans, time.UTC)
+
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/admin/dashboard", nil), rec)
+            c.Set("lto_client_id", "ADMIN-1")
+            if tt.region != "" {
+                c.Set(middleware.RegionKey, tt.region)
+            }
+            if err := h.Dashboard(c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if tt.wantCode != http.StatusOK {
+                if h.cache.Len() != 0 {
+                    t.Fatal("a failed dashboard was cached")
+                }
+                return
+            }
+            var got DashboardStats
+            if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+                t.Fatal(err)
+            }
+            if got.PendingRegistrations != tt.wantTotal {
+                t.Errorf("pending_registrations = %d, want %d", got.PendingRegistrations, tt.wantTotal)
+            }
+            if fmt.Sprint(got.PendingByRegion) != fmt.Sprint(tt.wantRegion) {
+                t.Errorf("pending_by_region = %v, want %v", got.PendingByRegion, tt.wantRegion)
+            }
+            if got.ExpiringPlates30d != 120 || plates.window != 30*24*time.Hour {
+                t.Errorf("expiring_plates_30d = %d within %v, want 120 within 30 days", got.ExpiringPlates30d, plates.window)
+            }
+            if got.ActivePlates != 15000 || plates.status != models.PlateActive {
+                t.Errorf("active_plates = %d counting %q, want 15000 counting %q", got.ActivePlates, plates.status, models.PlateActive)
+            }
+            if got.TotalScansToday != 2 {
+                t.Errorf("total_scans_today = %d, want 2", got.TotalScansToday)
+            }
+        })
+    }
+}
+
+func TestDashboardIsCachedPerUser(t *testing.T) {
+    forms := &dashboardForms{byRegion: map[string]int{"NCR": 3}}
+    h := NewAnalyticsHandler(forms, &dashboardPlates{}, &fakeScanRepo{}, time.UTC)
+
+    get := func(admin string) DashboardStats {
+        rec := httptest.NewRecorder()
+        c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/admin/dashboard", nil), rec)
+        c.Set("lto_client_id", admin)
+        if err := h.Dashboard(c); err != nil {
+            t.Fatal(err)
+        }
+        var got DashboardStats
+        if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+            t.Fatal(err)
+        }
+        return got
+    }
+
+    first := get("ADMIN-1")
+    forms.byRegion = map[string]int{"NCR": 4}
+    if again := get("ADMIN-1"); again.PendingRegistrations != first.PendingRegistrations || forms.calls.Load() != 2 {
+        t.Fatalf("second request counted %d with %d queries, want the cached %d from 2 queries",
+            again.PendingRegistrations, forms.calls.Load(), first.PendingRegistrations)
+    }
+    if other := get("ADMIN-2"); other.PendingRegistrations != 4 {
+        t.Fatalf("another admin got %d pending, want a fresh 4", other.PendingRegistrations)
+    }
+}
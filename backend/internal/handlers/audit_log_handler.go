@@ -0,0 +1,49 @@
+package handlers
+
+import (
+    "net/http"
+    "smartplate-api/internal/repository"
+    "time"
+
+    "github.com/labstack/echo/v4"
+)
+
+// AuditLogHandler exposes the audit trail to admins.
+type AuditLogHandler struct {
+    repo repository.AuditLogRepository
+}
+
+// NewAuditLogHandler creates a new AuditLogHandler.
+func NewAuditLogHandler(repo repository.AuditLogRepository) *AuditLogHandler {
+    return &AuditLogHandler{repo: repo}
+}
+
+// List returns audit rows filtered by actor, entity type and date range.
+// GET /api/admin/audit-logs?actor=&entity_type=&from=&to=&page=&limit=
+func (h *AuditLogHandler) List(c echo.Context) error {
+    filter := repository.AuditFilter{
+        ActorLTOID: c.QueryParam("actor"),
+        EntityType: c.QueryParam("entity_type"),
+    }
+    for name, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+        if v := c.QueryParam(name); v != "" {
+            t, err := time.Parse(time.RFC3339, v)
+            if err != nil {
+                return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid " + name + " (use RFC3339)"})
+            }
+            *dst = t
+        }
+    }
+
+    page, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    filter.Limit, filter.Offset = limit, offset
+
+    list, total, err := h.repo.List(c.Request().Context(), filter)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, PaginatedResponse(list, total, page, limit))
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "os"
+    "time"
+
+    "github.com/labstack/echo/v4"
+    "golang.org/x/oauth2"
+    "golang.org/x/oauth2/google"
+
+    mw "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+)
+
+const (
+    // googleStateCookie carries the OAuth state between GoogleLogin and GoogleCallback
+    googleStateCookie = "google_oauth_state"
+    googleStateTTL    = 10 * time.Minute
+    googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+)
+
+// googleProfile is the part of Google's OpenID userinfo response we use
+type googleProfile struct {
+    Sub           string `json:"sub"`
+    Email         string `json:"email"`
+    EmailVerified bool   `json:"email_verified"`
+    GivenName     string `json:"given_name"`
+    FamilyName    string `json:"family_name"`
+}
+
+// GoogleLoginResponse is the SmartPlate session issued after Google sign-in
+type GoogleLoginResponse struct {
+    Token       string    `json:"token"`
+    ExpiresAt   time.Time `json:"expires_at"`
+    LTOClientID string    `json:"lto_client_id"`
+    Created     bool      `json:"created"` // a new account was made for this Google user
+}
+
+// googleOAuthConfig reads GOOGLE_CLIENT_ID, GOOGLE_CLIENT_SECRET and
+// GOOGLE_REDIRECT_URL; nil when Google sign-in isn't configured
+func googleOAuthConfig() *oauth2.Config {
+    id, secret, redirect := os.Getenv("GOOGLE_CLIENT_ID"), os.Getenv("GOOGLE_CLIENT_SECRET"), os.Getenv("GOOGLE_REDIRECT_URL")
+    if id == "" || secret == "" || redirect == "" {
+        return nil
+    }
+    return &oauth2.Config{
+        ClientID:     id,
+        ClientSecret: secret,
+        RedirectURL:  redirect,
+        Scopes:       []string{"openid", "email", "profile"},
+        Endpoint:     google.Endpoint,
+    }
+}
+
+// GoogleLogin redirects to Google's consent screen
+// @Summary Sign in with Google
+// @Description Redirects to Google; Google sends the user back to /api/auth/google/callback.
+// @Tags auth
+// @Success 307
+// @Failure 503 {object} map[string]string
+// @Router /api/auth/google [get]
+func (h *AuthHandler) GoogleLogin(c echo.Context) error {
+    if h.google == nil {
+        return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Google sign-in is not configured"})
+    }
+    state, err := generateSecureToken()
+    if err != nil {
+        return err
+    }
+    c.SetCookie(&http.Cookie{
+        Name:     googleStateCookie,
+        Value:    state,
+        Path:     "/",
+        MaxAge:   int(googleStateTTL.Seconds()),
+        HttpOnly: true,
+        Secure:   c.IsTLS(),
+        SameSite: http.SameSiteLaxMode,
+    })
+    return c.Redirect(http.StatusTemporaryRedirect, h.google.AuthCodeURL(state))
+}
+
+// GoogleCallback finishes Google sign-in: it links the Google account to the
+// user with the same email, or creates a new "user" account, and issues a JWT
+// @Summary Google sign-in callback
+// @Tags auth
+// @Produce json
+// @Param state query string true "OAuth state"
+// @Param code query string true "Authorization code"
+// @Success 200 {object} GoogleLoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/auth/google/callback [get]
+func (h *AuthHandler) GoogleCallback(c echo.Context) error {
+    if h.google == nil {
+        return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Google sign-in is not configured"})
+    }
+    cookie, err := c.Cookie(googleStateCookie)
+    if err != nil || cookie.Value == "" || cookie.Value != c.QueryParam("state") {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid OAuth state"})
+    }
+    c.SetCookie(&http.Cookie{Name: googleStateCookie, Path: "/", MaxAge: -1})
+    code := c.QueryParam("code")
+    if code == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing authorization code"})
+    }
+
+    ctx := c.Request().Context()
+    tok, err := h.google.Exchange(ctx, code)
+    if err != nil {
+        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Google rejected the authorization code"})
+    }
+    profile, err := fetchGoogleProfile(h.google.Client(ctx, tok))
+    if err != nil {
+        return err
+    }
+    // an unverified address could belong to anyone, so never match accounts on it
+    if profile.Sub == "" || profile.Email == "" || !profile.EmailVerified {
+        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Google account has no verified email"})
+    }
+
+    user, created, err := h.googleUser(profile)
+    if err != nil {
+        return err
+    }
+    if user == nil {
+        return c.JSON(http.StatusConflict, map[string]string{"error": "this email is already linked to another Google account"})
+    }
+
+    token, expiresAt, err := h.generateJWTToken(c, user)
+    if err != nil {
+        return err
+    }
+    if err := h.userRepo.RecordLogin(user.LTO_CLIENT_ID); err != nil {
+        log.Printf("[req-id:%s] record login error: %v", mw.GetRequestID(c), err)
+    }
+    return c.JSON(http.StatusOK, GoogleLoginResponse{
+        Token:       token,
+        ExpiresAt:   expiresAt,
+        LTOClientID: user.LTO_CLIENT_ID,
+        Created:     created,
+    })
+}
+
+// googleUser finds the account for a Google profile. An existing account with
+// the same email is linked rather than duplicated; otherwise a new "user"
+// account is created. It returns nil when the email belongs to an account
+// already linked to a different Google id.
+func (h *AuthHandler) googleUser(p *googleProfile) (*models.User, bool, error) {
+    user, err := h.userRepo.GetByGoogleID(p.Sub)
+    if err == nil {
+        return &user, false, nil
+    }
+    if !errors.Is(err, sql.ErrNoRows) {
+        return nil, false, err
+    }
+
+    user, err = h.userRepo.GetByEmail(p.Email)
+    switch {
+    case err == nil:
+        if user.GOOGLE_ID != nil && *user.GOOGLE_ID != p.Sub {
+            return nil, false, nil
+        }
+        if err := h.userRepo.LinkGoogleID(user.LTO_CLIENT_ID, p.Sub); err != nil {
+            return nil, false, err
+        }
+        return &user, false, nil
+    case !errors.Is(err, sql.ErrNoRows):
+        return nil, false, err
+    }
+
+    // Google users sign in without a password; store an unguessable one so
+    // the column stays populated and password login can't succeed
+    secret, err := generateSecureToken()
+    if err != nil {
+        return nil, false, err
+    }
+    hashed, err := hashPassword(secret)
+    if err != nil {
+        return nil, false, err
+    }
+    ltoID, err := generateUniqueLTOID(h.userRepo)
+    if err != nil {
+        return nil, false, err
+    }
+    user = models.User{
+        FIRST_NAME:    p.GivenName,
+        LAST_NAME:     p.FamilyName,
+        EMAIL:         p.Email,
+        PASSWORD:      string(hashed),
+        ROLE:          models.RoleUser,
+        STATUS:        "active",
+        LTO_CLIENT_ID: ltoID,
+    }
+    if err := h.userRepo.Create(&user); err != nil {
+        return nil, false, err
+    }
+    if err := h.userRepo.LinkGoogleID(user.LTO_CLIENT_ID, p.Sub); err != nil {
+        return nil, false, err
+    }
+    return &user, true, nil
+}
+
+// fetchGoogleProfile reads the signed-in user's OpenID profile
+func fetchGoogleProfile(client *http.Client) (*googleProfile, error) {
+    resp, err := client.Get(googleUserInfoURL)
+    if err != nil {
+        return nil, fmt.Errorf("fetch Google profile: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("fetch Google profile: status %d", resp.StatusCode)
+    }
+    var p googleProfile
+    if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+        return nil, fmt.Errorf("decode Google profile: %w", err)
+    }
+    return &p, nil
+}
@@ -1,39 +1,131 @@
 package handlers
 
 import (
+    "context"
+    "crypto/rand"
     "database/sql"
+    "encoding/hex"
+    "errors"
     "net/http"
     "time"
-    "log"
 
+    "github.com/golang-jwt/jwt/v5"
     "github.com/labstack/echo/v4"
+    "golang.org/x/crypto/bcrypt"
+    "golang.org/x/oauth2"
 
+    "smartplate-api/internal/config"
     "smartplate-api/internal/email"
+    mw "smartplate-api/internal/middleware"
     "smartplate-api/internal/models"
     "smartplate-api/internal/repository"
 )
 
+// PasswordResetRequest is the body of a password reset request
+type PasswordResetRequest struct {
+    Email string `json:"email" example:"juan.delacruz@example.com"`
+}
+
+// ResetPasswordRequest redeems a password reset token
+type ResetPasswordRequest struct {
+    Token    string `json:"token"`
+    Password string `json:"password" example:"correct-horse-battery"`
+}
+
+// ChangePasswordRequest is the body of a signed-in password change
+type ChangePasswordRequest struct {
+    CurrentPassword string `json:"current_password"`
+    NewPassword     string `json:"new_password"`
+}
+
+// resetEmailTimeout bounds sending the reset email after the request has returned
+const resetEmailTimeout = 30 * time.Second
+
+// maxActiveResetTokens caps how many unexpired reset tokens a user may hold;
+// further requests are answered like any other so they don't reveal the
+// account exists, but no token is issued
+const maxActiveResetTokens = 3
+
+// passwordPolicy is what ResetPassword, ChangePassword and CreateUser require
+// of new passwords; see SetPasswordPolicy
+var passwordPolicy = config.DefaultPasswordPolicy()
+
+// SetPasswordPolicy sets the rules new passwords must follow. Call it before
+// serving requests.
+func SetPasswordPolicy(p config.PasswordPolicy) {
+    passwordPolicy = p
+}
+
+// rejectWeakPassword answers 422 with every policy rule password breaks and
+// reports whether it did
+func rejectWeakPassword(c echo.Context, password string) (bool, error) {
+    failures := config.ValidatePassword(passwordPolicy, password)
+    if len(failures) == 0 {
+        return false, nil
+    }
+    return true, c.JSON(http.StatusUnprocessableEntity, map[string][]string{"errors": failures})
+}
+
+// passwordCost is the bcrypt cost hashPassword uses; see SetPasswordCost
+var passwordCost = bcrypt.DefaultCost
+
+// SetPasswordCost sets the bcrypt cost new password hashes are made with.
+// Existing hashes at another cost are rehashed on the user's next login.
+// Call it before serving requests.
+func SetPasswordCost(cost int) {
+    passwordCost = cost
+}
+
+// hashPassword bcrypt-hashes password at the configured cost
+func hashPassword(password string) ([]byte, error) {
+    return bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+}
+
 type AuthHandler struct {
-    userRepo  repository.UserRepository
-    tokenRepo repository.PasswordResetTokenRepository
+    userRepo   repository.UserRepository
+    tokenRepo  repository.PasswordResetTokenRepository
+    sessions   repository.SessionRepository
+    loginAudit repository.LoginAuditRepository
+    jwtCfg     config.JWTConfig
+    google     *oauth2.Config // nil when Google sign-in isn't configured
+    geo        GeoLocator     // nil leaves login audit rows unlocated
 }
 
 func NewAuthHandler(
     userRepo repository.UserRepository,
     tokenRepo repository.PasswordResetTokenRepository,
+    sessions repository.SessionRepository,
+    loginAudit repository.LoginAuditRepository,
+    jwtCfg config.JWTConfig,
 ) *AuthHandler {
     return &AuthHandler{
-        userRepo:  userRepo,
-        tokenRepo: tokenRepo,
+        userRepo:   userRepo,
+        tokenRepo:  tokenRepo,
+        sessions:   sessions,
+        loginAudit: loginAudit,
+        jwtCfg:     jwtCfg,
+        google:     googleOAuthConfig(),
     }
 }
 
+// @Summary Request a password reset email
+// @Description Answers 202 whether or not the email is registered. A user already holding 3 unexpired tokens is sent no new one until the oldest expires. Each client address may ask 5 times a minute.
+// @Tags auth
+// @Accept json
+// @Param body body PasswordResetRequest true "Account email"
+// @Success 202
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/password-reset [post]
 func (h *AuthHandler) RequestPasswordReset(c echo.Context) error {
     // 1) bind input (e.g. JSON with { "email": "user@example.com" })
-    var req struct { Email string `json:"email"` }
+    var req PasswordResetRequest
     if err := c.Bind(&req); err != nil {
         return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
     }
+    if err := email.ValidateEmailAddress(req.Email); err != nil {
+        return echo.NewHTTPError(http.StatusBadRequest, "invalid email address")
+    }
 
     // 2) look up user by email
     user, err := h.userRepo.GetByEmail(req.Email)
@@ -44,25 +136,188 @@ func (h *AuthHandler) RequestPasswordReset(c echo.Context) error {
         return err
     }
 
-    // 3) create a token row in password_reset_token
-    token := generateSecureToken() // e.g. crypto/rand → hex
+    // 3) issue nothing once the user already holds too many live tokens,
+    // answering as for an unknown email
+    ctx := c.Request().Context()
+    logger := mw.LoggerFrom(c)
+    active, err := h.tokenRepo.CountActive(ctx, user.LTO_CLIENT_ID)
+    if err != nil {
+        return err
+    }
+    if active >= maxActiveResetTokens {
+        logger.Warn("too many reset requests", "lto_client_id", user.LTO_CLIENT_ID, "active", active)
+        return c.NoContent(http.StatusAccepted)
+    }
+
+    // 4) create a token row in password_reset_token
+    token, err := generateSecureToken()
+    if err != nil {
+        return err
+    }
     expires := time.Now().Add(1 * time.Hour)
-    if err := h.tokenRepo.Create(&models.PasswordResetToken{
-        LTOClientID: user.LTOClientID, // or user.ID
+    if err := h.tokenRepo.Create(ctx, &models.PasswordResetToken{
+        LTOClientID: user.LTO_CLIENT_ID,
         Token:       token,
         ExpiresAt:   expires,
     }); err != nil {
         return err
     }
 
-    // 4) send the email (fire-and-forget or handle error)
+    // 5) send the email (fire-and-forget or handle error); the request
+    // context ends with the response, so the send gets its own
     go func() {
-        if err := email.SendResetEmail(user.Email, token); err != nil {
-            log.Printf("email error: %v", err)
+        ctx, cancel := context.WithTimeout(context.Background(), resetEmailTimeout)
+        defer cancel()
+        if err := email.SendResetEmail(ctx, user.EMAIL, token); err != nil {
+            logger.Error("reset email error", "error", err)
         }
     }()
 
-    // 5) always respond “accepted” so attackers can’t enumerate
+    // 6) always respond “accepted” so attackers can’t enumerate
     return c.NoContent(http.StatusAccepted)
 }
 
+// @Summary Set a new password with a reset token
+// @Description Redeems the emailed token. Any other outstanding reset tokens for the account stop working.
+// @Tags auth
+// @Accept json
+// @Param body body ResetPasswordRequest true "Token and new password"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string][]string
+// @Router /auth/password-reset/confirm [post]
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+    var req ResetPasswordRequest
+    if err := c.Bind(&req); err != nil || req.Token == "" {
+        return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
+    }
+    if rejected, err := rejectWeakPassword(c, req.Password); rejected {
+        return err
+    }
+
+    ctx := c.Request().Context()
+    t, err := h.tokenRepo.Consume(ctx, req.Token)
+    if errors.Is(err, repository.ErrResetTokenInvalid) {
+        return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+    } else if err != nil {
+        return err
+    }
+
+    user, err := h.userRepo.GetByLTOClientID(t.LTOClientID)
+    if err == sql.ErrNoRows {
+        return echo.NewHTTPError(http.StatusBadRequest, repository.ErrResetTokenInvalid.Error())
+    } else if err != nil {
+        return err
+    }
+    hashed, err := hashPassword(req.Password)
+    if err != nil {
+        return err
+    }
+    if err := h.userRepo.UpdatePasswordHash(ctx, t.LTOClientID, user.PASSWORD, string(hashed)); err == sql.ErrNoRows {
+        return echo.NewHTTPError(http.StatusBadRequest, repository.ErrResetTokenInvalid.Error())
+    } else if err != nil {
+        return err
+    }
+    if err := h.tokenRepo.InvalidateByLTOClientID(ctx, t.LTOClientID); err != nil {
+        return err
+    }
+    return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary Change the signed-in user's password
+// @Tags auth
+// @Accept json
+// @Security BearerAuth
+// @Param body body ChangePasswordRequest true "Current and new password"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string][]string
+// @Router /api/users/me/password [put]
+func (h *AuthHandler) ChangePassword(c echo.Context) error {
+    var req ChangePasswordRequest
+    if err := c.Bind(&req); err != nil {
+        return echo.NewHTTPError(http.StatusBadRequest, "invalid payload")
+    }
+    if rejected, err := rejectWeakPassword(c, req.NewPassword); rejected {
+        return err
+    }
+
+    ltoClientID, _ := c.Get("lto_client_id").(string)
+    user, err := h.userRepo.GetByLTOClientID(ltoClientID)
+    if err != nil {
+        return echo.NewHTTPError(http.StatusNotFound, "user not found")
+    }
+    if bcrypt.CompareHashAndPassword([]byte(user.PASSWORD), []byte(req.CurrentPassword)) != nil {
+        return echo.NewHTTPError(http.StatusForbidden, "current password is incorrect")
+    }
+
+    hashed, err := hashPassword(req.NewPassword)
+    if err != nil {
+        return err
+    }
+    // the hash checked above must still be the stored one; otherwise the
+    // password changed in between and the caller should try again
+    if err := h.userRepo.UpdatePasswordHash(c.Request().Context(), ltoClientID, user.PASSWORD, string(hashed)); err == sql.ErrNoRows {
+        return echo.NewHTTPError(http.StatusConflict, "password was changed meanwhile, try again")
+    } else if err != nil {
+        return err
+    }
+    if err := h.tokenRepo.InvalidateByLTOClientID(c.Request().Context(), ltoClientID); err != nil {
+        return err
+    }
+    return c.NoContent(http.StatusNoContent)
+}
+
+// generateJWTToken issues a signed token for user. Its lifetime is the
+// JWTConfig expiry for the user's role, so admins and officers get short-lived
+// tokens and citizens long-lived ones.
+func (h *AuthHandler) generateJWTToken(c echo.Context, user *models.User) (string, time.Time, error) {
+    return h.signUserToken(c, user, h.jwtCfg.ExpiryFor(user.ROLE), "")
+}
+
+// signUserToken issues a token carrying user's claims that lasts ttl;
+// impersonatedBy is set only on impersonation tokens. Each token gets a
+// sessions row, recording the client of request c, whose id is the jti.
+func (h *AuthHandler) signUserToken(c echo.Context, user *models.User, ttl time.Duration, impersonatedBy string) (string, time.Time, error) {
+    now := time.Now()
+    expiresAt := now.Add(ttl)
+    session := &models.Session{LTOClientID: user.LTO_CLIENT_ID, ExpiresAt: expiresAt}
+    if ua := c.Request().UserAgent(); ua != "" {
+        session.UserAgent = &ua
+    }
+    if ip := c.RealIP(); ip != "" {
+        session.IPAddress = &ip
+    }
+    if err := h.sessions.Create(c.Request().Context(), session); err != nil {
+        return "", time.Time{}, err
+    }
+    claims := &mw.Claims{
+        Role:           user.ROLE,
+        ImpersonatedBy: impersonatedBy,
+        RegisteredClaims: jwt.RegisteredClaims{
+            ID:        session.SessionID,
+            Subject:   user.LTO_CLIENT_ID,
+            IssuedAt:  jwt.NewNumericDate(now),
+            ExpiresAt: jwt.NewNumericDate(expiresAt),
+        },
+    }
+    if user.REGION != nil {
+        claims.Region = *user.REGION
+    }
+    token, err := mw.SignToken(claims, h.jwtCfg.Secret)
+    if err != nil {
+        return "", time.Time{}, err
+    }
+    return token, expiresAt, nil
+}
+
+// generateSecureToken returns 32 random bytes, hex encoded
+func generateSecureToken() (string, error) {
+    b := make([]byte, 32)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/labstack/echo/v4"
+    "golang.org/x/crypto/bcrypt"
+
+    "smartplate-api/internal/config"
+    mw "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+    "smartplate-api/internal/testutil"
+)
+
+// fakeResetTokenRepo keeps reset tokens in memory, applying the same
+// used/expired rules as the SQL
+type fakeResetTokenRepo struct {
+    repository.PasswordResetTokenRepository
+    tokens []*models.PasswordResetToken
+}
+
+func (f *fakeResetTokenRepo) active(t *models.PasswordResetToken) bool {
+    return t.UsedAt == nil && t.ExpiresAt.After(time.Now())
+}
+
+func (f *fakeResetTokenRepo) Create(ctx context.Context, t *models.PasswordResetToken) error {
+    t.CreatedAt = time.Now()
+    f.tokens = append(f.tokens, t)
+    return nil
+}
+
+func (f *fakeResetTokenRepo) Consume(ctx context.Context, token string) (*models.PasswordResetToken, error) {
+    for _, t := range f.tokens {
+        if t.Token == token && f.active(t) {
+            now := time.Now()
+            t.UsedAt = &now
+            return t, nil
+        }
+    }
+    return nil, repository.ErrResetTokenInvalid
+}
+
+func (f *fakeResetTokenRepo) InvalidateByLTOClientID(ctx context.Context, ltoClientID string) error {
+    now := time.Now()
+    for _, t := range f.tokens {
+        if t.LTOClientID == ltoClientID && f.active(t) {
+            t.UsedAt = &now
+        }
+    }
+    return nil
+}
+
+func (f *fakeResetTokenRepo) CountActive(ctx context.Context, ltoClientID string) (int, error) {
+    n := 0
+    for _, t := range f.tokens {
+        if t.LTOClientID == ltoClientID && f.active(t) {
+            n++
+        }
+    }
+    return n, nil
+}
+
+// fakeSessions hands out sequential session IDs
+type fakeSessions struct {
+    repository.SessionRepository
+    created []*models.Session
+    revoked []string
+}
+
+func (f *fakeSessions) Create(ctx context.Context, s *models.Session) error {
+    s.SessionID = fmt.Sprintf("session-%d", len(f.created)+1)
+    f.created = append(f.created, s)
+    return nil
+}
+
+func (f *fakeSessions) RevokeAll(ctx context.Context, ltoClientID string) (int64, error) {
+    f.revoked = append(f.revoked, ltoClientID)
+    return 1, nil
+}
+
+// fakeLoginAudit records login attempts
+type fakeLoginAudit struct {
+    repository.LoginAuditRepository
+    entries []*models.LoginAudit
+}
+
+func (f *fakeLoginAudit) Create(ctx context.Context, a *models.LoginAudit) error {
+    f.entries = append(f.entries, a)
+    return nil
+}
+
+// jsonContext returns a context for a POST of body, and its recorder
+func jsonContext(body string) (echo.Context, *httptest.ResponseRecorder) {
+    req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+    req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+    rec := httptest.NewRecorder()
+    return echo.New().NewContext(req, rec), rec
+}
+
+// httpStatus is the status a handler answered with, whether it wrote the
+// response or returned an *echo.HTTPError
+func httpStatus(t *testing.T, rec *httptest.ResponseRecorder, err error) int {
+    t.Helper()
+    if err == nil {
+        return rec.Code
+    }
+    he, ok := err.(*echo.HTTPError)
+    if !ok {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    return he.Code
+}
+
+func TestResetPasswordInvalidatesOtherTokens(t *testing.T) {
+    cost := passwordCost
+    SetPasswordCost(4)
+    t.Cleanup(func() { SetPasswordCost(cost) })
+
+    expires := time.Now().Add(time.Hour)
+    tokens := &fakeResetTokenRepo{tokens: []*models.PasswordResetToken{
+        {Token: "first", LTOClientID: "LTO-1", ExpiresAt: expires},
+        {Token: "second", LTOClientID: "LTO-1", ExpiresAt: expires},
+        {Token: "other", LTOClientID: "LTO-2", ExpiresAt: expires},
+    }}
+    users := testutil.NewMockUserRepository(
+        models.User{LTO_CLIENT_ID: "LTO-1", PASSWORD: "old"},
+        models.User{LTO_CLIENT_ID: "LTO-2", PASSWORD: "old"},
+    )
+    h := NewAuthHandler(users, tokens, nil, nil, config.JWTConfig{})
+
+    c, rec := jsonContext(`{"token":"first","password":"N3w-Passw0rd!"}`)
+    if code := httpStatus(t, rec, h.ResetPassword(c)); code != http.StatusNoContent {
+        t.Fatalf("reset status = %d, want 204: %s", code, rec.Body)
+    }
+
+    tests := []struct {
+        token    string
+        wantUsed bool
+    }{
+        {"first", true},
+        {"second", true},
+        {"other", false},
+    }
+    for i, tt := range tests {
+        if used := tokens.tokens[i].UsedAt != nil; used != tt.wantUsed {
+            t.Errorf("token %q used = %v, want %v", tt.token, used, tt.wantUsed)
+        }
+    }
+
+    c, rec = jsonContext(`{"token":"second","password":"An0ther-Passw0rd!"}`)
+    if code := httpStatus(t, rec, h.ResetPassword(c)); code != http.StatusBadRequest {
+        t.Fatalf("second token status = %d, want 400", code)
+    }
+    if u, _ := users.GetByLTOClientID("LTO-2"); u.PASSWORD != "old" {
+        t.Fatalf("another user's password was changed")
+    }
+}
+
+func TestRequestPasswordResetLimit(t *testing.T) {
+    t.Setenv("APP_ENV", "test")
+    t.Setenv("SKIP_EMAIL_SENDING", "true")
+
+    tokens := &fakeResetTokenRepo{}
+    users := testutil.NewMockUserRepository(models.User{LTO_CLIENT_ID: "LTO-1", EMAIL: "juan@example.com"})
+    h := NewAuthHandler(users, tokens, nil, nil, config.JWTConfig{})
+
+    tests := []struct {
+        name     string
+        email    string
+        wantCode int
+    }{
+        {"first", "juan@example.com", http.StatusAccepted},
+        {"second", "juan@example.com", http.StatusAccepted},
+        {"third", "juan@example.com", http.StatusAccepted},
+        // answered like an unknown email, so it can't reveal the account
+        {"fourth", "juan@example.com", http.StatusAccepted},
+        {"unknown email", "nobody@example.com", http.StatusAccepted},
+    }
+    for _, tt := range tests {
+        c, rec := jsonContext(`{"email":"` + tt.email + `"}`)
+        if code := httpStatus(t, rec, h.RequestPasswordReset(c)); code != tt.wantCode {
+            t.Fatalf("%s request: status = %d, want %d", tt.name, code, tt.wantCode)
+        }
+        if retry := rec.Header().Get(echo.HeaderRetryAfter); retry != "" {
+            t.Fatalf("%s request: Retry-After = %q, want none", tt.name, retry)
+        }
+    }
+    if len(tokens.tokens) != 3 {
+        t.Fatalf("created %d tokens, want 3", len(tokens.tokens))
+    }
+}
+
+func TestLoginTokenExpiry(t *testing.T) {
+    tests := []struct {
+        name  string
+        env   map[string]string
+        role  string
+        admin bool // log in through the admin portal
+        want  time.Duration
+    }{
+        {"user default", nil, models.RoleUser, false, 168 * time.Hour},
+        {"user configured", map[string]string{"JWT_USER_EXPIRY_HOURS": "48"}, models.RoleUser, false, 48 * time.Hour},
+        {"officer default", nil, models.RoleOfficer, true, 8 * time.Hour},
+        {"officer configured", map[string]string{"JWT_OFFICER_EXPIRY_HOURS": "3"}, models.RoleOfficer, true, 3 * time.Hour},
+        {"admin default", nil, models.RoleAdmin, true, 12 * time.Hour},
+        {"admin configured", map[string]string{"JWT_ADMIN_EXPIRY_HOURS": "1"}, models.RoleAdmin, true, time.Hour},
+        {"superadmin uses the admin expiry", map[string]string{"JWT_ADMIN_EXPIRY_HOURS": "6"}, models.RoleSuperAdmin, true, 6 * time.Hour},
+    }
+
+    hash, err := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), bcrypt.MinCost)
+    if err != nil {
+        t.Fatal(err)
+    }
+    cost := passwordCost
+    SetPasswordCost(bcrypt.MinCost) // so the login doesn't rehash
+    t.Cleanup(func() { SetPasswordCost(cost) })
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            t.Setenv("JWT_SECRET", "expiry-test-secret")
+            for _, name := range []string{"JWT_USER_EXPIRY_HOURS", "JWT_OFFICER_EXPIRY_HOURS", "JWT_ADMIN_EXPIRY_HOURS"} {
+                t.Setenv(name, tt.env[name])
+            }
+            cfg, err := config.LoadJWTConfig()
+            if err != nil {
+                t.Fatal(err)
+            }
+            users := testutil.NewMockUserRepository(models.User{
+                LTO_CLIENT_ID: "LTO-1", EMAIL: "juan@example.com", PASSWORD: string(hash), ROLE: tt.role,
+            })
+            h := NewAuthHandler(users, nil, &fakeSessions{}, &fakeLoginAudit{}, cfg)
+
+            c, rec := jsonContext(`{"email":"juan@example.com","password":"Passw0rd!"}`)
+            login := h.Login
+            if tt.admin {
+                login = h.AdminLogin
+            }
+            if code := httpStatus(t, rec, login(c)); code != http.StatusOK {
+                t.Fatalf("login status = %d, want 200: %s", code, rec.Body)
+            }
+            var resp LoginResponse
+            if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+                t.Fatal(err)
+            }
+            claims, err := mw.ParseToken(resp.Token, cfg.Secret)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got := claims.ExpiresAt.Sub(claims.IssuedAt.Time); got != tt.want {
+                t.Fatalf("exp - iat = %v, want %v", got, tt.want)
+            }
+            if claims.Role != tt.role || claims.Subject != "LTO-1" {
+                t.Fatalf("claims = %+v", claims)
+            }
+        })
+    }
+}
+
+func TestLoginUpgradesHashCost(t *testing.T) {
+    tests := []struct {
+        name       string
+        storedCost int
+        configured int
+    }{
+        {"raised cost", 10, 12},
+        {"same cost is left alone", 10, 10},
+    }
+
+    cost := passwordCost
+    t.Cleanup(func() { SetPasswordCost(cost) })
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            hash, err := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), tt.storedCost)
+            if err != nil {
+                t.Fatal(err)
+            }
+            SetPasswordCost(tt.configured)
+            users := testutil.NewMockUserRepository(models.User{
+                LTO_CLIENT_ID: "LTO-1", EMAIL: "juan@example.com", PASSWORD: string(hash), ROLE: models.RoleUser,
+            })
+            h := NewAuthHandler(users, nil, &fakeSessions{}, &fakeLoginAudit{}, config.JWTConfig{Secret: []byte("s"), UserExpiry: time.Hour})
+
+            c, rec := jsonContext(`{"email":"juan@example.com","password":"Passw0rd!"}`)
+            if code := httpStatus(t, rec, h.Login(c)); code != http.StatusOK {
+                t.Fatalf("login status = %d, want 200: %s", code, rec.Body)
+            }
+
+            user, err := users.GetByLTOClientID("LTO-1")
+            if err != nil {
+                t.Fatal(err)
+            }
+            stored := user.PASSWORD
+            if got, _ := bcrypt.Cost([]byte(stored)); got != tt.configured {
+                t.Fatalf("stored hash cost = %d, want %d", got, tt.configured)
+            }
+            if tt.storedCost == tt.configured && stored != string(hash) {
+                t.Fatal("hash was rewritten although its cost already matched")
+            }
+            if err := bcrypt.CompareHashAndPassword([]byte(stored), []byte("Passw0rd!")); err != nil {
+                t.Fatalf("upgraded hash doesn't match the password: %v", err)
+            }
+        })
+    }
+}
+
+// racingUsers changes the stored password right after the login reads the
+// user, as a password change landing mid-login would
+type racingUsers struct {
+    *testutil.MockUserRepository
+    newHash string
+}
+
+func (r racingUsers) GetByEmail(email string) (models.User, error) {
+    user, err := r.MockUserRepository.GetByEmail(email)
+    if err == nil {
+        err = r.MockUserRepository.UpdatePasswordHash(context.Background(), user.LTO_CLIENT_ID, user.PASSWORD, r.newHash)
+    }
+    return user, err
+}
+
+func TestLoginRehashKeepsNewerPassword(t *testing.T) {
+    cost := passwordCost
+    t.Cleanup(func() { SetPasswordCost(cost) })
+    SetPasswordCost(bcrypt.MinCost + 1)
+
+    hash, err := bcrypt.GenerateFromPassword([]byte("Passw0rd!"), bcrypt.MinCost)
+    if err != nil {
+        t.Fatal(err)
+    }
+    users := racingUsers{
+        MockUserRepository: testutil.NewMockUserRepository(models.User{
+            LTO_CLIENT_ID: "LTO-1", EMAIL: "juan@example.com", PASSWORD: string(hash), ROLE: models.RoleUser,
+        }),
+        newHash: "changed-meanwhile",
+    }
+    h := NewAuthHandler(users, nil, &fakeSessions{}, &fakeLoginAudit{}, config.JWTConfig{Secret: []byte("s"), UserExpiry: time.Hour})
+
+    c, rec := jsonContext(`{"email":"juan@example.com","password":"Passw0rd!"}`)
+    if code := httpStatus(t, rec, h.Login(c)); code != http.StatusOK {
+        t.Fatalf("login status = %d, want 200: %s", code, rec.Body)
+    }
+    user, err := users.GetByLTOClientID("LTO-1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if user.PASSWORD != "changed-meanwhile" {
+        t.Fatalf("stored hash = %q, the rehash overwrote the newer password", user.PASSWORD)
+    }
+}
+
+func TestResetPasswordPolicy(t *testing.T) {
+    policy := passwordPolicy
+    SetPasswordPolicy(config.PasswordPolicy{MinLength: 10, MaxLength: 72, RequireUppercase: true, RequireDigit: true, RequireSpecialChar: true})
+    t.Cleanup(func() { SetPasswordPolicy(policy) })
+
+    tokens := &fakeResetTokenRepo{tokens: []*models.PasswordResetToken{
+        {Token: "tok", LTOClientID: "LTO-1", ExpiresAt: time.Now().Add(time.Hour)},
+    }}
+    users := testutil.NewMockUserRepository(models.User{LTO_CLIENT_ID: "LTO-1", PASSWORD: "old"})
+    h := NewAuthHandler(users, tokens, nil, nil, config.JWTConfig{})
+
+    c, rec := jsonContext(`{"token":"tok","password":"weak"}`)
+    if code := httpStatus(t, rec, h.ResetPassword(c)); code != http.StatusUnprocessableEntity {
+        t.Fatalf("status = %d, want 422: %s", code, rec.Body)
+    }
+    var body struct {
+        Errors []string `json:"errors"`
+    }
+    if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+        t.Fatal(err)
+    }
+    want := []string{
+        "password must be at least 10 characters",
+        "password must contain an uppercase letter",
+        "password must contain a digit",
+        "password must contain a special character",
+    }
+    if fmt.Sprint(body.Errors) != fmt.Sprint(want) {
+        t.Fatalf("errors = %q, want %q", body.Errors, want)
+    }
+    if tokens.tokens[0].UsedAt != nil {
+        t.Fatal("a rejected password used up the reset token")
+    }
+    if u, _ := users.GetByLTOClientID("LTO-1"); u.PASSWORD != "old" {
+        t.Fatal("a rejected password was stored")
+    }
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "time"
+
+    "github.com/labstack/echo/v4"
+
+    mw "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+)
+
+// impersonationTTL is how long an impersonation token lasts; it can't be
+// extended, only exchanged back with EndImpersonation
+const impersonationTTL = time.Hour
+
+// ImpersonationResponse carries a token issued by Impersonate or EndImpersonation
+type ImpersonationResponse struct {
+    Token       string    `json:"token"`
+    ExpiresAt   time.Time `json:"expires_at"`
+    LTOClientID string    `json:"lto_client_id"`
+}
+
+func isAdminRole(role string) bool {
+    return role == models.RoleAdmin || role == models.RoleSuperAdmin
+}
+
+// Impersonate issues a one-hour token with the target user's claims plus
+// impersonated_by, so support can see exactly what the user sees. Every
+// request made with it is audited. Only a superadmin may impersonate an admin.
+// @Summary Impersonate a user
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "LTO client id of the user to impersonate"
+// @Success 200 {object} ImpersonationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/admin/users/{id}/impersonate [post]
+func (h *AuthHandler) Impersonate(c echo.Context) error {
+    if by, _ := c.Get(mw.ImpersonatedByKey).(string); by != "" {
+        return c.JSON(http.StatusForbidden, map[string]string{"error": "already impersonating a user"})
+    }
+    adminID, _ := c.Get("lto_client_id").(string)
+    if c.Param("id") == adminID {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "cannot impersonate yourself"})
+    }
+
+    target, err := h.userRepo.GetByLTOClientID(c.Param("id"))
+    if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+    }
+    callerRole, _ := c.Get("role").(string)
+    if isAdminRole(target.ROLE) && callerRole != models.RoleSuperAdmin {
+        return c.JSON(http.StatusForbidden, map[string]string{"error": "only a superadmin can impersonate an admin"})
+    }
+
+    token, expiresAt, err := h.signUserToken(c, &target, impersonationTTL, adminID)
+    if err != nil {
+        return err
+    }
+    return c.JSON(http.StatusOK, ImpersonationResponse{Token: token, ExpiresAt: expiresAt, LTOClientID: target.LTO_CLIENT_ID})
+}
+
+// EndImpersonation trades an impersonation token for a normal token of the
+// admin who started it, provided they are still an admin. The impersonation
+// session is revoked.
+// @Summary End impersonation
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} ImpersonationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /api/auth/end-impersonation [post]
+func (h *AuthHandler) EndImpersonation(c echo.Context) error {
+    adminID, _ := c.Get(mw.ImpersonatedByKey).(string)
+    if adminID == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "not an impersonation token"})
+    }
+    admin, err := h.userRepo.GetByLTOClientID(adminID)
+    if err != nil || !isAdminRole(admin.ROLE) || admin.DELETED_AT != nil {
+        return c.JSON(http.StatusForbidden, map[string]string{"error": "impersonating admin is no longer an admin"})
+    }
+
+    token, expiresAt, err := h.generateJWTToken(c, &admin)
+    if err != nil {
+        return err
+    }
+    if sessionID, _ := c.Get(mw.SessionIDKey).(string); sessionID != "" {
+        if err := h.sessions.Revoke(c.Request().Context(), sessionID, c.Get("lto_client_id").(string)); err != nil && !errors.Is(err, sql.ErrNoRows) {
+            return err
+        }
+    }
+    return c.JSON(http.StatusOK, ImpersonationResponse{Token: token, ExpiresAt: expiresAt, LTOClientID: admin.LTO_CLIENT_ID})
+}
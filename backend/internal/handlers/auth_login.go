@@ -0,0 +1,238 @@
+package handlers
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/labstack/echo/v4"
+    "golang.org/x/crypto/bcrypt"
+
+    "smartplate-api/internal/email"
+    mw "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+)
+
+const (
+    defaultLoginHistoryLimit = 50
+    maxLoginHistoryLimit     = 500
+)
+
+// LoginRequest is the body of a password login
+type LoginRequest struct {
+    Email    string `json:"email" example:"juan.delacruz@example.com"`
+    Password string `json:"password"`
+}
+
+// LoginResponse carries the token issued by Login and AdminLogin
+type LoginResponse struct {
+    Token       string    `json:"token"`
+    ExpiresAt   time.Time `json:"expires_at"`
+    LTOClientID string    `json:"lto_client_id"`
+    Role        string    `json:"role"`
+}
+
+// GeoLocator resolves an IP address to an ISO country code and city name.
+// Either is empty when unknown.
+type GeoLocator interface {
+    Locate(ip string) (country, city string)
+}
+
+// SetGeoLocator enables country and city lookup for login audit rows and
+// new-location alerts; without one they are recorded unlocated.
+func (h *AuthHandler) SetGeoLocator(g GeoLocator) {
+    h.geo = g
+}
+
+// Login signs a citizen in with email and password
+// @Summary Log in
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body LoginRequest true "Credentials"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/auth/login [post]
+func (h *AuthHandler) Login(c echo.Context) error {
+    return h.passwordLogin(c, models.RoleUser)
+}
+
+// AdminLogin signs LTO officers and admins in to the admin portal
+// @Summary Log in to the admin portal
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body LoginRequest true "Credentials"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/auth/admin/login [post]
+func (h *AuthHandler) AdminLogin(c echo.Context) error {
+    return h.passwordLogin(c, models.RoleOfficer, models.RoleAdmin, models.RoleSuperAdmin)
+}
+
+// passwordLogin checks the credentials, records the attempt in login_audit
+// and issues a token. Accounts whose role isn't one of roles get the same 401
+// as a wrong password.
+func (h *AuthHandler) passwordLogin(c echo.Context, roles ...string) error {
+    var req LoginRequest
+    if err := c.Bind(&req); err != nil || req.Email == "" || req.Password == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "email and password are required"})
+    }
+    invalid := func() error {
+        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid email or password"})
+    }
+
+    user, err := h.userRepo.GetByEmail(req.Email)
+    if errors.Is(err, sql.ErrNoRows) {
+        h.auditLogin(c, nil, false)
+        return invalid()
+    } else if err != nil {
+        return err
+    }
+    if !hasAnyRole(user.ROLE, roles) || bcrypt.CompareHashAndPassword([]byte(user.PASSWORD), []byte(req.Password)) != nil {
+        h.auditLogin(c, &user, false)
+        return invalid()
+    }
+
+    h.upgradeHash(c, &user, req.Password)
+
+    token, expiresAt, err := h.generateJWTToken(c, &user)
+    if err != nil {
+        return err
+    }
+    if err := h.userRepo.RecordLogin(user.LTO_CLIENT_ID); err != nil {
+        mw.LoggerFrom(c).Error("record login error", "error", err)
+    }
+    h.auditLogin(c, &user, true)
+    return c.JSON(http.StatusOK, LoginResponse{
+        Token:       token,
+        ExpiresAt:   expiresAt,
+        LTOClientID: user.LTO_CLIENT_ID,
+        Role:        user.ROLE,
+    })
+}
+
+// rehashTimeout bounds the database write of a login's rehash
+const rehashTimeout = 2 * time.Second
+
+// upgradeHash rehashes the password when the stored hash was made at a
+// different cost than the configured one, so raising BCRYPT_COST takes effect
+// for each user at their next login. It runs inside the login, once per user
+// and cost change. The write only lands if the stored hash is still the one
+// the login checked, so it can't undo a password changed in the meantime.
+// Failures are logged; the login goes ahead with the old hash.
+func (h *AuthHandler) upgradeHash(c echo.Context, user *models.User, password string) {
+    cost, err := bcrypt.Cost([]byte(user.PASSWORD))
+    if err != nil || cost == passwordCost {
+        return
+    }
+    logger := mw.LoggerFrom(c)
+    hashed, err := hashPassword(password)
+    if err != nil {
+        logger.Error("password rehash error", "error", err)
+        return
+    }
+    ctx, cancel := context.WithTimeout(c.Request().Context(), rehashTimeout)
+    defer cancel()
+    err = h.userRepo.UpdatePasswordHash(ctx, user.LTO_CLIENT_ID, user.PASSWORD, string(hashed))
+    if errors.Is(err, sql.ErrNoRows) {
+        logger.Info("password changed during login, rehash skipped")
+    } else if err != nil {
+        logger.Error("password rehash update error", "error", err)
+    }
+}
+
+// auditLogin writes the login_audit row for an attempt; user is nil when the
+// email matched no account. A successful login from a country the user
+// hasn't logged in from before triggers a new-location email, except on
+// their first login ever. Failures are logged, never returned.
+func (h *AuthHandler) auditLogin(c echo.Context, user *models.User, success bool) {
+    logger := mw.LoggerFrom(c)
+    ctx := c.Request().Context()
+    entry := &models.LoginAudit{Success: success}
+    ip, ua := c.RealIP(), c.Request().UserAgent()
+    if ip != "" {
+        entry.IPAddress = &ip
+    }
+    if ua != "" {
+        entry.UserAgent = &ua
+    }
+    var country, city string
+    if h.geo != nil && ip != "" {
+        country, city = h.geo.Locate(ip)
+    }
+    if country != "" {
+        entry.Country = &country
+    }
+    if city != "" {
+        entry.City = &city
+    }
+
+    alert := false
+    if user != nil {
+        entry.LTOClientID = &user.LTO_CLIENT_ID
+        if success && country != "" && user.LAST_LOGIN_AT != nil {
+            seen, err := h.loginAudit.HasSucceededFrom(ctx, user.LTO_CLIENT_ID, country)
+            if err != nil {
+                logger.Error("login audit lookup error", "error", err)
+            }
+            alert = err == nil && !seen
+        }
+    }
+    if err := h.loginAudit.Create(ctx, entry); err != nil {
+        logger.Error("login audit error", "error", err)
+    }
+
+    if alert {
+        location := country
+        if city != "" {
+            location = city + ", " + country
+        }
+        recipient, name, at := user.EMAIL, user.FIRST_NAME, entry.AttemptedAt
+        go func() {
+            if err := email.SendNewLoginLocationAlert(recipient, name, location, ip, at); err != nil {
+                logger.Error("new login location email error", "error", err)
+            }
+        }()
+    }
+}
+
+// LoginHistory returns a user's latest login attempts, newest first
+// @Summary Get a user's login history
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "LTO client id"
+// @Param limit query int false "Max attempts to return (default 50, max 500)"
+// @Success 200 {array} models.LoginAudit
+// @Failure 400 {object} map[string]string
+// @Router /api/admin/users/{id}/login-history [get]
+func (h *AuthHandler) LoginHistory(c echo.Context) error {
+    limit := defaultLoginHistoryLimit
+    if raw := c.QueryParam("limit"); raw != "" {
+        n, err := strconv.Atoi(raw)
+        if err != nil || n < 1 {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+        }
+        limit = min(n, maxLoginHistoryLimit)
+    }
+    list, err := h.loginAudit.ListByUser(c.Request().Context(), c.Param("id"), limit)
+    if err != nil {
+        return err
+    }
+    return c.JSON(http.StatusOK, list)
+}
+
+func hasAnyRole(role string, roles []string) bool {
+    for _, r := range roles {
+        if r == role {
+            return true
+        }
+    }
+    return false
+}
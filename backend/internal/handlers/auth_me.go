@@ -0,0 +1,64 @@
+package handlers
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "time"
+
+    "github.com/hashicorp/golang-lru/v2/expirable"
+    "github.com/labstack/echo/v4"
+
+    mw "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+)
+
+// GetCurrentUser serves a profile from currentUsers for up to currentUserTTL,
+// so clients polling /api/auth/me don't each cost a six-table join
+const (
+    currentUserTTL       = 30 * time.Second
+    currentUserCacheSize = 10000
+)
+
+// currentUsers caches GetCurrentUser responses by lto_client_id. Handlers
+// that change or delete a user call forgetCurrentUser.
+var currentUsers = expirable.NewLRU[string, models.MaskedUser](currentUserCacheSize, nil, currentUserTTL)
+
+// forgetCurrentUser drops ltoClientID's cached profile
+func forgetCurrentUser(ltoClientID string) {
+    currentUsers.Remove(ltoClientID)
+}
+
+// GetCurrentUser returns the profile of the user the token was issued to. A
+// valid token whose account has since been deleted gets 401 and every
+// session of that account is revoked.
+// @Summary Get the signed-in user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.MaskedUser
+// @Failure 401 {object} map[string]string
+// @Router /api/auth/me [get]
+func (h *AuthHandler) GetCurrentUser(c echo.Context) error {
+    sub, _ := c.Get("lto_client_id").(string)
+    if sub == "" {
+        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+    }
+    if user, ok := currentUsers.Get(sub); ok {
+        return c.JSON(http.StatusOK, user)
+    }
+
+    user, err := h.userRepo.GetByLTOClientID(sub)
+    if errors.Is(err, sql.ErrNoRows) {
+        if _, err := h.sessions.RevokeAll(c.Request().Context(), sub); err != nil {
+            mw.LoggerFrom(c).Error("revoke sessions of deleted account", "error", err, "lto_client_id", sub)
+        }
+        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "account_not_found"})
+    }
+    if err != nil {
+        return err
+    }
+    masked := user.ToMasked()
+    currentUsers.Add(sub, masked)
+    return c.JSON(http.StatusOK, masked)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/config"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/testutil"
+)
+
+// countingUsers counts the profile lookups that reach the repository
+type countingUsers struct {
+    *testutil.MockUserRepository
+    lookups map[string]int
+}
+
+func (r *countingUsers) GetByLTOClientID(ltoClientID string) (models.User, error) {
+    r.lookups[ltoClientID]++
+    return r.MockUserRepository.GetByLTOClientID(ltoClientID)
+}
+
+func TestGetCurrentUserCache(t *testing.T) {
+    type step struct {
+        action   string // "me", "update" or "delete"
+        sub      string
+        wantCode int
+        wantName string // first name in the response, for "me"
+    }
+    tests := []struct {
+        name        string
+        steps       []step
+        wantLookups map[string]int
+        wantRevoked []string
+    }{
+        {"miss then hit", []step{
+            {"me", "LTO-1", http.StatusOK, "Juan"},
+            {"me", "LTO-1", http.StatusOK, "Juan"},
+            {"me", "LTO-1", http.StatusOK, "Juan"},
+        }, map[string]int{"LTO-1": 1}, nil},
+        {"users are cached separately", []step{
+            {"me", "LTO-1", http.StatusOK, "Juan"},
+            {"me", "LTO-2", http.StatusOK, "Maria"},
+            {"me", "LTO-1", http.StatusOK, "Juan"},
+            {"me", "LTO-2", http.StatusOK, "Maria"},
+        }, map[string]int{"LTO-1": 1, "LTO-2": 1}, nil},
+        {"profile update invalidates", []step{
+            {"me", "LTO-1", http.StatusOK, "Juan"},
+            {"update", "LTO-1", http.StatusOK, ""},
+            {"me", "LTO-1", http.StatusOK, "Johnny"},
+            {"me", "LTO-1", http.StatusOK, "Johnny"},
+        }, map[string]int{"LTO-1": 3}, nil}, // UpdateMe reads the profile back once
+        {"soft delete invalidates", []step{
+            {"me", "LTO-1", http.StatusOK, "Juan"},
+            {"delete", "LTO-1", http.StatusNoContent, ""},
+            {"me", "LTO-1", http.StatusUnauthorized, ""},
+        }, map[string]int{"LTO-1": 2}, []string{"LTO-1"}},
+        {"unknown accounts aren't cached", []step{
+            {"me", "LTO-9", http.StatusUnauthorized, ""},
+            {"me", "LTO-9", http.StatusUnauthorized, ""},
+        }, map[string]int{"LTO-9": 2}, []string{"LTO-9", "LTO-9"}},
+        {"no subject", []step{
+            {"me", "", http.StatusUnauthorized, ""},
+        }, map[string]int{}, nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            currentUsers.Purge()
+            t.Cleanup(currentUsers.Purge)
+
+            users := &countingUsers{
+                MockUserRepository: testutil.NewMockUserRepository(
+                    models.User{LTO_CLIENT_ID: "LTO-1", FIRST_NAME: "Juan", LAST_NAME: "Dela Cruz", PASSWORD: "hash"},
+                    models.User{LTO_CLIENT_ID: "LTO-2", FIRST_NAME: "Maria", LAST_NAME: "Santos", PASSWORD: "hash"},
+                ),
+                lookups: map[string]int{},
+            }
+            sessions := &fakeSessions{}
+            auth := NewAuthHandler(users, nil, sessions, nil, config.JWTConfig{})
+            profiles := NewUserHandler(users)
+
+            for i, s := range tt.steps {
+                var req *http.Request
+                var handle func(echo.Context) error
+                switch s.action {
+                case "me":
+                    req, handle = httptest.NewRequest(http.MethodGet, "/api/auth/me", nil), auth.GetCurrentUser
+                case "update":
+                    req = httptest.NewRequest(http.MethodPut, "/api/users/me", strings.NewReader(`{"first_name":"Johnny"}`))
+                    req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+                    handle = profiles.UpdateMe
+                case "delete":
+                    req, handle = httptest.NewRequest(http.MethodDelete, "/api/admin/users/"+s.sub, nil), profiles.AdminDeleteUser
+                }
+                rec := httptest.NewRecorder()
+                c := echo.New().NewContext(req, rec)
+                c.Set("lto_client_id", s.sub)
+                c.SetParamNames("id")
+                c.SetParamValues(s.sub)
+                if code := httpStatus(t, rec, handle(c)); code != s.wantCode {
+                    t.Fatalf("step %d (%s): status = %d, want %d: %s", i, s.action, code, s.wantCode, rec.Body)
+                }
+
+                if s.action != "me" {
+                    continue
+                }
+                var body map[string]interface{}
+                if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+                    t.Fatal(err)
+                }
+                if _, ok := body["password"]; ok {
+                    t.Fatalf("step %d: response carries the password hash", i)
+                }
+                if s.wantCode == http.StatusUnauthorized && s.sub != "" && body["error"] != "account_not_found" {
+                    t.Fatalf("step %d: error = %v, want account_not_found", i, body["error"])
+                }
+                if s.wantName != "" && body["first_name"] != s.wantName {
+                    t.Fatalf("step %d: first_name = %v, want %s", i, body["first_name"], s.wantName)
+                }
+            }
+
+            for sub, want := range tt.wantLookups {
+                if got := users.lookups[sub]; got != want {
+                    t.Errorf("%s looked up %d times, want %d", sub, got, want)
+                }
+            }
+            if len(users.lookups) != len(tt.wantLookups) {
+                t.Errorf("lookups = %v, want %v", users.lookups, tt.wantLookups)
+            }
+            if strings.Join(sessions.revoked, ",") != strings.Join(tt.wantRevoked, ",") {
+                t.Errorf("revoked sessions of %v, want %v", sessions.revoked, tt.wantRevoked)
+            }
+        })
+    }
+}
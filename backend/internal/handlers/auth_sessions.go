@@ -0,0 +1,106 @@
+package handlers
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+
+    "github.com/google/uuid"
+    "github.com/labstack/echo/v4"
+
+    mw "smartplate-api/internal/middleware"
+)
+
+// RevokeSessionsResponse reports how many sessions were revoked
+type RevokeSessionsResponse struct {
+    Revoked int64 `json:"revoked"`
+}
+
+// ListSessions returns the signed-in user's active sessions, newest first.
+// The one the request was made with is flagged current.
+// @Summary List my active sessions
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Session
+// @Router /api/auth/sessions [get]
+func (h *AuthHandler) ListSessions(c echo.Context) error {
+    ltoClientID, _ := c.Get("lto_client_id").(string)
+    list, err := h.sessions.ListActive(c.Request().Context(), ltoClientID)
+    if err != nil {
+        return err
+    }
+    current, _ := c.Get(mw.SessionIDKey).(string)
+    for i := range list {
+        list[i].Current = list[i].SessionID == current
+    }
+    return c.JSON(http.StatusOK, list)
+}
+
+// RevokeSession signs one of the user's sessions out; its token is rejected
+// from then on.
+// @Summary Revoke one of my sessions
+// @Tags auth
+// @Security BearerAuth
+// @Param session_id path string true "Session id"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/auth/sessions/{session_id} [delete]
+func (h *AuthHandler) RevokeSession(c echo.Context) error {
+    sessionID := c.Param("session_id")
+    if _, err := uuid.Parse(sessionID); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid session id"})
+    }
+    ltoClientID, _ := c.Get("lto_client_id").(string)
+    err := h.sessions.Revoke(c.Request().Context(), sessionID, ltoClientID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "session not found"})
+    }
+    if err != nil {
+        return err
+    }
+    return c.NoContent(http.StatusNoContent)
+}
+
+// Logout revokes the session the request was made with; the user's other
+// sessions stay signed in.
+// @Summary Log out
+// @Tags auth
+// @Security BearerAuth
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Router /api/auth/logout [post]
+func (h *AuthHandler) Logout(c echo.Context) error {
+    sessionID, _ := c.Get(mw.SessionIDKey).(string)
+    if sessionID == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "token has no session; it expires on its own"})
+    }
+    ltoClientID, _ := c.Get("lto_client_id").(string)
+    err := h.sessions.Revoke(c.Request().Context(), sessionID, ltoClientID)
+    if err != nil && !errors.Is(err, sql.ErrNoRows) {
+        return err
+    }
+    return c.NoContent(http.StatusNoContent)
+}
+
+// RevokeUserSessions signs a user out everywhere.
+// @Summary Revoke all of a user's sessions
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "LTO client id"
+// @Success 200 {object} RevokeSessionsResponse
+// @Failure 404 {object} map[string]string
+// @Router /api/admin/users/{id}/sessions [delete]
+func (h *AuthHandler) RevokeUserSessions(c echo.Context) error {
+    ltoClientID := c.Param("id")
+    if _, err := h.userRepo.GetByLTOClientID(ltoClientID); err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+    }
+    n, err := h.sessions.RevokeAll(c.Request().Context(), ltoClientID)
+    if err != nil {
+        return err
+    }
+    return c.JSON(http.StatusOK, RevokeSessionsResponse{Revoked: n})
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+    "net/http"
+    "smartplate-api/internal/repository"
+
+    "github.com/labstack/echo/v4"
+)
+
+// EmailQueueHandler lets admins see emails that haven't been delivered yet.
+type EmailQueueHandler struct {
+    repo repository.EmailQueueRepository
+}
+
+// NewEmailQueueHandler creates a new EmailQueueHandler.
+func NewEmailQueueHandler(repo repository.EmailQueueRepository) *EmailQueueHandler {
+    return &EmailQueueHandler{repo: repo}
+}
+
+// List returns pending and failed emails, newest first.
+// GET /api/admin/email-queue?page=&limit=
+func (h *EmailQueueHandler) List(c echo.Context) error {
+    page, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+
+    list, total, err := h.repo.ListUndelivered(c.Request().Context(), limit, offset)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, PaginatedResponse(list, total, page, limit))
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+)
+
+// fakeEmailQueue returns its items as the undelivered page
+type fakeEmailQueue struct {
+    repository.EmailQueueRepository
+    items []models.EmailQueueItem
+}
+
+func (f *fakeEmailQueue) ListUndelivered(ctx context.Context, limit, offset int) ([]models.EmailQueueItem, int, error) {
+    return f.items, len(f.items), nil
+}
+
+func TestEmailQueueListHidesBodies(t *testing.T) {
+    const link = "https://smartplate.example/reset?token=secret-token"
+    h := NewEmailQueueHandler(&fakeEmailQueue{items: []models.EmailQueueItem{{
+        ID:       "e1",
+        ToEmail:  "juan@example.com",
+        Subject:  "SmartPlate Password Reset",
+        HTMLBody: `<a href="` + link + `">Reset</a>`,
+        TextBody: link,
+        Status:   models.EmailFailed,
+    }}})
+
+    rec := httptest.NewRecorder()
+    c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+    if err := h.List(c); err != nil {
+        t.Fatal(err)
+    }
+
+    if rec.Code != http.StatusOK {
+        t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+    }
+    body := rec.Body.String()
+    for _, leak := range []string{"secret-token", "html_body", "text_body"} {
+        if strings.Contains(body, leak) {
+            t.Errorf("response contains %q: %s", leak, body)
+        }
+    }
+    if !strings.Contains(body, "SmartPlate Password Reset") {
+        t.Errorf("response lost the subject: %s", body)
+    }
+}
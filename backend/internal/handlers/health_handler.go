@@ -0,0 +1,77 @@
+package handlers
+
+import (
+    "context"
+    "net/http"
+    "smartplate-api/internal/database"
+    "smartplate-api/internal/email"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+    "github.com/labstack/echo/v4"
+)
+
+// readyCheckTimeout bounds each dependency check so a slow dependency can't stall the probe
+const readyCheckTimeout = 2 * time.Second
+
+// HealthHandler serves the liveness and readiness probes.
+type HealthHandler struct {
+    db      *sqlx.DB
+    version string
+}
+
+// NewHealthHandler creates a new HealthHandler reporting the given build version.
+func NewHealthHandler(db *sqlx.DB, version string) *HealthHandler {
+    return &HealthHandler{db: db, version: version}
+}
+
+// Health reports that the process is up. It never touches the database.
+// GET /health
+func (h *HealthHandler) Health(c echo.Context) error {
+    return c.JSON(http.StatusOK, map[string]string{
+        "status":  "ok",
+        "version": h.version,
+    })
+}
+
+// Ready checks the database and SMTP server and returns 503 naming the
+// checks that failed. The connection pool's stats are included either way.
+// GET /ready
+func (h *HealthHandler) Ready(c echo.Context) error {
+    checks := map[string]func(ctx context.Context) error{
+        "database": func(ctx context.Context) error {
+            _, err := h.db.ExecContext(ctx, "SELECT 1")
+            return err
+        },
+        "smtp": func(context.Context) error {
+            return email.TestSMTPConnection()
+        },
+    }
+
+    results := map[string]string{}
+    healthy := true
+    for name, check := range checks {
+        ctx, cancel := context.WithTimeout(c.Request().Context(), readyCheckTimeout)
+        err := check(ctx)
+        cancel()
+        if err != nil {
+            healthy = false
+            results[name] = err.Error()
+        } else {
+            results[name] = "ok"
+        }
+    }
+
+    if !healthy {
+        return c.JSON(http.StatusServiceUnavailable, map[string]interface{}{
+            "status":  "unavailable",
+            "checks":  results,
+            "db_pool": database.Stats(h.db),
+        })
+    }
+    return c.JSON(http.StatusOK, map[string]interface{}{
+        "status":  "ok",
+        "checks":  results,
+        "db_pool": database.Stats(h.db),
+    })
+}
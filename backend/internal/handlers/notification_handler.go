@@ -1 +1,82 @@
-package handlers
\ No newline at end of file
+package handlers
+
+import (
+    "log"
+    "net/http"
+
+    "github.com/labstack/echo/v4"
+
+    mw "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+)
+
+// NotificationPreferencesHandler lets users choose how they are notified.
+type NotificationPreferencesHandler struct {
+    repo repository.NotificationPreferencesRepository
+}
+
+// NewNotificationPreferencesHandler creates a new NotificationPreferencesHandler.
+func NewNotificationPreferencesHandler(repo repository.NotificationPreferencesRepository) *NotificationPreferencesHandler {
+    return &NotificationPreferencesHandler{repo: repo}
+}
+
+// GetMine returns the caller's notification preferences; every channel is on
+// until the user turns it off
+// @Summary Get my notification preferences
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.NotificationPreferences
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/me/notification-preferences [get]
+func (h *NotificationPreferencesHandler) GetMine(c echo.Context) error {
+    ltoID, _ := c.Get("lto_client_id").(string)
+    if ltoID == "" {
+        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+    }
+    prefs, err := h.repo.GetByLTOClientID(c.Request().Context(), ltoID)
+    if err != nil {
+        log.Printf("[req-id:%s] GetMine notification preferences error: %v", mw.GetRequestID(c), err)
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load notification preferences"})
+    }
+    return c.JSON(http.StatusOK, prefs)
+}
+
+// UpdateMine changes the caller's notification preferences; fields left out
+// of the body keep their current value
+// @Summary Update my notification preferences
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body models.NotificationPreferencesUpdate true "Channels to turn on or off"
+// @Success 200 {object} models.NotificationPreferences
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/me/notification-preferences [put]
+func (h *NotificationPreferencesHandler) UpdateMine(c echo.Context) error {
+    ltoID, _ := c.Get("lto_client_id").(string)
+    if ltoID == "" {
+        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+    }
+    var update models.NotificationPreferencesUpdate
+    if err := c.Bind(&update); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+    }
+
+    ctx := c.Request().Context()
+    prefs, err := h.repo.GetByLTOClientID(ctx, ltoID)
+    if err != nil {
+        log.Printf("[req-id:%s] UpdateMine notification preferences error: %v", mw.GetRequestID(c), err)
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load notification preferences"})
+    }
+    update.Apply(prefs)
+    if err := h.repo.Upsert(ctx, prefs); err != nil {
+        log.Printf("[req-id:%s] UpdateMine notification preferences error: %v", mw.GetRequestID(c), err)
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save notification preferences"})
+    }
+    return c.JSON(http.StatusOK, prefs)
+}
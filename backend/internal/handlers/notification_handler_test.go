@@ -0,0 +1,248 @@
+package handlers
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+    "smartplate-api/internal/testutil"
+)
+
+func (f *fakePrefsRepo) Upsert(ctx context.Context, p *models.NotificationPreferences) error {
+    p.UpdatedAt = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+    f.prefs[p.LTOClientID] = *p
+    return nil
+}
+
+// defaultingPrefs answers like the repository: saved preferences, or every
+// channel on for someone who never saved any
+type defaultingPrefs struct{ *fakePrefsRepo }
+
+func (f defaultingPrefs) GetByLTOClientID(ctx context.Context, ltoClientID string) (*models.NotificationPreferences, error) {
+    if _, ok := f.prefs[ltoClientID]; !ok {
+        p := models.DefaultNotificationPreferences(ltoClientID)
+        return &p, nil
+    }
+    return f.fakePrefsRepo.GetByLTOClientID(ctx, ltoClientID)
+}
+
+// fakeNotifyLog records who was notified about which plate and which
+// plates have been claimed
+type fakeNotifyLog struct {
+    repository.PlateNotificationLogRepository
+    mu      sync.Mutex
+    sent    []string
+    claimed map[string]bool
+}
+
+func (f *fakeNotifyLog) Create(ctx context.Context, plateID, recipient string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.sent = append(f.sent, plateID+" "+recipient)
+    return nil
+}
+
+func (f *fakeNotifyLog) Claim(ctx context.Context, plateID string, since time.Time) (bool, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if f.claimed[plateID] {
+        return false, nil
+    }
+    if f.claimed == nil {
+        f.claimed = map[string]bool{}
+    }
+    f.claimed[plateID] = true
+    return true, nil
+}
+
+func TestNotificationPreferencesEndpoints(t *testing.T) {
+    tests := []struct {
+        name     string
+        caller   string
+        method   string
+        body     string
+        wantCode int
+        want     *models.NotificationPreferences
+    }{
+        {"never saved", "LTO-1", http.MethodGet, "", http.StatusOK, &models.NotificationPreferences{
+            LTOClientID: "LTO-1", EmailPlateExpiry: true, SMSPlateExpiry: true, EmailScanAlert: true, SMSScanAlert: true,
+        }},
+        {"saved", "LTO-2", http.MethodGet, "", http.StatusOK, &models.NotificationPreferences{
+            LTOClientID: "LTO-2", EmailPlateExpiry: true,
+        }},
+        {"turn one channel off", "LTO-1", http.MethodPut, `{"sms_scan_alert":false}`, http.StatusOK, &models.NotificationPreferences{
+            LTOClientID: "LTO-1", EmailPlateExpiry: true, SMSPlateExpiry: true, EmailScanAlert: true,
+            UpdatedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+        }},
+        {"turn one channel on", "LTO-2", http.MethodPut, `{"sms_plate_expiry":true}`, http.StatusOK, &models.NotificationPreferences{
+            LTOClientID: "LTO-2", EmailPlateExpiry: true, SMSPlateExpiry: true,
+            UpdatedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+        }},
+        {"bad body", "LTO-1", http.MethodPut, `{"sms_scan_alert":"no"}`, http.StatusBadRequest, nil},
+        {"signed out", "", http.MethodGet, "", http.StatusUnauthorized, nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            repo := defaultingPrefs{&fakePrefsRepo{prefs: map[string]models.NotificationPreferences{
+                "LTO-2": {LTOClientID: "LTO-2", EmailPlateExpiry: true},
+            }}}
+            h := NewNotificationPreferencesHandler(repo)
+
+            req := httptest.NewRequest(tt.method, "/api/users/me/notification-preferences", strings.NewReader(tt.body))
+            req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(req, rec)
+            if tt.caller != "" {
+                c.Set("lto_client_id", tt.caller)
+            }
+            handle := h.GetMine
+            if tt.method == http.MethodPut {
+                handle = h.UpdateMine
+            }
+            if err := handle(c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if tt.want == nil {
+                return
+            }
+            var got models.NotificationPreferences
+            if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+                t.Fatal(err)
+            }
+            if got != *tt.want {
+                t.Fatalf("got %+v, want %+v", got, *tt.want)
+            }
+            _, saved := repo.prefs[tt.caller]
+            if tt.method == http.MethodGet && tt.caller == "LTO-1" && saved {
+                t.Fatal("reading the defaults saved a row")
+            }
+            if tt.method == http.MethodPut && repo.prefs[tt.caller] != got {
+                t.Fatalf("saved %+v, answered %+v", repo.prefs[tt.caller], got)
+            }
+        })
+    }
+}
+
+func TestExpiryNotificationsHonourPreferences(t *testing.T) {
+    t.Setenv("SKIP_EMAIL_SENDING", "true")
+    var logged bytes.Buffer
+    log.SetOutput(&logged)
+    t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+    mobile := "+639171234567"
+    var users []models.User
+    vehicles := map[string]*models.Vehicle{}
+    var plates []models.Plate
+    for i := 1; i <= 4; i++ {
+        id := fmt.Sprintf("LTO-%d", i)
+        u := models.User{LTO_CLIENT_ID: id, EMAIL: fmt.Sprintf("owner%d@example.com", i)}
+        u.Contact.MOBILE_NUMBER = &mobile
+        users = append(users, u)
+        vehicles[fmt.Sprintf("v%d", i)] = &models.Vehicle{VEHICLE_ID: fmt.Sprintf("v%d", i), LTO_CLIENT_ID: id}
+        plates = append(plates, models.Plate{
+            PlateID: fmt.Sprintf("p%d", i), VEHICLE_ID: fmt.Sprintf("v%d", i), PLATE_NUMBER: fmt.Sprintf("ABC %d", 1000+i),
+            PLATE_EXPIRATION_DATE: time.Now().Add(10 * 24 * time.Hour),
+        })
+    }
+    prefs := defaultingPrefs{&fakePrefsRepo{prefs: map[string]models.NotificationPreferences{
+        // LTO-1 never saved any, so gets both
+        "LTO-2": {LTOClientID: "LTO-2", EmailPlateExpiry: true},
+        "LTO-3": {LTOClientID: "LTO-3", SMSPlateExpiry: true},
+        "LTO-4": {LTOClientID: "LTO-4", EmailScanAlert: true, SMSScanAlert: true},
+    }}}
+    sender := &fakeExpirySMS{}
+    notified := &fakeNotifyLog{}
+    h := NewPlateHandler(&fakePlateRepo{}, &fakeVehicleRepo{vehicles: vehicles}, testutil.NewMockUserRepository(users...), notified, prefs, nil)
+    h.SetSMSSender(sender)
+
+    h.sendExpiryNotifications("req-1", plates)
+
+    if want := []string{"ABC 1001", "ABC 1003"}; fmt.Sprint(sender.plates) != fmt.Sprint(want) {
+        t.Fatalf("texted about %v, want %v", sender.plates, want)
+    }
+    for i, want := range []bool{true, true, false, false} {
+        to := fmt.Sprintf("simulated email to owner%d@example.com", i+1)
+        if strings.Contains(logged.String(), to) != want {
+            t.Errorf("owner %d emailed = %v, want %v", i+1, !want, want)
+        }
+    }
+    want := []string{"p1 owner1@example.com", "p2 owner2@example.com", "p3 owner3@example.com"}
+    if fmt.Sprint(notified.sent) != fmt.Sprint(want) {
+        t.Fatalf("notification log = %v, want %v", notified.sent, want)
+    }
+}
+
+// fakeExpirySMS records the plates it sent expiry reminders for
+type fakeExpirySMS struct {
+    fakeSMS
+    plates []string
+}
+
+func (f *fakeExpirySMS) SendPlateExpiry(to, plateNumber string, daysUntilExpiry int) error {
+    f.plates = append(f.plates, plateNumber)
+    return nil
+}
+
+func TestExpiringSoonNotifiesEachPlateOnce(t *testing.T) {
+    t.Setenv("SKIP_EMAIL_SENDING", "true")
+    plates := &fakePlateRepo{expiring: []models.Plate{{PlateID: "p1", VEHICLE_ID: "v1"}, {PlateID: "p2", VEHICLE_ID: "v1"}}}
+    vehicles := &fakeVehicleRepo{vehicles: map[string]*models.Vehicle{"v1": {VEHICLE_ID: "v1", LTO_CLIENT_ID: "LTO-1"}}}
+    users := testutil.NewMockUserRepository(models.User{LTO_CLIENT_ID: "LTO-1", EMAIL: "owner1@example.com"})
+    // the owner turned every channel off, so the background send stays quiet
+    prefs := &fakePrefsRepo{prefs: map[string]models.NotificationPreferences{"LTO-1": {LTOClientID: "LTO-1"}}}
+    notified := &fakeNotifyLog{claimed: map[string]bool{"p2": true}}
+    h := NewPlateHandler(plates, vehicles, users, notified, prefs, nil)
+
+    const callers = 8
+    sent := make(chan int, callers)
+    var wg sync.WaitGroup
+    for i := 0; i < callers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/admin/plates/expiring?notify=true", nil), rec)
+            if err := h.GetExpiringSoon(c); err != nil {
+                t.Error(err)
+                return
+            }
+            var got map[string]int
+            if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+                t.Error(err)
+                return
+            }
+            if got["total_expiring"] != 2 {
+                t.Errorf("total_expiring = %d, want 2", got["total_expiring"])
+            }
+            sent <- got["notifications_sent"]
+        }()
+    }
+    wg.Wait()
+    close(sent)
+
+    total := 0
+    for n := range sent {
+        total += n
+    }
+    if total != 1 {
+        t.Fatalf("%d concurrent calls sent %d notifications, want p1 alone once", callers, total)
+    }
+}
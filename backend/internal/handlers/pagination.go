@@ -0,0 +1,56 @@
+package handlers
+
+import (
+    "fmt"
+    "strconv"
+
+    "github.com/labstack/echo/v4"
+)
+
+const (
+    defaultPageLimit = 20
+    maxPageLimit     = 100
+)
+
+// ParsePaginationParams reads ?page= and ?limit=. A missing or non-positive
+// page means 1 and a missing or zero limit means 20; a limit over 100, or a
+// value that isn't a number, is an error the caller should answer with 400.
+func ParsePaginationParams(c echo.Context) (page, limit, offset int, err error) {
+    page, limit = 1, defaultPageLimit
+    if raw := c.QueryParam("page"); raw != "" {
+        n, err := strconv.Atoi(raw)
+        if err != nil {
+            return 0, 0, 0, fmt.Errorf("page must be a number, got %q", raw)
+        }
+        page = max(n, 1)
+    }
+    if raw := c.QueryParam("limit"); raw != "" {
+        n, err := strconv.Atoi(raw)
+        if err != nil {
+            return 0, 0, 0, fmt.Errorf("limit must be a number, got %q", raw)
+        }
+        if n > maxPageLimit {
+            return 0, 0, 0, fmt.Errorf("limit must be at most %d, got %d", maxPageLimit, n)
+        }
+        if n > 0 {
+            limit = n
+        }
+    }
+    return page, limit, (page - 1) * limit, nil
+}
+
+// PaginatedResponse wraps one page of items with the paging metadata every
+// list endpoint returns
+func PaginatedResponse(items interface{}, total, page, limit int) map[string]interface{} {
+    pages := 0
+    if limit > 0 {
+        pages = (total + limit - 1) / limit
+    }
+    return map[string]interface{}{
+        "items": items,
+        "total": total,
+        "page":  page,
+        "limit": limit,
+        "pages": pages,
+    }
+}
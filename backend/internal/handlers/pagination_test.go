@@ -0,0 +1,89 @@
+package handlers
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/labstack/echo/v4"
+)
+
+func TestParsePaginationParams(t *testing.T) {
+    tests := []struct {
+        query      string
+        wantPage   int
+        wantLimit  int
+        wantOffset int
+        wantErr    bool
+    }{
+        {"", 1, 20, 0, false},
+        {"?page=1&limit=10", 1, 10, 0, false},
+        {"?page=2", 2, 20, 20, false},
+        {"?page=3&limit=25", 3, 25, 50, false},
+        {"?limit=100", 1, 100, 0, false},
+        {"?limit=0", 1, 20, 0, false},
+        {"?limit=-5", 1, 20, 0, false},
+        {"?page=0", 1, 20, 0, false},
+        {"?page=-1", 1, 20, 0, false},
+        {"?page=-1&limit=5", 1, 5, 0, false},
+        {"?limit=101", 0, 0, 0, true},
+        {"?limit=999", 0, 0, 0, true},
+        {"?page=2&limit=999", 0, 0, 0, true},
+        {"?page=two", 0, 0, 0, true},
+        {"?limit=ten", 0, 0, 0, true},
+        {"?limit=1.5", 0, 0, 0, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.query, func(t *testing.T) {
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/"+tt.query, nil), httptest.NewRecorder())
+            page, limit, offset, err := ParsePaginationParams(c)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+            }
+            if page != tt.wantPage || limit != tt.wantLimit || offset != tt.wantOffset {
+                t.Fatalf("got page %d, limit %d, offset %d; want %d, %d, %d",
+                    page, limit, offset, tt.wantPage, tt.wantLimit, tt.wantOffset)
+            }
+        })
+    }
+}
+
+func TestPaginatedResponse(t *testing.T) {
+    tests := []struct {
+        total     int
+        limit     int
+        wantPages int
+    }{
+        {0, 10, 0},
+        {1, 10, 1},
+        {10, 10, 1},
+        {11, 10, 2},
+        {250, 100, 3},
+        {5, 0, 0},
+    }
+
+    for _, tt := range tests {
+        resp := PaginatedResponse([]string{}, tt.total, 1, tt.limit)
+        if resp["pages"] != tt.wantPages || resp["total"] != tt.total || resp["limit"] != tt.limit || resp["page"] != 1 {
+            t.Errorf("PaginatedResponse(total %d, limit %d) = %v, want %d pages", tt.total, tt.limit, resp, tt.wantPages)
+        }
+    }
+}
+
+func TestPaginationErrorIs400(t *testing.T) {
+    repo := &fakePlateRepo{}
+    h := NewPlateHandler(repo, nil, nil, nil, nil, nil)
+    rec := httptest.NewRecorder()
+    c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/admin/plates?status=Active&limit=999", nil), rec)
+    if err := h.ListPlates(c); err != nil {
+        t.Fatal(err)
+    }
+    if rec.Code != http.StatusBadRequest {
+        t.Fatalf("status = %d, want 400", rec.Code)
+    }
+    if body := rec.Body.String(); !strings.Contains(body, "limit must be at most 100") {
+        t.Fatalf("body = %s, want the limit explained", body)
+    }
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+    "database/sql"
+    "errors"
+    "net/http"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/labstack/echo/v4"
+)
+
+// PasswordResetTokenHandler lets admins audit and revoke outstanding reset tokens.
+type PasswordResetTokenHandler struct {
+    repo repository.PasswordResetTokenRepository
+}
+
+// NewPasswordResetTokenHandler creates a new PasswordResetTokenHandler.
+func NewPasswordResetTokenHandler(repo repository.PasswordResetTokenRepository) *PasswordResetTokenHandler {
+    return &PasswordResetTokenHandler{repo: repo}
+}
+
+// ResetTokenView is a reset token with its secret masked
+type ResetTokenView struct {
+    ID          string    `json:"id"`
+    Token       string    `json:"token"`
+    LTOClientID string    `json:"lto_client_id"`
+    ExpiresAt   time.Time `json:"expires_at"`
+    CreatedAt   time.Time `json:"created_at"`
+}
+
+// maskToken keeps the first 8 and last 4 characters of a token
+func maskToken(token string) string {
+    if len(token) <= 12 {
+        return "****"
+    }
+    return token[:8] + "****" + token[len(token)-4:]
+}
+
+func newResetTokenView(t *models.PasswordResetToken) ResetTokenView {
+    return ResetTokenView{
+        ID:          t.ID,
+        Token:       maskToken(t.Token),
+        LTOClientID: t.LTOClientID,
+        ExpiresAt:   t.ExpiresAt,
+        CreatedAt:   t.CreatedAt,
+    }
+}
+
+// List returns unused, unexpired reset tokens with their values masked.
+// GET /api/admin/password-reset-tokens?page=&limit=
+func (h *PasswordResetTokenHandler) List(c echo.Context) error {
+    page, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+
+    list, total, err := h.repo.GetAllActive(c.Request().Context(), limit, offset)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    items := make([]ResetTokenView, 0, len(list))
+    for _, t := range list {
+        items = append(items, newResetTokenView(t))
+    }
+    return c.JSON(http.StatusOK, PaginatedResponse(items, total, page, limit))
+}
+
+// Revoke marks a single reset token as used so it can no longer be redeemed.
+// DELETE /api/admin/password-reset-tokens/:id
+func (h *PasswordResetTokenHandler) Revoke(c echo.Context) error {
+    id := c.Param("id")
+    if _, err := uuid.Parse(id); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid token id"})
+    }
+    err := h.repo.MarkTokenAsUsed(c.Request().Context(), id)
+    if errors.Is(err, sql.ErrNoRows) {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "token not found or already used"})
+    }
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.NoContent(http.StatusNoContent)
+}
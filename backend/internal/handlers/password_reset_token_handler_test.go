@@ -0,0 +1,62 @@
+package handlers
+
+import (
+    "context"
+    "database/sql"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/models"
+)
+
+func (f *fakeResetTokenRepo) MarkTokenAsUsed(ctx context.Context, id string) error {
+    for _, t := range f.tokens {
+        if t.ID == id && t.UsedAt == nil {
+            now := time.Now()
+            t.UsedAt = &now
+            return nil
+        }
+    }
+    return sql.ErrNoRows
+}
+
+func TestRevokeResetToken(t *testing.T) {
+    const live = "0b6f1a52-8c1e-4e0a-9a57-3f0d2c7b9e41"
+    tests := []struct {
+        name     string
+        id       string
+        wantCode int
+    }{
+        {"live token", live, http.StatusNoContent},
+        {"unknown token", "5d9e0c3a-1b2f-4a6d-8e7c-9f0a1b2c3d4e", http.StatusNotFound},
+        {"not a uuid", "not-a-uuid", http.StatusBadRequest},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            repo := &fakeResetTokenRepo{tokens: []*models.PasswordResetToken{
+                {ID: live, Token: "abcdefgh12345678wxyz", LTOClientID: "LTO-1", ExpiresAt: time.Now().Add(time.Hour)},
+            }}
+            h := NewPasswordResetTokenHandler(repo)
+
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodDelete, "/", nil), rec)
+            c.SetParamNames("id")
+            c.SetParamValues(tt.id)
+            if err := h.Revoke(c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if revoked := repo.tokens[0].UsedAt != nil; revoked != (tt.wantCode == http.StatusNoContent) {
+                t.Fatalf("live token revoked = %v after a %d", revoked, rec.Code)
+            }
+        })
+    }
+}
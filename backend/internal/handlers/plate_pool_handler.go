@@ -0,0 +1,40 @@
+package handlers
+
+import (
+    "net/http"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+
+    "github.com/labstack/echo/v4"
+)
+
+// PlatePoolHandler reports how full each region's plate number pools are.
+type PlatePoolHandler struct {
+    repo repository.PlatePoolRepository
+}
+
+// NewPlatePoolHandler creates a new PlatePoolHandler.
+func NewPlatePoolHandler(repo repository.PlatePoolRepository) *PlatePoolHandler {
+    return &PlatePoolHandler{repo: repo}
+}
+
+// Usage lists reserved sequences and utilization per region.
+// GET /api/admin/plate-pools
+// @Summary Plate number pool utilization per region
+// @Description Capacity counts only the letter prefixes a region has opened so far.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.PlatePoolUsage
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/plate-pools [get]
+func (h *PlatePoolHandler) Usage(c echo.Context) error {
+    list, err := h.repo.Usage(c.Request().Context())
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    if list == nil {
+        list = []models.PlatePoolUsage{}
+    }
+    return c.JSON(http.StatusOK, list)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "net/http"
+    "os"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/labstack/echo/v4"
+)
+
+// verifySignatureMaxAge is how old a signed verification request may be
+const verifySignatureMaxAge = 5 * time.Minute
+
+// PlateVerifyHandler lets third parties such as insurers confirm a plate is
+// genuine and current without access to owner or vehicle records.
+type PlateVerifyHandler struct {
+    repo   repository.PlateRepository
+    secret []byte
+}
+
+// NewPlateVerifyHandler creates a PlateVerifyHandler signed with PLATE_VERIFY_SECRET.
+func NewPlateVerifyHandler(repo repository.PlateRepository) *PlateVerifyHandler {
+    return &PlateVerifyHandler{repo: repo, secret: []byte(os.Getenv("PLATE_VERIFY_SECRET"))}
+}
+
+// PlateVerifyResponse is all an integrator learns about a plate
+type PlateVerifyResponse struct {
+    Valid      bool   `json:"valid" example:"true"`
+    Status     string `json:"status" example:"Active"`
+    ExpiryDate string `json:"expiry_date,omitempty" example:"2027-01-15"`
+}
+
+// verifySignature checks sig against HMAC-SHA256("plate:<number>:<ts>")
+func (h *PlateVerifyHandler) verifySignature(number, ts, sig string) bool {
+    want, err := hex.DecodeString(sig)
+    if err != nil {
+        return false
+    }
+    mac := hmac.New(sha256.New, h.secret)
+    mac.Write([]byte("plate:" + number + ":" + ts))
+    return hmac.Equal(mac.Sum(nil), want)
+}
+
+// Verify reports whether a plate is currently valid.
+// GET /api/verify/plate?number=ABC 1234&ts=1718000000&sig=<hex hmac>
+// @Summary Verify a plate for third-party integrators
+// @Description sig is the hex HMAC-SHA256 of "plate:<number>:<ts>" keyed with the integration secret; ts is a Unix timestamp no more than 5 minutes old. Limited to 100 requests per minute per IP. No owner or vehicle details are returned.
+// @Tags verify
+// @Produce json
+// @Param number query string true "Plate number"
+// @Param ts query int true "Unix timestamp the signature was made at"
+// @Param sig query string true "Hex HMAC-SHA256 signature"
+// @Success 200 {object} PlateVerifyResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/verify/plate [get]
+func (h *PlateVerifyHandler) Verify(c echo.Context) error {
+    if len(h.secret) == 0 {
+        return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "plate verification is not configured"})
+    }
+    number, ts, sig := c.QueryParam("number"), c.QueryParam("ts"), c.QueryParam("sig")
+    if number == "" || ts == "" || sig == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "number, ts and sig are required"})
+    }
+    unix, err := strconv.ParseInt(ts, 10, 64)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "ts must be a Unix timestamp"})
+    }
+    // the signature is checked before the timestamp so the error doesn't
+    // reveal anything to unsigned callers
+    if !h.verifySignature(number, ts, sig) {
+        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid signature"})
+    }
+    if age := time.Since(time.Unix(unix, 0)); age > verifySignatureMaxAge || age < -verifySignatureMaxAge {
+        return c.JSON(http.StatusUnauthorized, map[string]string{"error": "signature expired"})
+    }
+
+    p, err := h.repo.GetByPlateNumber(c.Request().Context(), strings.TrimSpace(number))
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "lookup failed"})
+    }
+    if p == nil {
+        return c.JSON(http.StatusOK, PlateVerifyResponse{Status: "not_found"})
+    }
+
+    status := p.STATUS
+    expired := p.PLATE_EXPIRATION_DATE.Before(time.Now())
+    if expired && status == models.PlateActive {
+        status = models.PlateExpired
+    }
+    return c.JSON(http.StatusOK, PlateVerifyResponse{
+        Valid:      !expired && (status == models.PlateActive || status == models.PlateTemporary),
+        Status:     status,
+        ExpiryDate: p.PLATE_EXPIRATION_DATE.Format("2006-01-02"),
+    })
+}
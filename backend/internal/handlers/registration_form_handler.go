@@ -0,0 +1,436 @@
+package handlers
+
+import (
+    "context"
+    "database/sql"
+    "encoding/csv"
+    "errors"
+    "log"
+    "net/http"
+    "smartplate-api/internal/email"
+    "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/plate"
+    "smartplate-api/internal/repository"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+    "github.com/labstack/echo/v4"
+)
+
+// Registration form statuses used by the approval workflow; see
+// models.ValidTransitions for how a form moves between them
+const (
+    RegistrationPending     = string(models.FormSubmitted)
+    RegistrationUnderReview = string(models.FormUnderReview)
+    RegistrationApproved    = string(models.FormApproved)
+    RegistrationRejected    = string(models.FormRejected)
+)
+
+// RegistrationFormHandler handles the submit/approve/reject workflow for registration forms.
+type RegistrationFormHandler struct {
+    formRepo    repository.RegistrationFormRepository
+    plateRepo   repository.PlateRepository
+    vehicleRepo repository.VehicleRepository
+    userRepo    repository.UserRepository
+    scanRepo    repository.ScanLogRepository
+    history     repository.FormStateHistoryRepository
+    tx          repository.Transactor
+}
+
+// NewRegistrationFormHandler creates a new RegistrationFormHandler.
+func NewRegistrationFormHandler(
+    fr repository.RegistrationFormRepository,
+    pr repository.PlateRepository,
+    vr repository.VehicleRepository,
+    ur repository.UserRepository,
+    sr repository.ScanLogRepository,
+    hr repository.FormStateHistoryRepository,
+    tx repository.Transactor,
+) *RegistrationFormHandler {
+    return &RegistrationFormHandler{formRepo: fr, plateRepo: pr, vehicleRepo: vr, userRepo: ur, scanRepo: sr, history: hr, tx: tx}
+}
+
+// visibleForm loads a form the caller may see: applicants only their own,
+// LTO officers only those filed in their region, admins any. A form the
+// caller may not see is reported as missing.
+func visibleForm(c echo.Context, forms repository.RegistrationFormRepository, id string) (*models.RegistrationForm, bool) {
+    form, err := forms.GetByID(c.Request().Context(), id)
+    if err != nil || !mayActFor(c, form.LTOClientID) {
+        return nil, false
+    }
+    if region := middleware.ScopedRegion(c); region != "" && form.Region != region {
+        return nil, false
+    }
+    return form, true
+}
+
+// checkApplicant stops users filing a form for someone else or for a vehicle
+// they don't own; a user's form defaults to themselves. Officers and admins
+// file on anyone's behalf.
+func checkApplicant(c echo.Context, vehicles repository.VehicleRepository, params *models.CreateRegistrationFormParams) (int, string) {
+    if role, _ := c.Get("role").(string); role != models.RoleUser {
+        return 0, ""
+    }
+    if params.LTOClientID == "" {
+        params.LTOClientID = actorID(c)
+    }
+    if !mayActFor(c, params.LTOClientID) {
+        return http.StatusForbidden, "you can only file your own registrations"
+    }
+    v, err := vehicles.GetVehicleByID(c.Request().Context(), params.VehicleID)
+    if err != nil || v == nil || !mayActFor(c, v.LTO_CLIENT_ID) {
+        return http.StatusForbidden, "not your vehicle"
+    }
+    return 0, ""
+}
+
+// transitionError answers a failed status change: 409 for a move the
+// workflow doesn't allow or one that would give the vehicle a second active
+// form, 404 for an unknown form
+func transitionError(c echo.Context, err error) error {
+    switch {
+    case errors.Is(err, models.ErrInvalidTransition), errors.Is(err, repository.ErrActiveRegistrationExists):
+        return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+    case errors.Is(err, sql.ErrNoRows):
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
+    return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// decide moves a form awaiting review to outcome. A form still in Pending is
+// taken through Under Review first, so officers can approve or reject
+// straight from the queue.
+func (h *RegistrationFormHandler) decide(ctx context.Context, tx *sqlx.Tx, form *models.RegistrationForm, outcome models.FormState, actorID string) error {
+    if form.Status == RegistrationPending {
+        if err := h.formRepo.UpdateStatusTx(ctx, tx, form.RegistrationFormID, models.FormUnderReview, actorID); err != nil {
+            return err
+        }
+    }
+    return h.formRepo.UpdateStatusTx(ctx, tx, form.RegistrationFormID, outcome, actorID)
+}
+
+// StartReview marks a pending form as under review by the calling officer.
+// PUT /api/registrations/:id/review
+func (h *RegistrationFormHandler) StartReview(c echo.Context) error {
+    ctx := c.Request().Context()
+    id := c.Param("id")
+    if err := h.formRepo.UpdateStatus(ctx, id, models.FormUnderReview, actorID(c)); err != nil {
+        return transitionError(c, err)
+    }
+    form, err := h.formRepo.GetByID(ctx, id)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, form)
+}
+
+// History lists a form's status changes, oldest first. Users only see their
+// own forms'.
+// GET /api/registrations/:id/history
+func (h *RegistrationFormHandler) History(c echo.Context) error {
+    form, ok := visibleForm(c, h.formRepo, c.Param("id"))
+    if !ok {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
+    list, err := h.history.ListByForm(c.Request().Context(), form.RegistrationFormID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, list)
+}
+
+// Submit creates a new registration form awaiting review.
+// POST /api/registrations
+func (h *RegistrationFormHandler) Submit(c echo.Context) error {
+    var params models.CreateRegistrationFormParams
+    if err := c.Bind(&params); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    if code, msg := checkApplicant(c, h.vehicleRepo, &params); code != 0 {
+        return c.JSON(code, map[string]string{"error": msg})
+    }
+    if params.LTOClientID == "" || params.VehicleID == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "lto_client_id and vehicle_id are required"})
+    }
+    params.Status = RegistrationPending
+
+    form, err := h.formRepo.Create(c.Request().Context(), &params)
+    if errors.Is(err, repository.ErrActiveRegistrationExists) {
+        return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+    }
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusCreated, form)
+}
+
+// GetByID returns a single registration form. Users only see their own.
+// GET /api/registrations/:id
+func (h *RegistrationFormHandler) GetByID(c echo.Context) error {
+    form, ok := visibleForm(c, h.formRepo, c.Param("id"))
+    if !ok {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
+    return c.JSON(http.StatusOK, form)
+}
+
+// ScanLogs returns one page of the scans recorded against a registration.
+// LTO officers can only see registrations in their region.
+// GET /api/registrations/:id/scan-logs?page=&limit=
+func (h *RegistrationFormHandler) ScanLogs(c echo.Context) error {
+    ctx := c.Request().Context()
+    id := c.Param("id")
+    form, err := h.formRepo.GetByID(ctx, id)
+    if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
+    if region := middleware.ScopedRegion(c); region != "" && form.Region != region {
+        return c.JSON(http.StatusForbidden, map[string]string{"error": "registration is outside your region"})
+    }
+
+    page, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    total, err := h.scanRepo.CountByRegistrationID(ctx, id)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    logs, err := h.scanRepo.GetByRegistrationID(ctx, id, limit, offset)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, PaginatedResponse(logs, total, page, limit))
+}
+
+// List returns registration forms, optionally filtered by status or applicant.
+// Users only see their own forms and LTO officers only forms filed in their
+// region.
+// GET /api/registrations?status=&lto_client_id=&page=&limit=
+func (h *RegistrationFormHandler) List(c echo.Context) error {
+    ctx := c.Request().Context()
+    region := middleware.ScopedRegion(c)
+    client := c.QueryParam("lto_client_id")
+    if role, _ := c.Get("role").(string); role == models.RoleUser {
+        client = actorID(c)
+    }
+    if client != "" && region == "" {
+        list, err := h.formRepo.GetByLTOClientID(ctx, client)
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return c.JSON(http.StatusOK, list)
+    }
+
+    page, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    var (
+        list  []models.RegistrationForm
+        total int
+    )
+    if region != "" {
+        list, total, err = h.formRepo.Search(ctx, repository.RegistrationSearchFilter{
+            Status:      c.QueryParam("status"),
+            LTOClientID: c.QueryParam("lto_client_id"),
+            Region:      region,
+            Limit:       limit,
+            Offset:      offset,
+        })
+    } else {
+        list, total, err = h.formRepo.GetByStatus(ctx, c.QueryParam("status"), limit, offset)
+    }
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, PaginatedResponse(list, total, page, limit))
+}
+
+// Search filters registrations by status, applicant, MV file number and
+// submission date. LTO officers are limited to their own region.
+// GET /api/registrations/search?status=&lto_client_id=&mv_file=&from=&to=&page=&limit=&format=csv
+func (h *RegistrationFormHandler) Search(c echo.Context) error {
+    filter := repository.RegistrationSearchFilter{
+        Status:       c.QueryParam("status"),
+        LTOClientID:  c.QueryParam("lto_client_id"),
+        MVFileNumber: c.QueryParam("mv_file"),
+    }
+    for name, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+        if v := c.QueryParam(name); v != "" {
+            t, err := time.Parse(time.RFC3339, v)
+            if err != nil {
+                return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid " + name + " (use RFC3339)"})
+            }
+            *dst = t
+        }
+    }
+    filter.Region = middleware.ScopedRegion(c)
+
+    if c.QueryParam("format") == "csv" {
+        list, _, err := h.formRepo.Search(c.Request().Context(), filter)
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return writeRegistrationsCSV(c, list)
+    }
+
+    page, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    filter.Limit, filter.Offset = limit, offset
+
+    list, total, err := h.formRepo.Search(c.Request().Context(), filter)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, PaginatedResponse(list, total, page, limit))
+}
+
+// writeRegistrationsCSV streams forms as a CSV attachment
+func writeRegistrationsCSV(c echo.Context, list []models.RegistrationForm) error {
+    res := c.Response()
+    res.Header().Set(echo.HeaderContentType, "text/csv")
+    res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="registrations.csv"`)
+    res.WriteHeader(http.StatusOK)
+
+    w := csv.NewWriter(res)
+    w.Write([]string{"registration_form_id", "lto_client_id", "vehicle_id", "submitted_date", "status", "region", "registration_type"})
+    for _, f := range list {
+        w.Write([]string{
+            f.RegistrationFormID,
+            f.LTOClientID,
+            f.VehicleID,
+            f.SubmittedDate.Format(time.RFC3339),
+            f.Status,
+            f.Region,
+            f.RegistrationType,
+        })
+    }
+    w.Flush()
+    return w.Error()
+}
+
+// ApproveRequest optionally picks the plate type issued on approval
+type ApproveRequest struct {
+    PlateType string `json:"plate_type"`
+}
+
+// Approve marks a pending form approved and issues the vehicle's plate.
+// PUT /api/registrations/:id/approve
+func (h *RegistrationFormHandler) Approve(c echo.Context) error {
+    ctx := c.Request().Context()
+    id := c.Param("id")
+
+    var req ApproveRequest
+    if err := c.Bind(&req); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    if req.PlateType == "" {
+        req.PlateType = "Private"
+    }
+
+    form, err := h.formRepo.GetByID(ctx, id)
+    if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
+    if form.Status != RegistrationPending && form.Status != RegistrationUnderReview {
+        return c.JSON(http.StatusConflict, map[string]string{"error": "only pending registrations can be approved"})
+    }
+    vehicle, err := h.vehicleRepo.GetVehicleByID(ctx, form.VehicleID)
+    if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "vehicle not found"})
+    }
+
+    number, err := plate.DefaultGenerator.Generate(ctx, vehicle.VEHICLE_TYPE, req.PlateType, form.Region, h.plateRepo)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    now := time.Now()
+    p := &models.Plate{
+        VEHICLE_ID:            vehicle.VEHICLE_ID,
+        PLATE_NUMBER:          number,
+        PLATE_TYPE:            req.PlateType,
+        PLATE_ISSUE_DATE:      now,
+        PLATE_EXPIRATION_DATE: now.AddDate(plateValidityYears, 0, 0),
+        STATUS:                "Active",
+    }
+
+    // approve and issue together so a failed plate insert leaves the form pending
+    err = h.tx.WithTx(ctx, func(tx *sqlx.Tx) error {
+        if err := h.decide(ctx, tx, form, models.FormApproved, actorID(c)); err != nil {
+            return err
+        }
+        _, err := h.plateRepo.CreatePlateTx(ctx, tx, p)
+        return err
+    })
+    if err != nil {
+        return transitionError(c, err)
+    }
+
+    if owner, err := h.userRepo.GetByLTOClientID(form.LTOClientID); err == nil {
+        reqID := middleware.GetRequestID(c)
+        go func() {
+            name := owner.FIRST_NAME + " " + owner.LAST_NAME
+            if err := email.SendRegistrationApprovalEmail(owner.EMAIL, name, vehicle.MV_FILE_NUMBER, p.PLATE_NUMBER, p.PLATE_EXPIRATION_DATE); err != nil {
+                log.Printf("[req-id:%s] approval email error: %v", reqID, err)
+            }
+        }()
+    }
+
+    form.Status = RegistrationApproved
+    return c.JSON(http.StatusOK, map[string]interface{}{
+        "registration": form,
+        "plate":        p,
+    })
+}
+
+// RejectRequest carries the reason shown to the applicant
+type RejectRequest struct {
+    Reason string `json:"reason"`
+}
+
+// Reject marks a pending form rejected and emails the reason to the applicant.
+// PUT /api/registrations/:id/reject
+func (h *RegistrationFormHandler) Reject(c echo.Context) error {
+    ctx := c.Request().Context()
+    id := c.Param("id")
+
+    var req RejectRequest
+    if err := c.Bind(&req); err != nil || req.Reason == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "reason is required"})
+    }
+
+    form, err := h.formRepo.GetByID(ctx, id)
+    if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
+    if form.Status != RegistrationPending && form.Status != RegistrationUnderReview {
+        return c.JSON(http.StatusConflict, map[string]string{"error": "only pending registrations can be rejected"})
+    }
+    err = h.tx.WithTx(ctx, func(tx *sqlx.Tx) error {
+        return h.decide(ctx, tx, form, models.FormRejected, actorID(c))
+    })
+    if err != nil {
+        return transitionError(c, err)
+    }
+
+    mvFile := ""
+    if v, err := h.vehicleRepo.GetVehicleByID(ctx, form.VehicleID); err == nil {
+        mvFile = v.MV_FILE_NUMBER
+    }
+    if owner, err := h.userRepo.GetByLTOClientID(form.LTOClientID); err == nil {
+        reqID := middleware.GetRequestID(c)
+        go func() {
+            name := owner.FIRST_NAME + " " + owner.LAST_NAME
+            if err := email.SendRegistrationRejectionEmail(owner.EMAIL, name, mvFile, req.Reason); err != nil {
+                log.Printf("[req-id:%s] rejection email error: %v", reqID, err)
+            }
+        }()
+    }
+
+    form.Status = RegistrationRejected
+    return c.JSON(http.StatusOK, form)
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/jmoiron/sqlx"
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+)
+
+// fakeFormRepo holds one registration form and records the statuses it is
+// moved through
+type fakeFormRepo struct {
+    repository.RegistrationFormRepository
+    form      *models.RegistrationForm
+    statusErr error // returned by UpdateStatusTx
+    updateErr error // returned by UpdateTx
+    moves     []models.FormState
+    listedFor []string // applicants passed to GetByLTOClientID
+    listedAll bool
+    created   []*models.CreateRegistrationFormParams
+    saved     []models.RegistrationForm // forms passed to UpdateTx
+}
+
+func (f *fakeFormRepo) GetByLTOClientID(ctx context.Context, ltoClientID string) ([]models.RegistrationForm, error) {
+    f.listedFor = append(f.listedFor, ltoClientID)
+    if f.form == nil || f.form.LTOClientID != ltoClientID {
+        return nil, nil
+    }
+    return []models.RegistrationForm{*f.form}, nil
+}
+
+func (f *fakeFormRepo) GetAll(ctx context.Context) ([]models.RegistrationForm, error) {
+    f.listedAll = true
+    return []models.RegistrationForm{*f.form}, nil
+}
+
+func (f *fakeFormRepo) Create(ctx context.Context, p *models.CreateRegistrationFormParams) (*models.RegistrationForm, error) {
+    f.created = append(f.created, p)
+    return &models.RegistrationForm{RegistrationFormID: "f-new", LTOClientID: p.LTOClientID, VehicleID: p.VehicleID, Status: p.Status}, nil
+}
+
+func (f *fakeFormRepo) GetByID(ctx context.Context, id string) (*models.RegistrationForm, error) {
+    if f.form == nil || f.form.RegistrationFormID != id {
+        return nil, sql.ErrNoRows
+    }
+    return f.form, nil
+}
+
+func (f *fakeFormRepo) UpdateStatusTx(ctx context.Context, tx *sqlx.Tx, id string, to models.FormState, actorID string) error {
+    if tx == nil {
+        return errors.New("status change outside a transaction")
+    }
+    if f.statusErr != nil {
+        return f.statusErr
+    }
+    f.moves = append(f.moves, to)
+    return nil
+}
+
+func (f *fakeFormRepo) UpdateTx(ctx context.Context, tx *sqlx.Tx, form *models.RegistrationForm) error {
+    if tx == nil {
+        return errors.New("update outside a transaction")
+    }
+    if f.updateErr != nil {
+        return f.updateErr
+    }
+    f.saved = append(f.saved, *form)
+    return nil
+}
+
+// fakeHistoryRepo returns one transition for any form
+type fakeHistoryRepo struct {
+    repository.FormStateHistoryRepository
+}
+
+func (fakeHistoryRepo) ListByForm(ctx context.Context, formID string) ([]models.FormStateHistory, error) {
+    return []models.FormStateHistory{{RegistrationFormID: formID, FromState: models.FormDraft, ToState: models.FormSubmitted}}, nil
+}
+
+// fakeUserRepo finds nobody, so no notification email is sent
+type fakeUserRepo struct {
+    repository.UserRepository
+}
+
+func (fakeUserRepo) GetByLTOClientID(ltoClientID string) (models.User, error) {
+    return models.User{}, sql.ErrNoRows
+}
+
+// fakeInspectionRepo holds inspections by id
+type fakeInspectionRepo struct {
+    repository.RegistrationInspectionRepository
+    inspections map[string]*models.RegistrationInspection
+}
+
+func (f *fakeInspectionRepo) GetByID(ctx context.Context, id string) (*models.RegistrationInspection, error) {
+    i, ok := f.inspections[id]
+    if !ok {
+        return nil, sql.ErrNoRows
+    }
+    return i, nil
+}
+
+// formRoutes maps the registration form routes to their handlers, wrapped
+// the way cmd/main.go wires them
+func formRoutes(rfh *RegistrationFormHandler, rh *RegistrationHandler) map[string]echo.HandlerFunc {
+    scoped := middleware.RegionScope()
+    return map[string]echo.HandlerFunc{
+        "POST /api/registrations":                           rfh.Submit,
+        "GET /api/registrations":                            scoped(rfh.List),
+        "GET /api/registrations/:id":                        scoped(rfh.GetByID),
+        "GET /api/registrations/:id/history":                scoped(rfh.History),
+        "POST /api/registration-form":                       scoped(rh.CreateForm),
+        "GET /api/registration-form":                        scoped(rh.GetAllForms),
+        "GET /api/registration-form/:id":                    scoped(rh.OwnForm(rh.GetFormByID)),
+        "PUT /api/registration-form/:id":                    scoped(rh.OwnForm(rh.UpdateForm)),
+        "DELETE /api/registration-form/:id":                 scoped(rh.OwnForm(rh.DeleteForm)),
+        "GET /api/registration-form/:id/inspection/:inspId": scoped(rh.OwnForm(rh.GetInspection)),
+    }
+}
+
+// officerRegions is the region in each test officer's token
+var officerRegions = map[string]string{"officer-1": "NCR", "officer-2": "Region IV-A"}
+
+func TestRegistrationFormOwnership(t *testing.T) {
+    tests := []struct {
+        name     string
+        role     string
+        caller   string
+        params   map[string]string
+        body     string
+        route    string
+        wantCode int
+    }{
+        {"owner reads registration", models.RoleUser, "owner-1", map[string]string{"id": "f1"}, "", "GET /api/registrations/:id", http.StatusOK},
+        {"other user reads registration", models.RoleUser, "owner-2", map[string]string{"id": "f1"}, "", "GET /api/registrations/:id", http.StatusNotFound},
+        {"officer reads registration", models.RoleOfficer, "officer-1", map[string]string{"id": "f1"}, "", "GET /api/registrations/:id", http.StatusOK},
+        {"owner reads history", models.RoleUser, "owner-1", map[string]string{"id": "f1"}, "", "GET /api/registrations/:id/history", http.StatusOK},
+        {"other user reads history", models.RoleUser, "owner-2", map[string]string{"id": "f1"}, "", "GET /api/registrations/:id/history", http.StatusNotFound},
+        {"history of a missing form", models.RoleOfficer, "officer-1", map[string]string{"id": "f9"}, "", "GET /api/registrations/:id/history", http.StatusNotFound},
+        {"officer reads another region's registration", models.RoleOfficer, "officer-2", map[string]string{"id": "f1"}, "", "GET /api/registrations/:id", http.StatusNotFound},
+        {"officer reads another region's history", models.RoleOfficer, "officer-2", map[string]string{"id": "f1"}, "", "GET /api/registrations/:id/history", http.StatusNotFound},
+        {"officer reads another region's form", models.RoleOfficer, "officer-2", map[string]string{"id": "f1"}, "", "GET /api/registration-form/:id", http.StatusNotFound},
+        {"officer edits another region's form", models.RoleOfficer, "officer-2", map[string]string{"id": "f1"}, `{"plate_number":"ABC 1234"}`, "PUT /api/registration-form/:id", http.StatusNotFound},
+        {"officer reads own region's form", models.RoleOfficer, "officer-1", map[string]string{"id": "f1"}, "", "GET /api/registration-form/:id", http.StatusOK},
+        {"admin reads any region's form", models.RoleAdmin, "admin-1", map[string]string{"id": "f1"}, "", "GET /api/registration-form/:id", http.StatusOK},
+        {"owner reads form", models.RoleUser, "owner-1", map[string]string{"id": "f1"}, "", "GET /api/registration-form/:id", http.StatusOK},
+        {"other user reads form", models.RoleUser, "owner-2", map[string]string{"id": "f1"}, "", "GET /api/registration-form/:id", http.StatusNotFound},
+        {"other user deletes form", models.RoleUser, "owner-2", map[string]string{"id": "f1"}, "", "DELETE /api/registration-form/:id", http.StatusNotFound},
+        {"owner hands form to someone else", models.RoleUser, "owner-1", map[string]string{"id": "f1"}, `{"lto_client_id":"owner-2"}`, "PUT /api/registration-form/:id", http.StatusForbidden},
+        {"owner moves form to another's vehicle", models.RoleUser, "owner-1", map[string]string{"id": "f1"}, `{"vehicle_id":"v2"}`, "PUT /api/registration-form/:id", http.StatusForbidden},
+        {"owner reads another form's inspection", models.RoleUser, "owner-1", map[string]string{"id": "f1", "inspId": "i2"}, "", "GET /api/registration-form/:id/inspection/:inspId", http.StatusNotFound},
+        {"owner reads own inspection", models.RoleUser, "owner-1", map[string]string{"id": "f1", "inspId": "i1"}, "", "GET /api/registration-form/:id/inspection/:inspId", http.StatusOK},
+        {"user submits for someone else", models.RoleUser, "owner-2", nil, `{"lto_client_id":"owner-1","vehicle_id":"v1"}`, "POST /api/registrations", http.StatusForbidden},
+        {"user submits another's vehicle", models.RoleUser, "owner-2", nil, `{"vehicle_id":"v1"}`, "POST /api/registrations", http.StatusForbidden},
+        {"owner submits", models.RoleUser, "owner-1", nil, `{"vehicle_id":"v1"}`, "POST /api/registrations", http.StatusCreated},
+        {"user creates form for another's vehicle", models.RoleUser, "owner-2", nil, `{"vehicle_id":"v1"}`, "POST /api/registration-form", http.StatusForbidden},
+        {"officer submits for owner", models.RoleOfficer, "officer-1", nil, `{"lto_client_id":"owner-1","vehicle_id":"v1"}`, "POST /api/registrations", http.StatusCreated},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            forms := &fakeFormRepo{form: &models.RegistrationForm{RegistrationFormID: "f1", LTOClientID: "owner-1", VehicleID: "v1", Status: string(models.FormDraft), Region: "NCR"}}
+            vehicles := &fakeVehicleRepo{vehicles: map[string]*models.Vehicle{
+                "v1": {VEHICLE_ID: "v1", LTO_CLIENT_ID: "owner-1"},
+                "v2": {VEHICLE_ID: "v2", LTO_CLIENT_ID: "owner-2"},
+            }}
+            insps := &fakeInspectionRepo{inspections: map[string]*models.RegistrationInspection{
+                "i1": {InspectionID: "i1", RegistrationFormID: "f1"},
+                "i2": {InspectionID: "i2", RegistrationFormID: "f2"},
+            }}
+            rfh := NewRegistrationFormHandler(forms, &fakePlateRepo{}, vehicles, fakeUserRepo{}, nil, fakeHistoryRepo{}, nil)
+            rh := NewRegistrationHandler(forms, insps, nil, nil, vehicles, nil)
+
+            req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+            req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(req, rec)
+            var names, values []string
+            for name, value := range tt.params {
+                names, values = append(names, name), append(values, value)
+            }
+            c.SetParamNames(names...)
+            c.SetParamValues(values...)
+            c.Set("role", tt.role)
+            c.Set("lto_client_id", tt.caller)
+            c.Set("region", officerRegions[tt.caller])
+            if err := formRoutes(rfh, rh)[tt.route](c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if tt.wantCode != http.StatusCreated && len(forms.created) > 0 {
+                t.Fatalf("form created for %q after a %d", forms.created[0].LTOClientID, rec.Code)
+            }
+            if tt.wantCode == http.StatusCreated && forms.created[0].LTOClientID != "owner-1" {
+                t.Fatalf("form filed for %q, want owner-1", forms.created[0].LTOClientID)
+            }
+        })
+    }
+}
+
+func TestListFormsScopedToCaller(t *testing.T) {
+    tests := []struct {
+        name       string
+        role       string
+        caller     string
+        query      string
+        route      string
+        wantListed []string
+        wantAll    bool
+    }{
+        {"user asks for another's registrations", models.RoleUser, "owner-2", "?lto_client_id=owner-1", "GET /api/registrations", []string{"owner-2"}, false},
+        {"user lists registrations", models.RoleUser, "owner-1", "", "GET /api/registrations", []string{"owner-1"}, false},
+        {"admin asks for an applicant's registrations", models.RoleAdmin, "admin-1", "?lto_client_id=owner-1", "GET /api/registrations", []string{"owner-1"}, false},
+        {"user lists forms", models.RoleUser, "owner-2", "", "GET /api/registration-form", []string{"owner-2"}, false},
+        {"officer lists forms", models.RoleOfficer, "officer-1", "", "GET /api/registration-form", nil, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            forms := &fakeFormRepo{form: &models.RegistrationForm{RegistrationFormID: "f1", LTOClientID: "owner-1", VehicleID: "v1"}}
+            rfh := NewRegistrationFormHandler(forms, nil, nil, fakeUserRepo{}, nil, nil, nil)
+            rh := NewRegistrationHandler(forms, nil, nil, nil, nil, nil)
+
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/"+tt.query, nil), rec)
+            c.Set("role", tt.role)
+            c.Set("lto_client_id", tt.caller)
+            c.Set("region", officerRegions[tt.caller])
+            if err := formRoutes(rfh, rh)[tt.route](c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != http.StatusOK {
+                t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+            }
+            if fmt.Sprint(forms.listedFor) != fmt.Sprint(tt.wantListed) || forms.listedAll != tt.wantAll {
+                t.Fatalf("listed forms for %v (all = %v), want %v (all = %v)", forms.listedFor, forms.listedAll, tt.wantListed, tt.wantAll)
+            }
+        })
+    }
+}
+
+func TestApproveRollsBackOnFailure(t *testing.T) {
+    tests := []struct {
+        name      string
+        statusErr error
+        createErr error
+        wantCode  int
+        commit    bool
+    }{
+        {"approved", nil, nil, http.StatusOK, true},
+        {"plate insert fails", nil, errors.New("duplicate plate_number"), http.StatusInternalServerError, false},
+        {"status update fails", fmt.Errorf("%w: Submitted -> Approved", models.ErrInvalidTransition), nil, http.StatusConflict, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            raw, mock, err := sqlmock.New()
+            if err != nil {
+                t.Fatal(err)
+            }
+            defer raw.Close()
+            mock.ExpectBegin()
+            if tt.commit {
+                mock.ExpectCommit()
+            } else {
+                mock.ExpectRollback()
+            }
+
+            forms := &fakeFormRepo{
+                form:      &models.RegistrationForm{RegistrationFormID: "f1", VehicleID: "v1", Status: RegistrationPending, Region: "NCR"},
+                statusErr: tt.statusErr,
+            }
+            vehicles := &fakeVehicleRepo{vehicles: map[string]*models.Vehicle{
+                "v1": {VEHICLE_ID: "v1", VEHICLE_TYPE: "4-Wheel"},
+            }}
+            plates := &fakePlateRepo{createErr: tt.createErr}
+            h := NewRegistrationFormHandler(forms, plates, vehicles, fakeUserRepo{}, nil, nil,
+                repository.NewTransactor(sqlx.NewDb(raw, "postgres")))
+
+            req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"plate_type":"Private"}`))
+            req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(req, rec)
+            c.SetParamNames("id")
+            c.SetParamValues("f1")
+            if err := h.Approve(c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if err := mock.ExpectationsWereMet(); err != nil {
+                t.Fatal(err)
+            }
+            if tt.commit && len(plates.plates) != 1 {
+                t.Fatalf("approved with %d plates issued, want 1", len(plates.plates))
+            }
+            if tt.commit && fmt.Sprint(forms.moves) != fmt.Sprint([]models.FormState{models.FormUnderReview, models.FormApproved}) {
+                t.Fatalf("form moved through %v", forms.moves)
+            }
+        })
+    }
+}
+
+func TestUpdateFormIsOneTransaction(t *testing.T) {
+    tests := []struct {
+        name      string
+        body      string
+        statusErr error
+        updateErr error
+        wantCode  int
+        commit    bool
+        wantMoves []models.FormState
+    }{
+        {"fields only", `{"registration_type":"Renewal"}`, nil, nil, http.StatusNoContent, true, nil},
+        {"status and fields", `{"status":"Pending","registration_type":"Renewal"}`, nil, nil, http.StatusNoContent, true, []models.FormState{models.FormSubmitted}},
+        {"invalid transition", `{"status":"Approved","registration_type":"Renewal"}`, fmt.Errorf("%w: Draft -> Approved", models.ErrInvalidTransition), nil, http.StatusConflict, false, nil},
+        {"vehicle already has an active form", `{"registration_type":"Renewal"}`, nil, repository.ErrActiveRegistrationExists, http.StatusConflict, false, nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            raw, mock, err := sqlmock.New()
+            if err != nil {
+                t.Fatal(err)
+            }
+            defer raw.Close()
+            mock.ExpectBegin()
+            if tt.commit {
+                mock.ExpectCommit()
+            } else {
+                mock.ExpectRollback()
+            }
+
+            forms := &fakeFormRepo{
+                form:      &models.RegistrationForm{RegistrationFormID: "f1", LTOClientID: "owner-1", VehicleID: "v1", Status: string(models.FormDraft), RegistrationType: "New"},
+                statusErr: tt.statusErr,
+                updateErr: tt.updateErr,
+            }
+            rh := NewRegistrationHandler(forms, nil, nil, nil, nil, repository.NewTransactor(sqlx.NewDb(raw, "postgres")))
+
+            req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body))
+            req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(req, rec)
+            c.SetParamNames("id")
+            c.SetParamValues("f1")
+            c.Set("role", models.RoleOfficer)
+            c.Set("lto_client_id", "officer-1")
+            if err := rh.UpdateForm(c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if err := mock.ExpectationsWereMet(); err != nil {
+                t.Fatal(err)
+            }
+            if fmt.Sprint(forms.moves) != fmt.Sprint(tt.wantMoves) {
+                t.Fatalf("form moved through %v, want %v", forms.moves, tt.wantMoves)
+            }
+            if !tt.commit {
+                if len(forms.saved) > 0 {
+                    t.Fatalf("fields saved by a failed update: %+v", forms.saved)
+                }
+                return
+            }
+            if len(forms.saved) != 1 || forms.saved[0].RegistrationType != "Renewal" {
+                t.Fatalf("saved %+v, want one update with registration_type Renewal", forms.saved)
+            }
+        })
+    }
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+    "fmt"
+    "log"
+    "net/http"
+
+    "github.com/labstack/echo/v4"
+    "smartplate-api/internal/email"
+    "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+    "smartplate-api/internal/sms"
+)
+
+// ScanAlertHandler lets officers flag a logged scan as suspicious, such as a
+// deactivated or cloned plate seen on the road, and alerts the plate's owner.
+type ScanAlertHandler struct {
+    scans  repository.ScanLogRepository
+    plates repository.PlateRepository
+    users  repository.UserRepository
+    prefs  repository.NotificationPreferencesRepository
+    sms    sms.SMSSender
+}
+
+// NewScanAlertHandler creates a ScanAlertHandler. Without a preferences
+// repository every owner is alerted on every channel.
+func NewScanAlertHandler(
+    sr repository.ScanLogRepository,
+    pr repository.PlateRepository,
+    ur repository.UserRepository,
+    np repository.NotificationPreferencesRepository,
+    s sms.SMSSender,
+) *ScanAlertHandler {
+    return &ScanAlertHandler{scans: sr, plates: pr, users: ur, prefs: np, sms: s}
+}
+
+// ScanAlertResponse reports which channels the owner was alerted on
+type ScanAlertResponse struct {
+    SMSSent   bool `json:"sms_sent"`
+    EmailSent bool `json:"email_sent"`
+}
+
+// scanAlertLocation describes where a scan happened for the alert text
+func scanAlertLocation(entry *models.ScanLog) string {
+    if entry.Latitude != nil && entry.Longitude != nil {
+        return fmt.Sprintf("%.5f, %.5f", *entry.Latitude, *entry.Longitude)
+    }
+    if entry.ScannerDeviceID != nil && *entry.ScannerDeviceID != "" {
+        return "scanner " + *entry.ScannerDeviceID
+    }
+    return ""
+}
+
+// ReportSuspicious flags a logged scan as suspicious and alerts the plate's
+// registered owner by text and email, skipping any channel they have turned
+// off or have no address for. A channel that fails is logged and reported as
+// not sent. LTO officers can only flag scans from their region.
+// @Summary Report a suspicious scan
+// @Tags scan-log
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Scan log ID"
+// @Success 200 {object} ScanAlertResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/scan-log/{id}/suspicious [post]
+func (h *ScanAlertHandler) ReportSuspicious(c echo.Context) error {
+    ctx := c.Request().Context()
+    var (
+        entry *models.ScanLog
+        err   error
+    )
+    if region := middleware.ScopedRegion(c); region != "" {
+        entry, err = h.scans.GetByIDInRegion(ctx, c.Param("id"), region)
+    } else {
+        entry, err = h.scans.GetByID(ctx, c.Param("id"))
+    }
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    if entry == nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "scan log entry not found"})
+    }
+    plate, err := h.plates.GetPlateByPlateID(ctx, entry.PlateID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    if plate == nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "plate not found"})
+    }
+    owner, err := h.users.GetByLTOClientID(entry.LTOClientID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    prefs := models.DefaultNotificationPreferences(owner.LTO_CLIENT_ID)
+    if h.prefs != nil {
+        saved, err := h.prefs.GetByLTOClientID(ctx, owner.LTO_CLIENT_ID)
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        prefs = *saved
+    }
+
+    var resp ScanAlertResponse
+    location := scanAlertLocation(entry)
+    if mobile := owner.Contact.MOBILE_NUMBER; prefs.SMSScanAlert && mobile != nil && *mobile != "" {
+        if err := h.sms.SendScanAlert(*mobile, plate.PLATE_NUMBER, location); err != nil {
+            log.Printf("[req-id:%s] scan alert sms error for plate %s: %v", middleware.GetRequestID(c), plate.PlateID, err)
+        } else {
+            resp.SMSSent = true
+        }
+    }
+    if prefs.EmailScanAlert && owner.EMAIL != "" {
+        name := owner.FIRST_NAME + " " + owner.LAST_NAME
+        if err := email.SendScanAlertEmail(owner.EMAIL, name, plate.PLATE_NUMBER, location, entry.ScannedAt); err != nil {
+            log.Printf("[req-id:%s] scan alert email error for plate %s: %v", middleware.GetRequestID(c), plate.PlateID, err)
+        } else {
+            resp.EmailSent = true
+        }
+    }
+    return c.JSON(http.StatusOK, resp)
+}
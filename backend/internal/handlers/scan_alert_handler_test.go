@@ -0,0 +1,183 @@
+package handlers
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "strings"
+    "testing"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+    "smartplate-api/internal/testutil"
+)
+
+func (f *fakeScanRepo) GetByID(ctx context.Context, id string) (*models.ScanLog, error) {
+    for i := range f.scans {
+        if f.scans[i].LogID == id {
+            return &f.scans[i], nil
+        }
+    }
+    return nil, nil
+}
+
+func (f *fakeScanRepo) GetByIDInRegion(ctx context.Context, id, region string) (*models.ScanLog, error) {
+    entry, err := f.GetByID(ctx, id)
+    if entry == nil || f.regions[entry.RegistrationID] != region {
+        return nil, err
+    }
+    return entry, nil
+}
+
+func (f *fakePlateRepo) GetPlateByPlateID(ctx context.Context, plateID string) (*models.Plate, error) {
+    return f.plates[plateID], nil
+}
+
+// fakePrefsRepo holds notification preferences by LTO client id
+type fakePrefsRepo struct {
+    repository.NotificationPreferencesRepository
+    prefs map[string]models.NotificationPreferences
+}
+
+func (f *fakePrefsRepo) GetByLTOClientID(ctx context.Context, ltoClientID string) (*models.NotificationPreferences, error) {
+    p, ok := f.prefs[ltoClientID]
+    if !ok {
+        return nil, errors.New("no preferences")
+    }
+    return &p, nil
+}
+
+// fakeSMS records the scan alerts it was asked to send
+type fakeSMS struct {
+    alerts []string
+    err    error
+}
+
+func (f *fakeSMS) SendPlateExpiry(to, plateNumber string, daysUntilExpiry int) error {
+    return nil
+}
+
+func (f *fakeSMS) SendScanAlert(to, plateNumber, location string) error {
+    if f.err != nil {
+        return f.err
+    }
+    f.alerts = append(f.alerts, fmt.Sprintf("%s %s at %s", to, plateNumber, location))
+    return nil
+}
+
+func TestReportSuspiciousScan(t *testing.T) {
+    t.Setenv("SKIP_EMAIL_SENDING", "true")
+    var logged bytes.Buffer
+    log.SetOutput(&logged)
+    t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+    mobile, lat, lon, device := "+639171234567", 14.59951, 120.98422, "scanner-ncr-017"
+    owner := models.User{LTO_CLIENT_ID: "LTO-1", EMAIL: "juan@example.com", ROLE: models.RoleUser}
+    owner.Contact.MOBILE_NUMBER = &mobile
+    noMobile := models.User{LTO_CLIENT_ID: "LTO-2", EMAIL: "maria@example.com", ROLE: models.RoleUser}
+    noSMS := models.User{LTO_CLIENT_ID: "LTO-3", EMAIL: "jose@example.com", ROLE: models.RoleUser}
+    noSMS.Contact.MOBILE_NUMBER = &mobile
+    noEmail := models.User{LTO_CLIENT_ID: "LTO-4", EMAIL: "ana@example.com", ROLE: models.RoleUser}
+    noEmail.Contact.MOBILE_NUMBER = &mobile
+
+    tests := []struct {
+        name       string
+        id         string
+        smsErr     error
+        wantCode   int
+        wantAlerts []string
+        wantEmail  string // recipient of the alert email, if one is sent
+    }{
+        {"alerts the owner with the scan's location", "s1", nil, http.StatusOK, []string{"+639171234567 ABC 1234 at 14.59951, 120.98422"}, "juan@example.com"},
+        {"names the scanner without a location", "s2", nil, http.StatusOK, []string{"+639171234567 ABC 1234 at scanner scanner-ncr-017"}, "juan@example.com"},
+        {"owner has no mobile number", "s3", nil, http.StatusOK, nil, "maria@example.com"},
+        {"owner turned scan alert texts off", "s4", nil, http.StatusOK, nil, "jose@example.com"},
+        {"owner turned scan alert emails off", "s5", nil, http.StatusOK, []string{"+639171234567 ABC 1234 at "}, ""},
+        {"unknown scan", "s9", nil, http.StatusNotFound, nil, ""},
+        {"sms fails, email still goes", "s1", errors.New("twilio down"), http.StatusOK, nil, "juan@example.com"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            logged.Reset()
+            scans := &fakeScanRepo{scans: []models.ScanLog{
+                {LogID: "s1", PlateID: "p1", LTOClientID: "LTO-1", Latitude: &lat, Longitude: &lon},
+                {LogID: "s2", PlateID: "p1", LTOClientID: "LTO-1", ScannerDeviceID: &device},
+                {LogID: "s3", PlateID: "p1", LTOClientID: "LTO-2"},
+                {LogID: "s4", PlateID: "p1", LTOClientID: "LTO-3"},
+                {LogID: "s5", PlateID: "p1", LTOClientID: "LTO-4"},
+            }}
+            plates := &fakePlateRepo{plates: map[string]*models.Plate{"p1": {PlateID: "p1", PLATE_NUMBER: "ABC 1234"}}}
+            on := models.DefaultNotificationPreferences
+            prefs := &fakePrefsRepo{prefs: map[string]models.NotificationPreferences{
+                "LTO-1": on("LTO-1"),
+                "LTO-2": on("LTO-2"),
+                "LTO-3": {LTOClientID: "LTO-3", EmailScanAlert: true},
+                "LTO-4": {LTOClientID: "LTO-4", SMSScanAlert: true},
+            }}
+            sender := &fakeSMS{err: tt.smsErr}
+            users := testutil.NewMockUserRepository(owner, noMobile, noSMS, noEmail)
+            h := NewScanAlertHandler(scans, plates, users, prefs, sender)
+
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+            c.SetParamNames("id")
+            c.SetParamValues(tt.id)
+            if err := h.ReportSuspicious(c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if fmt.Sprint(sender.alerts) != fmt.Sprint(tt.wantAlerts) {
+                t.Fatalf("texted %q, want %q", sender.alerts, tt.wantAlerts)
+            }
+            emailed := strings.Contains(logged.String(), "Suspicious Scan Alert")
+            if emailed != (tt.wantEmail != "") || !strings.Contains(logged.String(), tt.wantEmail) {
+                t.Fatalf("log %q, want an alert email to %q", logged.String(), tt.wantEmail)
+            }
+            if tt.wantCode != http.StatusOK {
+                return
+            }
+            var got ScanAlertResponse
+            if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+                t.Fatal(err)
+            }
+            want := ScanAlertResponse{SMSSent: len(tt.wantAlerts) > 0, EmailSent: tt.wantEmail != ""}
+            if got != want {
+                t.Fatalf("response = %+v, want %+v", got, want)
+            }
+        })
+    }
+}
+
+func TestReportSuspiciousScanOutsideRegion(t *testing.T) {
+    scans := &fakeScanRepo{
+        scans:   []models.ScanLog{{LogID: "s1", PlateID: "p1", RegistrationID: "f1", LTOClientID: "LTO-1"}},
+        regions: map[string]string{"f1": "NCR"},
+    }
+    sender := &fakeSMS{}
+    h := NewScanAlertHandler(scans, &fakePlateRepo{}, testutil.NewMockUserRepository(), nil, sender)
+
+    rec := httptest.NewRecorder()
+    c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+    c.SetParamNames("id")
+    c.SetParamValues("s1")
+    c.Set(middleware.RegionKey, "Region IV-A")
+    if err := h.ReportSuspicious(c); err != nil {
+        t.Fatal(err)
+    }
+    if rec.Code != http.StatusNotFound || len(sender.alerts) != 0 {
+        t.Fatalf("status = %d with alerts %q, want 404 and none", rec.Code, sender.alerts)
+    }
+}
@@ -1,24 +1,43 @@
 package handlers
 
 import (
+    "context"
+    "encoding/csv"
+    "fmt"
+    "net"
     "net/http"
+    "strconv"
+    "time"
 
     "github.com/labstack/echo/v4"
+    "smartplate-api/internal/middleware"
     "smartplate-api/internal/models"
+    "smartplate-api/internal/report"
     "smartplate-api/internal/repository"
 )
 
 // ScanLogHandler handles HTTP requests for scan_log entries.
 type ScanLogHandler struct {
     repo repository.ScanLogRepository
+    loc  *time.Location // APP_TIMEZONE, for hour-of-day reports
 }
 
 // NewScanLogHandler creates a new ScanLogHandler.
-func NewScanLogHandler(repo repository.ScanLogRepository) *ScanLogHandler {
-    return &ScanLogHandler{repo: repo}
+func NewScanLogHandler(repo repository.ScanLogRepository, loc *time.Location) *ScanLogHandler {
+    return &ScanLogHandler{repo: repo, loc: loc}
 }
 
 // Create logs a new scan entry from JSON payload.
+// @Summary Record a scan
+// @Tags scan-log
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param entry body models.ScanLog true "Scan entry"
+// @Success 201 {object} models.ScanLog
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/scan-log [post]
 func (h *ScanLogHandler) Create(c echo.Context) error {
     var entry models.ScanLog
     if err := c.Bind(&entry); err != nil {
@@ -26,25 +45,60 @@ func (h *ScanLogHandler) Create(c echo.Context) error {
     }
     // Set timestamp server-side for consistency
     entry.ScannedAt = entry.ScannedAt // assume it's set by client or elsewhere
+    entry.ScannerIP = nil             // only recorded for scanner WebSocket connections
     if err := h.repo.Create(c.Request().Context(), &entry); err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
     }
     return c.JSON(http.StatusCreated, entry)
 }
 
-// GetAll retrieves all scan_log entries.
+// GetAll retrieves all scan_log entries; LTO officers only see scans from
+// their region.
+// @Summary List scans
+// @Tags scan-log
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.ScanLog
+// @Failure 500 {object} map[string]string
+// @Router /api/scan-log [get]
 func (h *ScanLogHandler) GetAll(c echo.Context) error {
-    logs, err := h.repo.GetAll(c.Request().Context())
+    var (
+        logs []models.ScanLog
+        err  error
+    )
+    if region := middleware.ScopedRegion(c); region != "" {
+        logs, err = h.repo.GetByRegion(c.Request().Context(), region)
+    } else {
+        logs, err = h.repo.GetAll(c.Request().Context())
+    }
     if err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
     }
     return c.JSON(http.StatusOK, logs)
 }
 
-// GetByID retrieves a single scan_log entry by its log_id.
+// GetByID retrieves a single scan_log entry by its log_id. LTO officers only
+// see scans whose registration was filed in their region.
+// @Summary Get a scan
+// @Tags scan-log
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Log ID"
+// @Success 200 {object} models.ScanLog
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/scan-log/{id} [get]
 func (h *ScanLogHandler) GetByID(c echo.Context) error {
-    id := c.Param("id")
-    entry, err := h.repo.GetByID(c.Request().Context(), id)
+    var (
+        id    = c.Param("id")
+        entry *models.ScanLog
+        err   error
+    )
+    if region := middleware.ScopedRegion(c); region != "" {
+        entry, err = h.repo.GetByIDInRegion(c.Request().Context(), id, region)
+    } else {
+        entry, err = h.repo.GetByID(c.Request().Context(), id)
+    }
     if err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
     }
@@ -53,3 +107,399 @@ func (h *ScanLogHandler) GetByID(c echo.Context) error {
     }
     return c.JSON(http.StatusOK, entry)
 }
+
+// scanExportFlushEvery is how many CSV rows ExportCSV buffers between flushes
+const scanExportFlushEvery = 500
+
+// ExportCSV streams every scan_log entry as a CSV attachment without
+// loading the table into memory.
+// @Summary Export all scans as CSV
+// @Tags admin
+// @Produce text/csv
+// @Security BearerAuth
+// @Success 200 {file} file
+// @Router /api/admin/scan-log/export [get]
+func (h *ScanLogHandler) ExportCSV(c echo.Context) error {
+    ctx, cancel := context.WithCancel(c.Request().Context())
+    defer cancel()
+
+    rows := make(chan models.ScanLog, scanExportFlushEvery)
+    errc := make(chan error, 1)
+    go func() { errc <- h.repo.StreamAll(ctx, rows) }()
+
+    res := c.Response()
+    res.Header().Set(echo.HeaderContentType, "text/csv")
+    res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="scan_log.csv"`)
+    res.WriteHeader(http.StatusOK)
+
+    w := csv.NewWriter(res)
+    w.Write([]string{"log_id", "plate_id", "registration_id", "lto_client_id", "scanned_at"})
+    n := 0
+    for entry := range rows {
+        w.Write([]string{
+            entry.LogID,
+            entry.PlateID,
+            entry.RegistrationID,
+            entry.LTOClientID,
+            entry.ScannedAt.Format(time.RFC3339),
+        })
+        if n++; n%scanExportFlushEvery == 0 {
+            w.Flush()
+            res.Flush()
+            if w.Error() != nil {
+                // client went away; stop the cursor and drain what's in flight
+                cancel()
+                for range rows {
+                }
+                break
+            }
+        }
+    }
+    w.Flush()
+    if err := <-errc; err != nil {
+        return err
+    }
+    return w.Error()
+}
+
+// ReportPDF renders one month of scans as a PDF table with a summary, in
+// the server's APP_TIMEZONE. Rows are streamed from the database into the
+// document without collecting them first; gofpdf still assembles the
+// finished file before it is written to the response.
+// @Summary Monthly scan report as PDF
+// @Tags admin
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param month query string true "Month as YYYY-MM"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/scan-logs/report.pdf [get]
+func (h *ScanLogHandler) ReportPDF(c echo.Context) error {
+    month, err := time.ParseInLocation("2006-01", c.QueryParam("month"), h.loc)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "month must be YYYY-MM"})
+    }
+    from, to := month, month.AddDate(0, 1, 0)
+
+    ctx, cancel := context.WithCancel(c.Request().Context())
+    defer cancel()
+    summary, err := h.repo.ReportSummary(ctx, from, to)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+
+    stream := func(draw func(models.ScanReportRow)) error {
+        rows := make(chan models.ScanReportRow, scanExportFlushEvery)
+        errc := make(chan error, 1)
+        go func() { errc <- h.repo.StreamReport(ctx, from, to, rows) }()
+        for row := range rows {
+            draw(row)
+        }
+        return <-errc
+    }
+
+    res := c.Response()
+    res.Header().Set(echo.HeaderContentType, "application/pdf")
+    res.Header().Set(echo.HeaderContentDisposition,
+        fmt.Sprintf(`attachment; filename="scan_report_%s.pdf"`, month.Format("2006-01")))
+    if err := report.WriteScanReport(res, month, h.loc, *summary, stream); err != nil {
+        if res.Committed {
+            return err
+        }
+        res.Header().Del(echo.HeaderContentDisposition)
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return nil
+}
+
+const (
+    // maxBulkScans is the largest batch BulkCreate accepts
+    maxBulkScans = 1000
+    // maxScanAge is how far back an offline scanner's scans may be dated
+    maxScanAge = 7 * 24 * time.Hour
+    // scanClockSkew tolerates scanner clocks running slightly ahead of ours
+    scanClockSkew = time.Minute
+)
+
+// BulkScanEntry is one scan in a batch upload
+type BulkScanEntry struct {
+    PlateID        string    `json:"plate_id"`
+    RegistrationID string    `json:"registration_id"`
+    LTOClientID    string    `json:"lto_client_id"`
+    ScannedAt      time.Time `json:"scanned_at"`
+    Latitude       *float64  `json:"latitude,omitempty"`
+    Longitude      *float64  `json:"longitude,omitempty"`
+    DeviceID       *string   `json:"device_id,omitempty"`
+}
+
+// BulkScanRejection explains why one entry of a batch was not stored
+type BulkScanRejection struct {
+    Index  int    `json:"index"`
+    Reason string `json:"reason"`
+}
+
+// BulkScanResponse summarises a batch upload
+type BulkScanResponse struct {
+    Accepted int                 `json:"accepted"`
+    Rejected []BulkScanRejection `json:"rejected"`
+}
+
+// BulkCreate stores scans uploaded by a scanner that was offline. Entries
+// dated in the future or more than 7 days ago are rejected individually; the
+// rest are inserted together.
+// @Summary Upload a batch of scans
+// @Tags scan-log
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param entries body []BulkScanEntry true "Scans"
+// @Success 200 {object} BulkScanResponse
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 500 {object} BulkScanResponse
+// @Router /api/scan-logs/bulk [post]
+func (h *ScanLogHandler) BulkCreate(c echo.Context) error {
+    var entries []BulkScanEntry
+    if err := c.Bind(&entries); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    if len(entries) > maxBulkScans {
+        return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+            "error": fmt.Sprintf("at most %d scans per request", maxBulkScans),
+        })
+    }
+
+    var scannedBy *string
+    if id := actorID(c); id != "" {
+        scannedBy = &id
+    }
+    now := time.Now()
+    resp := BulkScanResponse{Rejected: []BulkScanRejection{}}
+    valid := make([]*models.ScanLog, 0, len(entries))
+    validIdx := make([]int, 0, len(entries))
+    for i, e := range entries {
+        switch {
+        case e.PlateID == "" || e.RegistrationID == "" || e.LTOClientID == "":
+            resp.Rejected = append(resp.Rejected, BulkScanRejection{Index: i, Reason: "plate_id, registration_id and lto_client_id are required"})
+        case e.ScannedAt.IsZero():
+            resp.Rejected = append(resp.Rejected, BulkScanRejection{Index: i, Reason: "scanned_at is required"})
+        case e.ScannedAt.After(now.Add(scanClockSkew)):
+            resp.Rejected = append(resp.Rejected, BulkScanRejection{Index: i, Reason: "scanned_at is in the future"})
+        case e.ScannedAt.Before(now.Add(-maxScanAge)):
+            resp.Rejected = append(resp.Rejected, BulkScanRejection{Index: i, Reason: "scanned_at is more than 7 days old"})
+        case (e.Latitude == nil) != (e.Longitude == nil) || !validCoordinates(e.Latitude, e.Longitude):
+            resp.Rejected = append(resp.Rejected, BulkScanRejection{Index: i, Reason: "latitude and longitude must be given together and be in range"})
+        default:
+            valid = append(valid, &models.ScanLog{
+                PlateID:         e.PlateID,
+                RegistrationID:  e.RegistrationID,
+                LTOClientID:     e.LTOClientID,
+                ScannedAt:       e.ScannedAt,
+                Latitude:        e.Latitude,
+                Longitude:       e.Longitude,
+                ScannerDeviceID: e.DeviceID,
+                ScannedBy:       scannedBy,
+            })
+            validIdx = append(validIdx, i)
+        }
+    }
+
+    if len(valid) > 0 {
+        if err := h.repo.BulkCreate(c.Request().Context(), valid); err != nil {
+            // the insert was rolled back, so every valid entry failed with it
+            for _, i := range validIdx {
+                resp.Rejected = append(resp.Rejected, BulkScanRejection{Index: i, Reason: err.Error()})
+            }
+            return c.JSON(http.StatusInternalServerError, resp)
+        }
+        resp.Accepted = len(valid)
+    }
+    return c.JSON(http.StatusOK, resp)
+}
+
+// HourlyBreakdown returns scan counts for each hour of the day (0 = midnight)
+// in the server's APP_TIMEZONE. The range defaults to the last 30 days.
+// @Summary Scans per hour of day
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start (RFC3339)"
+// @Param to query string false "End (RFC3339)"
+// @Success 200 {array} models.HourlyCount
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/analytics/hourly-breakdown [get]
+func (h *ScanLogHandler) HourlyBreakdown(c echo.Context) error {
+    from, to, msg := analyticsRange(c)
+    if msg != "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+    }
+
+    counts, err := h.repo.HourlyBreakdown(c.Request().Context(), from.In(h.loc), to.In(h.loc))
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, counts)
+}
+
+// GetScanCountPerOfficer ranks LTO officers by the number of scans they
+// made. The range defaults to the last 30 days.
+// @Summary Scans per officer
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start (RFC3339)"
+// @Param to query string false "End (RFC3339)"
+// @Success 200 {array} models.OfficerScanCount
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/analytics/officer-scan-counts [get]
+func (h *ScanLogHandler) GetScanCountPerOfficer(c echo.Context) error {
+    from, to, msg := analyticsRange(c)
+    if msg != "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+    }
+
+    counts, err := h.repo.CountByOfficer(c.Request().Context(), from, to)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, counts)
+}
+
+// defaultTopScannerIPs and maxTopScannerIPs bound TopScannerIPs' ?n=
+const (
+    defaultTopScannerIPs = 10
+    maxTopScannerIPs     = 100
+)
+
+// GetByIPAddress returns one page of the scans sent from a scanner IP, newest
+// first.
+// @Summary Scans from a scanner IP
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param ip path string true "Scanner IP address"
+// @Param page query int false "Page (default 1)"
+// @Param limit query int false "Page size"
+// @Success 200 {array} models.ScanLog
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/scan-logs/by-ip/{ip} [get]
+func (h *ScanLogHandler) GetByIPAddress(c echo.Context) error {
+    ip := net.ParseIP(c.Param("ip"))
+    if ip == nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "ip must be an IPv4 or IPv6 address"})
+    }
+    _, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+
+    logs, err := h.repo.GetByIPAddress(c.Request().Context(), ip.String(), limit, offset)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, logs)
+}
+
+// TopScannerIPs ranks scanner IPs by the number of scans sent from them, to
+// spot a single address driving unusual volume. The range defaults to the
+// last 30 days.
+// @Summary Busiest scanner IPs
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param n query int false "How many IPs (default 10, max 100)"
+// @Param from query string false "Start (RFC3339)"
+// @Param to query string false "End (RFC3339)"
+// @Success 200 {array} models.ScannerIPCount
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/analytics/top-scanner-ips [get]
+func (h *ScanLogHandler) TopScannerIPs(c echo.Context) error {
+    from, to, msg := analyticsRange(c)
+    if msg != "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+    }
+    n := defaultTopScannerIPs
+    if raw := c.QueryParam("n"); raw != "" {
+        v, err := strconv.Atoi(raw)
+        if err != nil || v < 1 {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": "n must be a positive number"})
+        }
+        n = min(v, maxTopScannerIPs)
+    }
+
+    counts, err := h.repo.GetTopScannerIPs(c.Request().Context(), n, from, to)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, counts)
+}
+
+// analyticsRange reads the optional RFC3339 from and to query params,
+// defaulting to the last 30 days; msg explains a bad range
+func analyticsRange(c echo.Context) (from, to time.Time, msg string) {
+    to = time.Now()
+    from = to.AddDate(0, 0, -30)
+    for name, dst := range map[string]*time.Time{"from": &from, "to": &to} {
+        if v := c.QueryParam(name); v != "" {
+            t, err := time.Parse(time.RFC3339, v)
+            if err != nil {
+                return from, to, "invalid " + name + " (use RFC3339)"
+            }
+            *dst = t
+        }
+    }
+    if from.After(to) {
+        return from, to, "from must be before to"
+    }
+    return from, to, ""
+}
+
+// validCoordinates reports whether the optional lat/lon are in range
+func validCoordinates(lat, lon *float64) bool {
+    if lat != nil && (*lat < -90 || *lat > 90) {
+        return false
+    }
+    if lon != nil && (*lon < -180 || *lon > 180) {
+        return false
+    }
+    return true
+}
+
+// Map returns the newest scans (up to 5000) located inside a bounding box.
+// @Summary Scans inside a map bounding box
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param min_lat query number true "South edge"
+// @Param max_lat query number true "North edge"
+// @Param min_lon query number true "West edge"
+// @Param max_lon query number true "East edge"
+// @Success 200 {array} models.ScanLog
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/scan-logs/map [get]
+func (h *ScanLogHandler) Map(c echo.Context) error {
+    var box [4]float64
+    for i, name := range []string{"min_lat", "max_lat", "min_lon", "max_lon"} {
+        v, err := strconv.ParseFloat(c.QueryParam(name), 64)
+        if err != nil {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": name + " must be a number"})
+        }
+        box[i] = v
+    }
+    minLat, maxLat, minLon, maxLon := box[0], box[1], box[2], box[3]
+    if minLat > maxLat || minLon > maxLon || !validCoordinates(&minLat, &minLon) || !validCoordinates(&maxLat, &maxLon) {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid bounding box"})
+    }
+
+    logs, err := h.repo.GetByBoundingBox(c.Request().Context(), minLat, maxLat, minLon, maxLon)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, logs)
+}
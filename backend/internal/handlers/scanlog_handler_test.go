@@ -0,0 +1,289 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "sort"
+    "testing"
+    "time"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/config"
+    "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+)
+
+// fakeScanRepo holds scan times and groups them the way the SQL does: by
+// hour in the zone of the from argument
+type fakeScanRepo struct {
+    repository.ScanLogRepository
+    scannedAt []time.Time
+    report    []models.ScanReportRow
+    scans     []models.ScanLog
+    users     []models.User
+    regions   map[string]string // region of each registration, by ID
+}
+
+func (f *fakeScanRepo) HourlyBreakdown(ctx context.Context, from, to time.Time) ([]models.HourlyCount, error) {
+    out := make([]models.HourlyCount, 24)
+    for h := range out {
+        out[h].Hour = h
+    }
+    for _, at := range f.scannedAt {
+        if !at.Before(from) && !at.After(to) {
+            out[at.In(from.Location()).Hour()].Count++
+        }
+    }
+    return out, nil
+}
+
+func (f *fakeScanRepo) ReportSummary(ctx context.Context, from, to time.Time) (*models.ScanReportSummary, error) {
+    return &models.ScanReportSummary{TotalScans: len(f.report)}, nil
+}
+
+func (f *fakeScanRepo) StreamReport(ctx context.Context, from, to time.Time, out chan<- models.ScanReportRow) error {
+    defer close(out)
+    for _, row := range f.report {
+        out <- row
+    }
+    return nil
+}
+
+// CountByOfficer joins scans to who made them the way the SQL does
+func (f *fakeScanRepo) CountByOfficer(ctx context.Context, from, to time.Time) ([]models.OfficerScanCount, error) {
+    counts := []models.OfficerScanCount{}
+    for _, u := range f.users {
+        if u.ROLE != models.RoleOfficer {
+            continue
+        }
+        c := models.OfficerScanCount{LTOClientID: u.LTO_CLIENT_ID, FullName: u.FIRST_NAME + " " + u.LAST_NAME}
+        for _, s := range f.scans {
+            if s.ScannedBy != nil && *s.ScannedBy == u.LTO_CLIENT_ID && !s.ScannedAt.Before(from) && !s.ScannedAt.After(to) {
+                c.ScanCount++
+            }
+        }
+        if c.ScanCount > 0 {
+            counts = append(counts, c)
+        }
+    }
+    sort.SliceStable(counts, func(i, j int) bool { return counts[i].ScanCount > counts[j].ScanCount })
+    return counts, nil
+}
+
+func TestGetScanByIDScopedToRegion(t *testing.T) {
+    tests := []struct {
+        name     string
+        region   string // "" for callers who see every region
+        id       string
+        wantCode int
+    }{
+        {"admin", "", "s1", http.StatusOK},
+        {"officer in the registration's region", "NCR", "s1", http.StatusOK},
+        {"officer in another region", "Region IV-A", "s1", http.StatusNotFound},
+        {"unknown scan", "", "s9", http.StatusNotFound},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            repo := &fakeScanRepo{
+                scans:   []models.ScanLog{{LogID: "s1", PlateID: "p1", RegistrationID: "f1", LTOClientID: "LTO-1"}},
+                regions: map[string]string{"f1": "NCR"},
+            }
+            h := NewScanLogHandler(repo, time.UTC)
+
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+            c.SetParamNames("id")
+            c.SetParamValues(tt.id)
+            if tt.region != "" {
+                c.Set(middleware.RegionKey, tt.region)
+            }
+            if err := h.GetByID(c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if tt.wantCode != http.StatusOK {
+                return
+            }
+            var got models.ScanLog
+            if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+                t.Fatal(err)
+            }
+            if got.LogID != "s1" || got.RegistrationID != "f1" {
+                t.Fatalf("got %+v, want scan s1", got)
+            }
+        })
+    }
+}
+
+func TestHourlyBreakdown(t *testing.T) {
+    utc := func(day, hour, min int) time.Time { return time.Date(2026, 3, day, hour, min, 0, 0, time.UTC) }
+    scans := []time.Time{
+        utc(1, 0, 0),   // 08:00 in Manila
+        utc(1, 0, 59),  // 08:00
+        utc(1, 15, 30), // 23:00
+        utc(1, 16, 0),  // 00:00 the next day
+        utc(2, 23, 10), // 07:00
+        utc(9, 12, 0),  // outside the range
+    }
+
+    tests := []struct {
+        name     string
+        timezone string
+        query    string
+        wantCode int
+        want     map[int]int // hour -> count; every other hour is 0
+    }{
+        {"manila", "Asia/Manila", "?from=2026-03-01T00:00:00Z&to=2026-03-03T00:00:00Z", http.StatusOK,
+            map[int]int{8: 2, 23: 1, 0: 1, 7: 1}},
+        {"utc", "UTC", "?from=2026-03-01T00:00:00Z&to=2026-03-03T00:00:00Z", http.StatusOK,
+            map[int]int{0: 2, 15: 1, 16: 1, 23: 1}},
+        {"bad from", "UTC", "?from=yesterday", http.StatusBadRequest, nil},
+        {"from after to", "UTC", "?from=2026-03-03T00:00:00Z&to=2026-03-01T00:00:00Z", http.StatusBadRequest, nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            t.Setenv("APP_TIMEZONE", tt.timezone)
+            loc, err := config.LoadLocation()
+            if err != nil {
+                t.Fatal(err)
+            }
+            h := NewScanLogHandler(&fakeScanRepo{scannedAt: scans}, loc)
+
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/admin/analytics/hourly-breakdown"+tt.query, nil), rec)
+            if err := h.HourlyBreakdown(c); err != nil {
+                t.Fatal(err)
+            }
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if tt.wantCode != http.StatusOK {
+                return
+            }
+
+            var counts []models.HourlyCount
+            if err := json.Unmarshal(rec.Body.Bytes(), &counts); err != nil {
+                t.Fatal(err)
+            }
+            if len(counts) != 24 {
+                t.Fatalf("got %d hours, want 24", len(counts))
+            }
+            for hour, got := range counts {
+                if got.Hour != hour || got.Count != tt.want[hour] {
+                    t.Errorf("hour %d: got %+v, want %d scans", hour, got, tt.want[hour])
+                }
+            }
+        })
+    }
+}
+
+func TestReportPDF(t *testing.T) {
+    rows := []models.ScanReportRow{
+        {ScannedAt: time.Date(2024, 1, 3, 1, 15, 0, 0, time.UTC), PlateNumber: "ABC 12344", OwnerName: "Juan Dela Cruz", Status: "Active"},
+        {ScannedAt: time.Date(2024, 1, 9, 4, 40, 0, 0, time.UTC), PlateNumber: "NAB 12344", OwnerName: "Maria Santos", Status: "Expired"},
+        {ScannedAt: time.Date(2024, 1, 20, 9, 5, 0, 0, time.UTC), PlateNumber: "ABC 12344", OwnerName: "Juan Dela Cruz", Status: "Active"},
+    }
+    tests := []struct {
+        name     string
+        month    string
+        wantCode int
+    }{
+        {"january", "2024-01", http.StatusOK},
+        {"missing month", "", http.StatusBadRequest},
+        {"bad month", "2024-13", http.StatusBadRequest},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            h := NewScanLogHandler(&fakeScanRepo{report: rows}, time.UTC)
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/admin/scan-logs/report.pdf?month="+tt.month, nil), rec)
+            if err := h.ReportPDF(c); err != nil {
+                t.Fatal(err)
+            }
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if tt.wantCode != http.StatusOK {
+                return
+            }
+            if ct := rec.Header().Get(echo.HeaderContentType); ct != "application/pdf" {
+                t.Fatalf("Content-Type = %q, want application/pdf", ct)
+            }
+            if body := rec.Body.Bytes(); len(body) < 4 || string(body[:4]) != "%PDF" {
+                t.Fatalf("body does not start with %%PDF")
+            }
+        })
+    }
+}
+
+func TestGetScanCountPerOfficer(t *testing.T) {
+    day := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+    repo := &fakeScanRepo{users: []models.User{
+        {LTO_CLIENT_ID: "LTO-OFFICER-1", FIRST_NAME: "Maria", LAST_NAME: "Santos", ROLE: models.RoleOfficer},
+        {LTO_CLIENT_ID: "LTO-OFFICER-2", FIRST_NAME: "Jose", LAST_NAME: "Reyes", ROLE: models.RoleOfficer},
+        {LTO_CLIENT_ID: "LTO-2024-000123", FIRST_NAME: "Juan", LAST_NAME: "Dela Cruz", ROLE: models.RoleUser},
+    }}
+    // 10 scans of Juan's plate split 3 to 7 between the officers, plus one
+    // by Juan himself
+    for i := 0; i < 10; i++ {
+        officer := "LTO-OFFICER-2"
+        if i < 3 {
+            officer = "LTO-OFFICER-1"
+        }
+        repo.scans = append(repo.scans, models.ScanLog{LTOClientID: "LTO-2024-000123", ScannedBy: &officer, ScannedAt: day.Add(time.Duration(i) * time.Hour)})
+    }
+    owner := "LTO-2024-000123"
+    repo.scans = append(repo.scans, models.ScanLog{LTOClientID: owner, ScannedBy: &owner, ScannedAt: day})
+
+    tests := []struct {
+        name     string
+        query    string
+        wantCode int
+        want     []models.OfficerScanCount
+    }{
+        {"whole range", "?from=2026-03-01T00:00:00Z&to=2026-03-31T00:00:00Z", http.StatusOK, []models.OfficerScanCount{
+            {LTOClientID: "LTO-OFFICER-2", FullName: "Jose Reyes", ScanCount: 7},
+            {LTOClientID: "LTO-OFFICER-1", FullName: "Maria Santos", ScanCount: 3},
+        }},
+        {"first three hours", "?from=2026-03-10T09:00:00Z&to=2026-03-10T11:00:00Z", http.StatusOK, []models.OfficerScanCount{
+            {LTOClientID: "LTO-OFFICER-1", FullName: "Maria Santos", ScanCount: 3},
+        }},
+        {"no scans", "?from=2026-04-01T00:00:00Z&to=2026-04-30T00:00:00Z", http.StatusOK, []models.OfficerScanCount{}},
+        {"bad from", "?from=yesterday", http.StatusBadRequest, nil},
+        {"from after to", "?from=2026-03-31T00:00:00Z&to=2026-03-01T00:00:00Z", http.StatusBadRequest, nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/admin/analytics/officer-scan-counts"+tt.query, nil), rec)
+            if err := NewScanLogHandler(repo, time.UTC).GetScanCountPerOfficer(c); err != nil {
+                t.Fatal(err)
+            }
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if tt.want == nil {
+                return
+            }
+            var got []models.OfficerScanCount
+            if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+                t.Fatal(err)
+            }
+            if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+                t.Fatalf("got %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
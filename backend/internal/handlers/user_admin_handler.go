@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	mw "smartplate-api/internal/middleware"
+	"smartplate-api/internal/models"
+	"smartplate-api/internal/repository"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// assignableRoles maps the lower-cased role names accepted by UpdateRole to
+// the value stored in users.role
+var assignableRoles = map[string]string{
+	"user":        models.RoleUser,
+	"lto officer": models.RoleOfficer,
+	"admin":       models.RoleAdmin,
+}
+
+// UserAdminHandler serves the admin user-management endpoints. :id is the
+// user's LTO client ID throughout.
+type UserAdminHandler struct {
+	users repository.UserRepository
+	scans repository.ScanLogRepository
+}
+
+// NewUserAdminHandler creates a new UserAdminHandler.
+func NewUserAdminHandler(users repository.UserRepository, scans repository.ScanLogRepository) *UserAdminHandler {
+	return &UserAdminHandler{users: users, scans: scans}
+}
+
+// List returns one page of users, optionally filtered by role, status and
+// region.
+// GET /api/admin/users?role=&status=&region=&page=&limit=
+func (h *UserAdminHandler) List(c echo.Context) error {
+	page, limit, offset, err := ParsePaginationParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	users, total, err := h.users.List(repository.UserFilter{
+		Role:   c.QueryParam("role"),
+		Status: c.QueryParam("status"),
+		Region: c.QueryParam("region"),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		log.Printf("[req-id:%s] admin list users error: %v", mw.GetRequestID(c), err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch users"})
+	}
+	return c.JSON(http.StatusOK, PaginatedResponse(models.MaskUsers(users), total, page, limit))
+}
+
+// Get returns a user's full details without the password hash.
+// GET /api/admin/users/:id
+func (h *UserAdminHandler) Get(c echo.Context) error {
+	user, err := h.users.GetByLTOClientID(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+	return c.JSON(http.StatusOK, user.ToMasked())
+}
+
+// RoleChangeRequest is the body of UpdateRole
+type RoleChangeRequest struct {
+	Role string `json:"role"`
+}
+
+// UpdateRole changes a user's role. Only a superadmin may take the admin role
+// away from another admin.
+// PUT /api/admin/users/:id/role
+func (h *UserAdminHandler) UpdateRole(c echo.Context) error {
+	var req RoleChangeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	role, ok := assignableRoles[strings.ToLower(strings.TrimSpace(req.Role))]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "role must be one of: user, lto officer, admin"})
+	}
+
+	target, err := h.users.GetByLTOClientID(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	}
+	callerRole, _ := c.Get("role").(string)
+	demotesAdmin := (target.ROLE == models.RoleAdmin || target.ROLE == models.RoleSuperAdmin) && role != target.ROLE
+	if demotesAdmin && callerRole != models.RoleSuperAdmin {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "only a superadmin can demote an admin"})
+	}
+
+	if err := h.users.UpdateRole(target.LTO_CLIENT_ID, role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
+		log.Printf("[req-id:%s] UpdateRole error: %v", mw.GetRequestID(c), err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update role"})
+	}
+	forgetCurrentUser(target.LTO_CLIENT_ID)
+	return c.JSON(http.StatusOK, map[string]string{
+		"lto_client_id": target.LTO_CLIENT_ID,
+		"old_role":      target.ROLE,
+		"role":          role,
+	})
+}
+
+// RegionAssignRequest is the body of UpdateRegion
+type RegionAssignRequest struct {
+	Region string `json:"region"`
+}
+
+// UpdateRegion assigns the region an LTO officer is limited to; an empty
+// region clears the assignment. The officer's next token carries it.
+// PUT /api/admin/users/:id/region
+func (h *UserAdminHandler) UpdateRegion(c echo.Context) error {
+	var req RegionAssignRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	region := strings.ToUpper(strings.TrimSpace(req.Region))
+	if err := h.users.UpdateRegion(c.Param("id"), region); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
+		log.Printf("[req-id:%s] UpdateRegion error: %v", mw.GetRequestID(c), err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update region"})
+	}
+	forgetCurrentUser(c.Param("id"))
+	return c.JSON(http.StatusOK, map[string]string{
+		"lto_client_id": c.Param("id"),
+		"region":        region,
+	})
+}
+
+// ScanHistory lists every scan of the user's plates, newest first.
+// GET /api/admin/users/:id/scan-history
+func (h *UserAdminHandler) ScanHistory(c echo.Context) error {
+	logs, err := h.scans.GetByLTOClientID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, logs)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"smartplate-api/internal/models"
+	"smartplate-api/internal/testutil"
+)
+
+func TestUpdateRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		callerRole string
+		target     string
+		body       string
+		wantCode   int
+		wantRole   string
+	}{
+		{"promote user", models.RoleAdmin, "LTO-1", `{"role":"LTO Officer"}`, http.StatusOK, models.RoleOfficer},
+		{"unknown role", models.RoleAdmin, "LTO-1", `{"role":"root"}`, http.StatusBadRequest, models.RoleUser},
+		{"unknown user", models.RoleAdmin, "LTO-9", `{"role":"user"}`, http.StatusNotFound, ""},
+		{"admin demotes admin", models.RoleAdmin, "LTO-2", `{"role":"user"}`, http.StatusForbidden, models.RoleAdmin},
+		{"superadmin demotes admin", models.RoleSuperAdmin, "LTO-2", `{"role":"user"}`, http.StatusOK, models.RoleUser},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := testutil.NewMockUserRepository(
+				models.User{LTO_CLIENT_ID: "LTO-1", ROLE: models.RoleUser},
+				models.User{LTO_CLIENT_ID: "LTO-2", ROLE: models.RoleAdmin},
+			)
+			h := NewUserAdminHandler(users, nil)
+
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.target)
+			c.Set("role", tt.callerRole)
+			if err := h.UpdateRole(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if tt.wantRole == "" {
+				return
+			}
+			u, err := users.GetByLTOClientID(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u.ROLE != tt.wantRole {
+				t.Fatalf("role = %q, want %q", u.ROLE, tt.wantRole)
+			}
+		})
+	}
+}
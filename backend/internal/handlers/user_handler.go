@@ -1,23 +1,28 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
+	"regexp"
+	"smartplate-api/internal/email"
+	mw "smartplate-api/internal/middleware"
 	"smartplate-api/internal/models"
 	"smartplate-api/internal/repository"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type UserHandler struct {
-	repo *repository.UserRepository
+	repo repository.UserRepository
 }
-func NewUserHandler(repo *repository.UserRepository) *UserHandler {
+func NewUserHandler(repo repository.UserRepository) *UserHandler {
 	rand.Seed(time.Now().UnixNano())
 	return &UserHandler{repo: repo}
 }
@@ -25,25 +30,25 @@ func NewUserHandler(repo *repository.UserRepository) *UserHandler {
 func (h *UserHandler) CreateUser(c echo.Context) error {
     var user models.User
     if err := c.Bind(&user); err != nil {
-        log.Printf("CreateUser bind error: %v", err)
+        log.Printf("[req-id:%s] CreateUser bind error: %v", mw.GetRequestID(c), err)
         return c.JSON(http.StatusBadRequest, map[string]string{
             "error": "Invalid request body",
             "details": err.Error(),
         })
     }
-	hashed, err := bcrypt.GenerateFromPassword([]byte(user.PASSWORD), bcrypt.DefaultCost)
+	if rejected, err := rejectWeakPassword(c, user.PASSWORD); rejected {
+		return err
+	}
+	hashed, err := hashPassword(user.PASSWORD)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error":"couldn’t hash password"})
 	}
 	user.PASSWORD = string(hashed)
 
-	// 2) Default role/status if empty
-	if user.ROLE == "" {
-		user.ROLE = "user"
-	}
-	if user.STATUS == "" {
-		user.STATUS = "active"
-	}
+	// 2) self-registration always creates an active plain user; roles are
+	// only ever granted through PUT /api/admin/users/:id/role
+	user.ROLE = models.RoleUser
+	user.STATUS = "active"
 
     // Validate required fields
     if user.LAST_NAME == "" || user.FIRST_NAME == "" || user.EMAIL == "" || user.PASSWORD == "" {
@@ -56,7 +61,7 @@ func (h *UserHandler) CreateUser(c echo.Context) error {
     if user.LTO_CLIENT_ID == "" {
         ltoID, err := h.generateUniqueLTOID()
         if err != nil {
-            log.Printf("LTO ID generation failed: %v", err)
+            log.Printf("[req-id:%s] LTO ID generation failed: %v", mw.GetRequestID(c), err)
             return c.JSON(http.StatusInternalServerError, map[string]string{
                 "error": "Failed to generate unique LTO ID",
             })
@@ -66,27 +71,49 @@ func (h *UserHandler) CreateUser(c echo.Context) error {
 
     // Create user with transaction
     if err := h.repo.Create(&user); err != nil {
-        log.Printf("CreateUser error: %v", err) // Detailed logging
+        log.Printf("[req-id:%s] CreateUser error: %v", mw.GetRequestID(c), err) // Detailed logging
         return c.JSON(http.StatusInternalServerError, map[string]string{
             "error": "Failed to create user",
             "details": err.Error(), // Return actual error to client
         })
     }
 
-    // Clear sensitive data before response
-    user.PASSWORD = ""
-    return c.JSON(http.StatusCreated, user)
+    reqID := mw.GetRequestID(c)
+    go func(to, firstName, ltoID string) {
+        if err := email.SendWelcomeEmail(to, firstName, ltoID); err != nil {
+            log.Printf("[req-id:%s] welcome email error: %v", reqID, err)
+        }
+    }(user.EMAIL, user.FIRST_NAME, user.LTO_CLIENT_ID)
+
+    return c.JSON(http.StatusCreated, user.ToMasked())
 }
 
 
-// GetAllUsers handles GET /users
+// GetAllUsers handles GET /users?role=&q=&page=&limit=
+// role filters by exact role and q searches name, email and LTO client ID.
 func (h *UserHandler) GetAllUsers(c echo.Context) error {
-	users, err := h.repo.GetAll()
+	page, limit, offset, err := ParsePaginationParams(c)
 	if err != nil {
-		log.Printf("GetAllUsers error: %v", err)
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var (
+		users []models.User
+		total int
+	)
+	switch {
+	case c.QueryParam("q") != "":
+		users, total, err = h.repo.Search(c.QueryParam("q"), limit, offset)
+	case c.QueryParam("role") != "":
+		users, total, err = h.repo.GetByRole(c.QueryParam("role"), limit, offset)
+	default:
+		users, total, err = h.repo.GetAll(limit, offset)
+	}
+	if err != nil {
+		log.Printf("[req-id:%s] GetAllUsers error: %v", mw.GetRequestID(c), err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch users"})
 	}
-	return c.JSON(http.StatusOK, users)
+	return c.JSON(http.StatusOK, PaginatedResponse(models.MaskUsers(users), total, page, limit))
 }
 
 //GetUserByID handles GET /users/:id
@@ -101,7 +128,7 @@ func (h *UserHandler) GetUserByID(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
 	}
 
-	return c.JSON(http.StatusOK, user)
+	return c.JSON(http.StatusOK, user.ToMasked())
 }
 
 //GetUserByEmail handles GET /users/email/:email
@@ -113,7 +140,7 @@ func (h *UserHandler) GetUserByEmail(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
 	}
 
-	return c.JSON(http.StatusOK, user)
+	return c.JSON(http.StatusOK, user.ToMasked())
 }
 
 // UpdateUser handles PUT /users/:id
@@ -134,47 +161,50 @@ func (h *UserHandler) UpdateUser(c echo.Context) error {
     if err := c.Bind(&updateData); err != nil {
         return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
     }
+    if status, msg := checkUserUpdate(c, &existingUser, updateData); msg != "" {
+        return c.JSON(status, map[string]string{"error": msg})
+    }
 
     // Merge updates with existing data
     updatedUser := mergeUserUpdates(&existingUser, updateData)
     
     // Perform the update
     if err := h.repo.Update(updatedUser); err != nil {
-        log.Printf("UpdateUser error: %v", err)
+        log.Printf("[req-id:%s] UpdateUser error: %v", mw.GetRequestID(c), err)
         return c.JSON(http.StatusInternalServerError, map[string]string{
             "error": "Failed to update user: " + err.Error(),
         })
     }
+    forgetCurrentUser(updatedUser.LTO_CLIENT_ID)
 
-    return c.JSON(http.StatusOK, updatedUser)
+    return c.JSON(http.StatusOK, updatedUser.ToMasked())
+}
+
+// checkUserUpdate lets a user update only their own account, and admins
+// anyone's. Role, status and password have their own guarded endpoints, so a
+// generic update may not change them.
+func checkUserUpdate(c echo.Context, existing *models.User, update models.User) (int, string) {
+	role, _ := c.Get("role").(string)
+	if !isAdminRole(role) && existing.LTO_CLIENT_ID != actorID(c) {
+		return http.StatusForbidden, "you can only update your own account"
+	}
+	if update.PASSWORD != "" {
+		return http.StatusBadRequest, "password can't be changed here; use PUT /api/users/me/password"
+	}
+	if (update.ROLE != "" && update.ROLE != existing.ROLE) || (update.STATUS != "" && update.STATUS != existing.STATUS) {
+		return http.StatusBadRequest, "role and status can't be changed here"
+	}
+	return 0, ""
 }
 
 func mergeUserUpdates(existing *models.User, update models.User) *models.User {
-    // Preserve critical identifiers
+    // Preserve critical identifiers, and the fields checkUserUpdate guards
     update.USER_ID = existing.USER_ID
     update.LTO_CLIENT_ID = existing.LTO_CLIENT_ID
     update.EMAIL = existing.EMAIL
-
-	if update.PASSWORD == "" {
-        // client didn’t supply a new one → keep the old hash
-        update.PASSWORD = existing.PASSWORD
-    } else {
-        // hash the new password
-        hashed, err := bcrypt.GenerateFromPassword([]byte(update.PASSWORD), bcrypt.DefaultCost)
-        if err != nil {
-            // you might want to bubble this up instead of panic
-            log.Printf("mergeUserUpdates bcrypt error: %v", err)
-        } else {
-            update.PASSWORD = string(hashed)
-        }
-    }
-	// — ROLE & STATUS — defaults if empty
-	if update.ROLE == "" {
-		update.ROLE = existing.ROLE
-	}
-	if update.STATUS == "" {
-		update.STATUS = existing.STATUS
-	}
+    update.PASSWORD = existing.PASSWORD
+    update.ROLE = existing.ROLE
+    update.STATUS = existing.STATUS
 	
     // Preserve first name if not provided
     if update.FIRST_NAME == "" {
@@ -373,10 +403,43 @@ func (h *UserHandler) DeleteUser(c echo.Context) error {
     if err != nil {
         return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid user ID"})
     }
-    if err := h.repo.Delete(id); err != nil {
-        log.Printf("DeleteUser error: %v", err)
+    user, err := h.repo.GetByID(id)
+    if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+    }
+    return h.softDelete(c, user.LTO_CLIENT_ID)
+}
+
+// softDelete deactivates the user and answers 204, or 404 if they are already gone
+func (h *UserHandler) softDelete(c echo.Context, ltoID string) error {
+    if err := h.repo.Delete(ltoID); err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+        }
+        log.Printf("[req-id:%s] DeleteUser error: %v", mw.GetRequestID(c), err)
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to delete user"})
     }
+    forgetCurrentUser(ltoID)
+    return c.NoContent(http.StatusNoContent)
+}
+
+// AdminDeleteUser soft-deletes a user by LTO client ID.
+// DELETE /api/admin/users/:id
+func (h *UserHandler) AdminDeleteUser(c echo.Context) error {
+    return h.softDelete(c, c.Param("id"))
+}
+
+// RestoreUser reactivates a soft-deleted user by LTO client ID.
+// PUT /api/admin/users/:id/restore
+func (h *UserHandler) RestoreUser(c echo.Context) error {
+    if err := h.repo.Restore(c.Param("id")); err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return c.JSON(http.StatusNotFound, map[string]string{"error": "no deleted user with that id"})
+        }
+        log.Printf("[req-id:%s] RestoreUser error: %v", mw.GetRequestID(c), err)
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to restore user"})
+    }
+    forgetCurrentUser(c.Param("id"))
     return c.NoContent(http.StatusNoContent)
 }
 
@@ -403,36 +466,31 @@ func (h *UserHandler) UpdateUserByLTO(c echo.Context) error {
         })
     }
 
+    if status, msg := checkUserUpdate(c, &existing, payload); msg != "" {
+        return c.JSON(status, map[string]string{"error": msg})
+    }
+
     // 3) merge fields (preserves any nil/empty fields)
     merged := mergeUserUpdates(&existing, payload)
 
     // 4) perform update
     if err := h.repo.Update(merged); err != nil {
-        log.Printf("UpdateUserByLTO error: %v", err)
+        log.Printf("[req-id:%s] UpdateUserByLTO error: %v", mw.GetRequestID(c), err)
         return c.JSON(http.StatusInternalServerError, map[string]string{
             "error":   "Failed to update user",
             "details": err.Error(),
         })
     }
+    forgetCurrentUser(merged.LTO_CLIENT_ID)
 
-    // 5) clear sensitive data
-    merged.PASSWORD = ""
-    return c.JSON(http.StatusOK, merged)
+    return c.JSON(http.StatusOK, merged.ToMasked())
 }
 
 
 
 // DeleteUserByLTO handles DELETE /users/by-lto/:lto_client_id
 func (h *UserHandler) DeleteUserByLTO(c echo.Context) error {
-    ltoID := c.Param("lto_client_id")
-    if err := h.repo.DeleteByLTOClientID(ltoID); err != nil {
-        log.Printf("DeleteUserByLTO error: %v", err)
-        return c.JSON(http.StatusInternalServerError, map[string]string{
-            "error": "Failed to delete user",
-            "details": err.Error(),
-        })
-    }
-    return c.NoContent(http.StatusNoContent)
+    return h.softDelete(c, c.Param("lto_client_id"))
 }
 //get user by lto client id
 func (h *UserHandler) GetUserByLTOID(c echo.Context) error {
@@ -441,7 +499,7 @@ func (h *UserHandler) GetUserByLTOID(c echo.Context) error {
     if err != nil {
         return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
     }
-    return c.JSON(http.StatusOK, user)
+    return c.JSON(http.StatusOK, user.ToMasked())
 }
 
 // GenerateLTOID handles GET /generate-lto-id
@@ -458,8 +516,12 @@ func (h *UserHandler) GenerateLTOID(c echo.Context) error {
 	})
 }
 
-//15-digit generation
 func (h *UserHandler) generateUniqueLTOID() (string, error) {
+	return generateUniqueLTOID(h.repo)
+}
+
+//15-digit generation
+func generateUniqueLTOID(repo repository.UserRepository) (string, error) {
 	const (
 		prefix      = "25" // 2-digit prefix 25 for 2025
 		totalLength = 15
@@ -473,11 +535,124 @@ func (h *UserHandler) generateUniqueLTOID() (string, error) {
 		generatedID := prefix + randomPart
 
 		// Check uniqueness
-		_, err := h.repo.GetByLTOClientID(generatedID)
+		_, err := repo.GetByLTOClientID(generatedID)
 		if err != nil {
 			// If not found, return the unique ID
 			return generatedID, nil
 		}
 	}
 	return "", fmt.Errorf("failed to generate unique LTO ID after %d attempts", maxAttempts)
-}
\ No newline at end of file
+}
+
+var (
+	phMobilePattern = regexp.MustCompile(`^\+63\d{10}$`)
+	zipCodePattern  = regexp.MustCompile(`^\d{4}$`)
+)
+
+// maxProfileFieldLen caps the free-text address fields
+const maxProfileFieldLen = 100
+
+// validateProfileUpdate returns a message per failing field, keyed by its JSON path
+func validateProfileUpdate(u models.UserProfileUpdate) map[string]string {
+	errs := map[string]string{}
+	for field, v := range map[string]*string{"first_name": u.FirstName, "last_name": u.LastName} {
+		if v != nil && strings.TrimSpace(*v) == "" {
+			errs[field] = "must not be empty"
+		}
+	}
+	if c := u.Contact; c != nil {
+		for field, v := range map[string]*string{
+			"contact.telephone_number":         c.TelephoneNumber,
+			"contact.mobile_number":            c.MobileNumber,
+			"contact.emergency_contact_number": c.EmergencyContactNumber,
+		} {
+			if v != nil && !phMobilePattern.MatchString(*v) {
+				errs[field] = "must be in the format +63XXXXXXXXXX"
+			}
+		}
+	}
+	if a := u.Address; a != nil {
+		if a.ZipCode != nil && !zipCodePattern.MatchString(*a.ZipCode) {
+			errs["address.zip_code"] = "must be exactly 4 digits"
+		}
+		for field, v := range map[string]*string{
+			"address.province":          a.Province,
+			"address.city_municipality": a.CityMunicipality,
+			"address.barangay":          a.Barangay,
+		} {
+			if v == nil {
+				continue
+			}
+			if n := len(strings.TrimSpace(*v)); n == 0 || n >= maxProfileFieldLen {
+				errs[field] = fmt.Sprintf("must be non-empty and under %d characters", maxProfileFieldLen)
+			}
+		}
+	}
+	return errs
+}
+
+// UpdateMe applies a partial profile update for the authenticated user.
+// PUT /api/users/me
+func (h *UserHandler) UpdateMe(c echo.Context) error {
+	ltoID, _ := c.Get("lto_client_id").(string)
+	if ltoID == "" {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+
+	var update models.UserProfileUpdate
+	if err := c.Bind(&update); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if errs := validateProfileUpdate(update); len(errs) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":  "validation failed",
+			"fields": errs,
+		})
+	}
+
+	if err := h.repo.UpdateProfile(ltoID, update); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
+		log.Printf("[req-id:%s] UpdateMe error: %v", mw.GetRequestID(c), err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update profile"})
+	}
+	forgetCurrentUser(ltoID)
+
+	user, err := h.repo.GetByLTOClientID(ltoID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to load profile"})
+	}
+	return c.JSON(http.StatusOK, user.ToMasked())
+}
+
+// InactiveUser is one row of the dormant-accounts report
+type InactiveUser struct {
+	UserID      int        `json:"user_id"`
+	LTOClientID string     `json:"lto_client_id"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// GetInactiveUsers lists accounts with no login or scan activity in the last N days.
+// GET /api/admin/users/inactive?days=180
+func (h *UserHandler) GetInactiveUsers(c echo.Context) error {
+	days := 180
+	if v := c.QueryParam("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "days must be a positive integer"})
+		}
+		days = n
+	}
+
+	users, err := h.repo.GetInactiveUsers(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("[req-id:%s] GetInactiveUsers error: %v", mw.GetRequestID(c), err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to fetch inactive users"})
+	}
+	out := make([]InactiveUser, 0, len(users))
+	for _, u := range users {
+		out = append(out, InactiveUser{UserID: u.USER_ID, LTOClientID: u.LTO_CLIENT_ID, LastLoginAt: u.LAST_LOGIN_AT})
+	}
+	return c.JSON(http.StatusOK, out)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"smartplate-api/internal/models"
+)
+
+func TestCheckUserUpdate(t *testing.T) {
+	existing := &models.User{LTO_CLIENT_ID: "c1", ROLE: models.RoleUser, STATUS: "active"}
+	tests := []struct {
+		name     string
+		role     string
+		caller   string
+		update   models.User
+		wantCode int
+	}{
+		{"own profile", models.RoleUser, "c1", models.User{FIRST_NAME: "Juan"}, 0},
+		{"same role and status echoed back", models.RoleUser, "c1", models.User{ROLE: models.RoleUser, STATUS: "active"}, 0},
+		{"someone else's profile", models.RoleUser, "c2", models.User{FIRST_NAME: "Juan"}, http.StatusForbidden},
+		{"officer on someone else", models.RoleOfficer, "c2", models.User{FIRST_NAME: "Juan"}, http.StatusForbidden},
+		{"admin on someone else", models.RoleAdmin, "c2", models.User{FIRST_NAME: "Juan"}, 0},
+		{"superadmin on someone else", models.RoleSuperAdmin, "c2", models.User{FIRST_NAME: "Juan"}, 0},
+		{"self promotion", models.RoleUser, "c1", models.User{ROLE: models.RoleAdmin}, http.StatusBadRequest},
+		{"admin changing role", models.RoleAdmin, "c2", models.User{ROLE: models.RoleOfficer}, http.StatusBadRequest},
+		{"status change", models.RoleUser, "c1", models.User{STATUS: "inactive"}, http.StatusBadRequest},
+		{"password change", models.RoleUser, "c1", models.User{PASSWORD: "N3w-password!"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodPut, "/", nil), httptest.NewRecorder())
+			c.Set("role", tt.role)
+			c.Set("lto_client_id", tt.caller)
+			if code, msg := checkUserUpdate(c, existing, tt.update); code != tt.wantCode {
+				t.Fatalf("checkUserUpdate = %d %q, want %d", code, msg, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestMergeUserUpdatesKeepsGuardedFields(t *testing.T) {
+	existing := &models.User{
+		USER_ID: 1, LTO_CLIENT_ID: "c1", EMAIL: "juan@example.com",
+		PASSWORD: "hash", ROLE: models.RoleUser, STATUS: "active", FIRST_NAME: "Juan",
+	}
+	merged := mergeUserUpdates(existing, models.User{
+		USER_ID: 2, LTO_CLIENT_ID: "c2", EMAIL: "x@example.com",
+		PASSWORD: "plain", ROLE: models.RoleAdmin, STATUS: "inactive", LAST_NAME: "Dela Cruz",
+	})
+	if merged.USER_ID != 1 || merged.LTO_CLIENT_ID != "c1" || merged.EMAIL != "juan@example.com" ||
+		merged.PASSWORD != "hash" || merged.ROLE != models.RoleUser || merged.STATUS != "active" {
+		t.Fatalf("guarded fields changed: %+v", merged)
+	}
+	if merged.FIRST_NAME != "Juan" || merged.LAST_NAME != "Dela Cruz" {
+		t.Fatalf("names = %q %q, want Juan Dela Cruz", merged.FIRST_NAME, merged.LAST_NAME)
+	}
+}
@@ -2,22 +2,183 @@
 package handlers
 
 import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "log"
+    "math"
     "net/http"
+    "net/url"
+    "os"
+    "smartplate-api/internal/email"
+    "smartplate-api/internal/middleware"
     "smartplate-api/internal/models"
+    "smartplate-api/internal/plate"
     "smartplate-api/internal/repository"
+    "smartplate-api/internal/sms"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
 
     "github.com/labstack/echo/v4"
+    qrcode "github.com/skip2/go-qrcode"
 )
 
+// maxBulkPlates is the largest batch BulkCreatePlates accepts
+const maxBulkPlates = 500
+
+// CreatePlateRequest is one plate in a bulk creation request
+type CreatePlateRequest struct {
+    VehicleID           string    `json:"vehicle_id" example:"9b2d4e6f-1a3c-4b5d-8e7f-0a1b2c3d4e5f"`
+    PlateNumber         string    `json:"plate_number" example:"ABC 1234"`
+    PlateType           string    `json:"plate_type" example:"Private"`
+    PlateIssueDate      time.Time `json:"plate_issue_date" example:"2024-01-15T00:00:00Z"`
+    PlateExpirationDate time.Time `json:"plate_expiration_date" example:"2027-01-15T00:00:00Z"`
+    Status              string    `json:"status" example:"Active"`
+}
+
+// BulkPlateFailure reports why one row of a bulk request was rejected
+type BulkPlateFailure struct {
+    Index int    `json:"index" example:"3"`
+    Error string `json:"error" example:"vehicle not found"`
+}
+
+// BulkPlateResponse summarises a bulk creation request
+type BulkPlateResponse struct {
+    Succeeded int                `json:"succeeded" example:"42"`
+    Failed    []BulkPlateFailure `json:"failed"`
+}
+
+// ValidatePlateRequest is a plate number to check before creating it
+type ValidatePlateRequest struct {
+    PlateNumber string `json:"plate_number" example:"ABC 1234"`
+    VehicleType string `json:"vehicle_type" example:"4-Wheel"`
+    PlateType   string `json:"plate_type" example:"Private"`
+}
+
+// ValidatePlateResponse reports whether a plate number could be issued
+type ValidatePlateResponse struct {
+    Valid         bool   `json:"valid" example:"true"`
+    FormatError   string `json:"format_error,omitempty"`
+    AlreadyExists bool   `json:"already_exists" example:"false"`
+}
+
+// PublicPlateResponse is what anyone may see about a plate; it carries no
+// personal data
+type PublicPlateResponse struct {
+    PlateNumber string    `json:"plate_number" example:"ABC 12340"`
+    Status      string    `json:"status" example:"Active"`
+    Type        string    `json:"type" example:"Private"`
+    ExpiryDate  time.Time `json:"expiry_date" example:"2027-01-15T00:00:00Z"`
+}
+
+// PlateOwner is the registered owner shown to officers and admins
+type PlateOwner struct {
+    LTOClientID  string  `json:"lto_client_id"`
+    FirstName    string  `json:"first_name"`
+    MiddleName   string  `json:"middle_name,omitempty"`
+    LastName     string  `json:"last_name"`
+    Email        string  `json:"email"`
+    MobileNumber *string `json:"mobile_number,omitempty"`
+}
+
+// PlateDetailResponse is a plate with its vehicle and owner, for officers and admins
+type PlateDetailResponse struct {
+    Plate   models.Plate    `json:"plate"`
+    Vehicle *models.Vehicle `json:"vehicle"`
+    Owner   *PlateOwner     `json:"owner,omitempty"`
+}
+
 type PlateHandler struct {
-    repo repository.PlateRepository
+    repo        repository.PlateRepository
+    vehicleRepo repository.VehicleRepository
+    userRepo    repository.UserRepository
+    notifyRepo  repository.PlateNotificationLogRepository
+    prefsRepo   repository.NotificationPreferencesRepository
+    sms         sms.SMSSender
+    qrSecret    []byte // signs plate QR codes
+
+    statsCache  sync.Map // "stats" -> cachedPlateStats
 }
 
-func NewPlateHandler(pr repository.PlateRepository) *PlateHandler {
-    return &PlateHandler{repo: pr}
+// cachedPlateStats is a stats snapshot and when it was fetched
+type cachedPlateStats struct {
+    stats     *models.PlateStats
+    fetchedAt time.Time
 }
 
+// plateStatsTTL is how long GetPlateStats serves a cached snapshot
+const plateStatsTTL = 5 * time.Minute
+
+func NewPlateHandler(
+    pr repository.PlateRepository,
+    vr repository.VehicleRepository,
+    ur repository.UserRepository,
+    nr repository.PlateNotificationLogRepository,
+    np repository.NotificationPreferencesRepository,
+    qrSecret []byte,
+) *PlateHandler {
+    return &PlateHandler{repo: pr, vehicleRepo: vr, userRepo: ur, notifyRepo: nr, prefsRepo: np, sms: sms.NullSMSSender{}, qrSecret: qrSecret}
+}
+
+// SetSMSSender sends expiry reminders by text as well as email to owners
+// with a mobile number on file
+func (h *PlateHandler) SetSMSSender(s sms.SMSSender) {
+    h.sms = s
+}
+
+// actorID returns the caller's LTO client ID when auth middleware has set one
+func actorID(c echo.Context) string {
+    if id, ok := c.Get("lto_client_id").(string); ok {
+        return id
+    }
+    return ""
+}
+
+// mayActFor reports whether the caller may change ltoClientID's vehicles and
+// plates: officers and admins always, users only their own
+func mayActFor(c echo.Context, ltoClientID string) bool {
+    role, _ := c.Get("role").(string)
+    return role != models.RoleUser || ltoClientID == actorID(c)
+}
+
+// ownerOf looks up the user who owns the vehicle a plate belongs to
+func (h *PlateHandler) ownerOf(ctx context.Context, vehicleID string) (*models.User, error) {
+    v, err := h.vehicleRepo.GetVehicleByID(ctx, vehicleID)
+    if err != nil {
+        return nil, err
+    }
+    owner, err := h.userRepo.GetByLTOClientID(v.LTO_CLIENT_ID)
+    if err != nil {
+        return nil, err
+    }
+    return &owner, nil
+}
+
+// expiryNotifyCooldown is how long to wait before re-notifying the same plate
+const expiryNotifyCooldown = 7 * 24 * time.Hour
+
+// plateValidityYears is how long a plate stays valid after issue or renewal
+const plateValidityYears = 3
+
 // POST /api/vehicles/:vehicle_id/plates
+// @Summary Create a plate
+// @Description Adds a plate to a vehicle after checking the number matches the vehicle type's format.
+// @Tags plates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param vehicle_id path string true "Vehicle ID"
+// @Param plate body models.Plate true "Plate"
+// @Success 201 {object} models.Plate
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/vehicles/{vehicle_id}/plates [post]
 func (h *PlateHandler) CreatePlate(c echo.Context) error {
     vehicleID := c.Param("vehicle_id")
     var p models.Plate
@@ -25,6 +186,16 @@ func (h *PlateHandler) CreatePlate(c echo.Context) error {
         return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
     }
     p.VEHICLE_ID = vehicleID
+
+    // the plate format depends on the vehicle type (2-Wheel vs 4-wheelers)
+    v, err := h.vehicleRepo.GetVehicleByID(c.Request().Context(), vehicleID)
+    if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "vehicle not found"})
+    }
+    if err := plate.ValidatePlateNumber(v.VEHICLE_TYPE, p.PLATE_TYPE, p.PLATE_NUMBER); err != nil {
+        return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+    }
+
     created, err := h.repo.CreatePlate(c.Request().Context(), &p)
     if err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -33,9 +204,26 @@ func (h *PlateHandler) CreatePlate(c echo.Context) error {
 }
 
 // GET /api/vehicles/:vehicle_id/plates
+// LTO officers only see plates of vehicles registered in their region.
+// @Summary List a vehicle's plates
+// @Tags plates
+// @Produce json
+// @Security BearerAuth
+// @Param vehicle_id path string true "Vehicle ID"
+// @Success 200 {array} models.Plate
+// @Failure 500 {object} map[string]string
+// @Router /api/vehicles/{vehicle_id}/plates [get]
 func (h *PlateHandler) GetPlates(c echo.Context) error {
     vehicleID := c.Param("vehicle_id")
-    list, err := h.repo.GetPlatesByVehicleID(c.Request().Context(), vehicleID)
+    var (
+        list []models.Plate
+        err  error
+    )
+    if region := middleware.ScopedRegion(c); region != "" {
+        list, err = h.repo.GetPlatesByVehicleIDInRegion(c.Request().Context(), vehicleID, region)
+    } else {
+        list, err = h.repo.GetPlatesByVehicleID(c.Request().Context(), vehicleID)
+    }
     if err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
     }
@@ -43,9 +231,31 @@ func (h *PlateHandler) GetPlates(c echo.Context) error {
 }
 
 // GET /api/vehicles/:vehicle_id/plates/:plate_id
+// LTO officers only see plates of vehicles registered in their region.
+// @Summary Get a plate
+// @Tags plates
+// @Produce json
+// @Security BearerAuth
+// @Param vehicle_id path string true "Vehicle ID"
+// @Param plate_id path string true "Plate ID"
+// @Success 200 {object} models.Plate
+// @Failure 404 {object} map[string]string
+// @Router /api/vehicles/{vehicle_id}/plates/{plate_id} [get]
 func (h *PlateHandler) GetPlateByID(c echo.Context) error {
     vehicleID := c.Param("vehicle_id")
     plateID    := c.Param("plate_id")
+    if region := middleware.ScopedRegion(c); region != "" {
+        list, err := h.repo.GetPlatesByVehicleIDInRegion(c.Request().Context(), vehicleID, region)
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        for i := range list {
+            if list[i].PlateID == plateID {
+                return c.JSON(http.StatusOK, list[i])
+            }
+        }
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
     p, err := h.repo.GetPlateByID(c.Request().Context(), vehicleID, plateID)
     if err != nil {
         return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
@@ -54,6 +264,19 @@ func (h *PlateHandler) GetPlateByID(c echo.Context) error {
 }
 
 // PUT /api/vehicles/:vehicle_id/plates/:plate_id
+// @Summary Update a plate
+// @Description Updates only the fields present in the body; the previous version is kept in the plate history.
+// @Tags plates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param vehicle_id path string true "Vehicle ID"
+// @Param plate_id path string true "Plate ID"
+// @Param fields body object true "Fields to change"
+// @Success 200 {object} models.Plate
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/vehicles/{vehicle_id}/plates/{plate_id} [put]
 func (h *PlateHandler) UpdatePlate(c echo.Context) error {
     vehicleID := c.Param("vehicle_id")
     plateID   := c.Param("plate_id")
@@ -63,9 +286,13 @@ func (h *PlateHandler) UpdatePlate(c echo.Context) error {
     if err := c.Bind(&fields); err != nil {
         return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
     }
+    // a plate can't be moved to another vehicle by editing it
+    if v, ok := fields["vehicle_id"]; ok && v != vehicleID {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "vehicle_id does not match the path"})
+    }
 
     // perform dynamic update
-    if err := h.repo.UpdatePlate(c.Request().Context(), vehicleID, plateID, fields); err != nil {
+    if err := h.repo.UpdatePlate(c.Request().Context(), vehicleID, plateID, actorID(c), fields); err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
     }
 
@@ -77,7 +304,65 @@ func (h *PlateHandler) UpdatePlate(c echo.Context) error {
     return c.JSON(http.StatusOK, updated)
 }
 
+// PlateStatusRequest is the body of UpdateVehiclePlatesStatus
+type PlateStatusRequest struct {
+    Status string `json:"status" example:"Deactivated"`
+    Reason string `json:"reason" example:"Vehicle sold"`
+}
+
+// PUT /api/vehicles/:vehicle_id/plates/status
+// @Summary Set the status of all of a vehicle's plates
+// @Description Used when a vehicle is sold or decommissioned. Every plate is snapshotted into its history first.
+// @Tags plates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param vehicle_id path string true "Vehicle ID"
+// @Param body body PlateStatusRequest true "New status and reason"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/vehicles/{vehicle_id}/plates/status [put]
+func (h *PlateHandler) UpdateVehiclePlatesStatus(c echo.Context) error {
+    vehicleID := c.Param("vehicle_id")
+    var req PlateStatusRequest
+    if err := c.Bind(&req); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    if !models.ValidPlateStatus(req.Status) {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "status must be one of: Active, Expired, Deactivated, Temporary"})
+    }
+    if req.Reason == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "reason is required"})
+    }
+
+    n, err := h.repo.BulkUpdateStatus(c.Request().Context(), vehicleID, req.Status, actorID(c))
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    if n == 0 {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "vehicle has no plates"})
+    }
+    middleware.LoggerFrom(c).Info("vehicle plates status changed",
+        "vehicle_id", vehicleID, "status", req.Status, "reason", req.Reason, "affected_count", n)
+    return c.JSON(http.StatusOK, map[string]interface{}{
+        "vehicle_id":     vehicleID,
+        "status":         req.Status,
+        "reason":         req.Reason,
+        "affected_count": n,
+    })
+}
+
 // DELETE /api/vehicles/:vehicle_id/plates/:plate_id
+// @Summary Delete a plate
+// @Tags plates
+// @Security BearerAuth
+// @Param vehicle_id path string true "Vehicle ID"
+// @Param plate_id path string true "Plate ID"
+// @Success 204
+// @Failure 500 {object} map[string]string
+// @Router /api/vehicles/{vehicle_id}/plates/{plate_id} [delete]
 func (h *PlateHandler) DeletePlateByID(c echo.Context) error {
     vehicleID := c.Param("vehicle_id")
     plateID    := c.Param("plate_id")
@@ -86,3 +371,770 @@ func (h *PlateHandler) DeletePlateByID(c echo.Context) error {
     }
     return c.NoContent(http.StatusNoContent)
 }
+
+// GET /api/vehicles/:vehicle_id/plates/:plate_id/qr
+// @Summary Get a plate's signed QR code
+// @Tags plates
+// @Produce png
+// @Security BearerAuth
+// @Param vehicle_id path string true "Vehicle ID"
+// @Param plate_id path string true "Plate ID"
+// @Success 200 {file} binary
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/vehicles/{vehicle_id}/plates/{plate_id}/qr [get]
+func (h *PlateHandler) GenerateQR(c echo.Context) error {
+    vehicleID := c.Param("vehicle_id")
+    plateID   := c.Param("plate_id")
+    p, err := h.repo.GetPlateByID(c.Request().Context(), vehicleID, plateID)
+    if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
+
+    data, err := plate.EncodeQRData(plate.QRPayload{
+        PlateNumber:    p.PLATE_NUMBER,
+        VehicleID:      p.VEHICLE_ID,
+        ExpirationDate: p.PLATE_EXPIRATION_DATE.Format("2006-01-02"),
+    }, h.qrSecret)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+
+    png, err := qrcode.Encode(data, qrcode.Medium, 256)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.Blob(http.StatusOK, "image/png", png)
+}
+
+// GET /api/vehicles/:vehicle_id/plates/:plate_id/history
+// @Summary List a plate's previous versions
+// @Tags plates
+// @Produce json
+// @Security BearerAuth
+// @Param vehicle_id path string true "Vehicle ID"
+// @Param plate_id path string true "Plate ID"
+// @Success 200 {array} models.PlateHistory
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/vehicles/{vehicle_id}/plates/{plate_id}/history [get]
+func (h *PlateHandler) GetPlateHistory(c echo.Context) error {
+    vehicleID := c.Param("vehicle_id")
+    plateID   := c.Param("plate_id")
+    if _, err := h.repo.GetPlateByID(c.Request().Context(), vehicleID, plateID); err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
+    list, err := h.repo.GetPlateHistory(c.Request().Context(), plateID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, list)
+}
+
+// POST /api/vehicles/plates/bulk
+// @Summary Create plates in bulk
+// @Description Validates every row, including that its number isn't taken or repeated in the batch, and inserts the valid ones. A row that fails doesn't stop the others; each failure is reported by index.
+// @Tags plates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param plates body []CreatePlateRequest true "Plates"
+// @Success 200 {object} BulkPlateResponse
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 500 {object} BulkPlateResponse
+// @Router /api/vehicles/plates/bulk [post]
+func (h *PlateHandler) BulkCreatePlates(c echo.Context) error {
+    var reqs []CreatePlateRequest
+    if err := c.Bind(&reqs); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    if len(reqs) > maxBulkPlates {
+        return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+            "error": fmt.Sprintf("at most %d plates per request", maxBulkPlates),
+        })
+    }
+
+    ctx := c.Request().Context()
+    resp := BulkPlateResponse{Failed: []BulkPlateFailure{}}
+    vehicleTypes := map[string]string{} // vehicle_id -> vehicle_type, looked up once per vehicle
+    seen := map[string]int{}            // normalized plate number -> first index using it
+    valid := make([]*models.Plate, 0, len(reqs))
+    validIdx := make([]int, 0, len(reqs))
+
+    for i, r := range reqs {
+        if r.VehicleID == "" || r.PlateNumber == "" {
+            resp.Failed = append(resp.Failed, BulkPlateFailure{Index: i, Error: "vehicle_id and plate_number are required"})
+            continue
+        }
+        vt, ok := vehicleTypes[r.VehicleID]
+        if !ok {
+            v, err := h.vehicleRepo.GetVehicleByID(ctx, r.VehicleID)
+            if err != nil {
+                resp.Failed = append(resp.Failed, BulkPlateFailure{Index: i, Error: "vehicle not found"})
+                continue
+            }
+            vt = v.VEHICLE_TYPE
+            vehicleTypes[r.VehicleID] = vt
+        }
+        if err := plate.ValidatePlateNumber(vt, r.PlateType, r.PlateNumber); err != nil {
+            resp.Failed = append(resp.Failed, BulkPlateFailure{Index: i, Error: err.Error()})
+            continue
+        }
+        key := strings.ToUpper(strings.TrimSpace(r.PlateNumber))
+        if first, dup := seen[key]; dup {
+            resp.Failed = append(resp.Failed, BulkPlateFailure{Index: i, Error: fmt.Sprintf("plate number repeats row %d", first)})
+            continue
+        }
+        seen[key] = i
+        exists, err := h.repo.ExistsWithPlateNumber(ctx, r.PlateNumber)
+        if err != nil {
+            resp.Failed = append(resp.Failed, BulkPlateFailure{Index: i, Error: err.Error()})
+            continue
+        }
+        if exists {
+            resp.Failed = append(resp.Failed, BulkPlateFailure{Index: i, Error: "plate number already exists"})
+            continue
+        }
+        valid = append(valid, &models.Plate{
+            VEHICLE_ID:            r.VehicleID,
+            PLATE_NUMBER:          r.PlateNumber,
+            PLATE_TYPE:            r.PlateType,
+            PLATE_ISSUE_DATE:      r.PlateIssueDate,
+            PLATE_EXPIRATION_DATE: r.PlateExpirationDate,
+            STATUS:                r.Status,
+        })
+        validIdx = append(validIdx, i)
+    }
+
+    if len(valid) > 0 {
+        errs, err := h.repo.BulkCreate(ctx, valid)
+        if err != nil {
+            // the transaction was rolled back, so every valid row failed with it
+            for _, i := range validIdx {
+                resp.Failed = append(resp.Failed, BulkPlateFailure{Index: i, Error: err.Error()})
+            }
+            return c.JSON(http.StatusInternalServerError, resp)
+        }
+        for j, rowErr := range errs {
+            if rowErr != nil {
+                resp.Failed = append(resp.Failed, BulkPlateFailure{Index: validIdx[j], Error: rowErr.Error()})
+            } else {
+                resp.Succeeded++
+            }
+        }
+        sort.Slice(resp.Failed, func(a, b int) bool { return resp.Failed[a].Index < resp.Failed[b].Index })
+    }
+    return c.JSON(http.StatusOK, resp)
+}
+
+// RenewPlateRequest is the body of a plate renewal
+type RenewPlateRequest struct {
+    NewExpirationDate string `json:"new_expiration_date" example:"2030-01-15"`
+}
+
+// PUT /api/vehicles/:vehicle_id/plates/:plate_id/renew
+// @Summary Renew a plate
+// @Tags plates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param vehicle_id path string true "Vehicle ID"
+// @Param plate_id path string true "Plate ID"
+// @Param body body RenewPlateRequest true "New expiration date"
+// @Success 200 {object} models.Plate
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/vehicles/{vehicle_id}/plates/{plate_id}/renew [put]
+func (h *PlateHandler) RenewPlate(c echo.Context) error {
+    ctx := c.Request().Context()
+    vehicleID := c.Param("vehicle_id")
+    plateID   := c.Param("plate_id")
+
+    v, err := h.vehicleRepo.GetVehicleByID(ctx, vehicleID)
+    if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
+    if !mayActFor(c, v.LTO_CLIENT_ID) {
+        return c.JSON(http.StatusForbidden, map[string]string{"error": "you can only renew your own plates"})
+    }
+
+    var req RenewPlateRequest
+    if err := c.Bind(&req); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    newExpiry, err := time.Parse("2006-01-02", req.NewExpirationDate)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "new_expiration_date must be YYYY-MM-DD"})
+    }
+
+    p, err := h.repo.GetPlateByID(ctx, vehicleID, plateID)
+    if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
+    if p.STATUS == "Deactivated" {
+        return c.JSON(http.StatusConflict, map[string]string{"error": "deactivated plates cannot be renewed"})
+    }
+
+    // renewals extend from the current expiry while it's still running, otherwise from issue
+    base := p.PLATE_ISSUE_DATE
+    if p.PLATE_EXPIRATION_DATE.After(time.Now()) {
+        base = p.PLATE_EXPIRATION_DATE
+    }
+    expected := base.AddDate(plateValidityYears, 0, 0).Format("2006-01-02")
+    if req.NewExpirationDate != expected {
+        return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+            "error": fmt.Sprintf("new_expiration_date must be %s (%d years after %s)",
+                expected, plateValidityYears, base.Format("2006-01-02")),
+        })
+    }
+
+    // the new expiry and its renewal row are written together or not at all
+    if err := h.repo.RenewPlate(ctx, vehicleID, actorID(c), &models.PlateRenewalHistory{
+        PlateID:                plateID,
+        PreviousExpirationDate: p.PLATE_EXPIRATION_DATE,
+        NewExpirationDate:      newExpiry,
+    }); err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+
+    updated, err := h.repo.GetPlateByID(ctx, vehicleID, plateID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+
+    // confirmation email is best-effort and shouldn't hold up the response
+    if owner, err := h.ownerOf(ctx, vehicleID); err == nil {
+        reqID := middleware.GetRequestID(c)
+        go func() {
+            name := owner.FIRST_NAME + " " + owner.LAST_NAME
+            if err := email.SendPlateRenewalConfirmation(owner.EMAIL, name, updated.PLATE_NUMBER, newExpiry); err != nil {
+                log.Printf("[req-id:%s] renewal email error: %v", reqID, err)
+            }
+        }()
+    }
+    return c.JSON(http.StatusOK, updated)
+}
+
+// DeactivatePlateRequest is the body of a plate deactivation
+type DeactivatePlateRequest struct {
+    Reason        string `json:"reason" example:"Vehicle reported stolen"`
+    EffectiveDate string `json:"effective_date" example:"2025-03-01"`
+}
+
+// PUT /api/vehicles/:vehicle_id/plates/:plate_id/deactivate
+// @Summary Deactivate a plate
+// @Description Sets the plate to Deactivated and its expiration date to effective_date, recording the reason in the plate's history. The owner is emailed a confirmation.
+// @Tags plates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param vehicle_id path string true "Vehicle ID"
+// @Param plate_id path string true "Plate ID"
+// @Param body body DeactivatePlateRequest true "Reason and effective date"
+// @Success 200 {object} models.Plate
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/vehicles/{vehicle_id}/plates/{plate_id}/deactivate [put]
+func (h *PlateHandler) DeactivatePlate(c echo.Context) error {
+    ctx := c.Request().Context()
+    vehicleID := c.Param("vehicle_id")
+    plateID   := c.Param("plate_id")
+
+    var req DeactivatePlateRequest
+    if err := c.Bind(&req); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    req.Reason = strings.TrimSpace(req.Reason)
+    if req.Reason == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "reason is required"})
+    }
+    effective, err := time.Parse("2006-01-02", req.EffectiveDate)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "effective_date must be YYYY-MM-DD"})
+    }
+
+    p, err := h.repo.GetPlateByID(ctx, vehicleID, plateID)
+    if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
+    if p.STATUS == models.PlateDeactivated {
+        return c.JSON(http.StatusConflict, map[string]string{"error": "plate is already deactivated"})
+    }
+    if effective.Before(p.PLATE_ISSUE_DATE.Truncate(24 * time.Hour)) {
+        return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "effective_date must not be before the plate's issue date"})
+    }
+
+    err = h.repo.DeactivatePlate(ctx, vehicleID, plateID, actorID(c), req.Reason, effective)
+    switch {
+    case errors.Is(err, sql.ErrNoRows):
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    case errors.Is(err, repository.ErrPlateDeactivated):
+        // deactivated by someone else since the check above
+        return c.JSON(http.StatusConflict, map[string]string{"error": "plate is already deactivated"})
+    case err != nil:
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+
+    updated, err := h.repo.GetPlateByID(ctx, vehicleID, plateID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+
+    // confirmation email is best-effort and shouldn't hold up the response
+    if owner, err := h.ownerOf(ctx, vehicleID); err == nil {
+        reqID := middleware.GetRequestID(c)
+        go func() {
+            name := owner.FIRST_NAME + " " + owner.LAST_NAME
+            if err := email.SendPlateDeactivationNotification(owner.EMAIL, name, updated.PLATE_NUMBER, req.Reason, effective); err != nil {
+                log.Printf("[req-id:%s] deactivation email error: %v", reqID, err)
+            }
+        }()
+    }
+    return c.JSON(http.StatusOK, updated)
+}
+
+// GET /api/plates/search?q=&status=&type=&page=&limit=
+// @Summary Search plates
+// @Tags plates
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Plate number fragment (min 2 chars)"
+// @Param status query string false "Plate status"
+// @Param type query string false "Plate type"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {array} models.PlateSearchResult
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/plates/search [get]
+func (h *PlateHandler) SearchPlates(c echo.Context) error {
+    q := c.QueryParam("q")
+    if len(q) < 2 {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "q must be at least 2 characters"})
+    }
+
+    _, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+
+    list, err := h.repo.Search(c.Request().Context(), q, c.QueryParam("status"), c.QueryParam("type"), limit, offset)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, list)
+}
+
+// POST /api/plates/validate
+// @Summary Check a plate number before creating it
+// @Description Checks the number against the plate format for its vehicle and plate type and whether it is already issued. Nothing is written.
+// @Tags plates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body ValidatePlateRequest true "Plate number to check"
+// @Success 200 {object} ValidatePlateResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/plates/validate [post]
+func (h *PlateHandler) ValidatePlateNumber(c echo.Context) error {
+    var req ValidatePlateRequest
+    if err := c.Bind(&req); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    if req.PlateNumber == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "plate_number is required"})
+    }
+
+    resp := ValidatePlateResponse{Valid: true}
+    if err := plate.ValidatePlateNumber(req.VehicleType, req.PlateType, req.PlateNumber); err != nil {
+        resp.Valid = false
+        resp.FormatError = err.Error()
+    }
+    exists, err := h.repo.ExistsWithPlateNumber(c.Request().Context(), req.PlateNumber)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    if exists {
+        resp.Valid = false
+        resp.AlreadyExists = true
+    }
+    return c.JSON(http.StatusOK, resp)
+}
+
+// GET /api/plates/:plate_number
+// @Summary Look up a plate by number
+// @Description Public. Anonymous callers get plate_number, status, type and expiry_date only; LTO officers and admins get the plate with its vehicle and owner (PlateDetailResponse). URL-encode the space in the plate number.
+// @Tags plates
+// @Produce json
+// @Param plate_number path string true "Plate number" example(ABC 12340)
+// @Success 200 {object} PublicPlateResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/plates/{plate_number} [get]
+func (h *PlateHandler) GetPlateByNumber(c echo.Context) error {
+    number, err := url.PathUnescape(c.Param("plate_number"))
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid plate number"})
+    }
+    number = strings.ToUpper(strings.TrimSpace(number))
+    if err := plate.ValidateAnyPlateNumber(number); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+
+    ctx := c.Request().Context()
+    p, err := h.repo.GetByPlateNumber(ctx, number)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    if p == nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "plate not found"})
+    }
+    if !middleware.PIIVisible(c) {
+        return c.JSON(http.StatusOK, PublicPlateResponse{
+            PlateNumber: p.PLATE_NUMBER,
+            Status:      p.STATUS,
+            Type:        p.PLATE_TYPE,
+            ExpiryDate:  p.PLATE_EXPIRATION_DATE,
+        })
+    }
+
+    resp := PlateDetailResponse{Plate: *p}
+    if v, err := h.vehicleRepo.GetVehicleByID(ctx, p.VEHICLE_ID); err == nil {
+        resp.Vehicle = v
+        if owner, err := h.userRepo.GetByLTOClientID(v.LTO_CLIENT_ID); err == nil {
+            resp.Owner = &PlateOwner{
+                LTOClientID:  owner.LTO_CLIENT_ID,
+                FirstName:    owner.FIRST_NAME,
+                MiddleName:   owner.MIDDLE_NAME,
+                LastName:     owner.LAST_NAME,
+                Email:        owner.EMAIL,
+                MobileNumber: owner.Contact.MOBILE_NUMBER,
+            }
+        }
+    }
+    return c.JSON(http.StatusOK, resp)
+}
+
+// GET /api/admin/plates?status=Expired&page=1&limit=50
+// @Summary List plates by status or type
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Active, Expired, Deactivated or Temporary"
+// @Param type query string false "Plate type, used when status is not given"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/plates [get]
+func (h *PlateHandler) ListPlates(c echo.Context) error {
+    status, plateType := c.QueryParam("status"), c.QueryParam("type")
+    if status == "" && plateType == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "status or type is required"})
+    }
+    if status != "" && !models.ValidPlateStatus(status) {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "status must be one of: Active, Expired, Deactivated, Temporary"})
+    }
+
+    page, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+
+    var (
+        list  []models.Plate
+        total int
+    )
+    if status != "" {
+        list, total, err = h.repo.GetByStatus(c.Request().Context(), status, limit, offset)
+    } else {
+        list, total, err = h.repo.GetByType(c.Request().Context(), plateType, limit, offset)
+    }
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, PaginatedResponse(list, total, page, limit))
+}
+
+// GET /api/users/:lto_client_id/plates?status=active&page=1&limit=20
+// @Summary List a client's plates
+// @Description Plates on every vehicle registered to the client. Users may only list their own; officers and admins anyone's.
+// @Tags plates
+// @Produce json
+// @Security BearerAuth
+// @Param lto_client_id path string true "LTO client ID"
+// @Param status query string false "Active, Expired, Deactivated or Temporary (any case)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/{lto_client_id}/plates [get]
+func (h *PlateHandler) GetByOwner(c echo.Context) error {
+    owner := c.Param("lto_client_id")
+    if role, _ := c.Get("role").(string); role == models.RoleUser && owner != actorID(c) {
+        return c.JSON(http.StatusForbidden, map[string]string{"error": "you can only list your own plates"})
+    }
+    var status string
+    if raw := c.QueryParam("status"); raw != "" {
+        for _, s := range []string{models.PlateActive, models.PlateExpired, models.PlateDeactivated, models.PlateTemporary} {
+            if strings.EqualFold(raw, s) {
+                status = s
+            }
+        }
+        if status == "" {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": "status must be one of: Active, Expired, Deactivated, Temporary"})
+        }
+    }
+
+    page, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    list, total, err := h.repo.GetByOwner(c.Request().Context(), owner, status, limit, offset)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, PaginatedResponse(list, total, page, limit))
+}
+
+// GET /api/admin/plates/expired?page=1&limit=20
+// @Summary List expired plates
+// @Description Plates with status Expired. The hourly cleanup job marks Active plates past their expiration date Expired.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/plates/expired [get]
+func (h *PlateHandler) GetExpired(c echo.Context) error {
+    page, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    list, total, err := h.repo.GetByStatus(c.Request().Context(), models.PlateExpired, limit, offset)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, PaginatedResponse(list, total, page, limit))
+}
+
+// GET /api/admin/plates/expiring?days=30&notify=true
+// @Summary Count plates expiring soon
+// @Description Optionally emails each owner, skipping plates notified in the last week.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Window in days (1-365)" default(30)
+// @Param notify query bool false "Email the owners"
+// @Success 200 {object} map[string]int
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/plates/expiring [get]
+func (h *PlateHandler) GetExpiringSoon(c echo.Context) error {
+    ctx := c.Request().Context()
+    days := 30
+    if raw := c.QueryParam("days"); raw != "" {
+        d, err := strconv.Atoi(raw)
+        if err != nil || d < 1 || d > 365 {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": "days must be between 1 and 365"})
+        }
+        days = d
+    }
+
+    plates, err := h.repo.GetExpiringSoon(ctx, time.Duration(days)*24*time.Hour)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+
+    sent := 0
+    if c.QueryParam("notify") == "true" {
+        // claim each plate first so repeated or concurrent calls don't spam
+        // owners; a plate notified recently is already claimed
+        var pending []models.Plate
+        for _, p := range plates {
+            claimed, err := h.notifyRepo.Claim(ctx, p.PlateID, time.Now().Add(-expiryNotifyCooldown))
+            if err != nil {
+                return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+            }
+            if claimed {
+                pending = append(pending, p)
+            }
+        }
+        sent = len(pending)
+        go h.sendExpiryNotifications(middleware.GetRequestID(c), pending)
+    }
+
+    return c.JSON(http.StatusOK, map[string]int{
+        "total_expiring":     len(plates),
+        "notifications_sent": sent,
+    })
+}
+
+// sendExpiryNotifications emails each plate's owner, texts owners with a
+// mobile number, and records the email in the notification log; reqID tags
+// its log lines with the triggering request
+func (h *PlateHandler) sendExpiryNotifications(reqID string, plates []models.Plate) {
+    ctx := context.Background()
+    renewalBase := os.Getenv("FRONTEND_URL")
+    if renewalBase == "" {
+        renewalBase = "http://localhost:5173"
+    }
+    for _, p := range plates {
+        owner, err := h.ownerOf(ctx, p.VEHICLE_ID)
+        if err != nil {
+            log.Printf("[req-id:%s] expiry notification: no owner for plate %s: %v", reqID, p.PlateID, err)
+            continue
+        }
+        // without their preferences we can't tell whether they opted out, so skip them
+        prefs, err := h.prefsRepo.GetByLTOClientID(ctx, owner.LTO_CLIENT_ID)
+        if err != nil {
+            log.Printf("[req-id:%s] expiry notification preferences error for plate %s: %v", reqID, p.PlateID, err)
+            continue
+        }
+        sent := false
+        if mobile := owner.Contact.MOBILE_NUMBER; prefs.SMSPlateExpiry && mobile != nil && *mobile != "" {
+            days := int(math.Ceil(time.Until(p.PLATE_EXPIRATION_DATE).Hours() / 24))
+            if err := h.sms.SendPlateExpiry(*mobile, p.PLATE_NUMBER, days); err != nil {
+                log.Printf("[req-id:%s] expiry notification sms error: %v", reqID, err)
+            } else {
+                sent = true
+            }
+        }
+        if prefs.EmailPlateExpiry {
+            renewalURL := fmt.Sprintf("%s/vehicles/%s/plates/%s/renew", renewalBase, p.VEHICLE_ID, p.PlateID)
+            name := owner.FIRST_NAME + " " + owner.LAST_NAME
+            if err := email.SendPlateExpiryNotification(owner.EMAIL, name, p.PLATE_NUMBER, p.PLATE_EXPIRATION_DATE, renewalURL); err != nil {
+                log.Printf("[req-id:%s] expiry notification email error: %v", reqID, err)
+            } else {
+                sent = true
+            }
+        }
+        if !sent {
+            continue
+        }
+        if err := h.notifyRepo.Create(ctx, p.PlateID, owner.EMAIL); err != nil {
+            log.Printf("[req-id:%s] expiry notification log error: %v", reqID, err)
+        }
+    }
+}
+
+// TransferPlateRequest is the body of a plate transfer
+type TransferPlateRequest struct {
+    TargetVehicleID string `json:"target_vehicle_id" example:"1e2f3a4b-5c6d-4e7f-8a9b-0c1d2e3f4a5b"`
+}
+
+// POST /api/plates/:plate_id/transfer
+// @Summary Transfer a plate to another vehicle
+// @Tags plates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param plate_id path string true "Plate ID"
+// @Param body body TransferPlateRequest true "Target vehicle"
+// @Success 200 {object} models.PlateTransfer
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/plates/{plate_id}/transfer [post]
+func (h *PlateHandler) TransferPlate(c echo.Context) error {
+    ctx := c.Request().Context()
+    plateID := c.Param("plate_id")
+
+    var req TransferPlateRequest
+    if err := c.Bind(&req); err != nil || req.TargetVehicleID == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "target_vehicle_id is required"})
+    }
+
+    p, err := h.repo.GetPlateByPlateID(ctx, plateID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    if p == nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "plate not found"})
+    }
+    if p.STATUS != "Active" {
+        return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "only Active plates can be transferred"})
+    }
+    if p.VEHICLE_ID == req.TargetVehicleID {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "plate is already on that vehicle"})
+    }
+    if _, err := h.vehicleRepo.GetVehicleByID(ctx, p.VEHICLE_ID); err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "source vehicle not found"})
+    }
+    target, err := h.vehicleRepo.GetVehicleByID(ctx, req.TargetVehicleID)
+    if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "target vehicle not found"})
+    }
+
+    existing, err := h.repo.GetPlatesByVehicleID(ctx, target.VEHICLE_ID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    for _, ep := range existing {
+        if ep.PLATE_TYPE == p.PLATE_TYPE {
+            return c.JSON(http.StatusConflict, map[string]string{
+                "error": fmt.Sprintf("target vehicle already has a %s plate (%s)", ep.PLATE_TYPE, ep.PLATE_NUMBER),
+            })
+        }
+    }
+
+    transfer := &models.PlateTransfer{
+        PlateID:       p.PlateID,
+        FromVehicleID: p.VEHICLE_ID,
+        ToVehicleID:   target.VEHICLE_ID,
+        TransferredBy: actorID(c),
+    }
+    if err := h.repo.TransferPlate(ctx, transfer); err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+
+    if owner, err := h.ownerOf(ctx, target.VEHICLE_ID); err == nil {
+        reqID := middleware.GetRequestID(c)
+        go func() {
+            name := owner.FIRST_NAME + " " + owner.LAST_NAME
+            if err := email.SendPlateTransferNotification(owner.EMAIL, name, p.PLATE_NUMBER, target.MV_FILE_NUMBER); err != nil {
+                log.Printf("[req-id:%s] transfer email error: %v", reqID, err)
+            }
+        }()
+    }
+    return c.JSON(http.StatusOK, transfer)
+}
+
+// GET /api/admin/plates/stats
+// @Summary Plate statistics
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.PlateStats
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/plates/stats [get]
+func (h *PlateHandler) GetPlateStats(c echo.Context) error {
+    if v, ok := h.statsCache.Load("stats"); ok {
+        cached := v.(cachedPlateStats)
+        if time.Since(cached.fetchedAt) < plateStatsTTL {
+            return c.JSON(http.StatusOK, cached.stats)
+        }
+    }
+
+    stats, err := h.repo.GetStats(c.Request().Context())
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    h.statsCache.Store("stats", cachedPlateStats{stats: stats, fetchedAt: time.Now()})
+    return c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,782 @@
+package handlers
+
+import (
+    "bytes"
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "image/png"
+    "net/http"
+    "net/http/httptest"
+    "sort"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+    "github.com/labstack/echo/v4"
+    "github.com/makiuchi-d/gozxing"
+    "github.com/makiuchi-d/gozxing/qrcode"
+
+    "smartplate-api/internal/middleware"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/plate"
+    "smartplate-api/internal/repository"
+    "smartplate-api/internal/testutil"
+)
+
+// fakePlateRepo keeps plates in memory. Methods a test doesn't need fall
+// through to the nil embedded interface and panic.
+type fakePlateRepo struct {
+    repository.PlateRepository
+    plates map[string]*models.Plate // by plate ID
+
+    searchArgs []interface{} // the last Search call's arguments
+    pageArgs   []interface{} // the last GetByStatus or GetByType call's arguments
+    updates    []plateUpdate
+    history    []plateUpdate // snapshots written by BulkUpdateStatus
+    createErr  error         // returned by CreatePlateTx
+    // deactivateErr is returned by DeactivatePlate, as when another request
+    // got there first
+    deactivateErr error
+    // forms lists the vehicle of each registration form by owner; a
+    // vehicle may appear more than once
+    forms map[string][]string
+    // regions is where each vehicle is registered, by vehicle ID
+    regions map[string]string
+    // expiring is returned by GetExpiringSoon
+    expiring []models.Plate
+}
+
+// plateUpdate records one UpdatePlate call
+type plateUpdate struct {
+    plateID   string
+    changedBy string
+    fields    map[string]interface{}
+}
+
+func (f *fakePlateRepo) GetPlateByID(ctx context.Context, vehicleID, plateID string) (*models.Plate, error) {
+    p, ok := f.plates[plateID]
+    if !ok || p.VEHICLE_ID != vehicleID {
+        return nil, sql.ErrNoRows
+    }
+    return p, nil
+}
+
+func (f *fakePlateRepo) ExistsWithPlateNumber(ctx context.Context, number string) (bool, error) {
+    for _, p := range f.plates {
+        if p.PLATE_NUMBER == number {
+            return true, nil
+        }
+    }
+    return false, nil
+}
+
+func (f *fakePlateRepo) CreatePlateTx(ctx context.Context, tx *sqlx.Tx, p *models.Plate) (*models.Plate, error) {
+    if f.createErr != nil {
+        return nil, f.createErr
+    }
+    if f.plates == nil {
+        f.plates = map[string]*models.Plate{}
+    }
+    p.PlateID = fmt.Sprintf("p%d", len(f.plates)+1)
+    f.plates[p.PlateID] = p
+    return p, nil
+}
+
+func (f *fakePlateRepo) GetPlatesByVehicleID(ctx context.Context, vehicleID string) ([]models.Plate, error) {
+    list := []models.Plate{}
+    for _, p := range f.plates {
+        if p.VEHICLE_ID == vehicleID {
+            list = append(list, *p)
+        }
+    }
+    return list, nil
+}
+
+func (f *fakePlateRepo) GetPlatesByVehicleIDInRegion(ctx context.Context, vehicleID, region string) ([]models.Plate, error) {
+    if f.regions[vehicleID] != region {
+        return []models.Plate{}, nil
+    }
+    return f.GetPlatesByVehicleID(ctx, vehicleID)
+}
+
+func (f *fakePlateRepo) UpdatePlate(ctx context.Context, vehicleID, plateID, changedBy string, fields map[string]interface{}) error {
+    p, ok := f.plates[plateID]
+    if !ok || p.VEHICLE_ID != vehicleID {
+        return sql.ErrNoRows
+    }
+    if status, ok := fields["status"].(string); ok {
+        p.STATUS = status
+    }
+    f.updates = append(f.updates, plateUpdate{plateID: plateID, changedBy: changedBy, fields: fields})
+    return nil
+}
+
+func (f *fakePlateRepo) BulkUpdateStatus(ctx context.Context, vehicleID, newStatus, changedBy string) (int64, error) {
+    var n int64
+    for _, p := range f.plates {
+        if p.VEHICLE_ID == vehicleID {
+            f.history = append(f.history, plateUpdate{plateID: p.PlateID, changedBy: changedBy})
+            p.STATUS = newStatus
+            n++
+        }
+    }
+    return n, nil
+}
+
+func (f *fakePlateRepo) Search(ctx context.Context, q, status, plateType string, limit, offset int) ([]models.PlateSearchResult, error) {
+    f.searchArgs = []interface{}{q, status, plateType, limit, offset}
+    return []models.PlateSearchResult{}, nil
+}
+
+func (f *fakePlateRepo) GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.Plate, int, error) {
+    f.pageArgs = []interface{}{"status", status, limit, offset}
+    return []models.Plate{}, 0, nil
+}
+
+func (f *fakePlateRepo) GetExpiringSoon(ctx context.Context, within time.Duration) ([]models.Plate, error) {
+    return f.expiring, nil
+}
+
+func (f *fakePlateRepo) GetByType(ctx context.Context, plateType string, limit, offset int) ([]models.Plate, int, error) {
+    f.pageArgs = []interface{}{"type", plateType, limit, offset}
+    return []models.Plate{}, 0, nil
+}
+
+func (f *fakePlateRepo) DeactivatePlate(ctx context.Context, vehicleID, plateID, changedBy, reason string, effective time.Time) error {
+    if f.deactivateErr != nil {
+        return f.deactivateErr
+    }
+    p, ok := f.plates[plateID]
+    if !ok || p.VEHICLE_ID != vehicleID {
+        return sql.ErrNoRows
+    }
+    if p.STATUS == models.PlateDeactivated {
+        return repository.ErrPlateDeactivated
+    }
+    f.history = append(f.history, plateUpdate{plateID: plateID, changedBy: changedBy, fields: map[string]interface{}{"reason": reason}})
+    p.STATUS = models.PlateDeactivated
+    p.PLATE_EXPIRATION_DATE = effective
+    return nil
+}
+
+// GetByOwner joins plates to forms the way the SQL does: each vehicle once,
+// soonest to expire first
+func (f *fakePlateRepo) GetByOwner(ctx context.Context, ltoClientID, status string, limit, offset int) ([]models.Plate, int, error) {
+    owned := map[string]bool{}
+    for _, vehicleID := range f.forms[ltoClientID] {
+        owned[vehicleID] = true
+    }
+    list := []models.Plate{}
+    for _, p := range f.plates {
+        if owned[p.VEHICLE_ID] && (status == "" || p.STATUS == status) {
+            list = append(list, *p)
+        }
+    }
+    sort.Slice(list, func(i, j int) bool {
+        if !list[i].PLATE_EXPIRATION_DATE.Equal(list[j].PLATE_EXPIRATION_DATE) {
+            return list[i].PLATE_EXPIRATION_DATE.Before(list[j].PLATE_EXPIRATION_DATE)
+        }
+        return list[i].PlateID < list[j].PlateID
+    })
+    total := len(list)
+    list = list[min(offset, total):min(offset+limit, total)]
+    return list, total, nil
+}
+
+// decodeQR reads the text back out of a QR code PNG
+func decodeQR(t *testing.T, body []byte) string {
+    t.Helper()
+    img, err := png.Decode(bytes.NewReader(body))
+    if err != nil {
+        t.Fatalf("decode png: %v", err)
+    }
+    bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+    if err != nil {
+        t.Fatalf("bitmap: %v", err)
+    }
+    res, err := qrcode.NewQRCodeReader().Decode(bmp, nil)
+    if err != nil {
+        t.Fatalf("decode qr: %v", err)
+    }
+    return res.GetText()
+}
+
+func TestGenerateQR(t *testing.T) {
+    secret := []byte("qr-test-secret")
+    expiry := time.Date(2027, 1, 15, 0, 0, 0, 0, time.UTC)
+    repo := &fakePlateRepo{plates: map[string]*models.Plate{
+        "p1": {PlateID: "p1", VEHICLE_ID: "v1", PLATE_NUMBER: "ABC 12344", PLATE_EXPIRATION_DATE: expiry},
+    }}
+    h := NewPlateHandler(repo, nil, nil, nil, nil, secret)
+
+    rec := httptest.NewRecorder()
+    c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+    c.SetParamNames("vehicle_id", "plate_id")
+    c.SetParamValues("v1", "p1")
+    if err := h.GenerateQR(c); err != nil {
+        t.Fatal(err)
+    }
+    if rec.Code != http.StatusOK {
+        t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+    }
+    if ct := rec.Header().Get(echo.HeaderContentType); ct != "image/png" {
+        t.Fatalf("Content-Type = %q, want image/png", ct)
+    }
+
+    text := decodeQR(t, rec.Body.Bytes())
+    var data plate.QRData
+    if err := json.Unmarshal([]byte(text), &data); err != nil {
+        t.Fatalf("QR content %q is not QR data: %v", text, err)
+    }
+    if len(data.Signature) != 64 {
+        t.Fatalf("signature %q is not a hex HMAC-SHA256", data.Signature)
+    }
+    want := plate.QRPayload{PlateNumber: "ABC 12344", VehicleID: "v1", ExpirationDate: "2027-01-15"}
+    if data.Payload != want {
+        t.Fatalf("payload = %+v, want %+v", data.Payload, want)
+    }
+
+    tampered := data
+    tampered.Payload.PlateNumber = "XYZ 99990"
+    forged, _ := json.Marshal(tampered)
+
+    tests := []struct {
+        name    string
+        data    string
+        secret  []byte
+        wantErr error
+    }{
+        {"valid", text, secret, nil},
+        {"wrong secret", text, []byte("other-secret"), plate.ErrInvalidQRSignature},
+        {"no secret", text, nil, plate.ErrNoQRSecret},
+        {"tampered payload", string(forged), secret, plate.ErrInvalidQRSignature},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            p, err := plate.VerifyQRData(tt.data, tt.secret)
+            if !errors.Is(err, tt.wantErr) {
+                t.Fatalf("VerifyQRData error = %v, want %v", err, tt.wantErr)
+            }
+            if tt.wantErr == nil && *p != want {
+                t.Fatalf("verified payload = %+v, want %+v", *p, want)
+            }
+        })
+    }
+}
+
+func TestGenerateQRNotFound(t *testing.T) {
+    h := NewPlateHandler(&fakePlateRepo{}, nil, nil, nil, nil, []byte("s"))
+    rec := httptest.NewRecorder()
+    c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+    c.SetParamNames("vehicle_id", "plate_id")
+    c.SetParamValues("v1", "missing")
+    if err := h.GenerateQR(c); err != nil {
+        t.Fatal(err)
+    }
+    if rec.Code != http.StatusNotFound {
+        t.Fatalf("status = %d, want 404", rec.Code)
+    }
+}
+
+func TestGetPlateByIDScopedToRegion(t *testing.T) {
+    tests := []struct {
+        name     string
+        region   string // "" for callers who see every region
+        plateID  string
+        wantCode int
+    }{
+        {"admin", "", "p1", http.StatusOK},
+        {"officer in the vehicle's region", "NCR", "p1", http.StatusOK},
+        {"officer in another region", "Region IV-A", "p1", http.StatusNotFound},
+        {"officer asks for a missing plate", "NCR", "p9", http.StatusNotFound},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            repo := &fakePlateRepo{
+                plates:  map[string]*models.Plate{"p1": {PlateID: "p1", VEHICLE_ID: "v1", PLATE_NUMBER: "ABC 1234"}},
+                regions: map[string]string{"v1": "NCR"},
+            }
+            h := NewPlateHandler(repo, nil, nil, nil, nil, nil)
+
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+            c.SetParamNames("vehicle_id", "plate_id")
+            c.SetParamValues("v1", tt.plateID)
+            if tt.region != "" {
+                c.Set(middleware.RegionKey, tt.region)
+            }
+            if err := h.GetPlateByID(c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if tt.wantCode != http.StatusOK {
+                return
+            }
+            var got models.Plate
+            if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+                t.Fatal(err)
+            }
+            if got.PlateID != "p1" || got.PLATE_NUMBER != "ABC 1234" {
+                t.Fatalf("got %+v, want plate p1", got)
+            }
+        })
+    }
+}
+
+func TestUpdatePlate(t *testing.T) {
+    tests := []struct {
+        name      string
+        body      string
+        wantCode  int
+        wantSaved bool
+    }{
+        {"changes a field", `{"status":"Expired"}`, http.StatusOK, true},
+        {"repeats the path's vehicle", `{"vehicle_id":"v1","status":"Expired"}`, http.StatusOK, true},
+        {"names another vehicle", `{"vehicle_id":"v2","status":"Expired"}`, http.StatusBadRequest, false},
+        {"vehicle_id that isn't a string", `{"vehicle_id":1}`, http.StatusBadRequest, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            repo := &fakePlateRepo{plates: map[string]*models.Plate{"p1": {PlateID: "p1", VEHICLE_ID: "v1", STATUS: models.PlateActive}}}
+            h := NewPlateHandler(repo, nil, nil, nil, nil, nil)
+
+            req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body))
+            req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(req, rec)
+            c.SetParamNames("vehicle_id", "plate_id")
+            c.SetParamValues("v1", "p1")
+            if err := h.UpdatePlate(c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if saved := len(repo.updates) == 1; saved != tt.wantSaved {
+                t.Fatalf("saved = %v, want %v", saved, tt.wantSaved)
+            }
+            if tt.wantSaved && repo.plates["p1"].STATUS != "Expired" {
+                t.Fatalf("status is %q, want Expired", repo.plates["p1"].STATUS)
+            }
+        })
+    }
+}
+
+func TestSearchPlates(t *testing.T) {
+    tests := []struct {
+        name     string
+        query    string
+        wantCode int
+        wantArgs []interface{}
+    }{
+        {"missing q", "", http.StatusBadRequest, nil},
+        {"one character", "?q=A", http.StatusBadRequest, nil},
+        {"two characters", "?q=AB", http.StatusOK, []interface{}{"AB", "", "", 20, 0}},
+        {"filters", "?q=ABC&status=active&type=Private&limit=20", http.StatusOK, []interface{}{"ABC", "active", "Private", 20, 0}},
+        {"bad limit", "?q=ABC&limit=x", http.StatusBadRequest, nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            repo := &fakePlateRepo{}
+            h := NewPlateHandler(repo, nil, nil, nil, nil, nil)
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/plates/search"+tt.query, nil), rec)
+            if err := h.SearchPlates(c); err != nil {
+                t.Fatal(err)
+            }
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if fmt.Sprint(repo.searchArgs) != fmt.Sprint(tt.wantArgs) {
+                t.Fatalf("Search args = %v, want %v", repo.searchArgs, tt.wantArgs)
+            }
+        })
+    }
+}
+
+func TestListPlates(t *testing.T) {
+    tests := []struct {
+        name     string
+        query    string
+        wantCode int
+        wantArgs []interface{}
+    }{
+        {"no filter", "", http.StatusBadRequest, nil},
+        {"invalid status", "?status=Stolen", http.StatusBadRequest, nil},
+        {"lowercase status", "?status=expired", http.StatusBadRequest, nil},
+        {"expired", "?status=Expired&limit=50", http.StatusOK, []interface{}{"status", "Expired", 50, 0}},
+        {"temporary", "?status=Temporary", http.StatusOK, []interface{}{"status", "Temporary", 20, 0}},
+        {"third page", "?status=Expired&page=3&limit=20", http.StatusOK, []interface{}{"status", "Expired", 20, 40}},
+        {"by type", "?type=Diplomatic", http.StatusOK, []interface{}{"type", "Diplomatic", 20, 0}},
+        {"status wins over type", "?status=Active&type=Diplomatic", http.StatusOK, []interface{}{"status", "Active", 20, 0}},
+        {"limit too large", "?status=Active&limit=500", http.StatusBadRequest, nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            repo := &fakePlateRepo{}
+            h := NewPlateHandler(repo, nil, nil, nil, nil, nil)
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/admin/plates"+tt.query, nil), rec)
+            if err := h.ListPlates(c); err != nil {
+                t.Fatal(err)
+            }
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if fmt.Sprint(repo.pageArgs) != fmt.Sprint(tt.wantArgs) {
+                t.Fatalf("repository args = %v, want %v", repo.pageArgs, tt.wantArgs)
+            }
+        })
+    }
+}
+
+func TestUpdateVehiclePlatesStatus(t *testing.T) {
+    tests := []struct {
+        name         string
+        vehicleID    string
+        body         string
+        wantCode     int
+        wantAffected float64
+    }{
+        {"three plates", "v1", `{"status":"Deactivated","reason":"sold"}`, http.StatusOK, 3},
+        {"no plates", "v9", `{"status":"Deactivated","reason":"sold"}`, http.StatusNotFound, 0},
+        {"invalid status", "v1", `{"status":"Gone","reason":"sold"}`, http.StatusBadRequest, 0},
+        {"missing reason", "v1", `{"status":"Deactivated"}`, http.StatusBadRequest, 0},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            repo := &fakePlateRepo{plates: map[string]*models.Plate{
+                "p1": {PlateID: "p1", VEHICLE_ID: "v1", STATUS: models.PlateActive},
+                "p2": {PlateID: "p2", VEHICLE_ID: "v1", STATUS: models.PlateActive},
+                "p3": {PlateID: "p3", VEHICLE_ID: "v1", STATUS: models.PlateExpired},
+                "p4": {PlateID: "p4", VEHICLE_ID: "v2", STATUS: models.PlateActive},
+            }}
+            h := NewPlateHandler(repo, nil, nil, nil, nil, nil)
+
+            req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body))
+            req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(req, rec)
+            c.SetParamNames("vehicle_id")
+            c.SetParamValues(tt.vehicleID)
+            c.Set("lto_client_id", "LTO-OFFICER")
+            if err := h.UpdateVehiclePlatesStatus(c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if len(repo.history) != int(tt.wantAffected) {
+                t.Fatalf("got %d history rows, want %v", len(repo.history), tt.wantAffected)
+            }
+            for _, u := range repo.history {
+                if u.changedBy != "LTO-OFFICER" {
+                    t.Errorf("history for %s changed by %q", u.plateID, u.changedBy)
+                }
+            }
+            if tt.wantCode != http.StatusOK {
+                return
+            }
+            var resp map[string]interface{}
+            if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+                t.Fatal(err)
+            }
+            if resp["affected_count"] != tt.wantAffected {
+                t.Fatalf("affected_count = %v, want %v", resp["affected_count"], tt.wantAffected)
+            }
+            if repo.plates["p4"].STATUS != models.PlateActive {
+                t.Fatalf("plate on another vehicle was changed")
+            }
+        })
+    }
+}
+
+func TestValidatePlateNumberEndpoint(t *testing.T) {
+    type tc struct {
+        name        string
+        vehicleType string
+        plateType   string
+        number      string
+        wantValid   bool
+        wantFormat  bool // a format_error is reported
+        wantExists  bool
+    }
+
+    // valid numbers come straight from the generator, one per format
+    g := plate.NewGenerator(42)
+    var tests []tc
+    for _, f := range []struct{ vehicleType, plateType, region string }{
+        {"4-Wheel", "Private", "NCR"},
+        {"4-Wheel", "Government", "CALABARZON"},
+        {"4-Wheel", "Electric", "CENTRAL_LUZON"},
+        {"4-Wheel", "Hybrid", "CAR"},
+        {"4-Wheel", "Trailer", "BICOL"},
+        {"4-Wheel", "Concessionary", "ARMM"},
+        {"4-Wheel", "Vintage", "ZAMBOANGA"},
+        {"4-Wheel", "For Hire", "MIMAROPA"},
+        {"4-Wheel", "PublicUtility", "ILOCOS"},
+        {"4-Wheel", "Diplomatic", "NCR"},
+        {"2-Wheel", "", "SOCCSKSARGEN"},
+        {"2-Wheel", "", "CARAGA"},
+    } {
+        number := g.GeneratePlateNumber(f.vehicleType, f.plateType, f.region)
+        tests = append(tests, tc{"generated " + f.plateType + " " + number, f.vehicleType, f.plateType, number, true, false, false})
+    }
+
+    tests = append(tests, tc{"legacy number without check digit", "4-Wheel", "Private", "ABC 1234", true, false, false})
+
+    // invalid ones break a single rule of the generator's pools
+    tests = append(tests,
+        tc{"letter I is not in the pool", "4-Wheel", "Private", "AIB 1234", false, true, false},
+        tc{"letter O is not in the pool", "4-Wheel", "Private", "ABO 1234", false, true, false},
+        tc{"Q is not a region prefix", "4-Wheel", "Private", "QAB 1234", false, true, false},
+        tc{"government second letter must be S", "4-Wheel", "Government", "AAB 1234", false, true, false},
+        tc{"electric second letter from N on", "4-Wheel", "Electric", "ANV 1234", false, true, false},
+        tc{"hybrid second letter before N", "4-Wheel", "Hybrid", "AAV 1234", false, true, false},
+        tc{"electric third letter before V", "4-Wheel", "Electric", "AAB 1234", false, true, false},
+        tc{"trailer second letter must be U", "4-Wheel", "Trailer", "AAB 1234", false, true, false},
+        tc{"vintage suffix outside TX-TZ", "4-Wheel", "Vintage", "ABTA 1234", false, true, false},
+        tc{"concessionary without PWD", "4-Wheel", "Concessionary", "AAB 1234", false, true, false},
+        tc{"diplomatic country not issued", "4-Wheel", "Diplomatic", "DEU-1234", false, true, false},
+        tc{"motorcycle letter outside pool", "2-Wheel", "", "AI-12345", false, true, false},
+        tc{"wrong check digit", "4-Wheel", "Private", "ABC 12345", false, true, false},
+        tc{"already issued", "4-Wheel", "Private", "ABX 5678", false, false, true},
+    )
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            repo := &fakePlateRepo{plates: map[string]*models.Plate{
+                "p1": {PlateID: "p1", PLATE_NUMBER: "ABX 5678"},
+            }}
+            h := NewPlateHandler(repo, nil, nil, nil, nil, nil)
+            body, _ := json.Marshal(ValidatePlateRequest{PlateNumber: tt.number, VehicleType: tt.vehicleType, PlateType: tt.plateType})
+            req := httptest.NewRequest(http.MethodPost, "/api/plates/validate", bytes.NewReader(body))
+            req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+            rec := httptest.NewRecorder()
+            if err := h.ValidatePlateNumber(echo.New().NewContext(req, rec)); err != nil {
+                t.Fatal(err)
+            }
+            if rec.Code != http.StatusOK {
+                t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+            }
+
+            var resp ValidatePlateResponse
+            if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+                t.Fatal(err)
+            }
+            if resp.Valid != tt.wantValid || (resp.FormatError != "") != tt.wantFormat || resp.AlreadyExists != tt.wantExists {
+                t.Fatalf("%q: got %+v, want valid=%v format_error=%v already_exists=%v",
+                    tt.number, resp, tt.wantValid, tt.wantFormat, tt.wantExists)
+            }
+        })
+    }
+}
+
+func TestGetByOwner(t *testing.T) {
+    expires := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+    plate := func(id, vehicleID, status string, months int) *models.Plate {
+        return &models.Plate{PlateID: id, VEHICLE_ID: vehicleID, PLATE_NUMBER: "ABC " + id, STATUS: status,
+            PLATE_EXPIRATION_DATE: expires.AddDate(0, months, 0)}
+    }
+    repo := &fakePlateRepo{
+        plates: map[string]*models.Plate{},
+        forms: map[string][]string{
+            // v1 was registered and later renewed, so it has two forms
+            "LTO-1": {"v1", "v2", "v1"},
+            "LTO-2": {"v3"},
+        },
+    }
+    for _, p := range []*models.Plate{
+        plate("1001", "v1", models.PlateActive, 3),
+        plate("1002", "v1", models.PlateExpired, -6),
+        plate("1003", "v1", models.PlateDeactivated, 0),
+        plate("2001", "v2", models.PlateActive, 1),
+        plate("2002", "v2", models.PlateTemporary, 2),
+        plate("3001", "v3", models.PlateActive, 0),
+    } {
+        repo.plates[p.PlateID] = p
+    }
+
+    tests := []struct {
+        name      string
+        role      string
+        caller    string
+        owner     string
+        query     string
+        wantCode  int
+        wantIDs   []string
+        wantTotal float64
+    }{
+        {"own plates across vehicles", models.RoleUser, "LTO-1", "LTO-1", "", http.StatusOK,
+            []string{"1002", "1003", "2001", "2002", "1001"}, 5},
+        {"active only, any case", models.RoleUser, "LTO-1", "LTO-1", "?status=active", http.StatusOK,
+            []string{"2001", "1001"}, 2},
+        {"second page", models.RoleUser, "LTO-1", "LTO-1", "?page=2&limit=2", http.StatusOK,
+            []string{"2001", "2002"}, 5},
+        {"someone else's plates", models.RoleUser, "LTO-2", "LTO-1", "", http.StatusForbidden, nil, 0},
+        {"officer may list anyone", models.RoleOfficer, "LTO-OFFICER-7", "LTO-2", "", http.StatusOK,
+            []string{"3001"}, 1},
+        {"admin may list anyone", models.RoleAdmin, "LTO-ADMIN", "LTO-1", "?status=Expired", http.StatusOK,
+            []string{"1002"}, 1},
+        {"no vehicles", models.RoleAdmin, "LTO-ADMIN", "LTO-9", "", http.StatusOK, []string{}, 0},
+        {"unknown status", models.RoleUser, "LTO-1", "LTO-1", "?status=stolen", http.StatusBadRequest, nil, 0},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            h := NewPlateHandler(repo, nil, nil, nil, nil, nil)
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/users/"+tt.owner+"/plates"+tt.query, nil), rec)
+            c.SetParamNames("lto_client_id")
+            c.SetParamValues(tt.owner)
+            c.Set("role", tt.role)
+            c.Set("lto_client_id", tt.caller)
+            if err := h.GetByOwner(c); err != nil {
+                t.Fatal(err)
+            }
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if tt.wantCode != http.StatusOK {
+                return
+            }
+            var body struct {
+                Items []models.Plate `json:"items"`
+                Total float64        `json:"total"`
+            }
+            if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+                t.Fatal(err)
+            }
+            ids := []string{}
+            for _, p := range body.Items {
+                ids = append(ids, p.PlateID)
+            }
+            if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") || body.Total != tt.wantTotal {
+                t.Fatalf("got %v (total %v), want %v (total %v)", ids, body.Total, tt.wantIDs, tt.wantTotal)
+            }
+        })
+    }
+}
+
+func TestDeactivatePlate(t *testing.T) {
+    t.Setenv("SKIP_EMAIL_SENDING", "true")
+    issued := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+    tests := []struct {
+        name          string
+        plateID       string
+        body          string
+        deactivateErr error
+        wantCode      int
+    }{
+        {"deactivate", "p1", `{"reason":"stolen","effective_date":"2025-03-01"}`, nil, http.StatusOK},
+        {"already deactivated", "p2", `{"reason":"stolen","effective_date":"2025-03-01"}`, nil, http.StatusConflict},
+        {"deactivated concurrently", "p1", `{"reason":"stolen","effective_date":"2025-03-01"}`, repository.ErrPlateDeactivated, http.StatusConflict},
+        {"missing reason", "p1", `{"reason":"  ","effective_date":"2025-03-01"}`, nil, http.StatusBadRequest},
+        {"bad date", "p1", `{"reason":"stolen","effective_date":"03/01/2025"}`, nil, http.StatusBadRequest},
+        {"before issue", "p1", `{"reason":"stolen","effective_date":"2023-12-31"}`, nil, http.StatusUnprocessableEntity},
+        {"unknown plate", "p9", `{"reason":"stolen","effective_date":"2025-03-01"}`, nil, http.StatusNotFound},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            repo := &fakePlateRepo{
+                plates: map[string]*models.Plate{
+                    "p1": {PlateID: "p1", VEHICLE_ID: "v1", PLATE_NUMBER: "ABC 1234", STATUS: models.PlateActive,
+                        PLATE_ISSUE_DATE: issued, PLATE_EXPIRATION_DATE: issued.AddDate(3, 0, 0)},
+                    "p2": {PlateID: "p2", VEHICLE_ID: "v1", PLATE_NUMBER: "ABC 1235", STATUS: models.PlateDeactivated,
+                        PLATE_ISSUE_DATE: issued, PLATE_EXPIRATION_DATE: issued.AddDate(1, 0, 0)},
+                },
+                deactivateErr: tt.deactivateErr,
+            }
+            h := deactivationHandler(repo)
+
+            rec := deactivate(t, h, tt.plateID, tt.body)
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if tt.wantCode != http.StatusOK {
+                if len(repo.history) != 0 {
+                    t.Fatalf("history written on a refused deactivation: %+v", repo.history)
+                }
+                return
+            }
+            var got models.Plate
+            if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+                t.Fatal(err)
+            }
+            if got.STATUS != models.PlateDeactivated || !got.PLATE_EXPIRATION_DATE.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+                t.Fatalf("got %+v", got)
+            }
+            if len(repo.history) != 1 || repo.history[0].changedBy != "LTO-OFFICER-7" || repo.history[0].fields["reason"] != "stolen" {
+                t.Fatalf("history = %+v", repo.history)
+            }
+        })
+    }
+}
+
+func TestDeactivatePlateTwice(t *testing.T) {
+    t.Setenv("SKIP_EMAIL_SENDING", "true")
+    issued := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+    repo := &fakePlateRepo{plates: map[string]*models.Plate{
+        "p1": {PlateID: "p1", VEHICLE_ID: "v1", PLATE_NUMBER: "ABC 1234", STATUS: models.PlateActive,
+            PLATE_ISSUE_DATE: issued, PLATE_EXPIRATION_DATE: issued.AddDate(3, 0, 0)},
+    }}
+    h := deactivationHandler(repo)
+
+    body := `{"reason":"stolen","effective_date":"2025-03-01"}`
+    if rec := deactivate(t, h, "p1", body); rec.Code != http.StatusOK {
+        t.Fatalf("first deactivation: status = %d, want 200: %s", rec.Code, rec.Body)
+    }
+    rec := deactivate(t, h, "p1", `{"reason":"sold","effective_date":"2025-04-01"}`)
+    if rec.Code != http.StatusConflict {
+        t.Fatalf("second deactivation: status = %d, want 409: %s", rec.Code, rec.Body)
+    }
+    if !strings.Contains(rec.Body.String(), "already deactivated") {
+        t.Fatalf("body = %s", rec.Body)
+    }
+    if len(repo.history) != 1 {
+        t.Fatalf("history has %d entries, want 1", len(repo.history))
+    }
+    if got := repo.plates["p1"].PLATE_EXPIRATION_DATE; !got.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+        t.Fatalf("second request moved the effective date to %v", got)
+    }
+}
+
+// deactivationHandler serves plates from repo on vehicle v1, owned by LTO-1
+func deactivationHandler(repo *fakePlateRepo) *PlateHandler {
+    vehicles := &fakeVehicleRepo{vehicles: map[string]*models.Vehicle{"v1": {VEHICLE_ID: "v1", LTO_CLIENT_ID: "LTO-1"}}}
+    users := testutil.NewMockUserRepository(models.User{LTO_CLIENT_ID: "LTO-1", FIRST_NAME: "Juan", LAST_NAME: "Dela Cruz", EMAIL: "juan@example.com"})
+    return NewPlateHandler(repo, vehicles, users, nil, nil, nil)
+}
+
+// deactivate sends a deactivation of plateID on v1 as an officer
+func deactivate(t *testing.T, h *PlateHandler, plateID, body string) *httptest.ResponseRecorder {
+    t.Helper()
+    req := httptest.NewRequest(http.MethodPut, "/api/vehicles/v1/plates/"+plateID+"/deactivate", strings.NewReader(body))
+    req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+    rec := httptest.NewRecorder()
+    c := echo.New().NewContext(req, rec)
+    c.SetParamNames("vehicle_id", "plate_id")
+    c.SetParamValues("v1", plateID)
+    c.Set("role", models.RoleOfficer)
+    c.Set("lto_client_id", "LTO-OFFICER-7")
+    if err := h.DeactivatePlate(c); err != nil {
+        t.Fatal(err)
+    }
+    return rec
+}
@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"smartplate-api/internal/models"
 	"smartplate-api/internal/repository"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
 )
 
@@ -16,6 +18,7 @@ type RegistrationHandler struct {
     payRepo     repository.RegistrationPaymentRepository
     docRepo     repository.RegistrationDocumentRepository
     vehicleRepo repository.VehicleRepository
+    tx          repository.Transactor
 }
 
 func NewRegistrationHandler(
@@ -24,6 +27,7 @@ func NewRegistrationHandler(
     pr repository.RegistrationPaymentRepository,
     dr repository.RegistrationDocumentRepository,
     vr repository.VehicleRepository,            // ← add vehicle repo
+    tx repository.Transactor,
 ) *RegistrationHandler {
     return &RegistrationHandler{
         formRepo:    fr,
@@ -31,6 +35,19 @@ func NewRegistrationHandler(
         payRepo:     pr,
         docRepo:     dr,
         vehicleRepo: vr,                        // ← store it
+        tx:          tx,
+    }
+}
+
+// OwnForm guards the /:id routes: applicants reach only their own forms,
+// LTO officers only their region's, admins any. A form the caller may not
+// see is reported as missing.
+func (h *RegistrationHandler) OwnForm(next echo.HandlerFunc) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        if _, ok := visibleForm(c, h.formRepo, c.Param("id")); !ok {
+            return c.JSON(http.StatusNotFound, "registration form not found")
+        }
+        return next(c)
     }
 }
 
@@ -41,9 +58,15 @@ func (h *RegistrationHandler) CreateForm(c echo.Context) error {
     if err := c.Bind(&params); err != nil {
         return c.JSON(http.StatusBadRequest, err.Error())
     }
+    if code, msg := checkApplicant(c, h.vehicleRepo, &params); code != 0 {
+        return c.JSON(code, msg)
+    }
 
     // Now pass ONLY the DTO to the repo
     full, err := h.formRepo.Create(c.Request().Context(), &params)
+    if errors.Is(err, repository.ErrActiveRegistrationExists) {
+        return c.JSON(http.StatusConflict, err.Error())
+    }
     if err != nil {
         return c.JSON(http.StatusInternalServerError, err.Error())
     }
@@ -51,8 +74,18 @@ func (h *RegistrationHandler) CreateForm(c echo.Context) error {
     return c.JSON(http.StatusCreated, full)
 }
 
+// GetAllForms lists every form for officers and admins, and a user's own
+// forms for everyone else
 func (h *RegistrationHandler) GetAllForms(c echo.Context) error {
-    out, err := h.formRepo.GetAll(c.Request().Context())
+    var (
+        out []models.RegistrationForm
+        err error
+    )
+    if role, _ := c.Get("role").(string); role == models.RoleUser {
+        out, err = h.formRepo.GetByLTOClientID(c.Request().Context(), actorID(c))
+    } else {
+        out, err = h.formRepo.GetAll(c.Request().Context())
+    }
     if err != nil {
         return c.JSON(http.StatusInternalServerError, err.Error())
     }
@@ -88,10 +121,29 @@ func (h *RegistrationHandler) UpdateForm(c echo.Context) error {
         return c.JSON(http.StatusBadRequest, err.Error())
     }
 
-    // overlay fields
-    if patch.Status != nil {
-        existing.Status = *patch.Status
+    // users can't hand a form to someone else or move it to a vehicle they
+    // don't own
+    if patch.LTOClientID != nil && !mayActFor(c, *patch.LTOClientID) {
+        return c.JSON(http.StatusForbidden, "you can only file your own registrations")
+    }
+    if patch.VehicleID != nil && *patch.VehicleID != existing.VehicleID {
+        v, err := h.vehicleRepo.GetVehicleByID(c.Request().Context(), *patch.VehicleID)
+        if err != nil || v == nil || !mayActFor(c, v.LTO_CLIENT_ID) {
+            return c.JSON(http.StatusForbidden, "not your vehicle")
+        }
+    }
+
+    // status moves through the workflow; applicants may only take a rejected
+    // form back to draft and submit it again, reviews are up to officers
+    statusChanged := patch.Status != nil && *patch.Status != existing.Status
+    if statusChanged {
+        role, _ := c.Get("role").(string)
+        if to := models.FormState(*patch.Status); role == models.RoleUser && to != models.FormDraft && to != models.FormSubmitted {
+            return c.JSON(http.StatusForbidden, "only officers can review registration forms")
+        }
     }
+
+    // overlay fields
     if patch.RegistrationType != nil {
         existing.RegistrationType = *patch.RegistrationType
     }
@@ -102,8 +154,21 @@ func (h *RegistrationHandler) UpdateForm(c echo.Context) error {
         existing.VehicleID = *patch.VehicleID
     }
 
-    // 3) save full object
-    if err := h.formRepo.Update(c.Request().Context(), existing); err != nil {
+    // 3) move the status and save the fields together, so a rejected
+    // transition leaves the form untouched
+    ctx := c.Request().Context()
+    err = h.tx.WithTx(ctx, func(tx *sqlx.Tx) error {
+        if statusChanged {
+            if err := h.formRepo.UpdateStatusTx(ctx, tx, id, models.FormState(*patch.Status), actorID(c)); err != nil {
+                return err
+            }
+        }
+        return h.formRepo.UpdateTx(ctx, tx, existing)
+    })
+    if errors.Is(err, models.ErrInvalidTransition) || errors.Is(err, repository.ErrActiveRegistrationExists) {
+        return c.JSON(http.StatusConflict, err.Error())
+    }
+    if err != nil {
         return c.JSON(http.StatusInternalServerError, err.Error())
     }
     return c.NoContent(http.StatusNoContent)
@@ -214,6 +279,9 @@ func (h *RegistrationHandler) GetInspection(c echo.Context) error {
     if err != nil {
         return c.JSON(http.StatusNotFound, err.Error())
     }
+    if insp.RegistrationFormID != c.Param("id") {
+        return c.JSON(http.StatusNotFound, "inspection not found")
+    }
     return c.JSON(http.StatusOK, insp)
 }
 
@@ -224,6 +292,9 @@ func (h *RegistrationHandler) UpdateInspection(c echo.Context) error {
     if err := c.Bind(&i); err != nil {
         return c.JSON(http.StatusBadRequest, err.Error())
     }
+    if existing, err := h.inspRepo.GetByID(c.Request().Context(), inspID); err != nil || existing.RegistrationFormID != formID {
+        return c.JSON(http.StatusNotFound, "inspection not found")
+    }
     i.RegistrationFormID = formID
     i.InspectionID = inspID
     if err := h.inspRepo.Update(c.Request().Context(), &i); err != nil {
@@ -234,6 +305,9 @@ func (h *RegistrationHandler) UpdateInspection(c echo.Context) error {
 
 func (h *RegistrationHandler) DeleteInspection(c echo.Context) error {
     inspID := c.Param("inspId")
+    if existing, err := h.inspRepo.GetByID(c.Request().Context(), inspID); err != nil || existing.RegistrationFormID != c.Param("id") {
+        return c.JSON(http.StatusNotFound, "inspection not found")
+    }
     if err := h.inspRepo.Delete(c.Request().Context(), inspID); err != nil {
         return c.JSON(http.StatusInternalServerError, err.Error())
     }
@@ -283,6 +357,9 @@ func (h *RegistrationHandler) GetPayment(c echo.Context) error {
     if err != nil {
         return c.JSON(http.StatusNotFound, err.Error())
     }
+    if pay.RegistrationFormID != c.Param("id") {
+        return c.JSON(http.StatusNotFound, "payment not found")
+    }
     return c.JSON(http.StatusOK, pay)
 }
 
@@ -294,6 +371,9 @@ func (h *RegistrationHandler) UpdatePayment(c echo.Context) error {
     if err != nil {
         return c.JSON(http.StatusNotFound, err.Error())
     }
+    if existing.RegistrationFormID != c.Param("id") {
+        return c.JSON(http.StatusNotFound, "payment not found")
+    }
 
     // 2) bind only the updatable fields into a small struct
     var patch struct {
@@ -341,6 +421,9 @@ func (h *RegistrationHandler) UpdatePayment(c echo.Context) error {
 
 func (h *RegistrationHandler) DeletePayment(c echo.Context) error {
     payID := c.Param("payId")
+    if existing, err := h.payRepo.GetByID(c.Request().Context(), payID); err != nil || existing.RegistrationFormID != c.Param("id") {
+        return c.JSON(http.StatusNotFound, "payment not found")
+    }
     if err := h.payRepo.Delete(c.Request().Context(), payID); err != nil {
         return c.JSON(http.StatusInternalServerError, err.Error())
     }
@@ -377,6 +460,9 @@ func (h *RegistrationHandler) GetDocument(c echo.Context) error {
     if err != nil {
         return c.JSON(http.StatusNotFound, err.Error())
     }
+    if doc.RegistrationFormID != c.Param("id") {
+        return c.JSON(http.StatusNotFound, "document not found")
+    }
     return c.JSON(http.StatusOK, doc)
 }
 
@@ -387,6 +473,9 @@ func (h *RegistrationHandler) UpdateDocument(c echo.Context) error {
     if err := c.Bind(&d); err != nil {
         return c.JSON(http.StatusBadRequest, err.Error())
     }
+    if existing, err := h.docRepo.GetByID(c.Request().Context(), docID); err != nil || existing.RegistrationFormID != formID {
+        return c.JSON(http.StatusNotFound, "document not found")
+    }
     d.RegistrationFormID = formID
     d.DocumentID = docID
     if err := h.docRepo.Update(c.Request().Context(), &d); err != nil {
@@ -397,6 +486,9 @@ func (h *RegistrationHandler) UpdateDocument(c echo.Context) error {
 
 func (h *RegistrationHandler) DeleteDocument(c echo.Context) error {
     docID := c.Param("docId")
+    if existing, err := h.docRepo.GetByID(c.Request().Context(), docID); err != nil || existing.RegistrationFormID != c.Param("id") {
+        return c.JSON(http.StatusNotFound, "document not found")
+    }
     if err := h.docRepo.Delete(c.Request().Context(), docID); err != nil {
         return c.JSON(http.StatusInternalServerError, err.Error())
     }
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+    "smartplate-api/internal/storage"
+)
+
+// documentTypes are the vehicle documents that may be uploaded
+var documentTypes = map[string]bool{
+    "official_receipt":            true,
+    "certificate_of_registration": true,
+    "proof_of_ownership":          true,
+    "insurance":                   true,
+    "emission_test":               true,
+    "deed_of_sale":                true,
+}
+
+// documentExtensions are the file types accepted for vehicle documents
+var documentExtensions = map[string]bool{".pdf": true, ".jpg": true, ".jpeg": true, ".png": true}
+
+// maxDocumentSize caps an uploaded document at 10 MB
+const maxDocumentSize = 10 << 20
+
+// VehicleDocumentHandler hands out presigned S3 URLs for vehicle documents.
+type VehicleDocumentHandler struct {
+    repo        repository.VehicleDocumentRepository
+    vehicleRepo repository.VehicleRepository
+}
+
+// NewVehicleDocumentHandler creates a new VehicleDocumentHandler.
+func NewVehicleDocumentHandler(
+    repo repository.VehicleDocumentRepository,
+    vr repository.VehicleRepository,
+) *VehicleDocumentHandler {
+    return &VehicleDocumentHandler{repo: repo, vehicleRepo: vr}
+}
+
+// UploadDocumentRequest names the document about to be uploaded
+type UploadDocumentRequest struct {
+    DocType  string `json:"doc_type"`
+    FileName string `json:"file_name"` // only the extension is used
+    Size     int64  `json:"size"`      // bytes
+}
+
+// UploadDocumentResponse is where to PUT the file and the headers to send
+// with it. The document is recorded once the upload is confirmed.
+type UploadDocumentResponse struct {
+    storage.Upload
+    DocType   string    `json:"doc_type"`
+    ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ConfirmDocumentRequest names an uploaded file by the key Upload returned
+type ConfirmDocumentRequest struct {
+    DocType string `json:"doc_type"`
+    S3Key   string `json:"s3_key"`
+}
+
+// VehicleDocumentResponse is a document with a short-lived download link
+type VehicleDocumentResponse struct {
+    models.VehicleDocument
+    DownloadURL string `json:"download_url"`
+}
+
+// Upload returns a presigned S3 URL the client PUTs the file to within 15
+// minutes, then confirms with POST /api/vehicles/{id}/documents/confirm
+// @Summary Upload a vehicle document
+// @Tags vehicles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vehicle id"
+// @Param body body UploadDocumentRequest true "Document type, file name and size"
+// @Success 200 {object} UploadDocumentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/vehicles/{id}/documents [post]
+func (h *VehicleDocumentHandler) Upload(c echo.Context) error {
+    var req UploadDocumentRequest
+    if err := c.Bind(&req); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    if !documentTypes[req.DocType] {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "unknown doc_type"})
+    }
+    ext := strings.ToLower(filepath.Ext(req.FileName))
+    if !documentExtensions[ext] {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "file must be a PDF, JPEG or PNG"})
+    }
+    if req.Size <= 0 || req.Size > maxDocumentSize {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "size must be between 1 byte and 10 MB"})
+    }
+    vehicleID := c.Param("id")
+    if status, err := h.checkAccess(c, vehicleID); err != nil {
+        return c.JSON(status, map[string]string{"error": err.Error()})
+    }
+
+    up, err := storage.GenerateUploadURL(c.Request().Context(), vehicleID, req.DocType, ext, req.Size)
+    if errors.Is(err, storage.ErrNotConfigured) {
+        return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+    }
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, UploadDocumentResponse{
+        Upload:    up,
+        DocType:   req.DocType,
+        ExpiresAt: time.Now().Add(storage.UploadURLExpiry),
+    })
+}
+
+// Confirm records a document once its file is in S3
+// @Summary Confirm a vehicle document upload
+// @Tags vehicles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vehicle id"
+// @Param body body ConfirmDocumentRequest true "Document type and the key Upload returned"
+// @Success 201 {object} models.VehicleDocument
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/vehicles/{id}/documents/confirm [post]
+func (h *VehicleDocumentHandler) Confirm(c echo.Context) error {
+    var req ConfirmDocumentRequest
+    if err := c.Bind(&req); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    vehicleID := c.Param("id")
+    if !documentTypes[req.DocType] || !strings.HasPrefix(req.S3Key, "vehicles/"+vehicleID+"/"+req.DocType+"/") {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "s3_key doesn't belong to this vehicle and doc_type"})
+    }
+    if status, err := h.checkAccess(c, vehicleID); err != nil {
+        return c.JSON(status, map[string]string{"error": err.Error()})
+    }
+
+    ok, err := storage.Exists(c.Request().Context(), req.S3Key)
+    if errors.Is(err, storage.ErrNotConfigured) {
+        return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+    }
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    if !ok {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "file has not been uploaded"})
+    }
+
+    doc := models.VehicleDocument{VehicleID: vehicleID, DocType: req.DocType, S3Key: req.S3Key, UploadedBy: actorID(c)}
+    err = h.repo.Create(c.Request().Context(), &doc)
+    if errors.Is(err, repository.ErrDocumentExists) {
+        return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+    }
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusCreated, doc)
+}
+
+// List returns the vehicle's documents with download URLs valid for an hour
+// @Summary List vehicle documents
+// @Tags vehicles
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Vehicle id"
+// @Success 200 {array} VehicleDocumentResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /api/vehicles/{id}/documents [get]
+func (h *VehicleDocumentHandler) List(c echo.Context) error {
+    vehicleID := c.Param("id")
+    if status, err := h.checkAccess(c, vehicleID); err != nil {
+        return c.JSON(status, map[string]string{"error": err.Error()})
+    }
+    docs, err := h.repo.ListByVehicle(c.Request().Context(), vehicleID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    resp := make([]VehicleDocumentResponse, 0, len(docs))
+    for _, d := range docs {
+        url, err := storage.GenerateDownloadURL(c.Request().Context(), d.S3Key, storage.DownloadURLExpiry)
+        if errors.Is(err, storage.ErrNotConfigured) {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+        }
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        resp = append(resp, VehicleDocumentResponse{VehicleDocument: d, DownloadURL: url})
+    }
+    return c.JSON(http.StatusOK, resp)
+}
+
+// checkAccess lets owners at their own vehicle's documents and officers and
+// admins at anyone's
+func (h *VehicleDocumentHandler) checkAccess(c echo.Context, vehicleID string) (int, error) {
+    v, err := h.vehicleRepo.GetVehicleByID(c.Request().Context(), vehicleID)
+    if err != nil || v == nil {
+        return http.StatusNotFound, errors.New("vehicle not found")
+    }
+    if role, _ := c.Get("role").(string); role == models.RoleUser && v.LTO_CLIENT_ID != actorID(c) {
+        return http.StatusForbidden, errors.New("not your vehicle")
+    }
+    return 0, nil
+}
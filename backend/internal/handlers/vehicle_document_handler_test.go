@@ -0,0 +1,159 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+)
+
+// fakeDocumentRepo records created documents
+type fakeDocumentRepo struct {
+    repository.VehicleDocumentRepository
+    created   []models.VehicleDocument
+    createErr error
+}
+
+func (f *fakeDocumentRepo) Create(ctx context.Context, d *models.VehicleDocument) error {
+    if f.createErr != nil {
+        return f.createErr
+    }
+    d.DocumentID = "d1"
+    f.created = append(f.created, *d)
+    return nil
+}
+
+// fakeS3 answers HEAD for the keys in uploaded and configures storage to use it
+func fakeS3(t *testing.T, uploaded ...string) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        for _, key := range uploaded {
+            if r.URL.Path == "/smartplate-docs/"+key {
+                return
+            }
+        }
+        w.WriteHeader(http.StatusNotFound)
+    }))
+    t.Cleanup(srv.Close)
+    t.Setenv("S3_BUCKET", "smartplate-docs")
+    t.Setenv("AWS_REGION", "ap-southeast-1")
+    t.Setenv("AWS_ACCESS_KEY_ID", "AKIDTEST")
+    t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+    t.Setenv("S3_ENDPOINT", srv.URL)
+}
+
+func callDocuments(t *testing.T, h echo.HandlerFunc, caller, body string) *httptest.ResponseRecorder {
+    t.Helper()
+    req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+    req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+    rec := httptest.NewRecorder()
+    c := echo.New().NewContext(req, rec)
+    c.SetParamNames("id")
+    c.SetParamValues("v1")
+    c.Set("role", models.RoleUser)
+    c.Set("lto_client_id", caller)
+    if err := h(c); err != nil {
+        t.Fatal(err)
+    }
+    return rec
+}
+
+func TestUploadDocument(t *testing.T) {
+    tests := []struct {
+        name       string
+        caller     string
+        body       string
+        configured bool
+        wantCode   int
+    }{
+        {"owner", "owner-1", `{"doc_type":"insurance","file_name":"policy.PDF","size":2048}`, true, http.StatusOK},
+        {"someone else", "owner-2", `{"doc_type":"insurance","file_name":"policy.pdf","size":2048}`, true, http.StatusForbidden},
+        {"no size", "owner-1", `{"doc_type":"insurance","file_name":"policy.pdf"}`, true, http.StatusBadRequest},
+        {"too large", "owner-1", `{"doc_type":"insurance","file_name":"policy.pdf","size":10485761}`, true, http.StatusBadRequest},
+        {"unknown type", "owner-1", `{"doc_type":"selfie","file_name":"me.png","size":10}`, true, http.StatusBadRequest},
+        {"bad extension", "owner-1", `{"doc_type":"insurance","file_name":"policy.exe","size":10}`, true, http.StatusBadRequest},
+        {"storage not configured", "owner-1", `{"doc_type":"insurance","file_name":"policy.pdf","size":2048}`, false, http.StatusServiceUnavailable},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            fakeS3(t)
+            if !tt.configured {
+                t.Setenv("S3_BUCKET", "")
+            }
+            docs := &fakeDocumentRepo{}
+            vehicles := &fakeVehicleRepo{vehicles: map[string]*models.Vehicle{"v1": {VEHICLE_ID: "v1", LTO_CLIENT_ID: "owner-1"}}}
+            h := NewVehicleDocumentHandler(docs, vehicles)
+
+            rec := callDocuments(t, h.Upload, tt.caller, tt.body)
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if len(docs.created) != 0 {
+                t.Fatalf("document recorded before the upload: %+v", docs.created)
+            }
+            if tt.wantCode != http.StatusOK {
+                return
+            }
+            var resp UploadDocumentResponse
+            if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+                t.Fatal(err)
+            }
+            if !strings.HasPrefix(resp.Key, "vehicles/v1/insurance/") || !strings.HasSuffix(resp.Key, ".pdf") {
+                t.Fatalf("s3_key = %q", resp.Key)
+            }
+            if resp.Headers["Content-Type"] != "application/pdf" || resp.Headers["Content-Length"] != "2048" {
+                t.Fatalf("headers = %v", resp.Headers)
+            }
+            if !strings.Contains(resp.URL, "X-Amz-Signature=") {
+                t.Fatalf("upload_url isn't presigned: %s", resp.URL)
+            }
+        })
+    }
+}
+
+func TestConfirmDocument(t *testing.T) {
+    const key = "vehicles/v1/insurance/abc.pdf"
+    tests := []struct {
+        name      string
+        caller    string
+        body      string
+        createErr error
+        wantCode  int
+    }{
+        {"uploaded", "owner-1", `{"doc_type":"insurance","s3_key":"` + key + `"}`, nil, http.StatusCreated},
+        {"not uploaded", "owner-1", `{"doc_type":"insurance","s3_key":"vehicles/v1/insurance/missing.pdf"}`, nil, http.StatusBadRequest},
+        {"another vehicle's key", "owner-1", `{"doc_type":"insurance","s3_key":"vehicles/v2/insurance/abc.pdf"}`, nil, http.StatusBadRequest},
+        {"key of another doc type", "owner-1", `{"doc_type":"deed_of_sale","s3_key":"` + key + `"}`, nil, http.StatusBadRequest},
+        {"someone else", "owner-2", `{"doc_type":"insurance","s3_key":"` + key + `"}`, nil, http.StatusForbidden},
+        {"already recorded", "owner-1", `{"doc_type":"insurance","s3_key":"` + key + `"}`, repository.ErrDocumentExists, http.StatusConflict},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            fakeS3(t, key)
+            docs := &fakeDocumentRepo{createErr: tt.createErr}
+            vehicles := &fakeVehicleRepo{vehicles: map[string]*models.Vehicle{"v1": {VEHICLE_ID: "v1", LTO_CLIENT_ID: "owner-1"}}}
+            h := NewVehicleDocumentHandler(docs, vehicles)
+
+            rec := callDocuments(t, h.Confirm, tt.caller, tt.body)
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if tt.wantCode != http.StatusCreated {
+                if len(docs.created) != 0 {
+                    t.Fatalf("document recorded after a %d", rec.Code)
+                }
+                return
+            }
+            want := models.VehicleDocument{DocumentID: "d1", VehicleID: "v1", DocType: "insurance", S3Key: key, UploadedBy: "owner-1"}
+            if len(docs.created) != 1 || docs.created[0] != want {
+                t.Fatalf("recorded %+v, want %+v", docs.created, want)
+            }
+        })
+    }
+}
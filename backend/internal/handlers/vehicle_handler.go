@@ -2,42 +2,149 @@
 package handlers
 
 import (
+    "errors"
+    "fmt"
+    "log"
     "net/http"
+    mw "smartplate-api/internal/middleware"
     "smartplate-api/internal/models"
+    "smartplate-api/internal/plate"
     "smartplate-api/internal/repository"
 
+    "github.com/google/uuid"
     "github.com/labstack/echo/v4"
 )
 
 type VehicleHandler struct {
-    repo repository.VehicleRepository
+    repo      repository.VehicleRepository
+    plateRepo repository.PlateRepository
 }
 
-func NewVehicleHandler(repo repository.VehicleRepository) *VehicleHandler {
-    return &VehicleHandler{repo}
+func NewVehicleHandler(repo repository.VehicleRepository, pr repository.PlateRepository) *VehicleHandler {
+    return &VehicleHandler{repo: repo, plateRepo: pr}
+}
+
+// CreateVehicleRequest is a vehicle plus any plates to issue with it
+type CreateVehicleRequest struct {
+    models.Vehicle
+    Plates []models.Plate `json:"plates,omitempty"`
+}
+
+// CreateVehicleResponse is the created vehicle and its plates
+type CreateVehicleResponse struct {
+    *models.Vehicle
+    Plates []*models.Plate `json:"plates,omitempty"`
 }
 
 func (h *VehicleHandler) CreateVehicle(c echo.Context) error {
-    var v models.Vehicle
-    if err := c.Bind(&v); err != nil {
+    ctx := c.Request().Context()
+    var req CreateVehicleRequest
+    if err := c.Bind(&req); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    v := req.Vehicle
+    if v.MV_FILE_NUMBER == "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "mv_file_number is required"})
+    }
+
+    // users register vehicles to themselves; plates are issued by officers
+    if role, _ := c.Get("role").(string); role == models.RoleUser {
+        if v.LTO_CLIENT_ID == "" {
+            v.LTO_CLIENT_ID = actorID(c)
+        }
+        if len(req.Plates) > 0 {
+            return c.JSON(http.StatusForbidden, map[string]string{"error": "only officers can issue plates"})
+        }
+    }
+    if !mayActFor(c, v.LTO_CLIENT_ID) {
+        return c.JSON(http.StatusForbidden, map[string]string{"error": "not your vehicle"})
+    }
+
+    existing, err := h.repo.GetByMVFileNumber(ctx, v.MV_FILE_NUMBER)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    if existing != nil {
+        return c.JSON(http.StatusConflict, map[string]string{"error": "mv_file_number already registered"})
+    }
+
+    // check nested plates up front so we don't leave a half-created vehicle behind
+    for i, p := range req.Plates {
+        if err := plate.ValidatePlateNumber(v.VEHICLE_TYPE, p.PLATE_TYPE, p.PLATE_NUMBER); err != nil {
+            return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+                "error": fmt.Sprintf("plates[%d]: %s", i, err.Error()),
+            })
+        }
+    }
+
+    v.VEHICLE_ID = uuid.NewString()
+    created, err := h.repo.CreateVehicle(ctx, &v)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+
+    resp := CreateVehicleResponse{Vehicle: created}
+    for i := range req.Plates {
+        p := req.Plates[i]
+        p.VEHICLE_ID = created.VEHICLE_ID
+        cp, err := h.plateRepo.CreatePlate(ctx, &p)
+        if err != nil {
+            // undo the vehicle so the client can retry the whole request
+            if derr := h.repo.DeleteVehicle(ctx, created.VEHICLE_ID, actorID(c)); derr != nil {
+                log.Printf("[req-id:%s] CreateVehicle cleanup error: %v", mw.GetRequestID(c), derr)
+            }
+            return c.JSON(http.StatusInternalServerError, map[string]string{
+                "error": fmt.Sprintf("plates[%d]: %s", i, err.Error()),
+            })
+        }
+        resp.Plates = append(resp.Plates, cp)
+    }
+    return c.JSON(http.StatusCreated, resp)
+}
+
+// GET /api/vehicles?page=&limit=
+func (h *VehicleHandler) ListVehicles(c echo.Context) error {
+    page, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
         return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
     }
-    created, err := h.repo.CreateVehicle(c.Request().Context(), &v)
+    list, total, err := h.repo.ListVehicles(c.Request().Context(), limit, offset)
     if err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
     }
-    return c.JSON(http.StatusCreated, created)
+    return c.JSON(http.StatusOK, PaginatedResponse(list, total, page, limit))
 }
 
-func (h *VehicleHandler) GetAllVehicles(c echo.Context) error {
-    list, err := h.repo.GetAllVehicles(c.Request().Context())
+// GET /api/vehicles/search?make=&model=&year=&owner_lto_id=&page=&limit=
+func (h *VehicleHandler) SearchVehicles(c echo.Context) error {
+    page, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    list, total, err := h.repo.Search(c.Request().Context(), repository.VehicleSearchFilter{
+        Make:       c.QueryParam("make"),
+        Model:      c.QueryParam("model"),
+        Year:       c.QueryParam("year"),
+        OwnerLTOID: c.QueryParam("owner_lto_id"),
+        Limit:      limit,
+        Offset:     offset,
+    })
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, PaginatedResponse(list, total, page, limit))
+}
+
+// GET /api/vehicles/owner/:lto_client_id
+func (h *VehicleHandler) GetVehiclesByOwner(c echo.Context) error {
+    list, err := h.repo.GetVehiclesByOwner(c.Request().Context(), c.Param("lto_client_id"))
     if err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
     }
     return c.JSON(http.StatusOK, list)
 }
 
-func (h *VehicleHandler) GetVehicle(c echo.Context) error {
+func (h *VehicleHandler) GetVehicleByID(c echo.Context) error {
     id := c.Param("id")
     v, err := h.repo.GetVehicleByID(c.Request().Context(), id)
     if err != nil {
@@ -46,12 +153,41 @@ func (h *VehicleHandler) GetVehicle(c echo.Context) error {
     return c.JSON(http.StatusOK, v)
 }
 
+// checkOwner lets users change only their own vehicles; officers and admins
+// may change anyone's
+func (h *VehicleHandler) checkOwner(c echo.Context, vehicleID string) (int, string) {
+    v, err := h.repo.GetVehicleByID(c.Request().Context(), vehicleID)
+    if err != nil || v == nil {
+        return http.StatusNotFound, "vehicle not found"
+    }
+    if !mayActFor(c, v.LTO_CLIENT_ID) {
+        return http.StatusForbidden, "not your vehicle"
+    }
+    return 0, ""
+}
+
+// checkFields stops users handing their vehicles to someone else; ownership
+// changes go through officers
+func checkFields(c echo.Context, fields map[string]interface{}) string {
+    role, _ := c.Get("role").(string)
+    if _, ok := fields["lto_client_id"]; ok && role == models.RoleUser {
+        return "lto_client_id can only be changed by an officer"
+    }
+    return ""
+}
+
 func (h *VehicleHandler) UpdateVehicle(c echo.Context) error {
     id := c.Param("id")
+    if status, msg := h.checkOwner(c, id); msg != "" {
+        return c.JSON(status, map[string]string{"error": msg})
+    }
     var fields map[string]interface{}
     if err := c.Bind(&fields); err != nil {
         return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
     }
+    if msg := checkFields(c, fields); msg != "" {
+        return c.JSON(http.StatusForbidden, map[string]string{"error": msg})
+    }
     if err := h.repo.UpdateVehicle(c.Request().Context(), id, fields); err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
     }
@@ -64,12 +200,40 @@ func (h *VehicleHandler) UpdateVehicle(c echo.Context) error {
 
 func (h *VehicleHandler) DeleteVehicle(c echo.Context) error {
     id := c.Param("id")
-    if err := h.repo.DeleteVehicle(c.Request().Context(), id); err != nil {
+    if status, msg := h.checkOwner(c, id); msg != "" {
+        return c.JSON(status, map[string]string{"error": msg})
+    }
+    // the repository deactivates the plates in the same transaction
+    if err := h.repo.DeleteVehicle(c.Request().Context(), id, actorID(c)); err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
     }
     return c.NoContent(http.StatusNoContent)
 }
 
+// PUT /api/admin/vehicles/:id/restore
+func (h *VehicleHandler) RestoreVehicle(c echo.Context) error {
+    id := c.Param("id")
+    if err := h.repo.Restore(c.Request().Context(), id); errors.Is(err, repository.ErrMVFileNumberTaken) {
+        return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+    } else if err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+    }
+    v, err := h.repo.GetVehicleByID(c.Request().Context(), id)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, v)
+}
+
+// GET /api/admin/vehicles/deleted
+func (h *VehicleHandler) GetDeletedVehicles(c echo.Context) error {
+    list, err := h.repo.GetDeleted(c.Request().Context())
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, list)
+}
+
 func (h *VehicleHandler) GetByClientID(c echo.Context) error {
     client := c.Param("lto_client_id")
     v, err := h.repo.GetVehicleByClientID(c.Request().Context(), client)
@@ -81,10 +245,16 @@ func (h *VehicleHandler) GetByClientID(c echo.Context) error {
 
 func (h *VehicleHandler) UpdateByClientID(c echo.Context) error {
     client := c.Param("lto_client_id")
+    if !mayActFor(c, client) {
+        return c.JSON(http.StatusForbidden, map[string]string{"error": "not your vehicle"})
+    }
     var fields map[string]interface{}
     if err := c.Bind(&fields); err != nil {
         return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
     }
+    if msg := checkFields(c, fields); msg != "" {
+        return c.JSON(http.StatusForbidden, map[string]string{"error": msg})
+    }
     if err := h.repo.UpdateVehicleByClientID(c.Request().Context(), client, fields); err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
     }
@@ -94,7 +264,10 @@ func (h *VehicleHandler) UpdateByClientID(c echo.Context) error {
 
 func (h *VehicleHandler) DeleteByClientID(c echo.Context) error {
     client := c.Param("lto_client_id")
-    if err := h.repo.DeleteVehicleByClientID(c.Request().Context(), client); err != nil {
+    if !mayActFor(c, client) {
+        return c.JSON(http.StatusForbidden, map[string]string{"error": "not your vehicle"})
+    }
+    if err := h.repo.DeleteVehicleByClientID(c.Request().Context(), client, actorID(c)); err != nil {
         return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
     }
     return c.NoContent(http.StatusNoContent)
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+)
+
+// fakeVehicleRepo keeps vehicles in memory; DeleteVehicle soft-deletes like
+// the real repository, so a deleted vehicle is no longer found, and records
+// who deleted it
+type fakeVehicleRepo struct {
+    repository.VehicleRepository
+    vehicles map[string]*models.Vehicle
+    deleted  map[string]string
+}
+
+func (f *fakeVehicleRepo) GetVehicleByID(ctx context.Context, id string) (*models.Vehicle, error) {
+    v, ok := f.vehicles[id]
+    if _, gone := f.deleted[id]; !ok || gone {
+        return nil, errors.New("not found")
+    }
+    return v, nil
+}
+
+func (f *fakeVehicleRepo) GetByMVFileNumber(ctx context.Context, mvFileNumber string) (*models.Vehicle, error) {
+    for _, v := range f.vehicles {
+        if v.MV_FILE_NUMBER == mvFileNumber {
+            return v, nil
+        }
+    }
+    return nil, nil
+}
+
+func (f *fakeVehicleRepo) CreateVehicle(ctx context.Context, v *models.Vehicle) (*models.Vehicle, error) {
+    if f.vehicles == nil {
+        f.vehicles = map[string]*models.Vehicle{}
+    }
+    f.vehicles[v.VEHICLE_ID] = v
+    return v, nil
+}
+
+func (f *fakeVehicleRepo) DeleteVehicle(ctx context.Context, id, changedBy string) error {
+    if f.deleted == nil {
+        f.deleted = map[string]string{}
+    }
+    f.deleted[id] = changedBy
+    return nil
+}
+
+func TestDeleteVehicle(t *testing.T) {
+    tests := []struct {
+        name        string
+        role        string
+        caller      string
+        vehicleID   string
+        wantCode    int
+        wantDeleted bool
+    }{
+        {"owner", models.RoleUser, "owner-1", "v1", http.StatusNoContent, true},
+        {"officer", models.RoleOfficer, "officer-1", "v1", http.StatusNoContent, true},
+        {"someone else", models.RoleUser, "owner-2", "v1", http.StatusForbidden, false},
+        {"missing vehicle", models.RoleOfficer, "officer-1", "nope", http.StatusNotFound, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            vehicles := &fakeVehicleRepo{vehicles: map[string]*models.Vehicle{
+                "v1": {VEHICLE_ID: "v1", LTO_CLIENT_ID: "owner-1"},
+            }}
+            h := NewVehicleHandler(vehicles, &fakePlateRepo{})
+
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(httptest.NewRequest(http.MethodDelete, "/", nil), rec)
+            c.SetParamNames("id")
+            c.SetParamValues(tt.vehicleID)
+            c.Set("role", tt.role)
+            c.Set("lto_client_id", tt.caller)
+            if err := h.DeleteVehicle(c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            by, deleted := vehicles.deleted["v1"]
+            if deleted != tt.wantDeleted {
+                t.Fatalf("vehicle deleted = %v, want %v", deleted, tt.wantDeleted)
+            }
+            // the plates' history rows are written as changed by the caller
+            if deleted && by != tt.caller {
+                t.Fatalf("deleted by %q, want %q", by, tt.caller)
+            }
+        })
+    }
+}
+
+func TestCreateVehicleOwnership(t *testing.T) {
+    tests := []struct {
+        name      string
+        role      string
+        caller    string
+        body      string
+        wantCode  int
+        wantOwner string
+    }{
+        {"user registers own vehicle", models.RoleUser, "owner-1", `{"mv_file_number":"MV-1"}`, http.StatusCreated, "owner-1"},
+        {"user names themselves", models.RoleUser, "owner-1", `{"mv_file_number":"MV-1","lto_client_id":"owner-1"}`, http.StatusCreated, "owner-1"},
+        {"user registers for someone else", models.RoleUser, "owner-2", `{"mv_file_number":"MV-1","lto_client_id":"owner-1"}`, http.StatusForbidden, ""},
+        {"user issues plates", models.RoleUser, "owner-1", `{"mv_file_number":"MV-1","plates":[{"plate_number":"ABC 1234"}]}`, http.StatusForbidden, ""},
+        {"officer registers for owner", models.RoleOfficer, "officer-1", `{"mv_file_number":"MV-1","lto_client_id":"owner-1"}`, http.StatusCreated, "owner-1"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            vehicles := &fakeVehicleRepo{}
+            h := NewVehicleHandler(vehicles, &fakePlateRepo{})
+
+            req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+            req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+            rec := httptest.NewRecorder()
+            c := echo.New().NewContext(req, rec)
+            c.Set("role", tt.role)
+            c.Set("lto_client_id", tt.caller)
+            if err := h.CreateVehicle(c); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if tt.wantOwner == "" {
+                if len(vehicles.vehicles) != 0 {
+                    t.Fatalf("vehicle created after a %d", rec.Code)
+                }
+                return
+            }
+            for _, v := range vehicles.vehicles {
+                if v.LTO_CLIENT_ID != tt.wantOwner {
+                    t.Fatalf("vehicle owned by %q, want %q", v.LTO_CLIENT_ID, tt.wantOwner)
+                }
+            }
+        })
+    }
+}
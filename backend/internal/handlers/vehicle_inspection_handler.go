@@ -0,0 +1,82 @@
+package handlers
+
+import (
+    "net/http"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+    "time"
+
+    "github.com/labstack/echo/v4"
+)
+
+// VehicleInspectionHandler handles HTTP requests for vehicle inspections.
+type VehicleInspectionHandler struct {
+    repo        repository.VehicleInspectionRepository
+    vehicleRepo repository.VehicleRepository
+}
+
+// NewVehicleInspectionHandler creates a new VehicleInspectionHandler.
+func NewVehicleInspectionHandler(
+    repo repository.VehicleInspectionRepository,
+    vr repository.VehicleRepository,
+) *VehicleInspectionHandler {
+    return &VehicleInspectionHandler{repo: repo, vehicleRepo: vr}
+}
+
+// Create records an inspection for the vehicle in the path.
+// POST /api/vehicles/:id/inspections
+func (h *VehicleInspectionHandler) Create(c echo.Context) error {
+    vehicleID := c.Param("id")
+    var insp models.VehicleInspection
+    if err := c.Bind(&insp); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    if insp.Result != "Pass" && insp.Result != "Fail" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "result must be Pass or Fail"})
+    }
+    if insp.NextInspectionDue.IsZero() {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "next_inspection_due is required"})
+    }
+    if _, err := h.vehicleRepo.GetVehicleByID(c.Request().Context(), vehicleID); err != nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "vehicle not found"})
+    }
+
+    insp.VehicleID = vehicleID
+    if insp.InspectedAt.IsZero() {
+        insp.InspectedAt = time.Now()
+    }
+    if err := h.repo.Create(c.Request().Context(), &insp); err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusCreated, insp)
+}
+
+// GetByVehicle lists a vehicle's inspections.
+// GET /api/vehicles/:id/inspections
+func (h *VehicleInspectionHandler) GetByVehicle(c echo.Context) error {
+    list, err := h.repo.GetByVehicleID(c.Request().Context(), c.Param("id"))
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, list)
+}
+
+// Overdue lists vehicles whose latest inspection says the next one is past
+// due, longest overdue first.
+// GET /api/admin/inspections/overdue?page=&limit=
+func (h *VehicleInspectionHandler) Overdue(c echo.Context) error {
+    page, limit, offset, err := ParsePaginationParams(c)
+    if err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    ctx := c.Request().Context()
+    total, err := h.repo.CountOverdue(ctx)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    list, err := h.repo.GetOverdue(ctx, limit, offset)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, PaginatedResponse(list, total, page, limit))
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "net/http"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+    "smartplate-api/internal/webhook"
+)
+
+// WebhookHandler manages the webhooks scan results are pushed to.
+type WebhookHandler struct {
+    repo repository.WebhookRepository
+}
+
+// NewWebhookHandler creates a new WebhookHandler.
+func NewWebhookHandler(repo repository.WebhookRepository) *WebhookHandler {
+    return &WebhookHandler{repo: repo}
+}
+
+// WebhookRequest is the body of Create and Update. On Update, fields left
+// out keep their current value.
+type WebhookRequest struct {
+    URL        *string  `json:"url" example:"https://enforcement.example.gov.ph/smartplate"`
+    Secret     *string  `json:"secret"` // generated on Create when left out
+    EventTypes []string `json:"event_types" example:"scan.completed,scan.expired"`
+    Active     *bool    `json:"active"`
+}
+
+// WebhookCreatedResponse is a new webhook with its signing secret, which is
+// only ever returned here
+type WebhookCreatedResponse struct {
+    models.WebhookConfig
+    Secret string `json:"secret"`
+}
+
+// apply copies the set fields of req onto w and reports what is wrong with
+// them. The url must be https and resolve to a public address.
+func (req WebhookRequest) apply(ctx context.Context, w *models.WebhookConfig) string {
+    if req.URL != nil {
+        if err := webhook.CheckURL(ctx, *req.URL); err != nil {
+            return err.Error()
+        }
+        w.URL = *req.URL
+    }
+    if req.Secret != nil {
+        if *req.Secret == "" {
+            return "secret must not be empty"
+        }
+        w.Secret = *req.Secret
+    }
+    if req.EventTypes != nil {
+        if len(req.EventTypes) == 0 {
+            return "event_types must name at least one event"
+        }
+        for _, e := range req.EventTypes {
+            if !models.WebhookEvents[e] {
+                return "unknown event type " + e
+            }
+        }
+        w.EventTypes = req.EventTypes
+    }
+    if req.Active != nil {
+        w.Active = *req.Active
+    }
+    return ""
+}
+
+// Create registers a webhook. Deliveries are signed with its secret; one is
+// generated when the body has none.
+// @Summary Create a webhook
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body WebhookRequest true "Webhook"
+// @Success 201 {object} WebhookCreatedResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/webhooks [post]
+func (h *WebhookHandler) Create(c echo.Context) error {
+    var req WebhookRequest
+    if err := c.Bind(&req); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+    }
+    if req.URL == nil || req.EventTypes == nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "url and event_types are required"})
+    }
+    w := models.WebhookConfig{Active: true}
+    if msg := req.apply(c.Request().Context(), &w); msg != "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+    }
+    if w.Secret == "" {
+        secret, err := generateSecureToken()
+        if err != nil {
+            return err
+        }
+        w.Secret = secret
+    }
+    if err := h.repo.Create(c.Request().Context(), &w); err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusCreated, WebhookCreatedResponse{WebhookConfig: w, Secret: w.Secret})
+}
+
+// List returns every webhook
+// @Summary List webhooks
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.WebhookConfig
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/webhooks [get]
+func (h *WebhookHandler) List(c echo.Context) error {
+    hooks, err := h.repo.List(c.Request().Context())
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, hooks)
+}
+
+// Get returns one webhook
+// @Summary Get a webhook
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook id"
+// @Success 200 {object} models.WebhookConfig
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/webhooks/{id} [get]
+func (h *WebhookHandler) Get(c echo.Context) error {
+    w, err := h.repo.GetByID(c.Request().Context(), c.Param("id"))
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    if w == nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "webhook not found"})
+    }
+    return c.JSON(http.StatusOK, w)
+}
+
+// Update changes a webhook's url, secret, event types or active flag
+// @Summary Update a webhook
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook id"
+// @Param body body WebhookRequest true "Fields to change"
+// @Success 200 {object} models.WebhookConfig
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/webhooks/{id} [put]
+func (h *WebhookHandler) Update(c echo.Context) error {
+    var req WebhookRequest
+    if err := c.Bind(&req); err != nil {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+    }
+    ctx := c.Request().Context()
+    w, err := h.repo.GetByID(ctx, c.Param("id"))
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    if w == nil {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "webhook not found"})
+    }
+    if msg := req.apply(ctx, w); msg != "" {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+    }
+    if err := h.repo.Update(ctx, w); errors.Is(err, sql.ErrNoRows) {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "webhook not found"})
+    } else if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.JSON(http.StatusOK, w)
+}
+
+// Delete removes a webhook and its delivery log
+// @Summary Delete a webhook
+// @Tags admin
+// @Security BearerAuth
+// @Param id path string true "Webhook id"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c echo.Context) error {
+    if err := h.repo.Delete(c.Request().Context(), c.Param("id")); errors.Is(err, sql.ErrNoRows) {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": "webhook not found"})
+    } else if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+)
+
+// fakeWebhookRepo records created webhooks
+type fakeWebhookRepo struct {
+    repository.WebhookRepository
+    created []models.WebhookConfig
+}
+
+func (f *fakeWebhookRepo) Create(ctx context.Context, w *models.WebhookConfig) error {
+    w.WebhookID = "w1"
+    f.created = append(f.created, *w)
+    return nil
+}
+
+func TestCreateWebhookURLGuard(t *testing.T) {
+    tests := []struct {
+        name     string
+        url      string
+        wantCode int
+    }{
+        {"public https", "https://93.184.216.34/smartplate", http.StatusCreated},
+        {"plain http", "http://93.184.216.34/smartplate", http.StatusBadRequest},
+        {"loopback", "https://127.0.0.1:8080/admin", http.StatusBadRequest},
+        {"cloud metadata", "https://169.254.169.254/latest/meta-data/", http.StatusBadRequest},
+        {"private network", "https://10.0.0.12/hook", http.StatusBadRequest},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            repo := &fakeWebhookRepo{}
+            body := `{"url":"` + tt.url + `","event_types":["` + models.WebhookEventScanCompleted + `"]}`
+            req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+            req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+            rec := httptest.NewRecorder()
+            if err := NewWebhookHandler(repo).Create(echo.New().NewContext(req, rec)); err != nil {
+                t.Fatal(err)
+            }
+
+            if rec.Code != tt.wantCode {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+            }
+            if created := len(repo.created) == 1; created != (tt.wantCode == http.StatusCreated) {
+                t.Fatalf("created %d webhooks", len(repo.created))
+            }
+        })
+    }
+}
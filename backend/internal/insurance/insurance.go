@@ -0,0 +1,114 @@
+// Package insurance looks up third-party liability cover for vehicles.
+package insurance
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "hash/fnv"
+    "net/http"
+    "net/url"
+    "os"
+    "strings"
+    "time"
+
+    "smartplate-api/internal/models"
+)
+
+// InsuranceLookup reports the insurance status of a vehicle by MV file number
+type InsuranceLookup interface {
+    GetStatus(ctx context.Context, mvFileNumber string) (models.InsuranceStatus, error)
+}
+
+// FromEnv returns an HTTPInsuranceLookup when INSURANCE_API_URL is set, a
+// StubInsuranceLookup when INSURANCE_API_URL is "stub", and nil otherwise
+func FromEnv() InsuranceLookup {
+    switch raw := os.Getenv("INSURANCE_API_URL"); raw {
+    case "":
+        return nil
+    case "stub":
+        return StubInsuranceLookup{}
+    default:
+        return NewHTTPInsuranceLookup(raw)
+    }
+}
+
+// stubProviders are the insurers StubInsuranceLookup pretends to know
+var stubProviders = []string{"Pioneer Insurance", "Malayan Insurance", "Standard Insurance"}
+
+// StubInsuranceLookup answers without any network call. The same MV file
+// number always gets the same status, so it is usable in tests and demos.
+type StubInsuranceLookup struct{}
+
+// GetStatus derives a status from a hash of mvFileNumber: roughly 70% of
+// vehicles are insured, 20% lapsed and 10% uninsured
+func (StubInsuranceLookup) GetStatus(_ context.Context, mvFileNumber string) (models.InsuranceStatus, error) {
+    h := fnv.New32a()
+    h.Write([]byte(strings.ToUpper(mvFileNumber)))
+    sum := h.Sum32()
+
+    bucket := sum % 10
+    if bucket == 9 {
+        return models.InsuranceStatus{Status: models.InsuranceNone}, nil
+    }
+    status := models.InsuranceActive
+    // pin the expiry to the day, so repeated lookups agree
+    today := time.Now().UTC().Truncate(24 * time.Hour)
+    expires := today.AddDate(0, 0, int(sum>>8%365)+1)
+    if bucket >= 7 {
+        status = models.InsuranceExpired
+        expires = today.AddDate(0, 0, -int(sum>>8%365)-1)
+    }
+    return models.InsuranceStatus{
+        Status:       status,
+        Provider:     stubProviders[int(sum>>16)%len(stubProviders)],
+        PolicyNumber: fmt.Sprintf("CTPL-%08d", sum%100000000),
+        ExpiresAt:    &expires,
+    }, nil
+}
+
+// HTTPInsuranceLookup asks an external service:
+// GET <URL>?mv_file_number=<n> answering with an InsuranceStatus as JSON
+type HTTPInsuranceLookup struct {
+    URL    string
+    Client *http.Client
+}
+
+// NewHTTPInsuranceLookup returns an HTTPInsuranceLookup for apiURL. Callers
+// bound each lookup with their context, so the client has no timeout.
+func NewHTTPInsuranceLookup(apiURL string) *HTTPInsuranceLookup {
+    return &HTTPInsuranceLookup{URL: apiURL, Client: &http.Client{}}
+}
+
+// GetStatus calls the insurance API; a 404 means no policy on file
+func (l *HTTPInsuranceLookup) GetStatus(ctx context.Context, mvFileNumber string) (models.InsuranceStatus, error) {
+    u, err := url.Parse(l.URL)
+    if err != nil {
+        return models.InsuranceStatus{}, fmt.Errorf("insurance API URL: %w", err)
+    }
+    q := u.Query()
+    q.Set("mv_file_number", mvFileNumber)
+    u.RawQuery = q.Encode()
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+    if err != nil {
+        return models.InsuranceStatus{}, err
+    }
+    req.Header.Set("Accept", "application/json")
+    resp, err := l.Client.Do(req)
+    if err != nil {
+        return models.InsuranceStatus{}, fmt.Errorf("insurance lookup: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode == http.StatusNotFound {
+        return models.InsuranceStatus{Status: models.InsuranceNone}, nil
+    }
+    if resp.StatusCode != http.StatusOK {
+        return models.InsuranceStatus{}, fmt.Errorf("insurance lookup: status %d", resp.StatusCode)
+    }
+    var s models.InsuranceStatus
+    if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+        return models.InsuranceStatus{}, fmt.Errorf("decode insurance status: %w", err)
+    }
+    return s, nil
+}
@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"smartplate-api/internal/email"
+	"smartplate-api/internal/repository"
+	"time"
+)
+
+const (
+	// InactivityThreshold is how long an account must be idle before it gets the reactivation email
+	InactivityThreshold = 180 * 24 * time.Hour
+	inactivityInterval  = 24 * time.Hour
+)
+
+// InactivityMailer emails users who have been inactive for longer than
+// InactivityThreshold, once per lapse
+type InactivityMailer struct {
+	users  repository.UserRepository
+	logger *slog.Logger
+}
+
+// NewInactivityMailer creates a new InactivityMailer.
+func NewInactivityMailer(users repository.UserRepository, logger *slog.Logger) *InactivityMailer {
+	return &InactivityMailer{users: users, logger: logger}
+}
+
+// Run checks at startup and then once a day until ctx is cancelled, so a
+// restart doesn't put the next check a day away
+func (m *InactivityMailer) Run(ctx context.Context) {
+	ticks, stop := tick(inactivityInterval)
+	defer stop()
+	m.process()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticks:
+			m.process()
+		}
+	}
+}
+
+func (m *InactivityMailer) process() {
+	users, err := m.users.GetInactiveUsers(time.Now().Add(-InactivityThreshold))
+	if err != nil {
+		m.logger.Error("inactive user lookup failed", "error", err)
+		return
+	}
+	for _, u := range users {
+		lastSeen := u.CREATED
+		if u.LAST_LOGIN_AT != nil {
+			lastSeen = *u.LAST_LOGIN_AT
+		}
+		// already emailed for this lapse
+		if u.INACTIVITY_EMAILED_AT != nil && u.INACTIVITY_EMAILED_AT.After(lastSeen) {
+			continue
+		}
+		if err := email.SendInactivityEmail(u.EMAIL, u.FIRST_NAME); err != nil {
+			m.logger.Warn("inactivity email failed", "lto_client_id", u.LTO_CLIENT_ID, "error", err)
+			continue
+		}
+		if err := m.users.MarkInactivityEmailed(u.LTO_CLIENT_ID); err != nil {
+			m.logger.Error("inactivity email bookkeeping failed", "lto_client_id", u.LTO_CLIENT_ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"smartplate-api/internal/models"
+	"smartplate-api/internal/repository"
+)
+
+// fakeInactiveUsers returns the same idle users on every check and records
+// who was marked as emailed
+type fakeInactiveUsers struct {
+	repository.UserRepository
+	users   []models.User
+	checked chan struct{} // receives after every lookup
+	emailed []string
+}
+
+func (f *fakeInactiveUsers) GetInactiveUsers(since time.Time) ([]models.User, error) {
+	defer func() { f.checked <- struct{}{} }()
+	return f.users, nil
+}
+
+func (f *fakeInactiveUsers) MarkInactivityEmailed(ltoClientID string) error {
+	f.emailed = append(f.emailed, ltoClientID)
+	return nil
+}
+
+func TestInactivityMailerRunsAtStartup(t *testing.T) {
+	t.Setenv("SKIP_EMAIL_SENDING", "true")
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	lastLogin := time.Now().Add(-200 * 24 * time.Hour)
+	emailedBefore := lastLogin.Add(-time.Hour)
+	emailedSince := lastLogin.Add(time.Hour)
+	users := &fakeInactiveUsers{
+		users: []models.User{
+			{LTO_CLIENT_ID: "LTO-1", EMAIL: "juan@example.com", LAST_LOGIN_AT: &lastLogin},
+			// emailed during an earlier lapse, before logging in again
+			{LTO_CLIENT_ID: "LTO-2", EMAIL: "maria@example.com", LAST_LOGIN_AT: &lastLogin, INACTIVITY_EMAILED_AT: &emailedBefore},
+			// already emailed for this lapse
+			{LTO_CLIENT_ID: "LTO-3", EMAIL: "jose@example.com", LAST_LOGIN_AT: &lastLogin, INACTIVITY_EMAILED_AT: &emailedSince},
+		},
+		checked: make(chan struct{}),
+	}
+	ticks := fakeClock(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewInactivityMailer(users, slog.Default()).Run(ctx)
+	}()
+
+	select {
+	case <-users.checked:
+	case <-time.After(time.Second):
+		t.Fatal("no check at startup")
+	}
+	ticks <- time.Now()
+	<-users.checked
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run didn't return after cancel")
+	}
+
+	// the fake never records the emails, so both checks send them
+	if want := "[LTO-1 LTO-2 LTO-1 LTO-2]"; fmt.Sprint(users.emailed) != want {
+		t.Fatalf("marked %v as emailed, want %s", users.emailed, want)
+	}
+	if !strings.Contains(logged.String(), "simulated email to juan@example.com") {
+		t.Fatalf("log %q, want an email to juan@example.com", logged.String())
+	}
+	if strings.Contains(logged.String(), "jose@example.com") {
+		t.Fatal("emailed a user twice for the same lapse")
+	}
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"smartplate-api/internal/repository"
+	"strconv"
+	"time"
+)
+
+// resetTokenRetention is how long expired or used reset tokens are kept
+const resetTokenRetention = 24 * time.Hour
+
+// tick delivers the time every interval until stop is called; tests replace
+// it with a fake clock
+var tick = func(interval time.Duration) (ticks <-chan time.Time, stop func()) {
+	t := time.NewTicker(interval)
+	return t.C, t.Stop
+}
+
+// StartCleanupJobs deletes stale rows every interval until ctx is cancelled.
+// Reset tokens are dropped a day after they expire or are used. Scan logs
+// are only pruned when SCAN_LOG_RETENTION_DAYS is set. Active plates past
+// their expiration date are marked Expired.
+func StartCleanupJobs(ctx context.Context, tokenRepo repository.PasswordResetTokenRepository, scanRepo repository.ScanLogRepository, plateRepo repository.PlateRepository, interval time.Duration) {
+	scanRetention := scanLogRetention()
+	ticks, stop := tick(interval)
+	defer stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticks:
+			runCleanup(ctx, tokenRepo, scanRepo, plateRepo, scanRetention, now)
+		}
+	}
+}
+
+func runCleanup(ctx context.Context, tokenRepo repository.PasswordResetTokenRepository, scanRepo repository.ScanLogRepository, plateRepo repository.PlateRepository, scanRetention time.Duration, now time.Time) {
+	logger := slog.Default()
+
+	n, err := tokenRepo.DeleteExpired(ctx, now.Add(-resetTokenRetention))
+	if err != nil {
+		logger.Error("reset token cleanup failed", "error", err)
+	} else {
+		logger.Info("reset token cleanup", "deleted", n)
+	}
+
+	n, err = plateRepo.SyncExpiredPlates(ctx)
+	if err != nil {
+		logger.Error("expired plate sync failed", "error", err)
+	} else {
+		logger.Info("expired plate sync", "updated", n)
+	}
+
+	if scanRetention <= 0 {
+		return
+	}
+	n, err = scanRepo.DeleteOlderThan(ctx, now.Add(-scanRetention))
+	if err != nil {
+		logger.Error("scan log cleanup failed", "error", err)
+	} else {
+		logger.Info("scan log cleanup", "deleted", n)
+	}
+}
+
+// scanLogRetention reads SCAN_LOG_RETENTION_DAYS; zero keeps scans forever
+func scanLogRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("SCAN_LOG_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
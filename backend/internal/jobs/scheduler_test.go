@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"smartplate-api/internal/repository"
+)
+
+// cleanupCalls records the cutoffs each repository's cleanup was called with
+type cleanupCalls struct {
+	mu       sync.Mutex
+	tokens   []time.Time
+	scans    []time.Time
+	syncs    int
+	finished chan struct{} // receives at the end of every run
+}
+
+type fakeTokenRepo struct {
+	repository.PasswordResetTokenRepository
+	calls *cleanupCalls
+}
+
+func (f fakeTokenRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	f.calls.mu.Lock()
+	defer f.calls.mu.Unlock()
+	f.calls.tokens = append(f.calls.tokens, before)
+	return 2, nil
+}
+
+type fakeScanRepo struct {
+	repository.ScanLogRepository
+	calls *cleanupCalls
+}
+
+func (f fakeScanRepo) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	f.calls.mu.Lock()
+	f.calls.scans = append(f.calls.scans, before)
+	f.calls.mu.Unlock()
+	f.calls.finished <- struct{}{}
+	return 5, nil
+}
+
+type fakePlateRepo struct {
+	repository.PlateRepository
+	calls *cleanupCalls
+	last  bool // signal finished from here when scans aren't pruned
+}
+
+func (f fakePlateRepo) SyncExpiredPlates(ctx context.Context) (int64, error) {
+	f.calls.mu.Lock()
+	f.calls.syncs++
+	f.calls.mu.Unlock()
+	if f.last {
+		f.calls.finished <- struct{}{}
+	}
+	return 1, nil
+}
+
+// fakeClock swaps tick for a channel the test drives
+func fakeClock(t *testing.T) chan<- time.Time {
+	t.Helper()
+	ticks := make(chan time.Time)
+	orig := tick
+	tick = func(time.Duration) (<-chan time.Time, func()) { return ticks, func() {} }
+	t.Cleanup(func() { tick = orig })
+	return ticks
+}
+
+func TestStartCleanupJobs(t *testing.T) {
+	tests := []struct {
+		name          string
+		retentionDays string
+		wantScans     bool
+	}{
+		{"tokens and plates only", "", false},
+		{"with scan retention", "30", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SCAN_LOG_RETENTION_DAYS", tt.retentionDays)
+			ticks := fakeClock(t)
+			calls := &cleanupCalls{finished: make(chan struct{})}
+			plates := fakePlateRepo{calls: calls, last: !tt.wantScans}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				StartCleanupJobs(ctx, fakeTokenRepo{calls: calls}, fakeScanRepo{calls: calls}, plates, time.Hour)
+			}()
+
+			start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+			for i := 0; i < 3; i++ {
+				ticks <- start.Add(time.Duration(i) * time.Hour)
+				<-calls.finished
+			}
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("StartCleanupJobs didn't return after cancel")
+			}
+
+			if len(calls.tokens) != 3 || calls.syncs != 3 {
+				t.Fatalf("got %d token cleanups and %d plate syncs, want 3 each", len(calls.tokens), calls.syncs)
+			}
+			for i, before := range calls.tokens {
+				want := start.Add(time.Duration(i)*time.Hour - resetTokenRetention)
+				if !before.Equal(want) {
+					t.Errorf("run %d deleted tokens before %v, want %v", i, before, want)
+				}
+			}
+			if tt.wantScans {
+				if len(calls.scans) != 3 {
+					t.Fatalf("got %d scan cleanups, want 3", len(calls.scans))
+				}
+				if want := start.AddDate(0, 0, -30); !calls.scans[0].Equal(want) {
+					t.Errorf("deleted scans before %v, want %v", calls.scans[0], want)
+				}
+			} else if len(calls.scans) != 0 {
+				t.Fatalf("scan logs pruned without a retention period")
+			}
+		})
+	}
+}
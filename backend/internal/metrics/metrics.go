@@ -0,0 +1,60 @@
+// Package metrics holds the Prometheus collectors exposed on /metrics.
+package metrics
+
+import (
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+    HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Name: "http_requests_total",
+        Help: "HTTP requests by method, route and status code.",
+    }, []string{"method", "path", "status"})
+
+    HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+        Name:    "http_request_duration_seconds",
+        Help:    "HTTP request latency by method and route.",
+        Buckets: prometheus.DefBuckets,
+    }, []string{"method", "path"})
+
+    WebsocketConnectionsActive = prometheus.NewGauge(prometheus.GaugeOpts{
+        Name: "websocket_connections_active",
+        Help: "Open scanner websocket connections.",
+    })
+
+    ScanLogCreatesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+        Name: "scan_log_creates_total",
+        Help: "Rows inserted into scan_log.",
+    })
+
+    DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+        Name:    "db_query_duration_seconds",
+        Help:    "Latency of instrumented database queries.",
+        Buckets: prometheus.DefBuckets,
+    }, []string{"query_name"})
+
+    // the miss rate is rate(plate_cache_lookups_total{result="miss"}) over the total
+    PlateCacheLookupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Name: "plate_cache_lookups_total",
+        Help: "Scanner plate lookups answered by the plate cache (hit) or the database (miss).",
+    }, []string{"result"})
+)
+
+func init() {
+    prometheus.MustRegister(
+        HTTPRequestsTotal,
+        HTTPRequestDuration,
+        WebsocketConnectionsActive,
+        ScanLogCreatesTotal,
+        DBQueryDuration,
+        PlateCacheLookupsTotal,
+    )
+}
+
+// ObserveQuery records how long a query took; use as
+// defer metrics.ObserveQuery("name", time.Now())
+func ObserveQuery(name string, start time.Time) {
+    DBQueryDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,73 @@
+// Package middleware holds the API's own Echo middleware.
+package middleware
+
+import (
+    "context"
+    "encoding/json"
+    "log"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+
+    "github.com/labstack/echo/v4"
+)
+
+// AuditLoader fetches the current state of the entity being changed.
+// It may return nil when the entity doesn't exist (yet or anymore).
+type AuditLoader func(ctx context.Context, id string) (interface{}, error)
+
+// Audit records an audit_log row for each successful request on the route.
+// The entity id is read from the idParam path parameter; when load is set,
+// the entity is loaded before and after the handler runs so the row carries
+// both the old and the new value.
+func Audit(repo repository.AuditLogRepository, entityType, idParam string, load AuditLoader) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            ctx := c.Request().Context()
+            id := c.Param(idParam)
+
+            var before json.RawMessage
+            if load != nil && id != "" {
+                before = snapshot(ctx, load, id)
+            }
+
+            if err := next(c); err != nil {
+                return err
+            }
+            if c.Response().Status >= 400 {
+                return nil
+            }
+
+            var after json.RawMessage
+            if load != nil && id != "" {
+                after = snapshot(ctx, load, id)
+            }
+
+            actor, _ := c.Get("lto_client_id").(string)
+            entry := &models.AuditLog{
+                ActorLTOID: actor,
+                Action:     c.Request().Method + " " + c.Path(),
+                EntityType: entityType,
+                EntityID:   id,
+                OldValue:   before,
+                NewValue:   after,
+            }
+            if err := repo.Create(ctx, entry); err != nil {
+                log.Printf("[req-id:%s] audit log error: %v", GetRequestID(c), err)
+            }
+            return nil
+        }
+    }
+}
+
+// snapshot loads and marshals an entity, returning nil if it can't be read
+func snapshot(ctx context.Context, load AuditLoader, id string) json.RawMessage {
+    v, err := load(ctx, id)
+    if err != nil || v == nil {
+        return nil
+    }
+    b, err := json.Marshal(v)
+    if err != nil {
+        return nil
+    }
+    return b
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/models"
+)
+
+const testSecret = "test-secret"
+
+// signedToken returns a bearer token for subject with role, valid for ttl
+// (already expired when ttl is negative)
+func signedToken(t *testing.T, subject, role string, ttl time.Duration, secret string) string {
+    t.Helper()
+    token, err := SignToken(&Claims{
+        Role: role,
+        RegisteredClaims: jwt.RegisteredClaims{
+            Subject:   subject,
+            ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+        },
+    }, []byte(secret))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return token
+}
+
+func TestRequireRole(t *testing.T) {
+    t.Setenv("JWT_SECRET", testSecret)
+
+    groups := map[string][]string{
+        "user":    nil,
+        "officer": {models.RoleOfficer, models.RoleAdmin, models.RoleSuperAdmin},
+        "admin":   {models.RoleAdmin, models.RoleSuperAdmin},
+    }
+    tests := []struct {
+        name  string
+        token func(t *testing.T) string
+        want  map[string]int // group -> status
+    }{
+        {
+            "user",
+            func(t *testing.T) string { return signedToken(t, "c1", models.RoleUser, time.Hour, testSecret) },
+            map[string]int{"user": http.StatusOK, "officer": http.StatusForbidden, "admin": http.StatusForbidden},
+        },
+        {
+            "officer",
+            func(t *testing.T) string { return signedToken(t, "c1", models.RoleOfficer, time.Hour, testSecret) },
+            map[string]int{"user": http.StatusOK, "officer": http.StatusOK, "admin": http.StatusForbidden},
+        },
+        {
+            "admin",
+            func(t *testing.T) string { return signedToken(t, "c1", models.RoleAdmin, time.Hour, testSecret) },
+            map[string]int{"user": http.StatusOK, "officer": http.StatusOK, "admin": http.StatusOK},
+        },
+        {
+            "superadmin",
+            func(t *testing.T) string { return signedToken(t, "c1", models.RoleSuperAdmin, time.Hour, testSecret) },
+            map[string]int{"user": http.StatusOK, "officer": http.StatusOK, "admin": http.StatusOK},
+        },
+        {
+            "unknown role",
+            func(t *testing.T) string { return signedToken(t, "c1", "hacker", time.Hour, testSecret) },
+            map[string]int{"user": http.StatusOK, "officer": http.StatusForbidden, "admin": http.StatusForbidden},
+        },
+        {
+            "no token",
+            func(t *testing.T) string { return "" },
+            map[string]int{"user": http.StatusUnauthorized, "officer": http.StatusUnauthorized, "admin": http.StatusUnauthorized},
+        },
+        {
+            "wrong secret",
+            func(t *testing.T) string { return signedToken(t, "c1", models.RoleAdmin, time.Hour, "other-secret") },
+            map[string]int{"user": http.StatusUnauthorized, "officer": http.StatusUnauthorized, "admin": http.StatusUnauthorized},
+        },
+        {
+            "expired",
+            func(t *testing.T) string { return signedToken(t, "c1", models.RoleAdmin, -time.Minute, testSecret) },
+            map[string]int{"user": http.StatusUnauthorized, "officer": http.StatusUnauthorized, "admin": http.StatusUnauthorized},
+        },
+        {
+            "no subject",
+            func(t *testing.T) string { return signedToken(t, "", models.RoleAdmin, time.Hour, testSecret) },
+            map[string]int{"user": http.StatusUnauthorized, "officer": http.StatusUnauthorized, "admin": http.StatusUnauthorized},
+        },
+    }
+
+    e := echo.New()
+    ok := func(c echo.Context) error {
+        if c.Get("lto_client_id") != "c1" {
+            t.Errorf("lto_client_id = %v, want c1", c.Get("lto_client_id"))
+        }
+        return c.NoContent(http.StatusOK)
+    }
+    for name, roles := range groups {
+        e.GET("/"+name, ok, RequireRole(roles...))
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            token := tt.token(t)
+            for group, want := range tt.want {
+                req := httptest.NewRequest(http.MethodGet, "/"+group, nil)
+                if token != "" {
+                    req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+                }
+                rec := httptest.NewRecorder()
+                e.ServeHTTP(rec, req)
+                if rec.Code != want {
+                    t.Errorf("%s group: status = %d, want %d", group, rec.Code, want)
+                }
+            }
+        })
+    }
+}
+
+func TestRequireRoleRejectsOtherAlgorithms(t *testing.T) {
+    t.Setenv("JWT_SECRET", testSecret)
+    token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, &Claims{
+        Role:             models.RoleAdmin,
+        RegisteredClaims: jwt.RegisteredClaims{Subject: "c1"},
+    }).SignedString([]byte(testSecret))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    e := echo.New()
+    e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, RequireRole())
+    req := httptest.NewRequest(http.MethodGet, "/", nil)
+    req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+    rec := httptest.NewRecorder()
+    e.ServeHTTP(rec, req)
+    if rec.Code != http.StatusUnauthorized {
+        t.Fatalf("status = %d, want 401", rec.Code)
+    }
+}
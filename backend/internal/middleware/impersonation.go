@@ -0,0 +1,51 @@
+package middleware
+
+import (
+    "encoding/json"
+    "log"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+
+    "github.com/labstack/echo/v4"
+)
+
+// ImpersonatedByKey is the context key RequireRole sets to the admin's LTO
+// client id when the token is an impersonation token
+const ImpersonatedByKey = "impersonated_by"
+
+// ImpersonationAudit records an audit_log row for every request made with an
+// impersonation token, whatever its outcome. The admin is the actor and the
+// impersonated user the entity. It must run after RequireRole.
+func ImpersonationAudit(repo repository.AuditLogRepository) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            admin, _ := c.Get(ImpersonatedByKey).(string)
+            if admin == "" {
+                return next(c)
+            }
+
+            err := next(c)
+
+            status := c.Response().Status
+            if he, ok := err.(*echo.HTTPError); ok {
+                status = he.Code
+            }
+            target, _ := c.Get("lto_client_id").(string)
+            detail, _ := json.Marshal(map[string]interface{}{
+                "uri":    c.Request().RequestURI,
+                "status": status,
+            })
+            entry := &models.AuditLog{
+                ActorLTOID: admin,
+                Action:     c.Request().Method + " " + c.Path(),
+                EntityType: "impersonation",
+                EntityID:   target,
+                NewValue:   detail,
+            }
+            if err := repo.Create(c.Request().Context(), entry); err != nil {
+                log.Printf("[req-id:%s] impersonation audit log error: %v", GetRequestID(c), err)
+            }
+            return err
+        }
+    }
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+    "bytes"
+    "io"
+    "net/http"
+    "time"
+
+    "github.com/labstack/echo/v4"
+    "github.com/labstack/echo/v4/middleware"
+    "golang.org/x/time/rate"
+)
+
+// BodyLimitConfig sets the largest request body accepted, in bytes.
+// Routes overrides Default by route path (as registered, e.g. "/v1/api/plates/:id").
+type BodyLimitConfig struct {
+    Default int64
+    Routes  map[string]int64
+}
+
+// BodyLimit rejects bodies larger than the route's limit with 413. The body
+// is read up front so handlers see either the whole body or nothing.
+func BodyLimit(cfg BodyLimitConfig) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            req := c.Request()
+            if req.Body == nil || req.Body == http.NoBody {
+                return next(c)
+            }
+
+            limit := cfg.Default
+            if l, ok := cfg.Routes[c.Path()]; ok {
+                limit = l
+            }
+            if req.ContentLength > limit {
+                return tooLarge(c)
+            }
+
+            body, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+            req.Body.Close()
+            if err != nil {
+                return c.JSON(http.StatusBadRequest, map[string]string{"error": "could not read request body"})
+            }
+            if int64(len(body)) > limit {
+                return tooLarge(c)
+            }
+            req.Body = io.NopCloser(bytes.NewReader(body))
+            return next(c)
+        }
+    }
+}
+
+func tooLarge(c echo.Context) error {
+    return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "request body too large"})
+}
+
+// MaxParamLength rejects requests whose path parameters are longer than max.
+func MaxParamLength(max int) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            for _, v := range c.ParamValues() {
+                if len(v) > max {
+                    return c.JSON(http.StatusBadRequest, map[string]string{"error": "path parameter too long"})
+                }
+            }
+            return next(c)
+        }
+    }
+}
+
+// PerIPRateLimit allows each client address perMinute requests a minute,
+// in bursts of up to perMinute, and answers 429 beyond that. The address
+// comes from e.IPExtractor, so a client can't get a fresh bucket by sending
+// a different X-Forwarded-For.
+func PerIPRateLimit(perMinute int) echo.MiddlewareFunc {
+    return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+        Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+            Rate: rate.Limit(float64(perMinute) / 60), Burst: perMinute, ExpiresIn: 3 * time.Minute,
+        }),
+        IdentifierExtractor: func(c echo.Context) (string, error) { return c.RealIP(), nil },
+        DenyHandler: func(c echo.Context, _ string, _ error) error {
+            return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "rate limit exceeded"})
+        },
+    })
+}
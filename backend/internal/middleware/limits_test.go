@@ -0,0 +1,39 @@
+package middleware
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/labstack/echo/v4"
+)
+
+func TestPerIPRateLimitIgnoresForwardedFor(t *testing.T) {
+    e := echo.New()
+    e.IPExtractor = echo.ExtractIPDirect()
+    e.GET("/verify", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, PerIPRateLimit(3))
+
+    get := func(remote, xff string) int {
+        req := httptest.NewRequest("GET", "/verify", nil)
+        req.RemoteAddr = remote
+        req.Header.Set("X-Forwarded-For", xff)
+        req.Header.Set("X-Real-IP", xff)
+        rec := httptest.NewRecorder()
+        e.ServeHTTP(rec, req)
+        return rec.Code
+    }
+
+    // every request claims a different client; the bucket is still the peer's
+    for i := 0; i < 3; i++ {
+        if code := get("203.0.113.7:5000", fmt.Sprintf("198.51.100.%d", i)); code != http.StatusOK {
+            t.Fatalf("request %d: status = %d, want 200", i+1, code)
+        }
+    }
+    if code := get("203.0.113.7:5000", "198.51.100.99"); code != http.StatusTooManyRequests {
+        t.Fatalf("spoofed X-Forwarded-For: status = %d, want 429", code)
+    }
+    if code := get("203.0.113.8:5000", ""); code != http.StatusOK {
+        t.Fatalf("other client: status = %d, want 200", code)
+    }
+}
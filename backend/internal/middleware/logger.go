@@ -0,0 +1,61 @@
+package middleware
+
+import (
+    "log/slog"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/labstack/echo/v4"
+)
+
+// LoggerKey is the Echo context key holding the request-scoped *slog.Logger
+const LoggerKey = "logger"
+
+// StructuredLoggerMiddleware logs every request as a single JSON line and
+// stores a logger tagged with the request id on the context for handlers.
+// It uses the id from RequestIDMiddleware when that runs first.
+func StructuredLoggerMiddleware(logger *slog.Logger) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            start := time.Now()
+            requestID := GetRequestID(c)
+            if requestID == "" {
+                requestID = uuid.NewString()
+                c.Response().Header().Set(echo.HeaderXRequestID, requestID)
+            }
+
+            reqLogger := logger.With("request_id", requestID)
+            c.Set(LoggerKey, reqLogger)
+
+            err := next(c)
+            if err != nil {
+                // let Echo write the error response so the status below is final
+                c.Error(err)
+            }
+
+            attrs := []any{
+                "method", c.Request().Method,
+                "path", c.Path(),
+                "status", c.Response().Status,
+                "latency_ms", time.Since(start).Milliseconds(),
+                "remote_ip", c.RealIP(),
+            }
+            if userID, ok := c.Get("lto_client_id").(string); ok && userID != "" {
+                attrs = append(attrs, "user_id", userID)
+            }
+            if err != nil {
+                attrs = append(attrs, "error", err.Error())
+            }
+            reqLogger.Info("request", attrs...)
+            return nil
+        }
+    }
+}
+
+// LoggerFrom returns the request's logger, or slog.Default outside a request
+func LoggerFrom(c echo.Context) *slog.Logger {
+    if l, ok := c.Get(LoggerKey).(*slog.Logger); ok {
+        return l
+    }
+    return slog.Default()
+}
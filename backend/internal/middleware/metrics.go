@@ -0,0 +1,47 @@
+package middleware
+
+import (
+    "net"
+    "net/http"
+    "smartplate-api/internal/metrics"
+    "strconv"
+    "time"
+
+    "github.com/labstack/echo/v4"
+)
+
+// MetricsMiddleware counts and times every request by its route pattern.
+func MetricsMiddleware() echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            start := time.Now()
+            err := next(c)
+            // write the error response now so the status recorded below is
+            // the one the client gets; Echo won't write it a second time
+            if err != nil {
+                c.Error(err)
+            }
+
+            method, path := c.Request().Method, c.Path()
+            status := strconv.Itoa(c.Response().Status)
+            metrics.HTTPRequestsTotal.WithLabelValues(method, path, status).Inc()
+            metrics.HTTPRequestDuration.WithLabelValues(method, path).Observe(time.Since(start).Seconds())
+            return err
+        }
+    }
+}
+
+// InternalOnly rejects requests that don't come from a loopback or private
+// address. The address is whatever e.IPExtractor yields, so it can only be
+// taken from X-Forwarded-For when TRUSTED_PROXIES says so.
+func InternalOnly() echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            ip := net.ParseIP(c.RealIP())
+            if ip == nil || !(ip.IsLoopback() || ip.IsPrivate()) {
+                return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+            }
+            return next(c)
+        }
+    }
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "smartplate-api/internal/metrics"
+    "testing"
+
+    "github.com/labstack/echo/v4"
+    dto "github.com/prometheus/client_model/go"
+)
+
+func requestCount(t *testing.T, method, path, status string) float64 {
+    t.Helper()
+    var m dto.Metric
+    if err := metrics.HTTPRequestsTotal.WithLabelValues(method, path, status).Write(&m); err != nil {
+        t.Fatal(err)
+    }
+    return m.GetCounter().GetValue()
+}
+
+func TestMetricsMiddlewareReturnsHandlerError(t *testing.T) {
+    e := echo.New()
+    handlerErr := echo.NewHTTPError(http.StatusTeapot, "short and stout")
+    e.GET("/metrics-test", func(c echo.Context) error { return handlerErr }, MetricsMiddleware())
+
+    var got error
+    e.HTTPErrorHandler = func(err error, c echo.Context) {
+        got = err
+        e.DefaultHTTPErrorHandler(err, c)
+    }
+
+    before := requestCount(t, "GET", "/metrics-test", "418")
+    rec := httptest.NewRecorder()
+    e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics-test", nil))
+
+    if !errors.Is(got, handlerErr) {
+        t.Fatalf("error reaching Echo = %v, want the handler's", got)
+    }
+    if rec.Code != http.StatusTeapot {
+        t.Fatalf("status = %d, want 418", rec.Code)
+    }
+    if n := requestCount(t, "GET", "/metrics-test", "418") - before; n != 1 {
+        t.Fatalf("recorded %v requests with status 418, want 1", n)
+    }
+}
+
+func TestInternalOnlyIgnoresForwardedFor(t *testing.T) {
+    tests := []struct {
+        name   string
+        remote string
+        want   int
+    }{
+        {"loopback peer", "127.0.0.1:5000", http.StatusOK},
+        {"private peer", "10.1.2.3:5000", http.StatusOK},
+        {"public peer", "203.0.113.7:5000", http.StatusForbidden},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            e := echo.New()
+            e.IPExtractor = echo.ExtractIPDirect()
+            e.GET("/metrics", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, InternalOnly())
+
+            req := httptest.NewRequest("GET", "/metrics", nil)
+            req.RemoteAddr = tt.remote
+            req.Header.Set("X-Forwarded-For", "127.0.0.1")
+            req.Header.Set("X-Real-IP", "127.0.0.1")
+            rec := httptest.NewRecorder()
+            e.ServeHTTP(rec, req)
+            if rec.Code != tt.want {
+                t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+            }
+        })
+    }
+}
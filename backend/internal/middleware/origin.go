@@ -0,0 +1,23 @@
+package middleware
+
+import (
+    "net/http"
+    "smartplate-api/internal/config"
+
+    "github.com/labstack/echo/v4"
+)
+
+// OriginGuard rejects cross-origin requests, including websocket upgrades,
+// from origins not in cfg. Requests without an Origin header (non-browser
+// clients such as scanners) are let through.
+func OriginGuard(cfg config.CORSConfig) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            origin := c.Request().Header.Get(echo.HeaderOrigin)
+            if origin != "" && !cfg.Allowed(origin) {
+                return c.JSON(http.StatusForbidden, map[string]string{"error": "origin not allowed"})
+            }
+            return next(c)
+        }
+    }
+}
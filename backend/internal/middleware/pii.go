@@ -0,0 +1,52 @@
+package middleware
+
+import (
+    "os"
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+    "strings"
+
+    "github.com/labstack/echo/v4"
+)
+
+// PIIVisibleKey is the context key MaskPII sets when the caller may see
+// owner details
+const PIIVisibleKey = "pii_visible"
+
+// MaskPII decides whether a public route may show personal data. Callers
+// with a valid, unrevoked LTO officer or admin token see it; everyone else,
+// including callers with a missing or bad token, gets the masked view rather
+// than a 401. Handlers check PIIVisible.
+func MaskPII(sessions repository.SessionRepository) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            tokenString, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+            if !ok || tokenString == "" {
+                return next(c)
+            }
+            claims, err := ParseToken(tokenString, []byte(os.Getenv("JWT_SECRET")))
+            if err != nil || !hasRole([]string{models.RoleOfficer, models.RoleAdmin, models.RoleSuperAdmin}, claims.Role) {
+                return next(c)
+            }
+            if claims.ID != "" {
+                revoked, err := sessions.IsRevoked(c.Request().Context(), claims.ID)
+                if err != nil {
+                    return err
+                }
+                if revoked {
+                    return next(c)
+                }
+            }
+            c.Set("lto_client_id", claims.Subject)
+            c.Set("role", claims.Role)
+            c.Set(PIIVisibleKey, true)
+            return next(c)
+        }
+    }
+}
+
+// PIIVisible reports whether MaskPII let the caller see personal data
+func PIIVisible(c echo.Context) bool {
+    visible, _ := c.Get(PIIVisibleKey).(bool)
+    return visible
+}
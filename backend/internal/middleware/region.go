@@ -0,0 +1,36 @@
+package middleware
+
+import (
+    "net/http"
+    "smartplate-api/internal/models"
+
+    "github.com/labstack/echo/v4"
+)
+
+// RegionKey is the Echo context key holding the region a request is limited to
+const RegionKey = "region_scope"
+
+// RegionScope limits LTO officers to the region in their token. It must run
+// after RequireRole. Officers without a region get 403; other roles are not
+// scoped.
+func RegionScope() echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            if role, _ := c.Get("role").(string); role == models.RoleOfficer {
+                region, _ := c.Get("region").(string)
+                if region == "" {
+                    return c.JSON(http.StatusForbidden, map[string]string{"error": "no region assigned"})
+                }
+                c.Set(RegionKey, region)
+            }
+            return next(c)
+        }
+    }
+}
+
+// ScopedRegion returns the region set by RegionScope, or "" when the caller
+// may see every region
+func ScopedRegion(c echo.Context) string {
+    region, _ := c.Get(RegionKey).(string)
+    return region
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+    "github.com/google/uuid"
+    "github.com/labstack/echo/v4"
+)
+
+// RequestIDKey is the Echo context key holding the request's correlation id
+const RequestIDKey = "request_id"
+
+// RequestIDMiddleware gives every request a correlation id. An incoming
+// X-Request-ID is kept when it is a UUID, so a caller can follow one request
+// across services; otherwise a new UUID is generated. The id is echoed in the
+// X-Request-ID response header and stored under RequestIDKey.
+func RequestIDMiddleware() echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            id := c.Request().Header.Get(echo.HeaderXRequestID)
+            if parsed, err := uuid.Parse(id); err == nil {
+                id = parsed.String()
+            } else {
+                id = uuid.NewString()
+            }
+            c.Response().Header().Set(echo.HeaderXRequestID, id)
+            c.Set(RequestIDKey, id)
+            return next(c)
+        }
+    }
+}
+
+// GetRequestID returns the id set by RequestIDMiddleware, or "" outside it
+func GetRequestID(c echo.Context) string {
+    id, _ := c.Get(RequestIDKey).(string)
+    return id
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+    "bytes"
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/google/uuid"
+    "github.com/labstack/echo/v4"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+    const known = "3f2b8c1e-6a4d-4e0f-9b7a-1c2d3e4f5a6b"
+    tests := []struct {
+        name     string
+        incoming string
+        want     string // "" means a freshly generated id
+    }{
+        {"no header", "", ""},
+        {"uuid is kept", known, known},
+        {"uppercase uuid is normalised", strings.ToUpper(known), known},
+        {"not a uuid", "abc123", ""},
+        {"log injection", "x\n[req-id:admin]", ""},
+        {"too long", strings.Repeat("a", 500), ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var logs bytes.Buffer
+            logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+            var seen string
+            e := echo.New()
+            e.Use(RequestIDMiddleware(), StructuredLoggerMiddleware(logger))
+            e.GET("/", func(c echo.Context) error {
+                seen = GetRequestID(c)
+                return c.NoContent(http.StatusNoContent)
+            })
+
+            req := httptest.NewRequest(http.MethodGet, "/", nil)
+            if tt.incoming != "" {
+                req.Header.Set(echo.HeaderXRequestID, tt.incoming)
+            }
+            rec := httptest.NewRecorder()
+            e.ServeHTTP(rec, req)
+
+            got := rec.Header().Get(echo.HeaderXRequestID)
+            parsed, err := uuid.Parse(got)
+            if err != nil || parsed.String() != got {
+                t.Fatalf("X-Request-ID = %q, want a UUID", got)
+            }
+            if tt.want != "" && got != tt.want {
+                t.Fatalf("X-Request-ID = %q, want %q", got, tt.want)
+            }
+            if tt.want == "" && got == strings.ToLower(tt.incoming) {
+                t.Fatalf("X-Request-ID kept the invalid %q", tt.incoming)
+            }
+            if seen != got {
+                t.Fatalf("GetRequestID = %q, header %q", seen, got)
+            }
+
+            var line struct {
+                RequestID string `json:"request_id"`
+            }
+            if err := json.Unmarshal(logs.Bytes(), &line); err != nil {
+                t.Fatalf("log line %q: %v", logs.String(), err)
+            }
+            if line.RequestID != got {
+                t.Fatalf("logged request_id = %q, want %q", line.RequestID, got)
+            }
+        })
+    }
+}
+
+func TestRequestIDIsUnique(t *testing.T) {
+    e := echo.New()
+    e.Use(RequestIDMiddleware())
+    e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+
+    seen := make(map[string]bool)
+    for i := 0; i < 100; i++ {
+        rec := httptest.NewRecorder()
+        e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+        id := rec.Header().Get(echo.HeaderXRequestID)
+        if seen[id] {
+            t.Fatalf("request id %q handed out twice", id)
+        }
+        seen[id] = true
+    }
+}
+
+func TestGetRequestIDOutsideMiddleware(t *testing.T) {
+    c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+    if id := GetRequestID(c); id != "" {
+        t.Fatalf("GetRequestID = %q, want empty", id)
+    }
+}
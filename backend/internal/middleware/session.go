@@ -0,0 +1,34 @@
+package middleware
+
+import (
+    "net/http"
+    "smartplate-api/internal/repository"
+
+    "github.com/labstack/echo/v4"
+)
+
+// SessionIDKey is the context key RequireRole sets to the token's jti, which
+// is the id of its sessions row
+const SessionIDKey = "session_id"
+
+// RejectRevoked answers 401 for tokens whose session has been revoked, which
+// makes the sessions table the token blacklist. Tokens issued before sessions
+// existed carry no jti and pass until they expire. It must run after RequireRole.
+func RejectRevoked(repo repository.SessionRepository) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            sessionID, _ := c.Get(SessionIDKey).(string)
+            if sessionID == "" {
+                return next(c)
+            }
+            revoked, err := repo.IsRevoked(c.Request().Context(), sessionID)
+            if err != nil {
+                return err
+            }
+            if revoked {
+                return c.JSON(http.StatusUnauthorized, map[string]string{"error": "session has been revoked"})
+            }
+            return next(c)
+        }
+    }
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+    "net/http"
+    "strings"
+
+    "github.com/labstack/echo/v4"
+)
+
+// CurrentAPIVersion is reported for routes that live outside a version group
+const CurrentAPIVersion = "1"
+
+// versionPrefix returns "1" for paths like /v1/..., or "" if there is none
+func versionPrefix(path string) string {
+    if len(path) < 3 || path[0] != '/' || path[1] != 'v' {
+        return ""
+    }
+    rest := path[2:]
+    end := strings.IndexByte(rest, '/')
+    if end == -1 {
+        end = len(rest)
+    }
+    v := rest[:end]
+    for _, r := range v {
+        if r < '0' || r > '9' {
+            return ""
+        }
+    }
+    return v
+}
+
+// APIVersionHeader sets the API-Version response header from the route's
+// /vN prefix, falling back to CurrentAPIVersion.
+func APIVersionHeader() echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            v := versionPrefix(c.Request().URL.Path)
+            if v == "" {
+                v = CurrentAPIVersion
+            }
+            c.Response().Header().Set("API-Version", v)
+            return next(c)
+        }
+    }
+}
+
+// VersionNegotiation routes unprefixed requests carrying an Accept-Version
+// header ("1" or "v1") to that version's group. Unsupported versions get 406.
+// It must be registered with e.Pre so the rewrite happens before routing.
+func VersionNegotiation(supported ...string) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            req := c.Request()
+            want := strings.TrimPrefix(req.Header.Get("Accept-Version"), "v")
+            if want == "" || versionPrefix(req.URL.Path) != "" {
+                return next(c)
+            }
+            for _, v := range supported {
+                if v == want {
+                    req.URL.Path = "/v" + v + req.URL.Path
+                    if req.URL.RawPath != "" {
+                        req.URL.RawPath = "/v" + v + req.URL.RawPath
+                    }
+                    return next(c)
+                }
+            }
+            return c.JSON(http.StatusNotAcceptable, map[string]string{"error": "unsupported API version"})
+        }
+    }
+}
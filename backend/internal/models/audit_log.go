@@ -0,0 +1,18 @@
+package models
+
+import (
+    "encoding/json"
+    "time"
+)
+
+// AuditLog records one mutating action taken by an admin or LTO officer
+type AuditLog struct {
+    AuditID    string          `json:"audit_id"     db:"audit_id"`
+    ActorLTOID string          `json:"actor_lto_id" db:"actor_lto_id"`
+    Action     string          `json:"action"       db:"action"`
+    EntityType string          `json:"entity_type"  db:"entity_type"`
+    EntityID   string          `json:"entity_id"    db:"entity_id"`
+    OldValue   json.RawMessage `json:"old_value"    db:"old_value"`
+    NewValue   json.RawMessage `json:"new_value"    db:"new_value"`
+    CreatedAt  time.Time       `json:"created_at"   db:"created_at"`
+}
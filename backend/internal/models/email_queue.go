@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Email queue statuses
+const (
+    EmailPending = "Pending"
+    EmailSent    = "Sent"
+    EmailFailed  = "Failed"
+)
+
+// EmailQueueItem is one outgoing email waiting for, or done with, delivery.
+// The bodies never leave the server: they can carry password reset links.
+type EmailQueueItem struct {
+    ID              string     `json:"id"                db:"id"`
+    ToEmail         string     `json:"to_email"          db:"to_email"`
+    Subject         string     `json:"subject"           db:"subject"`
+    HTMLBody        string     `json:"-"                 db:"html_body"`
+    TextBody        string     `json:"-"                 db:"text_body"`
+    Status          string     `json:"status"            db:"status"`
+    Attempts        int        `json:"attempts"          db:"attempts"`
+    LastAttemptedAt *time.Time `json:"last_attempted_at" db:"last_attempted_at"`
+    ErrorMessage    string     `json:"error_message"     db:"error_message"`
+    CreatedAt       time.Time  `json:"created_at"        db:"created_at"`
+}
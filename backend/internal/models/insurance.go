@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Insurance statuses reported to scanners
+const (
+    InsuranceActive      = "active"
+    InsuranceExpired     = "expired"
+    InsuranceNone        = "none"        // no policy on file
+    InsuranceUnavailable = "unavailable" // the lookup failed or timed out
+)
+
+// InsuranceStatus is a vehicle's compulsory third-party liability cover
+type InsuranceStatus struct {
+    Status       string     `json:"status"`
+    Provider     string     `json:"provider,omitempty"`
+    PolicyNumber string     `json:"policy_number,omitempty"`
+    ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// LoginAudit is one password login attempt. Country is an ISO 3166-1 alpha-2
+// code; country and city are nil when the IP couldn't be located.
+type LoginAudit struct {
+    ID          int64     `json:"id"            db:"id"`
+    LTOClientID *string   `json:"lto_client_id" db:"lto_client_id"`
+    IPAddress   *string   `json:"ip_address"    db:"ip_address"`
+    UserAgent   *string   `json:"user_agent"    db:"user_agent"`
+    Success     bool      `json:"success"       db:"success"`
+    AttemptedAt time.Time `json:"attempted_at"  db:"attempted_at"`
+    Country     *string   `json:"country"       db:"country"`
+    City        *string   `json:"city"          db:"city"`
+}
@@ -1,3 +1,48 @@
 package models
 
+import "time"
 
+// NotificationPreferences says which channels a user wants each kind of
+// notification on. Every channel is on until the user turns it off.
+type NotificationPreferences struct {
+    LTOClientID      string    `json:"lto_client_id"      db:"lto_client_id"`
+    EmailPlateExpiry bool      `json:"email_plate_expiry" db:"email_plate_expiry"`
+    SMSPlateExpiry   bool      `json:"sms_plate_expiry"   db:"sms_plate_expiry"`
+    EmailScanAlert   bool      `json:"email_scan_alert"   db:"email_scan_alert"`
+    SMSScanAlert     bool      `json:"sms_scan_alert"     db:"sms_scan_alert"`
+    UpdatedAt        time.Time `json:"updated_at"         db:"updated_at"`
+}
+
+// DefaultNotificationPreferences are the preferences of a user who has never
+// saved any: every channel on
+func DefaultNotificationPreferences(ltoClientID string) NotificationPreferences {
+    return NotificationPreferences{
+        LTOClientID:      ltoClientID,
+        EmailPlateExpiry: true,
+        SMSPlateExpiry:   true,
+        EmailScanAlert:   true,
+        SMSScanAlert:     true,
+    }
+}
+
+// NotificationPreferencesUpdate is a partial update; nil fields are left as they are
+type NotificationPreferencesUpdate struct {
+    EmailPlateExpiry *bool `json:"email_plate_expiry"`
+    SMSPlateExpiry   *bool `json:"sms_plate_expiry"`
+    EmailScanAlert   *bool `json:"email_scan_alert"`
+    SMSScanAlert     *bool `json:"sms_scan_alert"`
+}
+
+// Apply copies the set fields of u onto p
+func (u NotificationPreferencesUpdate) Apply(p *NotificationPreferences) {
+    for _, f := range []struct{ src, dst *bool }{
+        {u.EmailPlateExpiry, &p.EmailPlateExpiry},
+        {u.SMSPlateExpiry, &p.SMSPlateExpiry},
+        {u.EmailScanAlert, &p.EmailScanAlert},
+        {u.SMSScanAlert, &p.SMSScanAlert},
+    } {
+        if f.src != nil {
+            *f.dst = *f.src
+        }
+    }
+}
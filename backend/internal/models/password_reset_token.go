@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// PasswordResetToken is a single-use token emailed to a user who asked to reset their password
+type PasswordResetToken struct {
+    ID          string     `json:"id"            db:"token_id"`
+    Token       string     `json:"-"             db:"token"`
+    LTOClientID string     `json:"lto_client_id" db:"lto_client_id"`
+    ExpiresAt   time.Time  `json:"expires_at"    db:"expires_at"`
+    UsedAt      *time.Time `json:"used_at"       db:"used_at"`
+    CreatedAt   time.Time  `json:"created_at"    db:"created_at"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+    "errors"
+    "fmt"
+    "time"
+)
+
+// FormState is a registration form's place in the review workflow, stored in
+// registration_form.status
+type FormState string
+
+// Registration form states. Submitted forms are stored as "Pending", the
+// name the API and the frontend already use for forms awaiting review.
+const (
+    FormDraft       FormState = "Draft"
+    FormSubmitted   FormState = "Pending"
+    FormUnderReview FormState = "Under Review"
+    FormApproved    FormState = "Approved"
+    FormRejected    FormState = "Rejected"
+)
+
+// ValidTransitions lists the states each state may move to. Approved is
+// final; a rejected form goes back to draft to be fixed and resubmitted.
+var ValidTransitions = map[FormState][]FormState{
+    FormDraft:       {FormSubmitted},
+    FormSubmitted:   {FormUnderReview},
+    FormUnderReview: {FormApproved, FormRejected},
+    FormRejected:    {FormDraft},
+}
+
+// ErrInvalidTransition is wrapped by every error TransitionTo returns
+var ErrInvalidTransition = errors.New("invalid registration form transition")
+
+// CanTransition reports whether a form in state from may move to state to
+func CanTransition(from, to FormState) bool {
+    for _, next := range ValidTransitions[from] {
+        if next == to {
+            return true
+        }
+    }
+    return false
+}
+
+// FormStateHistory is one status change of a registration form
+type FormStateHistory struct {
+    HistoryID          string    `json:"history_id"           db:"history_id"`
+    RegistrationFormID string    `json:"registration_form_id" db:"registration_form_id"`
+    FromState          FormState `json:"from_state"           db:"from_state"`
+    ToState            FormState `json:"to_state"             db:"to_state"`
+    ChangedBy          string    `json:"changed_by"           db:"changed_by"`
+    ChangedAt          time.Time `json:"changed_at"           db:"changed_at"`
+}
+
+// TransitionTo moves f to newState on behalf of actorID, or returns an error
+// wrapping ErrInvalidTransition and leaves f unchanged. The change is kept
+// for LastTransition so the repository can record it.
+func (f *RegistrationForm) TransitionTo(newState FormState, actorID string) error {
+    from := FormState(f.Status)
+    if !CanTransition(from, newState) {
+        return fmt.Errorf("%w: %q to %q", ErrInvalidTransition, from, newState)
+    }
+    f.Status = string(newState)
+    f.lastTransition = &FormStateHistory{
+        RegistrationFormID: f.RegistrationFormID,
+        FromState:          from,
+        ToState:            newState,
+        ChangedBy:          actorID,
+        ChangedAt:          time.Now(),
+    }
+    return nil
+}
+
+// LastTransition returns the change made by the latest TransitionTo, or nil
+func (f *RegistrationForm) LastTransition() *FormStateHistory {
+    return f.lastTransition
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+    "errors"
+    "fmt"
+    "testing"
+)
+
+func TestTransitionTo(t *testing.T) {
+    states := []FormState{FormDraft, FormSubmitted, FormUnderReview, FormApproved, FormRejected, "", "Cancelled"}
+    valid := map[[2]FormState]bool{
+        {FormDraft, FormSubmitted}:       true,
+        {FormSubmitted, FormUnderReview}: true,
+        {FormUnderReview, FormApproved}:  true,
+        {FormUnderReview, FormRejected}:  true,
+        {FormRejected, FormDraft}:        true,
+    }
+
+    // every ordered pair of states, including staying put and unknown states
+    for _, from := range states {
+        for _, to := range states {
+            t.Run(fmt.Sprintf("%q to %q", from, to), func(t *testing.T) {
+                f := RegistrationForm{RegistrationFormID: "rf-1", Status: string(from)}
+                err := f.TransitionTo(to, "LTO-OFFICER-7")
+
+                if valid[[2]FormState{from, to}] {
+                    if err != nil {
+                        t.Fatalf("TransitionTo = %v, want nil", err)
+                    }
+                    if f.Status != string(to) {
+                        t.Fatalf("Status = %q, want %q", f.Status, to)
+                    }
+                    h := f.LastTransition()
+                    if h == nil || h.RegistrationFormID != "rf-1" || h.FromState != from || h.ToState != to ||
+                        h.ChangedBy != "LTO-OFFICER-7" || h.ChangedAt.IsZero() {
+                        t.Fatalf("LastTransition = %+v", h)
+                    }
+                    return
+                }
+
+                if !errors.Is(err, ErrInvalidTransition) {
+                    t.Fatalf("TransitionTo = %v, want ErrInvalidTransition", err)
+                }
+                if want := fmt.Sprintf("%v: %q to %q", ErrInvalidTransition, from, to); err.Error() != want {
+                    t.Fatalf("error = %q, want %q", err, want)
+                }
+                if f.Status != string(from) {
+                    t.Fatalf("Status changed to %q on a rejected transition", f.Status)
+                }
+                if f.LastTransition() != nil {
+                    t.Fatalf("LastTransition = %+v after a rejected transition", f.LastTransition())
+                }
+            })
+        }
+    }
+}
+
+func TestValidTransitionsMatchCanTransition(t *testing.T) {
+    for from, nexts := range ValidTransitions {
+        for _, to := range nexts {
+            if !CanTransition(from, to) {
+                t.Errorf("CanTransition(%q, %q) = false", from, to)
+            }
+        }
+    }
+    if len(ValidTransitions[FormApproved]) != 0 {
+        t.Errorf("approved forms can move to %v, want final", ValidTransitions[FormApproved])
+    }
+}
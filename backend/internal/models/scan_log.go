@@ -3,9 +3,57 @@ package models
 import "time"
 
 type ScanLog struct {
-    LogID          string    `db:"log_id"`
-    PlateID        string    `db:"plate_id"`
-    RegistrationID string    `db:"registration_id"`
-    LTOClientID    string    `db:"lto_client_id"`
-    ScannedAt      time.Time `db:"scanned_at"`
+    LogID          string    `db:"log_id" example:"2b3c4d5e-6f7a-4b8c-9d0e-1f2a3b4c5d6e"`
+    PlateID        string    `db:"plate_id" example:"3f1c2a9e-7b4d-4c1e-9a2f-5d6e7f8a9b0c"`
+    RegistrationID string    `db:"registration_id" example:"4c5d6e7f-8a9b-4c0d-8e1f-2a3b4c5d6e7f"`
+    LTOClientID    string    `db:"lto_client_id" example:"LTO-2024-000123"`
+    ScannedAt      time.Time `db:"scanned_at" example:"2024-06-01T08:30:00Z"`
+
+    // where and by which scanner the plate was read, when the device reports it
+    Latitude        *float64 `db:"latitude" example:"14.599512"`
+    Longitude       *float64 `db:"longitude" example:"120.984222"`
+    ScannerDeviceID *string  `db:"scanner_device_id" example:"scanner-ncr-017"`
+    ScannerIP       *string  `db:"scanner_ip" example:"203.0.113.7"` // address the scanner connected from
+    ScannedBy       *string  `db:"scanned_by" example:"LTO-2024-000045"` // signed-in account that ran the scan
+
+    // PlateNumber is not stored on scan_log; it's filled by queries that join plates
+    PlateNumber    string    `db:"plate_number" example:"ABC 1234"`
+}
+
+// HourlyCount is the number of scans recorded in one hour of the day (0-23)
+type HourlyCount struct {
+    Hour  int `json:"hour"  db:"hour"`
+    Count int `json:"count" db:"count"`
+}
+
+// OfficerScanCount is how many scans one LTO officer has made
+type OfficerScanCount struct {
+    LTOClientID string `json:"lto_client_id" db:"lto_client_id"`
+    FullName    string `json:"officer_name"  db:"full_name"`
+    ScanCount   int    `json:"scan_count"    db:"scan_count"`
+}
+
+// ScannerIPCount is how many scans came from one scanner IP
+type ScannerIPCount struct {
+    IP            string    `json:"ip"              db:"ip"`
+    ScanCount     int       `json:"scan_count"      db:"scan_count"`
+    DeviceCount   int       `json:"device_count"    db:"device_count"` // distinct scanner_device_ids seen from it
+    LastScannedAt time.Time `json:"last_scanned_at" db:"last_scanned_at"`
+}
+
+// ScanReportRow is one scan in the monthly PDF report. Status is the plate's
+// status at the time of the scan, so a plate past its expiry reads Expired.
+type ScanReportRow struct {
+    ScannedAt   time.Time `db:"scanned_at"`
+    PlateNumber string    `db:"plate_number"`
+    OwnerName   string    `db:"owner_name"`
+    Status      string    `db:"status"`
+}
+
+// ScanReportSummary totals a month of scans for the PDF report
+type ScanReportSummary struct {
+    TotalScans        int `db:"total_scans"`
+    UniquePlates      int `db:"unique_plates"`
+    UniqueOwners      int `db:"unique_owners"`
+    ExpiredDetections int `db:"expired_detections"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Session is one issued JWT; its id is the token's jti claim. Revoking the
+// session blacklists the token.
+type Session struct {
+    SessionID   string     `json:"session_id"    db:"session_id"`
+    LTOClientID string     `json:"lto_client_id" db:"lto_client_id"`
+    IssuedAt    time.Time  `json:"issued_at"     db:"issued_at"`
+    ExpiresAt   time.Time  `json:"expires_at"    db:"expires_at"`
+    UserAgent   *string    `json:"user_agent"    db:"user_agent"`
+    IPAddress   *string    `json:"ip_address"    db:"ip_address"`
+    RevokedAt   *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+    Current     bool       `json:"current"       db:"-"` // the session the request was made with
+}
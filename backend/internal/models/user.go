@@ -5,22 +5,79 @@ import (
 )
 
 type User struct {
-	USER_ID             int                 `json:"user_id" db:"user_id"`
-	LAST_NAME           string              `json:"last_name" db:"last_name"`
-	FIRST_NAME          string              `json:"first_name" db:"first_name"`
-	MIDDLE_NAME         string              `json:"middle_name,omitempty" db:"middle_name"`
-	EMAIL               string              `json:"email" db:"email"`
-	PASSWORD            string              `json:"password" db:"password" binding:"required"`
-	ROLE                string              `json:"role" db:"role"`
-	STATUS              string              `json:"status" db:"status"`
-	LTO_CLIENT_ID       string              `json:"lto_client_id" db:"lto_client_id"`
-	CREATED             time.Time           `json:"-" db:"created"`
-	UPDATED             time.Time           `json:"-" db:"updated"`
-	Contact             Contact             `json:"contact" db:"contact"`
-	Address             Address             `json:"address" db:"address"`
-	MedicalInformation  MedicalInformation  `json:"medical_information" db:"medical_information"`
-	People              People              `json:"people" db:"people"`
-	PersonalInformation PersonalInformation `json:"personal_information" db:"personal_information"`
+	USER_ID               int                 `json:"user_id" db:"user_id"`
+	LAST_NAME             string              `json:"last_name" db:"last_name"`
+	FIRST_NAME            string              `json:"first_name" db:"first_name"`
+	MIDDLE_NAME           string              `json:"middle_name,omitempty" db:"middle_name"`
+	EMAIL                 string              `json:"email" db:"email"`
+	PASSWORD              string              `json:"password" db:"password" binding:"required"`
+	ROLE                  string              `json:"role" db:"role"`
+	STATUS                string              `json:"status" db:"status"`
+	REGION                *string             `json:"region,omitempty" db:"region"`
+	LTO_CLIENT_ID         string              `json:"lto_client_id" db:"lto_client_id"`
+	GOOGLE_ID             *string             `json:"-" db:"google_id"` // Google account "sub", once linked
+	CREATED               time.Time           `json:"-" db:"created"`
+	UPDATED               time.Time           `json:"-" db:"updated"`
+	DELETED_AT            *time.Time          `json:"deleted_at,omitempty" db:"deleted_at"`
+	LAST_LOGIN_AT         *time.Time          `json:"last_login_at,omitempty" db:"last_login_at"`
+	INACTIVITY_EMAILED_AT *time.Time          `json:"-" db:"inactivity_emailed_at"`
+	Contact               Contact             `json:"contact" db:"contact"`
+	Address               Address             `json:"address" db:"address"`
+	MedicalInformation    MedicalInformation  `json:"medical_information" db:"medical_information"`
+	People                People              `json:"people" db:"people"`
+	PersonalInformation   PersonalInformation `json:"personal_information" db:"personal_information"`
+}
+
+// MaskedUser is the view of a User that API responses carry. It leaves out
+// the password hash, the linked Google id and bookkeeping columns.
+type MaskedUser struct {
+	USER_ID             int                 `json:"user_id"`
+	LAST_NAME           string              `json:"last_name"`
+	FIRST_NAME          string              `json:"first_name"`
+	MIDDLE_NAME         string              `json:"middle_name,omitempty"`
+	EMAIL               string              `json:"email"`
+	ROLE                string              `json:"role"`
+	STATUS              string              `json:"status"`
+	REGION              *string             `json:"region,omitempty"`
+	LTO_CLIENT_ID       string              `json:"lto_client_id"`
+	DELETED_AT          *time.Time          `json:"deleted_at,omitempty"`
+	LAST_LOGIN_AT       *time.Time          `json:"last_login_at,omitempty"`
+	Contact             Contact             `json:"contact"`
+	Address             Address             `json:"address"`
+	MedicalInformation  MedicalInformation  `json:"medical_information"`
+	People              People              `json:"people"`
+	PersonalInformation PersonalInformation `json:"personal_information"`
+}
+
+// ToMasked returns the response view of u
+func (u User) ToMasked() MaskedUser {
+	return MaskedUser{
+		USER_ID:             u.USER_ID,
+		LAST_NAME:           u.LAST_NAME,
+		FIRST_NAME:          u.FIRST_NAME,
+		MIDDLE_NAME:         u.MIDDLE_NAME,
+		EMAIL:               u.EMAIL,
+		ROLE:                u.ROLE,
+		STATUS:              u.STATUS,
+		REGION:              u.REGION,
+		LTO_CLIENT_ID:       u.LTO_CLIENT_ID,
+		DELETED_AT:          u.DELETED_AT,
+		LAST_LOGIN_AT:       u.LAST_LOGIN_AT,
+		Contact:             u.Contact,
+		Address:             u.Address,
+		MedicalInformation:  u.MedicalInformation,
+		People:              u.People,
+		PersonalInformation: u.PersonalInformation,
+	}
+}
+
+// MaskUsers returns the response view of each user
+func MaskUsers(users []User) []MaskedUser {
+	out := make([]MaskedUser, 0, len(users))
+	for _, u := range users {
+		out = append(out, u.ToMasked())
+	}
+	return out
 }
 
 type Contact struct {
@@ -82,3 +139,44 @@ type PersonalInformation struct {
 	TIN                    *string `json:"tin" db:"tin"`
 	LTO_CLIENT_ID          *string `json:"lto_client_id" db:"lto_client_id"`
 }
+
+// User roles as stored in users.role and carried in the JWT role claim
+const (
+	RoleUser    = "user"
+	RoleOfficer = "LTO Officer"
+	RoleAdmin   = "admin"
+	// RoleSuperAdmin can do everything an admin can, and may also demote
+	// other admins. It can't be granted through the API.
+	RoleSuperAdmin = "superadmin"
+)
+
+// UserProfileUpdate holds the fields a user may change on their own profile.
+// Email, password, role and LTO client ID are deliberately absent; nil fields
+// are left unchanged.
+type UserProfileUpdate struct {
+	FirstName  *string        `json:"first_name,omitempty"`
+	MiddleName *string        `json:"middle_name,omitempty"`
+	LastName   *string        `json:"last_name,omitempty"`
+	Contact    *ContactUpdate `json:"contact,omitempty"`
+	Address    *AddressUpdate `json:"address,omitempty"`
+}
+
+// ContactUpdate is the contact part of a UserProfileUpdate
+type ContactUpdate struct {
+	TelephoneNumber              *string `json:"telephone_number,omitempty"`
+	MobileNumber                 *string `json:"mobile_number,omitempty"`
+	EmergencyContactNumber       *string `json:"emergency_contact_number,omitempty"`
+	EmergencyContactName         *string `json:"emergency_contact_name,omitempty"`
+	EmergencyContactRelationship *string `json:"emergency_contact_relationship,omitempty"`
+	EmergencyContactAddress      *string `json:"emergency_contact_address,omitempty"`
+}
+
+// AddressUpdate is the address part of a UserProfileUpdate
+type AddressUpdate struct {
+	HouseNo          *string `json:"house_no,omitempty"`
+	Street           *string `json:"street,omitempty"`
+	Province         *string `json:"province,omitempty"`
+	CityMunicipality *string `json:"city_municipality,omitempty"`
+	Barangay         *string `json:"barangay,omitempty"`
+	ZipCode          *string `json:"zip_code,omitempty"`
+}
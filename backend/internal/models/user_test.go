@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMaskedUserJSON(t *testing.T) {
+	google := "google-sub-123"
+	region := "NCR"
+	login := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	user := User{
+		USER_ID:               7,
+		FIRST_NAME:            "Juan",
+		LAST_NAME:             "Dela Cruz",
+		EMAIL:                 "juan@example.com",
+		PASSWORD:              "$2a$12$secret-hash",
+		ROLE:                  RoleUser,
+		STATUS:                "active",
+		REGION:                &region,
+		LTO_CLIENT_ID:         "LTO-1",
+		GOOGLE_ID:             &google,
+		CREATED:               login.AddDate(-1, 0, 0),
+		UPDATED:               login,
+		LAST_LOGIN_AT:         &login,
+		INACTIVITY_EMAILED_AT: &login,
+	}
+
+	decode := func(v any) map[string]any {
+		t.Helper()
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]any
+		if err := json.Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	plain := decode(user)
+	masked := decode(user.ToMasked())
+
+	tests := []struct {
+		key      string
+		inUser   bool
+		inMasked bool
+	}{
+		{"password", true, false},
+		{"google_id", false, false},
+		{"created", false, false},
+		{"updated", false, false},
+		{"inactivity_emailed_at", false, false},
+		{"user_id", true, true},
+		{"email", true, true},
+		{"role", true, true},
+		{"region", true, true},
+		{"lto_client_id", true, true},
+		{"last_login_at", true, true},
+		{"contact", true, true},
+		{"personal_information", true, true},
+	}
+	for _, tt := range tests {
+		if _, ok := plain[tt.key]; ok != tt.inUser {
+			t.Errorf("User JSON has %q = %v, want %v", tt.key, ok, tt.inUser)
+		}
+		if _, ok := masked[tt.key]; ok != tt.inMasked {
+			t.Errorf("MaskedUser JSON has %q = %v, want %v", tt.key, ok, tt.inMasked)
+		}
+	}
+	if plain["password"] != user.PASSWORD {
+		t.Errorf("User JSON password = %v", plain["password"])
+	}
+	for key, value := range masked {
+		if s, ok := value.(string); ok && s == user.PASSWORD {
+			t.Errorf("MaskedUser JSON leaks the hash under %q", key)
+		}
+	}
+}
+
+func TestMaskUsers(t *testing.T) {
+	users := []User{{LTO_CLIENT_ID: "LTO-1", PASSWORD: "a"}, {LTO_CLIENT_ID: "LTO-2", PASSWORD: "b"}}
+	masked := MaskUsers(users)
+	if len(masked) != 2 || masked[0].LTO_CLIENT_ID != "LTO-1" || masked[1].LTO_CLIENT_ID != "LTO-2" {
+		t.Fatalf("MaskUsers = %+v", masked)
+	}
+	if got := MaskUsers(nil); got == nil || len(got) != 0 {
+		t.Fatalf("MaskUsers(nil) = %#v, want an empty slice", got)
+	}
+}
@@ -36,17 +36,182 @@ type Vehicle struct {
 	OR_NUMBER                string           `json:"or_number" db:"or_number"`
 	CR_NUMBER                string           `json:"cr_number" db:"cr_number"`
 	LTO_CLIENT_ID            string           `json:"lto_client_id,omitempty" db:"lto_client_id"`
+	DELETED_AT               *time.Time       `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 
 type Plate struct {
-    PlateID             string       `json:"plate_id"            db:"plate_id"`
-    VEHICLE_ID          string    `json:"vehicle_id"          db:"vehicle_id"`          // now a UUID
-    PLATE_NUMBER        string    `json:"plate_number"        db:"plate_number"`
-    PLATE_TYPE          string    `json:"plate_type"          db:"plate_type"`
-    PLATE_ISSUE_DATE    time.Time `json:"plate_issue_date"    db:"plate_issue_date"`
-    PLATE_EXPIRATION_DATE time.Time `json:"plate_expiration_date" db:"plate_expiration_date"`
-    STATUS              string    `json:"status"              db:"status"`
+    PlateID             string       `json:"plate_id"            db:"plate_id" example:"3f1c2a9e-7b4d-4c1e-9a2f-5d6e7f8a9b0c"`
+    VEHICLE_ID          string    `json:"vehicle_id"          db:"vehicle_id" example:"9b2d4e6f-1a3c-4b5d-8e7f-0a1b2c3d4e5f"`          // now a UUID
+    PLATE_NUMBER        string    `json:"plate_number"        db:"plate_number" example:"ABC 1234"`
+    PLATE_TYPE          string    `json:"plate_type"          db:"plate_type" example:"Private"`
+    PLATE_ISSUE_DATE    time.Time `json:"plate_issue_date"    db:"plate_issue_date" example:"2024-01-15T00:00:00Z"`
+    PLATE_EXPIRATION_DATE time.Time `json:"plate_expiration_date" db:"plate_expiration_date" example:"2027-01-15T00:00:00Z"`
+    STATUS              string    `json:"status"              db:"status" example:"Active"`
+}
+
+// Plate statuses
+const (
+    PlateActive      = "Active"
+    PlateExpired     = "Expired"
+    PlateDeactivated = "Deactivated"
+    PlateTemporary   = "Temporary"
+)
+
+// ValidPlateStatus reports whether s is one of the plate statuses
+func ValidPlateStatus(s string) bool {
+    switch s {
+    case PlateActive, PlateExpired, PlateDeactivated, PlateTemporary:
+        return true
+    }
+    return false
+}
+
+// PlateExpiringSoon is the computed status of a plate that expires within
+// ExpiringSoonWindow
+const PlateExpiringSoon = "Expiring Soon"
+
+// ExpiringSoonWindow is how close to its expiry a plate counts as expiring soon
+const ExpiringSoonWindow = 30 * 24 * time.Hour
+
+// clock is the time ComputedStatus measures against; tests replace it
+var clock = time.Now
+
+// ComputedStatus is the plate's standing: Deactivated for a deactivated
+// plate, otherwise Active, Expiring Soon or Expired by its expiration date.
+// It is sent as computed_status.
+func (p Plate) ComputedStatus() string {
+    return p.ComputedStatusAt(clock())
+}
+
+// ComputedStatusAt is ComputedStatus as of now
+func (p Plate) ComputedStatusAt(now time.Time) string {
+    switch {
+    case p.STATUS == PlateDeactivated:
+        return PlateDeactivated
+    case !p.PLATE_EXPIRATION_DATE.After(now):
+        return PlateExpired
+    case p.PLATE_EXPIRATION_DATE.Sub(now) <= ExpiringSoonWindow:
+        return PlateExpiringSoon
+    }
+    return PlateActive
+}
+
+// plateFields is Plate without its JSON methods, so encoding it doesn't recurse
+type plateFields Plate
+
+// plateJSON is a Plate as sent to clients
+type plateJSON struct {
+    plateFields
+    ComputedStatus string `json:"computed_status"`
+}
+
+func (p Plate) toJSON() plateJSON {
+    return plateJSON{plateFields(p), p.ComputedStatus()}
+}
+
+// MarshalJSON adds the read-only computed_status to the plate's fields
+func (p Plate) MarshalJSON() ([]byte, error) {
+    return json.Marshal(p.toJSON())
+}
+
+// UnmarshalJSON reads the plate's fields; computed_status is derived, so a
+// value sent by the client is ignored
+func (p *Plate) UnmarshalJSON(b []byte) error {
+    return json.Unmarshal(b, (*plateFields)(p))
+}
+
+// PlateHistory is a snapshot of a plate taken before it was changed
+type PlateHistory struct {
+    HistoryID string    `json:"history_id" db:"history_id" example:"5a6b7c8d-9e0f-4a1b-8c2d-3e4f5a6b7c8d"`
+    Plate
+    ChangedAt time.Time `json:"changed_at" db:"changed_at" example:"2024-06-01T08:30:00Z"`
+    ChangedBy string    `json:"changed_by" db:"changed_by" example:"LTO-2024-000123"`
+    Reason    string    `json:"reason" db:"reason" example:"Vehicle reported stolen"` // why it was changed, when given
+}
+
+// MarshalJSON keeps the history fields, which Plate's promoted MarshalJSON
+// would otherwise drop
+func (h PlateHistory) MarshalJSON() ([]byte, error) {
+    return json.Marshal(struct {
+        HistoryID string `json:"history_id"`
+        plateJSON
+        ChangedAt time.Time `json:"changed_at"`
+        ChangedBy string    `json:"changed_by"`
+        Reason    string    `json:"reason"`
+    }{h.HistoryID, h.Plate.toJSON(), h.ChangedAt, h.ChangedBy, h.Reason})
+}
+
+// PlateTransfer records a plate being moved from one vehicle to another
+type PlateTransfer struct {
+    TransferID    string    `json:"transfer_id"     db:"transfer_id" example:"7c8d9e0f-1a2b-4c3d-9e4f-5a6b7c8d9e0f"`
+    PlateID       string    `json:"plate_id"        db:"plate_id" example:"3f1c2a9e-7b4d-4c1e-9a2f-5d6e7f8a9b0c"`
+    FromVehicleID string    `json:"from_vehicle_id" db:"from_vehicle_id" example:"9b2d4e6f-1a3c-4b5d-8e7f-0a1b2c3d4e5f"`
+    ToVehicleID   string    `json:"to_vehicle_id"   db:"to_vehicle_id" example:"1e2f3a4b-5c6d-4e7f-8a9b-0c1d2e3f4a5b"`
+    TransferredBy string    `json:"transferred_by"  db:"transferred_by" example:"LTO-2024-000123"`
+    TransferredAt time.Time `json:"transferred_at"  db:"transferred_at" example:"2024-06-01T08:30:00Z"`
+}
+
+// PlateExpiryCohorts counts plates by when they expire
+type PlateExpiryCohorts struct {
+    ThisMonth int `json:"this_month" example:"12"`
+    NextMonth int `json:"next_month" example:"30"`
+    ThisYear  int `json:"this_year" example:"240"`
+}
+
+// PlateStats aggregates plate counts for the admin dashboard
+type PlateStats struct {
+    Total    int                `json:"total" example:"1500"`
+    ByStatus map[string]int     `json:"by_status"`
+    ByType   map[string]int     `json:"by_type"`
+    ByRegion map[string]int     `json:"by_region"` // keyed by region prefix letter
+    ByExpiry PlateExpiryCohorts `json:"by_expiry"`
+}
+
+// PlateSearchResult is a plate joined with its owner's name and vehicle type
+type PlateSearchResult struct {
+    Plate
+    OwnerName   string `json:"owner_name"   db:"owner_name" example:"Juan Dela Cruz"`
+    VehicleType string `json:"vehicle_type" db:"vehicle_type" example:"4-Wheel"`
+}
+
+// MarshalJSON keeps the owner name and vehicle type, which Plate's promoted
+// MarshalJSON would otherwise drop
+func (r PlateSearchResult) MarshalJSON() ([]byte, error) {
+    return json.Marshal(struct {
+        plateJSON
+        OwnerName   string `json:"owner_name"`
+        VehicleType string `json:"vehicle_type"`
+    }{r.Plate.toJSON(), r.OwnerName, r.VehicleType})
+}
+
+type PlateRenewalHistory struct {
+    RenewalID              string    `json:"renewal_id"               db:"renewal_id"`
+    PlateID                string    `json:"plate_id"                 db:"plate_id"`
+    PreviousExpirationDate time.Time `json:"previous_expiration_date" db:"previous_expiration_date"`
+    NewExpirationDate      time.Time `json:"new_expiration_date"      db:"new_expiration_date"`
+    RenewedAt              time.Time `json:"renewed_at"               db:"renewed_at"`
+}
+
+type VehicleInspection struct {
+    InspectionID      string    `json:"inspection_id"       db:"inspection_id"`
+    VehicleID         string    `json:"vehicle_id"          db:"vehicle_id"`
+    InspectorLTOID    string    `json:"inspector_lto_id"    db:"inspector_lto_id"`
+    InspectedAt       time.Time `json:"inspected_at"        db:"inspected_at"`
+    Result            string    `json:"result"              db:"result"` // Pass, Fail
+    Remarks           string    `json:"remarks"             db:"remarks"`
+    NextInspectionDue time.Time `json:"next_inspection_due" db:"next_inspection_due"`
+}
+
+// VehicleDocument is a file stored in S3 for a vehicle. The client PUTs the
+// file to S3 itself; the row is written once it confirms the upload.
+type VehicleDocument struct {
+    DocumentID string    `json:"document_id" db:"document_id"`
+    VehicleID  string    `json:"vehicle_id"  db:"vehicle_id"`
+    DocType    string    `json:"doc_type"    db:"doc_type"`
+    S3Key      string    `json:"s3_key"      db:"s3_key"`
+    UploadedAt time.Time `json:"uploaded_at" db:"uploaded_at"`
+    UploadedBy string    `json:"uploaded_by" db:"uploaded_by"`
 }
 
 type RegistrationForm struct {
@@ -57,6 +222,8 @@ type RegistrationForm struct {
     Status             string    `db:"status"                json:"status"`
     Region             string    `db:"region"               json:"region"`
     RegistrationType   string    `db:"registration_type"     json:"registration_type"`
+
+    lastTransition *FormStateHistory // set by TransitionTo
 }
 type RegistrationInspection struct {
     InspectionID        string    `db:"inspection_id"         json:"inspection_id"`
@@ -99,4 +266,13 @@ type CreateRegistrationFormParams struct {
 
 type UpdateRegistrationFormParams struct {
     Status string `json:"status" db:"status"`
-}
\ No newline at end of file
+}
+// PlatePoolUsage summarises how much of a region's plate sequences have been
+// reserved, counting only the letter prefixes opened so far
+type PlatePoolUsage struct {
+    Region         string  `json:"region" db:"region" example:"NCR"`
+    Prefixes       int     `json:"prefixes" db:"prefixes" example:"12"`
+    Issued         int     `json:"issued" db:"issued" example:"5400"`
+    Capacity       int     `json:"capacity" db:"capacity" example:"108000"`
+    UtilizationPct float64 `json:"utilization_pct" db:"-" example:"5"`
+}
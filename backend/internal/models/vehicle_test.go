@@ -0,0 +1,130 @@
+package models
+
+import (
+    "encoding/json"
+    "testing"
+    "time"
+)
+
+// fixClock pins ComputedStatus to now for the rest of the test
+func fixClock(t *testing.T, now time.Time) {
+    t.Helper()
+    old := clock
+    clock = func() time.Time { return now }
+    t.Cleanup(func() { clock = old })
+}
+
+func TestPlateComputedStatus(t *testing.T) {
+    now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+    fixClock(t, now)
+
+    tests := []struct {
+        name    string
+        expires time.Time
+        want    string
+    }{
+        {"expired last year", now.AddDate(-1, 0, 0), PlateExpired},
+        {"expires this instant", now, PlateExpired},
+        {"expires in a second", now.Add(time.Second), PlateExpiringSoon},
+        {"expires in a week", now.AddDate(0, 0, 7), PlateExpiringSoon},
+        {"expires in exactly 30 days", now.Add(ExpiringSoonWindow), PlateExpiringSoon},
+        {"expires in 30 days and a second", now.Add(ExpiringSoonWindow + time.Second), PlateActive},
+        {"expires in three years", now.AddDate(3, 0, 0), PlateActive},
+        {"other zone, same instant", now.In(time.FixedZone("PHT", 8*60*60)), PlateExpired},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            p := Plate{PLATE_EXPIRATION_DATE: tt.expires, STATUS: PlateActive}
+            if got := p.ComputedStatus(); got != tt.want {
+                t.Fatalf("ComputedStatus() = %q, want %q", got, tt.want)
+            }
+            if got := p.ComputedStatusAt(now); got != tt.want {
+                t.Fatalf("ComputedStatusAt(now) = %q, want %q", got, tt.want)
+            }
+
+            b, err := json.Marshal(p)
+            if err != nil {
+                t.Fatal(err)
+            }
+            var fields map[string]interface{}
+            if err := json.Unmarshal(b, &fields); err != nil {
+                t.Fatal(err)
+            }
+            if fields["computed_status"] != tt.want {
+                t.Fatalf("computed_status = %v, want %q", fields["computed_status"], tt.want)
+            }
+            if fields["status"] != PlateActive {
+                t.Fatalf("stored status = %v, want it left alone", fields["status"])
+            }
+        })
+    }
+}
+
+func TestDeactivatedPlateComputedStatus(t *testing.T) {
+    now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+    fixClock(t, now)
+
+    // deactivation outranks whatever the expiry date says
+    for _, expires := range []time.Time{now.AddDate(-1, 0, 0), now.AddDate(0, 0, 7), now.AddDate(3, 0, 0)} {
+        p := Plate{PLATE_EXPIRATION_DATE: expires, STATUS: PlateDeactivated}
+        if got := p.ComputedStatus(); got != PlateDeactivated {
+            t.Errorf("expiring %s: ComputedStatus() = %q, want %q", expires.Format(time.DateOnly), got, PlateDeactivated)
+        }
+    }
+}
+
+func TestPlateComputedStatusIsReadOnly(t *testing.T) {
+    fixClock(t, time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+
+    var p Plate
+    body := `{"plate_id":"p1","plate_number":"ABC 1234","status":"Active",
+        "plate_expiration_date":"2020-01-01T00:00:00Z","computed_status":"Active"}`
+    if err := json.Unmarshal([]byte(body), &p); err != nil {
+        t.Fatal(err)
+    }
+    if p.PlateID != "p1" || p.PLATE_NUMBER != "ABC 1234" || p.STATUS != PlateActive {
+        t.Fatalf("fields not read: %+v", p)
+    }
+    if got := p.ComputedStatus(); got != PlateExpired {
+        t.Fatalf("ComputedStatus() = %q after the client claimed Active, want Expired", got)
+    }
+}
+
+func TestEmbeddedPlateJSON(t *testing.T) {
+    now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+    fixClock(t, now)
+    plate := Plate{PlateID: "p1", PLATE_EXPIRATION_DATE: now.AddDate(0, 0, 10)}
+
+    tests := []struct {
+        name  string
+        value interface{}
+        keys  []string
+    }{
+        {"history", PlateHistory{HistoryID: "h1", Plate: plate, ChangedBy: "LTO-1", Reason: "sold"},
+            []string{"history_id", "changed_at", "changed_by", "reason"}},
+        {"search result", PlateSearchResult{Plate: plate, OwnerName: "Juan Dela Cruz", VehicleType: "4-Wheel"},
+            []string{"owner_name", "vehicle_type"}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            b, err := json.Marshal(tt.value)
+            if err != nil {
+                t.Fatal(err)
+            }
+            var fields map[string]interface{}
+            if err := json.Unmarshal(b, &fields); err != nil {
+                t.Fatal(err)
+            }
+            if fields["computed_status"] != PlateExpiringSoon || fields["plate_id"] != "p1" {
+                t.Fatalf("plate fields missing from %s", b)
+            }
+            for _, k := range tt.keys {
+                if _, ok := fields[k]; !ok {
+                    t.Errorf("%s missing from %s", k, b)
+                }
+            }
+        })
+    }
+}
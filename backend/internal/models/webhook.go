@@ -0,0 +1,53 @@
+package models
+
+import (
+    "time"
+
+    "github.com/lib/pq"
+)
+
+// Scan events an external system can subscribe a webhook to
+const (
+    WebhookEventScanCompleted = "scan.completed" // every scan that was logged
+    WebhookEventScanExpired   = "scan.expired"   // a logged scan of an expired plate
+)
+
+// WebhookEvents are the event types a webhook may subscribe to
+var WebhookEvents = map[string]bool{
+    WebhookEventScanCompleted: true,
+    WebhookEventScanExpired:   true,
+}
+
+// WebhookConfig is an external endpoint scan results are POSTed to. Each
+// delivery is signed with Secret, which is never sent back out once set.
+type WebhookConfig struct {
+    WebhookID  string         `json:"webhook_id"  db:"webhook_id"`
+    URL        string         `json:"url"         db:"url"`
+    Secret     string         `json:"-"           db:"secret"`
+    EventTypes pq.StringArray `json:"event_types" db:"event_types" swaggertype:"array,string"`
+    Active     bool           `json:"active"      db:"active"`
+    CreatedAt  time.Time      `json:"created_at"  db:"created_at"`
+    UpdatedAt  time.Time      `json:"updated_at"  db:"updated_at"`
+}
+
+// Subscribes reports whether w wants event
+func (w WebhookConfig) Subscribes(event string) bool {
+    for _, e := range w.EventTypes {
+        if e == event {
+            return true
+        }
+    }
+    return false
+}
+
+// WebhookDelivery is one attempt to POST an event to a webhook. StatusCode
+// is nil when no response came back, in which case Error says why.
+type WebhookDelivery struct {
+    DeliveryID  string    `json:"delivery_id"  db:"delivery_id"`
+    WebhookID   string    `json:"webhook_id"   db:"webhook_id"`
+    EventType   string    `json:"event_type"   db:"event_type"`
+    Attempt     int       `json:"attempt"      db:"attempt"`
+    StatusCode  *int      `json:"status_code"  db:"status_code"`
+    Error       *string   `json:"error"        db:"error"`
+    AttemptedAt time.Time `json:"attempted_at" db:"attempted_at"`
+}
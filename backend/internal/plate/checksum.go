@@ -0,0 +1,72 @@
+package plate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Plates issued by SmartPlate end in a space, a four-digit sequence and a
+// Luhn check digit, e.g. "ABC 12348". Legacy and externally issued plates
+// keep the bare four-digit sequence, so the digit is only checked when present.
+
+// sequenceDigits is the length of the numeric sequence before the check digit
+const sequenceDigits = 4
+
+// numericSuffix returns the digits after the last space in number, or false
+// when that part is empty or not all digits
+func numericSuffix(number string) (string, bool) {
+	i := strings.LastIndexByte(number, ' ')
+	if i < 0 || i == len(number)-1 {
+		return "", false
+	}
+	suffix := number[i+1:]
+	for _, r := range suffix {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return suffix, true
+}
+
+// luhnDigit computes the Luhn check digit for digits
+func luhnDigit(digits string) byte {
+	sum := 0
+	double := true // the digit next to the check digit is doubled
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// AppendChecksum appends the check digit for the plate's four-digit sequence
+func AppendChecksum(plateNumber string) (string, error) {
+	seq, ok := numericSuffix(plateNumber)
+	if !ok || len(seq) != sequenceDigits {
+		return "", fmt.Errorf("plate number %q does not end in a %d-digit sequence", plateNumber, sequenceDigits)
+	}
+	return plateNumber + string(luhnDigit(seq)), nil
+}
+
+// HasChecksum reports whether plateNumber carries a check digit after its sequence
+func HasChecksum(plateNumber string) bool {
+	seq, ok := numericSuffix(plateNumber)
+	return ok && len(seq) == sequenceDigits+1
+}
+
+// VerifyChecksum reports whether plateNumber ends in a sequence followed by
+// its correct check digit
+func VerifyChecksum(plateNumber string) bool {
+	if !HasChecksum(plateNumber) {
+		return false
+	}
+	seq, _ := numericSuffix(plateNumber)
+	return luhnDigit(seq[:sequenceDigits]) == seq[sequenceDigits]
+}
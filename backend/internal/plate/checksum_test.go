@@ -0,0 +1,109 @@
+package plate
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAppendChecksum(t *testing.T) {
+	tests := []struct {
+		number  string
+		want    string
+		wantErr bool
+	}{
+		{"ABC 1234", "ABC 12344", false},
+		{"ABC 1000", "ABC 10009", false},
+		{"ABC 9999", "ABC 99994", false},
+		{"PWD-AAB 1234", "PWD-AAB 12344", false},
+		{"ABC 0000", "ABC 00000", false},
+		{"ABC 123", "", true},
+		{"ABC 12345", "", true},
+		{"ABC 12A4", "", true},
+		{"ABC ", "", true},
+		{"ABC1234", "", true},
+		{"USA-1234", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			got, err := AppendChecksum(tt.number)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AppendChecksum(%q) error = %v, wantErr %v", tt.number, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("AppendChecksum(%q) = %q, want %q", tt.number, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	tests := []struct {
+		number       string
+		wantHas      bool
+		wantVerified bool
+	}{
+		{"ABC 12344", true, true},
+		{"ABC 99994", true, true},
+		{"PWD-AAB 12344", true, true},
+		{"ABC 12345", true, false},
+		{"ABC 12340", true, false},
+		{"ABC 1234", false, false}, // legacy, no check digit
+		{"ABC 123456", false, false},
+		{"USA-1234", false, false},
+		{"A-123", false, false},
+		{"", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			if got := HasChecksum(tt.number); got != tt.wantHas {
+				t.Errorf("HasChecksum(%q) = %v, want %v", tt.number, got, tt.wantHas)
+			}
+			if got := VerifyChecksum(tt.number); got != tt.wantVerified {
+				t.Errorf("VerifyChecksum(%q) = %v, want %v", tt.number, got, tt.wantVerified)
+			}
+		})
+	}
+}
+
+// TestChecksumCatchesTypos checks every sequence against the typos the
+// digit is meant to catch: any single wrong digit, and swapping two
+// neighbours (Luhn misses only 09 <-> 90)
+func TestChecksumCatchesTypos(t *testing.T) {
+	for seq := 1000; seq <= 9999; seq++ {
+		number, err := AppendChecksum(fmt.Sprintf("ABC %d", seq))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyChecksum(number) {
+			t.Fatalf("%q doesn't verify", number)
+		}
+
+		digits := []byte(number[4:])
+		for i := range digits {
+			for d := byte('0'); d <= '9'; d++ {
+				if d == digits[i] {
+					continue
+				}
+				typo := append([]byte(nil), digits...)
+				typo[i] = d
+				if VerifyChecksum("ABC " + string(typo)) {
+					t.Fatalf("%q verifies after changing digit %d of %q", typo, i, number)
+				}
+			}
+			if i+1 < len(digits) && digits[i] != digits[i+1] {
+				pair := string(digits[i : i+2])
+				if pair == "09" || pair == "90" {
+					continue
+				}
+				swapped := append([]byte(nil), digits...)
+				swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+				if VerifyChecksum("ABC " + string(swapped)) {
+					t.Fatalf("%q verifies after swapping digits %d and %d of %q", swapped, i, i+1, number)
+				}
+			}
+		}
+	}
+}
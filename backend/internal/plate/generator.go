@@ -1,10 +1,16 @@
 package plate
 
 import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"math/rand"
+	"regexp"
+	"sort"
 	"strconv"
-	"time"
+	"strings"
+	"sync"
 )
 
 // Region prefixes mapping
@@ -25,17 +31,103 @@ var regionPrefixes = map[string]string{
 	"SOCCSKSARGEN":     "P",
 	"ZAMBOANGA":        "R",
 	"BARMM":            "S",
+	"ARMM":             "S", // pre-BARMM name, still found in legacy records
 }
 
 const lettersPool = "ABCDEFGHJKLMNPRSTUVWXYZ"
 
-func init() {
-	// seed once when package is imported
-	rand.Seed(time.Now().UnixNano())
+// maxGenerateAttempts bounds how many candidates Generate tries before giving up
+const maxGenerateAttempts = 10
+
+// PlateRepository is the lookup Generate needs to check for collisions
+type PlateRepository interface {
+	ExistsWithPlateNumber(ctx context.Context, number string) (bool, error)
+}
+
+// SequencePool reserves four-wheel plate sequences in order per region and
+// letter prefix; repository.PlatePoolRepository implements it
+type SequencePool interface {
+	NextSequence(ctx context.Context, region, prefix string) (int, error)
+}
+
+// Generator produces plate numbers from its own random source, so a fixed
+// seed gives a reproducible sequence. It is safe for concurrent use.
+type Generator struct {
+	mu   sync.Mutex
+	rnd  *rand.Rand
+	pool SequencePool
+}
+
+// NewGenerator returns a Generator seeded with seed
+func NewGenerator(seed int64) *Generator {
+	return &Generator{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// DefaultGenerator is the Generator used for issuing real plates, seeded from crypto/rand
+var DefaultGenerator = NewGenerator(cryptoSeed())
+
+func cryptoSeed() int64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("plate: seed generator: %v", err))
+	}
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+// SetPool makes g take four-wheel sequences from pool. Without one, or when
+// the pool is busy, exhausted or unreachable, sequences are random.
+func (g *Generator) SetPool(pool SequencePool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pool = pool
 }
 
 // GeneratePlateNumber returns a Philippine-style plate based on vehicleType, plateType and region.
-func GeneratePlateNumber(vehicleType, plateType, region string) string {
+func (g *Generator) GeneratePlateNumber(vehicleType, plateType, region string) string {
+	return g.generate(context.Background(), vehicleType, plateType, region)
+}
+
+func (g *Generator) generate(ctx context.Context, vehicleType, plateType, region string) string {
+	g.mu.Lock()
+	letters, complete := g.letters(vehicleType, plateType, region)
+	pool := g.pool
+	g.mu.Unlock()
+	if complete != "" {
+		return complete
+	}
+
+	seq := 0
+	if pool != nil {
+		if n, err := pool.NextSequence(ctx, poolRegion(region), letters); err == nil {
+			seq = n
+		}
+	}
+	if seq == 0 {
+		g.mu.Lock()
+		seq = g.rnd.Intn(9000) + 1000
+		g.mu.Unlock()
+	}
+	// seq always has four digits, so AppendChecksum can't fail here
+	number, _ := AppendChecksum(fmt.Sprintf("%s %d", letters, seq))
+	return number
+}
+
+// poolRegion is the region a pool is kept under: unknown regions fall back
+// to NCR like their prefix does, and ARMM shares BARMM's number space
+func poolRegion(region string) string {
+	if region == "ARMM" {
+		return "BARMM"
+	}
+	if _, ok := regionPrefixes[region]; !ok {
+		return "NCR"
+	}
+	return region
+}
+
+// letters picks the letter part of a four-wheel plate. Formats without a
+// pooled sequence (motorcycles, diplomatic) come back whole in complete.
+// The caller must hold g.mu.
+func (g *Generator) letters(vehicleType, plateType, region string) (letters, complete string) {
 	pref, ok := regionPrefixes[region]
 	if !ok {
 		pref = regionPrefixes["NCR"]
@@ -43,15 +135,15 @@ func GeneratePlateNumber(vehicleType, plateType, region string) string {
 
 	// special case: motorcycle
 	if vehicleType == "2-Wheel" {
-		num := rand.Intn(9000) + 1000 // 1000–9999
-		if rand.Float64() > 0.5 {
+		num := g.rnd.Intn(9000) + 1000 // 1000–9999
+		if g.rnd.Float64() > 0.5 {
 			// L-NNN
-			return fmt.Sprintf("%s-%s", pref, strconv.Itoa(num)[:3])
+			return "", fmt.Sprintf("%s-%s", pref, strconv.Itoa(num)[:3])
 		}
 		// LL-NNNNN
-		sec := lettersPool[rand.Intn(len(lettersPool))]
-		five := rand.Intn(90000) + 10000
-		return fmt.Sprintf("%s%c-%d", pref, sec, five)
+		sec := lettersPool[g.rnd.Intn(len(lettersPool))]
+		five := g.rnd.Intn(90000) + 10000
+		return "", fmt.Sprintf("%s%c-%d", pref, sec, five)
 	}
 
 	// 4-wheelers
@@ -59,34 +151,131 @@ func GeneratePlateNumber(vehicleType, plateType, region string) string {
 	switch plateType {
 	case "Diplomatic":
 		codes := []string{"USA", "JPN", "KOR", "CHN", "GBR", "AUS"}
-		cc := codes[rand.Intn(len(codes))]
-		return fmt.Sprintf("%s-%d", cc, rand.Intn(9000)+1000)
+		cc := codes[g.rnd.Intn(len(codes))]
+		return "", fmt.Sprintf("%s-%d", cc, g.rnd.Intn(9000)+1000)
 	case "Government":
 		L2 = "S"
-		L3 = string(lettersPool[rand.Intn(len(lettersPool))])
+		L3 = string(lettersPool[g.rnd.Intn(len(lettersPool))])
 	case "Electric":
 		a2 := "ABCDEFGHJKLM"
-		L2 = string(a2[rand.Intn(len(a2))])
-		L3 = string("VWXYZ"[rand.Intn(5)])
+		L2 = string(a2[g.rnd.Intn(len(a2))])
+		L3 = string("VWXYZ"[g.rnd.Intn(5)])
 	case "Hybrid":
 		h2 := "NPRSTUVWXYZ"
-		L2 = string(h2[rand.Intn(len(h2))])
-		L3 = string("VWXYZ"[rand.Intn(5)])
+		L2 = string(h2[g.rnd.Intn(len(h2))])
+		L3 = string("VWXYZ"[g.rnd.Intn(5)])
 	case "Trailer":
 		L2 = "U"
-		L3 = string(lettersPool[rand.Intn(len(lettersPool))])
+		L3 = string(lettersPool[g.rnd.Intn(len(lettersPool))])
+	case "Concessionary": // PWD plates
+		L2 = string(lettersPool[g.rnd.Intn(len(lettersPool))])
+		L3 = string(lettersPool[g.rnd.Intn(len(lettersPool))])
+		pref = "PWD-" + pref
 	case "Vintage":
-		L2 = string(lettersPool[rand.Intn(len(lettersPool))])
+		L2 = string(lettersPool[g.rnd.Intn(len(lettersPool))])
 		sufs := []string{"TX", "TY", "TZ"}
-		L3 = sufs[rand.Intn(len(sufs))]
+		L3 = sufs[g.rnd.Intn(len(sufs))]
 	case "For Hire", "PublicUtility":
-		L2 = string(lettersPool[rand.Intn(len(lettersPool))])
-		L3 = string(lettersPool[rand.Intn(len(lettersPool))])
+		L2 = string(lettersPool[g.rnd.Intn(len(lettersPool))])
+		L3 = string(lettersPool[g.rnd.Intn(len(lettersPool))])
 	default: // Private
-		L2 = string(lettersPool[rand.Intn(len(lettersPool))])
-		L3 = string(lettersPool[rand.Intn(len(lettersPool))])
+		L2 = string(lettersPool[g.rnd.Intn(len(lettersPool))])
+		L3 = string(lettersPool[g.rnd.Intn(len(lettersPool))])
+	}
+
+	return pref + L2 + L3, ""
+}
+
+// Generate returns a plate number that isn't already issued, retrying
+// GeneratePlateNumber until repo reports no collision.
+func (g *Generator) Generate(ctx context.Context, vehicleType, plateType, region string, repo PlateRepository) (string, error) {
+	for i := 0; i < maxGenerateAttempts; i++ {
+		candidate := g.generate(ctx, vehicleType, plateType, region)
+		exists, err := repo.ExistsWithPlateNumber(ctx, candidate)
+		if err != nil {
+			return "", fmt.Errorf("check plate number %q: %w", candidate, err)
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("could not generate a unique %s/%s plate number for region %s after %d attempts",
+		vehicleType, plateType, region, maxGenerateAttempts)
+}
+
+// charClass turns a pool of letters into a regexp character class
+func charClass(pool string) string {
+	return "[" + pool + "]"
+}
+
+// regionClass is the character class of every known region prefix
+func regionClass() string {
+	seen := make(map[string]bool, len(regionPrefixes))
+	prefs := make([]string, 0, len(regionPrefixes))
+	for _, p := range regionPrefixes {
+		if !seen[p] {
+			seen[p] = true
+			prefs = append(prefs, p)
+		}
+	}
+	sort.Strings(prefs)
+	return charClass(strings.Join(prefs, ""))
+}
+
+// platePatterns mirror the formats produced by GeneratePlateNumber, keyed by plateType.
+// Two-wheelers are matched separately since their format ignores plateType.
+// Sequences may omit the check digit so legacy plates still validate.
+var (
+	motorcyclePattern = regexp.MustCompile(
+		`^` + regionClass() + `-[1-9]\d{2}$|^` + regionClass() + charClass(lettersPool) + `-[1-9]\d{4}$`)
+
+	platePatterns = map[string]*regexp.Regexp{
+		"Diplomatic":    regexp.MustCompile(`^(USA|JPN|KOR|CHN|GBR|AUS)-[1-9]\d{3}$`),
+		"Government":    regexp.MustCompile(`^` + regionClass() + `S` + charClass(lettersPool) + ` [1-9]\d{3}\d?$`),
+		"Electric":      regexp.MustCompile(`^` + regionClass() + charClass("ABCDEFGHJKLM") + charClass("VWXYZ") + ` [1-9]\d{3}\d?$`),
+		"Hybrid":        regexp.MustCompile(`^` + regionClass() + charClass("NPRSTUVWXYZ") + charClass("VWXYZ") + ` [1-9]\d{3}\d?$`),
+		"Trailer":       regexp.MustCompile(`^` + regionClass() + `U` + charClass(lettersPool) + ` [1-9]\d{3}\d?$`),
+		"Concessionary": regexp.MustCompile(`^PWD-` + regionClass() + charClass(lettersPool) + `{2} [1-9]\d{3}\d?$`),
+		"Vintage":       regexp.MustCompile(`^` + regionClass() + charClass(lettersPool) + `T[XYZ] [1-9]\d{3}\d?$`),
+		"For Hire":      regexp.MustCompile(`^` + regionClass() + charClass(lettersPool) + `{2} [1-9]\d{3}\d?$`),
+		"PublicUtility": regexp.MustCompile(`^` + regionClass() + charClass(lettersPool) + `{2} [1-9]\d{3}\d?$`),
+		"Private":       regexp.MustCompile(`^` + regionClass() + charClass(lettersPool) + `{2} [1-9]\d{3}\d?$`),
+	}
+)
+
+// ValidateAnyPlateNumber checks that number has one of the LTO plate formats,
+// for lookups where the vehicle and plate type aren't known yet.
+func ValidateAnyPlateNumber(number string) error {
+	if ValidatePlateNumber("2-Wheel", "", number) == nil {
+		return nil
+	}
+	for plateType := range platePatterns {
+		if platePatterns[plateType].MatchString(number) {
+			return ValidatePlateNumber("", plateType, number)
+		}
+	}
+	return fmt.Errorf("plate number %q is not a valid LTO plate number", number)
+}
+
+// ValidatePlateNumber checks that number has the LTO format GeneratePlateNumber
+// would produce for the given vehicleType and plateType.
+func ValidatePlateNumber(vehicleType, plateType, number string) error {
+	if vehicleType == "2-Wheel" {
+		if !motorcyclePattern.MatchString(number) {
+			return fmt.Errorf("plate number %q is not a valid motorcycle plate (expected e.g. A-123 or AB-12345)", number)
+		}
+		return nil
 	}
 
-	seq := rand.Intn(9000) + 1000
-	return fmt.Sprintf("%s%s%s %d", pref, L2, L3, seq)
+	re, ok := platePatterns[plateType]
+	if !ok {
+		re = platePatterns["Private"] // GeneratePlateNumber's default branch
+	}
+	if !re.MatchString(number) {
+		return fmt.Errorf("plate number %q does not match the %s plate format", number, plateType)
+	}
+	if HasChecksum(number) && !VerifyChecksum(number) {
+		return fmt.Errorf("plate number %q has an incorrect check digit", number)
+	}
+	return nil
 }
@@ -0,0 +1,265 @@
+package plate
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestValidatePlateNumberAcceptsGenerated(t *testing.T) {
+	tests := []struct {
+		vehicleType string
+		plateType   string
+	}{
+		{"2-Wheel", "Private"},
+		{"4-Wheel", "Diplomatic"},
+		{"4-Wheel", "Government"},
+		{"4-Wheel", "Electric"},
+		{"4-Wheel", "Hybrid"},
+		{"4-Wheel", "Trailer"},
+		{"4-Wheel", "Concessionary"},
+		{"4-Wheel", "Vintage"},
+		{"4-Wheel", "For Hire"},
+		{"4-Wheel", "PublicUtility"},
+		{"4-Wheel", "Private"},
+		{"4-Wheel", ""}, // default branch
+	}
+	regions := []string{"NCR", "CALABARZON", "BARMM", "ARMM", "NOWHERE"}
+
+	g := NewGenerator(42)
+	for _, tt := range tests {
+		t.Run(tt.vehicleType+"/"+tt.plateType, func(t *testing.T) {
+			for _, region := range regions {
+				for i := 0; i < 50; i++ {
+					number := g.GeneratePlateNumber(tt.vehicleType, tt.plateType, region)
+					if err := ValidatePlateNumber(tt.vehicleType, tt.plateType, number); err != nil {
+						t.Fatalf("generated %q for region %s: %v", number, region, err)
+					}
+					if err := ValidateAnyPlateNumber(number); err != nil {
+						t.Fatalf("ValidateAnyPlateNumber(%q): %v", number, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestValidatePlateNumber(t *testing.T) {
+	tests := []struct {
+		name        string
+		vehicleType string
+		plateType   string
+		number      string
+		wantErr     bool
+	}{
+		{"private legacy", "4-Wheel", "Private", "ABC 1234", false},
+		{"private with check digit", "4-Wheel", "Private", "ABC 12344", false},
+		{"unknown type falls back to private", "4-Wheel", "Custom", "ABC 1234", false},
+		{"government", "4-Wheel", "Government", "ASB 1234", false},
+		{"electric", "4-Wheel", "Electric", "BAV 1234", false},
+		{"hybrid", "4-Wheel", "Hybrid", "CNZ 1234", false},
+		{"trailer", "4-Wheel", "Trailer", "DUA 1234", false},
+		{"concessionary", "4-Wheel", "Concessionary", "PWD-AAB 1234", false},
+		{"vintage", "4-Wheel", "Vintage", "ABTX 1234", false},
+		{"for hire", "4-Wheel", "For Hire", "NAB 1234", false},
+		{"public utility", "4-Wheel", "PublicUtility", "NAB 1234", false},
+		{"diplomatic", "4-Wheel", "Diplomatic", "USA-1234", false},
+		{"motorcycle short", "2-Wheel", "", "A-123", false},
+		{"motorcycle long", "2-Wheel", "", "AB-12345", false},
+
+		{"lowercase letters", "4-Wheel", "Private", "abc 1234", true},
+		{"dash separator", "4-Wheel", "Private", "ABC-1234", true},
+		{"no separator", "4-Wheel", "Private", "ABC1234", true},
+		{"double space", "4-Wheel", "Private", "ABC  1234", true},
+		{"surrounding spaces", "4-Wheel", "Private", " ABC 1234 ", true},
+		{"leading zero", "4-Wheel", "Private", "ABC 0123", true},
+		{"three digits", "4-Wheel", "Private", "ABC 123", true},
+		{"six digits", "4-Wheel", "Private", "ABC 123456", true},
+		{"unknown region prefix", "4-Wheel", "Private", "QBC 1234", true},
+		{"letter outside pool", "4-Wheel", "Private", "AIO 1234", true},
+		{"wrong check digit", "4-Wheel", "Private", "ABC 12345", true},
+		{"government without S", "4-Wheel", "Government", "ABC 1234", true},
+		{"electric wrong suffix", "4-Wheel", "Electric", "BAA 1234", true},
+		{"hybrid wrong middle", "4-Wheel", "Hybrid", "CAZ 1234", true},
+		{"trailer without U", "4-Wheel", "Trailer", "DAA 1234", true},
+		{"concessionary without PWD", "4-Wheel", "Concessionary", "AAB 1234", true},
+		{"vintage wrong suffix", "4-Wheel", "Vintage", "ABTA 1234", true},
+		{"diplomatic unknown country", "4-Wheel", "Diplomatic", "FRA-1234", true},
+		{"diplomatic space separator", "4-Wheel", "Diplomatic", "USA 1234", true},
+		{"motorcycle lowercase", "2-Wheel", "", "a-123", true},
+		{"motorcycle space separator", "2-Wheel", "", "A 123", true},
+		{"motorcycle four digits", "2-Wheel", "", "A-1234", true},
+		{"empty", "4-Wheel", "Private", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePlateNumber(tt.vehicleType, tt.plateType, tt.number)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidatePlateNumber(%q, %q, %q) = %v, wantErr %v",
+					tt.vehicleType, tt.plateType, tt.number, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAnyPlateNumber(t *testing.T) {
+	tests := []struct {
+		number  string
+		wantErr bool
+	}{
+		{"ABC 1234", false},
+		{"ABC 12344", false},
+		{"PWD-AAB 1234", false},
+		{"USA-1234", false},
+		{"A-123", false},
+		{"AB-12345", false},
+		{"ABC 12345", true},
+		{"abc 1234", true},
+		{"ABC_1234", true},
+		{"1234 ABC", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			err := ValidateAnyPlateNumber(tt.number)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateAnyPlateNumber(%q) = %v, wantErr %v", tt.number, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateConcessionary(t *testing.T) {
+	tests := []struct {
+		region string
+		prefix string
+	}{
+		{"NCR", "PWD-A"},
+		{"CALABARZON", "PWD-B"},
+		{"BARMM", "PWD-S"},
+		{"ARMM", "PWD-S"},
+		{"NOWHERE", "PWD-A"}, // unknown regions fall back to NCR
+	}
+	format := regexp.MustCompile(`^PWD-[A-Z][ABCDEFGHJKLMNPRSTUVWXYZ]{2} [1-9]\d{4}$`)
+
+	g := NewGenerator(42)
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				number := g.GeneratePlateNumber("4-Wheel", "Concessionary", tt.region)
+				if !format.MatchString(number) || !strings.HasPrefix(number, tt.prefix) {
+					t.Fatalf("plate %d %q is not a %s concessionary plate", i, number, tt.prefix)
+				}
+				if !VerifyChecksum(number) {
+					t.Fatalf("plate %q has a bad check digit", number)
+				}
+				if err := ValidatePlateNumber("4-Wheel", "Concessionary", number); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestNewGeneratorIsReproducible(t *testing.T) {
+	tests := []struct {
+		vehicleType string
+		plateType   string
+		region      string
+	}{
+		{"4-Wheel", "Private", "NCR"},
+		{"4-Wheel", "Diplomatic", "NCR"},
+		{"4-Wheel", "Vintage", "BICOL"},
+		{"2-Wheel", "", "CAR"},
+	}
+
+	a, b, other := NewGenerator(42), NewGenerator(42), NewGenerator(43)
+	differs := false
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			x := a.GeneratePlateNumber(tt.vehicleType, tt.plateType, tt.region)
+			y := b.GeneratePlateNumber(tt.vehicleType, tt.plateType, tt.region)
+			if x != y {
+				t.Fatalf("seed 42 gave %q then %q for %s/%s", x, y, tt.vehicleType, tt.plateType)
+			}
+			if other.GeneratePlateNumber(tt.vehicleType, tt.plateType, tt.region) != x {
+				differs = true
+			}
+		}
+	}
+	if !differs {
+		t.Fatal("seeds 42 and 43 produced identical sequences")
+	}
+}
+
+// takenPlates reports the first n candidates it sees as already issued
+type takenPlates struct {
+	n, calls int
+	err      error
+}
+
+func (r *takenPlates) ExistsWithPlateNumber(ctx context.Context, number string) (bool, error) {
+	r.calls++
+	if r.err != nil {
+		return false, r.err
+	}
+	return r.calls <= r.n, nil
+}
+
+func TestGenerate(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name      string
+		repo      *takenPlates
+		wantCalls int
+		wantErr   bool
+	}{
+		{"free at once", &takenPlates{}, 1, false},
+		{"three collisions", &takenPlates{n: 3}, 4, false},
+		{"every candidate taken", &takenPlates{n: maxGenerateAttempts}, maxGenerateAttempts, true},
+		{"repository error", &takenPlates{err: boom}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := NewGenerator(7)
+			for i := 1; i < tt.wantCalls; i++ {
+				want.GeneratePlateNumber("4-Wheel", "Private", "NCR")
+			}
+
+			number, err := NewGenerator(7).Generate(context.Background(), "4-Wheel", "Private", "NCR", tt.repo)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.repo.calls != tt.wantCalls {
+				t.Fatalf("checked %d candidates, want %d", tt.repo.calls, tt.wantCalls)
+			}
+			if !tt.wantErr && number != want.GeneratePlateNumber("4-Wheel", "Private", "NCR") {
+				t.Fatalf("Generate returned %q, not the seeded sequence's candidate %d", number, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGeneratorConcurrentUse(t *testing.T) {
+	g := NewGenerator(42)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if err := ValidateAnyPlateNumber(g.GeneratePlateNumber("4-Wheel", "Private", "NCR")); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
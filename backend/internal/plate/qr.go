@@ -0,0 +1,73 @@
+package plate
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// QRPayload is the plate information printed into a plate's QR code
+type QRPayload struct {
+	PlateNumber    string `json:"plate_number"`
+	VehicleID      string `json:"vehicle_id"`
+	ExpirationDate string `json:"expiration_date"`
+}
+
+// QRData is the full QR content: the payload plus its HMAC-SHA256 signature
+type QRData struct {
+	Payload   QRPayload `json:"payload"`
+	Signature string    `json:"sig"`
+}
+
+// ErrInvalidQRSignature is returned when a QR code's signature doesn't match its payload
+var ErrInvalidQRSignature = errors.New("invalid QR signature")
+
+// ErrNoQRSecret is returned when asked to sign or verify with an empty key,
+// which would let anyone forge a QR code
+var ErrNoQRSecret = errors.New("QR signing secret is not set")
+
+// signPayload computes the hex HMAC-SHA256 of the JSON-encoded payload
+func signPayload(p QRPayload, secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", ErrNoQRSecret
+	}
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(raw)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// EncodeQRData signs the payload and returns the JSON string to encode in the QR code
+func EncodeQRData(p QRPayload, secret []byte) (string, error) {
+	sig, err := signPayload(p, secret)
+	if err != nil {
+		return "", fmt.Errorf("sign QR payload: %w", err)
+	}
+	raw, err := json.Marshal(QRData{Payload: p, Signature: sig})
+	if err != nil {
+		return "", fmt.Errorf("encode QR data: %w", err)
+	}
+	return string(raw), nil
+}
+
+// VerifyQRData parses scanned QR content and checks its signature
+func VerifyQRData(data string, secret []byte) (*QRPayload, error) {
+	var d QRData
+	if err := json.Unmarshal([]byte(data), &d); err != nil {
+		return nil, fmt.Errorf("decode QR data: %w", err)
+	}
+	expected, err := signPayload(d.Payload, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(expected), []byte(d.Signature)) {
+		return nil, ErrInvalidQRSignature
+	}
+	return &d.Payload, nil
+}
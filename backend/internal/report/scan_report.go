@@ -0,0 +1,111 @@
+// Package report renders printable reports for LTO headquarters.
+package report
+
+import (
+	"fmt"
+	"io"
+	"smartplate-api/internal/models"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// scanColumns is the scan table layout: heading and width in mm (A4 portrait
+// leaves 190mm between the 10mm margins)
+var scanColumns = []struct {
+	title string
+	width float64
+}{
+	{"Date", 28},
+	{"Time", 20},
+	{"Plate Number", 38},
+	{"Owner Name", 76},
+	{"Status", 28},
+}
+
+const (
+	rowHeight    = 6.0
+	footerHeight = 15.0
+)
+
+// WriteScanReport renders the scans of month as a PDF and writes it to w,
+// showing dates and times in loc. rows is called once with a func that draws
+// one table row; if rows fails nothing is written.
+func WriteScanReport(w io.Writer, month time.Time, loc *time.Location, summary models.ScanReportSummary, rows func(draw func(models.ScanReportRow)) error) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // core fonts are cp1252
+	title := "Monthly Scan Report - " + month.Format("January 2006")
+	pdf.SetTitle(title, true)
+	pdf.AliasNbPages("")
+	pdf.SetAutoPageBreak(false, footerHeight)
+
+	pdf.SetHeaderFunc(func() {
+		// logo placeholder until headquarters supplies the artwork
+		pdf.SetDrawColor(120, 120, 120)
+		pdf.Rect(10, 8, 18, 18, "D")
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetXY(10, 14)
+		pdf.CellFormat(18, 6, "LTO", "", 0, "C", false, 0, "")
+		pdf.SetXY(32, 10)
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 7, title, "", 2, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 5, "Land Transportation Office - SmartPlate", "", 1, "L", false, 0, "")
+		pdf.SetY(30)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-footerHeight)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	tableHeader := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(220, 220, 220)
+		for _, col := range scanColumns {
+			pdf.CellFormat(col.width, rowHeight+1, col.title, "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 7, "Summary", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range [][2]string{
+		{"Total scans", fmt.Sprint(summary.TotalScans)},
+		{"Unique plates", fmt.Sprint(summary.UniquePlates)},
+		{"Unique owners", fmt.Sprint(summary.UniqueOwners)},
+		{"Expired detections", fmt.Sprint(summary.ExpiredDetections)},
+	} {
+		pdf.CellFormat(50, 6, line[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, line[1], "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	_, pageHeight := pdf.GetPageSize()
+	tableHeader()
+	n := 0
+	err := rows(func(row models.ScanReportRow) {
+		if pdf.GetY()+rowHeight > pageHeight-footerHeight {
+			pdf.AddPage()
+			tableHeader()
+		}
+		at := row.ScannedAt.In(loc)
+		cells := []string{at.Format("2006-01-02"), at.Format("15:04:05"), tr(row.PlateNumber), tr(row.OwnerName), row.Status}
+		for i, col := range scanColumns {
+			pdf.CellFormat(col.width, rowHeight, cells[i], "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+		n++
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		pdf.CellFormat(0, rowHeight, "No scans were recorded this month.", "", 1, "L", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
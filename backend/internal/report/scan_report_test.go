@@ -0,0 +1,88 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"smartplate-api/internal/models"
+)
+
+// sampleRows returns n scans spread over January 2024
+func sampleRows(n int) []models.ScanReportRow {
+	rows := make([]models.ScanReportRow, n)
+	statuses := []string{"Active", "Expired", "Deactivated"}
+	for i := range rows {
+		rows[i] = models.ScanReportRow{
+			ScannedAt:   time.Date(2024, 1, 1+i%31, 8, i%60, 0, 0, time.UTC),
+			PlateNumber: fmt.Sprintf("ABC %d", 1000+i),
+			OwnerName:   "Juan Dela Cruz",
+			Status:      statuses[i%len(statuses)],
+		}
+	}
+	return rows
+}
+
+// pageObject matches a page dictionary, not the /Pages tree
+var pageObject = regexp.MustCompile(`/Type /Page\b[^s]`)
+
+func TestWriteScanReport(t *testing.T) {
+	manila := time.FixedZone("PHT", 8*60*60)
+	tests := []struct {
+		name      string
+		rows      int
+		wantPages int
+	}{
+		{"no scans", 0, 1},
+		{"three scans", 3, 1},
+		{"several pages", 200, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := sampleRows(tt.rows)
+			summary := models.ScanReportSummary{TotalScans: tt.rows, UniquePlates: tt.rows, UniqueOwners: 1, ExpiredDetections: tt.rows / 3}
+			var buf bytes.Buffer
+			err := WriteScanReport(&buf, time.Date(2024, 1, 1, 0, 0, 0, 0, manila), manila, summary,
+				func(draw func(models.ScanReportRow)) error {
+					for _, r := range rows {
+						draw(r)
+					}
+					return nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out := buf.Bytes()
+			if !bytes.HasPrefix(out, []byte("%PDF")) {
+				t.Fatalf("output starts with %q, want %%PDF", out[:min(len(out), 8)])
+			}
+			if !bytes.HasSuffix(bytes.TrimSpace(out), []byte("%%EOF")) {
+				t.Fatal("output does not end with the PDF trailer")
+			}
+			if pages := len(pageObject.FindAll(out, -1)); pages != tt.wantPages {
+				t.Fatalf("got %d pages, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestWriteScanReportRowsFail(t *testing.T) {
+	boom := errors.New("boom")
+	var buf bytes.Buffer
+	err := WriteScanReport(&buf, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.UTC, models.ScanReportSummary{},
+		func(draw func(models.ScanReportRow)) error {
+			draw(sampleRows(1)[0])
+			return boom
+		})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("wrote %d bytes of a failed report", buf.Len())
+	}
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "smartplate-api/internal/models"
+    "strings"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+)
+
+// AuditLogRepository stores the audit trail of admin and officer actions.
+type AuditLogRepository interface {
+    Create(ctx context.Context, a *models.AuditLog) error
+    List(ctx context.Context, filter AuditFilter) ([]models.AuditLog, int, error)
+}
+
+// AuditFilter narrows List; zero-valued fields are ignored
+type AuditFilter struct {
+    ActorLTOID string
+    EntityType string
+    From       time.Time
+    To         time.Time
+    Limit      int
+    Offset     int
+}
+
+type auditLogRepo struct {
+    db *sqlx.DB
+}
+
+// NewAuditLogRepository returns an AuditLogRepository backed by sqlx.DB.
+func NewAuditLogRepository(db *sqlx.DB) AuditLogRepository {
+    return &auditLogRepo{db: db}
+}
+
+// nullJSON maps an empty value to SQL NULL
+func nullJSON(v json.RawMessage) interface{} {
+    if len(v) == 0 {
+        return nil
+    }
+    return string(v)
+}
+
+// Create inserts an audit row and fills in its id and timestamp.
+func (r *auditLogRepo) Create(ctx context.Context, a *models.AuditLog) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    INSERT INTO audit_log (
+      audit_id, actor_lto_id, action, entity_type, entity_id,
+      old_value, new_value, created_at
+    ) VALUES (
+      gen_random_uuid(), $1, $2, $3, $4, $5::jsonb, $6::jsonb, NOW()
+    )
+    RETURNING audit_id, created_at`
+    if err := r.db.QueryRowxContext(ctx, q,
+        a.ActorLTOID, a.Action, a.EntityType, a.EntityID,
+        nullJSON(a.OldValue), nullJSON(a.NewValue),
+    ).Scan(&a.AuditID, &a.CreatedAt); err != nil {
+        return fmt.Errorf("insert audit_log: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// List returns one page of audit rows, newest first, plus the total count.
+func (r *auditLogRepo) List(ctx context.Context, filter AuditFilter) ([]models.AuditLog, int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    conds := []string{}
+    args := []interface{}{}
+    add := func(cond string, val interface{}) {
+        args = append(args, val)
+        conds = append(conds, fmt.Sprintf(cond, len(args)))
+    }
+    if filter.ActorLTOID != "" {
+        add("actor_lto_id = $%d", filter.ActorLTOID)
+    }
+    if filter.EntityType != "" {
+        add("entity_type = $%d", filter.EntityType)
+    }
+    if !filter.From.IsZero() {
+        add("created_at >= $%d", filter.From)
+    }
+    if !filter.To.IsZero() {
+        add("created_at <= $%d", filter.To)
+    }
+
+    where := ""
+    if len(conds) > 0 {
+        where = " WHERE " + strings.Join(conds, " AND ")
+    }
+
+    var total int
+    if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM audit_log"+where, args...); err != nil {
+        return nil, 0, fmt.Errorf("count audit_log: %w", queryErr(ctx, err))
+    }
+
+    list := []models.AuditLog{}
+    query := fmt.Sprintf(`
+    SELECT
+      audit_id, actor_lto_id, action, entity_type, entity_id,
+      COALESCE(old_value, 'null'::jsonb) AS old_value,
+      COALESCE(new_value, 'null'::jsonb) AS new_value,
+      created_at
+    FROM audit_log%s
+    ORDER BY created_at DESC
+    LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+    if err := r.db.SelectContext(ctx, &list, query, append(args, filter.Limit, filter.Offset)...); err != nil {
+        return nil, 0, fmt.Errorf("select audit_log: %w", queryErr(ctx, err))
+    }
+    return list, total, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "smartplate-api/internal/models"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+)
+
+// EmailQueueRepository persists outgoing emails until the worker delivers them.
+type EmailQueueRepository interface {
+    Enqueue(ctx context.Context, to, subject, htmlBody, textBody string) error
+    Due(ctx context.Context, maxAttempts, limit int) ([]models.EmailQueueItem, error)
+    MarkSent(ctx context.Context, id string) error
+    MarkFailed(ctx context.Context, id, errMsg string) error
+    ListUndelivered(ctx context.Context, limit, offset int) ([]models.EmailQueueItem, int, error)
+}
+
+// emailClaimLease is how long Due hides the emails it hands out from other
+// workers; it outlasts a full batch of SMTP sends
+const emailClaimLease = 30 * time.Minute
+
+type emailQueueRepo struct {
+    db *sqlx.DB
+}
+
+// NewEmailQueueRepository returns an EmailQueueRepository backed by sqlx.DB.
+func NewEmailQueueRepository(db *sqlx.DB) EmailQueueRepository {
+    return &emailQueueRepo{db: db}
+}
+
+// Enqueue inserts a Pending email.
+func (r *emailQueueRepo) Enqueue(ctx context.Context, to, subject, htmlBody, textBody string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    INSERT INTO email_queue (to_email, subject, html_body, text_body, status)
+    VALUES ($1, $2, $3, $4, $5)`
+    if _, err := r.db.ExecContext(ctx, q, to, subject, htmlBody, textBody, models.EmailPending); err != nil {
+        return fmt.Errorf("insert email_queue: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// Due claims the oldest Pending or Failed emails with fewer than maxAttempts
+// tries. Claimed rows are stamped so no other worker picks them up until
+// emailClaimLease has passed, and rows another worker is claiming are skipped.
+func (r *emailQueueRepo) Due(ctx context.Context, maxAttempts, limit int) ([]models.EmailQueueItem, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var list []models.EmailQueueItem
+    const q = `
+    UPDATE email_queue
+       SET last_attempted_at = NOW()
+     WHERE id IN (
+           SELECT id
+             FROM email_queue
+            WHERE status IN ($1, $2) AND attempts < $3
+              AND (last_attempted_at IS NULL OR last_attempted_at < NOW() - make_interval(secs => $5))
+            ORDER BY created_at
+            LIMIT $4
+              FOR UPDATE SKIP LOCKED)
+    RETURNING id, to_email, subject, html_body, text_body, status, attempts,
+              last_attempted_at, error_message, created_at`
+    err := r.db.SelectContext(ctx, &list, q,
+        models.EmailPending, models.EmailFailed, maxAttempts, limit, emailClaimLease.Seconds())
+    if err != nil {
+        return nil, fmt.Errorf("select due email_queue: %w", queryErr(ctx, err))
+    }
+    return list, nil
+}
+
+// MarkSent records a successful delivery attempt and drops the bodies, which
+// may hold reset links that shouldn't outlive the send.
+func (r *emailQueueRepo) MarkSent(ctx context.Context, id string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    UPDATE email_queue
+       SET status = $1, attempts = attempts + 1, last_attempted_at = NOW(), error_message = '',
+           html_body = '', text_body = ''
+     WHERE id = $2`
+    _, err := r.db.ExecContext(ctx, q, models.EmailSent, id)
+    return queryErr(ctx, err)
+}
+
+// MarkFailed records a failed delivery attempt and its error.
+func (r *emailQueueRepo) MarkFailed(ctx context.Context, id, errMsg string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    UPDATE email_queue
+       SET status = $1, attempts = attempts + 1, last_attempted_at = NOW(), error_message = $2
+     WHERE id = $3`
+    _, err := r.db.ExecContext(ctx, q, models.EmailFailed, errMsg, id)
+    return queryErr(ctx, err)
+}
+
+// ListUndelivered returns one page of Pending and Failed emails, newest first,
+// plus the total count. Bodies are left out.
+func (r *emailQueueRepo) ListUndelivered(ctx context.Context, limit, offset int) ([]models.EmailQueueItem, int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var total int
+    if err := r.db.GetContext(ctx, &total,
+        `SELECT COUNT(*) FROM email_queue WHERE status IN ($1, $2)`,
+        models.EmailPending, models.EmailFailed,
+    ); err != nil {
+        return nil, 0, fmt.Errorf("count email_queue: %w", queryErr(ctx, err))
+    }
+    list := []models.EmailQueueItem{}
+    const q = `
+    SELECT id, to_email, subject, status, attempts,
+           last_attempted_at, error_message, created_at
+      FROM email_queue
+     WHERE status IN ($1, $2)
+     ORDER BY created_at DESC
+     LIMIT $3 OFFSET $4`
+    if err := r.db.SelectContext(ctx, &list, q, models.EmailPending, models.EmailFailed, limit, offset); err != nil {
+        return nil, 0, fmt.Errorf("select email_queue: %w", queryErr(ctx, err))
+    }
+    return list, total, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+
+    "smartplate-api/internal/models"
+)
+
+func TestEmailQueueDueClaimsRows(t *testing.T) {
+    db, mock := newMockDB(t)
+    rows := sqlmock.NewRows([]string{"id", "to_email", "subject", "html_body", "text_body", "status", "attempts",
+        "last_attempted_at", "error_message", "created_at"}).
+        AddRow("e1", "juan@example.com", "Reset", "<a>link</a>", "link", models.EmailPending, 0, time.Now(), "", time.Now())
+    mock.ExpectQuery(`UPDATE email_queue\s+SET last_attempted_at = NOW\(\)\s+WHERE id IN \(.*FOR UPDATE SKIP LOCKED\)\s+RETURNING`).
+        WithArgs(models.EmailPending, models.EmailFailed, 3, 50, emailClaimLease.Seconds()).
+        WillReturnRows(rows)
+
+    list, err := NewEmailQueueRepository(db).Due(context.Background(), 3, 50)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(list) != 1 || list[0].HTMLBody != "<a>link</a>" {
+        t.Fatalf("Due returned %+v", list)
+    }
+}
+
+func TestEmailQueueMarkSentPurgesBodies(t *testing.T) {
+    db, mock := newMockDB(t)
+    mock.ExpectExec(`UPDATE email_queue\s+SET status = \$1, .*html_body = '', text_body = ''\s+WHERE id = \$2`).
+        WithArgs(models.EmailSent, "e1").
+        WillReturnResult(sqlmock.NewResult(0, 1))
+
+    if err := NewEmailQueueRepository(db).MarkSent(context.Background(), "e1"); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestEmailQueueListUndeliveredSkipsBodies(t *testing.T) {
+    db, mock := newMockDB(t)
+    mock.ExpectQuery(`SELECT COUNT\(\*\) FROM email_queue`).
+        WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+    mock.ExpectQuery(`SELECT id, to_email, subject, status, attempts,\s+last_attempted_at, error_message, created_at\s+FROM email_queue`).
+        WithArgs(models.EmailPending, models.EmailFailed, 20, 0).
+        WillReturnRows(sqlmock.NewRows([]string{"id", "to_email", "subject", "status", "attempts", "last_attempted_at", "error_message", "created_at"}).
+            AddRow("e1", "juan@example.com", "Reset", models.EmailFailed, 1, time.Now(), "timeout", time.Now()))
+
+    list, total, err := NewEmailQueueRepository(db).ListUndelivered(context.Background(), 20, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if total != 1 || len(list) != 1 || list[0].HTMLBody != "" || list[0].TextBody != "" {
+        t.Fatalf("ListUndelivered = %+v, %d", list, total)
+    }
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "smartplate-api/internal/models"
+
+    "github.com/jmoiron/sqlx"
+)
+
+// FormStateHistoryRepository reads the audit trail of registration form
+// status changes. Entries are written by RegistrationFormRepository.UpdateStatus.
+type FormStateHistoryRepository interface {
+    ListByForm(ctx context.Context, formID string) ([]models.FormStateHistory, error)
+}
+
+type formStateHistoryRepo struct {
+    db *sqlx.DB
+}
+
+// NewFormStateHistoryRepository returns a FormStateHistoryRepository backed by sqlx.DB.
+func NewFormStateHistoryRepository(db *sqlx.DB) FormStateHistoryRepository {
+    return &formStateHistoryRepo{db: db}
+}
+
+// ListByForm returns a form's status changes, oldest first.
+func (r *formStateHistoryRepo) ListByForm(ctx context.Context, formID string) ([]models.FormStateHistory, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.FormStateHistory{}
+    const q = `
+    SELECT history_id, registration_form_id, from_state, to_state, changed_by, changed_at
+      FROM form_state_history
+     WHERE registration_form_id = $1
+     ORDER BY changed_at`
+    if err := r.db.SelectContext(ctx, &list, q, formID); err != nil {
+        return nil, fmt.Errorf("select form state history: %w", queryErr(ctx, err))
+    }
+    return list, nil
+}
+
+// insertFormStateHistory records h in the caller's transaction and fills in its id
+func insertFormStateHistory(ctx context.Context, tx *sqlx.Tx, h *models.FormStateHistory) error {
+    const q = `
+    INSERT INTO form_state_history (registration_form_id, from_state, to_state, changed_by, changed_at)
+    VALUES ($1, $2, $3, $4, $5)
+    RETURNING history_id`
+    if err := tx.QueryRowxContext(ctx, q, h.RegistrationFormID, h.FromState, h.ToState, h.ChangedBy, h.ChangedAt).
+        Scan(&h.HistoryID); err != nil {
+        return fmt.Errorf("insert form state history: %w", err)
+    }
+    return nil
+}
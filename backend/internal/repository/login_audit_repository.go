@@ -0,0 +1,74 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "smartplate-api/internal/models"
+
+    "github.com/jmoiron/sqlx"
+)
+
+// LoginAuditRepository records password login attempts.
+type LoginAuditRepository interface {
+    Create(ctx context.Context, a *models.LoginAudit) error
+    ListByUser(ctx context.Context, ltoClientID string, limit int) ([]models.LoginAudit, error)
+    HasSucceededFrom(ctx context.Context, ltoClientID, country string) (bool, error)
+}
+
+type loginAuditRepo struct {
+    db *sqlx.DB
+}
+
+// NewLoginAuditRepository returns a LoginAuditRepository backed by sqlx.DB.
+func NewLoginAuditRepository(db *sqlx.DB) LoginAuditRepository {
+    return &loginAuditRepo{db: db}
+}
+
+// Create inserts an attempt and fills in its id and time.
+func (r *loginAuditRepo) Create(ctx context.Context, a *models.LoginAudit) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    INSERT INTO login_audit (lto_client_id, ip_address, user_agent, success, country, city)
+    VALUES ($1, $2, $3, $4, $5, $6)
+    RETURNING id, attempted_at`
+    if err := r.db.QueryRowxContext(ctx, q, a.LTOClientID, a.IPAddress, a.UserAgent, a.Success, a.Country, a.City).
+        Scan(&a.ID, &a.AttemptedAt); err != nil {
+        return fmt.Errorf("insert login audit: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// ListByUser returns the user's latest attempts, newest first.
+func (r *loginAuditRepo) ListByUser(ctx context.Context, ltoClientID string, limit int) ([]models.LoginAudit, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.LoginAudit{}
+    const q = `
+    SELECT id, lto_client_id, ip_address, user_agent, success, attempted_at, country, city
+      FROM login_audit
+     WHERE lto_client_id = $1
+     ORDER BY attempted_at DESC
+     LIMIT $2`
+    if err := r.db.SelectContext(ctx, &list, q, ltoClientID, limit); err != nil {
+        return nil, fmt.Errorf("select login audit: %w", queryErr(ctx, err))
+    }
+    return list, nil
+}
+
+// HasSucceededFrom reports whether the user has ever logged in successfully
+// from country.
+func (r *loginAuditRepo) HasSucceededFrom(ctx context.Context, ltoClientID, country string) (bool, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var seen bool
+    const q = `
+    SELECT EXISTS (
+        SELECT 1 FROM login_audit
+         WHERE lto_client_id = $1 AND country = $2 AND success
+    )`
+    if err := r.db.GetContext(ctx, &seen, q, ltoClientID, country); err != nil {
+        return false, queryErr(ctx, err)
+    }
+    return seen, nil
+}
@@ -1 +1,76 @@
-package repository
\ No newline at end of file
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+
+    "github.com/jmoiron/sqlx"
+
+    "smartplate-api/internal/models"
+)
+
+// NotificationPreferencesRepository stores which notification channels each user wants.
+type NotificationPreferencesRepository interface {
+    GetByLTOClientID(ctx context.Context, ltoClientID string) (*models.NotificationPreferences, error)
+    Upsert(ctx context.Context, p *models.NotificationPreferences) error
+}
+
+type notificationPreferencesRepo struct {
+    db *sqlx.DB
+}
+
+// NewNotificationPreferencesRepository returns a NotificationPreferencesRepository backed by sqlx.DB.
+func NewNotificationPreferencesRepository(db *sqlx.DB) NotificationPreferencesRepository {
+    return &notificationPreferencesRepo{db: db}
+}
+
+// GetByLTOClientID returns the user's preferences, or the defaults with
+// every channel on if they have never saved any. It never writes; the row is
+// created by the user's first Upsert.
+func (r *notificationPreferencesRepo) GetByLTOClientID(ctx context.Context, ltoClientID string) (*models.NotificationPreferences, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var p models.NotificationPreferences
+    const q = `
+    SELECT lto_client_id, email_plate_expiry, sms_plate_expiry,
+           email_scan_alert, sms_scan_alert, updated_at
+    FROM notification_preferences
+    WHERE lto_client_id = $1`
+    err := r.db.GetContext(ctx, &p, q, ltoClientID)
+    if errors.Is(err, sql.ErrNoRows) {
+        p = models.DefaultNotificationPreferences(ltoClientID)
+        return &p, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("select notification_preferences: %w", queryErr(ctx, err))
+    }
+    return &p, nil
+}
+
+// Upsert saves p and sets its UpdatedAt.
+func (r *notificationPreferencesRepo) Upsert(ctx context.Context, p *models.NotificationPreferences) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    INSERT INTO notification_preferences (
+      lto_client_id, email_plate_expiry, sms_plate_expiry,
+      email_scan_alert, sms_scan_alert, updated_at
+    ) VALUES (
+      $1, $2, $3, $4, $5, NOW()
+    )
+    ON CONFLICT (lto_client_id) DO UPDATE SET
+      email_plate_expiry = EXCLUDED.email_plate_expiry,
+      sms_plate_expiry   = EXCLUDED.sms_plate_expiry,
+      email_scan_alert   = EXCLUDED.email_scan_alert,
+      sms_scan_alert     = EXCLUDED.sms_scan_alert,
+      updated_at         = EXCLUDED.updated_at
+    RETURNING updated_at`
+    err := r.db.GetContext(ctx, &p.UpdatedAt, q,
+        p.LTOClientID, p.EmailPlateExpiry, p.SMSPlateExpiry, p.EmailScanAlert, p.SMSScanAlert)
+    if err != nil {
+        return fmt.Errorf("upsert notification_preferences: %w", queryErr(ctx, err))
+    }
+    return nil
+}
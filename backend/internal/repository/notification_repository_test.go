@@ -0,0 +1,73 @@
+package repository
+
+import (
+    "context"
+    "regexp"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+
+    "smartplate-api/internal/models"
+)
+
+func TestNotificationPreferencesGetIsReadOnly(t *testing.T) {
+    saved := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+    tests := []struct {
+        name string
+        rows *sqlmock.Rows
+        want models.NotificationPreferences
+    }{
+        {"never saved", sqlmock.NewRows([]string{"lto_client_id"}), models.DefaultNotificationPreferences("LTO-1")},
+        {"saved", sqlmock.NewRows([]string{"lto_client_id", "email_plate_expiry", "sms_plate_expiry", "email_scan_alert", "sms_scan_alert", "updated_at"}).
+            AddRow("LTO-1", true, false, true, false, saved),
+            models.NotificationPreferences{LTOClientID: "LTO-1", EmailPlateExpiry: true, EmailScanAlert: true, UpdatedAt: saved}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            // any INSERT would be an unexpected call and fail the test
+            db, mock := newMockDB(t)
+            mock.ExpectQuery(regexp.QuoteMeta("FROM notification_preferences WHERE lto_client_id = $1")).
+                WithArgs("LTO-1").
+                WillReturnRows(tt.rows)
+
+            got, err := NewNotificationPreferencesRepository(db).GetByLTOClientID(context.Background(), "LTO-1")
+            if err != nil {
+                t.Fatal(err)
+            }
+            if *got != tt.want {
+                t.Fatalf("got %+v, want %+v", *got, tt.want)
+            }
+        })
+    }
+}
+
+func TestPlateNotificationClaim(t *testing.T) {
+    since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+    tests := []struct {
+        name     string
+        affected int64
+        want     bool
+    }{
+        {"not notified since", 1, true},
+        {"already claimed", 0, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db, mock := newMockDB(t)
+            mock.ExpectExec(regexp.QuoteMeta("UPDATE plates SET expiry_notified_at = NOW()")).
+                WithArgs("p1", since).
+                WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+            got, err := NewPlateNotificationLogRepository(db).Claim(context.Background(), "p1", since)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Fatalf("claimed = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
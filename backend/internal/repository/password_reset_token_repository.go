@@ -0,0 +1,150 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "smartplate-api/internal/models"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+)
+
+// PasswordResetTokenRepository stores password reset tokens.
+type PasswordResetTokenRepository interface {
+    Create(ctx context.Context, t *models.PasswordResetToken) error
+    Consume(ctx context.Context, token string) (*models.PasswordResetToken, error)
+    InvalidateByLTOClientID(ctx context.Context, ltoClientID string) error
+    CountActive(ctx context.Context, ltoClientID string) (int, error)
+    GetAllActive(ctx context.Context, limit, offset int) ([]*models.PasswordResetToken, int, error)
+    MarkTokenAsUsed(ctx context.Context, id string) error
+    DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ErrResetTokenInvalid is returned by Consume for an unknown, used or expired token
+var ErrResetTokenInvalid = errors.New("reset token is invalid or expired")
+
+type passwordResetTokenRepo struct {
+    db *sqlx.DB
+}
+
+// NewPasswordResetTokenRepository returns a PasswordResetTokenRepository backed by sqlx.DB.
+func NewPasswordResetTokenRepository(db *sqlx.DB) PasswordResetTokenRepository {
+    return &passwordResetTokenRepo{db: db}
+}
+
+// Create inserts a token and fills in its creation time.
+func (r *passwordResetTokenRepo) Create(ctx context.Context, t *models.PasswordResetToken) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    INSERT INTO password_reset_token (token, lto_client_id, expires_at)
+    VALUES ($1, $2, $3)
+    RETURNING token_id, created_at`
+    if err := r.db.QueryRowxContext(ctx, q, t.Token, t.LTOClientID, t.ExpiresAt).Scan(&t.ID, &t.CreatedAt); err != nil {
+        return fmt.Errorf("insert password_reset_token: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// Consume marks an unused, unexpired token as used and returns it, so a
+// token can only ever be redeemed once.
+func (r *passwordResetTokenRepo) Consume(ctx context.Context, token string) (*models.PasswordResetToken, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var t models.PasswordResetToken
+    const q = `
+    UPDATE password_reset_token
+       SET used_at = NOW()
+     WHERE token = $1 AND used_at IS NULL AND expires_at > NOW()
+    RETURNING token_id, token, lto_client_id, expires_at, used_at, created_at`
+    err := r.db.GetContext(ctx, &t, q, token)
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, ErrResetTokenInvalid
+    }
+    if err != nil {
+        return nil, fmt.Errorf("consume password_reset_token: %w", queryErr(ctx, err))
+    }
+    return &t, nil
+}
+
+// InvalidateByLTOClientID marks every outstanding token for the client as
+// used, e.g. once their password has been changed.
+func (r *passwordResetTokenRepo) InvalidateByLTOClientID(ctx context.Context, ltoClientID string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    UPDATE password_reset_token
+       SET used_at = NOW()
+     WHERE lto_client_id = $1 AND used_at IS NULL AND expires_at > NOW()`
+    if _, err := r.db.ExecContext(ctx, q, ltoClientID); err != nil {
+        return fmt.Errorf("invalidate password_reset_token: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// CountActive returns how many unused, unexpired tokens the client holds
+func (r *passwordResetTokenRepo) CountActive(ctx context.Context, ltoClientID string) (int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var n int
+    const q = `
+    SELECT COUNT(*) FROM password_reset_token
+     WHERE lto_client_id = $1 AND used_at IS NULL AND expires_at > NOW()`
+    if err := r.db.GetContext(ctx, &n, q, ltoClientID); err != nil {
+        return 0, fmt.Errorf("count password_reset_token: %w", queryErr(ctx, err))
+    }
+    return n, nil
+}
+
+// GetAllActive returns one page of unused, unexpired tokens, soonest to
+// expire first, plus the total count
+func (r *passwordResetTokenRepo) GetAllActive(ctx context.Context, limit, offset int) ([]*models.PasswordResetToken, int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const where = `WHERE used_at IS NULL AND expires_at > NOW()`
+    var total int
+    if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM password_reset_token `+where); err != nil {
+        return nil, 0, fmt.Errorf("count active password_reset_token: %w", queryErr(ctx, err))
+    }
+    list := []*models.PasswordResetToken{}
+    q := `
+    SELECT token_id, token, lto_client_id, expires_at, used_at, created_at
+      FROM password_reset_token ` + where + `
+     ORDER BY expires_at
+     LIMIT $1 OFFSET $2`
+    if err := r.db.SelectContext(ctx, &list, q, limit, offset); err != nil {
+        return nil, 0, fmt.Errorf("select active password_reset_token: %w", queryErr(ctx, err))
+    }
+    return list, total, nil
+}
+
+// MarkTokenAsUsed invalidates a single unused token by its id, returning
+// sql.ErrNoRows if there is no such token or it was already used
+func (r *passwordResetTokenRepo) MarkTokenAsUsed(ctx context.Context, id string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    res, err := r.db.ExecContext(ctx,
+        `UPDATE password_reset_token SET used_at = NOW() WHERE token_id = $1 AND used_at IS NULL`, id)
+    if err != nil {
+        return fmt.Errorf("mark password_reset_token used: %w", queryErr(ctx, err))
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+    return nil
+}
+
+// DeleteExpired removes tokens that expired or were used before the cutoff
+// and returns how many were deleted
+func (r *passwordResetTokenRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    res, err := r.db.ExecContext(ctx,
+        `DELETE FROM password_reset_token WHERE expires_at < $1 OR used_at < $1`, before)
+    if err != nil {
+        return 0, fmt.Errorf("delete expired password_reset_token: %w", queryErr(ctx, err))
+    }
+    return res.RowsAffected()
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+    "context"
+    "strings"
+    "sync/atomic"
+    "time"
+
+    "github.com/hashicorp/golang-lru/v2/expirable"
+
+    "smartplate-api/internal/metrics"
+    "smartplate-api/internal/models"
+)
+
+// plateCacheTTL bounds how stale a cached plate can get when it is changed
+// outside this process, e.g. by another API instance
+const plateCacheTTL = 5 * time.Minute
+
+// CachingPlateRepository wraps a PlateRepository and keeps GetByPlateNumber
+// results, the query behind every scan, in an LRU. Writes made through it
+// drop the plates they touch. Misses aren't cached, so a newly issued plate
+// is found at once.
+type CachingPlateRepository struct {
+    PlateRepository
+    cache *expirable.LRU[string, models.Plate]
+    // gen changes on every invalidation so a lookup that raced a write
+    // doesn't put the old row back
+    gen atomic.Uint64
+}
+
+// NewCachingPlateRepository caches up to size plates in front of inner;
+// size must be positive
+func NewCachingPlateRepository(inner PlateRepository, size int) *CachingPlateRepository {
+    return &CachingPlateRepository{
+        PlateRepository: inner,
+        cache:           expirable.NewLRU[string, models.Plate](size, nil, plateCacheTTL),
+    }
+}
+
+// plateKey matches GetByPlateNumber, which ignores case
+func plateKey(number string) string {
+    return strings.ToUpper(number)
+}
+
+func (r *CachingPlateRepository) GetByPlateNumber(ctx context.Context, plateNumber string) (*models.Plate, error) {
+    key := plateKey(plateNumber)
+    if p, ok := r.cache.Get(key); ok {
+        metrics.PlateCacheLookupsTotal.WithLabelValues("hit").Inc()
+        return &p, nil
+    }
+    metrics.PlateCacheLookupsTotal.WithLabelValues("miss").Inc()
+
+    gen := r.gen.Load()
+    p, err := r.PlateRepository.GetByPlateNumber(ctx, plateNumber)
+    if err != nil || p == nil {
+        return p, err
+    }
+    if r.gen.Load() == gen {
+        r.cache.Add(key, *p)
+    }
+    return p, nil
+}
+
+// forget drops the given plate numbers; an empty one means the number
+// couldn't be determined, so everything goes
+func (r *CachingPlateRepository) forget(numbers ...string) {
+    r.gen.Add(1)
+    for _, n := range numbers {
+        if n == "" {
+            r.cache.Purge()
+            return
+        }
+    }
+    for _, n := range numbers {
+        r.cache.Remove(plateKey(n))
+    }
+}
+
+// numberOf returns the plate's current number, or "" when it can't be read
+func (r *CachingPlateRepository) numberOf(ctx context.Context, plateID string) string {
+    p, err := r.PlateRepository.GetPlateByPlateID(ctx, plateID)
+    if err != nil || p == nil {
+        return ""
+    }
+    return p.PLATE_NUMBER
+}
+
+func (r *CachingPlateRepository) CreatePlate(ctx context.Context, p *models.Plate) (*models.Plate, error) {
+    created, err := r.PlateRepository.CreatePlate(ctx, p)
+    if err == nil {
+        r.forget(created.PLATE_NUMBER)
+    }
+    return created, err
+}
+
+func (r *CachingPlateRepository) UpdatePlate(ctx context.Context, vehicleID, plateID, changedBy string, fields map[string]interface{}) error {
+    old := r.numberOf(ctx, plateID)
+    newNumber, _ := fields["plate_number"].(string)
+    if err := r.PlateRepository.UpdatePlate(ctx, vehicleID, plateID, changedBy, fields); err != nil {
+        return err
+    }
+    if newNumber != "" {
+        r.forget(old, newNumber)
+    } else {
+        r.forget(old)
+    }
+    return nil
+}
+
+func (r *CachingPlateRepository) DeletePlateByID(ctx context.Context, vehicleID, plateID string) error {
+    old := r.numberOf(ctx, plateID)
+    if err := r.PlateRepository.DeletePlateByID(ctx, vehicleID, plateID); err != nil {
+        return err
+    }
+    r.forget(old)
+    return nil
+}
+
+func (r *CachingPlateRepository) RestoreVersion(ctx context.Context, plateID, historyID string) error {
+    old := r.numberOf(ctx, plateID)
+    if err := r.PlateRepository.RestoreVersion(ctx, plateID, historyID); err != nil {
+        return err
+    }
+    r.forget(old, r.numberOf(ctx, plateID))
+    return nil
+}
+
+func (r *CachingPlateRepository) TransferPlate(ctx context.Context, t *models.PlateTransfer) error {
+    old := r.numberOf(ctx, t.PlateID)
+    if err := r.PlateRepository.TransferPlate(ctx, t); err != nil {
+        return err
+    }
+    r.forget(old)
+    return nil
+}
+
+func (r *CachingPlateRepository) RenewPlate(ctx context.Context, vehicleID, changedBy string, renewal *models.PlateRenewalHistory) error {
+    old := r.numberOf(ctx, renewal.PlateID)
+    if err := r.PlateRepository.RenewPlate(ctx, vehicleID, changedBy, renewal); err != nil {
+        return err
+    }
+    r.forget(old)
+    return nil
+}
+
+func (r *CachingPlateRepository) DeactivatePlate(ctx context.Context, vehicleID, plateID, changedBy, reason string, effective time.Time) error {
+    old := r.numberOf(ctx, plateID)
+    if err := r.PlateRepository.DeactivatePlate(ctx, vehicleID, plateID, changedBy, reason, effective); err != nil {
+        return err
+    }
+    r.forget(old)
+    return nil
+}
+
+func (r *CachingPlateRepository) BulkUpdateStatus(ctx context.Context, vehicleID, newStatus, changedBy string) (int64, error) {
+    n, err := r.PlateRepository.BulkUpdateStatus(ctx, vehicleID, newStatus, changedBy)
+    if err != nil || n == 0 {
+        return n, err
+    }
+    r.forgetVehicle(ctx, vehicleID)
+    return n, nil
+}
+
+// forgetVehicle drops every plate of a vehicle, or everything when they
+// can't be listed
+func (r *CachingPlateRepository) forgetVehicle(ctx context.Context, vehicleID string) {
+    plates, err := r.PlateRepository.GetPlatesByVehicleID(ctx, vehicleID)
+    if err != nil {
+        r.forget("")
+        return
+    }
+    numbers := make([]string, len(plates))
+    for i, p := range plates {
+        numbers[i] = p.PLATE_NUMBER
+    }
+    r.forget(numbers...)
+}
+
+// ForgetUserPlates wraps users so that Delete, whose cascade deactivates the
+// user's plates in SQL rather than through this repository, empties the cache
+func (r *CachingPlateRepository) ForgetUserPlates(users UserRepository) UserRepository {
+    return cachePurgingUsers{UserRepository: users, plates: r}
+}
+
+type cachePurgingUsers struct {
+    UserRepository
+    plates *CachingPlateRepository
+}
+
+func (u cachePurgingUsers) Delete(ltoClientID string) error {
+    if err := u.UserRepository.Delete(ltoClientID); err != nil {
+        return err
+    }
+    u.plates.forget("")
+    return nil
+}
+
+// ForgetVehiclePlates wraps vehicles so that deleting vehicles, which
+// deactivates their plates in SQL, drops those plates from the cache
+func (r *CachingPlateRepository) ForgetVehiclePlates(vehicles VehicleRepository) VehicleRepository {
+    return cachePurgingVehicles{VehicleRepository: vehicles, plates: r}
+}
+
+type cachePurgingVehicles struct {
+    VehicleRepository
+    plates *CachingPlateRepository
+}
+
+func (v cachePurgingVehicles) DeleteVehicle(ctx context.Context, id, changedBy string) error {
+    if err := v.VehicleRepository.DeleteVehicle(ctx, id, changedBy); err != nil {
+        return err
+    }
+    v.plates.forgetVehicle(ctx, id)
+    return nil
+}
+
+func (v cachePurgingVehicles) DeleteVehicleByClientID(ctx context.Context, clientID, changedBy string) error {
+    if err := v.VehicleRepository.DeleteVehicleByClientID(ctx, clientID, changedBy); err != nil {
+        return err
+    }
+    v.plates.forget("")
+    return nil
+}
+
+func (r *CachingPlateRepository) SyncExpiredPlates(ctx context.Context) (int64, error) {
+    n, err := r.PlateRepository.SyncExpiredPlates(ctx)
+    if err == nil && n > 0 {
+        r.forget("")
+    }
+    return n, err
+}
@@ -0,0 +1,286 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "runtime"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "smartplate-api/internal/models"
+)
+
+// memPlates is an in-memory PlateRepository; delay stands in for the
+// database round trip
+type memPlates struct {
+    PlateRepository
+    mu      sync.Mutex
+    plates  map[string]models.Plate // by plate id
+    delay   time.Duration
+    lookups atomic.Int64
+}
+
+func newMemPlates(n int) *memPlates {
+    m := &memPlates{plates: make(map[string]models.Plate, n)}
+    for i := 0; i < n; i++ {
+        id := fmt.Sprintf("p%d", i)
+        m.plates[id] = models.Plate{PlateID: id, VEHICLE_ID: "v1", PLATE_NUMBER: fmt.Sprintf("ABC %04d", i), STATUS: models.PlateActive}
+    }
+    return m
+}
+
+func (m *memPlates) GetByPlateNumber(ctx context.Context, number string) (*models.Plate, error) {
+    m.lookups.Add(1)
+    if m.delay > 0 {
+        time.Sleep(m.delay)
+    }
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, p := range m.plates {
+        if strings.EqualFold(p.PLATE_NUMBER, number) {
+            return &p, nil
+        }
+    }
+    return nil, nil
+}
+
+func (m *memPlates) GetPlateByPlateID(ctx context.Context, plateID string) (*models.Plate, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if p, ok := m.plates[plateID]; ok {
+        return &p, nil
+    }
+    return nil, nil
+}
+
+func (m *memPlates) GetPlatesByVehicleID(ctx context.Context, vehicleID string) ([]models.Plate, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var list []models.Plate
+    for _, p := range m.plates {
+        if p.VEHICLE_ID == vehicleID {
+            list = append(list, p)
+        }
+    }
+    return list, nil
+}
+
+// deactivate sets every plate's status to Deactivated behind the cache's back
+func (m *memPlates) deactivate() {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for id, p := range m.plates {
+        p.STATUS = models.PlateDeactivated
+        m.plates[id] = p
+    }
+}
+
+func (m *memPlates) CreatePlate(ctx context.Context, p *models.Plate) (*models.Plate, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.plates[p.PlateID] = *p
+    return p, nil
+}
+
+func (m *memPlates) UpdatePlate(ctx context.Context, vehicleID, plateID, changedBy string, fields map[string]interface{}) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    p := m.plates[plateID]
+    if number, ok := fields["plate_number"].(string); ok {
+        p.PLATE_NUMBER = number
+    }
+    if status, ok := fields["status"].(string); ok {
+        p.STATUS = status
+    }
+    m.plates[plateID] = p
+    return nil
+}
+
+func (m *memPlates) DeletePlateByID(ctx context.Context, vehicleID, plateID string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    delete(m.plates, plateID)
+    return nil
+}
+
+func TestCachingPlateRepository(t *testing.T) {
+    ctx := context.Background()
+    tests := []struct {
+        name string
+        // write runs between two lookups of lookup
+        write       func(r *CachingPlateRepository) error
+        lookup      string
+        wantLookups int64
+        wantNumber  string // "" means not found
+        wantStatus  string
+    }{
+        {"repeat lookup is cached", nil, "ABC 0001", 1, "ABC 0001", models.PlateActive},
+        {"case and padding share an entry", nil, "abc 0001", 1, "ABC 0001", models.PlateActive},
+        {"misses aren't cached", nil, "ZZZ 9999", 2, "", ""},
+        {"create drops a cached miss", func(r *CachingPlateRepository) error {
+            _, err := r.CreatePlate(ctx, &models.Plate{PlateID: "new", PLATE_NUMBER: "NEW 1234", STATUS: models.PlateActive})
+            return err
+        }, "NEW 1234", 2, "NEW 1234", models.PlateActive},
+        {"update drops the plate", func(r *CachingPlateRepository) error {
+            return r.UpdatePlate(ctx, "v1", "p1", "LTO-9", map[string]interface{}{"status": models.PlateExpired})
+        }, "ABC 0001", 2, "ABC 0001", models.PlateExpired},
+        {"renumbering drops the old number", func(r *CachingPlateRepository) error {
+            return r.UpdatePlate(ctx, "v1", "p1", "LTO-9", map[string]interface{}{"plate_number": "ABC 9001"})
+        }, "ABC 0001", 2, "", ""},
+        {"delete drops the plate", func(r *CachingPlateRepository) error {
+            return r.DeletePlateByID(ctx, "v1", "p1")
+        }, "ABC 0001", 2, "", ""},
+        {"other plates stay cached", func(r *CachingPlateRepository) error {
+            return r.DeletePlateByID(ctx, "v1", "p2")
+        }, "ABC 0001", 1, "ABC 0001", models.PlateActive},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            inner := newMemPlates(10)
+            r := NewCachingPlateRepository(inner, 100)
+            if _, err := r.GetByPlateNumber(ctx, tt.lookup); err != nil {
+                t.Fatal(err)
+            }
+            if tt.write != nil {
+                if err := tt.write(r); err != nil {
+                    t.Fatal(err)
+                }
+            }
+            p, err := r.GetByPlateNumber(ctx, tt.lookup)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got := inner.lookups.Load(); got != tt.wantLookups {
+                t.Fatalf("inner repository queried %d times, want %d", got, tt.wantLookups)
+            }
+            switch {
+            case tt.wantNumber == "" && p != nil:
+                t.Fatalf("got %+v, want not found", p)
+            case tt.wantNumber != "" && (p == nil || p.PLATE_NUMBER != tt.wantNumber || p.STATUS != tt.wantStatus):
+                t.Fatalf("got %+v, want %s %s", p, tt.wantNumber, tt.wantStatus)
+            }
+        })
+    }
+}
+
+func TestCachingPlateRepositoryEvicts(t *testing.T) {
+    ctx := context.Background()
+    inner := newMemPlates(10)
+    r := NewCachingPlateRepository(inner, 2)
+    for _, number := range []string{"ABC 0001", "ABC 0002", "ABC 0003", "ABC 0001"} {
+        if _, err := r.GetByPlateNumber(ctx, number); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if got := inner.lookups.Load(); got != 4 {
+        t.Fatalf("inner repository queried %d times, want 4 (ABC 0001 evicted)", got)
+    }
+}
+
+func TestCachingPlateRepositoryReturnsCopies(t *testing.T) {
+    ctx := context.Background()
+    r := NewCachingPlateRepository(newMemPlates(2), 10)
+    p, _ := r.GetByPlateNumber(ctx, "ABC 0001")
+    p.STATUS = "tampered"
+    again, _ := r.GetByPlateNumber(ctx, "ABC 0001")
+    if again.STATUS != models.PlateActive {
+        t.Fatalf("caller's change leaked into the cache: %q", again.STATUS)
+    }
+}
+
+// benchmarkScanners looks plates up from 1,000 concurrent scanners against
+// a repository that takes 100µs per query
+func benchmarkScanners(b *testing.B, cached bool) {
+    const scanners, plates = 1000, 500
+    inner := newMemPlates(plates)
+    inner.delay = 100 * time.Microsecond
+    var repo PlateRepository = inner
+    if cached {
+        repo = NewCachingPlateRepository(inner, plates)
+    }
+
+    ctx := context.Background()
+    var next atomic.Int64
+    b.SetParallelism(max(scanners/runtime.GOMAXPROCS(0), 1))
+    b.ResetTimer()
+    b.RunParallel(func(pb *testing.PB) {
+        for pb.Next() {
+            number := fmt.Sprintf("ABC %04d", next.Add(1)%plates)
+            if p, err := repo.GetByPlateNumber(ctx, number); err != nil || p == nil {
+                b.Fatalf("%s: %v, %v", number, p, err)
+            }
+        }
+    })
+    b.ReportMetric(float64(inner.lookups.Load())/float64(b.N), "queries/op")
+}
+
+func BenchmarkGetByPlateNumber(b *testing.B) {
+    b.Run("uncached", func(b *testing.B) { benchmarkScanners(b, false) })
+    b.Run("cached", func(b *testing.B) { benchmarkScanners(b, true) })
+}
+
+// cascadingUsers deactivates every plate in plates on Delete, as the SQL
+// cascade in userRepo.Delete does
+type cascadingUsers struct {
+    UserRepository
+    plates *memPlates
+}
+
+func (u cascadingUsers) Delete(ltoClientID string) error {
+    u.plates.deactivate()
+    return nil
+}
+
+func TestCachingPlateRepositoryForgetsDeletedUsersPlates(t *testing.T) {
+    ctx := context.Background()
+    inner := newMemPlates(1)
+    cache := NewCachingPlateRepository(inner, 10)
+    users := cache.ForgetUserPlates(cascadingUsers{plates: inner})
+
+    if p, _ := cache.GetByPlateNumber(ctx, "ABC 0000"); p == nil || p.STATUS != models.PlateActive {
+        t.Fatalf("before delete: %+v", p)
+    }
+    if err := users.Delete("LTO-1"); err != nil {
+        t.Fatal(err)
+    }
+    if p, _ := cache.GetByPlateNumber(ctx, "ABC 0000"); p == nil || p.STATUS != models.PlateDeactivated {
+        t.Fatalf("after delete: %+v, want the plate deactivated", p)
+    }
+}
+
+// cascadingVehicles deactivates every plate in plates on DeleteVehicle, as
+// the SQL cascade in vehicleRepo.DeleteVehicle does
+type cascadingVehicles struct {
+    VehicleRepository
+    plates *memPlates
+}
+
+func (v cascadingVehicles) DeleteVehicle(ctx context.Context, id, changedBy string) error {
+    v.plates.deactivate()
+    return nil
+}
+
+func TestCachingPlateRepositoryForgetsDeletedVehiclesPlates(t *testing.T) {
+    ctx := context.Background()
+    inner := newMemPlates(2)
+    cache := NewCachingPlateRepository(inner, 10)
+    vehicles := cache.ForgetVehiclePlates(cascadingVehicles{plates: inner})
+
+    for _, number := range []string{"ABC 0000", "ABC 0001"} {
+        if p, _ := cache.GetByPlateNumber(ctx, number); p == nil || p.STATUS != models.PlateActive {
+            t.Fatalf("%s before delete: %+v", number, p)
+        }
+    }
+    if err := vehicles.DeleteVehicle(ctx, "v1", "officer-1"); err != nil {
+        t.Fatal(err)
+    }
+    for _, number := range []string{"ABC 0000", "ABC 0001"} {
+        if p, _ := cache.GetByPlateNumber(ctx, number); p == nil || p.STATUS != models.PlateDeactivated {
+            t.Fatalf("%s after delete: %+v, want the plate deactivated", number, p)
+        }
+    }
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+)
+
+// PlateNotificationLogRepository records which plates have had expiry notifications sent.
+type PlateNotificationLogRepository interface {
+    Create(ctx context.Context, plateID, recipient string) error
+    Claim(ctx context.Context, plateID string, since time.Time) (bool, error)
+}
+
+type plateNotificationLogRepo struct {
+    db *sqlx.DB
+}
+
+// NewPlateNotificationLogRepository returns a PlateNotificationLogRepository backed by sqlx.DB.
+func NewPlateNotificationLogRepository(db *sqlx.DB) PlateNotificationLogRepository {
+    return &plateNotificationLogRepo{db: db}
+}
+
+// Create records that a notification for plateID was sent to recipient.
+func (r *plateNotificationLogRepo) Create(ctx context.Context, plateID, recipient string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    INSERT INTO plate_notification_log (
+      notification_id, plate_id, recipient, sent_at
+    ) VALUES (
+      gen_random_uuid(), $1, $2, NOW()
+    )`
+    if _, err := r.db.ExecContext(ctx, q, plateID, recipient); err != nil {
+        return fmt.Errorf("insert plate_notification_log: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// Claim marks plateID as notified unless it already was at or after since,
+// and reports whether this call made the mark. Only the caller that gets true
+// should send, so concurrent runs never notify the same plate twice.
+func (r *plateNotificationLogRepo) Claim(ctx context.Context, plateID string, since time.Time) (bool, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    UPDATE plates SET expiry_notified_at = NOW()
+    WHERE plate_id = $1 AND (expiry_notified_at IS NULL OR expiry_notified_at < $2)`
+    res, err := r.db.ExecContext(ctx, q, plateID, since)
+    if err != nil {
+        return false, fmt.Errorf("claim plate expiry notification: %w", queryErr(ctx, err))
+    }
+    n, err := res.RowsAffected()
+    if err != nil {
+        return false, fmt.Errorf("claim plate expiry notification: %w", err)
+    }
+    return n == 1, nil
+}
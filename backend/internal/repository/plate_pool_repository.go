@@ -0,0 +1,116 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "smartplate-api/internal/models"
+
+    "github.com/jmoiron/sqlx"
+)
+
+// Plate sequences run from 1000 to 9999 within each region and letter prefix
+const (
+    poolFirstSequence = 1000
+    poolLastSequence  = 9999
+)
+
+var (
+    // ErrPoolBusy is returned when another caller holds the prefix's row;
+    // the caller should try a different prefix rather than wait
+    ErrPoolBusy = errors.New("plate number pool is busy")
+    // ErrPoolExhausted is returned once every sequence of a prefix is reserved
+    ErrPoolExhausted = errors.New("plate number pool is exhausted")
+)
+
+// PlatePoolRepository hands out plate sequences so regions fill their
+// number space in order instead of at random
+type PlatePoolRepository interface {
+    NextSequence(ctx context.Context, region, prefix string) (int, error)
+    Usage(ctx context.Context) ([]models.PlatePoolUsage, error)
+}
+
+type platePoolRepo struct {
+    db *sqlx.DB
+}
+
+func NewPlatePoolRepository(db *sqlx.DB) PlatePoolRepository {
+    return &platePoolRepo{db: db}
+}
+
+// NextSequence reserves the next unused sequence for region and prefix,
+// opening the pool on first use
+func (r *platePoolRepo) NextSequence(ctx context.Context, region, prefix string) (int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return 0, queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+
+    const open = `
+        INSERT INTO plate_number_pool (region, prefix, last_sequence)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (region, prefix) DO NOTHING
+    `
+    if _, err := tx.ExecContext(ctx, open, region, prefix, poolFirstSequence-1); err != nil {
+        return 0, queryErr(ctx, err)
+    }
+
+    var last int
+    const lock = `
+        SELECT last_sequence FROM plate_number_pool
+         WHERE region = $1 AND prefix = $2
+           FOR UPDATE SKIP LOCKED
+    `
+    err = tx.GetContext(ctx, &last, lock, region, prefix)
+    if err == sql.ErrNoRows {
+        return 0, ErrPoolBusy
+    }
+    if err != nil {
+        return 0, queryErr(ctx, err)
+    }
+    if last >= poolLastSequence {
+        return 0, ErrPoolExhausted
+    }
+
+    const reserve = `
+        UPDATE plate_number_pool
+           SET last_sequence = $3, updated_at = NOW()
+         WHERE region = $1 AND prefix = $2
+    `
+    if _, err := tx.ExecContext(ctx, reserve, region, prefix, last+1); err != nil {
+        return 0, queryErr(ctx, err)
+    }
+    if err := tx.Commit(); err != nil {
+        return 0, queryErr(ctx, err)
+    }
+    return last + 1, nil
+}
+
+// Usage reports reserved sequences per region against the capacity of the
+// prefixes opened in that region
+func (r *platePoolRepo) Usage(ctx context.Context) ([]models.PlatePoolUsage, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var list []models.PlatePoolUsage
+    const q = `
+        SELECT region,
+               COUNT(*)                               AS prefixes,
+               SUM(last_sequence - $1 + 1)            AS issued,
+               COUNT(*) * ($2 - $1 + 1)               AS capacity
+          FROM plate_number_pool
+         GROUP BY region
+         ORDER BY region
+    `
+    if err := r.db.SelectContext(ctx, &list, q, poolFirstSequence, poolLastSequence); err != nil {
+        return nil, queryErr(ctx, err)
+    }
+    for i := range list {
+        if list[i].Capacity > 0 {
+            list[i].UtilizationPct = float64(list[i].Issued) * 100 / float64(list[i].Capacity)
+        }
+    }
+    return list, nil
+}
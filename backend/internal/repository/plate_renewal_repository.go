@@ -0,0 +1,67 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "smartplate-api/internal/models"
+
+    "github.com/jmoiron/sqlx"
+)
+
+// PlateRenewalHistoryRepository records plate renewals.
+type PlateRenewalHistoryRepository interface {
+    Create(ctx context.Context, h *models.PlateRenewalHistory) error
+    GetByPlateID(ctx context.Context, plateID string) ([]models.PlateRenewalHistory, error)
+}
+
+type plateRenewalHistoryRepo struct {
+    db *sqlx.DB
+}
+
+// NewPlateRenewalHistoryRepository returns a PlateRenewalHistoryRepository backed by sqlx.DB.
+func NewPlateRenewalHistoryRepository(db *sqlx.DB) PlateRenewalHistoryRepository {
+    return &plateRenewalHistoryRepo{db: db}
+}
+
+// Create inserts a renewal row and fills in its generated id and timestamp.
+func (r *plateRenewalHistoryRepo) Create(ctx context.Context, h *models.PlateRenewalHistory) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    return insertRenewal(ctx, r.db, h)
+}
+
+// insertRenewal writes h through q, which may be the caller's transaction
+func insertRenewal(ctx context.Context, q sqlx.QueryerContext, h *models.PlateRenewalHistory) error {
+    const insert = `
+    INSERT INTO plate_renewal_history (
+      renewal_id, plate_id, previous_expiration_date, new_expiration_date, renewed_at
+    ) VALUES (
+      gen_random_uuid(), $1, $2, $3, NOW()
+    )
+    RETURNING renewal_id, renewed_at`
+    if err := q.QueryRowxContext(ctx, insert,
+        h.PlateID,
+        h.PreviousExpirationDate,
+        h.NewExpirationDate,
+    ).Scan(&h.RenewalID, &h.RenewedAt); err != nil {
+        return fmt.Errorf("insert plate_renewal_history: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// GetByPlateID lists a plate's renewals, newest first.
+func (r *plateRenewalHistoryRepo) GetByPlateID(ctx context.Context, plateID string) ([]models.PlateRenewalHistory, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var list []models.PlateRenewalHistory
+    const q = `
+    SELECT
+      renewal_id, plate_id, previous_expiration_date, new_expiration_date, renewed_at
+    FROM plate_renewal_history
+    WHERE plate_id = $1
+    ORDER BY renewed_at DESC`
+    if err := r.db.SelectContext(ctx, &list, q, plateID); err != nil {
+        return nil, fmt.Errorf("select plate_renewal_history: %w", queryErr(ctx, err))
+    }
+    return list, nil
+}
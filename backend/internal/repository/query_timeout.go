@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultQueryTimeout bounds a repository call when DB_QUERY_TIMEOUT_MS is unset or invalid
+const defaultQueryTimeout = 5 * time.Second
+
+var (
+	queryTimeout     time.Duration
+	queryTimeoutOnce sync.Once
+)
+
+// QueryTimeout returns the per-call query budget from DB_QUERY_TIMEOUT_MS,
+// read once on first use so it picks up values loaded from .env
+func QueryTimeout() time.Duration {
+	queryTimeoutOnce.Do(func() {
+		queryTimeout = defaultQueryTimeout
+		if ms, err := strconv.Atoi(os.Getenv("DB_QUERY_TIMEOUT_MS")); err == nil && ms > 0 {
+			queryTimeout = time.Duration(ms) * time.Millisecond
+		}
+	})
+	return queryTimeout
+}
+
+// WithQueryTimeout derives a context that expires after d, or after the
+// configured QueryTimeout when d is zero; a shorter parent deadline still wins
+func WithQueryTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		d = QueryTimeout()
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// queryErr makes a query cut short by its context report the context's error.
+// lib/pq surfaces a cancelled statement as its own "canceling statement" error,
+// so without this callers couldn't tell a timeout from any other failure.
+func queryErr(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		return fmt.Errorf("%w: %v", ctxErr, err)
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSlowQueryTimesOut(t *testing.T) {
+    rw, mock := newMockReadWrite(t)
+    mock.ExpectQuery("FROM plates").
+        WillDelayFor(time.Second).
+        WillReturnRows(sqlmock.NewRows(plateColumns))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+    defer cancel()
+    start := time.Now()
+    _, err := NewPlateRepository(rw).GetByPlateNumber(ctx, "ABC 1234")
+    if !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("err = %v, want it to wrap context.DeadlineExceeded", err)
+    }
+    if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+        t.Fatalf("query ran for %s after its deadline", elapsed)
+    }
+}
+
+func TestWithQueryTimeout(t *testing.T) {
+    tests := []struct {
+        name    string
+        parent  time.Duration // 0 means no parent deadline
+        d       time.Duration
+        wantMax time.Duration
+    }{
+        {"default budget", 0, 0, QueryTimeout()},
+        {"explicit budget", 0, 50 * time.Millisecond, 50 * time.Millisecond},
+        {"shorter parent wins", 10 * time.Millisecond, time.Minute, 10 * time.Millisecond},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            parent := context.Background()
+            if tt.parent > 0 {
+                var cancel context.CancelFunc
+                parent, cancel = context.WithTimeout(parent, tt.parent)
+                defer cancel()
+            }
+            ctx, cancel := WithQueryTimeout(parent, tt.d)
+            defer cancel()
+            deadline, ok := ctx.Deadline()
+            if !ok {
+                t.Fatal("no deadline set")
+            }
+            if left := time.Until(deadline); left > tt.wantMax || left <= 0 {
+                t.Fatalf("deadline in %s, want at most %s", left, tt.wantMax)
+            }
+        })
+    }
+}
+
+func TestQueryErr(t *testing.T) {
+    driverErr := errors.New("pq: canceling statement due to user request")
+    expired, cancel := context.WithTimeout(context.Background(), -time.Second)
+    defer cancel()
+    cancelled, cancelNow := context.WithCancel(context.Background())
+    cancelNow()
+
+    tests := []struct {
+        name string
+        ctx  context.Context
+        err  error
+        want error
+    }{
+        {"nil", context.Background(), nil, nil},
+        {"live context", context.Background(), driverErr, driverErr},
+        {"deadline", expired, driverErr, context.DeadlineExceeded},
+        {"cancelled", cancelled, driverErr, context.Canceled},
+        {"already wrapped", expired, context.DeadlineExceeded, context.DeadlineExceeded},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := queryErr(tt.ctx, tt.err)
+            if !errors.Is(got, tt.want) {
+                t.Fatalf("queryErr = %v, want it to wrap %v", got, tt.want)
+            }
+            if tt.err == nil && got != nil {
+                t.Fatalf("queryErr(nil) = %v", got)
+            }
+        })
+    }
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "smartplate-api/internal/database"
+    "smartplate-api/internal/models"
+)
+
+// TestReadWriteRouting checks each call lands on the pool the README says
+// it does. The target mock answers with a sentinel error; a query sent to
+// the other mock fails with sqlmock's "not expected" error instead.
+func TestReadWriteRouting(t *testing.T) {
+    ctx := context.Background()
+    now := time.Now()
+    tests := []struct {
+        name   string
+        toRead bool
+        exec   bool // an INSERT/UPDATE/DELETE rather than a query
+        call   func(rw database.ReadWriteDB) error
+    }{
+        {"plate search", true, false, func(rw database.ReadWriteDB) error {
+            _, err := NewPlateRepository(rw).Search(ctx, "AB", "", "", 10, 0)
+            return err
+        }},
+        {"plates expiring soon", true, false, func(rw database.ReadWriteDB) error {
+            _, err := NewPlateRepository(rw).GetExpiringSoon(ctx, time.Hour)
+            return err
+        }},
+        {"plate stats", true, false, func(rw database.ReadWriteDB) error {
+            _, err := NewPlateRepository(rw).GetStats(ctx)
+            return err
+        }},
+        {"plates by status", true, false, func(rw database.ReadWriteDB) error {
+            _, _, err := NewPlateRepository(rw).GetByStatus(ctx, models.PlateActive, 10, 0)
+            return err
+        }},
+        {"plates by type", true, false, func(rw database.ReadWriteDB) error {
+            _, _, err := NewPlateRepository(rw).GetByType(ctx, "Private", 10, 0)
+            return err
+        }},
+        {"scan log export", true, false, func(rw database.ReadWriteDB) error {
+            _, err := NewScanLogRepository(rw).GetAll(ctx)
+            return err
+        }},
+        {"scan logs by date range", true, false, func(rw database.ReadWriteDB) error {
+            _, err := NewScanLogRepository(rw).GetByDateRange(ctx, "c1", now.Add(-time.Hour), now, 100)
+            return err
+        }},
+        {"scan report summary", true, false, func(rw database.ReadWriteDB) error {
+            _, err := NewScanLogRepository(rw).ReportSummary(ctx, now.Add(-time.Hour), now)
+            return err
+        }},
+
+        {"scanner plate lookup", false, false, func(rw database.ReadWriteDB) error {
+            _, err := NewPlateRepository(rw).GetByPlateNumber(ctx, "ABC 1234")
+            return err
+        }},
+        {"plates on a vehicle", false, false, func(rw database.ReadWriteDB) error {
+            _, err := NewPlateRepository(rw).GetPlatesByVehicleID(ctx, "v1")
+            return err
+        }},
+        {"plate number exists", false, false, func(rw database.ReadWriteDB) error {
+            _, err := NewPlateRepository(rw).ExistsWithPlateNumber(ctx, "ABC 1234")
+            return err
+        }},
+        {"scan log by id", false, false, func(rw database.ReadWriteDB) error {
+            _, err := NewScanLogRepository(rw).GetByID(ctx, "s1")
+            return err
+        }},
+        {"scan log insert", false, true, func(rw database.ReadWriteDB) error {
+            return NewScanLogRepository(rw).Create(ctx, &models.ScanLog{})
+        }},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            write, writeMock := newMockDB(t)
+            read, readMock := newMockDB(t)
+            target := writeMock
+            if tt.toRead {
+                target = readMock
+            }
+            routed := errors.New("routed")
+            if tt.exec {
+                target.ExpectExec(".").WillReturnError(routed)
+            } else {
+                target.ExpectQuery(".").WillReturnError(routed)
+            }
+
+            err := tt.call(database.ReadWriteDB{Write: write, Read: read})
+            if !errors.Is(err, routed) {
+                want := "primary"
+                if tt.toRead {
+                    want = "replica"
+                }
+                t.Fatalf("query didn't reach the %s: %v", want, err)
+            }
+        })
+    }
+}
@@ -4,7 +4,11 @@ import (
     "context"
     "database/sql"
     "fmt"
+    "smartplate-api/internal/database"
+    "smartplate-api/internal/metrics"
     "smartplate-api/internal/models"
+    "strings"
+    "time"
 
     "github.com/jmoiron/sqlx"
 )
@@ -14,56 +18,231 @@ type ScanLogRepository interface {
     Create(ctx context.Context, log *models.ScanLog) error
     GetAll(ctx context.Context) ([]models.ScanLog, error)
     GetByID(ctx context.Context, id string) (*models.ScanLog, error)
+    GetByIDInRegion(ctx context.Context, id, region string) (*models.ScanLog, error)
+    GetByDateRange(ctx context.Context, ltoClientID string, from, to time.Time, limit int) ([]models.ScanLog, error)
+    GetByLTOClientID(ctx context.Context, ltoClientID string) ([]models.ScanLog, error)
+    GetByRegion(ctx context.Context, region string) ([]models.ScanLog, error)
+    DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+    GetByRegistrationID(ctx context.Context, registrationID string, limit, offset int) ([]models.ScanLog, error)
+    CountByRegistrationID(ctx context.Context, registrationID string) (int, error)
+    StreamAll(ctx context.Context, out chan<- models.ScanLog) error
+    BulkCreate(ctx context.Context, logs []*models.ScanLog) error
+    HourlyBreakdown(ctx context.Context, from, to time.Time) ([]models.HourlyCount, error)
+    CountByOfficer(ctx context.Context, from, to time.Time) ([]models.OfficerScanCount, error)
+    GetByDeviceID(ctx context.Context, deviceID string, limit, offset int) ([]models.ScanLog, error)
+    GetByBoundingBox(ctx context.Context, minLat, maxLat, minLon, maxLon float64) ([]models.ScanLog, error)
+    GetByIPAddress(ctx context.Context, ip string, limit, offset int) ([]models.ScanLog, error)
+    GetTopScannerIPs(ctx context.Context, topN int, from, to time.Time) ([]models.ScannerIPCount, error)
+    StreamReport(ctx context.Context, from, to time.Time, out chan<- models.ScanReportRow) error
+    ReportSummary(ctx context.Context, from, to time.Time) (*models.ScanReportSummary, error)
 }
 
+// maxBoundingBoxScans caps GetByBoundingBox so a zoomed-out map stays cheap
+const maxBoundingBoxScans = 5000
+
+// scanStreamTimeout bounds a StreamAll export, which outlives the normal
+// per-query budget
+const scanStreamTimeout = 10 * time.Minute
+
 type scanLogRepo struct {
-    db *sqlx.DB
+    db   *sqlx.DB
+    read *sqlx.DB // every listing except GetByID, since they back reports
 }
 
-// NewScanLogRepository returns a new ScanLogRepository backed by sqlx.DB.
-func NewScanLogRepository(db *sqlx.DB) ScanLogRepository {
-    return &scanLogRepo{db: db}
+// NewScanLogRepository returns a new ScanLogRepository that writes to the
+// primary and serves its reporting reads from the replica.
+func NewScanLogRepository(rw database.ReadWriteDB) ScanLogRepository {
+    return &scanLogRepo{db: rw.Write, read: rw.Read}
 }
 
 // Create inserts a new scan log entry into the database.
 func (r *scanLogRepo) Create(ctx context.Context, logEntry *models.ScanLog) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     const q = `
     INSERT INTO scan_log (
-      log_id, plate_id, registration_id, lto_client_id, scanned_at
+      log_id, plate_id, registration_id, lto_client_id, scanned_at,
+      latitude, longitude, scanner_device_id, scanner_ip, scanned_by
     ) VALUES (
-      gen_random_uuid(), $1, $2, $3, $4
+      gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9
     )`
     if _, err := r.db.ExecContext(ctx, q,
         logEntry.PlateID,
         logEntry.RegistrationID,
         logEntry.LTOClientID,
         logEntry.ScannedAt,
+        logEntry.Latitude,
+        logEntry.Longitude,
+        logEntry.ScannerDeviceID,
+        logEntry.ScannerIP,
+        logEntry.ScannedBy,
     ); err != nil {
-        return fmt.Errorf("insert scan_log: %w", err)
+        return fmt.Errorf("insert scan_log: %w", queryErr(ctx, err))
+    }
+    metrics.ScanLogCreatesTotal.Inc()
+    return nil
+}
+
+// BulkCreate inserts every entry with one multi-row INSERT inside a
+// transaction, so either all of them are stored or none are.
+func (r *scanLogRepo) BulkCreate(ctx context.Context, logs []*models.ScanLog) error {
+    if len(logs) == 0 {
+        return nil
+    }
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+
+    values := make([]string, 0, len(logs))
+    args := make([]interface{}, 0, len(logs)*9)
+    for _, l := range logs {
+        n := len(args)
+        values = append(values, fmt.Sprintf("(gen_random_uuid(), $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+            n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9))
+        args = append(args, l.PlateID, l.RegistrationID, l.LTOClientID, l.ScannedAt,
+            l.Latitude, l.Longitude, l.ScannerDeviceID, l.ScannerIP, l.ScannedBy)
+    }
+    q := `
+    INSERT INTO scan_log (
+      log_id, plate_id, registration_id, lto_client_id, scanned_at,
+      latitude, longitude, scanner_device_id, scanner_ip, scanned_by
+    ) VALUES ` + strings.Join(values, ", ")
+
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+    if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+        return fmt.Errorf("bulk insert scan_log: %w", queryErr(ctx, err))
+    }
+    if err := tx.Commit(); err != nil {
+        return queryErr(ctx, err)
     }
+    metrics.ScanLogCreatesTotal.Add(float64(len(logs)))
     return nil
 }
 
 // GetAll retrieves all scan log entries, ordered by scanned_at descending.
 func (r *scanLogRepo) GetAll(ctx context.Context) ([]models.ScanLog, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    defer metrics.ObserveQuery("scan_log_get_all", time.Now())
     var logs []models.ScanLog
     const q = `
     SELECT
-      log_id, plate_id, registration_id, lto_client_id, scanned_at
+      log_id, plate_id, registration_id, lto_client_id, scanned_at,
+      latitude, longitude, scanner_device_id, scanner_ip
     FROM scan_log
     ORDER BY scanned_at DESC` 
-    if err := r.db.SelectContext(ctx, &logs, q); err != nil {
-        return nil, fmt.Errorf("select all scan_log: %w", err)
+    if err := r.read.SelectContext(ctx, &logs, q); err != nil {
+        return nil, fmt.Errorf("select all scan_log: %w", queryErr(ctx, err))
     }
     return logs, nil
 }
 
+// StreamAll sends every scan log entry to out, newest first, reading them
+// through a cursor so the table is never held in memory. out is closed when
+// StreamAll returns. It holds one read connection for the whole export.
+func (r *scanLogRepo) StreamAll(ctx context.Context, out chan<- models.ScanLog) error {
+    defer close(out)
+    ctx, cancel := WithQueryTimeout(ctx, scanStreamTimeout)
+    defer cancel()
+    const q = `
+    SELECT
+      log_id, plate_id, registration_id, lto_client_id, scanned_at
+    FROM scan_log
+    ORDER BY scanned_at DESC`
+    rows, err := r.read.QueryxContext(ctx, q)
+    if err != nil {
+        return fmt.Errorf("stream scan_log: %w", queryErr(ctx, err))
+    }
+    defer rows.Close()
+    for rows.Next() {
+        var entry models.ScanLog
+        if err := rows.StructScan(&entry); err != nil {
+            return fmt.Errorf("scan scan_log row: %w", queryErr(ctx, err))
+        }
+        select {
+        case out <- entry:
+        case <-ctx.Done():
+            return ctx.Err()
+        }
+    }
+    if err := rows.Err(); err != nil {
+        return fmt.Errorf("stream scan_log: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// scanReportSelect picks the report columns for scans in [$1, $2); the
+// status is Expired when the plate had lapsed by the time it was scanned
+const scanReportSelect = `
+    SELECT s.scanned_at,
+           p.plate_number,
+           COALESCE(u.first_name || ' ' || u.last_name, '') AS owner_name,
+           CASE WHEN p.plate_expiration_date < s.scanned_at THEN 'Expired'
+                ELSE p.status END AS status
+      FROM scan_log s
+      JOIN plates p ON p.plate_id = s.plate_id
+      LEFT JOIN users u ON u.lto_client_id = s.lto_client_id
+     WHERE s.scanned_at >= $1 AND s.scanned_at < $2`
+
+// StreamReport sends the scans in [from, to) to out, oldest first, through a
+// cursor like StreamAll. out is closed when StreamReport returns.
+func (r *scanLogRepo) StreamReport(ctx context.Context, from, to time.Time, out chan<- models.ScanReportRow) error {
+    defer close(out)
+    ctx, cancel := WithQueryTimeout(ctx, scanStreamTimeout)
+    defer cancel()
+    rows, err := r.read.QueryxContext(ctx, scanReportSelect+` ORDER BY s.scanned_at`, from, to)
+    if err != nil {
+        return fmt.Errorf("stream scan report: %w", queryErr(ctx, err))
+    }
+    defer rows.Close()
+    for rows.Next() {
+        var row models.ScanReportRow
+        if err := rows.StructScan(&row); err != nil {
+            return fmt.Errorf("scan scan report row: %w", queryErr(ctx, err))
+        }
+        select {
+        case out <- row:
+        case <-ctx.Done():
+            return ctx.Err()
+        }
+    }
+    if err := rows.Err(); err != nil {
+        return fmt.Errorf("stream scan report: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// ReportSummary totals the scans in [from, to) for the monthly report.
+func (r *scanLogRepo) ReportSummary(ctx context.Context, from, to time.Time) (*models.ScanReportSummary, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var sum models.ScanReportSummary
+    const q = `
+    SELECT COUNT(*)                                            AS total_scans,
+           COUNT(DISTINCT s.plate_id)                          AS unique_plates,
+           COUNT(DISTINCT s.lto_client_id)                     AS unique_owners,
+           COUNT(*) FILTER (WHERE p.plate_expiration_date < s.scanned_at) AS expired_detections
+      FROM scan_log s
+      JOIN plates p ON p.plate_id = s.plate_id
+     WHERE s.scanned_at >= $1 AND s.scanned_at < $2`
+    if err := r.read.GetContext(ctx, &sum, q, from, to); err != nil {
+        return nil, fmt.Errorf("select scan report summary: %w", queryErr(ctx, err))
+    }
+    return &sum, nil
+}
+
 // GetByID retrieves a single scan log entry by its log_id.
 func (r *scanLogRepo) GetByID(ctx context.Context, id string) (*models.ScanLog, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var entry models.ScanLog
     const q = `
     SELECT
-      log_id, plate_id, registration_id, lto_client_id, scanned_at
+      log_id, plate_id, registration_id, lto_client_id, scanned_at,
+      latitude, longitude, scanner_device_id, scanner_ip
     FROM scan_log
     WHERE log_id = $1` 
     err := r.db.GetContext(ctx, &entry, q, id)
@@ -71,7 +250,276 @@ func (r *scanLogRepo) GetByID(ctx context.Context, id string) (*models.ScanLog,
         return nil, nil
     }
     if err != nil {
-        return nil, fmt.Errorf("select scan_log by id: %w", err)
+        return nil, fmt.Errorf("select scan_log by id: %w", queryErr(ctx, err))
     }
     return &entry, nil
 }
+
+// GetByIDInRegion is GetByID limited to scans whose registration was filed
+// in region
+func (r *scanLogRepo) GetByIDInRegion(ctx context.Context, id, region string) (*models.ScanLog, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var entry models.ScanLog
+    const q = `
+    SELECT
+      s.log_id, s.plate_id, s.registration_id, s.lto_client_id, s.scanned_at,
+      s.latitude, s.longitude, s.scanner_device_id, s.scanner_ip
+    FROM scan_log s
+    JOIN registration_form rf ON rf.registration_form_id = s.registration_id
+    WHERE s.log_id = $1 AND rf.region = $2`
+    err := r.db.GetContext(ctx, &entry, q, id, region)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("select scan_log by id in region: %w", queryErr(ctx, err))
+    }
+    return &entry, nil
+}
+
+// GetByDateRange retrieves up to limit scan log entries for an LTO client
+// scanned within [from, to], oldest first, with the plate number joined in.
+func (r *scanLogRepo) GetByDateRange(ctx context.Context, ltoClientID string, from, to time.Time, limit int) ([]models.ScanLog, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var logs []models.ScanLog
+    const q = `
+    SELECT
+      s.log_id, s.plate_id, s.registration_id, s.lto_client_id, s.scanned_at,
+      p.plate_number
+    FROM scan_log s
+    JOIN plates p ON p.plate_id = s.plate_id
+    WHERE s.lto_client_id = $1
+      AND s.scanned_at BETWEEN $2 AND $3
+    ORDER BY s.scanned_at ASC
+    LIMIT $4`
+    if err := r.read.SelectContext(ctx, &logs, q, ltoClientID, from, to, limit); err != nil {
+        return nil, fmt.Errorf("select scan_log by date range: %w", queryErr(ctx, err))
+    }
+    return logs, nil
+}
+
+// GetByLTOClientID returns every scan of an LTO client's plates, newest
+// first, with the plate number joined in.
+func (r *scanLogRepo) GetByLTOClientID(ctx context.Context, ltoClientID string) ([]models.ScanLog, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    logs := []models.ScanLog{}
+    const q = `
+    SELECT
+      s.log_id, s.plate_id, s.registration_id, s.lto_client_id, s.scanned_at,
+      p.plate_number
+    FROM scan_log s
+    JOIN plates p ON p.plate_id = s.plate_id
+    WHERE s.lto_client_id = $1
+    ORDER BY s.scanned_at DESC`
+    if err := r.read.SelectContext(ctx, &logs, q, ltoClientID); err != nil {
+        return nil, fmt.Errorf("select scan_log by lto_client_id: %w", queryErr(ctx, err))
+    }
+    return logs, nil
+}
+
+// GetByRegion returns every scan whose registration was filed in region,
+// newest first.
+func (r *scanLogRepo) GetByRegion(ctx context.Context, region string) ([]models.ScanLog, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    defer metrics.ObserveQuery("scan_log_get_by_region", time.Now())
+    logs := []models.ScanLog{}
+    const q = `
+    SELECT
+      s.log_id, s.plate_id, s.registration_id, s.lto_client_id, s.scanned_at
+    FROM scan_log s
+    JOIN registration_form rf ON rf.registration_form_id = s.registration_id
+    WHERE rf.region = $1
+    ORDER BY s.scanned_at DESC`
+    if err := r.read.SelectContext(ctx, &logs, q, region); err != nil {
+        return nil, fmt.Errorf("select scan_log by region: %w", queryErr(ctx, err))
+    }
+    return logs, nil
+}
+
+// DeleteOlderThan removes scans recorded before the cutoff and returns how
+// many were deleted
+func (r *scanLogRepo) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    res, err := r.db.ExecContext(ctx, `DELETE FROM scan_log WHERE scanned_at < $1`, before)
+    if err != nil {
+        return 0, fmt.Errorf("delete old scan_log: %w", queryErr(ctx, err))
+    }
+    return res.RowsAffected()
+}
+
+// GetByRegistrationID returns one page of the scans recorded against a
+// registration, newest first, with the plate number joined in.
+func (r *scanLogRepo) GetByRegistrationID(ctx context.Context, registrationID string, limit, offset int) ([]models.ScanLog, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    logs := []models.ScanLog{}
+    const q = `
+    SELECT
+      s.log_id, s.plate_id, s.registration_id, s.lto_client_id, s.scanned_at,
+      p.plate_number
+    FROM scan_log s
+    JOIN plates p ON p.plate_id = s.plate_id
+    WHERE s.registration_id = $1
+    ORDER BY s.scanned_at DESC
+    LIMIT $2 OFFSET $3`
+    if err := r.read.SelectContext(ctx, &logs, q, registrationID, limit, offset); err != nil {
+        return nil, fmt.Errorf("select scan_log by registration_id: %w", queryErr(ctx, err))
+    }
+    return logs, nil
+}
+
+// CountByRegistrationID returns how many scans were recorded against a registration
+func (r *scanLogRepo) CountByRegistrationID(ctx context.Context, registrationID string) (int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var n int
+    const q = `SELECT COUNT(*) FROM scan_log WHERE registration_id = $1`
+    if err := r.read.GetContext(ctx, &n, q, registrationID); err != nil {
+        return 0, fmt.Errorf("count scan_log by registration_id: %w", queryErr(ctx, err))
+    }
+    return n, nil
+}
+
+// HourlyBreakdown counts scans in [from, to] by hour of day, always
+// returning all 24 hours. Hours are taken in from's time zone, so callers
+// pick the zone by passing from.In(loc).
+func (r *scanLogRepo) HourlyBreakdown(ctx context.Context, from, to time.Time) ([]models.HourlyCount, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    defer metrics.ObserveQuery("scan_log_hourly_breakdown", time.Now())
+    var rows []models.HourlyCount
+    const q = `
+    SELECT EXTRACT(HOUR FROM scanned_at AT TIME ZONE $3)::int AS hour,
+           COUNT(*) AS count
+      FROM scan_log
+     WHERE scanned_at BETWEEN $1 AND $2
+     GROUP BY 1`
+    if err := r.read.SelectContext(ctx, &rows, q, from, to, from.Location().String()); err != nil {
+        return nil, fmt.Errorf("select scan_log hourly breakdown: %w", queryErr(ctx, err))
+    }
+    out := make([]models.HourlyCount, 24)
+    for h := range out {
+        out[h].Hour = h
+    }
+    for _, row := range rows {
+        if row.Hour >= 0 && row.Hour < 24 {
+            out[row.Hour].Count = row.Count
+        }
+    }
+    return out, nil
+}
+
+// CountByOfficer counts the scans in [from, to] made by each LTO officer,
+// busiest officer first. Scans are attributed by scanned_by; lto_client_id is
+// the plate's registrant.
+func (r *scanLogRepo) CountByOfficer(ctx context.Context, from, to time.Time) ([]models.OfficerScanCount, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    defer metrics.ObserveQuery("scan_log_count_by_officer", time.Now())
+    counts := []models.OfficerScanCount{}
+    const q = `
+    SELECT u.lto_client_id,
+           TRIM(u.first_name || ' ' || u.last_name) AS full_name,
+           COUNT(*) AS scan_count
+      FROM scan_log s
+      JOIN users u ON u.lto_client_id = s.scanned_by
+     WHERE u.role = $3
+       AND s.scanned_at BETWEEN $1 AND $2
+     GROUP BY u.lto_client_id, u.first_name, u.last_name
+     ORDER BY scan_count DESC, u.lto_client_id`
+    if err := r.read.SelectContext(ctx, &counts, q, from, to, models.RoleOfficer); err != nil {
+        return nil, fmt.Errorf("select scan_log counts by officer: %w", queryErr(ctx, err))
+    }
+    return counts, nil
+}
+
+// GetByDeviceID returns one page of the scans made by a scanner device,
+// newest first.
+func (r *scanLogRepo) GetByDeviceID(ctx context.Context, deviceID string, limit, offset int) ([]models.ScanLog, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    logs := []models.ScanLog{}
+    const q = `
+    SELECT
+      log_id, plate_id, registration_id, lto_client_id, scanned_at,
+      latitude, longitude, scanner_device_id, scanner_ip
+    FROM scan_log
+    WHERE scanner_device_id = $1
+    ORDER BY scanned_at DESC
+    LIMIT $2 OFFSET $3`
+    if err := r.read.SelectContext(ctx, &logs, q, deviceID, limit, offset); err != nil {
+        return nil, fmt.Errorf("select scan_log by device: %w", queryErr(ctx, err))
+    }
+    return logs, nil
+}
+
+// GetByIPAddress returns one page of the scans sent from a scanner IP,
+// newest first.
+func (r *scanLogRepo) GetByIPAddress(ctx context.Context, ip string, limit, offset int) ([]models.ScanLog, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    logs := []models.ScanLog{}
+    const q = `
+    SELECT
+      log_id, plate_id, registration_id, lto_client_id, scanned_at,
+      latitude, longitude, scanner_device_id, scanner_ip
+    FROM scan_log
+    WHERE scanner_ip = $1::inet
+    ORDER BY scanned_at DESC
+    LIMIT $2 OFFSET $3`
+    if err := r.read.SelectContext(ctx, &logs, q, ip, limit, offset); err != nil {
+        return nil, fmt.Errorf("select scan_log by ip: %w", queryErr(ctx, err))
+    }
+    return logs, nil
+}
+
+// GetTopScannerIPs returns the topN scanner IPs with the most scans in
+// [from, to], busiest first.
+func (r *scanLogRepo) GetTopScannerIPs(ctx context.Context, topN int, from, to time.Time) ([]models.ScannerIPCount, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    defer metrics.ObserveQuery("scan_log_top_scanner_ips", time.Now())
+    counts := []models.ScannerIPCount{}
+    const q = `
+    SELECT host(scanner_ip) AS ip,
+           COUNT(*) AS scan_count,
+           COUNT(DISTINCT scanner_device_id) AS device_count,
+           MAX(scanned_at) AS last_scanned_at
+      FROM scan_log
+     WHERE scanner_ip IS NOT NULL
+       AND scanned_at BETWEEN $1 AND $2
+     GROUP BY scanner_ip
+     ORDER BY scan_count DESC, scanner_ip
+     LIMIT $3`
+    if err := r.read.SelectContext(ctx, &counts, q, from, to, topN); err != nil {
+        return nil, fmt.Errorf("select scan_log top scanner ips: %w", queryErr(ctx, err))
+    }
+    return counts, nil
+}
+
+// GetByBoundingBox returns the newest scans located inside the box, at most
+// maxBoundingBoxScans of them.
+func (r *scanLogRepo) GetByBoundingBox(ctx context.Context, minLat, maxLat, minLon, maxLon float64) ([]models.ScanLog, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    defer metrics.ObserveQuery("scan_log_get_by_bounding_box", time.Now())
+    logs := []models.ScanLog{}
+    const q = `
+    SELECT
+      log_id, plate_id, registration_id, lto_client_id, scanned_at,
+      latitude, longitude, scanner_device_id, scanner_ip
+    FROM scan_log
+    WHERE latitude BETWEEN $1 AND $2
+      AND longitude BETWEEN $3 AND $4
+    ORDER BY scanned_at DESC
+    LIMIT $5`
+    if err := r.read.SelectContext(ctx, &logs, q, minLat, maxLat, minLon, maxLon, maxBoundingBoxScans); err != nil {
+        return nil, fmt.Errorf("select scan_log by bounding box: %w", queryErr(ctx, err))
+    }
+    return logs, nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "regexp"
+    "runtime"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+
+    "smartplate-api/internal/models"
+)
+
+func TestScanLogStreamAll(t *testing.T) {
+    const total = 10000
+    rw, mock := newMockReadWrite(t)
+    rows := sqlmock.NewRows([]string{"log_id", "plate_id", "registration_id", "lto_client_id", "scanned_at"})
+    start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+    for i := 0; i < total; i++ {
+        rows.AddRow(fmt.Sprintf("log-%d", i), "p1", "r1", "LTO-1", start.Add(-time.Duration(i)*time.Minute))
+    }
+    mock.ExpectQuery(`FROM scan_log\s+ORDER BY scanned_at DESC`).WillReturnRows(rows)
+
+    runtime.GC()
+    var before, now runtime.MemStats
+    runtime.ReadMemStats(&before)
+    peak := before.HeapAlloc
+
+    out := make(chan models.ScanLog, 64)
+    errc := make(chan error, 1)
+    go func() { errc <- NewScanLogRepository(rw).StreamAll(context.Background(), out) }()
+
+    n := 0
+    for entry := range out {
+        if want := fmt.Sprintf("log-%d", n); entry.LogID != want {
+            t.Fatalf("row %d is %s, want %s", n, entry.LogID, want)
+        }
+        n++
+        if n%500 == 0 {
+            runtime.ReadMemStats(&now)
+            peak = max(peak, now.HeapAlloc)
+        }
+    }
+    if err := <-errc; err != nil {
+        t.Fatal(err)
+    }
+    if n != total {
+        t.Fatalf("streamed %d rows, want %d", n, total)
+    }
+    if grew := int64(peak) - int64(before.HeapAlloc); grew > 50<<20 {
+        t.Fatalf("heap grew by %d MB while streaming, want under 50 MB", grew>>20)
+    }
+}
+
+func TestScanLogStreamAllClosesOnError(t *testing.T) {
+    boom := errors.New("boom")
+    tests := []struct {
+        name    string
+        setup   func(mock sqlmock.Sqlmock)
+        wantErr error
+    }{
+        {"query fails", func(mock sqlmock.Sqlmock) {
+            mock.ExpectQuery(`FROM scan_log`).WillReturnError(boom)
+        }, boom},
+        {"row fails", func(mock sqlmock.Sqlmock) {
+            rows := sqlmock.NewRows([]string{"log_id", "plate_id", "registration_id", "lto_client_id", "scanned_at"}).
+                AddRow("log-0", "p1", "r1", "LTO-1", time.Now()).
+                RowError(0, boom)
+            mock.ExpectQuery(`FROM scan_log`).WillReturnRows(rows)
+        }, boom},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rw, mock := newMockReadWrite(t)
+            tt.setup(mock)
+            out := make(chan models.ScanLog)
+            errc := make(chan error, 1)
+            go func() { errc <- NewScanLogRepository(rw).StreamAll(context.Background(), out) }()
+            for range out {
+            }
+            if err := <-errc; !errors.Is(err, tt.wantErr) {
+                t.Fatalf("err = %v, want %v", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestScanLogHourlyBreakdown(t *testing.T) {
+    manila, err := time.LoadLocation("Asia/Manila")
+    if err != nil {
+        t.Skip("no Asia/Manila zone data:", err)
+    }
+    tests := []struct {
+        name     string
+        loc      *time.Location
+        wantZone string
+    }{
+        {"utc", time.UTC, "UTC"},
+        {"manila", manila, "Asia/Manila"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rw, mock := newMockReadWrite(t)
+            from := time.Date(2026, 3, 1, 0, 0, 0, 0, tt.loc)
+            to := from.AddDate(0, 0, 7)
+            mock.ExpectQuery(`EXTRACT\(HOUR FROM scanned_at AT TIME ZONE \$3\)`).
+                WithArgs(from, to, tt.wantZone).
+                WillReturnRows(sqlmock.NewRows([]string{"hour", "count"}).
+                    AddRow(0, 4).
+                    AddRow(8, 12).
+                    AddRow(23, 1))
+
+            counts, err := NewScanLogRepository(rw).HourlyBreakdown(context.Background(), from, to)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if len(counts) != 24 {
+                t.Fatalf("got %d hours, want 24", len(counts))
+            }
+            want := map[int]int{0: 4, 8: 12, 23: 1}
+            for hour, c := range counts {
+                if c.Hour != hour || c.Count != want[hour] {
+                    t.Errorf("hour %d: got %+v, want %d scans", hour, c, want[hour])
+                }
+            }
+        })
+    }
+}
+
+func TestScanLogCountByOfficer(t *testing.T) {
+    rw, mock := newMockReadWrite(t)
+    from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+    to := from.AddDate(0, 1, 0)
+    mock.ExpectQuery(regexp.QuoteMeta(`FROM scan_log s JOIN users u ON u.lto_client_id = s.scanned_by WHERE u.role = $3 AND s.scanned_at BETWEEN $1 AND $2 GROUP BY u.lto_client_id, u.first_name, u.last_name ORDER BY scan_count DESC, u.lto_client_id`)).
+        WithArgs(from, to, models.RoleOfficer).
+        WillReturnRows(sqlmock.NewRows([]string{"lto_client_id", "full_name", "scan_count"}).
+            AddRow("LTO-9", "Ana Reyes", 12).
+            AddRow("LTO-3", "Ben Cruz", 4))
+
+    got, err := NewScanLogRepository(rw).CountByOfficer(context.Background(), from, to)
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []models.OfficerScanCount{
+        {LTOClientID: "LTO-9", FullName: "Ana Reyes", ScanCount: 12},
+        {LTOClientID: "LTO-3", FullName: "Ben Cruz", ScanCount: 4},
+    }
+    if fmt.Sprint(got) != fmt.Sprint(want) {
+        t.Fatalf("got %+v, want %+v", got, want)
+    }
+}
+
+func TestScanLogCreateRecordsScanner(t *testing.T) {
+    rw, mock := newMockReadWrite(t)
+    officer := "LTO-9"
+    at := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+    mock.ExpectExec(regexp.QuoteMeta("latitude, longitude, scanner_device_id, scanner_ip, scanned_by ) VALUES ( gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9 )")).
+        WithArgs("p1", "r1", "LTO-1", at, nil, nil, nil, nil, &officer).
+        WillReturnResult(sqlmock.NewResult(0, 1))
+
+    entry := &models.ScanLog{PlateID: "p1", RegistrationID: "r1", LTOClientID: "LTO-1", ScannedAt: at, ScannedBy: &officer}
+    if err := NewScanLogRepository(rw).Create(context.Background(), entry); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestScanLogGetByIDInRegion(t *testing.T) {
+    tests := []struct {
+        name  string
+        found bool
+    }{
+        {"registration in the region", true},
+        {"registration elsewhere", false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rw, mock := newMockReadWrite(t)
+            rows := sqlmock.NewRows([]string{"log_id", "plate_id", "registration_id", "lto_client_id", "scanned_at"})
+            if tt.found {
+                rows.AddRow("s1", "p1", "r1", "LTO-1", time.Now())
+            }
+            mock.ExpectQuery(regexp.QuoteMeta("FROM scan_log s JOIN registration_form rf ON rf.registration_form_id = s.registration_id WHERE s.log_id = $1 AND rf.region = $2")).
+                WithArgs("s1", "NCR").
+                WillReturnRows(rows)
+
+            entry, err := NewScanLogRepository(rw).GetByIDInRegion(context.Background(), "s1", "NCR")
+            if err != nil {
+                t.Fatal(err)
+            }
+            if (entry != nil) != tt.found {
+                t.Fatalf("got %+v, want found = %v", entry, tt.found)
+            }
+        })
+    }
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "smartplate-api/internal/models"
+
+    "github.com/jmoiron/sqlx"
+)
+
+// SessionRepository stores issued tokens so they can be listed and revoked.
+type SessionRepository interface {
+    Create(ctx context.Context, s *models.Session) error
+    ListActive(ctx context.Context, ltoClientID string) ([]models.Session, error)
+    Revoke(ctx context.Context, sessionID, ltoClientID string) error
+    RevokeAll(ctx context.Context, ltoClientID string) (int64, error)
+    IsRevoked(ctx context.Context, sessionID string) (bool, error)
+}
+
+type sessionRepo struct {
+    db *sqlx.DB
+}
+
+// NewSessionRepository returns a SessionRepository backed by sqlx.DB.
+func NewSessionRepository(db *sqlx.DB) SessionRepository {
+    return &sessionRepo{db: db}
+}
+
+// Create inserts a session and fills in its id and issue time.
+func (r *sessionRepo) Create(ctx context.Context, s *models.Session) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    INSERT INTO sessions (lto_client_id, expires_at, user_agent, ip_address)
+    VALUES ($1, $2, $3, $4)
+    RETURNING session_id, issued_at`
+    if err := r.db.QueryRowxContext(ctx, q, s.LTOClientID, s.ExpiresAt, s.UserAgent, s.IPAddress).
+        Scan(&s.SessionID, &s.IssuedAt); err != nil {
+        return fmt.Errorf("insert session: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// ListActive returns the client's unrevoked, unexpired sessions, newest first.
+func (r *sessionRepo) ListActive(ctx context.Context, ltoClientID string) ([]models.Session, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.Session{}
+    const q = `
+    SELECT session_id, lto_client_id, issued_at, expires_at, user_agent, ip_address, revoked_at
+      FROM sessions
+     WHERE lto_client_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+     ORDER BY issued_at DESC`
+    if err := r.db.SelectContext(ctx, &list, q, ltoClientID); err != nil {
+        return nil, fmt.Errorf("select sessions: %w", queryErr(ctx, err))
+    }
+    return list, nil
+}
+
+// Revoke revokes one of the client's sessions. It returns sql.ErrNoRows if
+// the session isn't theirs or is already revoked.
+func (r *sessionRepo) Revoke(ctx context.Context, sessionID, ltoClientID string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    UPDATE sessions SET revoked_at = NOW()
+     WHERE session_id = $1 AND lto_client_id = $2 AND revoked_at IS NULL`
+    res, err := r.db.ExecContext(ctx, q, sessionID, ltoClientID)
+    if err != nil {
+        return fmt.Errorf("revoke session: %w", queryErr(ctx, err))
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+    return nil
+}
+
+// RevokeAll revokes every live session of the client and reports how many.
+func (r *sessionRepo) RevokeAll(ctx context.Context, ltoClientID string) (int64, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    UPDATE sessions SET revoked_at = NOW()
+     WHERE lto_client_id = $1 AND revoked_at IS NULL AND expires_at > NOW()`
+    res, err := r.db.ExecContext(ctx, q, ltoClientID)
+    if err != nil {
+        return 0, fmt.Errorf("revoke sessions: %w", queryErr(ctx, err))
+    }
+    return res.RowsAffected()
+}
+
+// IsRevoked reports whether the session has been revoked. Unknown ids are
+// treated as revoked, since every issued token has a session row.
+func (r *sessionRepo) IsRevoked(ctx context.Context, sessionID string) (bool, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var revoked bool
+    const q = `SELECT revoked_at IS NOT NULL FROM sessions WHERE session_id = $1`
+    err := r.db.GetContext(ctx, &revoked, q, sessionID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return true, nil
+    }
+    if err != nil {
+        return false, queryErr(ctx, err)
+    }
+    return revoked, nil
+}
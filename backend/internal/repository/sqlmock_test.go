@@ -0,0 +1,34 @@
+package repository
+
+import (
+    "testing"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/jmoiron/sqlx"
+
+    "smartplate-api/internal/database"
+)
+
+// newMockDB returns a sqlx handle backed by sqlmock. The test fails if any
+// expectation is left unmet.
+func newMockDB(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+    t.Helper()
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("sqlmock: %v", err)
+    }
+    t.Cleanup(func() {
+        if err := mock.ExpectationsWereMet(); err != nil {
+            t.Error(err)
+        }
+        db.Close()
+    })
+    return sqlx.NewDb(db, "postgres"), mock
+}
+
+// newMockReadWrite is newMockDB for repositories that take a ReadWriteDB,
+// with reads and writes going to the same mock
+func newMockReadWrite(t *testing.T) (database.ReadWriteDB, sqlmock.Sqlmock) {
+    db, mock := newMockDB(t)
+    return database.ReadWriteDB{Write: db, Read: db}, mock
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/jmoiron/sqlx"
+)
+
+// Transactor opens transactions so handlers can group writes that span
+// several repositories
+type Transactor interface {
+    BeginTx(ctx context.Context) (*sqlx.Tx, error)
+    WithTx(ctx context.Context, fn func(*sqlx.Tx) error) error
+}
+
+type sqlxTransactor struct {
+    db *sqlx.DB
+}
+
+// NewTransactor returns a Transactor backed by sqlx.DB.
+func NewTransactor(db *sqlx.DB) Transactor {
+    return &sqlxTransactor{db: db}
+}
+
+func (t *sqlxTransactor) BeginTx(ctx context.Context) (*sqlx.Tx, error) {
+    return t.db.BeginTxx(ctx, nil)
+}
+
+// WithTx runs fn inside a transaction, committing if it returns nil and
+// rolling back if it returns an error or panics
+func (t *sqlxTransactor) WithTx(ctx context.Context, fn func(*sqlx.Tx) error) error {
+    tx, err := t.BeginTx(ctx)
+    if err != nil {
+        return fmt.Errorf("begin tx: %w", err)
+    }
+    defer func() {
+        if p := recover(); p != nil {
+            tx.Rollback()
+            panic(p)
+        }
+    }()
+
+    if err := fn(tx); err != nil {
+        if rerr := tx.Rollback(); rerr != nil {
+            return fmt.Errorf("%w (rollback: %v)", err, rerr)
+        }
+        return err
+    }
+    return tx.Commit()
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "testing"
+
+    "github.com/jmoiron/sqlx"
+)
+
+func TestWithTx(t *testing.T) {
+    boom := errors.New("boom")
+    tests := []struct {
+        name        string
+        fnErr       error
+        rollbackErr error
+        wantErr     error
+        wantCommit  bool
+    }{
+        {"success commits", nil, nil, nil, true},
+        {"failure rolls back", boom, nil, boom, false},
+        {"failed rollback is reported", boom, errors.New("conn reset"), boom, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db, mock := newMockDB(t)
+            mock.ExpectBegin()
+            switch {
+            case tt.wantCommit:
+                mock.ExpectCommit()
+            case tt.rollbackErr != nil:
+                mock.ExpectRollback().WillReturnError(tt.rollbackErr)
+            default:
+                mock.ExpectRollback()
+            }
+
+            err := NewTransactor(db).WithTx(context.Background(), func(tx *sqlx.Tx) error {
+                return tt.fnErr
+            })
+            if !errors.Is(err, tt.wantErr) {
+                t.Fatalf("err = %v, want %v", err, tt.wantErr)
+            }
+            if tt.rollbackErr != nil && !strings.Contains(err.Error(), tt.rollbackErr.Error()) {
+                t.Fatalf("err %q doesn't mention the rollback failure", err)
+            }
+        })
+    }
+}
+
+func TestWithTxRollsBackOnPanic(t *testing.T) {
+    db, mock := newMockDB(t)
+    mock.ExpectBegin()
+    mock.ExpectRollback()
+
+    defer func() {
+        if p := recover(); p != "kaboom" {
+            t.Fatalf("recovered %v, want the original panic", p)
+        }
+    }()
+    NewTransactor(db).WithTx(context.Background(), func(tx *sqlx.Tx) error {
+        panic("kaboom")
+    })
+}
+
+func TestWithTxBeginFails(t *testing.T) {
+    db, mock := newMockDB(t)
+    boom := errors.New("too many connections")
+    mock.ExpectBegin().WillReturnError(boom)
+
+    called := false
+    err := NewTransactor(db).WithTx(context.Background(), func(tx *sqlx.Tx) error {
+        called = true
+        return nil
+    })
+    if !errors.Is(err, boom) || called {
+        t.Fatalf("err = %v, fn called = %v", err, called)
+    }
+}
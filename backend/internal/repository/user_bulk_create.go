@@ -0,0 +1,216 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "strings"
+
+    "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+
+    "smartplate-api/internal/models"
+)
+
+// BulkCreateError explains why one user passed to BulkCreate wasn't inserted.
+// Index is its position in the slice.
+type BulkCreateError struct {
+    Index  int    `json:"index"`
+    Email  string `json:"email"`
+    Reason string `json:"reason"`
+}
+
+// Reasons BulkCreate gives for skipping a user
+const (
+    ReasonEmailTaken       = "email already registered"
+    ReasonLTOClientIDTaken = "lto_client_id already registered"
+)
+
+// valuesList returns "($1, $2), ($3, $4)" for rows of width parameters each
+func valuesList(rows, width int) string {
+    var b strings.Builder
+    n := 1
+    for i := 0; i < rows; i++ {
+        if i > 0 {
+            b.WriteString(", ")
+        }
+        b.WriteByte('(')
+        for j := 0; j < width; j++ {
+            if j > 0 {
+                b.WriteString(", ")
+            }
+            fmt.Fprintf(&b, "$%d", n)
+            n++
+        }
+        b.WriteByte(')')
+    }
+    return b.String()
+}
+
+// BulkCreate inserts users and their related rows in one transaction, with
+// one multi-row INSERT per table. Passwords must already be hashed. A user
+// whose email or lto_client_id is already registered, or repeats an earlier
+// one in users, is skipped and reported in errs with ReasonEmailTaken or
+// ReasonLTOClientIDTaken; err is only set when the whole batch failed.
+// Keep batches to a few thousand users: Postgres allows 65535 parameters
+// per statement.
+func (r *userRepo) BulkCreate(ctx context.Context, users []*models.User) (succeeded, failed int, errs []BulkCreateError, err error) {
+    if len(users) == 0 {
+        return 0, 0, nil, nil
+    }
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return 0, 0, nil, queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+
+    args := make([]interface{}, 0, len(users)*9)
+    for _, u := range users {
+        args = append(args, u.LAST_NAME, u.FIRST_NAME, u.MIDDLE_NAME, u.EMAIL, u.PASSWORD,
+            u.ROLE, u.STATUS, u.REGION, u.LTO_CLIENT_ID)
+    }
+    q := `
+    INSERT INTO users (
+        last_name, first_name, middle_name, email, password,
+        role, status, region, lto_client_id
+    ) VALUES ` + valuesList(len(users), 9) + `
+    ON CONFLICT DO NOTHING
+    RETURNING email, user_id, created, updated`
+    rows, err := tx.QueryxContext(ctx, q, args...)
+    if err != nil {
+        return 0, 0, nil, fmt.Errorf("bulk insert users: %w", queryErr(ctx, err))
+    }
+    inserted := map[string]models.User{}
+    for rows.Next() {
+        var row models.User
+        if err := rows.Scan(&row.EMAIL, &row.USER_ID, &row.CREATED, &row.UPDATED); err != nil {
+            rows.Close()
+            return 0, 0, nil, queryErr(ctx, err)
+        }
+        inserted[row.EMAIL] = row
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return 0, 0, nil, queryErr(ctx, err)
+    }
+
+    // RETURNING only yields the rows that went in; match them back by email.
+    // Within one statement the first of two equal emails is the one kept.
+    created := make([]*models.User, 0, len(inserted))
+    var skipped []int
+    for i, u := range users {
+        row, ok := inserted[u.EMAIL]
+        if !ok {
+            skipped = append(skipped, i)
+            continue
+        }
+        delete(inserted, u.EMAIL)
+        u.USER_ID, u.CREATED, u.UPDATED = row.USER_ID, row.CREATED, row.UPDATED
+        created = append(created, u)
+    }
+    if len(skipped) > 0 {
+        if errs, err = skipReasons(ctx, tx, users, skipped); err != nil {
+            return 0, 0, nil, err
+        }
+    }
+
+    if len(created) > 0 {
+        if err := bulkInsertUserDetails(ctx, tx, created); err != nil {
+            return 0, 0, nil, err
+        }
+    }
+    if err := tx.Commit(); err != nil {
+        return 0, 0, nil, queryErr(ctx, err)
+    }
+    return len(created), len(errs), errs, nil
+}
+
+// skipReasons explains each skipped user: its email is registered, or
+// failing that its lto_client_id must be
+func skipReasons(ctx context.Context, tx *sqlx.Tx, users []*models.User, skipped []int) ([]BulkCreateError, error) {
+    emails := make([]string, len(skipped))
+    for i, idx := range skipped {
+        emails[i] = users[idx].EMAIL
+    }
+    var taken []string
+    if err := tx.SelectContext(ctx, &taken, `SELECT email FROM users WHERE email = ANY($1)`, pq.Array(emails)); err != nil {
+        return nil, fmt.Errorf("look up skipped emails: %w", queryErr(ctx, err))
+    }
+    registered := make(map[string]bool, len(taken))
+    for _, e := range taken {
+        registered[e] = true
+    }
+    errs := make([]BulkCreateError, len(skipped))
+    for i, idx := range skipped {
+        reason := ReasonLTOClientIDTaken
+        if registered[users[idx].EMAIL] {
+            reason = ReasonEmailTaken
+        }
+        errs[i] = BulkCreateError{Index: idx, Email: users[idx].EMAIL, Reason: reason}
+    }
+    return errs, nil
+}
+
+// bulkInsertUserDetails writes the contact, address, medical, family and
+// personal rows Create makes for each user
+func bulkInsertUserDetails(ctx context.Context, tx *sqlx.Tx, users []*models.User) error {
+    tables := []struct {
+        name string
+        cols string
+        row  func(u *models.User) []interface{}
+    }{
+        {"contacts", `lto_client_id, telephone_number, int_area_code, mobile_number, emergency_contact_number,
+            emergency_contact_name, emergency_contact_relationship, emergency_contact_address`,
+            func(u *models.User) []interface{} {
+                c := u.Contact
+                return []interface{}{u.LTO_CLIENT_ID, toNullString(c.TELEPHONE_NUMBER), toNullString(c.INT_AREA_CODE), toNullString(c.MOBILE_NUMBER),
+                    toNullString(c.EMERGENCY_CONTACT_NUMBER), toNullString(c.EMERGENCY_CONTACT_NAME),
+                    toNullString(c.EMERGENCY_CONTACT_RELATIONSHIP), toNullString(c.EMERGENCY_CONTACT_ADDRESS)}
+            }},
+        {"addresses", `lto_client_id, house_no, street, province, city_municipality, barangay, zip_code`,
+            func(u *models.User) []interface{} {
+                a := u.Address
+                return []interface{}{u.LTO_CLIENT_ID, toNullString(a.HOUSE_NO), toNullString(a.STREET),
+                    toNullString(a.PROVINCE), toNullString(a.CITY_MUNICIPALITY), toNullString(a.BARANGAY),
+                    toNullString(a.ZIP_CODE)}
+            }},
+        {"medical_information", `lto_client_id, gender, blood_type, complexion, eye_color, hair_color,
+            weight, height, organ_donor`,
+            func(u *models.User) []interface{} {
+                m := u.MedicalInformation
+                return []interface{}{u.LTO_CLIENT_ID, toNullString(m.GENDER), toNullString(m.BLOOD_TYPE),
+                    toNullString(m.COMPLEXION), toNullString(m.EYE_COLOR), toNullString(m.HAIR_COLOR),
+                    m.WEIGHT, m.HEIGHT, m.ORGAN_DONOR}
+            }},
+        {"people", `lto_client_id, employer_name, employer_address, mother_first_name, mother_maiden_name,
+            mother_middle_name, father_first_name, father_middle_name, father_last_name, address`,
+            func(u *models.User) []interface{} {
+                p := u.People
+                return []interface{}{u.LTO_CLIENT_ID, p.EMPLOYER_NAME, p.EMPLOYER_ADDRESS, p.MOTHER_FIRST_NAME,
+                    p.MOTHER_MAIDEN_NAME, p.MOTHER_MIDDLE_NAME, p.FATHER_FIRST_NAME, p.FATHER_MIDDLE_NAME,
+                    p.FATHER_LAST_NAME, p.ADDRESS}
+            }},
+        {"personal_information", `lto_client_id, nationality, civil_status, date_of_birth, place_of_birth,
+            educational_attainment, tin`,
+            func(u *models.User) []interface{} {
+                p := u.PersonalInformation
+                return []interface{}{u.LTO_CLIENT_ID, p.NATIONALITY, p.CIVIL_STATUS, p.DATE_OF_BIRTH,
+                    p.PLACE_OF_BIRTH, p.EDUCATIONAL_ATTAINMENT, p.TIN}
+            }},
+    }
+    for _, t := range tables {
+        var args []interface{}
+        width := 0
+        for _, u := range users {
+            row := t.row(u)
+            width = len(row)
+            args = append(args, row...)
+        }
+        q := `INSERT INTO ` + t.name + ` (` + t.cols + `) VALUES ` + valuesList(len(users), width)
+        if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+            return fmt.Errorf("bulk insert %s: %w", t.name, queryErr(ctx, err))
+        }
+    }
+    return nil
+}
@@ -1,22 +1,63 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"smartplate-api/internal/metrics"
 	"smartplate-api/internal/models"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
-type UserRepository  struct{
+// UserRepository manages users and their contact, address, medical, family
+// and personal information rows.
+type UserRepository interface {
+	Create(user *models.User) error
+	BulkCreate(ctx context.Context, users []*models.User) (succeeded, failed int, errs []BulkCreateError, err error)
+	GetAll(limit, offset int) ([]models.User, int, error)
+	GetByID(userID int) (models.User, error)
+	GetByLTOClientID(ltoClientID string) (models.User, error)
+	GetByEmail(email string) (models.User, error)
+	GetByGoogleID(googleID string) (models.User, error)
+	LinkGoogleID(ltoClientID, googleID string) error
+	GetByRole(role string, limit, offset int) ([]models.User, int, error)
+	GetByRegion(region string, limit, offset int) ([]models.User, int, error)
+	Search(q string, limit, offset int) ([]models.User, int, error)
+	List(filter UserFilter) ([]models.User, int, error)
+	UpdateRole(ltoClientID, role string) error
+	UpdateRegion(ltoClientID, region string) error
+	UpdatePasswordHash(ctx context.Context, ltoClientID, oldHash, newHash string) error
+	Update(user *models.User) error
+	UpdateProfile(ltoClientID string, update models.UserProfileUpdate) error
+	Delete(ltoClientID string) error
+	Restore(ltoClientID string) error
+	RecordLogin(ltoClientID string) error
+	GetInactiveUsers(since time.Time) ([]models.User, error)
+	MarkInactivityEmailed(ltoClientID string) error
+}
+
+// UserFilter narrows List; empty fields are ignored
+type UserFilter struct {
+	Role   string
+	Status string
+	Region string
+	Limit  int
+	Offset int
+}
+
+type userRepo struct {
 	db *sqlx.DB
 }
 
-func NewUserRepository(db *sqlx.DB) *UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(db *sqlx.DB) UserRepository {
+	return &userRepo{db: db}
 }
 
 //create a new user
-func (r *UserRepository) Create(user *models.User) error {
+func (r *userRepo) Create(user *models.User) error {
     tx := r.db.MustBegin()
 
     // Insert user with explicit parameter binding
@@ -172,8 +213,9 @@ func toNullString(s *string) interface{} {
 }
 
 
-func (r *UserRepository) GetAll() ([]models.User, error) {
-    const query = `
+// userSelect reads a user with all of its related rows; callers append
+// WHERE/ORDER BY/LIMIT
+const userSelect = `
     SELECT 
         u.*,
         c.contact_id AS "contact.contact_id",
@@ -228,15 +270,73 @@ func (r *UserRepository) GetAll() ([]models.User, error) {
     LEFT JOIN medical_information m ON u.lto_client_id = m.lto_client_id
     LEFT JOIN people p ON u.lto_client_id = p.lto_client_id
     LEFT JOIN personal_information pi ON u.lto_client_id = pi.lto_client_id
-    ORDER BY u.user_id
 `
-    var users []models.User
-    err := r.db.Select(&users, query)
-    return users, err
+
+// userPage runs userSelect for the live users matching cond, a condition on
+// users u ("" for all of them), and returns one page plus the total number
+// of matching users
+func (r *userRepo) userPage(cond string, args []interface{}, limit, offset int) ([]models.User, int, error) {
+    where := "WHERE u.deleted_at IS NULL"
+    if cond != "" {
+        where += " AND (" + cond + ")"
+    }
+    var total int
+    if err := r.db.Get(&total, "SELECT COUNT(*) FROM users u "+where, args...); err != nil {
+        return nil, 0, err
+    }
+    n := len(args)
+    query := fmt.Sprintf("%s %s ORDER BY u.user_id LIMIT $%d OFFSET $%d", userSelect, where, n+1, n+2)
+    users := []models.User{}
+    if err := r.db.Select(&users, query, append(args, limit, offset)...); err != nil {
+        return nil, 0, err
+    }
+    return users, total, nil
+}
+
+// GetAll returns one page of users ordered by id plus the total count
+func (r *userRepo) GetAll(limit, offset int) ([]models.User, int, error) {
+    return r.userPage("", nil, limit, offset)
+}
+
+// GetByRole returns one page of users with the given role plus the total count
+func (r *userRepo) GetByRole(role string, limit, offset int) ([]models.User, int, error) {
+    return r.userPage("u.role = $1", []interface{}{role}, limit, offset)
+}
+
+// GetByRegion returns one page of users assigned to region plus the total count
+func (r *userRepo) GetByRegion(region string, limit, offset int) ([]models.User, int, error) {
+    return r.userPage("u.region = $1", []interface{}{region}, limit, offset)
+}
+
+// List returns one page of users matching every non-empty filter field
+func (r *userRepo) List(filter UserFilter) ([]models.User, int, error) {
+    conds := []string{}
+    args := []interface{}{}
+    add := func(cond string, val interface{}) {
+        args = append(args, val)
+        conds = append(conds, fmt.Sprintf(cond, len(args)))
+    }
+    if filter.Role != "" {
+        add("u.role = $%d", filter.Role)
+    }
+    if filter.Status != "" {
+        add("u.status = $%d", filter.Status)
+    }
+    if filter.Region != "" {
+        add("u.region = $%d", filter.Region)
+    }
+    return r.userPage(strings.Join(conds, " AND "), args, filter.Limit, filter.Offset)
+}
+
+// Search matches q against the user's name, email and LTO client ID
+func (r *userRepo) Search(q string, limit, offset int) ([]models.User, int, error) {
+    cond := `u.first_name ILIKE $1 OR u.last_name ILIKE $1
+       OR u.email ILIKE $1 OR u.lto_client_id ILIKE $1`
+    return r.userPage(cond, []interface{}{"%" + q + "%"}, limit, offset)
 }
 
 // GetByID
-func (r *UserRepository) GetByID(user_id int) (models.User, error) {
+func (r *userRepo) GetByID(user_id int) (models.User, error) {
     var user models.User
     query := `
     SELECT 
@@ -292,14 +392,14 @@ func (r *UserRepository) GetByID(user_id int) (models.User, error) {
     LEFT JOIN medical_information m ON u.lto_client_id = m.lto_client_id
     LEFT JOIN people p ON u.lto_client_id = p.lto_client_id
     LEFT JOIN personal_information pi ON u.lto_client_id = pi.lto_client_id
-    WHERE u.user_id = $1
+    WHERE u.user_id = $1 AND u.deleted_at IS NULL
     
 `
     err := r.db.Get(&user, query, user_id)
     return user, err
 }
 
-func (r *UserRepository) GetByLTOClientID(ltoClientID string) (models.User, error) {
+func (r *userRepo) GetByLTOClientID(ltoClientID string) (models.User, error) {
     var user models.User
     fmt.Printf("Executing query with LTO ID: %s\n", ltoClientID)
     query := `
@@ -356,103 +456,98 @@ func (r *UserRepository) GetByLTOClientID(ltoClientID string) (models.User, erro
         LEFT JOIN medical_information m ON u.lto_client_id = m.lto_client_id
         LEFT JOIN people p ON u.lto_client_id = p.lto_client_id
         LEFT JOIN personal_information pi ON u.lto_client_id = pi.lto_client_id
-        WHERE u.lto_client_id = $1
+        WHERE u.lto_client_id = $1 AND u.deleted_at IS NULL
     `
     err := r.db.Get(&user, query, ltoClientID)
     return user, err
 }
 //get user by email.l
-func (r *UserRepository) GetByEmail(email string) (models.User, error){
+func (r *userRepo) GetByEmail(email string) (models.User, error){
+	defer metrics.ObserveQuery("user_get_by_email", time.Now())
 	var user models.User
-	err := r.db.Get(&user, "SELECT * FROM users WHERE email = $1", email)
+	err := r.db.Get(&user, "SELECT * FROM users WHERE email = $1 AND deleted_at IS NULL", email)
 	return user, err
 }
 
-//delete by id use
-func (r *UserRepository) Delete(user_id int) error {
+// GetByGoogleID returns the user linked to a Google account's "sub" id
+func (r *userRepo) GetByGoogleID(googleID string) (models.User, error) {
+    var user models.User
+    err := r.db.Get(&user, "SELECT * FROM users WHERE google_id = $1 AND deleted_at IS NULL", googleID)
+    return user, err
+}
+
+// LinkGoogleID records the Google account a user signs in with
+func (r *userRepo) LinkGoogleID(ltoClientID, googleID string) error {
+    res, err := r.db.Exec(
+        "UPDATE users SET google_id = $1, updated = NOW() WHERE lto_client_id = $2 AND deleted_at IS NULL",
+        googleID, ltoClientID,
+    )
+    if err != nil {
+        return err
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+    return nil
+}
+
+// Delete soft-deletes a user: it stamps deleted_at, deactivates the plates
+// on their vehicles and invalidates any unused password reset tokens.
+// Restore does not reactivate the plates. The plates are updated in SQL, so
+// a plate cache must be told; see CachingPlateRepository.ForgetUserPlates.
+func (r *userRepo) Delete(ltoClientID string) error {
     tx, err := r.db.Beginx()
     if err != nil {
         return fmt.Errorf("failed to begin transaction: %w", err)
     }
+    defer tx.Rollback()
 
-    // 1) Fetch the LTO ID for this user_id
-    var ltoID string
-    if err := tx.Get(
-        &ltoID,
-        `SELECT lto_client_id FROM users WHERE user_id = $1`,
-        user_id,
-    ); err != nil {
-        tx.Rollback()
-        return fmt.Errorf("failed to lookup lto_client_id: %w", err)
-    }
-
-    // 2) Delete all dependent records by lto_client_id
-    for _, tbl := range []string{
-        "personal_information",
-        "people",
-        "medical_information",
-        "addresses",
-        "contacts",
-    } {
-        if _, err := tx.Exec(
-            fmt.Sprintf("DELETE FROM %s WHERE lto_client_id = $1", tbl),
-            ltoID,
-        ); err != nil {
-            tx.Rollback()
-            return fmt.Errorf("failed to delete %s: %w", tbl, err)
-        }
+    res, err := tx.Exec(
+        "UPDATE users SET deleted_at = NOW(), updated = NOW() WHERE lto_client_id = $1 AND deleted_at IS NULL",
+        ltoClientID,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to delete user: %w", err)
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+
+    if _, err := tx.Exec(`
+        UPDATE plates SET status = 'Deactivated'
+         WHERE status = 'Active'
+           AND vehicle_id IN (SELECT vehicle_id FROM vehicles WHERE lto_client_id = $1)
+    `, ltoClientID); err != nil {
+        return fmt.Errorf("failed to deactivate plates: %w", err)
     }
 
-    // 3) Now delete the user row itself
     if _, err := tx.Exec(
-        "DELETE FROM users WHERE user_id = $1",
-        user_id,
+        "UPDATE password_reset_token SET used_at = NOW() WHERE lto_client_id = $1 AND used_at IS NULL",
+        ltoClientID,
     ); err != nil {
-        tx.Rollback()
-        return fmt.Errorf("failed to delete user: %w", err)
+        return fmt.Errorf("failed to invalidate reset tokens: %w", err)
     }
 
     return tx.Commit()
 }
 
-//delete user by lto_client_id
-func (r *UserRepository) DeleteByLTOClientID(ltoID string) error {
-    tx, err := r.db.Beginx()
+// Restore clears deleted_at on a soft-deleted user
+func (r *userRepo) Restore(ltoClientID string) error {
+    res, err := r.db.Exec(
+        "UPDATE users SET deleted_at = NULL, updated = NOW() WHERE lto_client_id = $1 AND deleted_at IS NOT NULL",
+        ltoClientID,
+    )
     if err != nil {
-        return err
+        return fmt.Errorf("failed to restore user: %w", err)
     }
-    // Delete all dependents
-    for _, tbl := range []string{
-        "personal_information",
-        "people",
-        "medical_information",
-        "addresses",
-        "contacts",
-    } {
-        if _, err := tx.Exec(
-            fmt.Sprintf("DELETE FROM %s WHERE lto_client_id = $1", tbl),
-            ltoID,
-        ); err != nil {
-            tx.Rollback()
-            return fmt.Errorf("failed to delete %s: %w", tbl, err)
-        }
-    }
-
-    // Now delete the user by LTO rather than user_id
-    if _, err := tx.Exec(
-        "DELETE FROM users WHERE lto_client_id = $1",
-        ltoID,
-    ); err != nil {
-        tx.Rollback()
-        return fmt.Errorf("failed to delete user: %w", err)
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
     }
-
-    return tx.Commit()
+    return nil
 }
 
-
 //update user
-func (r *UserRepository) Update(user *models.User) error {
+func (r *userRepo) Update(user *models.User) error {
     tx, err := r.db.Beginx()
     if err != nil {
         return fmt.Errorf("failed to begin transaction: %w", err)
@@ -649,3 +744,148 @@ if err != nil {
 
     return tx.Commit()
 }
+
+// UpdateProfile applies the non-nil fields of update to the user and their
+// contact and address rows in one transaction
+func (r *userRepo) UpdateProfile(ltoClientID string, update models.UserProfileUpdate) error {
+    tx, err := r.db.Beginx()
+    if err != nil {
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    res, err := tx.Exec(`
+        UPDATE users SET
+            first_name  = COALESCE($1, first_name),
+            middle_name = COALESCE($2, middle_name),
+            last_name   = COALESCE($3, last_name),
+            updated     = NOW()
+        WHERE lto_client_id = $4
+    `, update.FirstName, update.MiddleName, update.LastName, ltoClientID)
+    if err != nil {
+        return fmt.Errorf("user update failed: %w", err)
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+
+    if c := update.Contact; c != nil {
+        if _, err := tx.Exec(`
+            INSERT INTO contacts (
+                lto_client_id, telephone_number, mobile_number, emergency_contact_number,
+                emergency_contact_name, emergency_contact_relationship, emergency_contact_address
+            ) VALUES ($1, $2, $3, $4, $5, $6, $7)
+            ON CONFLICT (lto_client_id) DO UPDATE SET
+                telephone_number               = COALESCE(EXCLUDED.telephone_number, contacts.telephone_number),
+                mobile_number                  = COALESCE(EXCLUDED.mobile_number, contacts.mobile_number),
+                emergency_contact_number       = COALESCE(EXCLUDED.emergency_contact_number, contacts.emergency_contact_number),
+                emergency_contact_name         = COALESCE(EXCLUDED.emergency_contact_name, contacts.emergency_contact_name),
+                emergency_contact_relationship = COALESCE(EXCLUDED.emergency_contact_relationship, contacts.emergency_contact_relationship),
+                emergency_contact_address      = COALESCE(EXCLUDED.emergency_contact_address, contacts.emergency_contact_address)
+        `, ltoClientID, c.TelephoneNumber, c.MobileNumber, c.EmergencyContactNumber,
+            c.EmergencyContactName, c.EmergencyContactRelationship, c.EmergencyContactAddress,
+        ); err != nil {
+            return fmt.Errorf("contact update failed: %w", err)
+        }
+    }
+
+    if a := update.Address; a != nil {
+        if _, err := tx.Exec(`
+            INSERT INTO addresses (
+                lto_client_id, house_no, street, province, city_municipality, barangay, zip_code
+            ) VALUES ($1, $2, $3, $4, $5, $6, $7)
+            ON CONFLICT (lto_client_id) DO UPDATE SET
+                house_no          = COALESCE(EXCLUDED.house_no, addresses.house_no),
+                street            = COALESCE(EXCLUDED.street, addresses.street),
+                province          = COALESCE(EXCLUDED.province, addresses.province),
+                city_municipality = COALESCE(EXCLUDED.city_municipality, addresses.city_municipality),
+                barangay          = COALESCE(EXCLUDED.barangay, addresses.barangay),
+                zip_code          = COALESCE(EXCLUDED.zip_code, addresses.zip_code)
+        `, ltoClientID, a.HouseNo, a.Street, a.Province, a.CityMunicipality, a.Barangay, a.ZipCode,
+        ); err != nil {
+            return fmt.Errorf("address update failed: %w", err)
+        }
+    }
+
+    return tx.Commit()
+}
+
+// RecordLogin stamps last_login_at; call it after a successful authentication
+func (r *userRepo) RecordLogin(ltoClientID string) error {
+    _, err := r.db.Exec("UPDATE users SET last_login_at = NOW() WHERE lto_client_id = $1", ltoClientID)
+    return err
+}
+
+// GetInactiveUsers returns active accounts that haven't logged in since the
+// given time (or were created before it and never logged in) and have no
+// scan_log entries since then either
+func (r *userRepo) GetInactiveUsers(since time.Time) ([]models.User, error) {
+    users := []models.User{}
+    err := r.db.Select(&users, `
+        SELECT u.*
+          FROM users u
+         WHERE u.deleted_at IS NULL
+           AND COALESCE(u.last_login_at, u.created) < $1
+           AND NOT EXISTS (
+               SELECT 1 FROM scan_log s
+                WHERE s.lto_client_id = u.lto_client_id AND s.scanned_at >= $1
+           )
+         ORDER BY COALESCE(u.last_login_at, u.created)
+    `, since)
+    return users, err
+}
+
+// MarkInactivityEmailed records that the reactivation email went out so the
+// job doesn't send it again until the user logs in and lapses once more
+func (r *userRepo) MarkInactivityEmailed(ltoClientID string) error {
+    _, err := r.db.Exec("UPDATE users SET inactivity_emailed_at = NOW() WHERE lto_client_id = $1", ltoClientID)
+    return err
+}
+
+// UpdateRole changes a user's role
+func (r *userRepo) UpdateRole(ltoClientID, role string) error {
+    res, err := r.db.Exec(
+        "UPDATE users SET role = $1, updated = NOW() WHERE lto_client_id = $2 AND deleted_at IS NULL",
+        role, ltoClientID,
+    )
+    if err != nil {
+        return err
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+    return nil
+}
+
+// UpdateRegion assigns a user to a region; an empty region clears it
+func (r *userRepo) UpdateRegion(ltoClientID, region string) error {
+    res, err := r.db.Exec(
+        "UPDATE users SET region = NULLIF($1, ''), updated = NOW() WHERE lto_client_id = $2 AND deleted_at IS NULL",
+        region, ltoClientID,
+    )
+    if err != nil {
+        return err
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+    return nil
+}
+
+// UpdatePasswordHash replaces a user's bcrypt password hash, but only while
+// it is still oldHash, so a write based on a stale read (a login's rehash
+// racing a password change) can't undo a newer password. It returns
+// sql.ErrNoRows when the user is gone or the hash has changed.
+func (r *userRepo) UpdatePasswordHash(ctx context.Context, ltoClientID, oldHash, newHash string) error {
+    res, err := r.db.ExecContext(ctx,
+        "UPDATE users SET password = $1, updated = NOW() WHERE lto_client_id = $2 AND password = $3 AND deleted_at IS NULL",
+        newHash, ltoClientID, oldHash,
+    )
+    if err != nil {
+        return err
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+    return nil
+}
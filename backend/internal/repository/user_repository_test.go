@@ -0,0 +1,128 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "fmt"
+    "regexp"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+
+    "smartplate-api/internal/models"
+)
+
+func TestUpdatePasswordHashComparesOldHash(t *testing.T) {
+    tests := []struct {
+        name     string
+        affected int64
+        wantErr  error
+    }{
+        {"hash unchanged", 1, nil},
+        {"hash changed meanwhile", 0, sql.ErrNoRows},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db, mock := newMockDB(t)
+            mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET password = $1, updated = NOW() WHERE lto_client_id = $2 AND password = $3 AND deleted_at IS NULL")).
+                WithArgs("new-hash", "LTO-1", "old-hash").
+                WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+            err := NewUserRepository(db).UpdatePasswordHash(context.Background(), "LTO-1", "old-hash", "new-hash")
+            if err != tt.wantErr {
+                t.Fatalf("err = %v, want %v", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestUserPagesHideDeletedUsers(t *testing.T) {
+    tests := []struct {
+        name  string
+        where string
+        args  []driver.Value
+        page  func(UserRepository) ([]models.User, int, error)
+    }{
+        {"all", "WHERE u.deleted_at IS NULL", nil,
+            func(r UserRepository) ([]models.User, int, error) { return r.GetAll(20, 40) }},
+        {"by role", "WHERE u.deleted_at IS NULL AND (u.role = $1)", []driver.Value{"LTO Officer"},
+            func(r UserRepository) ([]models.User, int, error) { return r.GetByRole("LTO Officer", 20, 40) }},
+        {"filtered", "WHERE u.deleted_at IS NULL AND (u.role = $1 AND u.region = $2)", []driver.Value{"user", "NCR"},
+            func(r UserRepository) ([]models.User, int, error) {
+                return r.List(UserFilter{Role: "user", Region: "NCR", Limit: 20, Offset: 40})
+            }},
+        // the OR conditions stay inside the parentheses
+        {"search", "WHERE u.deleted_at IS NULL AND (u.first_name ILIKE $1 OR u.last_name ILIKE $1 OR u.email ILIKE $1 OR u.lto_client_id ILIKE $1)", []driver.Value{"%cruz%"},
+            func(r UserRepository) ([]models.User, int, error) { return r.Search("cruz", 20, 40) }},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db, mock := newMockDB(t)
+            mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT COUNT(*) FROM users u "+tt.where) + "$").
+                WithArgs(tt.args...).
+                WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(41))
+            n := len(tt.args)
+            pageSQL := fmt.Sprintf("ON u.lto_client_id = pi.lto_client_id %s ORDER BY u.user_id LIMIT $%d OFFSET $%d", tt.where, n+1, n+2)
+            mock.ExpectQuery(regexp.QuoteMeta(pageSQL) + "$").
+                WithArgs(append(tt.args, 20, 40)...).
+                WillReturnRows(sqlmock.NewRows([]string{"user_id", "lto_client_id"}).AddRow(41, "LTO-41"))
+
+            users, total, err := tt.page(NewUserRepository(db))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if total != 41 || len(users) != 1 || users[0].LTO_CLIENT_ID != "LTO-41" {
+                t.Fatalf("got total %d and %+v", total, users)
+            }
+        })
+    }
+}
+
+func TestGetByIDHidesDeletedUsers(t *testing.T) {
+    db, mock := newMockDB(t)
+    mock.ExpectQuery(regexp.QuoteMeta("WHERE u.user_id = $1 AND u.deleted_at IS NULL")).
+        WithArgs(7).
+        WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+    if _, err := NewUserRepository(db).GetByID(7); err == nil {
+        t.Fatal("GetByID found a deleted user")
+    }
+}
+
+func TestBulkCreateExplainsSkippedUsers(t *testing.T) {
+    db, mock := newMockDB(t)
+    mock.ExpectBegin()
+    mock.ExpectQuery(regexp.QuoteMeta("ON CONFLICT DO NOTHING RETURNING email, user_id, created, updated")).
+        WillReturnRows(sqlmock.NewRows([]string{"email", "user_id", "created", "updated"}).
+            AddRow("ana@example.com", 10, time.Now(), time.Now()))
+    mock.ExpectQuery(regexp.QuoteMeta("SELECT email FROM users WHERE email = ANY($1)")).
+        WithArgs(`{"juan@example.com","ben@example.com"}`).
+        WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("juan@example.com"))
+    for _, table := range []string{"contacts", "addresses", "medical_information", "people", "personal_information"} {
+        mock.ExpectExec("INSERT INTO " + table).WillReturnResult(sqlmock.NewResult(0, 1))
+    }
+    mock.ExpectCommit()
+
+    users := []*models.User{
+        {EMAIL: "ana@example.com", LTO_CLIENT_ID: "LTO-1"},
+        {EMAIL: "juan@example.com", LTO_CLIENT_ID: "LTO-2"},
+        {EMAIL: "ben@example.com", LTO_CLIENT_ID: "LTO-3"},
+    }
+    succeeded, failed, errs, err := NewUserRepository(db).BulkCreate(context.Background(), users)
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []BulkCreateError{
+        {Index: 1, Email: "juan@example.com", Reason: ReasonEmailTaken},
+        {Index: 2, Email: "ben@example.com", Reason: ReasonLTOClientIDTaken},
+    }
+    if succeeded != 1 || failed != 2 || fmt.Sprint(errs) != fmt.Sprint(want) {
+        t.Fatalf("succeeded %d, failed %d, errors %+v, want 1, 2, %+v", succeeded, failed, errs, want)
+    }
+    if users[0].USER_ID != 10 {
+        t.Fatalf("inserted user got id %d, want 10", users[0].USER_ID)
+    }
+}
@@ -25,16 +25,21 @@ func NewRegistrationDocumentRepository(db *sqlx.DB) RegistrationDocumentReposito
 }
 
 func (r *registrationDocumentRepo) Create(ctx context.Context, d *models.RegistrationDocument) error {
-    return r.db.QueryRowxContext(ctx, `
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    err := r.db.QueryRowxContext(ctx, `
         INSERT INTO registration_document
           (registration_form_id, doc_type, filename, file_size)
         VALUES ($1, $2, $3, $4)
         RETURNING document_id, uploaded_at
     `, d.RegistrationFormID, d.DocType, d.Filename, d.FileSize).
         Scan(&d.DocumentID, &d.UploadedAt)
+    return queryErr(ctx, err)
 }
 
 func (r *registrationDocumentRepo) GetByFormID(ctx context.Context, formID string) ([]models.RegistrationDocument, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var out []models.RegistrationDocument
     err := r.db.SelectContext(ctx, &out, `
         SELECT document_id,
@@ -47,10 +52,12 @@ func (r *registrationDocumentRepo) GetByFormID(ctx context.Context, formID strin
          WHERE registration_form_id = $1
          ORDER BY uploaded_at DESC
     `, formID)
-    return out, err
+    return out, queryErr(ctx, err)
 }
 
 func (r *registrationDocumentRepo) GetByID(ctx context.Context, id string) (*models.RegistrationDocument, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var d models.RegistrationDocument
     err := r.db.GetContext(ctx, &d, `
         SELECT document_id,
@@ -63,12 +70,14 @@ func (r *registrationDocumentRepo) GetByID(ctx context.Context, id string) (*mod
          WHERE document_id = $1
     `, id)
     if err != nil {
-        return nil, err
+        return nil, queryErr(ctx, err)
     }
     return &d, nil
 }
 
 func (r *registrationDocumentRepo) Update(ctx context.Context, d *models.RegistrationDocument) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     _, err := r.db.NamedExecContext(ctx, `
         UPDATE registration_document SET
           doc_type  = :doc_type,
@@ -76,13 +85,15 @@ func (r *registrationDocumentRepo) Update(ctx context.Context, d *models.Registr
           file_size = :file_size
         WHERE document_id = :document_id
     `, d)
-    return err
+    return queryErr(ctx, err)
 }
 
 func (r *registrationDocumentRepo) Delete(ctx context.Context, id string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     _, err := r.db.ExecContext(ctx, `
         DELETE FROM registration_document
          WHERE document_id = $1
     `, id)
-    return err
+    return queryErr(ctx, err)
 }
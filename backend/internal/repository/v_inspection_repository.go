@@ -25,16 +25,21 @@ func NewRegistrationInspectionRepository(db *sqlx.DB) RegistrationInspectionRepo
 }
 
 func (r *registrationInspectionRepo) Create(ctx context.Context, i *models.RegistrationInspection) error {
-    return r.db.QueryRowxContext(ctx, `
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    err := r.db.QueryRowxContext(ctx, `
     INSERT INTO registration_inspection
       (registration_form_id, inspection_status, inspection_code, inspection_notes)
     VALUES ($1,$2,$3,$4)
     RETURNING inspection_id, inspected_at
   `, i.RegistrationFormID, i.InspectionStatus, i.InspectionCode, i.InspectionNotes).
     Scan(&i.InspectionID, &i.InspectedAt)
+    return queryErr(ctx, err)
 }
 
 func (r *registrationInspectionRepo) GetByFormID(ctx context.Context, formID string) ([]models.RegistrationInspection, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var out []models.RegistrationInspection
     err := r.db.SelectContext(ctx, &out, `
         SELECT inspection_id,
@@ -47,10 +52,12 @@ func (r *registrationInspectionRepo) GetByFormID(ctx context.Context, formID str
          WHERE registration_form_id = $1
          ORDER BY inspected_at DESC
     `, formID)
-    return out, err
+    return out, queryErr(ctx, err)
 }
 
 func (r *registrationInspectionRepo) GetByID(ctx context.Context, id string) (*models.RegistrationInspection, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var i models.RegistrationInspection
     err := r.db.GetContext(ctx, &i, `
         SELECT inspection_id,
@@ -63,12 +70,14 @@ func (r *registrationInspectionRepo) GetByID(ctx context.Context, id string) (*m
          WHERE inspection_id = $1
     `, id)
     if err != nil {
-        return nil, err
+        return nil, queryErr(ctx, err)
     }
     return &i, nil
 }
 
 func (r *registrationInspectionRepo) Update(ctx context.Context, i *models.RegistrationInspection) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     _, err := r.db.NamedExecContext(ctx, `
         UPDATE registration_inspection SET
           inspection_status = :inspection_status,
@@ -76,13 +85,15 @@ func (r *registrationInspectionRepo) Update(ctx context.Context, i *models.Regis
           inspection_notes  = :inspection_notes
         WHERE inspection_id = :inspection_id
     `, i)
-    return err
+    return queryErr(ctx, err)
 }
 
 func (r *registrationInspectionRepo) Delete(ctx context.Context, id string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     _, err := r.db.ExecContext(ctx, `
         DELETE FROM registration_inspection
          WHERE inspection_id = $1
     `, id)
-    return err
+    return queryErr(ctx, err)
 }
@@ -28,7 +28,9 @@ func (r *registrationPaymentRepo) Create(
     ctx context.Context,
     p *models.RegistrationPayment,
 ) error {
-    return r.db.
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    err := r.db.
         QueryRowxContext(ctx, `
             INSERT INTO registration_payment
               (registration_form_id, payment_status, payment_code,
@@ -46,10 +48,13 @@ func (r *registrationPaymentRepo) Create(
             p.PaymentDetails,
         ).
         Scan(&p.PaymentID)
+    return queryErr(ctx, err)
 }
 
 
 func (r *registrationPaymentRepo) GetByFormID(ctx context.Context, formID string) ([]models.RegistrationPayment, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     out := make([]models.RegistrationPayment, 0)
     err := r.db.SelectContext(ctx, &out, `
         SELECT payment_id,
@@ -65,10 +70,12 @@ func (r *registrationPaymentRepo) GetByFormID(ctx context.Context, formID string
          WHERE registration_form_id = $1
          ORDER BY payment_date DESC
     `, formID)
-    return out, err
+    return out, queryErr(ctx, err)
 }
 
 func (r *registrationPaymentRepo) GetByID(ctx context.Context, id string) (*models.RegistrationPayment, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var p models.RegistrationPayment
     err := r.db.GetContext(ctx, &p, `
         SELECT payment_id,
@@ -84,12 +91,14 @@ func (r *registrationPaymentRepo) GetByID(ctx context.Context, id string) (*mode
          WHERE payment_id = $1
     `, id)
     if err != nil {
-        return nil, err
+        return nil, queryErr(ctx, err)
     }
     return &p, nil
 }
 
 func (r *registrationPaymentRepo) Update(ctx context.Context, p *models.RegistrationPayment) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     _, err := r.db.NamedExecContext(ctx, `
         UPDATE registration_payment SET
           payment_status  = :payment_status,
@@ -101,13 +110,15 @@ func (r *registrationPaymentRepo) Update(ctx context.Context, p *models.Registra
           payment_details = :payment_details
         WHERE payment_id = :payment_id
     `, p)
-    return err
+    return queryErr(ctx, err)
 }
 
 func (r *registrationPaymentRepo) Delete(ctx context.Context, id string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     _, err := r.db.ExecContext(ctx, `
         DELETE FROM registration_payment
          WHERE payment_id = $1
     `, id)
-    return err
+    return queryErr(ctx, err)
 }
@@ -3,53 +3,108 @@ package repository
 
 import (
     "context"
+    "errors"
     "fmt"
 	"strings"
     "database/sql"
+    "smartplate-api/internal/database"
+    "smartplate-api/internal/metrics"
     "smartplate-api/internal/models"
+    "time"
 
     "github.com/jmoiron/sqlx"
 )
 
+// ErrPlateDeactivated is returned by DeactivatePlate for a plate that is
+// already deactivated
+var ErrPlateDeactivated = errors.New("plate is already deactivated")
+
 type PlateRepository interface {
     CreatePlate(ctx context.Context, p *models.Plate) (*models.Plate, error)
+    CreatePlateTx(ctx context.Context, tx *sqlx.Tx, p *models.Plate) (*models.Plate, error)
     GetPlateByID(ctx context.Context, vehicleID, plateID string) (*models.Plate, error)
-    UpdatePlate(ctx context.Context, vehicleID, plateID string, fields map[string]interface{}) error
+    UpdatePlate(ctx context.Context, vehicleID, plateID, changedBy string, fields map[string]interface{}) error
     DeletePlateByID(ctx context.Context, vehicleID, plateID string) error
   
     GetByPlateNumber(ctx context.Context, plateNumber string) (*models.Plate, error)
     GetPlatesByVehicleID(ctx context.Context, vehicleID string) ([]models.Plate, error)
+    GetPlatesByVehicleIDInRegion(ctx context.Context, vehicleID, region string) ([]models.Plate, error)
+    ExistsWithPlateNumber(ctx context.Context, number string) (bool, error)
+    BulkCreate(ctx context.Context, plates []*models.Plate) ([]error, error)
+    Search(ctx context.Context, q, status, plateType string, limit, offset int) ([]models.PlateSearchResult, error)
+    GetExpiringSoon(ctx context.Context, within time.Duration) ([]models.Plate, error)
+    GetPlateHistory(ctx context.Context, plateID string) ([]models.PlateHistory, error)
+    RestoreVersion(ctx context.Context, plateID, historyID string) error
+    GetPlateByPlateID(ctx context.Context, plateID string) (*models.Plate, error)
+    TransferPlate(ctx context.Context, t *models.PlateTransfer) error
+    RenewPlate(ctx context.Context, vehicleID, changedBy string, renewal *models.PlateRenewalHistory) error
+    DeactivatePlate(ctx context.Context, vehicleID, plateID, changedBy, reason string, effective time.Time) error
+    GetStats(ctx context.Context) (*models.PlateStats, error)
+    GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.Plate, int, error)
+    GetByType(ctx context.Context, plateType string, limit, offset int) ([]models.Plate, int, error)
+    GetByOwner(ctx context.Context, ltoClientID, status string, limit, offset int) ([]models.Plate, int, error)
+    CountByStatus(ctx context.Context, status string) (int, error)
+    CountExpiringSoon(ctx context.Context, within time.Duration) (int, error)
+    BulkUpdateStatus(ctx context.Context, vehicleID, newStatus, changedBy string) (int64, error)
+    SyncExpiredPlates(ctx context.Context) (int64, error)
   }
   
 
 type plateRepo struct {
-    db *sqlx.DB
+    db   *sqlx.DB
+    read *sqlx.DB // Search, GetExpiringSoon, GetStats, GetByStatus and GetByType
 }
 
-func NewPlateRepository(db *sqlx.DB) PlateRepository {
-    return &plateRepo{db}
+func NewPlateRepository(rw database.ReadWriteDB) PlateRepository {
+    return &plateRepo{db: rw.Write, read: rw.Read}
 }
-//for the checker
+// GetByPlateNumber is the scanner lookup; it ignores case since scanners
+// send plates as typed or read. nil if missing.
 func (r *plateRepo) GetByPlateNumber(ctx context.Context, plateNumber string) (*models.Plate, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    defer metrics.ObserveQuery("plate_get_by_plate_number", time.Now())
     var p models.Plate
     const q = `
         SELECT plate_id, vehicle_id, plate_number, plate_type,
                plate_issue_date, plate_expiration_date, status
           FROM plates
-         WHERE plate_number = $1
+         WHERE UPPER(plate_number) = UPPER($1)
     `
     err := r.db.GetContext(ctx, &p, q, plateNumber)
     if err == sql.ErrNoRows {
         return nil, nil
     }
     if err != nil {
-        return nil, err
+        return nil, queryErr(ctx, err)
     }
     return &p, nil
 }
 
+// ExistsWithPlateNumber reports whether a plate with this number is already issued
+func (r *plateRepo) ExistsWithPlateNumber(ctx context.Context, number string) (bool, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var exists bool
+    const q = `SELECT EXISTS (SELECT 1 FROM plates WHERE plate_number = $1)`
+    if err := r.db.GetContext(ctx, &exists, q, number); err != nil {
+        return false, queryErr(ctx, err)
+    }
+    return exists, nil
+}
 
 func (r *plateRepo) CreatePlate(ctx context.Context, p *models.Plate) (*models.Plate, error) {
+    return createPlate(ctx, r.db, p)
+}
+
+// CreatePlateTx inserts the plate as part of the caller's transaction
+func (r *plateRepo) CreatePlateTx(ctx context.Context, tx *sqlx.Tx, p *models.Plate) (*models.Plate, error) {
+    return createPlate(ctx, tx, p)
+}
+
+func createPlate(ctx context.Context, db sqlx.ExtContext, p *models.Plate) (*models.Plate, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     const q = `
     INSERT INTO plates (
       plate_id, vehicle_id, plate_number, plate_type,
@@ -60,20 +115,221 @@ func (r *plateRepo) CreatePlate(ctx context.Context, p *models.Plate) (*models.P
     )
     RETURNING plate_id;
     `
-    rows, err := r.db.NamedQueryContext(ctx, q, p)
+    rows, err := sqlx.NamedQueryContext(ctx, db, q, p)
     if err != nil {
-        return nil, err
+        return nil, queryErr(ctx, err)
     }
     defer rows.Close()
     if rows.Next() {
         if err := rows.Scan(&p.PlateID); err != nil {
-            return nil, err
+            return nil, queryErr(ctx, err)
         }
     }
     return p, nil
 }
 
+// BulkCreate inserts the plates in one transaction, each behind its own
+// savepoint, so a row that fails (a duplicate number, say) is skipped without
+// undoing the rest. errs[i] is why plates[i] wasn't stored, nil when it was;
+// err is set only when the transaction itself failed and nothing was stored.
+func (r *plateRepo) BulkCreate(ctx context.Context, plates []*models.Plate) (errs []error, err error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    INSERT INTO plates (
+      plate_id, vehicle_id, plate_number, plate_type,
+      plate_issue_date, plate_expiration_date, status
+    ) VALUES (
+      gen_random_uuid(), $1, $2, $3, $4, $5, $6
+    )
+    RETURNING plate_id;
+    `
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return nil, queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+
+    stmt, err := tx.PreparexContext(ctx, q)
+    if err != nil {
+        return nil, queryErr(ctx, err)
+    }
+    defer stmt.Close()
+
+    errs = make([]error, len(plates))
+    for i, p := range plates {
+        if _, err := tx.ExecContext(ctx, `SAVEPOINT bulk_plate`); err != nil {
+            return nil, queryErr(ctx, err)
+        }
+        if err := stmt.QueryRowxContext(ctx,
+            p.VEHICLE_ID, p.PLATE_NUMBER, p.PLATE_TYPE,
+            p.PLATE_ISSUE_DATE, p.PLATE_EXPIRATION_DATE, p.STATUS,
+        ).Scan(&p.PlateID); err != nil {
+            errs[i] = fmt.Errorf("insert plate %s: %w", p.PLATE_NUMBER, queryErr(ctx, err))
+            if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT bulk_plate`); err != nil {
+                return nil, queryErr(ctx, err)
+            }
+            continue
+        }
+        if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT bulk_plate`); err != nil {
+            return nil, queryErr(ctx, err)
+        }
+    }
+    if err := tx.Commit(); err != nil {
+        return nil, queryErr(ctx, err)
+    }
+    return errs, nil
+}
+
+// Search matches q against the plate number or owner name; status and
+// plateType narrow the results when non-empty.
+func (r *plateRepo) Search(
+    ctx context.Context,
+    q, status, plateType string,
+    limit, offset int,
+) ([]models.PlateSearchResult, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.PlateSearchResult{}
+    const query = `
+      SELECT p.plate_id, p.vehicle_id, p.plate_number, p.plate_type,
+             p.plate_issue_date, p.plate_expiration_date, p.status,
+             COALESCE(u.first_name || ' ' || u.last_name, '') AS owner_name,
+             v.vehicle_type
+        FROM plates p
+        JOIN vehicles v    ON v.vehicle_id = p.vehicle_id
+        LEFT JOIN users u  ON u.lto_client_id = v.lto_client_id
+       WHERE (p.plate_number ILIKE '%' || $1 || '%'
+              OR (u.first_name || ' ' || u.last_name) ILIKE '%' || $1 || '%')
+         AND ($2 = '' OR LOWER(p.status) = LOWER($2))
+         AND ($3 = '' OR p.plate_type = $3)
+       ORDER BY p.plate_number
+       LIMIT $4 OFFSET $5
+    `
+    if err := r.read.SelectContext(ctx, &list, query, q, status, plateType, limit, offset); err != nil {
+        return nil, queryErr(ctx, err)
+    }
+    return list, nil
+}
+
+// GetByStatus returns one page of plates with the given status, soonest to
+// expire first, plus the total count
+func (r *plateRepo) GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.Plate, int, error) {
+    return r.platePage(ctx, "status", status, limit, offset)
+}
+
+// GetByType returns one page of plates of the given type, soonest to expire
+// first, plus the total count
+func (r *plateRepo) GetByType(ctx context.Context, plateType string, limit, offset int) ([]models.Plate, int, error) {
+    return r.platePage(ctx, "plate_type", plateType, limit, offset)
+}
+
+// GetByOwner returns one page of the plates on vehicles registered to
+// ltoClientID, soonest to expire first, plus the total count. A vehicle with
+// several registration forms is only counted once. An empty status matches
+// every plate.
+func (r *plateRepo) GetByOwner(ctx context.Context, ltoClientID, status string, limit, offset int) ([]models.Plate, int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    defer metrics.ObserveQuery("plate_get_by_owner", time.Now())
+    const where = `
+       WHERE p.vehicle_id IN (
+               SELECT rf.vehicle_id FROM registration_form rf WHERE rf.lto_client_id = $1
+             )
+         AND ($2::text = '' OR p.status = $2)`
+    var total int
+    if err := r.read.GetContext(ctx, &total, `SELECT COUNT(*) FROM plates p`+where, ltoClientID, status); err != nil {
+        return nil, 0, queryErr(ctx, err)
+    }
+    list := []models.Plate{}
+    q := `
+      SELECT p.plate_id, p.vehicle_id, p.plate_number, p.plate_type,
+             p.plate_issue_date, p.plate_expiration_date, p.status
+        FROM plates p` + where + `
+       ORDER BY p.plate_expiration_date, p.plate_id
+       LIMIT $3 OFFSET $4
+    `
+    if err := r.read.SelectContext(ctx, &list, q, ltoClientID, status, limit, offset); err != nil {
+        return nil, 0, queryErr(ctx, err)
+    }
+    return list, total, nil
+}
+
+// platePage pages through plates where column equals value; column is
+// always a literal from this file
+func (r *plateRepo) platePage(ctx context.Context, column, value string, limit, offset int) ([]models.Plate, int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var total int
+    if err := r.read.GetContext(ctx, &total,
+        `SELECT COUNT(*) FROM plates WHERE `+column+` = $1`, value); err != nil {
+        return nil, 0, queryErr(ctx, err)
+    }
+    list := []models.Plate{}
+    q := `
+      SELECT plate_id, vehicle_id, plate_number, plate_type,
+             plate_issue_date, plate_expiration_date, status
+        FROM plates
+       WHERE ` + column + ` = $1
+       ORDER BY plate_expiration_date
+       LIMIT $2 OFFSET $3
+    `
+    if err := r.read.SelectContext(ctx, &list, q, value, limit, offset); err != nil {
+        return nil, 0, queryErr(ctx, err)
+    }
+    return list, total, nil
+}
+
+// GetExpiringSoon returns plates that haven't expired yet but will within the given window
+func (r *plateRepo) GetExpiringSoon(ctx context.Context, within time.Duration) ([]models.Plate, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.Plate{}
+    const q = `
+      SELECT plate_id, vehicle_id, plate_number, plate_type,
+             plate_issue_date, plate_expiration_date, status
+        FROM plates
+       WHERE plate_expiration_date >= NOW()
+         AND plate_expiration_date <  $1
+       ORDER BY plate_expiration_date
+    `
+    if err := r.read.SelectContext(ctx, &list, q, time.Now().Add(within)); err != nil {
+        return nil, queryErr(ctx, err)
+    }
+    return list, nil
+}
+
+// CountByStatus counts the plates with the given status
+func (r *plateRepo) CountByStatus(ctx context.Context, status string) (int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var n int
+    if err := r.read.GetContext(ctx, &n, `SELECT COUNT(*) FROM plates WHERE status = $1`, status); err != nil {
+        return 0, queryErr(ctx, err)
+    }
+    return n, nil
+}
+
+// CountExpiringSoon counts the plates GetExpiringSoon would return
+func (r *plateRepo) CountExpiringSoon(ctx context.Context, within time.Duration) (int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var n int
+    const q = `
+      SELECT COUNT(*)
+        FROM plates
+       WHERE plate_expiration_date >= NOW()
+         AND plate_expiration_date <  $1
+    `
+    if err := r.read.GetContext(ctx, &n, q, time.Now().Add(within)); err != nil {
+        return 0, queryErr(ctx, err)
+    }
+    return n, nil
+}
+
 func (r *plateRepo) GetPlatesByVehicleID(ctx context.Context, vehicleID string) ([]models.Plate, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var list []models.Plate
     const q = `
       SELECT plate_id, vehicle_id, plate_number, plate_type,
@@ -83,12 +339,35 @@ func (r *plateRepo) GetPlatesByVehicleID(ctx context.Context, vehicleID string)
        ORDER BY plate_issue_date DESC
     `
     if err := r.db.SelectContext(ctx, &list, q, vehicleID); err != nil {
-        return nil, err
+        return nil, queryErr(ctx, err)
+    }
+    return list, nil
+}
+
+// GetPlatesByVehicleIDInRegion is GetPlatesByVehicleID limited to vehicles
+// with a registration filed in region
+func (r *plateRepo) GetPlatesByVehicleIDInRegion(ctx context.Context, vehicleID, region string) ([]models.Plate, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.Plate{}
+    const q = `
+      SELECT plate_id, vehicle_id, plate_number, plate_type,
+             plate_issue_date, plate_expiration_date, status
+        FROM plates p
+       WHERE vehicle_id = $1
+         AND EXISTS (SELECT 1 FROM registration_form rf
+                      WHERE rf.vehicle_id = p.vehicle_id AND rf.region = $2)
+       ORDER BY plate_issue_date DESC
+    `
+    if err := r.db.SelectContext(ctx, &list, q, vehicleID, region); err != nil {
+        return nil, queryErr(ctx, err)
     }
     return list, nil
 }
 
 func (r *plateRepo) GetPlateByID(ctx context.Context, vehicleID, plateID string) (*models.Plate, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var p models.Plate
     const q = `
       SELECT plate_id, vehicle_id, plate_number, plate_type,
@@ -105,9 +384,11 @@ func (r *plateRepo) GetPlateByID(ctx context.Context, vehicleID, plateID string)
 
 func (r *plateRepo) UpdatePlate(
     ctx context.Context,
-    vehicleID, plateID string,
+    vehicleID, plateID, changedBy string,
     fields map[string]interface{},
 ) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     // remove PK fields so client can't overwrite them
     delete(fields, "vehicle_id")
     delete(fields, "plate_id")
@@ -130,16 +411,387 @@ func (r *plateRepo) UpdatePlate(
         "UPDATE plates SET %s WHERE vehicle_id = :vehicle_id AND plate_id = :plate_id",
         strings.Join(setClauses, ", "),
     )
-    _, err := r.db.NamedExecContext(ctx, query, fields)
-    return err
+
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+
+    // keep the old version around before overwriting it
+    if err := snapshotPlate(ctx, tx, plateID, changedBy); err != nil {
+        return queryErr(ctx, err)
+    }
+    if _, err := tx.NamedExecContext(ctx, query, fields); err != nil {
+        return queryErr(ctx, err)
+    }
+    return queryErr(ctx, tx.Commit())
+}
+
+// BulkUpdateStatus sets the status of every plate on a vehicle in one
+// transaction, snapshotting each plate into plate_history first. It returns
+// the number of plates changed.
+func (r *plateRepo) BulkUpdateStatus(ctx context.Context, vehicleID, newStatus, changedBy string) (int64, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return 0, queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+
+    const snapshot = `
+    INSERT INTO plate_history (
+      history_id, plate_id, vehicle_id, plate_number, plate_type,
+      plate_issue_date, plate_expiration_date, status, changed_at, changed_by
+    )
+    SELECT gen_random_uuid(), plate_id, vehicle_id, plate_number, plate_type,
+           plate_issue_date, plate_expiration_date, status, NOW(), $2
+      FROM plates
+     WHERE vehicle_id = $1
+    `
+    if _, err := tx.ExecContext(ctx, snapshot, vehicleID, changedBy); err != nil {
+        return 0, fmt.Errorf("insert plate_history: %w", queryErr(ctx, err))
+    }
+    res, err := tx.ExecContext(ctx, `UPDATE plates SET status = $1 WHERE vehicle_id = $2`, newStatus, vehicleID)
+    if err != nil {
+        return 0, queryErr(ctx, err)
+    }
+    n, err := res.RowsAffected()
+    if err != nil {
+        return 0, err
+    }
+    return n, queryErr(ctx, tx.Commit())
+}
+
+// SyncExpiredPlates marks Active plates whose expiration date has passed as
+// Expired, snapshotting each into plate_history first, and returns how many
+// changed
+func (r *plateRepo) SyncExpiredPlates(ctx context.Context) (int64, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return 0, queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+
+    // lock the rows first so the snapshot and the update see the same set;
+    // NOW() is fixed for the whole transaction
+    const where = `plate_expiration_date < NOW() AND status = 'Active'`
+    if _, err := tx.ExecContext(ctx, `SELECT 1 FROM plates WHERE `+where+` FOR UPDATE`); err != nil {
+        return 0, queryErr(ctx, err)
+    }
+    snapshot := `
+    INSERT INTO plate_history (
+      history_id, plate_id, vehicle_id, plate_number, plate_type,
+      plate_issue_date, plate_expiration_date, status, changed_at, changed_by
+    )
+    SELECT gen_random_uuid(), plate_id, vehicle_id, plate_number, plate_type,
+           plate_issue_date, plate_expiration_date, status, NOW(), 'system'
+      FROM plates
+     WHERE ` + where
+    if _, err := tx.ExecContext(ctx, snapshot); err != nil {
+        return 0, fmt.Errorf("insert plate_history: %w", queryErr(ctx, err))
+    }
+    res, err := tx.ExecContext(ctx, `UPDATE plates SET status = 'Expired' WHERE `+where)
+    if err != nil {
+        return 0, queryErr(ctx, err)
+    }
+    n, err := res.RowsAffected()
+    if err != nil {
+        return 0, err
+    }
+    return n, queryErr(ctx, tx.Commit())
+}
+
+// snapshotPlate copies the current plate row into plate_history
+func snapshotPlate(ctx context.Context, tx *sqlx.Tx, plateID, changedBy string) error {
+    return snapshotPlateWithReason(ctx, tx, plateID, changedBy, "")
+}
+
+// snapshotPlateWithReason is snapshotPlate recording why the plate is changing
+func snapshotPlateWithReason(ctx context.Context, tx *sqlx.Tx, plateID, changedBy, reason string) error {
+    const q = `
+    INSERT INTO plate_history (
+      history_id, plate_id, vehicle_id, plate_number, plate_type,
+      plate_issue_date, plate_expiration_date, status, changed_at, changed_by, reason
+    )
+    SELECT gen_random_uuid(), plate_id, vehicle_id, plate_number, plate_type,
+           plate_issue_date, plate_expiration_date, status, NOW(), $2, $3
+      FROM plates
+     WHERE plate_id = $1
+    `
+    if _, err := tx.ExecContext(ctx, q, plateID, changedBy, reason); err != nil {
+        return fmt.Errorf("insert plate_history: %w", err)
+    }
+    return nil
+}
+
+// GetPlateHistory lists a plate's previous versions, newest first
+func (r *plateRepo) GetPlateHistory(ctx context.Context, plateID string) ([]models.PlateHistory, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.PlateHistory{}
+    const q = `
+      SELECT history_id, plate_id, vehicle_id, plate_number, plate_type,
+             plate_issue_date, plate_expiration_date, status, changed_at, changed_by, reason
+        FROM plate_history
+       WHERE plate_id = $1
+       ORDER BY changed_at DESC
+    `
+    if err := r.db.SelectContext(ctx, &list, q, plateID); err != nil {
+        return nil, queryErr(ctx, err)
+    }
+    return list, nil
+}
+
+// RestoreVersion copies a history row back onto the plate. The version being
+// replaced is itself snapshotted first, so a restore can be undone.
+func (r *plateRepo) RestoreVersion(ctx context.Context, plateID, historyID string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+
+    if err := snapshotPlate(ctx, tx, plateID, "restore:"+historyID); err != nil {
+        return queryErr(ctx, err)
+    }
+    const q = `
+      UPDATE plates p
+         SET vehicle_id            = h.vehicle_id,
+             plate_number          = h.plate_number,
+             plate_type            = h.plate_type,
+             plate_issue_date      = h.plate_issue_date,
+             plate_expiration_date = h.plate_expiration_date,
+             status                = h.status
+        FROM plate_history h
+       WHERE h.history_id = $2
+         AND h.plate_id   = $1
+         AND p.plate_id   = $1
+    `
+    res, err := tx.ExecContext(ctx, q, plateID, historyID)
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return fmt.Errorf("history %s not found for plate %s", historyID, plateID)
+    }
+    return queryErr(ctx, tx.Commit())
+}
+
+// GetPlateByPlateID looks a plate up without knowing its vehicle; nil if missing
+func (r *plateRepo) GetPlateByPlateID(ctx context.Context, plateID string) (*models.Plate, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var p models.Plate
+    const q = `
+      SELECT plate_id, vehicle_id, plate_number, plate_type,
+             plate_issue_date, plate_expiration_date, status
+        FROM plates
+       WHERE plate_id = $1
+    `
+    err := r.db.GetContext(ctx, &p, q, plateID)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, queryErr(ctx, err)
+    }
+    return &p, nil
+}
+
+// TransferPlate moves a plate to t.ToVehicleID and writes the transfer log row
+// in the same transaction.
+func (r *plateRepo) TransferPlate(ctx context.Context, t *models.PlateTransfer) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+
+    if err := snapshotPlate(ctx, tx, t.PlateID, t.TransferredBy); err != nil {
+        return queryErr(ctx, err)
+    }
+    res, err := tx.ExecContext(ctx, `
+      UPDATE plates SET vehicle_id = $1
+       WHERE plate_id = $2 AND vehicle_id = $3
+    `, t.ToVehicleID, t.PlateID, t.FromVehicleID)
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return fmt.Errorf("plate %s is no longer on vehicle %s", t.PlateID, t.FromVehicleID)
+    }
+
+    const q = `
+    INSERT INTO plate_transfer_log (
+      transfer_id, plate_id, from_vehicle_id, to_vehicle_id, transferred_by, transferred_at
+    ) VALUES (
+      gen_random_uuid(), $1, $2, $3, $4, NOW()
+    )
+    RETURNING transfer_id, transferred_at
+    `
+    if err := tx.QueryRowxContext(ctx, q,
+        t.PlateID, t.FromVehicleID, t.ToVehicleID, t.TransferredBy,
+    ).Scan(&t.TransferID, &t.TransferredAt); err != nil {
+        return fmt.Errorf("insert plate_transfer_log: %w", queryErr(ctx, err))
+    }
+    return queryErr(ctx, tx.Commit())
+}
+
+// RenewPlate sets the plate's expiration date to renewal.NewExpirationDate
+// and its status to Active, snapshotting it into plate_history and writing
+// the renewal row in the same transaction. It returns sql.ErrNoRows if the
+// plate isn't on the vehicle.
+func (r *plateRepo) RenewPlate(ctx context.Context, vehicleID, changedBy string, renewal *models.PlateRenewalHistory) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+
+    if err := snapshotPlate(ctx, tx, renewal.PlateID, changedBy); err != nil {
+        return queryErr(ctx, err)
+    }
+    res, err := tx.ExecContext(ctx, `
+      UPDATE plates SET plate_expiration_date = $1, status = $2
+       WHERE plate_id = $3 AND vehicle_id = $4
+    `, renewal.NewExpirationDate, models.PlateActive, renewal.PlateID, vehicleID)
+    if err != nil {
+        return fmt.Errorf("update plates: %w", queryErr(ctx, err))
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+    if err := insertRenewal(ctx, tx, renewal); err != nil {
+        return err
+    }
+    return queryErr(ctx, tx.Commit())
+}
+
+// DeactivatePlate sets the plate's status to Deactivated and its expiration
+// date to effective, snapshotting it into plate_history with reason first. It
+// returns sql.ErrNoRows if the plate isn't on the vehicle and
+// ErrPlateDeactivated if it already is deactivated.
+func (r *plateRepo) DeactivatePlate(ctx context.Context, vehicleID, plateID, changedBy, reason string, effective time.Time) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+
+    // lock the row so two officers deactivating at once can't both succeed
+    var status string
+    err = tx.GetContext(ctx, &status, `
+      SELECT status FROM plates
+       WHERE plate_id = $1 AND vehicle_id = $2
+         FOR UPDATE
+    `, plateID, vehicleID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return err
+    }
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    if status == models.PlateDeactivated {
+        return ErrPlateDeactivated
+    }
+
+    if err := snapshotPlateWithReason(ctx, tx, plateID, changedBy, reason); err != nil {
+        return queryErr(ctx, err)
+    }
+    if _, err := tx.ExecContext(ctx, `
+      UPDATE plates SET status = $1, plate_expiration_date = $2
+       WHERE plate_id = $3
+    `, models.PlateDeactivated, effective, plateID); err != nil {
+        return fmt.Errorf("update plates: %w", queryErr(ctx, err))
+    }
+    return queryErr(ctx, tx.Commit())
+}
+
+// plateStatsRow is one grouping-set row of the GetStats query
+type plateStatsRow struct {
+    GStatus   int    `db:"g_status"`
+    GType     int    `db:"g_type"`
+    GRegion   int    `db:"g_region"`
+    Status    string `db:"status"`
+    PlateType string `db:"plate_type"`
+    Region    string `db:"region"`
+    Total     int    `db:"total"`
+    ThisMonth int    `db:"expiring_this_month"`
+    NextMonth int    `db:"expiring_next_month"`
+    ThisYear  int    `db:"expiring_this_year"`
+}
+
+// GetStats computes plate counts by status, type, region prefix and expiry
+// cohort in one pass. ROLLUP(status) supplies the grand-total row, which also
+// carries the expiry cohort counts.
+func (r *plateRepo) GetStats(ctx context.Context) (*models.PlateStats, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var rows []plateStatsRow
+    const q = `
+      SELECT GROUPING(status)     AS g_status,
+             GROUPING(plate_type) AS g_type,
+             GROUPING(region)     AS g_region,
+             COALESCE(status, '')     AS status,
+             COALESCE(plate_type, '') AS plate_type,
+             COALESCE(region, '')     AS region,
+             COUNT(*) AS total,
+             COUNT(*) FILTER (WHERE date_trunc('month', plate_expiration_date) = date_trunc('month', NOW()))                      AS expiring_this_month,
+             COUNT(*) FILTER (WHERE date_trunc('month', plate_expiration_date) = date_trunc('month', NOW() + INTERVAL '1 month')) AS expiring_next_month,
+             COUNT(*) FILTER (WHERE date_trunc('year',  plate_expiration_date) = date_trunc('year',  NOW()))                      AS expiring_this_year
+        FROM (SELECT status, plate_type, region_prefix AS region, plate_expiration_date
+                FROM plates) p
+       GROUP BY GROUPING SETS (ROLLUP(status), (plate_type), (region))
+    `
+    if err := r.read.SelectContext(ctx, &rows, q); err != nil {
+        return nil, queryErr(ctx, err)
+    }
+
+    stats := &models.PlateStats{
+        ByStatus: map[string]int{},
+        ByType:   map[string]int{},
+        ByRegion: map[string]int{},
+    }
+    for _, row := range rows {
+        switch {
+        case row.GStatus == 0:
+            stats.ByStatus[row.Status] = row.Total
+        case row.GType == 0:
+            stats.ByType[row.PlateType] = row.Total
+        case row.GRegion == 0:
+            stats.ByRegion[row.Region] = row.Total
+        default: // grand total
+            stats.Total = row.Total
+            stats.ByExpiry = models.PlateExpiryCohorts{
+                ThisMonth: row.ThisMonth,
+                NextMonth: row.NextMonth,
+                ThisYear:  row.ThisYear,
+            }
+        }
+    }
+    return stats, nil
 }
 
 func (r *plateRepo) DeletePlateByID(ctx context.Context, vehicleID, plateID string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     const q = `
       DELETE FROM plates
        WHERE vehicle_id = $1
          AND plate_id   = $2
     `
     _, err := r.db.ExecContext(ctx, q, vehicleID, plateID)
-    return err
+    return queryErr(ctx, err)
 }
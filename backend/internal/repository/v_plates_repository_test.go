@@ -0,0 +1,257 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "regexp"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+
+    "smartplate-api/internal/models"
+)
+
+var plateColumns = []string{
+    "plate_id", "vehicle_id", "plate_number", "plate_type",
+    "plate_issue_date", "plate_expiration_date", "status",
+}
+
+func TestPlateSearch(t *testing.T) {
+    tests := []struct {
+        name      string
+        q         string
+        status    string
+        plateType string
+        limit     int
+        offset    int
+    }{
+        {"number only", "ABC", "", "", 20, 0},
+        {"with status", "ABC", "active", "", 20, 0},
+        {"with type", "ABC", "", "Private", 20, 0},
+        {"all filters", "dela cruz", "Expired", "For Hire", 10, 30},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rw, mock := newMockReadWrite(t)
+            issued := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+            rows := sqlmock.NewRows(append(plateColumns, "owner_name", "vehicle_type")).
+                AddRow("p1", "v1", "ABC 1234", "Private", issued, issued.AddDate(3, 0, 0), "Active", "Juan Dela Cruz", "4-Wheel")
+            mock.ExpectQuery(`p.plate_number ILIKE '%' \|\| \$1 \|\| '%'`).
+                WithArgs(tt.q, tt.status, tt.plateType, tt.limit, tt.offset).
+                WillReturnRows(rows)
+
+            list, err := NewPlateRepository(rw).Search(context.Background(), tt.q, tt.status, tt.plateType, tt.limit, tt.offset)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if len(list) != 1 || list[0].PLATE_NUMBER != "ABC 1234" || list[0].OwnerName != "Juan Dela Cruz" || list[0].VehicleType != "4-Wheel" {
+                t.Fatalf("unexpected results: %+v", list)
+            }
+        })
+    }
+}
+
+func TestPlateSearchNoMatches(t *testing.T) {
+    rw, mock := newMockReadWrite(t)
+    mock.ExpectQuery(`FROM plates p`).
+        WithArgs("ZZ", "", "", 20, 0).
+        WillReturnRows(sqlmock.NewRows(append(plateColumns, "owner_name", "vehicle_type")))
+
+    list, err := NewPlateRepository(rw).Search(context.Background(), "ZZ", "", "", 20, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if list == nil || len(list) != 0 {
+        t.Fatalf("want an empty, non-nil list, got %#v", list)
+    }
+}
+
+func TestPlateSearchError(t *testing.T) {
+    rw, mock := newMockReadWrite(t)
+    boom := errors.New("boom")
+    mock.ExpectQuery(`FROM plates p`).WillReturnError(boom)
+
+    if _, err := NewPlateRepository(rw).Search(context.Background(), "AB", "", "", 20, 0); !errors.Is(err, boom) {
+        t.Fatalf("err = %v, want %v", err, boom)
+    }
+}
+
+func TestPlatePages(t *testing.T) {
+    tests := []struct {
+        name   string
+        column string
+        value  string
+        call   func(r PlateRepository) ([]models.Plate, int, error)
+    }{
+        {"by status", "status", "Expired", func(r PlateRepository) ([]models.Plate, int, error) {
+            return r.GetByStatus(context.Background(), "Expired", 50, 100)
+        }},
+        {"by type", "plate_type", "Diplomatic", func(r PlateRepository) ([]models.Plate, int, error) {
+            return r.GetByType(context.Background(), "Diplomatic", 50, 100)
+        }},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rw, mock := newMockReadWrite(t)
+            issued := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+            mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM plates WHERE ` + tt.column + ` = $1`)).
+                WithArgs(tt.value).
+                WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(102))
+            mock.ExpectQuery(`WHERE `+tt.column+` = \$1\s+ORDER BY plate_expiration_date\s+LIMIT \$2 OFFSET \$3`).
+                WithArgs(tt.value, 50, 100).
+                WillReturnRows(sqlmock.NewRows(plateColumns).
+                    AddRow("p1", "v1", "ABC 1234", "Diplomatic", issued, issued.AddDate(3, 0, 0), "Expired").
+                    AddRow("p2", "v2", "XYZ 5678", "Diplomatic", issued, issued.AddDate(3, 1, 0), "Expired"))
+
+            list, total, err := tt.call(NewPlateRepository(rw))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if total != 102 || len(list) != 2 || list[0].PlateID != "p1" || list[1].PlateID != "p2" {
+                t.Fatalf("got %d of %d: %+v", len(list), total, list)
+            }
+        })
+    }
+}
+
+func TestPlatePageCountError(t *testing.T) {
+    rw, mock := newMockReadWrite(t)
+    boom := errors.New("boom")
+    mock.ExpectQuery(`SELECT COUNT\(\*\) FROM plates`).WillReturnError(boom)
+
+    if _, _, err := NewPlateRepository(rw).GetByStatus(context.Background(), "Active", 10, 0); !errors.Is(err, boom) {
+        t.Fatalf("err = %v, want %v", err, boom)
+    }
+}
+
+func TestPlateStats(t *testing.T) {
+    rw, mock := newMockReadWrite(t)
+    cols := []string{"g_status", "g_type", "g_region", "status", "plate_type", "region", "total",
+        "expiring_this_month", "expiring_next_month", "expiring_this_year"}
+    // the region comes from the indexed stored column, not LEFT(plate_number, 1)
+    mock.ExpectQuery(regexp.QuoteMeta("region_prefix AS region")).
+        WillReturnRows(sqlmock.NewRows(cols).
+            AddRow(0, 1, 1, "Active", "", "", 7, 0, 0, 0).
+            AddRow(0, 1, 1, "Expired", "", "", 3, 0, 0, 0).
+            AddRow(1, 0, 1, "", "Private", "", 10, 0, 0, 0).
+            AddRow(1, 1, 0, "", "", "N", 6, 0, 0, 0).
+            AddRow(1, 1, 0, "", "", "D", 4, 0, 0, 0).
+            AddRow(1, 1, 1, "", "", "", 10, 1, 2, 5))
+
+    got, err := NewPlateRepository(rw).GetStats(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := models.PlateStats{
+        Total:    10,
+        ByStatus: map[string]int{"Active": 7, "Expired": 3},
+        ByType:   map[string]int{"Private": 10},
+        ByRegion: map[string]int{"N": 6, "D": 4},
+        ByExpiry: models.PlateExpiryCohorts{ThisMonth: 1, NextMonth: 2, ThisYear: 5},
+    }
+    if fmt.Sprint(*got) != fmt.Sprint(want) {
+        t.Fatalf("got %+v, want %+v", *got, want)
+    }
+}
+
+func TestPlateBulkUpdateStatus(t *testing.T) {
+    boom := errors.New("boom")
+    tests := []struct {
+        name      string
+        plates    int64
+        updateErr error
+        want      int64
+    }{
+        {"three plates", 3, nil, 3},
+        {"no plates", 0, nil, 0},
+        {"update fails", 3, boom, 0},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rw, mock := newMockReadWrite(t)
+            mock.ExpectBegin()
+            mock.ExpectExec(`INSERT INTO plate_history`).
+                WithArgs("v1", "LTO-OFFICER").
+                WillReturnResult(sqlmock.NewResult(0, tt.plates))
+            update := mock.ExpectExec(regexp.QuoteMeta(`UPDATE plates SET status = $1 WHERE vehicle_id = $2`)).
+                WithArgs(models.PlateDeactivated, "v1")
+            if tt.updateErr != nil {
+                update.WillReturnError(tt.updateErr)
+                mock.ExpectRollback()
+            } else {
+                update.WillReturnResult(sqlmock.NewResult(0, tt.plates))
+                mock.ExpectCommit()
+            }
+
+            n, err := NewPlateRepository(rw).BulkUpdateStatus(context.Background(), "v1", models.PlateDeactivated, "LTO-OFFICER")
+            if !errors.Is(err, tt.updateErr) {
+                t.Fatalf("err = %v, want %v", err, tt.updateErr)
+            }
+            if n != tt.want {
+                t.Fatalf("affected = %d, want %d", n, tt.want)
+            }
+        })
+    }
+}
+
+func TestPlateGetByOwner(t *testing.T) {
+    issued := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+    tests := []struct {
+        name   string
+        status string
+        rows   [][]string // plate_id, vehicle_id, status
+        total  int
+        // plates per vehicle in the result
+        wantVehicles map[string]int
+    }{
+        {"two vehicles, several plates each", "", [][]string{
+            {"p1", "v1", "Expired"}, {"p2", "v1", "Active"}, {"p3", "v2", "Active"}, {"p4", "v2", "Temporary"}, {"p5", "v1", "Deactivated"},
+        }, 5, map[string]int{"v1": 3, "v2": 2}},
+        {"active only", "Active", [][]string{{"p2", "v1", "Active"}, {"p3", "v2", "Active"}}, 2, map[string]int{"v1": 1, "v2": 1}},
+        {"no vehicles", "", nil, 0, map[string]int{}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rw, mock := newMockReadWrite(t)
+            // the subquery keeps a vehicle with several forms from
+            // repeating its plates
+            owned := regexp.QuoteMeta(`WHERE p.vehicle_id IN (
+               SELECT rf.vehicle_id FROM registration_form rf WHERE rf.lto_client_id = $1
+             )`)
+            mock.ExpectQuery(`SELECT COUNT\(\*\) FROM plates p\s+`+owned).
+                WithArgs("LTO-1", tt.status).
+                WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.total))
+            rows := sqlmock.NewRows(plateColumns)
+            for i, r := range tt.rows {
+                rows.AddRow(r[0], r[1], "ABC 100"+r[0][1:], "Private", issued, issued.AddDate(i, 0, 0), r[2])
+            }
+            mock.ExpectQuery(owned+`\s+AND \(\$2::text = '' OR p.status = \$2\)\s+ORDER BY p.plate_expiration_date, p.plate_id\s+LIMIT \$3 OFFSET \$4`).
+                WithArgs("LTO-1", tt.status, 10, 0).
+                WillReturnRows(rows)
+
+            list, total, err := NewPlateRepository(rw).GetByOwner(context.Background(), "LTO-1", tt.status, 10, 0)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if total != tt.total || len(list) != len(tt.rows) {
+                t.Fatalf("got %d plates (total %d), want %d (total %d)", len(list), total, len(tt.rows), tt.total)
+            }
+            vehicles := map[string]int{}
+            for i, p := range list {
+                if p.PlateID != tt.rows[i][0] || p.VEHICLE_ID != tt.rows[i][1] || p.STATUS != tt.rows[i][2] {
+                    t.Fatalf("plate %d = %+v, want %v", i, p, tt.rows[i])
+                }
+                vehicles[p.VEHICLE_ID]++
+            }
+            if fmt.Sprint(vehicles) != fmt.Sprint(tt.wantVehicles) {
+                t.Fatalf("plates per vehicle = %v, want %v", vehicles, tt.wantVehicles)
+            }
+        })
+    }
+}
@@ -3,19 +3,65 @@ package repository
 import (
     "context"
     "database/sql"             // for sql.ErrNoRows
+    "errors"
+    "fmt"
+    "strings"
+    "time"
     "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
     "smartplate-api/internal/models"
 )
 
+// ErrActiveRegistrationExists is returned when a form would become the
+// vehicle's second one that hasn't been rejected: by Create, by Update when
+// the form moves to such a vehicle, and by a status change reopening a
+// rejected form
+var ErrActiveRegistrationExists = errors.New("vehicle already has an active registration form")
+
+// activeRegistrationIndex keeps one non-rejected form per vehicle
+const activeRegistrationIndex = "idx_registration_form_active_vehicle"
+
+// formWriteErr reports a clash on activeRegistrationIndex as
+// ErrActiveRegistrationExists
+func formWriteErr(ctx context.Context, err error) error {
+    var pqErr *pq.Error
+    if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == activeRegistrationIndex {
+        return ErrActiveRegistrationExists
+    }
+    return queryErr(ctx, err)
+}
+
 type RegistrationFormRepository interface {
     Create(ctx context.Context, p *models.CreateRegistrationFormParams) (*models.RegistrationForm, error)
     GetAll(ctx context.Context) ([]models.RegistrationForm, error)
     GetByID(ctx context.Context, id string) (*models.RegistrationForm, error)
     Update(ctx context.Context, f *models.RegistrationForm) error
+    UpdateTx(ctx context.Context, tx *sqlx.Tx, f *models.RegistrationForm) error
     Delete(ctx context.Context, id string) error
 
     // ← the key lookup for your WS handler
     GetByVehicleID(ctx context.Context, vehicleID string) (*models.RegistrationForm, error)
+
+    UpdateStatus(ctx context.Context, id string, to models.FormState, actorID string) error
+    UpdateStatusTx(ctx context.Context, tx *sqlx.Tx, id string, to models.FormState, actorID string) error
+    GetByStatus(ctx context.Context, status string, limit, offset int) ([]models.RegistrationForm, int, error)
+    GetByLTOClientID(ctx context.Context, ltoClientID string) ([]models.RegistrationForm, error)
+    Search(ctx context.Context, filter RegistrationSearchFilter) ([]models.RegistrationForm, int, error)
+    GetPendingCount(ctx context.Context) (int, error)
+    GetPendingByRegion(ctx context.Context) (map[string]int, error)
+}
+
+// RegistrationSearchFilter narrows Search; zero-valued fields are ignored
+// and a Limit of 0 returns every match
+type RegistrationSearchFilter struct {
+    Status       string
+    LTOClientID  string
+    MVFileNumber string
+    Region       string
+    From         time.Time
+    To           time.Time
+    Limit        int
+    Offset       int
 }
 
 type registrationFormRepo struct {
@@ -30,6 +76,8 @@ func (r *registrationFormRepo) Create(
     ctx context.Context,
     p *models.CreateRegistrationFormParams,
 ) (*models.RegistrationForm, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var full models.RegistrationForm
     err := r.db.
         QueryRowxContext(ctx, `
@@ -48,12 +96,14 @@ func (r *registrationFormRepo) Create(
     `, p.LTOClientID, p.VehicleID, p.Status, p.Region, p.RegistrationType).
         StructScan(&full)
     if err != nil {
-        return nil, err
+        return nil, formWriteErr(ctx, err)
     }
     return &full, nil
 }
 
 func (r *registrationFormRepo) GetAll(ctx context.Context) ([]models.RegistrationForm, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var out []models.RegistrationForm
     err := r.db.SelectContext(ctx, &out, `
         SELECT
@@ -67,10 +117,12 @@ func (r *registrationFormRepo) GetAll(ctx context.Context) ([]models.Registratio
         FROM registration_form
         ORDER BY submitted_date DESC
     `)
-    return out, err
+    return out, queryErr(ctx, err)
 }
 
 func (r *registrationFormRepo) GetByID(ctx context.Context, id string) (*models.RegistrationForm, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var f models.RegistrationForm
     err := r.db.GetContext(ctx, &f, `
         SELECT
@@ -85,36 +137,54 @@ func (r *registrationFormRepo) GetByID(ctx context.Context, id string) (*models.
         WHERE registration_form_id = $1
     `, id)
     if err != nil {
-        return nil, err
+        return nil, queryErr(ctx, err)
     }
     return &f, nil
 }
 
-func (r *registrationFormRepo) Update(ctx context.Context, f *models.RegistrationForm) error {
-    _, err := r.db.NamedExecContext(ctx, `
+const updateFormQuery = `
         UPDATE registration_form SET
           lto_client_id     = :lto_client_id,
           vehicle_id        = :vehicle_id,
-          status            = :status,
           region            = :region,
           registration_type = :registration_type
         WHERE registration_form_id = :registration_form_id
-    `, f)
-    return err
+    `
+
+func (r *registrationFormRepo) Update(ctx context.Context, f *models.RegistrationForm) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    _, err := r.db.NamedExecContext(ctx, updateFormQuery, f)
+    return formWriteErr(ctx, err)
+}
+
+// UpdateTx is Update as part of the caller's transaction
+func (r *registrationFormRepo) UpdateTx(ctx context.Context, tx *sqlx.Tx, f *models.RegistrationForm) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    _, err := tx.NamedExecContext(ctx, updateFormQuery, f)
+    return formWriteErr(ctx, err)
 }
 
 func (r *registrationFormRepo) Delete(ctx context.Context, id string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     _, err := r.db.ExecContext(ctx, `
         DELETE FROM registration_form
         WHERE registration_form_id = $1
     `, id)
-    return err
+    return queryErr(ctx, err)
 }
 
+// GetByVehicleID returns the vehicle's most recently submitted form, or nil
+// with no error when it has none. Rejected forms stay on file, so a vehicle
+// can have several; only the newest is returned.
 func (r *registrationFormRepo) GetByVehicleID(
     ctx context.Context,
     vehicleID string,
 ) (*models.RegistrationForm, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var f models.RegistrationForm
     const q = `
       SELECT
@@ -127,13 +197,222 @@ func (r *registrationFormRepo) GetByVehicleID(
         registration_type
       FROM registration_form
       WHERE vehicle_id = $1
+      ORDER BY submitted_date DESC
+      LIMIT 1
     `
     err := r.db.GetContext(ctx, &f, q, vehicleID)
-    if err == sql.ErrNoRows {
+    if errors.Is(err, sql.ErrNoRows) {
         return nil, nil
     }
     if err != nil {
-        return nil, err
+        return nil, queryErr(ctx, err)
     }
     return &f, nil
 }
+
+// UpdateStatus moves a form to another workflow state, enforcing
+// models.ValidTransitions and recording the change in form_state_history.
+// It returns sql.ErrNoRows for an unknown form and an error wrapping
+// models.ErrInvalidTransition for a move the workflow doesn't allow.
+func (r *registrationFormRepo) UpdateStatus(ctx context.Context, id string, to models.FormState, actorID string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+    if err := transitionForm(ctx, tx, id, to, actorID); err != nil {
+        return err
+    }
+    return queryErr(ctx, tx.Commit())
+}
+
+// UpdateStatusTx is UpdateStatus as part of the caller's transaction
+func (r *registrationFormRepo) UpdateStatusTx(ctx context.Context, tx *sqlx.Tx, id string, to models.FormState, actorID string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    return transitionForm(ctx, tx, id, to, actorID)
+}
+
+// transitionForm locks the form so concurrent reviews can't both move it,
+// then applies and records the transition
+func transitionForm(ctx context.Context, tx *sqlx.Tx, id string, to models.FormState, actorID string) error {
+    var f models.RegistrationForm
+    err := tx.GetContext(ctx, &f, `
+        SELECT registration_form_id, status
+          FROM registration_form
+         WHERE registration_form_id = $1
+           FOR UPDATE
+    `, id)
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    if err := f.TransitionTo(to, actorID); err != nil {
+        return err
+    }
+    if _, err := tx.ExecContext(ctx, `
+        UPDATE registration_form SET status = $1
+        WHERE registration_form_id = $2
+    `, f.Status, id); err != nil {
+        return formWriteErr(ctx, err)
+    }
+    return queryErr(ctx, insertFormStateHistory(ctx, tx, f.LastTransition()))
+}
+
+// GetByStatus returns one page of forms with the given status (all forms when
+// status is empty) plus the total count
+func (r *registrationFormRepo) GetByStatus(
+    ctx context.Context,
+    status string,
+    limit, offset int,
+) ([]models.RegistrationForm, int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var total int
+    if err := r.db.GetContext(ctx, &total, `
+        SELECT COUNT(*) FROM registration_form
+        WHERE ($1 = '' OR status = $1)
+    `, status); err != nil {
+        return nil, 0, queryErr(ctx, err)
+    }
+
+    out := []models.RegistrationForm{}
+    err := r.db.SelectContext(ctx, &out, `
+        SELECT
+          registration_form_id,
+          lto_client_id,
+          vehicle_id,
+          submitted_date,
+          status,
+          region,
+          registration_type
+        FROM registration_form
+        WHERE ($1 = '' OR status = $1)
+        ORDER BY submitted_date DESC
+        LIMIT $2 OFFSET $3
+    `, status, limit, offset)
+    return out, total, queryErr(ctx, err)
+}
+
+// GetByLTOClientID returns every form submitted by an LTO client
+func (r *registrationFormRepo) GetByLTOClientID(ctx context.Context, ltoClientID string) ([]models.RegistrationForm, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    out := []models.RegistrationForm{}
+    err := r.db.SelectContext(ctx, &out, `
+        SELECT
+          registration_form_id,
+          lto_client_id,
+          vehicle_id,
+          submitted_date,
+          status,
+          region,
+          registration_type
+        FROM registration_form
+        WHERE lto_client_id = $1
+        ORDER BY submitted_date DESC
+    `, ltoClientID)
+    return out, queryErr(ctx, err)
+}
+
+// Search returns forms matching every non-zero filter field, newest first,
+// plus the total number of matches
+func (r *registrationFormRepo) Search(
+    ctx context.Context,
+    filter RegistrationSearchFilter,
+) ([]models.RegistrationForm, int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    conds := []string{}
+    args := []interface{}{}
+    add := func(cond string, val interface{}) {
+        args = append(args, val)
+        conds = append(conds, fmt.Sprintf(cond, len(args)))
+    }
+    if filter.Status != "" {
+        add("rf.status = $%d", filter.Status)
+    }
+    if filter.LTOClientID != "" {
+        add("rf.lto_client_id = $%d", filter.LTOClientID)
+    }
+    if filter.MVFileNumber != "" {
+        add("v.mv_file_number = $%d", filter.MVFileNumber)
+    }
+    if filter.Region != "" {
+        add("rf.region = $%d", filter.Region)
+    }
+    if !filter.From.IsZero() {
+        add("rf.submitted_date >= $%d", filter.From)
+    }
+    if !filter.To.IsZero() {
+        add("rf.submitted_date <= $%d", filter.To)
+    }
+
+    from := " FROM registration_form rf LEFT JOIN vehicles v ON v.vehicle_id = rf.vehicle_id"
+    if len(conds) > 0 {
+        from += " WHERE " + strings.Join(conds, " AND ")
+    }
+
+    var total int
+    if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*)"+from, args...); err != nil {
+        return nil, 0, queryErr(ctx, err)
+    }
+
+    query := `SELECT
+          rf.registration_form_id,
+          rf.lto_client_id,
+          rf.vehicle_id,
+          rf.submitted_date,
+          rf.status,
+          rf.region,
+          rf.registration_type` + from + " ORDER BY rf.submitted_date DESC"
+    if filter.Limit > 0 {
+        query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+        args = append(args, filter.Limit, filter.Offset)
+    }
+
+    out := []models.RegistrationForm{}
+    if err := r.db.SelectContext(ctx, &out, query, args...); err != nil {
+        return nil, 0, queryErr(ctx, err)
+    }
+    return out, total, nil
+}
+
+// GetPendingCount counts the forms awaiting a decision: submitted, or taken
+// up for review but not yet approved or rejected.
+func (r *registrationFormRepo) GetPendingCount(ctx context.Context) (int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var n int
+    if err := r.db.GetContext(ctx, &n,
+        `SELECT COUNT(*) FROM registration_form WHERE status IN ($1, $2)`,
+        models.FormSubmitted, models.FormUnderReview); err != nil {
+        return 0, fmt.Errorf("count pending registration_form: %w", queryErr(ctx, err))
+    }
+    return n, nil
+}
+
+// GetPendingByRegion is GetPendingCount broken down by region; forms without
+// a region are counted under "".
+func (r *registrationFormRepo) GetPendingByRegion(ctx context.Context) (map[string]int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var rows []struct {
+        Region string `db:"region"`
+        Count  int    `db:"count"`
+    }
+    const q = `
+    SELECT COALESCE(region, '') AS region, COUNT(*) AS count
+      FROM registration_form
+     WHERE status IN ($1, $2)
+     GROUP BY 1`
+    if err := r.db.SelectContext(ctx, &rows, q, models.FormSubmitted, models.FormUnderReview); err != nil {
+        return nil, fmt.Errorf("count pending registration_form by region: %w", queryErr(ctx, err))
+    }
+    out := make(map[string]int, len(rows))
+    for _, row := range rows {
+        out[row.Region] = row.Count
+    }
+    return out, nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "regexp"
+    "testing"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/lib/pq"
+
+    "smartplate-api/internal/models"
+)
+
+func TestRegistrationFormUpdateStatus(t *testing.T) {
+    tests := []struct {
+        name    string
+        current models.FormState // "" means the form doesn't exist
+        to      models.FormState
+        wantErr error
+    }{
+        {"submit a draft", models.FormDraft, models.FormSubmitted, nil},
+        {"start review", models.FormSubmitted, models.FormUnderReview, nil},
+        {"approve", models.FormUnderReview, models.FormApproved, nil},
+        {"reject", models.FormUnderReview, models.FormRejected, nil},
+        {"reopen a rejection", models.FormRejected, models.FormDraft, nil},
+        {"approve without review", models.FormSubmitted, models.FormApproved, models.ErrInvalidTransition},
+        {"skip submission", models.FormDraft, models.FormUnderReview, models.ErrInvalidTransition},
+        {"reopen an approval", models.FormApproved, models.FormDraft, models.ErrInvalidTransition},
+        {"unknown form", "", models.FormSubmitted, sql.ErrNoRows},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db, mock := newMockDB(t)
+            mock.ExpectBegin()
+            lock := mock.ExpectQuery(regexp.QuoteMeta("FROM registration_form")).WithArgs("rf-1")
+            if tt.current == "" {
+                lock.WillReturnError(sql.ErrNoRows)
+            } else {
+                lock.WillReturnRows(sqlmock.NewRows([]string{"registration_form_id", "status"}).AddRow("rf-1", string(tt.current)))
+            }
+            if tt.wantErr != nil {
+                mock.ExpectRollback()
+            } else {
+                mock.ExpectExec(regexp.QuoteMeta("UPDATE registration_form SET status = $1")).
+                    WithArgs(string(tt.to), "rf-1").
+                    WillReturnResult(sqlmock.NewResult(0, 1))
+                mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO form_state_history")).
+                    WithArgs("rf-1", tt.current, tt.to, "LTO-OFFICER-7", sqlmock.AnyArg()).
+                    WillReturnRows(sqlmock.NewRows([]string{"history_id"}).AddRow("h1"))
+                mock.ExpectCommit()
+            }
+
+            err := NewRegistrationFormRepository(db).UpdateStatus(context.Background(), "rf-1", tt.to, "LTO-OFFICER-7")
+            if !errors.Is(err, tt.wantErr) {
+                t.Fatalf("UpdateStatus = %v, want %v", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestPendingCountsIncludeFormsUnderReview(t *testing.T) {
+    db, mock := newMockDB(t)
+    mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM registration_form WHERE status IN ($1, $2)")).
+        WithArgs(models.FormSubmitted, models.FormUnderReview).
+        WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+    mock.ExpectQuery(regexp.QuoteMeta("WHERE status IN ($1, $2) GROUP BY 1")).
+        WithArgs(models.FormSubmitted, models.FormUnderReview).
+        WillReturnRows(sqlmock.NewRows([]string{"region", "count"}).AddRow("NCR", 5).AddRow("", 1))
+    repo := NewRegistrationFormRepository(db)
+
+    n, err := repo.GetPendingCount(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+    byRegion, err := repo.GetPendingByRegion(context.Background())
+    if err != nil {
+        t.Fatal(err)
+    }
+    if n != 6 || len(byRegion) != 2 || byRegion["NCR"] != 5 || byRegion[""] != 1 {
+        t.Fatalf("got %d and %v, want 6 and map[:1 NCR:5]", n, byRegion)
+    }
+}
+
+func TestRegistrationFormUpdateActiveClash(t *testing.T) {
+    tests := []struct {
+        name    string
+        err     error
+        wantErr error
+    }{
+        {"vehicle has another active form", &pq.Error{Code: "23505", Constraint: activeRegistrationIndex}, ErrActiveRegistrationExists},
+        {"some other unique key", &pq.Error{Code: "23505", Constraint: "registration_form_pkey"}, nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db, mock := newMockDB(t)
+            mock.ExpectExec(regexp.QuoteMeta("UPDATE registration_form")).WillReturnError(tt.err)
+
+            err := NewRegistrationFormRepository(db).Update(context.Background(), &models.RegistrationForm{RegistrationFormID: "f1", VehicleID: "v2"})
+            if tt.wantErr != nil && err != tt.wantErr {
+                t.Fatalf("err = %v, want %v", err, tt.wantErr)
+            }
+            if tt.wantErr == nil && (err == nil || errors.Is(err, ErrActiveRegistrationExists)) {
+                t.Fatalf("err = %v, want the driver error", err)
+            }
+        })
+    }
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "smartplate-api/internal/models"
+
+    "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+)
+
+// ErrDocumentExists is returned when an S3 object is already recorded
+var ErrDocumentExists = errors.New("document already recorded")
+
+// VehicleDocumentRepository stores metadata for vehicle documents kept in S3.
+type VehicleDocumentRepository interface {
+    Create(ctx context.Context, d *models.VehicleDocument) error
+    ListByVehicle(ctx context.Context, vehicleID string) ([]models.VehicleDocument, error)
+}
+
+type vehicleDocumentRepo struct {
+    db *sqlx.DB
+}
+
+// NewVehicleDocumentRepository returns a VehicleDocumentRepository backed by sqlx.DB.
+func NewVehicleDocumentRepository(db *sqlx.DB) VehicleDocumentRepository {
+    return &vehicleDocumentRepo{db: db}
+}
+
+// Create inserts a document and fills in its id and upload time. Recording
+// the same S3 key twice returns ErrDocumentExists.
+func (r *vehicleDocumentRepo) Create(ctx context.Context, d *models.VehicleDocument) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    INSERT INTO vehicle_documents (vehicle_id, doc_type, s3_key, uploaded_by)
+    VALUES ($1, $2, $3, $4)
+    RETURNING document_id, uploaded_at`
+    if err := r.db.QueryRowxContext(ctx, q, d.VehicleID, d.DocType, d.S3Key, d.UploadedBy).
+        Scan(&d.DocumentID, &d.UploadedAt); err != nil {
+        var pqErr *pq.Error
+        if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+            return ErrDocumentExists
+        }
+        return fmt.Errorf("insert vehicle document: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// ListByVehicle returns a vehicle's documents, newest first.
+func (r *vehicleDocumentRepo) ListByVehicle(ctx context.Context, vehicleID string) ([]models.VehicleDocument, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.VehicleDocument{}
+    const q = `
+    SELECT document_id, vehicle_id, doc_type, s3_key, uploaded_at, uploaded_by
+      FROM vehicle_documents
+     WHERE vehicle_id = $1
+     ORDER BY uploaded_at DESC`
+    if err := r.db.SelectContext(ctx, &list, q, vehicleID); err != nil {
+        return nil, fmt.Errorf("select vehicle documents: %w", queryErr(ctx, err))
+    }
+    return list, nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "smartplate-api/internal/models"
+
+    "github.com/jmoiron/sqlx"
+)
+
+// VehicleInspectionRepository manages periodic LTO vehicle inspections.
+type VehicleInspectionRepository interface {
+    Create(ctx context.Context, i *models.VehicleInspection) error
+    GetByVehicleID(ctx context.Context, vehicleID string) ([]models.VehicleInspection, error)
+    GetLatest(ctx context.Context, vehicleID string) (*models.VehicleInspection, error)
+    GetOverdue(ctx context.Context, limit, offset int) ([]models.VehicleInspection, error)
+    CountOverdue(ctx context.Context) (int, error)
+}
+
+type vehicleInspectionRepo struct {
+    db *sqlx.DB
+}
+
+// NewVehicleInspectionRepository returns a VehicleInspectionRepository backed by sqlx.DB.
+func NewVehicleInspectionRepository(db *sqlx.DB) VehicleInspectionRepository {
+    return &vehicleInspectionRepo{db: db}
+}
+
+// Create inserts an inspection and fills in its generated id.
+func (r *vehicleInspectionRepo) Create(ctx context.Context, i *models.VehicleInspection) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    INSERT INTO vehicle_inspections (
+      inspection_id, vehicle_id, inspector_lto_id, inspected_at,
+      result, remarks, next_inspection_due
+    ) VALUES (
+      gen_random_uuid(), $1, $2, $3, $4, $5, $6
+    )
+    RETURNING inspection_id`
+    if err := r.db.QueryRowxContext(ctx, q,
+        i.VehicleID, i.InspectorLTOID, i.InspectedAt,
+        i.Result, i.Remarks, i.NextInspectionDue,
+    ).Scan(&i.InspectionID); err != nil {
+        return fmt.Errorf("insert vehicle_inspections: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// GetByVehicleID lists a vehicle's inspections, newest first.
+func (r *vehicleInspectionRepo) GetByVehicleID(ctx context.Context, vehicleID string) ([]models.VehicleInspection, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.VehicleInspection{}
+    const q = `
+    SELECT
+      inspection_id, vehicle_id, inspector_lto_id, inspected_at,
+      result, remarks, next_inspection_due
+    FROM vehicle_inspections
+    WHERE vehicle_id = $1
+    ORDER BY inspected_at DESC`
+    if err := r.db.SelectContext(ctx, &list, q, vehicleID); err != nil {
+        return nil, fmt.Errorf("select vehicle_inspections: %w", queryErr(ctx, err))
+    }
+    return list, nil
+}
+
+// GetLatest returns a vehicle's most recent inspection, or nil if it has none.
+func (r *vehicleInspectionRepo) GetLatest(ctx context.Context, vehicleID string) (*models.VehicleInspection, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var i models.VehicleInspection
+    const q = `
+    SELECT
+      inspection_id, vehicle_id, inspector_lto_id, inspected_at,
+      result, remarks, next_inspection_due
+    FROM vehicle_inspections
+    WHERE vehicle_id = $1
+    ORDER BY inspected_at DESC
+    LIMIT 1`
+    err := r.db.GetContext(ctx, &i, q, vehicleID)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("select latest vehicle_inspection: %w", queryErr(ctx, err))
+    }
+    return &i, nil
+}
+
+// latestInspections is every vehicle's most recent inspection
+const latestInspections = `
+    SELECT DISTINCT ON (vehicle_id)
+      inspection_id, vehicle_id, inspector_lto_id, inspected_at,
+      result, remarks, next_inspection_due
+    FROM vehicle_inspections
+    ORDER BY vehicle_id, inspected_at DESC`
+
+// GetOverdue returns one page of the latest inspections of vehicles whose
+// next inspection is past due, longest overdue first.
+func (r *vehicleInspectionRepo) GetOverdue(ctx context.Context, limit, offset int) ([]models.VehicleInspection, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.VehicleInspection{}
+    const q = `
+    SELECT * FROM (` + latestInspections + `
+    ) latest
+    WHERE next_inspection_due < NOW()
+    ORDER BY next_inspection_due
+    LIMIT $1 OFFSET $2`
+    if err := r.db.SelectContext(ctx, &list, q, limit, offset); err != nil {
+        return nil, fmt.Errorf("select overdue vehicle_inspections: %w", queryErr(ctx, err))
+    }
+    return list, nil
+}
+
+// CountOverdue counts vehicles whose next inspection is past due.
+func (r *vehicleInspectionRepo) CountOverdue(ctx context.Context) (int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var n int
+    const q = `
+    SELECT COUNT(*) FROM (` + latestInspections + `
+    ) latest
+    WHERE next_inspection_due < NOW()`
+    if err := r.db.GetContext(ctx, &n, q); err != nil {
+        return 0, fmt.Errorf("count overdue vehicle_inspections: %w", queryErr(ctx, err))
+    }
+    return n, nil
+}
@@ -3,23 +3,53 @@ package repository
 
 import (
     "context"
+    "database/sql"
+    "errors"
     "fmt"
     "strings"
     "smartplate-api/internal/models"
 
     "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
 )
 
+// ErrMVFileNumberTaken is returned when restoring a vehicle whose MV file
+// number has since been registered to another vehicle
+var ErrMVFileNumberTaken = errors.New("mv_file_number already registered")
+
+// liveMVFileNumberIndex keeps MV file numbers unique among vehicles that
+// aren't soft-deleted
+const liveMVFileNumberIndex = "idx_vehicles_live_mv_file_number"
+
 type VehicleRepository interface {
     CreateVehicle(ctx context.Context, v *models.Vehicle) (*models.Vehicle, error)
     GetAllVehicles(ctx context.Context) ([]models.Vehicle, error)
     GetVehicleByID(ctx context.Context, id string) (*models.Vehicle, error)
     UpdateVehicle(ctx context.Context, id string, fields map[string]interface{}) error
-    DeleteVehicle(ctx context.Context, id string) error
+    DeleteVehicle(ctx context.Context, id, changedBy string) error
 
     GetVehicleByClientID(ctx context.Context, clientID string) (*models.Vehicle, error)
     UpdateVehicleByClientID(ctx context.Context, clientID string, fields map[string]interface{}) error
-    DeleteVehicleByClientID(ctx context.Context, clientID string) error
+    DeleteVehicleByClientID(ctx context.Context, clientID, changedBy string) error
+
+    ListVehicles(ctx context.Context, limit, offset int) ([]models.Vehicle, int, error)
+    GetVehiclesByOwner(ctx context.Context, clientID string) ([]models.Vehicle, error)
+    GetByMVFileNumber(ctx context.Context, mvFileNumber string) (*models.Vehicle, error)
+    SearchByMVFileNumber(ctx context.Context, query string) ([]*models.Vehicle, error)
+    Search(ctx context.Context, filter VehicleSearchFilter) ([]models.Vehicle, int, error)
+
+    Restore(ctx context.Context, vehicleID string) error
+    GetDeleted(ctx context.Context) ([]models.Vehicle, error)
+}
+
+// VehicleSearchFilter narrows Search; empty fields are ignored
+type VehicleSearchFilter struct {
+    Make       string
+    Model      string
+    Year       string
+    OwnerLTOID string
+    Limit      int
+    Offset     int
 }
 
 type vehicleRepo struct {
@@ -31,9 +61,11 @@ func NewVehicleRepository(db *sqlx.DB) VehicleRepository {
 }
 
 func (r *vehicleRepo) CreateVehicle(ctx context.Context, v *models.Vehicle) (*models.Vehicle, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     query := `
     INSERT INTO vehicles (
-        vehicle_category, mv_file_number, vehicle_make, vehicle_series, vehicle_type,
+        vehicle_id, vehicle_category, mv_file_number, vehicle_make, vehicle_series, vehicle_type,
         body_type, year_model, engine_model, engine_number, chassis_number,
         piston_displacement, number_of_cylinders, fuel_type, color, gvw,
         net_weight, shipping_weight, usage_classification,
@@ -41,7 +73,7 @@ func (r *vehicleRepo) CreateVehicle(ctx context.Context, v *models.Vehicle) (*mo
         lto_office_code, classification, denomination, or_number, cr_number,
         lto_client_id
     ) VALUES (
-        :vehicle_category, :mv_file_number, :vehicle_make, :vehicle_series, :vehicle_type,
+        :vehicle_id, :vehicle_category, :mv_file_number, :vehicle_make, :vehicle_series, :vehicle_type,
         :body_type, :year_model, :engine_model, :engine_number, :chassis_number,
         :piston_displacement, :number_of_cylinders, :fuel_type, :color, :gvw,
         :net_weight, :shipping_weight, :usage_classification,
@@ -53,33 +85,39 @@ func (r *vehicleRepo) CreateVehicle(ctx context.Context, v *models.Vehicle) (*mo
     `
     rows, err := r.db.NamedQueryContext(ctx, query, v)
     if err != nil {
-        return nil, err
+        return nil, queryErr(ctx, err)
     }
     defer rows.Close()
 
     if rows.Next() {
         if err := rows.Scan(&v.VEHICLE_ID); err != nil {
-            return nil, err
+            return nil, queryErr(ctx, err)
         }
     }
     return v, nil
 }
 
 func (r *vehicleRepo) GetAllVehicles(ctx context.Context) ([]models.Vehicle, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var list []models.Vehicle
-    err := r.db.SelectContext(ctx, &list, "SELECT * FROM vehicles ORDER BY vehicle_id")
-    return list, err
+    err := r.db.SelectContext(ctx, &list, "SELECT * FROM vehicles WHERE deleted_at IS NULL ORDER BY vehicle_id")
+    return list, queryErr(ctx, err)
 }
 
 func (r *vehicleRepo) GetVehicleByID(ctx context.Context, id string) (*models.Vehicle, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var v models.Vehicle
-    if err := r.db.GetContext(ctx, &v, "SELECT * FROM vehicles WHERE vehicle_id = $1", id); err != nil {
+    if err := r.db.GetContext(ctx, &v, "SELECT * FROM vehicles WHERE vehicle_id = $1 AND deleted_at IS NULL", id); err != nil {
         return nil, fmt.Errorf("not found")
     }
     return &v, nil
 }
 
 func (r *vehicleRepo) UpdateVehicle(ctx context.Context, id string, fields map[string]interface{}) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     delete(fields, "id")
     delete(fields, "vehicle_id")
 
@@ -94,23 +132,72 @@ func (r *vehicleRepo) UpdateVehicle(ctx context.Context, id string, fields map[s
     fields["vehicle_id"] = id
 
     query := fmt.Sprintf(
-        "UPDATE vehicles SET %s WHERE vehicle_id = :vehicle_id",
+        "UPDATE vehicles SET %s WHERE vehicle_id = :vehicle_id AND deleted_at IS NULL",
         strings.Join(setClauses, ", "),
     )
 
     _, err := r.db.NamedExecContext(ctx, query, fields)
-    return err
+    return queryErr(ctx, err)
 }
 
-func (r *vehicleRepo) DeleteVehicle(ctx context.Context, id string) error {
-    _, err := r.db.ExecContext(ctx, "DELETE FROM vehicles WHERE vehicle_id = $1", id)
-    return err
+// vehicleDeletedReason is recorded in plate_history for plates deactivated
+// because their vehicle was deleted
+const vehicleDeletedReason = "vehicle deleted"
+
+// DeleteVehicle soft-deletes a vehicle by stamping deleted_at and, in the
+// same transaction, deactivates its plates, snapshotting each into
+// plate_history as changed by changedBy
+func (r *vehicleRepo) DeleteVehicle(ctx context.Context, id, changedBy string) error {
+    return r.softDelete(ctx, "vehicle_id = $1", id, changedBy)
+}
+
+// softDelete is DeleteVehicle for every live vehicle matching match, a
+// condition on vehicles with arg as $1
+func (r *vehicleRepo) softDelete(ctx context.Context, match, arg, changedBy string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    defer tx.Rollback()
+
+    res, err := tx.ExecContext(ctx,
+        "UPDATE vehicles SET deleted_at = NOW() WHERE "+match+" AND deleted_at IS NULL", arg,
+    )
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return nil
+    }
+
+    // plates of vehicles deleted earlier are already deactivated
+    plates := "vehicle_id IN (SELECT vehicle_id FROM vehicles WHERE " + match + ") AND status <> 'Deactivated'"
+    snapshot := `
+    INSERT INTO plate_history (
+      history_id, plate_id, vehicle_id, plate_number, plate_type,
+      plate_issue_date, plate_expiration_date, status, changed_at, changed_by, reason
+    )
+    SELECT gen_random_uuid(), plate_id, vehicle_id, plate_number, plate_type,
+           plate_issue_date, plate_expiration_date, status, NOW(), $2, $3
+      FROM plates
+     WHERE ` + plates
+    if _, err := tx.ExecContext(ctx, snapshot, arg, changedBy, vehicleDeletedReason); err != nil {
+        return fmt.Errorf("insert plate_history: %w", queryErr(ctx, err))
+    }
+    if _, err := tx.ExecContext(ctx, "UPDATE plates SET status = 'Deactivated' WHERE "+plates, arg); err != nil {
+        return fmt.Errorf("deactivate plates: %w", queryErr(ctx, err))
+    }
+    return queryErr(ctx, tx.Commit())
 }
 
 func (r *vehicleRepo) GetVehicleByClientID(ctx context.Context, clientID string) (*models.Vehicle, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     var v models.Vehicle
     if err := r.db.GetContext(ctx, &v,
-        "SELECT * FROM vehicles WHERE lto_client_id = $1", clientID,
+        "SELECT * FROM vehicles WHERE lto_client_id = $1 AND deleted_at IS NULL", clientID,
     ); err != nil {
         return nil, fmt.Errorf("not found")
     }
@@ -118,6 +205,8 @@ func (r *vehicleRepo) GetVehicleByClientID(ctx context.Context, clientID string)
 }
 
 func (r *vehicleRepo) UpdateVehicleByClientID(ctx context.Context, clientID string, fields map[string]interface{}) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
     delete(fields, "lto_client_id")
     delete(fields, "vehicle_id")
 
@@ -132,17 +221,153 @@ func (r *vehicleRepo) UpdateVehicleByClientID(ctx context.Context, clientID stri
     fields["lto_client_id"] = clientID
 
     query := fmt.Sprintf(
-        "UPDATE vehicles SET %s WHERE lto_client_id = :lto_client_id",
+        "UPDATE vehicles SET %s WHERE lto_client_id = :lto_client_id AND deleted_at IS NULL",
         strings.Join(setClauses, ", "),
     )
     _, err := r.db.NamedExecContext(ctx, query, fields)
-    return err
+    return queryErr(ctx, err)
+}
+
+// DeleteVehicleByClientID is DeleteVehicle for every vehicle of a client
+func (r *vehicleRepo) DeleteVehicleByClientID(ctx context.Context, clientID, changedBy string) error {
+    return r.softDelete(ctx, "lto_client_id = $1", clientID, changedBy)
+}
+
+// ListVehicles returns one page of vehicles plus the total count
+func (r *vehicleRepo) ListVehicles(ctx context.Context, limit, offset int) ([]models.Vehicle, int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.Vehicle{}
+    var total int
+    if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM vehicles WHERE deleted_at IS NULL"); err != nil {
+        return nil, 0, queryErr(ctx, err)
+    }
+    err := r.db.SelectContext(ctx, &list,
+        "SELECT * FROM vehicles WHERE deleted_at IS NULL ORDER BY vehicle_id LIMIT $1 OFFSET $2", limit, offset,
+    )
+    return list, total, queryErr(ctx, err)
+}
+
+// GetVehiclesByOwner returns every vehicle registered to an LTO client
+func (r *vehicleRepo) GetVehiclesByOwner(ctx context.Context, clientID string) ([]models.Vehicle, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.Vehicle{}
+    err := r.db.SelectContext(ctx, &list,
+        "SELECT * FROM vehicles WHERE lto_client_id = $1 AND deleted_at IS NULL ORDER BY vehicle_id", clientID,
+    )
+    return list, queryErr(ctx, err)
 }
 
-func (r *vehicleRepo) DeleteVehicleByClientID(ctx context.Context, clientID string) error {
-    _, err := r.db.ExecContext(ctx,
-        "DELETE FROM vehicles WHERE lto_client_id = $1", clientID,
+// GetByMVFileNumber returns the vehicle with this MV file number, or nil if none
+func (r *vehicleRepo) GetByMVFileNumber(ctx context.Context, mvFileNumber string) (*models.Vehicle, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var v models.Vehicle
+    err := r.db.GetContext(ctx, &v, "SELECT * FROM vehicles WHERE mv_file_number = $1 AND deleted_at IS NULL", mvFileNumber)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, queryErr(ctx, err)
+    }
+    return &v, nil
+}
+
+// mvFileSearchLimit caps SearchByMVFileNumber; a scanner only needs enough
+// candidates to show the query was too loose
+const mvFileSearchLimit = 10
+
+// SearchByMVFileNumber returns vehicles whose MV file number contains query,
+// ignoring case and surrounding whitespace
+func (r *vehicleRepo) SearchByMVFileNumber(ctx context.Context, query string) ([]*models.Vehicle, error) {
+    query = strings.ToUpper(strings.TrimSpace(query))
+    if query == "" {
+        return nil, nil
+    }
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var list []*models.Vehicle
+    const q = `
+        SELECT * FROM vehicles
+         WHERE UPPER(mv_file_number) LIKE '%' || $1 || '%'
+           AND deleted_at IS NULL
+         ORDER BY mv_file_number
+         LIMIT $2
+    `
+    err := r.db.SelectContext(ctx, &list, q, query, mvFileSearchLimit)
+    return list, queryErr(ctx, err)
+}
+
+// Search returns one page of vehicles matching every non-empty filter field,
+// plus the total number of matches
+func (r *vehicleRepo) Search(ctx context.Context, filter VehicleSearchFilter) ([]models.Vehicle, int, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    conds := []string{"deleted_at IS NULL"}
+    args := []interface{}{}
+    add := func(cond string, val interface{}) {
+        args = append(args, val)
+        conds = append(conds, fmt.Sprintf(cond, len(args)))
+    }
+    if filter.Make != "" {
+        add("vehicle_make ILIKE $%d", filter.Make)
+    }
+    if filter.Model != "" {
+        add("vehicle_series ILIKE $%d", filter.Model)
+    }
+    if filter.Year != "" {
+        add("year_model = $%d", filter.Year)
+    }
+    if filter.OwnerLTOID != "" {
+        add("lto_client_id = $%d", filter.OwnerLTOID)
+    }
+
+    where := " WHERE " + strings.Join(conds, " AND ")
+
+    var total int
+    if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM vehicles"+where, args...); err != nil {
+        return nil, 0, queryErr(ctx, err)
+    }
+
+    list := []models.Vehicle{}
+    query := fmt.Sprintf("SELECT * FROM vehicles%s ORDER BY vehicle_id LIMIT $%d OFFSET $%d",
+        where, len(args)+1, len(args)+2)
+    if err := r.db.SelectContext(ctx, &list, query, append(args, filter.Limit, filter.Offset)...); err != nil {
+        return nil, 0, queryErr(ctx, err)
+    }
+    return list, total, nil
+}
+
+// Restore clears deleted_at on a soft-deleted vehicle. It returns
+// ErrMVFileNumberTaken when a live vehicle now has the same MV file number.
+// The plates stay deactivated.
+func (r *vehicleRepo) Restore(ctx context.Context, vehicleID string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    res, err := r.db.ExecContext(ctx,
+        "UPDATE vehicles SET deleted_at = NULL WHERE vehicle_id = $1 AND deleted_at IS NOT NULL", vehicleID,
     )
-    return err
+    var pqErr *pq.Error
+    if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == liveMVFileNumberIndex {
+        return ErrMVFileNumberTaken
+    }
+    if err != nil {
+        return queryErr(ctx, err)
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return fmt.Errorf("not found")
+    }
+    return nil
 }
 
+// GetDeleted lists soft-deleted vehicles, most recently deleted first
+func (r *vehicleRepo) GetDeleted(ctx context.Context) ([]models.Vehicle, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    list := []models.Vehicle{}
+    err := r.db.SelectContext(ctx, &list,
+        "SELECT * FROM vehicles WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC",
+    )
+    return list, queryErr(ctx, err)
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+    "context"
+    "database/sql/driver"
+    "errors"
+    "fmt"
+    "regexp"
+    "strings"
+    "testing"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/lib/pq"
+)
+
+func TestVehicleSearchFilterCombinations(t *testing.T) {
+    filters := []struct {
+        cond string
+        val  string
+        set  func(*VehicleSearchFilter, string)
+    }{
+        {"vehicle_make ILIKE $%d", "Toyota", func(f *VehicleSearchFilter, v string) { f.Make = v }},
+        {"vehicle_series ILIKE $%d", "Hilux", func(f *VehicleSearchFilter, v string) { f.Model = v }},
+        {"year_model = $%d", "2020", func(f *VehicleSearchFilter, v string) { f.Year = v }},
+        {"lto_client_id = $%d", "XYZ", func(f *VehicleSearchFilter, v string) { f.OwnerLTOID = v }},
+    }
+
+    // every subset of the filters, from none to all four
+    for mask := 0; mask < 1<<len(filters); mask++ {
+        filter := VehicleSearchFilter{Limit: 20, Offset: 40}
+        conds := []string{"deleted_at IS NULL"}
+        var args []driver.Value
+        var names []string
+        for i, f := range filters {
+            if mask&(1<<i) == 0 {
+                continue
+            }
+            f.set(&filter, f.val)
+            args = append(args, f.val)
+            conds = append(conds, fmt.Sprintf(f.cond, len(args)))
+            names = append(names, f.val)
+        }
+        name := strings.Join(names, "+")
+        if name == "" {
+            name = "no filters"
+        }
+
+        t.Run(name, func(t *testing.T) {
+            db, mock := newMockDB(t)
+            where := " WHERE " + strings.Join(conds, " AND ")
+            mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT COUNT(*) FROM vehicles"+where) + "$").
+                WithArgs(args...).
+                WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(41))
+            pageSQL := fmt.Sprintf("SELECT * FROM vehicles%s ORDER BY vehicle_id LIMIT $%d OFFSET $%d",
+                where, len(args)+1, len(args)+2)
+            mock.ExpectQuery("^" + regexp.QuoteMeta(pageSQL) + "$").
+                WithArgs(append(args, 20, 40)...).
+                WillReturnRows(sqlmock.NewRows([]string{"vehicle_id", "vehicle_make"}).AddRow("v41", "Toyota"))
+
+            list, total, err := NewVehicleRepository(db).Search(context.Background(), filter)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if total != 41 || len(list) != 1 || list[0].VEHICLE_ID != "v41" {
+                t.Fatalf("got total %d and %+v", total, list)
+            }
+        })
+    }
+}
+
+func TestVehicleSoftDelete(t *testing.T) {
+    db, mock := newMockDB(t)
+    repo := NewVehicleRepository(db)
+
+    mock.ExpectBegin()
+    mock.ExpectExec(regexp.QuoteMeta("UPDATE vehicles SET deleted_at = NOW() WHERE vehicle_id = $1 AND deleted_at IS NULL")).
+        WithArgs("v1").
+        WillReturnResult(sqlmock.NewResult(0, 0))
+    mock.ExpectRollback()
+    if err := repo.DeleteVehicle(context.Background(), "v1", "officer-1"); err != nil {
+        t.Fatal(err)
+    }
+
+    // a soft-deleted vehicle is hidden from lookups
+    mock.ExpectQuery(regexp.QuoteMeta("WHERE vehicle_id = $1 AND deleted_at IS NULL")).
+        WithArgs("v1").
+        WillReturnRows(sqlmock.NewRows([]string{"vehicle_id"}))
+    if v, err := repo.GetVehicleByID(context.Background(), "v1"); err == nil {
+        t.Fatalf("GetVehicleByID found deleted vehicle %+v", v)
+    }
+
+    mock.ExpectExec(regexp.QuoteMeta("UPDATE vehicles SET deleted_at = NULL WHERE vehicle_id = $1 AND deleted_at IS NOT NULL")).
+        WithArgs("v1").
+        WillReturnResult(sqlmock.NewResult(0, 1))
+    if err := repo.Restore(context.Background(), "v1"); err != nil {
+        t.Fatal(err)
+    }
+
+    mock.ExpectExec(regexp.QuoteMeta("UPDATE vehicles SET deleted_at = NULL")).
+        WithArgs("v1").
+        WillReturnResult(sqlmock.NewResult(0, 0))
+    if err := repo.Restore(context.Background(), "v1"); err == nil {
+        t.Fatal("restoring a vehicle that isn't deleted should fail")
+    }
+}
+
+func TestVehicleSoftDeleteCascade(t *testing.T) {
+    const plates = "vehicle_id IN (SELECT vehicle_id FROM vehicles WHERE vehicle_id = $1) AND status <> 'Deactivated'"
+    tests := []struct {
+        name       string
+        failUpdate bool
+    }{
+        {"plates deactivated with the vehicle", false},
+        {"failed plate update undoes the delete", true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            db, mock := newMockDB(t)
+            mock.ExpectBegin()
+            mock.ExpectExec(regexp.QuoteMeta("UPDATE vehicles SET deleted_at = NOW() WHERE vehicle_id = $1 AND deleted_at IS NULL")).
+                WithArgs("v1").
+                WillReturnResult(sqlmock.NewResult(0, 1))
+            mock.ExpectExec(`INSERT INTO plate_history \(.*reason.*FROM plates\s+WHERE `+regexp.QuoteMeta(plates)+"$").
+                WithArgs("v1", "officer-1", "vehicle deleted").
+                WillReturnResult(sqlmock.NewResult(0, 2))
+            update := mock.ExpectExec("^" + regexp.QuoteMeta("UPDATE plates SET status = 'Deactivated' WHERE "+plates) + "$").
+                WithArgs("v1")
+            if tt.failUpdate {
+                update.WillReturnError(errors.New("connection reset"))
+                mock.ExpectRollback()
+            } else {
+                update.WillReturnResult(sqlmock.NewResult(0, 2))
+                mock.ExpectCommit()
+            }
+
+            err := NewVehicleRepository(db).DeleteVehicle(context.Background(), "v1", "officer-1")
+            if (err != nil) != tt.failUpdate {
+                t.Fatalf("err = %v, want failure %v", err, tt.failUpdate)
+            }
+        })
+    }
+}
+
+func TestVehicleRestoreTakenMVFileNumber(t *testing.T) {
+    db, mock := newMockDB(t)
+    mock.ExpectExec(regexp.QuoteMeta("UPDATE vehicles SET deleted_at = NULL")).
+        WithArgs("v1").
+        WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_vehicles_live_mv_file_number"})
+    if err := NewVehicleRepository(db).Restore(context.Background(), "v1"); err != ErrMVFileNumberTaken {
+        t.Fatalf("err = %v, want ErrMVFileNumberTaken", err)
+    }
+}
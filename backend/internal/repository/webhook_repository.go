@@ -0,0 +1,140 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+
+    "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+
+    "smartplate-api/internal/models"
+)
+
+// WebhookRepository stores webhook endpoints and the log of deliveries to them.
+type WebhookRepository interface {
+    Create(ctx context.Context, w *models.WebhookConfig) error
+    GetByID(ctx context.Context, id string) (*models.WebhookConfig, error)
+    List(ctx context.Context) ([]models.WebhookConfig, error)
+    Update(ctx context.Context, w *models.WebhookConfig) error
+    Delete(ctx context.Context, id string) error
+    ListActiveForEvents(ctx context.Context, events []string) ([]models.WebhookConfig, error)
+    LogDelivery(ctx context.Context, d *models.WebhookDelivery) error
+}
+
+type webhookRepo struct {
+    db *sqlx.DB
+}
+
+// NewWebhookRepository returns a WebhookRepository backed by sqlx.DB.
+func NewWebhookRepository(db *sqlx.DB) WebhookRepository {
+    return &webhookRepo{db: db}
+}
+
+const webhookColumns = `webhook_id, url, secret, event_types, active, created_at, updated_at`
+
+// Create inserts w and fills in its id and timestamps.
+func (r *webhookRepo) Create(ctx context.Context, w *models.WebhookConfig) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    INSERT INTO webhooks (url, secret, event_types, active)
+    VALUES ($1, $2, $3, $4)
+    RETURNING webhook_id, created_at, updated_at`
+    err := r.db.QueryRowxContext(ctx, q, w.URL, w.Secret, w.EventTypes, w.Active).
+        Scan(&w.WebhookID, &w.CreatedAt, &w.UpdatedAt)
+    if err != nil {
+        return fmt.Errorf("insert webhooks: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// GetByID returns the webhook, or nil if there is none with that id.
+func (r *webhookRepo) GetByID(ctx context.Context, id string) (*models.WebhookConfig, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var w models.WebhookConfig
+    err := r.db.GetContext(ctx, &w, `SELECT `+webhookColumns+` FROM webhooks WHERE webhook_id = $1`, id)
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("select webhooks: %w", queryErr(ctx, err))
+    }
+    return &w, nil
+}
+
+// List returns every webhook, oldest first.
+func (r *webhookRepo) List(ctx context.Context) ([]models.WebhookConfig, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    hooks := []models.WebhookConfig{}
+    if err := r.db.SelectContext(ctx, &hooks, `SELECT `+webhookColumns+` FROM webhooks ORDER BY created_at`); err != nil {
+        return nil, fmt.Errorf("select webhooks: %w", queryErr(ctx, err))
+    }
+    return hooks, nil
+}
+
+// Update saves w's url, secret, event types and active flag. It returns
+// sql.ErrNoRows if the webhook doesn't exist.
+func (r *webhookRepo) Update(ctx context.Context, w *models.WebhookConfig) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    UPDATE webhooks
+       SET url = $2, secret = $3, event_types = $4, active = $5, updated_at = NOW()
+     WHERE webhook_id = $1
+    RETURNING updated_at`
+    err := r.db.QueryRowxContext(ctx, q, w.WebhookID, w.URL, w.Secret, w.EventTypes, w.Active).Scan(&w.UpdatedAt)
+    if errors.Is(err, sql.ErrNoRows) {
+        return err
+    }
+    if err != nil {
+        return fmt.Errorf("update webhooks: %w", queryErr(ctx, err))
+    }
+    return nil
+}
+
+// Delete removes the webhook and its delivery log. It returns sql.ErrNoRows
+// if the webhook doesn't exist.
+func (r *webhookRepo) Delete(ctx context.Context, id string) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    res, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE webhook_id = $1`, id)
+    if err != nil {
+        return fmt.Errorf("delete webhooks: %w", queryErr(ctx, err))
+    }
+    if n, _ := res.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+    return nil
+}
+
+// ListActiveForEvents returns the active webhooks subscribed to any of events.
+func (r *webhookRepo) ListActiveForEvents(ctx context.Context, events []string) ([]models.WebhookConfig, error) {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    var hooks []models.WebhookConfig
+    const q = `SELECT ` + webhookColumns + ` FROM webhooks WHERE active AND event_types && $1`
+    if err := r.db.SelectContext(ctx, &hooks, q, pq.Array(events)); err != nil {
+        return nil, fmt.Errorf("select active webhooks: %w", queryErr(ctx, err))
+    }
+    return hooks, nil
+}
+
+// LogDelivery records one delivery attempt.
+func (r *webhookRepo) LogDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+    ctx, cancel := WithQueryTimeout(ctx, 0)
+    defer cancel()
+    const q = `
+    INSERT INTO webhook_delivery_log (webhook_id, event_type, attempt, status_code, error)
+    VALUES ($1, $2, $3, $4, $5)
+    RETURNING delivery_id, attempted_at`
+    err := r.db.QueryRowxContext(ctx, q, d.WebhookID, d.EventType, d.Attempt, d.StatusCode, d.Error).
+        Scan(&d.DeliveryID, &d.AttemptedAt)
+    if err != nil {
+        return fmt.Errorf("insert webhook_delivery_log: %w", queryErr(ctx, err))
+    }
+    return nil
+}
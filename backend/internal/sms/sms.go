@@ -0,0 +1,159 @@
+// Package sms sends text message notifications for users who prefer them to
+// email.
+package sms
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "net/url"
+    "os"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+// maxLength keeps each message to a single SMS segment
+const maxLength = 160
+
+// twilioAPIBase is Twilio's REST API root
+const twilioAPIBase = "https://api.twilio.com/2010-04-01"
+
+// SMSSender sends the notifications SmartPlate delivers by text message.
+// Numbers are in E.164 form, e.g. +639171234567.
+type SMSSender interface {
+    SendPlateExpiry(to, plateNumber string, daysUntilExpiry int) error
+    SendScanAlert(to, plateNumber, location string) error
+}
+
+// FromEnv returns a TwilioSMSSender when TWILIO_ACCOUNT_SID,
+// TWILIO_AUTH_TOKEN and TWILIO_FROM_NUMBER are all set, and a NullSMSSender
+// otherwise
+func FromEnv() SMSSender {
+    sid, token, from := os.Getenv("TWILIO_ACCOUNT_SID"), os.Getenv("TWILIO_AUTH_TOKEN"), os.Getenv("TWILIO_FROM_NUMBER")
+    if sid == "" || token == "" || from == "" {
+        return NullSMSSender{}
+    }
+    return NewTwilioSMSSender(sid, token, from)
+}
+
+// plateExpiryMessage is the text of a plate expiry reminder
+func plateExpiryMessage(plateNumber string, daysUntilExpiry int) string {
+    var when string
+    switch {
+    case daysUntilExpiry < 0:
+        when = "has expired"
+    case daysUntilExpiry == 0:
+        when = "expires today"
+    case daysUntilExpiry == 1:
+        when = "expires tomorrow"
+    default:
+        when = fmt.Sprintf("expires in %d days", daysUntilExpiry)
+    }
+    return fit(fmt.Sprintf("SmartPlate: Your plate %s %s. Renew it at the SmartPlate portal or any LTO office.", plateNumber, when))
+}
+
+// scanAlertMessage is the text of a suspicious-scan alert. The location is
+// shortened, never the warning, when the message runs long.
+func scanAlertMessage(plateNumber, location string) string {
+    if location == "" {
+        location = "an unknown location"
+    }
+    head := fmt.Sprintf("SmartPlate alert: your plate %s was flagged in a scan at ", plateNumber)
+    tail := ". If this wasn't your vehicle, contact the LTO."
+    room := maxLength - utf8.RuneCountInString(head) - utf8.RuneCountInString(tail)
+    return fit(head + truncate(location, room) + tail)
+}
+
+// fit cuts msg to maxLength characters
+func fit(msg string) string {
+    return truncate(msg, maxLength)
+}
+
+// truncate cuts s to at most n characters, ending it with "..." when cut.
+// It counts runes, so a multi-byte character is never split.
+func truncate(s string, n int) string {
+    r := []rune(s)
+    switch {
+    case len(r) <= n:
+        return s
+    case n < 3:
+        return string(r[:max(n, 0)])
+    }
+    return string(r[:n-3]) + "..."
+}
+
+// maskNumber keeps only the last four digits of a phone number for logs
+func maskNumber(n string) string {
+    if len(n) <= 4 {
+        return "****"
+    }
+    return strings.Repeat("*", len(n)-4) + n[len(n)-4:]
+}
+
+// NullSMSSender logs messages instead of sending them; used in development
+// and whenever Twilio isn't configured
+type NullSMSSender struct{}
+
+func (NullSMSSender) SendPlateExpiry(to, plateNumber string, daysUntilExpiry int) error {
+    log.Printf("sms (not sent) to %s: %s", maskNumber(to), plateExpiryMessage(plateNumber, daysUntilExpiry))
+    return nil
+}
+
+func (NullSMSSender) SendScanAlert(to, plateNumber, location string) error {
+    log.Printf("sms (not sent) to %s: %s", maskNumber(to), scanAlertMessage(plateNumber, location))
+    return nil
+}
+
+// TwilioSMSSender sends messages through Twilio's Messages API
+type TwilioSMSSender struct {
+    AccountSID string
+    AuthToken  string
+    From       string
+    Client     *http.Client
+}
+
+// NewTwilioSMSSender returns a TwilioSMSSender sending from the given number
+func NewTwilioSMSSender(accountSID, authToken, from string) *TwilioSMSSender {
+    return &TwilioSMSSender{
+        AccountSID: accountSID,
+        AuthToken:  authToken,
+        From:       from,
+        Client:     &http.Client{Timeout: 10 * time.Second},
+    }
+}
+
+func (s *TwilioSMSSender) SendPlateExpiry(to, plateNumber string, daysUntilExpiry int) error {
+    return s.send(to, plateExpiryMessage(plateNumber, daysUntilExpiry))
+}
+
+func (s *TwilioSMSSender) SendScanAlert(to, plateNumber, location string) error {
+    return s.send(to, scanAlertMessage(plateNumber, location))
+}
+
+// send posts one message; Twilio answers 201 with the queued message
+func (s *TwilioSMSSender) send(to, body string) error {
+    form := url.Values{"To": {to}, "From": {s.From}, "Body": {body}}
+    endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioAPIBase, url.PathEscape(s.AccountSID))
+    req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+    if err != nil {
+        return err
+    }
+    req.SetBasicAuth(s.AccountSID, s.AuthToken)
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    resp, err := s.Client.Do(req)
+    if err != nil {
+        return fmt.Errorf("send sms: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusCreated {
+        var apiErr struct {
+            Code    int    `json:"code"`
+            Message string `json:"message"`
+        }
+        json.NewDecoder(resp.Body).Decode(&apiErr)
+        return fmt.Errorf("send sms: twilio status %d: %s (code %d)", resp.StatusCode, apiErr.Message, apiErr.Code)
+    }
+    return nil
+}
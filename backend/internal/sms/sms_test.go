@@ -0,0 +1,57 @@
+package sms
+
+import (
+    "strings"
+    "testing"
+    "unicode/utf8"
+)
+
+func TestMessagesFitOneSegment(t *testing.T) {
+    long := strings.Repeat("Barangay San Isidro Labrador, ", 10)
+    tests := []struct {
+        name string
+        msg  string
+        want string // a part the message must keep
+    }{
+        {"expiry in days", plateExpiryMessage("ABC 1234", 30), "expires in 30 days"},
+        {"expired", plateExpiryMessage("ABC 1234", -2), "has expired"},
+        {"long plate number", plateExpiryMessage(strings.Repeat("X", 200), 1), "SmartPlate: Your plate"},
+        {"scan alert", scanAlertMessage("ABC 1234", "14.59951, 120.98422"), "14.59951, 120.98422"},
+        {"no location", scanAlertMessage("ABC 1234", ""), "an unknown location"},
+        {"long location", scanAlertMessage("ABC 1234", long), "If this wasn't your vehicle, contact the LTO."},
+        {"multi-byte location", scanAlertMessage("ABC 1234", strings.Repeat("Parañaque ", 30)), "contact the LTO."},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if n := utf8.RuneCountInString(tt.msg); n > maxLength {
+                t.Fatalf("message is %d characters, want at most %d: %q", n, maxLength, tt.msg)
+            }
+            if !utf8.ValidString(tt.msg) {
+                t.Fatalf("message is not valid UTF-8: %q", tt.msg)
+            }
+            if !strings.Contains(tt.msg, tt.want) {
+                t.Fatalf("message %q is missing %q", tt.msg, tt.want)
+            }
+        })
+    }
+}
+
+func TestTruncate(t *testing.T) {
+    tests := []struct {
+        in   string
+        n    int
+        want string
+    }{
+        {"short", 10, "short"},
+        {"exactly10!", 10, "exactly10!"},
+        {"a bit too long", 10, "a bit t..."},
+        {"ñññññññññññ", 10, "ñññññññ..."},
+        {"abc", 2, "ab"},
+    }
+    for _, tt := range tests {
+        if got := truncate(tt.in, tt.n); got != tt.want {
+            t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,157 @@
+// Package storage issues presigned S3 URLs so clients upload and download
+// documents directly, without the files passing through the API.
+package storage
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/credentials"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+    "github.com/google/uuid"
+)
+
+const (
+    // UploadURLExpiry is how long a presigned upload URL stays usable
+    UploadURLExpiry = 15 * time.Minute
+    // DownloadURLExpiry is how long a presigned download URL stays usable
+    DownloadURLExpiry = time.Hour
+)
+
+// ErrNotConfigured is returned when S3_BUCKET, AWS_REGION or the AWS
+// credentials are missing
+var ErrNotConfigured = errors.New("document storage is not configured")
+
+// ErrUnsupportedType is returned for file extensions without a known
+// content type
+var ErrUnsupportedType = errors.New("unsupported document type")
+
+// contentTypes are the content types uploads are signed for, by extension
+var contentTypes = map[string]string{
+    ".pdf":  "application/pdf",
+    ".jpg":  "image/jpeg",
+    ".jpeg": "image/jpeg",
+    ".png":  "image/png",
+}
+
+// config is read from S3_BUCKET, AWS_REGION, AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and, for temporary credentials, AWS_SESSION_TOKEN.
+// S3_ENDPOINT points at an S3-compatible server such as MinIO instead of AWS.
+type config struct {
+    bucket, region       string
+    accessKey, secretKey string
+    sessionToken         string
+    endpoint             string
+}
+
+func loadConfig() (config, error) {
+    cfg := config{
+        bucket:       os.Getenv("S3_BUCKET"),
+        region:       os.Getenv("AWS_REGION"),
+        accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+        secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+        sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+        endpoint:     os.Getenv("S3_ENDPOINT"),
+    }
+    if cfg.bucket == "" || cfg.region == "" || cfg.accessKey == "" || cfg.secretKey == "" {
+        return config{}, ErrNotConfigured
+    }
+    return cfg, nil
+}
+
+// client builds an S3 client for cfg. A custom endpoint is addressed
+// path-style, as S3-compatible servers expect.
+func (cfg config) client() *s3.Client {
+    return s3.New(s3.Options{
+        Region:      cfg.region,
+        Credentials: credentials.NewStaticCredentialsProvider(cfg.accessKey, cfg.secretKey, cfg.sessionToken),
+    }, func(o *s3.Options) {
+        if cfg.endpoint != "" {
+            o.BaseEndpoint = aws.String(cfg.endpoint)
+            o.UsePathStyle = true
+        }
+    })
+}
+
+// Upload is a presigned PUT. The client must send Headers with the file or
+// S3 rejects the signature.
+type Upload struct {
+    URL     string            `json:"upload_url"`
+    Key     string            `json:"s3_key"`
+    Headers map[string]string `json:"headers"`
+}
+
+// GenerateUploadURL picks a fresh object key for a vehicle document and
+// presigns a PUT for it. The content type, from ext (with the dot, e.g.
+// ".pdf"), and the size in bytes are signed, so the client can't upload
+// anything else.
+func GenerateUploadURL(ctx context.Context, vehicleID, docType, ext string, size int64) (Upload, error) {
+    cfg, err := loadConfig()
+    if err != nil {
+        return Upload{}, err
+    }
+    contentType, ok := contentTypes[ext]
+    if !ok {
+        return Upload{}, ErrUnsupportedType
+    }
+    key := fmt.Sprintf("vehicles/%s/%s/%s%s", vehicleID, docType, uuid.NewString(), ext)
+    req, err := s3.NewPresignClient(cfg.client()).PresignPutObject(ctx, &s3.PutObjectInput{
+        Bucket:        aws.String(cfg.bucket),
+        Key:           aws.String(key),
+        ContentType:   aws.String(contentType),
+        ContentLength: aws.Int64(size),
+    }, s3.WithPresignExpires(UploadURLExpiry))
+    if err != nil {
+        return Upload{}, fmt.Errorf("presign upload: %w", err)
+    }
+    headers := map[string]string{}
+    for name := range req.SignedHeader {
+        if !strings.EqualFold(name, "Host") {
+            headers[http.CanonicalHeaderKey(name)] = req.SignedHeader.Get(name)
+        }
+    }
+    return Upload{URL: req.URL, Key: key, Headers: headers}, nil
+}
+
+// GenerateDownloadURL returns a presigned GET URL for key valid for expiry
+func GenerateDownloadURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+    cfg, err := loadConfig()
+    if err != nil {
+        return "", err
+    }
+    req, err := s3.NewPresignClient(cfg.client()).PresignGetObject(ctx, &s3.GetObjectInput{
+        Bucket: aws.String(cfg.bucket),
+        Key:    aws.String(key),
+    }, s3.WithPresignExpires(expiry))
+    if err != nil {
+        return "", fmt.Errorf("presign download: %w", err)
+    }
+    return req.URL, nil
+}
+
+// Exists reports whether key has been uploaded
+func Exists(ctx context.Context, key string) (bool, error) {
+    cfg, err := loadConfig()
+    if err != nil {
+        return false, err
+    }
+    _, err = cfg.client().HeadObject(ctx, &s3.HeadObjectInput{
+        Bucket: aws.String(cfg.bucket),
+        Key:    aws.String(key),
+    })
+    var notFound *types.NotFound
+    switch {
+    case err == nil:
+        return true, nil
+    case errors.As(err, &notFound):
+        return false, nil
+    }
+    return false, fmt.Errorf("head %s: %w", key, err)
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+)
+
+// useS3 configures storage for the rest of the test; endpoint "" means AWS
+func useS3(t *testing.T, endpoint string) {
+    t.Setenv("S3_BUCKET", "smartplate-docs")
+    t.Setenv("AWS_REGION", "ap-southeast-1")
+    t.Setenv("AWS_ACCESS_KEY_ID", "AKIDTEST")
+    t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+    t.Setenv("AWS_SESSION_TOKEN", "")
+    t.Setenv("S3_ENDPOINT", endpoint)
+}
+
+func TestGenerateUploadURL(t *testing.T) {
+    tests := []struct {
+        name    string
+        ext     string
+        wantErr error
+        wantCT  string
+    }{
+        {"pdf", ".pdf", nil, "application/pdf"},
+        {"jpeg", ".jpg", nil, "image/jpeg"},
+        {"png", ".png", nil, "image/png"},
+        {"executable", ".exe", ErrUnsupportedType, ""},
+    }
+    useS3(t, "")
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            up, err := GenerateUploadURL(context.Background(), "v1", "insurance", tt.ext, 2048)
+            if err != tt.wantErr {
+                t.Fatalf("err = %v, want %v", err, tt.wantErr)
+            }
+            if err != nil {
+                return
+            }
+            if !strings.HasPrefix(up.Key, "vehicles/v1/insurance/") || !strings.HasSuffix(up.Key, tt.ext) {
+                t.Fatalf("key = %q", up.Key)
+            }
+            u, err := url.Parse(up.URL)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if u.Host != "smartplate-docs.s3.ap-southeast-1.amazonaws.com" || u.Path != "/"+up.Key {
+                t.Fatalf("url = %q", up.URL)
+            }
+            q := u.Query()
+            if got := q.Get("X-Amz-SignedHeaders"); got != "content-length;content-type;host" {
+                t.Fatalf("signed headers = %q", got)
+            }
+            if got := q.Get("X-Amz-Expires"); got != "900" {
+                t.Fatalf("expires = %q, want 900", got)
+            }
+            if up.Headers["Content-Type"] != tt.wantCT || up.Headers["Content-Length"] != "2048" || len(up.Headers) != 2 {
+                t.Fatalf("headers = %v", up.Headers)
+            }
+        })
+    }
+}
+
+func TestGenerateDownloadURL(t *testing.T) {
+    useS3(t, "")
+    got, err := GenerateDownloadURL(context.Background(), "vehicles/v1/insurance/a.pdf", DownloadURLExpiry)
+    if err != nil {
+        t.Fatal(err)
+    }
+    u, err := url.Parse(got)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if u.Path != "/vehicles/v1/insurance/a.pdf" || u.Query().Get("X-Amz-Expires") != "3600" || u.Query().Get("X-Amz-Signature") == "" {
+        t.Fatalf("url = %q", got)
+    }
+}
+
+func TestNotConfigured(t *testing.T) {
+    useS3(t, "")
+    t.Setenv("S3_BUCKET", "")
+    if _, err := GenerateUploadURL(context.Background(), "v1", "insurance", ".pdf", 1); err != ErrNotConfigured {
+        t.Fatalf("GenerateUploadURL err = %v, want ErrNotConfigured", err)
+    }
+    if _, err := Exists(context.Background(), "k"); err != ErrNotConfigured {
+        t.Fatalf("Exists err = %v, want ErrNotConfigured", err)
+    }
+}
+
+func TestExists(t *testing.T) {
+    tests := []struct {
+        name    string
+        status  int
+        want    bool
+        wantErr bool
+    }{
+        {"uploaded", http.StatusOK, true, false},
+        {"missing", http.StatusNotFound, false, false},
+        {"denied", http.StatusForbidden, false, true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var got *http.Request
+            srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                got = r
+                w.WriteHeader(tt.status)
+            }))
+            defer srv.Close()
+            useS3(t, srv.URL)
+
+            ok, err := Exists(context.Background(), "vehicles/v1/insurance/a.pdf")
+            if ok != tt.want || (err != nil) != tt.wantErr {
+                t.Fatalf("Exists = %v, %v", ok, err)
+            }
+            if got.Method != http.MethodHead || got.URL.Path != "/smartplate-docs/vehicles/v1/insurance/a.pdf" {
+                t.Fatalf("request = %s %s", got.Method, got.URL)
+            }
+            if !strings.Contains(got.Header.Get("Authorization"), "Credential=AKIDTEST/") {
+                t.Fatalf("request not signed: %q", got.Header.Get("Authorization"))
+            }
+        })
+    }
+}
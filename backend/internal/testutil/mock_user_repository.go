@@ -0,0 +1,366 @@
+// Package testutil holds in-memory stand-ins for the repositories so handler
+// tests can run without a database.
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"smartplate-api/internal/models"
+	"smartplate-api/internal/repository"
+)
+
+var _ repository.UserRepository = (*MockUserRepository)(nil)
+
+// MockUserRepository is an in-memory repository.UserRepository. It follows
+// the real repository's rules: soft-deleted users are invisible to lookups,
+// pages are ordered by user ID and a missing user is sql.ErrNoRows. Set Err
+// to make every call fail with it.
+type MockUserRepository struct {
+	mu     sync.Mutex
+	users  []*models.User
+	nextID int
+
+	Err error
+}
+
+// NewMockUserRepository returns a MockUserRepository holding copies of users.
+// Users without a USER_ID are numbered from 1.
+func NewMockUserRepository(users ...models.User) *MockUserRepository {
+	m := &MockUserRepository{}
+	for i := range users {
+		u := users[i]
+		m.add(&u)
+	}
+	return m
+}
+
+// add stores u, assigning it the next free ID when it has none
+func (m *MockUserRepository) add(u *models.User) {
+	if u.USER_ID == 0 {
+		m.nextID++
+		u.USER_ID = m.nextID
+	} else if u.USER_ID > m.nextID {
+		m.nextID = u.USER_ID
+	}
+	m.users = append(m.users, u)
+}
+
+// find returns the first live user matching match
+func (m *MockUserRepository) find(match func(*models.User) bool) (*models.User, error) {
+	for _, u := range m.users {
+		if u.DELETED_AT == nil && match(u) {
+			return u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// page returns the live users matching match, ordered by ID, and their total
+func (m *MockUserRepository) page(match func(*models.User) bool, limit, offset int) ([]models.User, int) {
+	all := []models.User{}
+	for _, u := range m.users {
+		if u.DELETED_AT == nil && match(u) {
+			all = append(all, *u)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].USER_ID < all[j].USER_ID })
+
+	// LIMIT and OFFSET as Postgres applies them; LIMIT 0 returns nothing
+	total := len(all)
+	offset = min(max(offset, 0), total)
+	end := min(offset+max(limit, 0), total)
+	return all[offset:end], total
+}
+
+// update applies change to the live user with the given LTO client ID
+func (m *MockUserRepository) update(ltoClientID string, change func(*models.User)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	u, err := m.find(func(u *models.User) bool { return u.LTO_CLIENT_ID == ltoClientID })
+	if err != nil {
+		return err
+	}
+	change(u)
+	u.UPDATED = time.Now()
+	return nil
+}
+
+func (m *MockUserRepository) Create(user *models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	u := *user
+	u.USER_ID = 0
+	u.CREATED = time.Now()
+	u.UPDATED = u.CREATED
+	m.add(&u)
+	user.USER_ID = u.USER_ID
+	return nil
+}
+
+func (m *MockUserRepository) BulkCreate(ctx context.Context, users []*models.User) (succeeded, failed int, errs []repository.BulkCreateError, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return 0, 0, nil, m.Err
+	}
+	for i, user := range users {
+		// users.email and lto_client_id are UNIQUE over every row,
+		// soft-deleted ones included
+		reason := ""
+		switch {
+		case slices.ContainsFunc(m.users, func(u *models.User) bool { return u.EMAIL == user.EMAIL }):
+			reason = repository.ReasonEmailTaken
+		case slices.ContainsFunc(m.users, func(u *models.User) bool { return u.LTO_CLIENT_ID == user.LTO_CLIENT_ID }):
+			reason = repository.ReasonLTOClientIDTaken
+		}
+		if reason != "" {
+			failed++
+			errs = append(errs, repository.BulkCreateError{Index: i, Email: user.EMAIL, Reason: reason})
+			continue
+		}
+		u := *user
+		u.USER_ID = 0
+		m.add(&u)
+		user.USER_ID = u.USER_ID
+		succeeded++
+	}
+	return succeeded, failed, errs, nil
+}
+
+func (m *MockUserRepository) GetAll(limit, offset int) ([]models.User, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return nil, 0, m.Err
+	}
+	users, total := m.page(func(*models.User) bool { return true }, limit, offset)
+	return users, total, nil
+}
+
+// get returns a copy of the first live user matching match
+func (m *MockUserRepository) get(match func(*models.User) bool) (models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return models.User{}, m.Err
+	}
+	u, err := m.find(match)
+	if err != nil {
+		return models.User{}, err
+	}
+	return *u, nil
+}
+
+func (m *MockUserRepository) GetByID(userID int) (models.User, error) {
+	return m.get(func(u *models.User) bool { return u.USER_ID == userID })
+}
+
+func (m *MockUserRepository) GetByLTOClientID(ltoClientID string) (models.User, error) {
+	return m.get(func(u *models.User) bool { return u.LTO_CLIENT_ID == ltoClientID })
+}
+
+func (m *MockUserRepository) GetByEmail(email string) (models.User, error) {
+	return m.get(func(u *models.User) bool { return u.EMAIL == email })
+}
+
+func (m *MockUserRepository) GetByGoogleID(googleID string) (models.User, error) {
+	return m.get(func(u *models.User) bool { return u.GOOGLE_ID != nil && *u.GOOGLE_ID == googleID })
+}
+
+func (m *MockUserRepository) LinkGoogleID(ltoClientID, googleID string) error {
+	return m.update(ltoClientID, func(u *models.User) { u.GOOGLE_ID = &googleID })
+}
+
+func (m *MockUserRepository) GetByRole(role string, limit, offset int) ([]models.User, int, error) {
+	return m.List(repository.UserFilter{Role: role, Limit: limit, Offset: offset})
+}
+
+func (m *MockUserRepository) GetByRegion(region string, limit, offset int) ([]models.User, int, error) {
+	return m.List(repository.UserFilter{Region: region, Limit: limit, Offset: offset})
+}
+
+// Search matches '%q%' with ILIKE semantics against the first and last
+// name, email and LTO client ID, so % and _ in q are wildcards as in the SQL
+func (m *MockUserRepository) Search(q string, limit, offset int) ([]models.User, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return nil, 0, m.Err
+	}
+	pattern := ilikePattern("%" + q + "%")
+	users, total := m.page(func(u *models.User) bool {
+		for _, field := range []string{u.FIRST_NAME, u.LAST_NAME, u.EMAIL, u.LTO_CLIENT_ID} {
+			if pattern.MatchString(field) {
+				return true
+			}
+		}
+		return false
+	}, limit, offset)
+	return users, total, nil
+}
+
+// ilikePattern compiles an ILIKE pattern: % matches any run of characters,
+// _ any one character, and a backslash escapes the next character
+func ilikePattern(like string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	escaped := false
+	for _, r := range like {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func (m *MockUserRepository) List(filter repository.UserFilter) ([]models.User, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return nil, 0, m.Err
+	}
+	users, total := m.page(func(u *models.User) bool {
+		if filter.Role != "" && u.ROLE != filter.Role {
+			return false
+		}
+		if filter.Status != "" && u.STATUS != filter.Status {
+			return false
+		}
+		if filter.Region != "" && (u.REGION == nil || *u.REGION != filter.Region) {
+			return false
+		}
+		return true
+	}, filter.Limit, filter.Offset)
+	return users, total, nil
+}
+
+func (m *MockUserRepository) UpdateRole(ltoClientID, role string) error {
+	return m.update(ltoClientID, func(u *models.User) { u.ROLE = role })
+}
+
+func (m *MockUserRepository) UpdateRegion(ltoClientID, region string) error {
+	return m.update(ltoClientID, func(u *models.User) {
+		if region == "" {
+			u.REGION = nil
+		} else {
+			u.REGION = &region
+		}
+	})
+}
+
+func (m *MockUserRepository) UpdatePasswordHash(ctx context.Context, ltoClientID, oldHash, newHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	u, err := m.find(func(u *models.User) bool { return u.LTO_CLIENT_ID == ltoClientID && u.PASSWORD == oldHash })
+	if err != nil {
+		return err
+	}
+	u.PASSWORD, u.UPDATED = newHash, time.Now()
+	return nil
+}
+
+func (m *MockUserRepository) Update(user *models.User) error {
+	return m.update(user.LTO_CLIENT_ID, func(u *models.User) {
+		id, created, deleted := u.USER_ID, u.CREATED, u.DELETED_AT
+		*u = *user
+		u.USER_ID, u.CREATED, u.DELETED_AT = id, created, deleted
+	})
+}
+
+func (m *MockUserRepository) UpdateProfile(ltoClientID string, update models.UserProfileUpdate) error {
+	return m.update(ltoClientID, func(u *models.User) {
+		if update.FirstName != nil {
+			u.FIRST_NAME = *update.FirstName
+		}
+		if update.MiddleName != nil {
+			u.MIDDLE_NAME = *update.MiddleName
+		}
+		if update.LastName != nil {
+			u.LAST_NAME = *update.LastName
+		}
+	})
+}
+
+func (m *MockUserRepository) Delete(ltoClientID string) error {
+	return m.update(ltoClientID, func(u *models.User) {
+		now := time.Now()
+		u.DELETED_AT = &now
+	})
+}
+
+func (m *MockUserRepository) Restore(ltoClientID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	for _, u := range m.users {
+		if u.LTO_CLIENT_ID == ltoClientID && u.DELETED_AT != nil {
+			u.DELETED_AT = nil
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *MockUserRepository) RecordLogin(ltoClientID string) error {
+	return m.update(ltoClientID, func(u *models.User) {
+		now := time.Now()
+		u.LAST_LOGIN_AT = &now
+	})
+}
+
+// GetInactiveUsers returns live users whose last login, or creation when
+// they never logged in, is before since. It has no scan log to consult.
+func (m *MockUserRepository) GetInactiveUsers(since time.Time) ([]models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	users := []models.User{}
+	for _, u := range m.users {
+		last := u.CREATED
+		if u.LAST_LOGIN_AT != nil {
+			last = *u.LAST_LOGIN_AT
+		}
+		if u.DELETED_AT == nil && last.Before(since) {
+			users = append(users, *u)
+		}
+	}
+	return users, nil
+}
+
+func (m *MockUserRepository) MarkInactivityEmailed(ltoClientID string) error {
+	return m.update(ltoClientID, func(u *models.User) {
+		now := time.Now()
+		u.INACTIVITY_EMAILED_AT = &now
+	})
+}
@@ -0,0 +1,205 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"smartplate-api/internal/models"
+	"smartplate-api/internal/repository"
+)
+
+func seedUsers() *MockUserRepository {
+	region := "NCR"
+	return NewMockUserRepository(
+		models.User{FIRST_NAME: "Ana", LAST_NAME: "Cruz", EMAIL: "ana@example.com", PASSWORD: "old-hash", LTO_CLIENT_ID: "LTO-1", ROLE: models.RoleUser, STATUS: "active", REGION: &region},
+		models.User{FIRST_NAME: "Ben", LAST_NAME: "Reyes", EMAIL: "ben@example.com", LTO_CLIENT_ID: "LTO-2", ROLE: models.RoleOfficer, STATUS: "active", REGION: &region},
+		models.User{FIRST_NAME: "Carla", LAST_NAME: "Santos", EMAIL: "carla@example.com", LTO_CLIENT_ID: "LTO-3", ROLE: models.RoleUser, STATUS: "inactive"},
+		models.User{FIRST_NAME: "Dan", LAST_NAME: "Cruzado", EMAIL: "dan@example.com", LTO_CLIENT_ID: "LTO-4", ROLE: models.RoleAdmin, STATUS: "active"},
+	)
+}
+
+func clientIDs(users []models.User) string {
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.LTO_CLIENT_ID
+	}
+	return fmt.Sprint(ids)
+}
+
+func TestMockUserRepositoryPaging(t *testing.T) {
+	tests := []struct {
+		name      string
+		call      func(m *MockUserRepository) ([]models.User, int, error)
+		wantIDs   string
+		wantTotal int
+	}{
+		{"all", func(m *MockUserRepository) ([]models.User, int, error) { return m.GetAll(10, 0) },
+			"[LTO-1 LTO-2 LTO-3 LTO-4]", 4},
+		{"second page", func(m *MockUserRepository) ([]models.User, int, error) { return m.GetAll(3, 3) },
+			"[LTO-4]", 4},
+		{"offset past the end", func(m *MockUserRepository) ([]models.User, int, error) { return m.GetAll(3, 9) },
+			"[]", 4},
+		{"limit 0 returns nothing", func(m *MockUserRepository) ([]models.User, int, error) { return m.GetAll(0, 0) },
+			"[]", 4},
+		{"by role", func(m *MockUserRepository) ([]models.User, int, error) { return m.GetByRole(models.RoleUser, 10, 0) },
+			"[LTO-1 LTO-3]", 2},
+		{"by region", func(m *MockUserRepository) ([]models.User, int, error) { return m.GetByRegion("NCR", 1, 1) },
+			"[LTO-2]", 2},
+		{"search is case-insensitive", func(m *MockUserRepository) ([]models.User, int, error) { return m.Search("CRUZ", 10, 0) },
+			"[LTO-1 LTO-4]", 2},
+		{"search by client id", func(m *MockUserRepository) ([]models.User, int, error) { return m.Search("lto-3", 10, 0) },
+			"[LTO-3]", 1},
+		{"search wildcards as ILIKE", func(m *MockUserRepository) ([]models.User, int, error) { return m.Search("b_n", 10, 0) },
+			"[LTO-2]", 1},
+		{"list filters combine", func(m *MockUserRepository) ([]models.User, int, error) {
+			return m.List(repository.UserFilter{Role: models.RoleUser, Status: "active", Limit: 10})
+		}, "[LTO-1]", 1},
+		{"deleted users are hidden", func(m *MockUserRepository) ([]models.User, int, error) {
+			if err := m.Delete("LTO-1"); err != nil {
+				return nil, 0, err
+			}
+			return m.GetAll(10, 0)
+		}, "[LTO-2 LTO-3 LTO-4]", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := tt.call(seedUsers())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := clientIDs(users); got != tt.wantIDs || total != tt.wantTotal {
+				t.Fatalf("got %s (total %d), want %s (total %d)", got, total, tt.wantIDs, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestMockUserRepositoryLookups(t *testing.T) {
+	m := seedUsers()
+	newUser := &models.User{EMAIL: "eve@example.com", LTO_CLIENT_ID: "LTO-5"}
+	if err := m.Create(newUser); err != nil {
+		t.Fatal(err)
+	}
+	if newUser.USER_ID != 5 {
+		t.Fatalf("Create assigned USER_ID %d, want 5", newUser.USER_ID)
+	}
+	if err := m.Delete("LTO-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		call    func() (models.User, error)
+		wantID  string
+		wantErr error
+	}{
+		{"by id", func() (models.User, error) { return m.GetByID(3) }, "LTO-3", nil},
+		{"by email", func() (models.User, error) { return m.GetByEmail("ana@example.com") }, "LTO-1", nil},
+		// email = $1 in the SQL is case-sensitive
+		{"by email in another case", func() (models.User, error) { return m.GetByEmail("ANA@example.com") }, "", sql.ErrNoRows},
+		{"created user", func() (models.User, error) { return m.GetByLTOClientID("LTO-5") }, "LTO-5", nil},
+		{"deleted user", func() (models.User, error) { return m.GetByLTOClientID("LTO-2") }, "", sql.ErrNoRows},
+		{"unknown user", func() (models.User, error) { return m.GetByID(99) }, "", sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := tt.call()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if u.LTO_CLIENT_ID != tt.wantID {
+				t.Fatalf("got %q, want %q", u.LTO_CLIENT_ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestMockUserRepositoryUpdates(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(m *MockUserRepository) error
+		check   func(u models.User) bool
+		wantErr error
+	}{
+		{"role", func(m *MockUserRepository) error { return m.UpdateRole("LTO-1", models.RoleOfficer) },
+			func(u models.User) bool { return u.ROLE == models.RoleOfficer }, nil},
+		{"clear region", func(m *MockUserRepository) error { return m.UpdateRegion("LTO-1", "") },
+			func(u models.User) bool { return u.REGION == nil }, nil},
+		{"password", func(m *MockUserRepository) error {
+			return m.UpdatePasswordHash(context.Background(), "LTO-1", "old-hash", "hash")
+		}, func(u models.User) bool { return u.PASSWORD == "hash" }, nil},
+		{"stale password", func(m *MockUserRepository) error {
+			return m.UpdatePasswordHash(context.Background(), "LTO-1", "other-hash", "hash")
+		}, nil, sql.ErrNoRows},
+		{"google id", func(m *MockUserRepository) error { return m.LinkGoogleID("LTO-1", "g-1") },
+			func(u models.User) bool { return u.GOOGLE_ID != nil && *u.GOOGLE_ID == "g-1" }, nil},
+		{"restore", func(m *MockUserRepository) error {
+			if err := m.Delete("LTO-1"); err != nil {
+				return err
+			}
+			return m.Restore("LTO-1")
+		}, func(u models.User) bool { return u.DELETED_AT == nil }, nil},
+		{"restore a live user", func(m *MockUserRepository) error { return m.Restore("LTO-1") }, nil, sql.ErrNoRows},
+		{"unknown user", func(m *MockUserRepository) error { return m.UpdateRole("LTO-9", models.RoleAdmin) }, nil, sql.ErrNoRows},
+		{"injected error", func(m *MockUserRepository) error {
+			m.Err = errors.New("boom")
+			return m.RecordLogin("LTO-1")
+		}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := seedUsers()
+			err := tt.change(m)
+			if m.Err != nil {
+				if err != m.Err {
+					t.Fatalf("error = %v, want the injected %v", err, m.Err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.check == nil {
+				return
+			}
+			u, err := m.GetByLTOClientID("LTO-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(u) {
+				t.Fatalf("change not applied: %+v", u)
+			}
+		})
+	}
+}
+
+func TestMockUserRepositoryBulkCreateTaken(t *testing.T) {
+	m := seedUsers()
+	if err := m.Delete("LTO-3"); err != nil {
+		t.Fatal(err)
+	}
+	users := []*models.User{
+		{EMAIL: "eve@example.com", LTO_CLIENT_ID: "LTO-5"},
+		// still held by the soft-deleted LTO-3, as the UNIQUE constraint sees it
+		{EMAIL: "carla@example.com", LTO_CLIENT_ID: "LTO-6"},
+		{EMAIL: "eve@example.com", LTO_CLIENT_ID: "LTO-7"},
+		{EMAIL: "fred@example.com", LTO_CLIENT_ID: "LTO-3"},
+	}
+	succeeded, failed, errs, err := m.BulkCreate(context.Background(), users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []repository.BulkCreateError{
+		{Index: 1, Email: "carla@example.com", Reason: repository.ReasonEmailTaken},
+		{Index: 2, Email: "eve@example.com", Reason: repository.ReasonEmailTaken},
+		{Index: 3, Email: "fred@example.com", Reason: repository.ReasonLTOClientIDTaken},
+	}
+	if succeeded != 1 || failed != 3 || fmt.Sprint(errs) != fmt.Sprint(want) {
+		t.Fatalf("succeeded %d, failed %d, errors %+v", succeeded, failed, errs)
+	}
+}
@@ -0,0 +1,229 @@
+// Package webhook pushes scan results to external enforcement systems.
+package webhook
+
+import (
+    "bytes"
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net"
+    "net/http"
+    "net/url"
+    "sync"
+    "syscall"
+    "time"
+
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+)
+
+// Headers sent with every delivery. The signature is "sha256=" and the hex
+// HMAC-SHA256 of the request body keyed with the webhook's secret.
+const (
+    SignatureHeader = "X-SmartPlate-Signature"
+    EventHeader     = "X-SmartPlate-Event"
+)
+
+const (
+    // maxRetries is how many times a failed delivery is retried, waiting
+    // initialBackoff and then twice as long before each further retry
+    maxRetries = 3
+
+    deliveryTimeout = 10 * time.Second
+
+    // maxInFlight caps concurrent deliveries so a slow endpoint can't pile
+    // up connections; further deliveries wait their turn
+    maxInFlight = 32
+)
+
+// initialBackoff is the wait before the first retry; tests shorten it
+var initialBackoff = time.Second
+
+// lookupIPAddr resolves webhook hosts; tests replace it
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// publicIP reports whether ip is reachable on the internet: not loopback,
+// private, link-local (which covers the 169.254.169.254 metadata service),
+// multicast or unspecified
+func publicIP(ip net.IP) bool {
+    return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+        !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// CheckURL rejects webhook URLs that aren't https or whose host resolves to
+// an internal address, so a webhook can't be aimed at our own network
+func CheckURL(ctx context.Context, raw string) error {
+    u, err := url.Parse(raw)
+    if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+        return errors.New("url must be an absolute https URL")
+    }
+    addrs, err := lookupIPAddr(ctx, u.Hostname())
+    if err != nil || len(addrs) == 0 {
+        return fmt.Errorf("url host %s doesn't resolve", u.Hostname())
+    }
+    for _, a := range addrs {
+        if !publicIP(a.IP) {
+            return fmt.Errorf("url host %s resolves to the internal address %s", u.Hostname(), a.IP)
+        }
+    }
+    return nil
+}
+
+// newClient returns the delivery client. It connects only to public
+// addresses, checked when dialling so a host can't pass CheckURL and then
+// re-resolve to an internal one, and it doesn't follow redirects.
+func newClient() *http.Client {
+    dialer := &net.Dialer{
+        Timeout: deliveryTimeout,
+        Control: func(network, address string, _ syscall.RawConn) error {
+            host, _, err := net.SplitHostPort(address)
+            if err != nil {
+                return err
+            }
+            if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+                return fmt.Errorf("refusing to deliver to internal address %s", host)
+            }
+            return nil
+        },
+    }
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    transport.Proxy = nil
+    transport.DialContext = dialer.DialContext
+    return &http.Client{
+        Timeout:   deliveryTimeout,
+        Transport: transport,
+        CheckRedirect: func(*http.Request, []*http.Request) error {
+            return http.ErrUseLastResponse
+        },
+    }
+}
+
+// retryable reports whether a delivery that got status (0 for no response)
+// is worth trying again: client errors other than 429 won't change
+func retryable(status int) bool {
+    return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+// Dispatcher delivers events to the webhooks subscribed to them.
+type Dispatcher struct {
+    ctx    context.Context
+    repo   repository.WebhookRepository
+    client *http.Client
+    sem    chan struct{}
+    wg     sync.WaitGroup // lookups and deliveries still running
+}
+
+// NewDispatcher returns a Dispatcher; deliveries still waiting to retry are
+// abandoned once ctx is cancelled
+func NewDispatcher(ctx context.Context, repo repository.WebhookRepository) *Dispatcher {
+    return &Dispatcher{
+        ctx:    ctx,
+        repo:   repo,
+        client: newClient(),
+        sem:    make(chan struct{}, maxInFlight),
+    }
+}
+
+// Dispatch POSTs body to every active webhook subscribed to any of events,
+// once per matching event. It returns at once; delivery happens in the
+// background and every attempt is written to the delivery log.
+func (d *Dispatcher) Dispatch(logger *slog.Logger, events []string, body []byte) {
+    d.wg.Add(1)
+    go func() {
+        defer d.wg.Done()
+        hooks, err := d.repo.ListActiveForEvents(d.ctx, events)
+        if err != nil {
+            logger.Error("webhook lookup error", "error", err)
+            return
+        }
+        for _, hook := range hooks {
+            for _, event := range events {
+                if hook.Subscribes(event) {
+                    d.wg.Add(1)
+                    go func() {
+                        defer d.wg.Done()
+                        d.deliver(logger, hook, event, body)
+                    }()
+                }
+            }
+        }
+    }()
+}
+
+// Wait blocks until every dispatched delivery has finished or given up.
+// Cancel the Dispatcher's ctx first so pending retries are abandoned.
+func (d *Dispatcher) Wait() {
+    d.wg.Wait()
+}
+
+// deliver POSTs body to hook, retrying with exponential backoff until it
+// gets a 2xx, an answer retrying won't change, or runs out of retries
+func (d *Dispatcher) deliver(logger *slog.Logger, hook models.WebhookConfig, event string, body []byte) {
+    signature := Sign(hook.Secret, body)
+    backoff := initialBackoff
+    for attempt := 1; attempt <= maxRetries+1; attempt++ {
+        status, err := d.post(hook.URL, event, signature, body)
+        entry := models.WebhookDelivery{WebhookID: hook.WebhookID, EventType: event, Attempt: attempt}
+        if status != 0 {
+            entry.StatusCode = &status
+        }
+        if err == nil && (status < 200 || status > 299) {
+            err = fmt.Errorf("endpoint answered %d", status)
+        }
+        if err != nil {
+            msg := err.Error()
+            entry.Error = &msg
+        }
+        if logErr := d.repo.LogDelivery(context.WithoutCancel(d.ctx), &entry); logErr != nil {
+            logger.Error("webhook delivery log error", "error", logErr, "webhook_id", hook.WebhookID)
+        }
+        if err == nil {
+            return
+        }
+        logger.Warn("webhook delivery failed", "error", err, "webhook_id", hook.WebhookID, "event", event, "attempt", attempt)
+        if attempt > maxRetries || !retryable(status) {
+            return
+        }
+        select {
+        case <-d.ctx.Done():
+            return
+        case <-time.After(backoff):
+        }
+        backoff *= 2
+    }
+}
+
+// post sends one delivery and returns the response status, or 0 when no
+// response came back
+func (d *Dispatcher) post(url, event, signature string, body []byte) (int, error) {
+    select {
+    case d.sem <- struct{}{}:
+        defer func() { <-d.sem }()
+    case <-d.ctx.Done():
+        return 0, d.ctx.Err()
+    }
+    req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, url, bytes.NewReader(body))
+    if err != nil {
+        return 0, err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set(EventHeader, event)
+    req.Header.Set(SignatureHeader, signature)
+    resp, err := d.client.Do(req)
+    if err != nil {
+        return 0, err
+    }
+    resp.Body.Close()
+    return resp.StatusCode, nil
+}
+
+// Sign returns the X-SmartPlate-Signature value for body
+func Sign(secret string, body []byte) string {
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write(body)
+    return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,219 @@
+package webhook
+
+import (
+    "context"
+    "errors"
+    "io"
+    "log/slog"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+)
+
+func TestSign(t *testing.T) {
+    // the widely published HMAC-SHA256 example
+    got := Sign("key", []byte("The quick brown fox jumps over the lazy dog"))
+    want := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+    if got != want {
+        t.Fatalf("Sign = %s, want %s", got, want)
+    }
+    if Sign("other", []byte("body")) == Sign("key", []byte("body")) {
+        t.Fatal("signature doesn't depend on the secret")
+    }
+}
+
+func TestCheckURL(t *testing.T) {
+    hosts := map[string][]string{
+        "hooks.example.com":    {"93.184.216.34"},
+        "internal.example.com": {"192.168.1.10"},
+        "mixed.example.com":    {"93.184.216.34", "10.0.0.1"},
+        "metadata.example.com": {"169.254.169.254"},
+    }
+    lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+        if ip := net.ParseIP(host); ip != nil {
+            return []net.IPAddr{{IP: ip}}, nil
+        }
+        ips, ok := hosts[host]
+        if !ok {
+            return nil, errors.New("no such host")
+        }
+        var addrs []net.IPAddr
+        for _, ip := range ips {
+            addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+        }
+        return addrs, nil
+    }
+    t.Cleanup(func() { lookupIPAddr = net.DefaultResolver.LookupIPAddr })
+
+    tests := []struct {
+        url     string
+        wantErr string
+    }{
+        {"https://hooks.example.com/smartplate", ""},
+        {"https://93.184.216.34:8443/hook", ""},
+        {"http://hooks.example.com/smartplate", "https"},
+        {"ftp://hooks.example.com/", "https"},
+        {"https:///no-host", "https"},
+        {"not a url", "https"},
+        {"https://127.0.0.1/hook", "internal address"},
+        {"https://[::1]/hook", "internal address"},
+        {"https://10.1.2.3/hook", "internal address"},
+        {"https://169.254.169.254/latest/meta-data/", "internal address"},
+        {"https://[fe80::1]/hook", "internal address"},
+        {"https://0.0.0.0/hook", "internal address"},
+        {"https://internal.example.com/hook", "internal address"},
+        {"https://metadata.example.com/hook", "internal address"},
+        {"https://mixed.example.com/hook", "internal address"},
+        {"https://nowhere.example.com/hook", "doesn't resolve"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.url, func(t *testing.T) {
+            err := CheckURL(context.Background(), tt.url)
+            if tt.wantErr == "" {
+                if err != nil {
+                    t.Fatalf("CheckURL = %v, want nil", err)
+                }
+                return
+            }
+            if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                t.Fatalf("CheckURL = %v, want an error about %q", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+// fakeRepo records the delivery log
+type fakeRepo struct {
+    repository.WebhookRepository
+    mu      sync.Mutex
+    entries []models.WebhookDelivery
+    hooks   []models.WebhookConfig // returned by ListActiveForEvents
+}
+
+func (f *fakeRepo) ListActiveForEvents(ctx context.Context, events []string) ([]models.WebhookConfig, error) {
+    return f.hooks, nil
+}
+
+func (f *fakeRepo) LogDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.entries = append(f.entries, *d)
+    return nil
+}
+
+func TestDeliverRetries(t *testing.T) {
+    initialBackoff = 10 * time.Millisecond
+    t.Cleanup(func() { initialBackoff = time.Second })
+
+    tests := []struct {
+        name         string
+        statuses     []int // answered in turn; the last one repeats
+        wantAttempts int
+    }{
+        {"ok", []int{200}, 1},
+        {"server error then ok", []int{503, 200}, 2},
+        {"server error throughout", []int{500}, maxRetries + 1},
+        {"rate limited then ok", []int{429, 429, 200}, 3},
+        {"not found", []int{404}, 1},
+        {"unauthorized", []int{401}, 1},
+        {"redirect is not followed", []int{http.StatusFound}, 1},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var (
+                mu         sync.Mutex
+                arrivals   []time.Time
+                redirected bool
+            )
+            srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                mu.Lock()
+                defer mu.Unlock()
+                if r.URL.Path == "/elsewhere" {
+                    redirected = true
+                    return
+                }
+                body, _ := io.ReadAll(r.Body)
+                if r.Header.Get(SignatureHeader) != Sign("s3cret", body) || r.Header.Get(EventHeader) != models.WebhookEventScanCompleted {
+                    t.Errorf("bad headers: %v", r.Header)
+                }
+                status := tt.statuses[min(len(arrivals), len(tt.statuses)-1)]
+                arrivals = append(arrivals, time.Now())
+                if status == http.StatusFound {
+                    http.Redirect(w, r, "/elsewhere", status)
+                    return
+                }
+                w.WriteHeader(status)
+            }))
+            defer srv.Close()
+
+            repo := &fakeRepo{}
+            client := newClient()
+            client.Transport = http.DefaultTransport // the test server is on loopback
+            d := &Dispatcher{ctx: context.Background(), repo: repo, client: client, sem: make(chan struct{}, 1)}
+            hook := models.WebhookConfig{WebhookID: "w1", URL: srv.URL + "/hook", Secret: "s3cret"}
+            d.deliver(slog.New(slog.NewTextHandler(io.Discard, nil)), hook, models.WebhookEventScanCompleted, []byte(`{"plate":"ABC 1234"}`))
+
+            if len(arrivals) != tt.wantAttempts || len(repo.entries) != tt.wantAttempts {
+                t.Fatalf("%d requests and %d log entries, want %d", len(arrivals), len(repo.entries), tt.wantAttempts)
+            }
+            for i := 1; i < len(arrivals); i++ {
+                if gap, want := arrivals[i].Sub(arrivals[i-1]), initialBackoff<<(i-1); gap < want {
+                    t.Errorf("retry %d came after %v, want at least %v", i, gap, want)
+                }
+            }
+            for i, e := range repo.entries {
+                if e.Attempt != i+1 || e.StatusCode == nil {
+                    t.Errorf("log entry %d = %+v", i, e)
+                }
+            }
+            if redirected {
+                t.Error("redirect was followed")
+            }
+        })
+    }
+}
+
+func TestDispatcherWait(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        time.Sleep(50 * time.Millisecond)
+    }))
+    defer srv.Close()
+
+    repo := &fakeRepo{hooks: []models.WebhookConfig{
+        {WebhookID: "w1", URL: srv.URL + "/a", Secret: "s1", EventTypes: []string{models.WebhookEventScanCompleted}},
+        {WebhookID: "w2", URL: srv.URL + "/b", Secret: "s2", EventTypes: []string{models.WebhookEventScanCompleted}},
+    }}
+    client := newClient()
+    client.Transport = http.DefaultTransport // the test server is on loopback
+    d := &Dispatcher{ctx: context.Background(), repo: repo, client: client, sem: make(chan struct{}, 2)}
+
+    d.Dispatch(slog.New(slog.NewTextHandler(io.Discard, nil)), []string{models.WebhookEventScanCompleted}, []byte(`{}`))
+    d.Wait()
+
+    repo.mu.Lock()
+    defer repo.mu.Unlock()
+    if len(repo.entries) != 2 {
+        t.Fatalf("Wait returned with %d of 2 deliveries logged", len(repo.entries))
+    }
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+    hit := false
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hit = true }))
+    defer srv.Close()
+
+    _, err := newClient().Post(srv.URL, "application/json", strings.NewReader("{}"))
+    if err == nil || !strings.Contains(err.Error(), "internal address") {
+        t.Fatalf("Post = %v, want a refusal", err)
+    }
+    if hit {
+        t.Fatal("request reached the loopback server")
+    }
+}
@@ -0,0 +1,19 @@
+package ws
+
+import (
+    "time"
+
+    "github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+// detailCacheTTL is how long a vehicle's DetailPack is reused between scans
+const detailCacheTTL = 30 * time.Second
+
+// newDetailCache returns an LRU of DetailPacks keyed by vehicle ID, shared by
+// every scanner connection, or nil when size is below 1 and caching is off
+func newDetailCache(size int) *expirable.LRU[string, *DetailPack] {
+    if size < 1 {
+        return nil
+    }
+    return expirable.NewLRU[string, *DetailPack](size, nil, detailCacheTTL)
+}
@@ -0,0 +1,135 @@
+package ws
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "sync"
+    "time"
+
+    "github.com/labstack/echo/v4"
+
+    mw "smartplate-api/internal/middleware"
+)
+
+// maxFeedSubscribers caps concurrent scan feed connections
+const maxFeedSubscribers = 50
+
+// feedBuffer is how many events a slow subscriber may fall behind before
+// events are dropped for it
+const feedBuffer = 64
+
+// feedKeepAlive is how often an idle feed sends a comment so proxies keep it open
+const feedKeepAlive = 30 * time.Second
+
+// ErrHubFull is returned by Subscribe when maxFeedSubscribers are connected
+var ErrHubFull = errors.New("too many scan feed subscribers")
+
+// Hub fans new scan log entries out to live feed subscribers.
+type Hub struct {
+    mu   sync.Mutex
+    subs map[chan []byte]string // the region each subscriber is limited to, or ""
+    sem  chan struct{}
+}
+
+// NewHub returns an empty Hub
+func NewHub() *Hub {
+    return &Hub{
+        subs: make(map[chan []byte]string),
+        sem:  make(chan struct{}, maxFeedSubscribers),
+    }
+}
+
+// Subscribe registers a subscriber and returns its event channel and a func
+// that unregisters it. A subscriber with a region only gets that region's
+// scans; one without gets every scan.
+func (h *Hub) Subscribe(region string) (<-chan []byte, func(), error) {
+    select {
+    case h.sem <- struct{}{}:
+    default:
+        return nil, nil, ErrHubFull
+    }
+    ch := make(chan []byte, feedBuffer)
+    h.mu.Lock()
+    h.subs[ch] = region
+    h.mu.Unlock()
+
+    var once sync.Once
+    unsubscribe := func() {
+        once.Do(func() {
+            h.mu.Lock()
+            delete(h.subs, ch)
+            h.mu.Unlock()
+            <-h.sem
+        })
+    }
+    return ch, unsubscribe, nil
+}
+
+// Broadcast sends msg, a scan filed in region, to every subscriber that may
+// see it without blocking; a subscriber whose buffer is full misses it
+func (h *Hub) Broadcast(region string, msg []byte) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    for ch, only := range h.subs {
+        if only != "" && only != region {
+            continue
+        }
+        select {
+        case ch <- msg:
+        default:
+        }
+    }
+}
+
+// ScanLogStream serves the live scan feed as Server-Sent Events, one
+// "data: <scan log JSON>" event per scan. Officers only see scans of plates
+// registered in their region. It ends when the client goes away or ctx is
+// cancelled at shutdown.
+// @Summary Live scan feed
+// @Description Server-Sent Events stream of scan log entries as scanners record them. LTO officers only receive their region's scans. At most 50 clients at once.
+// @Tags admin
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Success 200 {string} string "event stream"
+// @Failure 503 {object} map[string]string
+// @Router /api/admin/scan-logs/stream [get]
+func ScanLogStream(ctx context.Context, hub *Hub) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        events, unsubscribe, err := hub.Subscribe(mw.ScopedRegion(c))
+        if err != nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+        }
+        defer unsubscribe()
+        logger := mw.LoggerFrom(c)
+
+        res := c.Response()
+        res.Header().Set(echo.HeaderContentType, "text/event-stream")
+        res.Header().Set(echo.HeaderCacheControl, "no-cache")
+        res.Header().Set(echo.HeaderConnection, "keep-alive")
+        res.WriteHeader(http.StatusOK)
+        res.Flush()
+
+        keepAlive := time.NewTicker(feedKeepAlive)
+        defer keepAlive.Stop()
+        for {
+            select {
+            case <-c.Request().Context().Done():
+                logger.Debug("scan feed client disconnected")
+                return nil
+            case <-ctx.Done():
+                return nil
+            case <-keepAlive.C:
+                if _, err := res.Write([]byte(": keep-alive\n\n")); err != nil {
+                    return nil
+                }
+                res.Flush()
+            case msg := <-events:
+                if _, err := res.Write(append(append([]byte("data: "), msg...), '\n', '\n')); err != nil {
+                    return nil
+                }
+                res.Flush()
+            }
+        }
+    }
+}
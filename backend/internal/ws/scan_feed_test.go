@@ -0,0 +1,173 @@
+package ws
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/models"
+)
+
+// subscribers returns how many feed clients the hub holds
+func (h *Hub) subscribers() int {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    return len(h.subs)
+}
+
+// waitFor polls cond for up to a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+    t.Helper()
+    deadline := time.Now().Add(time.Second)
+    for !cond() {
+        if time.Now().After(deadline) {
+            t.Fatalf("timed out waiting for %s", what)
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+}
+
+func newFeedServer(t *testing.T, hub *Hub) *httptest.Server {
+    t.Helper()
+    ctx, cancel := context.WithCancel(context.Background())
+    e := echo.New()
+    e.GET("/stream", ScanLogStream(ctx, hub))
+    srv := httptest.NewServer(e)
+    t.Cleanup(func() {
+        cancel()
+        srv.Close()
+    })
+    return srv
+}
+
+func TestScanLogStream(t *testing.T) {
+    hub := NewHub()
+    srv := newFeedServer(t, hub)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/stream", nil)
+    res, err := http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer res.Body.Close()
+    if ct := res.Header.Get(echo.HeaderContentType); ct != "text/event-stream" {
+        t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+    }
+    waitFor(t, "the subscription", func() bool { return hub.subscribers() == 1 })
+
+    scannedAt := time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)
+    for i := 0; i < 3; i++ {
+        event, _ := json.Marshal(models.ScanLog{
+            LogID:       fmt.Sprintf("log-%d", i),
+            PlateID:     "p1",
+            LTOClientID: "LTO-1",
+            ScannedAt:   scannedAt.Add(time.Duration(i) * time.Minute),
+        })
+        hub.Broadcast("NCR", event)
+    }
+
+    lines := bufio.NewScanner(res.Body)
+    tests := []struct {
+        logID     string
+        scannedAt time.Time
+    }{
+        {"log-0", scannedAt},
+        {"log-1", scannedAt.Add(time.Minute)},
+        {"log-2", scannedAt.Add(2 * time.Minute)},
+    }
+    for _, tt := range tests {
+        var data string
+        for lines.Scan() {
+            if line := lines.Text(); strings.HasPrefix(line, "data: ") {
+                data = strings.TrimPrefix(line, "data: ")
+                break
+            }
+        }
+        if data == "" {
+            t.Fatalf("stream ended before %s: %v", tt.logID, lines.Err())
+        }
+        var got models.ScanLog
+        if err := json.Unmarshal([]byte(data), &got); err != nil {
+            t.Fatalf("event %q is not a scan log: %v", data, err)
+        }
+        if got.LogID != tt.logID || got.PlateID != "p1" || got.LTOClientID != "LTO-1" || !got.ScannedAt.Equal(tt.scannedAt) {
+            t.Fatalf("got %+v, want %s at %v", got, tt.logID, tt.scannedAt)
+        }
+    }
+
+    cancel()
+    waitFor(t, "the subscriber to be dropped", func() bool { return hub.subscribers() == 0 })
+}
+
+func TestScanLogStreamLimit(t *testing.T) {
+    hub := NewHub()
+    var unsubscribes []func()
+    for i := 0; i < maxFeedSubscribers; i++ {
+        _, unsubscribe, err := hub.Subscribe("")
+        if err != nil {
+            t.Fatalf("subscriber %d: %v", i+1, err)
+        }
+        unsubscribes = append(unsubscribes, unsubscribe)
+    }
+
+    srv := newFeedServer(t, hub)
+    res, err := http.Get(srv.URL + "/stream")
+    if err != nil {
+        t.Fatal(err)
+    }
+    res.Body.Close()
+    if res.StatusCode != http.StatusServiceUnavailable {
+        t.Fatalf("status with %d subscribers = %d, want 503", maxFeedSubscribers, res.StatusCode)
+    }
+
+    unsubscribes[0]()
+    unsubscribes[0]() // a second call must not free another slot
+    if _, _, err := hub.Subscribe(""); err != nil {
+        t.Fatalf("subscribe after one left: %v", err)
+    }
+    if _, _, err := hub.Subscribe(""); err != ErrHubFull {
+        t.Fatalf("err = %v, want ErrHubFull", err)
+    }
+}
+
+func TestHubFiltersByRegion(t *testing.T) {
+    hub := NewHub()
+    all, _, _ := hub.Subscribe("")
+    ncr, _, _ := hub.Subscribe("NCR")
+    calabarzon, _, _ := hub.Subscribe("Region IV-A")
+
+    hub.Broadcast("NCR", []byte("ncr scan"))
+    hub.Broadcast("Region IV-A", []byte("calabarzon scan"))
+    hub.Broadcast("", []byte("scan without a region"))
+
+    tests := []struct {
+        name   string
+        events <-chan []byte
+        want   []string
+    }{
+        {"unscoped", all, []string{"ncr scan", "calabarzon scan", "scan without a region"}},
+        {"NCR officer", ncr, []string{"ncr scan"}},
+        {"Region IV-A officer", calabarzon, []string{"calabarzon scan"}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var got []string
+            for len(tt.events) > 0 {
+                got = append(got, string(<-tt.events))
+            }
+            if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+                t.Fatalf("got %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,34 @@
+package ws
+
+import (
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/webhook"
+)
+
+// webhooks pushes logged scans to external systems; nil until main sets it
+var webhooks *webhook.Dispatcher
+
+// SetWebhookDispatcher makes every logged scan go out to subscribed webhooks
+func SetWebhookDispatcher(d *webhook.Dispatcher) {
+    webhooks = d
+}
+
+// scanEvents are the webhook events a logged scan with the given status raises
+func scanEvents(status string) []string {
+    events := []string{models.WebhookEventScanCompleted}
+    if status == "expired" {
+        events = append(events, models.WebhookEventScanExpired)
+    }
+    return events
+}
+
+// webhookPayload is resp as sent to webhooks: the vehicle owner's details
+// stay out of third-party systems
+func webhookPayload(resp PlateCheckResponse) PlateCheckResponse {
+    if resp.Details != nil {
+        details := *resp.Details
+        details.User = nil
+        resp.Details = &details
+    }
+    return resp
+}
@@ -0,0 +1,36 @@
+package ws
+
+import (
+    "encoding/json"
+    "strings"
+    "testing"
+
+    "smartplate-api/internal/models"
+)
+
+func TestWebhookPayloadDropsOwner(t *testing.T) {
+    resp := PlateCheckResponse{
+        Plate:  "ABC 1234",
+        Status: "valid",
+        Details: &DetailPack{
+            RegistrationForm: &models.RegistrationForm{RegistrationFormID: "f1"},
+            User:             &models.MaskedUser{FIRST_NAME: "Juan", EMAIL: "j***@example.com"},
+        },
+    }
+
+    body, err := json.Marshal(webhookPayload(resp))
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, leak := range []string{"user_record", "Juan", "example.com"} {
+        if strings.Contains(string(body), leak) {
+            t.Errorf("payload contains %q: %s", leak, body)
+        }
+    }
+    if !strings.Contains(string(body), `"registration_form_id":"f1"`) {
+        t.Errorf("payload lost the registration: %s", body)
+    }
+    if resp.Details.User == nil {
+        t.Error("webhookPayload cleared the scanner's copy of the owner")
+    }
+}
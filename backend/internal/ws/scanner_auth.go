@@ -0,0 +1,59 @@
+package ws
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "strings"
+
+    mw "smartplate-api/internal/middleware"
+    "smartplate-api/internal/repository"
+)
+
+// bearerSubprotocol lets browsers, which can't set headers on a WebSocket
+// handshake, send their token as "Sec-WebSocket-Protocol: bearer, <token>"
+const bearerSubprotocol = "bearer"
+
+// errNoToken means the upgrade request carried no bearer token
+var errNoToken = errors.New("missing bearer token")
+
+// socketToken returns the bearer token from the Authorization header or,
+// failing that, from the subprotocol list. viaSubprotocol tells the caller
+// to answer with the bearer subprotocol so the browser accepts the socket.
+func socketToken(r *http.Request) (token string, viaSubprotocol bool) {
+    if t, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && t != "" {
+        return t, false
+    }
+    protocols := strings.Split(r.Header.Get("Sec-WebSocket-Protocol"), ",")
+    for i := 0; i+1 < len(protocols); i++ {
+        if strings.TrimSpace(protocols[i]) == bearerSubprotocol {
+            if t := strings.TrimSpace(protocols[i+1]); t != "" {
+                return t, true
+            }
+        }
+    }
+    return "", false
+}
+
+// authenticateSocket verifies the upgrade request's bearer token and that
+// its session hasn't been revoked. It returns errNoToken when there is none.
+func authenticateSocket(ctx context.Context, r *http.Request, secret []byte, sessions repository.SessionRepository) (*mw.Claims, bool, error) {
+    token, viaSubprotocol := socketToken(r)
+    if token == "" {
+        return nil, false, errNoToken
+    }
+    claims, err := mw.ParseToken(token, secret)
+    if err != nil {
+        return nil, false, err
+    }
+    if claims.ID != "" && sessions != nil {
+        revoked, err := sessions.IsRevoked(ctx, claims.ID)
+        if err != nil {
+            return nil, false, err
+        }
+        if revoked {
+            return nil, false, errors.New("session has been revoked")
+        }
+    }
+    return claims, viaSubprotocol, nil
+}
@@ -0,0 +1,276 @@
+package ws
+
+import (
+    "context"
+    "fmt"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/gorilla/websocket"
+    "github.com/labstack/echo/v4"
+
+    "smartplate-api/internal/models"
+    "smartplate-api/internal/repository"
+    "smartplate-api/internal/testutil"
+)
+
+// scannerPlates serves one registered vehicle with several plates and
+// records the numbers it was asked for
+type scannerPlates struct {
+    repository.PlateRepository
+    plates []models.Plate
+    mu     sync.Mutex
+    asked  []string
+}
+
+func (f *scannerPlates) GetByPlateNumber(ctx context.Context, number string) (*models.Plate, error) {
+    f.mu.Lock()
+    f.asked = append(f.asked, number)
+    f.mu.Unlock()
+    for _, p := range f.plates {
+        if strings.EqualFold(p.PLATE_NUMBER, number) {
+            return &p, nil
+        }
+    }
+    return nil, nil
+}
+
+func (f *scannerPlates) GetPlatesByVehicleID(ctx context.Context, vehicleID string) ([]models.Plate, error) {
+    return f.plates, nil
+}
+
+// scannerForms counts lookups, so tests can tell whether details were fetched
+type scannerForms struct {
+    repository.RegistrationFormRepository
+    lookups atomic.Int32
+}
+
+func (f *scannerForms) GetByVehicleID(ctx context.Context, vehicleID string) (*models.RegistrationForm, error) {
+    f.lookups.Add(1)
+    return &models.RegistrationForm{
+        RegistrationFormID: "rf-" + vehicleID,
+        LTOClientID:        "LTO-2024-000123",
+        VehicleID:          vehicleID,
+        SubmittedDate:      time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC),
+        Status:             "Approved",
+        Region:             "NCR",
+        RegistrationType:   "New",
+    }, nil
+}
+
+type scannerInspections struct {
+    repository.VehicleInspectionRepository
+}
+
+func (scannerInspections) GetLatest(ctx context.Context, vehicleID string) (*models.VehicleInspection, error) {
+    return &models.VehicleInspection{
+        InspectionID:      "insp-1",
+        VehicleID:         vehicleID,
+        InspectorLTOID:    "LTO-OFFICER-7",
+        InspectedAt:       time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC),
+        Result:            "Pass",
+        Remarks:           "Brakes, lights and emissions within limits",
+        NextInspectionDue: time.Now().AddDate(1, 0, 0),
+    }, nil
+}
+
+// scannerScanLogs records the entries ScannerWS logs
+type scannerScanLogs struct {
+    repository.ScanLogRepository
+    mu      sync.Mutex
+    entries []models.ScanLog
+}
+
+func (f *scannerScanLogs) Create(ctx context.Context, entry *models.ScanLog) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.entries = append(f.entries, *entry)
+    return nil
+}
+
+func (f *scannerScanLogs) logged() []models.ScanLog {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return append([]models.ScanLog(nil), f.entries...)
+}
+
+// scannerFixture is a ScannerWS endpoint backed by in-memory repositories
+type scannerFixture struct {
+    url      string
+    plates   *scannerPlates
+    forms    *scannerForms
+    scanLogs *scannerScanLogs
+}
+
+// newScannerFixture serves ScannerWS for vehicle v1, whose plates are
+// "ABC 1234" (valid) and "OLD 1111" (expired)
+func newScannerFixture(t *testing.T) *scannerFixture {
+    t.Helper()
+    issued := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+    plates := &scannerPlates{}
+    for i := 0; i < 6; i++ {
+        number := fmt.Sprintf("ABC %d", 1234+i)
+        expires := time.Now().AddDate(2, 0, 0)
+        if i == 5 {
+            number, expires = "OLD 1111", issued.AddDate(1, 0, 0)
+        }
+        plates.plates = append(plates.plates, models.Plate{
+            PlateID: fmt.Sprintf("p%d", i+1), VEHICLE_ID: "v1", PLATE_NUMBER: number, PLATE_TYPE: "Private",
+            PLATE_ISSUE_DATE: issued, PLATE_EXPIRATION_DATE: expires, STATUS: models.PlateActive,
+        })
+    }
+    forms := &scannerForms{}
+    scanLogs := &scannerScanLogs{}
+    users := testutil.NewMockUserRepository(models.User{
+        LTO_CLIENT_ID: "LTO-2024-000123", FIRST_NAME: "Juan", LAST_NAME: "Dela Cruz",
+        EMAIL: "juan.delacruz@example.com", ROLE: models.RoleUser, STATUS: "active",
+    })
+
+    ctx, cancel := context.WithCancel(context.Background())
+    var wg sync.WaitGroup
+    e := echo.New()
+    e.IPExtractor = echo.ExtractIPDirect()
+    e.GET("/ws", ScannerWS(ctx, &wg, plates, nil, forms, users, scanLogs, scannerInspections{}, nil, nil, nil, nil))
+    srv := httptest.NewServer(e)
+    t.Cleanup(func() {
+        cancel()
+        srv.Close()
+    })
+    return &scannerFixture{url: "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws", plates: plates, forms: forms, scanLogs: scanLogs}
+}
+
+// countingConn counts the bytes read off the wire
+type countingConn struct {
+    net.Conn
+    read *atomic.Int64
+}
+
+func (c countingConn) Read(b []byte) (int, error) {
+    n, err := c.Conn.Read(b)
+    c.read.Add(int64(n))
+    return n, err
+}
+
+// dial connects to the fixture; read counts the bytes received after the
+// handshake
+func (f *scannerFixture) dial(t *testing.T, compress bool) (conn *websocket.Conn, read *atomic.Int64) {
+    t.Helper()
+    read = &atomic.Int64{}
+    d := websocket.Dialer{
+        EnableCompression: compress,
+        NetDial: func(network, addr string) (net.Conn, error) {
+            c, err := net.Dial(network, addr)
+            return countingConn{Conn: c, read: read}, err
+        },
+    }
+    conn, _, err := d.Dial(f.url, nil)
+    if err != nil {
+        t.Fatalf("dial: %v", err)
+    }
+    t.Cleanup(func() { conn.Close() })
+    read.Store(0)
+    return conn, read
+}
+
+// check sends a plate check and returns the response
+func check(t *testing.T, conn *websocket.Conn, number string) PlateCheckResponse {
+    t.Helper()
+    if err := conn.WriteJSON(PlateCheckRequest{Plate: number}); err != nil {
+        t.Fatalf("write: %v", err)
+    }
+    conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+    var resp PlateCheckResponse
+    if err := conn.ReadJSON(&resp); err != nil {
+        t.Fatalf("read: %v", err)
+    }
+    return resp
+}
+
+func TestScannerWSCompression(t *testing.T) {
+    f := newScannerFixture(t)
+
+    wireSize := func(compress bool) int64 {
+        conn, read := f.dial(t, compress)
+        resp := check(t, conn, "ABC 1234")
+        if resp.Status != "valid" || resp.Details == nil || len(resp.Details.Plates) != 6 || resp.Details.User == nil {
+            t.Fatalf("want a valid plate with full details, got %+v", resp)
+        }
+        return read.Load()
+    }
+
+    plain := wireSize(false)
+    compressed := wireSize(true)
+    if compressed > plain*7/10 {
+        t.Fatalf("compressed response is %d bytes against %d uncompressed, want at least 30%% smaller", compressed, plain)
+    }
+}
+
+func TestScannerWSDetailCache(t *testing.T) {
+    tests := []struct {
+        name        string
+        plates      []string
+        wantStatus  string
+        wantLookups int32
+    }{
+        {"repeat scan is cached", []string{"ABC 1234", "ABC 1234", "abc 1234"}, "valid", 1},
+        {"other plates of the vehicle share the entry", []string{"ABC 1234", "ABC 1235"}, "valid", 1},
+        {"expired plates are never cached", []string{"OLD 1111", "OLD 1111"}, "expired", 2},
+        {"unknown plates fetch nothing", []string{"ZZZ 9999", "ZZZ 9999"}, "not_found", 0},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f := newScannerFixture(t)
+            conn, _ := f.dial(t, false)
+            for _, number := range tt.plates {
+                if resp := check(t, conn, number); resp.Status != tt.wantStatus {
+                    t.Fatalf("%s: status = %q, want %q", number, resp.Status, tt.wantStatus)
+                }
+            }
+            if got := f.forms.lookups.Load(); got != tt.wantLookups {
+                t.Fatalf("repository called %d times, want %d", got, tt.wantLookups)
+            }
+        })
+    }
+}
+
+func TestScannerWSDetailCacheDisabled(t *testing.T) {
+    cfg := wsConfig
+    SetConfig(WSConfig{RateLimit: 10, RateBurst: 20, MaxRateLimited: 500})
+    t.Cleanup(func() { SetConfig(cfg) })
+
+    f := newScannerFixture(t)
+    conn, _ := f.dial(t, false)
+    check(t, conn, "ABC 1234")
+    check(t, conn, "ABC 1234")
+    if got := f.forms.lookups.Load(); got != 2 {
+        t.Fatalf("repository called %d times with the cache off, want 2", got)
+    }
+}
+
+func TestScannerWSLogsPeerAddress(t *testing.T) {
+    f := newScannerFixture(t)
+    header := http.Header{"X-Forwarded-For": {"198.51.100.4"}, "X-Real-Ip": {"198.51.100.4"}}
+    conn, _, err := websocket.DefaultDialer.Dial(f.url, header)
+    if err != nil {
+        t.Fatalf("dial: %v", err)
+    }
+    defer conn.Close()
+
+    if resp := check(t, conn, "ABC 1234"); resp.Status != "valid" {
+        t.Fatalf("status = %q, want valid", resp.Status)
+    }
+    logged := f.scanLogs.logged()
+    if len(logged) != 1 {
+        t.Fatalf("logged %d scans, want 1", len(logged))
+    }
+    if ip := logged[0].ScannerIP; ip == nil || *ip != "127.0.0.1" {
+        t.Fatalf("scanner_ip = %v, want the peer address 127.0.0.1", ip)
+    }
+}
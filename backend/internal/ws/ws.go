@@ -1,15 +1,29 @@
 package ws
 
 import (
+    "bytes"
+    "compress/flate"
+    "context"
+    "errors"
+    "net"
     "net/http"
     "encoding/json"
-    "log"
+    "log/slog"
+    "os"
+    "strconv"
+    "strings"
+    "sync"
     "time"
 
     "github.com/gorilla/websocket"
     "github.com/labstack/echo/v4"
+    "golang.org/x/time/rate"
 
+    "smartplate-api/internal/insurance"
+    "smartplate-api/internal/metrics"
+    mw "smartplate-api/internal/middleware"
     "smartplate-api/internal/models"
+    "smartplate-api/internal/plate"
     "smartplate-api/internal/repository"
 )
 
@@ -17,74 +31,362 @@ import (
 var Upgrader = websocket.Upgrader{
     ReadBufferSize:  1024,
     WriteBufferSize: 1024,
-    CheckOrigin:     func(r *http.Request) bool { return true },
+    // negotiate permessage-deflate; DetailPack responses compress well
+    EnableCompression: true,
+    CheckOrigin: func(r *http.Request) bool {
+        origin := r.Header.Get("Origin")
+        return origin == "" || (wsConfig.AllowOrigin != nil && wsConfig.AllowOrigin(origin))
+    },
 }
 
-// scanLogRepo holds the scan-log repository; set in main
-var scanLogRepo repository.ScanLogRepository
+// maxReplayEntries caps how many past scans are replayed on reconnect
+const maxReplayEntries = 100
 
-// SetScanLogRepository must be called in main to initialize logging
-func SetScanLogRepository(repo repository.ScanLogRepository) {
-    scanLogRepo = repo
+// insuranceTimeout bounds the insurance lookup so a slow insurer can't hold up a scan
+const insuranceTimeout = 500 * time.Millisecond
+
+// shutdownAckTimeout is how long a client gets to acknowledge a server-restart close
+const shutdownAckTimeout = 5 * time.Second
+
+// WSConfig holds the tunables for scanner connections
+type WSConfig struct {
+    RateLimit        float64 // allowed messages per second per connection
+    RateBurst        int     // token bucket size
+    MaxRateLimited   int     // rate-limited messages tolerated before closing
+    CompressionLevel int     // permessage-deflate level, 0 (off) to 9
+    DetailCacheSize  int     // vehicles whose DetailPack is cached, 0 to disable
+
+    // AllowOrigin decides which browser origins may connect; when nil only
+    // clients that send no Origin header are accepted
+    AllowOrigin func(origin string) bool
+}
+
+// DefaultWSConfig returns the defaults used when nothing is configured
+func DefaultWSConfig() WSConfig {
+    return WSConfig{
+        RateLimit:        10,
+        RateBurst:        20,
+        MaxRateLimited:   500,
+        CompressionLevel: 6,
+        DetailCacheSize:  256,
+    }
+}
+
+// ConfigFromEnv reads WS_RATE_LIMIT, WS_RATE_BURST, WS_COMPRESSION_LEVEL and
+// WS_DETAIL_CACHE_SIZE, falling back to defaults
+func ConfigFromEnv() WSConfig {
+    cfg := DefaultWSConfig()
+    if v, err := strconv.ParseFloat(os.Getenv("WS_RATE_LIMIT"), 64); err == nil && v > 0 {
+        cfg.RateLimit = v
+    }
+    if v, err := strconv.Atoi(os.Getenv("WS_RATE_BURST")); err == nil && v > 0 {
+        cfg.RateBurst = v
+    }
+    if v, err := strconv.Atoi(os.Getenv("WS_COMPRESSION_LEVEL")); err == nil && v >= 0 && v <= 9 {
+        cfg.CompressionLevel = v
+    }
+    if v, err := strconv.Atoi(os.Getenv("WS_DETAIL_CACHE_SIZE")); err == nil && v >= 0 {
+        cfg.DetailCacheSize = v
+    }
+    return cfg
+}
+
+// compressionStats tracks what permessage-deflate saves on one connection.
+// gorilla doesn't expose the compressed frame size, so it is estimated by
+// deflating the payload at the same level.
+type compressionStats struct {
+    messages   int
+    raw        int
+    compressed int
+}
+
+func (s *compressionStats) record(payload []byte, level int) {
+    var buf bytes.Buffer
+    fw, err := flate.NewWriter(&buf, level)
+    if err != nil {
+        return
+    }
+    fw.Write(payload)
+    fw.Close()
+    s.messages++
+    s.raw += len(payload)
+    s.compressed += buf.Len()
+}
+
+// wsConfig is the active configuration; set in main
+var wsConfig = DefaultWSConfig()
+
+// SetConfig overrides the scanner connection configuration
+func SetConfig(cfg WSConfig) {
+    wsConfig = cfg
 }
 
 // PlateCheckRequest is the incoming WS payload
 type PlateCheckRequest struct {
-    Plate     string `json:"plate"`
-    Timestamp string `json:"timestamp"`
+    Plate     string  `json:"plate"`
+    Timestamp string  `json:"timestamp"`
+    QR        string  `json:"qr,omitempty"` // raw QR content, when scanned from a printed code
+    Latitude  float64 `json:"latitude,omitempty"`
+    Longitude float64 `json:"longitude,omitempty"`
+    DeviceID  string  `json:"device_id,omitempty"`
+}
+
+// location returns the request's coordinates, or nils when the scanner sent
+// none (0,0) or they are out of range
+func (r PlateCheckRequest) location() (*float64, *float64) {
+    if r.Latitude == 0 && r.Longitude == 0 {
+        return nil, nil
+    }
+    if r.Latitude < -90 || r.Latitude > 90 || r.Longitude < -180 || r.Longitude > 180 {
+        return nil, nil
+    }
+    lat, lon := r.Latitude, r.Longitude
+    return &lat, &lon
+}
+
+// UnmarshalJSON trims the plate number, since scanners often pad it with
+// whitespace
+func (r *PlateCheckRequest) UnmarshalJSON(b []byte) error {
+    type raw PlateCheckRequest
+    if err := json.Unmarshal(b, (*raw)(r)); err != nil {
+        return err
+    }
+    r.Plate = normalizePlate(r.Plate)
+    return nil
+}
+
+// normalizePlate strips surrounding whitespace; case is ignored by the lookup
+func normalizePlate(p string) string {
+    return strings.TrimSpace(p)
 }
 
 // PlateCheckResponse is the outgoing WS response
 type PlateCheckResponse struct {
     Plate   string      `json:"plate"`
-    Status  string      `json:"status"` // valid, not_found, expired, error
+    Status  string      `json:"status"` // valid, not_found, expired, error, replay, invalid_signature, ambiguous
     Details *DetailPack `json:"details,omitempty"`
+    // Candidates lists the vehicles a loose MV file number matched when Status is ambiguous
+    Candidates []MVFileCandidate `json:"candidates,omitempty"`
+    // ChecksumMismatch flags a plate whose check digit is wrong; the lookup still runs
+    ChecksumMismatch bool `json:"checksum_mismatch,omitempty"`
+}
+
+// MVFileCandidate is one vehicle an ambiguous MV file number search matched.
+// It describes the vehicle only; rescanning with the full MV file number
+// gets its details.
+type MVFileCandidate struct {
+    MVFileNumber string `json:"mv_file_number"`
+    Make         string `json:"vehicle_make"`
+    Series       string `json:"vehicle_series"`
+    YearModel    string `json:"year_model"`
 }
 
 // DetailPack holds optional details for a valid plate
 type DetailPack struct {
     RegistrationForm *models.RegistrationForm `json:"registration_form,omitempty"`
     Plates           []models.Plate           `json:"plates,omitempty"`
-    User             *models.User             `json:"user_record,omitempty"`
+    User             *models.MaskedUser       `json:"user_record,omitempty"`
+    LatestInspection *models.VehicleInspection `json:"latest_inspection,omitempty"`
+    // InspectionOverdue is set when the latest inspection's next due date has
+    // passed; the plate still checks as valid
+    InspectionOverdue bool `json:"inspection_overdue"`
+    // InsuranceStatus is nil when no insurance lookup is configured
+    InsuranceStatus *models.InsuranceStatus `json:"insurance_status,omitempty"`
 }
 
-// ScannerWS serves the WS endpoint; signature unchanged.
+// ScannerWS serves the WS endpoint. Live checks need no token, but an optional
+// ?since=<RFC3339> replays scans since then and requires a bearer token
+// (Authorization header or "bearer, <token>" subprotocol); only the scans of
+// the token's subject are replayed.
+// Each connection is tracked in wg; when ctx is cancelled the client is sent a
+// CloseServiceRestart frame and given a few seconds to acknowledge it.
 func ScannerWS(
+    ctx         context.Context,
+    wg          *sync.WaitGroup,
     plateRepo   repository.PlateRepository,
+    vehicleRepo repository.VehicleRepository,
     regFormRepo repository.RegistrationFormRepository,
-    userRepo    *repository.UserRepository,
+    userRepo    repository.UserRepository,
+    scanLogRepo repository.ScanLogRepository,
+    inspectionRepo repository.VehicleInspectionRepository,
+    insuranceLookup insurance.InsuranceLookup,
+    hub         *Hub,
+    sessions    repository.SessionRepository,
+    secret      []byte,
 ) echo.HandlerFunc {
+    // a convoy scans the same vehicles repeatedly; share their details briefly
+    cache := newDetailCache(wsConfig.DetailCacheSize)
     return func(c echo.Context) error {
-        ws, err := Upgrader.Upgrade(c.Response().Writer, c.Request(), nil)
+        var since time.Time
+        if raw := c.QueryParam("since"); raw != "" {
+            t, err := time.Parse(time.RFC3339, raw)
+            if err != nil {
+                return c.JSON(http.StatusBadRequest, map[string]string{"error": "since must be RFC3339"})
+            }
+            since = t
+        }
+        logger := mw.LoggerFrom(c)
+        // a token is optional for live checks but required for replay
+        claims, viaSubprotocol, err := authenticateSocket(c.Request().Context(), c.Request(), secret, sessions)
+        switch {
+        case errors.Is(err, errNoToken):
+            if !since.IsZero() {
+                return c.JSON(http.StatusUnauthorized, map[string]string{"error": "replaying scans requires a bearer token"})
+            }
+        case err != nil:
+            logger.Warn("ws token rejected", "error", err)
+            return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+        }
+        // partial MV file matching would let anyone enumerate vehicles, so it
+        // is reserved for officers and admins
+        looseMVFile := claims != nil && claims.Role != models.RoleUser
+        var respHeader http.Header
+        if viaSubprotocol {
+            respHeader = http.Header{"Sec-Websocket-Protocol": {bearerSubprotocol}}
+        }
+        // the upgrade request's address as e.IPExtractor sees it, so a
+        // scanner can't log a made-up X-Forwarded-For; scan_log.scanner_ip
+        // is INET, so anything unparseable is left out rather than failing
+        // the insert
+        var scannerIP *string
+        if ip := c.RealIP(); net.ParseIP(ip) != nil {
+            scannerIP = &ip
+        }
+
+        // join wg before upgrading so the shutdown drain can't miss this
+        // connection; once shutdown has begun, new scanners are turned away
+        if ctx.Err() != nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "server is shutting down"})
+        }
+        wg.Add(1)
+        defer wg.Done()
+
+        ws, err := Upgrader.Upgrade(c.Response().Writer, c.Request(), respHeader)
         if err != nil {
             return err
         }
         defer ws.Close()
+        if level := wsConfig.CompressionLevel; level > 0 {
+            ws.EnableWriteCompression(true)
+            if err := ws.SetCompressionLevel(level); err != nil {
+                logger.Warn("ws compression level rejected", "level", level, "error", err)
+            }
+        } else {
+            ws.EnableWriteCompression(false)
+        }
+        // sizes are only measured when someone will read the debug log
+        var sizes *compressionStats
+        if wsConfig.CompressionLevel > 0 && logger.Enabled(c.Request().Context(), slog.LevelDebug) {
+            sizes = &compressionStats{}
+            defer func() {
+                if sizes.messages > 0 {
+                    logger.Debug("ws message sizes",
+                        "messages", sizes.messages,
+                        "avg_raw_bytes", sizes.raw/sizes.messages,
+                        "avg_compressed_bytes", sizes.compressed/sizes.messages)
+                }
+            }()
+        }
+
+        metrics.WebsocketConnectionsActive.Inc()
+        defer metrics.WebsocketConnectionsActive.Dec()
+
+        done := make(chan struct{})
+        defer close(done)
+        go func() {
+            select {
+            case <-ctx.Done():
+                logger.Info("server shutting down; closing ws connection", "remote_ip", c.RealIP())
+                ws.WriteControl(
+                    websocket.CloseMessage,
+                    websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server restarting"),
+                    time.Now().Add(time.Second),
+                )
+                // the read loop exits once the client echoes the close frame or this expires
+                ws.SetReadDeadline(time.Now().Add(shutdownAckTimeout))
+            case <-done:
+            }
+        }()
+
+        if !since.IsZero() && claims != nil && scanLogRepo != nil {
+            if err := replayScans(c, logger, ws, scanLogRepo, claims.Subject, since); err != nil {
+                logger.Error("ws replay error", "error", err)
+                return nil
+            }
+        }
+
+        // per-connection token bucket so one scanner can't flood the DB
+        limiter := rate.NewLimiter(rate.Limit(wsConfig.RateLimit), wsConfig.RateBurst)
+        rateLimited := 0
+        defer func() {
+            if rateLimited > 0 {
+                logger.Warn("ws connection closed after rate-limited messages", "remote_ip", c.RealIP(), "rate_limited", rateLimited)
+            }
+        }()
 
         for {
             _, msg, err := ws.ReadMessage()
             if err != nil {
-                log.Println("ws read error:", err)
+                logger.Info("ws read error", "error", err)
                 break
             }
 
+            if !limiter.Allow() {
+                rateLimited++
+                if rateLimited > wsConfig.MaxRateLimited {
+                    logger.Warn("ws connection exceeded rate-limited messages; closing", "remote_ip", c.RealIP(), "max", wsConfig.MaxRateLimited)
+                    ws.WriteControl(
+                        websocket.CloseMessage,
+                        websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "rate limit exceeded"),
+                        time.Now().Add(time.Second),
+                    )
+                    break
+                }
+                if err := ws.WriteJSON(map[string]string{"status": "rate_limited"}); err != nil {
+                    logger.Error("ws write error", "error", err)
+                    break
+                }
+                continue
+            }
+
             var req PlateCheckRequest
             if err := json.Unmarshal(msg, &req); err != nil {
-                log.Println("json unmarshal error:", err)
+                logger.Warn("json unmarshal error", "error", err)
                 ws.WriteJSON(PlateCheckResponse{Status: "bad_request"})
                 continue
             }
 
-            log.Printf("[DEBUG] Received request: %+v", req)
+            logger.Debug("received request", "plate", req.Plate, "qr", req.QR != "")
+
+            // signed QR codes are verified before touching the database
+            if req.QR != "" {
+                payload, err := plate.VerifyQRData(req.QR, secret)
+                if err != nil {
+                    logger.Warn("qr verification error", "error", err)
+                    ws.WriteJSON(PlateCheckResponse{Status: "invalid_signature"})
+                    continue
+                }
+                req.Plate = payload.PlateNumber
+            }
 
             // 1) Plate lookup
+            req.Plate = normalizePlate(req.Plate)
             rec, err := plateRepo.GetByPlateNumber(c.Request().Context(), req.Plate)
+            var candidates []MVFileCandidate
+            if err == nil && rec == nil && vehicleRepo != nil {
+                // scanners also accept MV file numbers, often typed loosely
+                rec, candidates, err = lookupByMVFile(c.Request().Context(), vehicleRepo, plateRepo, req.Plate, looseMVFile)
+            }
             validity := "error"
             if err != nil {
-                log.Println("db lookup error:", err)
+                logger.Error("db lookup error", "error", err)
+            } else if len(candidates) > 0 {
+                validity = "ambiguous"
             } else if rec == nil {
                 validity = "not_found"
             } else if rec.PLATE_EXPIRATION_DATE.Before(time.Now()) {
+                // go by the date, not the status column: the cleanup job
+                // only marks plates Expired once an hour
                 validity = "expired"
             } else {
                 validity = "valid"
@@ -92,18 +394,29 @@ func ScannerWS(
 
             var details *DetailPack
             if rec != nil {
-                // fetch related details
-                regForm, _ := regFormRepo.GetByVehicleID(c.Request().Context(), rec.VEHICLE_ID)
-                plates, _ := plateRepo.GetPlatesByVehicleID(c.Request().Context(), rec.VEHICLE_ID)
-                var usr *models.User
-                if regForm != nil {
-                    u, _ := userRepo.GetByLTOClientID(regForm.LTOClientID)
-                    usr = &u
+                var cached *DetailPack
+                ok := false
+                if cache != nil {
+                    cached, ok = cache.Get(rec.VEHICLE_ID)
+                }
+                if ok && validity == "valid" {
+                    details = cached
+                } else {
+                    details = fetchDetails(c.Request().Context(), logger, rec.VEHICLE_ID, plateRepo, vehicleRepo, regFormRepo, userRepo, inspectionRepo, insuranceLookup)
+                    // expired plates are looked up fresh every time so a renewal shows
+                    // at once, and a failed insurance lookup is retried on the next scan
+                    if cache != nil && validity == "valid" && !details.insuranceUnavailable() {
+                        cache.Add(rec.VEHICLE_ID, details)
+                    }
                 }
-                details = &DetailPack{RegistrationForm: regForm, Plates: plates, User: usr}
             }
 
-            resp := PlateCheckResponse{Plate: req.Plate, Status: validity, Details: details}
+            resp := PlateCheckResponse{Plate: req.Plate, Status: validity, Details: details, Candidates: candidates}
+            // legacy plates have no check digit, so only flag ones that carry one
+            if plate.HasChecksum(req.Plate) && !plate.VerifyChecksum(req.Plate) {
+                logger.Warn("plate check digit mismatch", "plate", req.Plate)
+                resp.ChecksumMismatch = true
+            }
 
             // 2) Log scan event if repo set and details present
             if scanLogRepo != nil && rec != nil && details != nil && details.RegistrationForm != nil {
@@ -111,24 +424,189 @@ func ScannerWS(
                 registrationID := details.RegistrationForm.RegistrationFormID
                 vehicleID := rec.VEHICLE_ID
                 ltoClientID := details.RegistrationForm.LTOClientID
-                log.Printf("[DEBUG] Extracted IDs -> plate_id=%s, registration_id=%s, vehicle_id=%s, lto_client_id=%s", plateID, registrationID, vehicleID, ltoClientID)
                 entry := &models.ScanLog{PlateID: plateID, RegistrationID: registrationID, LTOClientID: ltoClientID, ScannedAt: time.Now()}
-                log.Printf("[DEBUG] Inserting scan_log entry: %+v", entry)
+                entry.Latitude, entry.Longitude = req.location()
+                if req.DeviceID != "" {
+                    entry.ScannerDeviceID = &req.DeviceID
+                }
+                entry.ScannerIP = scannerIP
+                if claims != nil {
+                    entry.ScannedBy = &claims.Subject
+                }
                 if err := scanLogRepo.Create(c.Request().Context(), entry); err != nil {
-                    log.Printf("[DEBUG] scan_log insert FAILED: %v", err)
+                    logger.Error("scan_log insert failed", "error", err, "plate_id", plateID, "vehicle_id", vehicleID)
                 } else {
-                    log.Printf("[DEBUG] scan_log insert SUCCESS")
+                    logger.Debug("scan_log inserted", "plate_id", plateID, "registration_id", registrationID, "lto_client_id", ltoClientID)
+                    if hub != nil {
+                        if event, err := json.Marshal(entry); err == nil {
+                            hub.Broadcast(details.RegistrationForm.Region, event)
+                        }
+                    }
+                    if webhooks != nil {
+                        if body, err := json.Marshal(webhookPayload(resp)); err == nil {
+                            webhooks.Dispatch(logger, scanEvents(resp.Status), body)
+                        }
+                    }
                 }
             } else {
-                log.Println("[DEBUG] scanLogRepo missing or details incomplete; skipping scan_log")
+                logger.Debug("scanLogRepo missing or details incomplete; skipping scan_log")
             }
 
-            log.Printf("[DEBUG] Sending WS response: %+v", resp)
-            if err := ws.WriteJSON(resp); err != nil {
-                log.Println("ws write error:", err)
+            logger.Debug("sending ws response", "plate", resp.Plate, "status", resp.Status)
+            payload, err := json.Marshal(resp)
+            if err != nil {
+                logger.Error("ws response marshal error", "error", err)
                 break
             }
+            if sizes != nil {
+                sizes.record(payload, wsConfig.CompressionLevel)
+            }
+            if err := ws.WriteMessage(websocket.TextMessage, payload); err != nil {
+                logger.Error("ws write error", "error", err)
+                break
+            }
+        }
+        return nil
+    }
+}
+
+// fetchDetails gathers the related records shown alongside a plate check
+func fetchDetails(
+    ctx            context.Context,
+    logger         *slog.Logger,
+    vehicleID      string,
+    plateRepo      repository.PlateRepository,
+    vehicleRepo    repository.VehicleRepository,
+    regFormRepo    repository.RegistrationFormRepository,
+    userRepo       repository.UserRepository,
+    inspectionRepo repository.VehicleInspectionRepository,
+    insuranceLookup insurance.InsuranceLookup,
+) *DetailPack {
+    // nil without an error just means the vehicle was never registered
+    regForm, err := regFormRepo.GetByVehicleID(ctx, vehicleID)
+    if err != nil {
+        logger.Error("registration form lookup error", "error", err)
+    }
+    plates, _ := plateRepo.GetPlatesByVehicleID(ctx, vehicleID)
+    var usr *models.MaskedUser
+    if regForm != nil {
+        u, _ := userRepo.GetByLTOClientID(regForm.LTOClientID)
+        masked := u.ToMasked()
+        usr = &masked
+    }
+    inspection, err := inspectionRepo.GetLatest(ctx, vehicleID)
+    if err != nil {
+        logger.Error("inspection lookup error", "error", err)
+    }
+    return &DetailPack{
+        RegistrationForm:  regForm,
+        Plates:            plates,
+        User:              usr,
+        LatestInspection:  inspection,
+        InspectionOverdue: inspection != nil && inspection.NextInspectionDue.Before(time.Now()),
+        InsuranceStatus:   lookupInsurance(ctx, logger, vehicleID, vehicleRepo, insuranceLookup),
+    }
+}
+
+// lookupInsurance asks the insurer about the vehicle, giving up after
+// insuranceTimeout. Any failure is reported as "unavailable" so the scan
+// still goes through; nil means insurance isn't checked at all.
+func lookupInsurance(
+    ctx             context.Context,
+    logger          *slog.Logger,
+    vehicleID       string,
+    vehicleRepo     repository.VehicleRepository,
+    insuranceLookup insurance.InsuranceLookup,
+) *models.InsuranceStatus {
+    if insuranceLookup == nil || vehicleRepo == nil {
+        return nil
+    }
+    unavailable := &models.InsuranceStatus{Status: models.InsuranceUnavailable}
+    v, err := vehicleRepo.GetVehicleByID(ctx, vehicleID)
+    if err != nil || v == nil {
+        logger.Error("insurance vehicle lookup error", "error", err)
+        return unavailable
+    }
+    ctx, cancel := context.WithTimeout(ctx, insuranceTimeout)
+    defer cancel()
+    status, err := insuranceLookup.GetStatus(ctx, v.MV_FILE_NUMBER)
+    if errors.Is(err, context.DeadlineExceeded) {
+        logger.Warn("insurance lookup timed out", "timeout", insuranceTimeout)
+        return unavailable
+    }
+    if err != nil {
+        logger.Error("insurance lookup error", "error", err)
+        return unavailable
+    }
+    return &status
+}
+
+// insuranceUnavailable reports whether the insurance lookup failed
+func (d *DetailPack) insuranceUnavailable() bool {
+    return d.InsuranceStatus != nil && d.InsuranceStatus.Status == models.InsuranceUnavailable
+}
+
+// lookupByMVFile resolves query as an MV file number: an exact match first,
+// then, when loose is set, a partial one. A single vehicle yields its most
+// recently issued plate; several yield candidates instead.
+func lookupByMVFile(
+    ctx         context.Context,
+    vehicleRepo repository.VehicleRepository,
+    plateRepo   repository.PlateRepository,
+    query       string,
+    loose       bool,
+) (*models.Plate, []MVFileCandidate, error) {
+    v, err := vehicleRepo.GetByMVFileNumber(ctx, query)
+    if err != nil {
+        return nil, nil, err
+    }
+    if v == nil && !loose {
+        return nil, nil, nil
+    }
+    if v == nil {
+        matches, err := vehicleRepo.SearchByMVFileNumber(ctx, query)
+        if err != nil {
+            return nil, nil, err
+        }
+        switch len(matches) {
+        case 0:
+            return nil, nil, nil
+        case 1:
+            v = matches[0]
+        default:
+            candidates := make([]MVFileCandidate, 0, len(matches))
+            for _, m := range matches {
+                candidates = append(candidates, MVFileCandidate{
+                    MVFileNumber: m.MV_FILE_NUMBER,
+                    Make:         m.VEHICLE_MAKE,
+                    Series:       m.VEHICLE_SERIES,
+                    YearModel:    m.YEAR_MODEL,
+                })
+            }
+            return nil, candidates, nil
         }
+    }
+    // plates come back newest first
+    plates, err := plateRepo.GetPlatesByVehicleID(ctx, v.VEHICLE_ID)
+    if err != nil || len(plates) == 0 {
+        return nil, nil, err
+    }
+    return &plates[0], nil, nil
+}
+
+// replayScans streams the client's scans in [since, now] as individual responses
+func replayScans(c echo.Context, logger *slog.Logger, ws *websocket.Conn, repo repository.ScanLogRepository, clientID string, since time.Time) error {
+    entries, err := repo.GetByDateRange(c.Request().Context(), clientID, since, time.Now(), maxReplayEntries)
+    if err != nil {
+        // a failed replay shouldn't keep the scanner from working
+        logger.Error("scan_log replay lookup error", "error", err)
         return nil
     }
+    logger.Debug("replaying scans", "count", len(entries), "lto_client_id", clientID, "since", since.Format(time.RFC3339))
+    for _, e := range entries {
+        if err := ws.WriteJSON(PlateCheckResponse{Plate: e.PlateNumber, Status: "replay"}); err != nil {
+            return err
+        }
+    }
+    return nil
 }
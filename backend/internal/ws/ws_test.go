@@ -0,0 +1,99 @@
+package ws
+
+import (
+    "context"
+    "encoding/json"
+    "regexp"
+    "testing"
+    "time"
+
+    "github.com/DATA-DOG/go-sqlmock"
+    "github.com/jmoiron/sqlx"
+
+    "smartplate-api/internal/database"
+    "smartplate-api/internal/repository"
+)
+
+func TestPlateLookupIgnoresCaseAndPadding(t *testing.T) {
+    tests := []struct {
+        name  string
+        plate string
+        want  string // what reaches the query
+    }{
+        {"upper case", "ABC 1234", "ABC 1234"},
+        {"lower case", "abc 1234", "abc 1234"},
+        {"padded", "  ABC 1234  ", "ABC 1234"},
+        {"tabs and newline", "\tAbC 1234\n", "AbC 1234"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            body, _ := json.Marshal(map[string]string{"plate": tt.plate})
+            var req PlateCheckRequest
+            if err := json.Unmarshal(body, &req); err != nil {
+                t.Fatal(err)
+            }
+            if req.Plate != tt.want {
+                t.Fatalf("unmarshalled plate = %q, want %q", req.Plate, tt.want)
+            }
+
+            db, mock, err := sqlmock.New()
+            if err != nil {
+                t.Fatal(err)
+            }
+            defer db.Close()
+            sdb := sqlx.NewDb(db, "postgres")
+            issued := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+            mock.ExpectQuery(regexp.QuoteMeta(`WHERE UPPER(plate_number) = UPPER($1)`)).
+                WithArgs(tt.want).
+                WillReturnRows(sqlmock.NewRows([]string{
+                    "plate_id", "vehicle_id", "plate_number", "plate_type",
+                    "plate_issue_date", "plate_expiration_date", "status",
+                }).AddRow("p1", "v1", "ABC 1234", "Private", issued, issued.AddDate(3, 0, 0), "Active"))
+
+            repo := repository.NewPlateRepository(database.ReadWriteDB{Write: sdb, Read: sdb})
+            p, err := repo.GetByPlateNumber(context.Background(), req.Plate)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if p == nil || p.PLATE_NUMBER != "ABC 1234" {
+                t.Fatalf("lookup of %q found %+v", tt.plate, p)
+            }
+            if err := mock.ExpectationsWereMet(); err != nil {
+                t.Fatal(err)
+            }
+        })
+    }
+}
+
+func TestScannerWSPlateLookupIgnoresCaseAndPadding(t *testing.T) {
+    tests := []struct {
+        name  string
+        plate string
+        want  string // what reaches the repository and comes back
+    }{
+        {"upper case", "ABC 1234", "ABC 1234"},
+        {"lower case", "abc 1234", "abc 1234"},
+        {"padded", "  ABC 1234  ", "ABC 1234"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f := newScannerFixture(t)
+            conn, _ := f.dial(t, false)
+
+            resp := check(t, conn, tt.plate)
+            if resp.Plate != tt.want || resp.Status != "valid" {
+                t.Fatalf("got plate %q status %q, want %q valid", resp.Plate, resp.Status, tt.want)
+            }
+            if resp.Details == nil || resp.Details.RegistrationForm == nil || resp.Details.RegistrationForm.VehicleID != "v1" {
+                t.Fatalf("details = %+v, want vehicle v1's", resp.Details)
+            }
+            f.plates.mu.Lock()
+            defer f.plates.mu.Unlock()
+            if len(f.plates.asked) != 1 || f.plates.asked[0] != tt.want {
+                t.Fatalf("repository asked for %q, want just %q", f.plates.asked, tt.want)
+            }
+        })
+    }
+}